@@ -4,6 +4,9 @@ package afmt
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/MatusOllah/resona/freq"
@@ -19,6 +22,11 @@ func DurationToNumFrames(sampleRate freq.Frequency, d time.Duration) int {
 	return int(d * time.Duration(sampleRate.Hertz()) / time.Second)
 }
 
+// IsStandardRate reports whether the sample rate is one of [freq.StandardRates].
+func IsStandardRate(sampleRate freq.Frequency) bool {
+	return freq.NearestStandardRate(sampleRate) == sampleRate
+}
+
 // Format represents an abstract audio stream format.
 type Format struct {
 	// SampleRate is the sample rate.
@@ -29,6 +37,28 @@ type Format struct {
 	NumChannels int
 }
 
+// BitRate returns the number of bits per second of uncompressed audio in the format,
+// that is the sample rate × the number of channels × the bit depth of sf.
+func (f Format) BitRate(sf SampleFormat) int {
+	return int(math.Round(f.SampleRate.Hertz() * float64(f.NumChannels*sf.BitDepth)))
+}
+
+// ByteRate returns the number of bytes per second of uncompressed audio in the format.
+// Unlike BitRate / 8, it counts whole bytes per sample, as they are stored (see [SampleFormat.BytesPerSample]),
+// so a 12-bit sample takes 2 bytes.
+func (f Format) ByteRate(sf SampleFormat) int {
+	return int(math.Round(f.SampleRate.Hertz() * float64(sf.BytesPerFrame(f.NumChannels))))
+}
+
+// FormatBitRate formats a number of bits per second human-readably, rounded to whole kilobits
+// per second, such as "1411 kbps" for CD audio. Bitrates below 1 kbps are formatted in bits per second.
+func FormatBitRate(bps int) string {
+	if bps < 1000 {
+		return strconv.Itoa(bps) + " bps"
+	}
+	return strconv.FormatInt(int64(math.Round(float64(bps)/1000)), 10) + " kbps"
+}
+
 // Formatter is an interface for types that can report their audio format.
 type Formatter interface {
 	// Format returns the audio stream format.
@@ -123,6 +153,60 @@ func (f SampleFormat) String() string {
 	return s
 }
 
+// MarshalText implements [encoding.TextMarshaler], so that sample formats are marshaled as their string form, such as "int16le".
+func (f SampleFormat) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler] using [ParseSampleFormat].
+func (f *SampleFormat) UnmarshalText(text []byte) error {
+	parsed, err := ParseSampleFormat(string(text))
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// ParseSampleFormat parses a sample format in the syntax of [SampleFormat.String], such as "int16le", "uint8" or "float32be",
+// or in the shorter syntax of FFmpeg, such as "s16le", "u8" or "f32be". Without "le" or "be", the endianness is nil.
+func ParseSampleFormat(s string) (SampleFormat, error) {
+	var f SampleFormat
+	rest := strings.ToLower(s)
+
+	switch {
+	case strings.HasPrefix(rest, "uint"):
+		f.Encoding, rest = SampleEncodingUint, rest[4:]
+	case strings.HasPrefix(rest, "int"):
+		f.Encoding, rest = SampleEncodingInt, rest[3:]
+	case strings.HasPrefix(rest, "float"):
+		f.Encoding, rest = SampleEncodingFloat, rest[5:]
+	case strings.HasPrefix(rest, "u"):
+		f.Encoding, rest = SampleEncodingUint, rest[1:]
+	case strings.HasPrefix(rest, "s"):
+		f.Encoding, rest = SampleEncodingInt, rest[1:]
+	case strings.HasPrefix(rest, "f"):
+		f.Encoding, rest = SampleEncodingFloat, rest[1:]
+	default:
+		return SampleFormat{}, fmt.Errorf("afmt: invalid sample format %q: unknown encoding", s)
+	}
+
+	if strings.HasSuffix(rest, "le") {
+		f.Endian, rest = binary.LittleEndian, rest[:len(rest)-2]
+	} else if strings.HasSuffix(rest, "be") {
+		f.Endian, rest = binary.BigEndian, rest[:len(rest)-2]
+	}
+
+	if rest != "" {
+		depth, err := strconv.Atoi(rest)
+		if err != nil || depth <= 0 {
+			return SampleFormat{}, fmt.Errorf("afmt: invalid sample format %q: invalid bit depth", s)
+		}
+		f.BitDepth = depth
+	}
+	return f, nil
+}
+
 // SampleFormatter is an interface for types that can report their sample format.
 type SampleFormatter interface {
 	// SampleFormat returns the sample format.
@@ -2,6 +2,7 @@ package afmt_test
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -57,6 +58,71 @@ func TestDurationToNumFrames(t *testing.T) {
 	}
 }
 
+func TestIsStandardRate(t *testing.T) {
+	for _, rate := range freq.StandardRates() {
+		if !afmt.IsStandardRate(rate) {
+			t.Errorf("expected %v to be standard", rate)
+		}
+	}
+	for _, rate := range []freq.Frequency{0, -freq.SampleRate48k, 12 * freq.KiloHertz, 44101 * freq.Hertz, freq.SampleRate48k + 1, 384 * freq.KiloHertz} {
+		if afmt.IsStandardRate(rate) {
+			t.Errorf("expected %v not to be standard", rate)
+		}
+	}
+}
+
+func TestFormat_BitRate(t *testing.T) {
+	int16LE := afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}
+	int24LE := afmt.SampleFormat{BitDepth: 24, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}
+	int12LE := afmt.SampleFormat{BitDepth: 12, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}
+
+	tests := []struct {
+		name      string
+		format    afmt.Format
+		sampleFmt afmt.SampleFormat
+		bitRate   int
+		byteRate  int
+	}{
+		{"CD", afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 2}, int16LE, 1411200, 176400},
+		{"24BitMono", afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}, int24LE, 1152000, 144000},
+		{"24BitThreeChannels", afmt.Format{SampleRate: 22050 * freq.Hertz, NumChannels: 3}, int24LE, 1587600, 198450},
+		// 12-bit samples are stored in 2 bytes each, so the byte rate is not the bit rate / 8
+		{"12BitThreeChannels", afmt.Format{SampleRate: 11025 * freq.Hertz, NumChannels: 3}, int12LE, 396900, 66150},
+		{"FractionalRate", afmt.Format{SampleRate: 44100*freq.Hertz + freq.Hertz/2, NumChannels: 1}, int24LE, 1058412, 132302},
+		{"ZeroChannels", afmt.Format{SampleRate: 44100 * freq.Hertz}, int16LE, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.BitRate(tt.sampleFmt); got != tt.bitRate {
+				t.Errorf("BitRate(%v) = %d; want %d", tt.sampleFmt, got, tt.bitRate)
+			}
+			if got := tt.format.ByteRate(tt.sampleFmt); got != tt.byteRate {
+				t.Errorf("ByteRate(%v) = %d; want %d", tt.sampleFmt, got, tt.byteRate)
+			}
+		})
+	}
+}
+
+func TestFormatBitRate(t *testing.T) {
+	tests := []struct {
+		bps  int
+		want string
+	}{
+		{1411200, "1411 kbps"},
+		{128000, "128 kbps"},
+		{264600, "265 kbps"}, // rounded rather than truncated
+		{999, "999 bps"},
+		{0, "0 bps"},
+	}
+
+	for _, tt := range tests {
+		if got := afmt.FormatBitRate(tt.bps); got != tt.want {
+			t.Errorf("FormatBitRate(%d) = %q; want %q", tt.bps, got, tt.want)
+		}
+	}
+}
+
 func TestSampleEncoding_IsSigned(t *testing.T) {
 	tests := []struct {
 		name string
@@ -194,3 +260,83 @@ func TestSampleFormat_String(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSampleFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want afmt.SampleFormat
+	}{
+		{"int16le", afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}},
+		{"int24be", afmt.SampleFormat{BitDepth: 24, Encoding: afmt.SampleEncodingInt, Endian: binary.BigEndian}},
+		{"uint8", afmt.SampleFormat{BitDepth: 8, Encoding: afmt.SampleEncodingUint}},
+		{"float32le", afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingFloat, Endian: binary.LittleEndian}},
+		{"Float64BE", afmt.SampleFormat{BitDepth: 64, Encoding: afmt.SampleEncodingFloat, Endian: binary.BigEndian}},
+		{"int", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt}},
+		{"s16le", afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}},
+		{"u8", afmt.SampleFormat{BitDepth: 8, Encoding: afmt.SampleEncodingUint}},
+		{"f32be", afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingFloat, Endian: binary.BigEndian}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := afmt.ParseSampleFormat(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSampleFormat(%q) = %v; want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	for _, in := range []string{"", "pcm16", "int16xe", "int-8", "float0le", "le"} {
+		if _, err := afmt.ParseSampleFormat(in); err == nil {
+			t.Errorf("ParseSampleFormat(%q): expected an error", in)
+		}
+	}
+}
+
+func TestParseSampleFormatRoundTrip(t *testing.T) {
+	for _, f := range []afmt.SampleFormat{
+		{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian},
+		{BitDepth: 8, Encoding: afmt.SampleEncodingUint},
+		{BitDepth: 64, Encoding: afmt.SampleEncodingFloat, Endian: binary.BigEndian},
+	} {
+		got, err := afmt.ParseSampleFormat(f.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != f {
+			t.Errorf("ParseSampleFormat(%q) = %v; want %v", f.String(), got, f)
+		}
+	}
+}
+
+func TestSampleFormat_JSON(t *testing.T) {
+	type doc struct {
+		Format       afmt.Format       `json:"format"`
+		SampleFormat afmt.SampleFormat `json:"sample_format"`
+	}
+	in := doc{
+		Format:       afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2},
+		SampleFormat: afmt.SampleFormat{BitDepth: 24, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian},
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"format":{"SampleRate":48000000000000,"NumChannels":2},"sample_format":"int24le"}`; string(b) != want {
+		t.Errorf("Marshal = %s; want %s", b, want)
+	}
+
+	var out doc
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("Unmarshal = %+v; want %+v", out, in)
+	}
+
+	if err := json.Unmarshal([]byte(`{"sample_format":"pcm"}`), &out); err == nil {
+		t.Error("expected an error unmarshaling an invalid sample format")
+	}
+}
@@ -0,0 +1,55 @@
+package audio
+
+import (
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+)
+
+// MapChannels converts the interleaved samples of r from one number of channels to another.
+// Mono is spread to every channel with an [Upmixer] and every channel is averaged to mono with a [Downmixer];
+// otherwise, a [ChannelMapper] keeps the first channels in order and drops the rest or adds silent ones.
+func MapChannels(r aio.SampleReader, from, to int) aio.SampleReader {
+	switch {
+	case from == to:
+		return r
+	case from == 1:
+		return NewUpmixer(r, to)
+	case to == 1:
+		return NewDownmixer(r, from)
+	default:
+		return NewChannelMapper(r, from, to)
+	}
+}
+
+// ChannelMapper keeps the first channels of a reader, dropping the rest or adding silent ones.
+type ChannelMapper struct {
+	r        aio.SampleReader
+	from, to int
+	buf      []float32
+}
+
+// NewChannelMapper creates a new [ChannelMapper] that converts r from one number of channels to another.
+func NewChannelMapper(r aio.SampleReader, from, to int) *ChannelMapper {
+	return &ChannelMapper{r: r, from: from, to: to}
+}
+
+func (m *ChannelMapper) ReadSamples(p []float32) (int, error) {
+	frames := len(p) / m.to
+	if frames == 0 {
+		return 0, io.ErrShortBuffer
+	}
+	if cap(m.buf) < frames*m.from {
+		m.buf = make([]float32, frames*m.from)
+	}
+	buf := m.buf[:frames*m.from]
+
+	n, err := m.r.ReadSamples(buf)
+	n /= m.from
+	for i := range n {
+		out := p[i*m.to : (i+1)*m.to]
+		k := copy(out, buf[i*m.from:(i+1)*m.from])
+		clear(out[k:])
+	}
+	return n * m.to, err
+}
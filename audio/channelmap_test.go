@@ -0,0 +1,36 @@
+package audio_test
+
+import (
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+func TestMapChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       []float32
+		from, to int
+		want     []float32
+	}{
+		{"Same", []float32{0.1, 0.2}, 2, 2, []float32{0.1, 0.2}},
+		{"MonoToStereo", []float32{0.1, 0.2}, 1, 2, []float32{0.1, 0.1, 0.2, 0.2}},
+		{"StereoToMono", []float32{0.1, 0.3, 0.2, 0.4}, 2, 1, []float32{0.2, 0.3}},
+		{"StereoToQuad", []float32{0.1, 0.2, 0.3, 0.4}, 2, 4, []float32{0.1, 0.2, 0, 0, 0.3, 0.4, 0, 0}},
+		{"QuadToStereo", []float32{0.1, 0.2, 0.9, 0.9, 0.3, 0.4, 0.9, 0.9}, 4, 2, []float32{0.1, 0.2, 0.3, 0.4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := audio.MapChannels(audio.NewReader(tt.in), tt.from, tt.to)
+			got := make([]float32, len(tt.want))
+			if _, err := aio.ReadFull(r, got); err != nil {
+				t.Fatal(err)
+			}
+			if !testutil.EqualSliceWithinTolerance(got, tt.want, 1e-6) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
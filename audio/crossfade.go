@@ -0,0 +1,196 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+)
+
+// CrossfadeCurve represents the law by which the gains of two signals change during a crossfade.
+type CrossfadeCurve int
+
+const (
+	// CrossfadeLinear fades the gains linearly. The gains always sum to 1,
+	// which keeps the level constant for identical (correlated) signals,
+	// but dips by 3 dB in the middle for unrelated (uncorrelated) signals.
+	CrossfadeLinear CrossfadeCurve = iota
+
+	// CrossfadeEqualPower fades along a quarter of a sine and cosine. The squared gains always sum to 1,
+	// which keeps the power constant for uncorrelated signals,
+	// but bumps the level by 3 dB in the middle for correlated signals.
+	CrossfadeEqualPower
+
+	// CrossfadeSCurve is like [CrossfadeLinear], but starts and ends smoothly along a raised cosine.
+	CrossfadeSCurve
+)
+
+// String returns the name of the curve.
+func (c CrossfadeCurve) String() string {
+	switch c {
+	case CrossfadeLinear:
+		return "Linear"
+	case CrossfadeEqualPower:
+		return "EqualPower"
+	case CrossfadeSCurve:
+		return "SCurve"
+	default:
+		return fmt.Sprintf("CrossfadeCurve(%d)", int(c))
+	}
+}
+
+// Gains returns the gains of the fading out and fading in signals at the position t in [0, 1] of the crossfade.
+func (c CrossfadeCurve) Gains(t float64) (out, in float64) {
+	t = max(0, min(1, t))
+	switch c {
+	case CrossfadeEqualPower:
+		in, out = math.Sincos(t * math.Pi / 2)
+		return out, in
+	case CrossfadeSCurve:
+		in = 0.5 - 0.5*math.Cos(t*math.Pi)
+		return 1 - in, in
+	default:
+		return 1 - t, t
+	}
+}
+
+// gains returns the gains of the i-th of n frames of a crossfade, sampled in the middle of the frame.
+func (c CrossfadeCurve) gains(i, n int) (out, in float32) {
+	o, x := c.Gains((float64(i) + 0.5) / float64(n))
+	return float32(o), float32(x)
+}
+
+// CrossfadeSamples crossfades the mono signal a into b over their whole length and writes the result to dst.
+// a and b must be the same length and dst must be at least as long. dst may be the same slice as a or b.
+func CrossfadeSamples(dst, a, b []float32, curve CrossfadeCurve) {
+	if len(a) != len(b) {
+		panic("audio: crossfaded slices differ in length")
+	}
+	dst = dst[:len(a)]
+	for i := range dst {
+		out, in := curve.gains(i, len(a))
+		dst[i] = a[i]*out + b[i]*in
+	}
+}
+
+type crossfader struct {
+	a, b        aio.SampleReader
+	curve       CrossfadeCurve
+	numChannels int
+	fadeLen     int // length of the fade in samples
+	err         error
+
+	tail []float32 // the last fadeLen samples read from a, held back for the fade
+	buf  []float32
+
+	fading     bool
+	fadePos    int // samples of the fade written
+	bDone      bool
+	fadeFrames int // length of the fade in frames, shorter than requested if a is
+}
+
+// Crossfade returns an aio.SampleReader that plays a, crossfades to b over the duration d and then plays the rest of b.
+// Both readers must have the given format.
+//
+// The end of a is found by reading ahead the length of the fade, so a does not need to have a known length.
+// If a is shorter than d, the fade spans all of a. If b ends during the fade, a fades out over silence.
+func Crossfade(a, b aio.SampleReader, format afmt.Format, d time.Duration, curve CrossfadeCurve) aio.SampleReader {
+	c := &crossfader{
+		a:           a,
+		b:           b,
+		curve:       curve,
+		numChannels: format.NumChannels,
+	}
+	switch {
+	case format.NumChannels <= 0:
+		c.err = fmt.Errorf("audio: invalid number of channels: %d", format.NumChannels)
+	case d < 0:
+		c.err = fmt.Errorf("audio: invalid crossfade duration: %v", d)
+	case curve < CrossfadeLinear || curve > CrossfadeSCurve:
+		c.err = fmt.Errorf("audio: invalid crossfade curve: %v", curve)
+	}
+	c.fadeLen = afmt.DurationToNumFrames(format.SampleRate, d) * format.NumChannels
+	return c
+}
+
+func (c *crossfader) ReadSamples(p []float32) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n := 0
+	for n < len(p) && c.err == nil {
+		switch {
+		case !c.fading:
+			n += c.readA(p[n:])
+		case c.fadePos < len(c.tail):
+			n += c.fade(p[n:])
+		default:
+			m, err := c.b.ReadSamples(p[n:])
+			n += m
+			c.err = err
+		}
+	}
+
+	if n == 0 && c.err != nil {
+		return 0, c.err
+	}
+	return n, nil
+}
+
+// readA reads from a into p, holding back the last fadeLen samples, and returns the number of samples written to p.
+func (c *crossfader) readA(p []float32) int {
+	if cap(c.buf) < len(p) {
+		c.buf = make([]float32, len(p))
+	}
+	m, err := c.a.ReadSamples(c.buf[:len(p)])
+	c.tail = append(c.tail, c.buf[:m]...)
+
+	n := 0
+	if len(c.tail) > c.fadeLen {
+		n = copy(p, c.tail[:len(c.tail)-c.fadeLen])
+		c.tail = c.tail[:copy(c.tail, c.tail[n:])]
+	}
+
+	if err == io.EOF {
+		// whatever is held back is the end of a
+		c.fading = true
+		c.tail = c.tail[:len(c.tail)/c.numChannels*c.numChannels]
+		c.fadeFrames = len(c.tail) / c.numChannels
+	} else if err != nil {
+		c.err = err
+	}
+	return n
+}
+
+// fade mixes the end of a with the start of b into p and returns the number of samples written to p.
+func (c *crossfader) fade(p []float32) int {
+	p = p[:min(len(p), len(c.tail)-c.fadePos)]
+
+	m := 0
+	if !c.bDone {
+		var err error
+		m, err = aio.ReadFull(c.b, p)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			c.bDone = true
+		} else if err != nil {
+			c.err = err
+			return 0
+		}
+	}
+	clear(p[m:])
+
+	for i := range p {
+		out, in := c.curve.gains((c.fadePos+i)/c.numChannels, c.fadeFrames)
+		p[i] = c.tail[c.fadePos+i]*out + p[i]*in
+	}
+	c.fadePos += len(p)
+
+	if c.fadePos == len(c.tail) && c.bDone {
+		c.err = io.EOF
+	}
+	return len(p)
+}
@@ -0,0 +1,186 @@
+package audio_test
+
+import (
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+var crossfadeFormat = afmt.Format{SampleRate: 1 * freq.KiloHertz, NumChannels: 2}
+
+func constant(v float32, numFrames int) []float32 {
+	p := make([]float32, numFrames*crossfadeFormat.NumChannels)
+	for i := range p {
+		p[i] = v
+	}
+	return p
+}
+
+func noise(seed uint64, n int) []float32 {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	p := make([]float32, n)
+	for i := range p {
+		p[i] = float32(rng.NormFloat64() * 0.1)
+	}
+	return p
+}
+
+// readCrossfade reads a crossfade in chunks of an odd size.
+func readCrossfade(t *testing.T, a, b []float32, d time.Duration, curve audio.CrossfadeCurve) []float32 {
+	t.Helper()
+
+	r := audio.Crossfade(audio.NewBuffer(a), audio.NewBuffer(b), crossfadeFormat, d, curve)
+	var got []float32
+	buf := make([]float32, 7)
+	for {
+		n, err := r.ReadSamples(buf)
+		got = append(got, buf[:n]...)
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCrossfade(t *testing.T) {
+	tests := []struct {
+		name       string
+		lenA, lenB int // in frames
+		fadeFrames int // actual length of the fade
+	}{
+		{"Normal", 1000, 1000, 100},
+		{"AShorterThanFade", 50, 1000, 50},
+		{"BEndsDuringFade", 1000, 30, 100},
+		{"BothShort", 20, 10, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := readCrossfade(t, constant(1, tt.lenA), constant(3, tt.lenB), 100*time.Millisecond, audio.CrossfadeLinear)
+
+			fadeStart := tt.lenA - tt.fadeFrames
+			wantLen := max(tt.lenA, fadeStart+tt.lenB)
+			if len(got) != wantLen*2 {
+				t.Fatalf("expected %d frames, got %d", wantLen, len(got)/2)
+			}
+
+			for i := range wantLen {
+				var want float32
+				switch {
+				case i < fadeStart:
+					want = 1
+				case i < tt.lenA:
+					out, in := audio.CrossfadeLinear.Gains((float64(i-fadeStart) + 0.5) / float64(tt.fadeFrames))
+					want = float32(out)
+					if i-fadeStart < tt.lenB {
+						want += float32(3 * in)
+					}
+				default:
+					want = 3
+				}
+				for ch := range 2 {
+					if s := got[2*i+ch]; !testutil.EqualWithinTolerance(s, want, 1e-6) {
+						t.Fatalf("frame %d, channel %d: expected %v, got %v", i, ch, want, s)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCrossfadeEqualPower(t *testing.T) {
+	const (
+		numFrames  = 4000
+		fadeFrames = 2000
+		fadeStart  = numFrames - fadeFrames
+	)
+	fade := afmt.NumFramesToDuration(crossfadeFormat.SampleRate, fadeFrames)
+
+	t.Run("Uncorrelated", func(t *testing.T) {
+		a, b := noise(1, 2*numFrames), noise(2, 2*numFrames)
+		got := readCrossfade(t, a, b, fade, audio.CrossfadeEqualPower)
+
+		// the power stays at that of the noise (0.01) throughout the fade
+		const window = 250 // frames
+		for start := fadeStart; start < numFrames; start += window {
+			var sum float64
+			for _, s := range got[2*start : 2*(start+window)] {
+				sum += float64(s) * float64(s)
+			}
+			if p := sum / (2 * window); p < 0.0085 || p > 0.0115 {
+				t.Errorf("frame %d: expected a power of about 0.01, got %v", start, p)
+			}
+		}
+	})
+
+	t.Run("Correlated", func(t *testing.T) {
+		// a continues into b, so a linear crossfade leaves it unchanged and equal power bumps it by up to 3 dB
+		a := noise(1, 2*numFrames)
+		got := readCrossfade(t, a, slices.Clone(a[2*fadeStart:]), fade, audio.CrossfadeLinear)
+		if !testutil.EqualSliceWithinTolerance(got, a, 1e-6) {
+			t.Error("linear crossfade of a signal with itself changed the signal")
+		}
+
+		got = readCrossfade(t, a, slices.Clone(a[2*fadeStart:]), fade, audio.CrossfadeEqualPower)
+		for i := 2 * fadeStart; i < 2*numFrames; i++ {
+			out, in := audio.CrossfadeEqualPower.Gains((float64(i/2-fadeStart) + 0.5) / fadeFrames)
+			if want := a[i] * float32(out+in); !testutil.EqualWithinTolerance(got[i], want, 1e-6) {
+				t.Fatalf("sample %d: expected %v, got %v", i, want, got[i])
+			}
+		}
+		if out, in := audio.CrossfadeEqualPower.Gains(0.5); !testutil.EqualWithinTolerance(out+in, math.Sqrt2, 1e-12) {
+			t.Errorf("expected a gain of √2 in the middle, got %v", out+in)
+		}
+	})
+}
+
+func TestCrossfadeSamples(t *testing.T) {
+	for _, curve := range []audio.CrossfadeCurve{audio.CrossfadeLinear, audio.CrossfadeEqualPower, audio.CrossfadeSCurve} {
+		t.Run(curve.String(), func(t *testing.T) {
+			a, b := make([]float32, 100), make([]float32, 100)
+			for i := range a {
+				a[i], b[i] = 1, -1
+			}
+			dst := make([]float32, 100)
+			audio.CrossfadeSamples(dst, a, b, curve)
+
+			if dst[0] < 0.9 || dst[99] > -0.9 {
+				t.Errorf("expected a fade from 1 to -1, got %v to %v", dst[0], dst[99])
+			}
+			for i := 1; i < len(dst); i++ {
+				if dst[i] > dst[i-1] {
+					t.Fatalf("expected a monotonic fade, got %v after %v", dst[i], dst[i-1])
+				}
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for slices of different lengths")
+		}
+	}()
+	audio.CrossfadeSamples(make([]float32, 10), make([]float32, 10), make([]float32, 5), audio.CrossfadeLinear)
+}
+
+func TestCrossfadeInvalid(t *testing.T) {
+	for name, r := range map[string]aio.SampleReader{
+		"Channels": audio.Crossfade(audio.NewBuffer(nil), audio.NewBuffer(nil), afmt.Format{SampleRate: freq.KiloHertz}, time.Second, audio.CrossfadeLinear),
+		"Duration": audio.Crossfade(audio.NewBuffer(nil), audio.NewBuffer(nil), crossfadeFormat, -time.Second, audio.CrossfadeLinear),
+		"Curve":    audio.Crossfade(audio.NewBuffer(nil), audio.NewBuffer(nil), crossfadeFormat, time.Second, 42),
+	} {
+		if _, err := r.ReadSamples(make([]float32, 2)); err == nil || err == io.EOF {
+			t.Errorf("%s: expected error, got %v", name, err)
+		}
+	}
+}
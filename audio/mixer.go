@@ -2,6 +2,7 @@ package audio
 
 import (
 	"io"
+	"sync"
 
 	"github.com/MatusOllah/resona/aio"
 )
@@ -12,8 +13,11 @@ import (
 // Mixer will either output silence or drain when all SampleReaders have been drained.
 // By default, it will output silence.
 //
+// Mixer is safe for concurrent use, so readers can be added while it is being read, such as during playback.
+//
 // The zero value for Mixer is an empty mixer ready to use.
 type Mixer struct {
+	mu            sync.Mutex
 	readers       []aio.SampleReader
 	stopWhenEmpty bool
 }
@@ -32,21 +36,29 @@ func NewMixer(readers ...aio.SampleReader) *Mixer {
 // KeepAlive sets the [Mixer] whether to keep playing silence when all readers have drained (true),
 // or stop playing and return an [io.EOF] (false).
 func (m *Mixer) KeepAlive(KeepAlive bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.stopWhenEmpty = !KeepAlive
 }
 
 // Len returns the number of readers currently playing in the [Mixer].
 func (m *Mixer) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return len(m.readers)
 }
 
 // Add adds new reader(s) to the [Mixer].
 func (m *Mixer) Add(readers ...aio.SampleReader) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.readers = append(m.readers, readers...)
 }
 
 // Clear wipes and removes all readers from the [Mixer].
 func (m *Mixer) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	clear(m.readers)
 }
 
@@ -55,6 +67,9 @@ func (m *Mixer) Clear() {
 // ReadSamples reads the samples of all readers currently playing in the [Mixer], mixed together.
 // Depending on [Mixer.KeepAlive], this will either output silence or drain and return an [io.EOF].
 func (m *Mixer) ReadSamples(p []float32) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if len(p) == 0 || len(m.readers) == 0 {
 		return 0, nil
 	}
@@ -76,6 +91,7 @@ func (m *Mixer) ReadSamples(p []float32) (int, error) {
 		anyRead bool
 		readErr error
 	)
+	clear(p) // the readers are added on top
 
 	for _, r := range m.readers {
 		if r == nil {
@@ -105,8 +121,12 @@ func (m *Mixer) ReadSamples(p []float32) (int, error) {
 	m.readers = keep
 
 	if maxRead == 0 && !anyRead {
-		if m.stopWhenEmpty && len(m.readers) == 0 {
-			return 0, io.EOF
+		if len(m.readers) == 0 {
+			// all readers have drained, so the mixer is empty now
+			if m.stopWhenEmpty {
+				return 0, io.EOF
+			}
+			return 0, readErr
 		}
 		clear(p)
 		return len(p), nil
@@ -1,8 +1,11 @@
 package audio_test
 
 import (
+	"io"
+	"slices"
 	"testing"
 
+	"github.com/MatusOllah/resona/aio"
 	"github.com/MatusOllah/resona/audio"
 	"github.com/MatusOllah/resona/internal/testutil"
 )
@@ -24,3 +27,46 @@ func TestMixer(t *testing.T) {
 		t.Errorf("mixer: got %v, want %v", got, want)
 	}
 }
+
+func TestMixerLenDrains(t *testing.T) {
+	readers := []aio.SampleReader{
+		audio.NewReader([]float32{0.1, 0.2}),
+		audio.NewReader([]float32{0.1, 0.2, 0.3, 0.4}),
+		audio.NewReader([]float32{0.1}),
+	}
+	mixer := audio.NewMixer()
+	mixer.KeepAlive(false)
+	mixer.Add(readers...)
+	if got := mixer.Len(); got != len(readers) {
+		t.Fatalf("expected %d readers, got %d", len(readers), got)
+	}
+
+	buf := make([]float32, 2)
+	for range 10 {
+		if _, err := mixer.ReadSamples(buf); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := mixer.Len(); got != 0 {
+		t.Errorf("expected all readers to drain, got %d left", got)
+	}
+}
+
+// idleReader never has samples ready, like a source that cannot keep up.
+type idleReader struct{}
+
+func (idleReader) ReadSamples(p []float32) (int, error) { return 0, nil }
+
+func TestMixerSilence(t *testing.T) {
+	mixer := audio.NewMixer(idleReader{})
+	got := []float32{1, 1, 1, 1}
+	n, err := mixer.ReadSamples(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(got) || !slices.Equal(got, make([]float32, len(got))) {
+		t.Errorf("expected %d samples of silence while the reader has nothing ready, got %v", len(got), got[:n])
+	}
+}
@@ -0,0 +1,43 @@
+package audio
+
+import (
+	"io"
+	"math"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/effect"
+)
+
+// Normalize normalizes the peak level of r to targetDB dBFS in two passes.
+// It reads r to the end to measure the peak, seeks back to the start
+// and returns a reader that applies the gain to the samples of r.
+// Silence can not be normalized and is returned with unity gain.
+func Normalize(r aio.SampleReadSeeker, targetDB float64) (aio.SampleReader, error) {
+	var peak float32
+	buf := make([]float32, 4096)
+	for {
+		n, err := r.ReadSamples(buf)
+		for _, s := range buf[:n] {
+			peak = max(peak, float32(math.Abs(float64(s))))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	gain := float32(dsp.PeakGain(float64(peak), targetDB))
+	return effect.Reader(r, effect.EffectFunc(func(p []float32) error {
+		for i := range p {
+			p[i] *= gain
+		}
+		return nil
+	})), nil
+}
@@ -0,0 +1,56 @@
+package audio_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+func TestNormalize(t *testing.T) {
+	samples := make([]float32, 10000)
+	for i := range samples {
+		samples[i] = 0.1 - 0.25*float32(math.Cos(2*math.Pi*float64(i)/100))
+	}
+
+	r, err := audio.Normalize(audio.NewReader(samples), -6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := aio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(samples) {
+		t.Fatalf("expected %d samples, got %d", len(samples), len(got))
+	}
+	if peak := dsp.MeasurePeakDB(got); !testutil.EqualWithinTolerance(peak, -6, 1e-5) {
+		t.Errorf("expected a peak of -6 dBFS, got %v dBFS", peak)
+	}
+	// the gain is the same for every sample
+	ratio := got[0] / samples[0]
+	for i := range got {
+		if !testutil.EqualWithinTolerance(got[i], samples[i]*ratio, 1e-6) {
+			t.Fatalf("sample %d: expected %v, got %v", i, samples[i]*ratio, got[i])
+		}
+	}
+}
+
+func TestNormalizeSilence(t *testing.T) {
+	r, err := audio.Normalize(audio.NewReader(make([]float32, 100)), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := aio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range got {
+		if s != 0 {
+			t.Fatalf("expected silence, got %v", s)
+		}
+	}
+}
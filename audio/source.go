@@ -2,11 +2,12 @@ package audio
 
 import (
 	"io"
-	"math"
+	"sync"
 	"time"
 
 	"github.com/MatusOllah/resona/afmt"
 	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
 	"github.com/MatusOllah/resona/effect"
 )
 
@@ -14,32 +15,55 @@ import (
 //
 // It wraps an aio.SampleReader and provides high-level playback controls
 // and utilities such as pausing, muting, seeking, and volume control.
-// Internally, Source uses an effects.Chain with Mute and Gain, so that
-// the user does not need to compose them manually.
+// Internally, Source uses an [effect.DynamicChain] with Mute and Gain, so that
+// the user does not need to compose them manually. More effects can be added to it
+// with [Source.Effects], even while the source is playing.
+//
+// The controls are safe to use from another goroutine than the one reading the source.
 type Source struct {
 	r        aio.SampleReader
 	pausable *aio.PausableReader
-	mute     *effect.Mute
-	gain     *effect.Gain
+	chain    *effect.DynamicChain
+
+	mu   sync.Mutex // guards the mute and gain effects, which are processed with it held
+	mute *effect.Mute
+	gain *effect.Gain
 }
 
 // NewSource creates a new [Source] from the given reader.
 // It automatically wraps the reader with mute and gain effects, and makes it pausable.
 func NewSource(r aio.SampleReader) *Source {
-	mute := &effect.Mute{}
-	gain := &effect.Gain{}
-
-	return &Source{
-		r:        r,
-		pausable: aio.NewPausableReader(effect.Reader(r, effect.Chain{mute, gain})),
-		mute:     mute,
-		gain:     gain,
+	s := &Source{
+		r:     r,
+		chain: &effect.DynamicChain{},
+		mute:  &effect.Mute{},
+		gain:  &effect.Gain{},
 	}
+	s.chain.Append(lockedEffect{&s.mu, s.mute})
+	s.chain.Append(lockedEffect{&s.mu, s.gain})
+	s.pausable = aio.NewPausableReader(effect.Reader(r, s.chain))
+	return s
+}
+
+// lockedEffect processes an effect with a lock held, so that the controls of a [Source] can modify it in place
+// and it keeps ramping smoothly from where it is.
+type lockedEffect struct {
+	mu *sync.Mutex
+	fx effect.Effect
 }
 
-// Format returns the audio stream format.
+func (l lockedEffect) Process(p []float32) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.fx.Process(p)
+}
+
+// Format returns the audio stream format, or the zero [afmt.Format] if the underlying reader does not report it.
 func (s *Source) Format() afmt.Format {
-	return s.r.(afmt.Formatter).Format()
+	if f, ok := s.r.(afmt.Formatter); ok {
+		return f.Format()
+	}
+	return afmt.Format{}
 }
 
 // SampleFormat returns the sample format.
@@ -80,43 +104,73 @@ func (s *Source) IsPaused() bool {
 
 // Mute mutes the audio stream.
 func (s *Source) Mute() {
-	s.mute.Mute = true
+	s.setMute(true)
 }
 
 // Unmute unmutes the audio stream.
 func (s *Source) Unmute() {
-	s.mute.Mute = false
+	s.setMute(false)
 }
 
 // ToggleMute toggles the mute state.
 func (s *Source) ToggleMute() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.mute.Mute = !s.mute.Mute
 }
 
+func (s *Source) setMute(mute bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mute.Mute = mute
+}
+
 // IsMuted reports whether the audio stream is muted.
 func (s *Source) IsMuted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.mute.Mute
 }
 
 // Volume returns the current volume as a linear gain value.
 func (s *Source) Volume() float64 {
-	return s.gain.Gain
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return 1 + s.gain.Gain // the gain effect adds its gain to unity
 }
 
 // SetVolume sets the volume as a linear gain value.
 // A value of 1.0 is unity gain, 0.5 is half volume, values >1.0 amplify.
 func (s *Source) SetVolume(gain float64) {
-	s.gain.Gain = gain
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gain.Gain = gain - 1
+}
+
+// SetSmoothingTime sets the time over which changes of the volume ramp to the new value, and muting and unmuting fade,
+// for samples of the given format. A time of 0, the default, applies changes instantly.
+func (s *Source) SetSmoothingTime(d time.Duration, format afmt.Format) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mute.SetSmoothingTime(d, format)
+	s.gain.SetSmoothingTime(d, format)
 }
 
 // SetVolumeDB sets the volume using a decibel (dB) value.
 // 0.0 dB is unity gain, -6.0 dB is roughly half perceived loudness.
 func (s *Source) SetVolumeDB(dB float64) {
-	s.gain.Gain = math.Pow(10, dB/20)
+	s.SetVolume(dsp.DBToAmplitude(dB))
 }
 
 //TODO: maybe pan
 
+// Effects returns the effect chain of the source, which starts with its mute and gain effects.
+// Effects appended to it are applied after the volume, and it can be edited while the source is playing.
+// The mute and gain effects are modified by the controls of the source, and must not be replaced.
+func (s *Source) Effects() *effect.DynamicChain {
+	return s.chain
+}
+
 // ReadSamples reads audio samples into p from the underlying stream.
 // It passes through the mute/gain effects and respects pause/resume state.
 func (s *Source) ReadSamples(p []float32) (n int, err error) {
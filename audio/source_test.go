@@ -0,0 +1,121 @@
+package audio_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+func TestSourceVolume(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(s *audio.Source)
+		want float64
+	}{
+		{"Default", func(s *audio.Source) {}, 1},
+		{"Linear", func(s *audio.Source) { s.SetVolume(0.5) }, 0.5},
+		{"UnityDB", func(s *audio.Source) { s.SetVolumeDB(0) }, 1},
+		{"MinusSixDB", func(s *audio.Source) { s.SetVolumeDB(-6) }, 0.501},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := audio.NewSource(audio.NewReader([]float32{0.5, -0.5}))
+			tt.set(src)
+			if v := src.Volume(); !testutil.EqualWithinTolerance(v, tt.want, 1e-3) {
+				t.Errorf("Volume() = %v, want %v", v, tt.want)
+			}
+			got := make([]float32, 2)
+			if _, err := src.ReadSamples(got); err != nil {
+				t.Fatal(err)
+			}
+			want := []float32{float32(0.5 * tt.want), float32(-0.5 * tt.want)}
+			if !testutil.EqualSliceWithinTolerance(got, want, 1e-3) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// ones is an endless stream of ones.
+type ones struct{}
+
+func (ones) ReadSamples(p []float32) (int, error) {
+	for i := range p {
+		p[i] = 1
+	}
+	return len(p), nil
+}
+
+func TestSourceConcurrentControls(t *testing.T) {
+	src := audio.NewSource(ones{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 1000 {
+			src.SetVolumeDB(-float64(i % 20))
+			src.ToggleMute()
+			_ = src.Volume()
+			_ = src.IsMuted()
+		}
+	}()
+
+	p := make([]float32, 64)
+	for reading := true; reading; {
+		select {
+		case <-done:
+			reading = false
+		default:
+			if _, err := src.ReadSamples(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// 1000 toggles leave the source unmuted
+	src.SetVolume(0.5)
+	if _, err := src.ReadSamples(p); err != nil {
+		t.Fatal(err)
+	}
+	if src.IsMuted() || p[0] != 0.5 {
+		t.Errorf("expected the source unmuted at half volume, got %v (muted: %v)", p[0], src.IsMuted())
+	}
+}
+
+func TestSourceSmoothing(t *testing.T) {
+	src := audio.NewSource(ones{})
+	src.SetSmoothingTime(100*time.Second/48000, afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1})
+
+	p := make([]float32, 50)
+	if _, err := src.ReadSamples(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// the volume ramps from 1 to 0.5 over 100 samples
+	src.SetVolume(0.5)
+	if _, err := src.ReadSamples(p); err != nil {
+		t.Fatal(err)
+	}
+	if p[49] < 0.74 || p[49] > 0.76 {
+		t.Errorf("expected the volume halfway down the ramp, got %v", p[49])
+	}
+
+	// a change in the middle of the ramp continues from where it is
+	before := src.Effects().Handles()
+	src.SetVolume(1)
+	src.ToggleMute()
+	src.ToggleMute()
+	if after := src.Effects().Handles(); !slices.Equal(after, before) || after[1].Effect() != before[1].Effect() {
+		t.Fatal("expected the controls to keep the effects of the source")
+	}
+	if _, err := src.ReadSamples(p[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if p[0] < 0.74 || p[0] > 0.76 {
+		t.Errorf("expected the volume to ramp back from 0.75, got %v", p[0])
+	}
+}
@@ -0,0 +1,268 @@
+package capture
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/capture/driver"
+	"github.com/MatusOllah/resona/resample"
+)
+
+// Option represents an option for configuring [NewReader].
+type Option func(*options)
+
+type options struct {
+	driverName string
+	device     string
+	bufferSize int
+	quality    resample.Quality
+}
+
+// WithDriver sets the capture driver.
+func WithDriver(name string) Option {
+	return func(o *options) {
+		o.driverName = name
+	}
+}
+
+// WithDevice sets the name of the input device. The names depend on the driver.
+// If no device is specified, the default input device is used.
+func WithDevice(name string) Option {
+	return func(o *options) {
+		o.device = name
+	}
+}
+
+// WithBufferSize sets the size of the buffer that holds the captured samples until they are read.
+// If the reader falls behind by more than the buffer holds, the oldest samples are dropped.
+// The default is one second.
+func WithBufferSize(size int) Option {
+	return func(o *options) {
+		o.bufferSize = size
+	}
+}
+
+// WithResampleQuality sets the quality of the resampler used when the device cannot capture at the requested sample rate.
+// The default is [resample.QualityMedium].
+func WithResampleQuality(q resample.Quality) Option {
+	return func(o *options) {
+		o.quality = q
+	}
+}
+
+// Reader reads the audio captured from an input device.
+//
+// ReadSamples blocks until captured samples are available. Reader is safe to read from one goroutine
+// while Close is called from another, which makes a pending ReadSamples return io.EOF.
+type Reader struct {
+	format afmt.Format
+	drv    driver.Driver
+	buf    *ringBuffer
+
+	readMu sync.Mutex
+	r      aio.SampleReader
+	rs     resample.Resampler // nil if the device captures at the requested sample rate
+	closed atomic.Bool
+	once   sync.Once
+	err    error
+}
+
+// NewReader starts capturing audio with the specified format and options and returns a [Reader] of the captured samples.
+// If no driver is specified, the default driver (first one registered) is used.
+//
+// The samples are delivered interleaved at the sample rate of format, even if the device has to capture at another one.
+//
+// Registered drivers that implement [driver.Instancer] capture for every reader through its own instance;
+// the others capture for one reader at a time, until it is closed.
+func NewReader(format afmt.Format, opts ...Option) (*Reader, error) {
+	o := &options{
+		driverName: "", // Empty string = default driver
+		quality:    resample.QualityMedium,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if format.NumChannels <= 0 {
+		return nil, fmt.Errorf("capture: invalid number of channels: %d", format.NumChannels)
+	}
+	if format.SampleRate <= 0 {
+		return nil, fmt.Errorf("capture: invalid sample rate: %v", format.SampleRate)
+	}
+
+	var drv driver.Driver
+	if o.driverName == "" {
+		driversMu.RLock()
+		drv = defaultDriver
+		driversMu.RUnlock()
+		if drv == nil {
+			return nil, fmt.Errorf("capture: no default driver registered")
+		}
+	} else {
+		var ok bool
+		driversMu.RLock()
+		drv, ok = drivers[o.driverName]
+		driversMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("capture: unknown driver %q (forgotten import?)", o.driverName)
+		}
+	}
+
+	drv = instance(drv)
+
+	size := o.bufferSize
+	if size <= 0 {
+		size = int(format.SampleRate.Hertz()) * format.NumChannels
+	}
+	r := &Reader{
+		format: format,
+		drv:    drv,
+		buf:    newRingBuffer(size, format.NumChannels),
+	}
+	r.r = r.buf
+
+	devFormat, err := drv.Init(format, o.device, r.buf)
+	if err != nil {
+		return nil, fmt.Errorf("capture: failed to initialize driver %q: %w", o.driverName, err)
+	}
+	if devFormat.NumChannels != format.NumChannels {
+		drv.Close()
+		return nil, fmt.Errorf("capture: driver %q captures %d channels instead of %d", o.driverName, devFormat.NumChannels, format.NumChannels)
+	}
+	if devFormat.SampleRate != format.SampleRate {
+		rs, err := resample.New("", r.buf, devFormat.SampleRate, format.SampleRate, format.NumChannels, o.quality)
+		if err != nil {
+			drv.Close()
+			return nil, fmt.Errorf("capture: %w", err)
+		}
+		r.rs = rs
+		r.r = rs
+	}
+
+	return r, nil
+}
+
+// Format returns the format of the samples read from r.
+func (r *Reader) Format() afmt.Format {
+	return r.format
+}
+
+// ReadSamples reads the captured samples into p. It blocks until at least one sample is available,
+// and returns io.EOF once r is closed.
+func (r *Reader) ReadSamples(p []float32) (int, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+	if r.closed.Load() {
+		return 0, io.EOF
+	}
+	return r.r.ReadSamples(p)
+}
+
+// Dropped returns the number of samples dropped because the reader fell behind.
+func (r *Reader) Dropped() int64 {
+	return r.buf.dropped.Load()
+}
+
+// Close stops capturing and closes the underlying driver.
+func (r *Reader) Close() error {
+	r.once.Do(func() {
+		r.closed.Store(true)
+		r.buf.Close() // wakes up a pending ReadSamples
+		r.err = r.drv.Close()
+
+		// wait for a pending ReadSamples to return before freeing the resampler
+		r.readMu.Lock()
+		defer r.readMu.Unlock()
+		if r.rs != nil {
+			if err := r.rs.Close(); err != nil && r.err == nil {
+				r.err = err
+			}
+		}
+	})
+	return r.err
+}
+
+// ringBuffer is a buffer of captured samples, written by the driver and read by the [Reader].
+// Writes never block; when the buffer is full, the oldest frames are dropped.
+type ringBuffer struct {
+	mu          sync.Mutex
+	cond        sync.Cond
+	buf         []float32
+	numChannels int
+	start       int // index of the oldest sample
+	n           int // number of samples held
+	closed      bool
+
+	dropped atomic.Int64
+}
+
+func newRingBuffer(size, numChannels int) *ringBuffer {
+	// the buffer holds whole frames, so that dropping frames keeps the channels in order
+	size = max(size-size%numChannels, numChannels)
+	b := &ringBuffer{buf: make([]float32, size), numChannels: numChannels}
+	b.cond.L = &b.mu
+	return b
+}
+
+// WriteSamples implements the aio.SampleWriter interface.
+func (b *ringBuffer) WriteSamples(p []float32) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	n := len(p)
+	if len(p) > len(b.buf) {
+		// only the newest samples fit
+		b.dropped.Add(int64(len(p) - len(b.buf)))
+		p = p[len(p)-len(b.buf):]
+	}
+	if over := b.n + len(p) - len(b.buf); over > 0 {
+		over += (b.numChannels - over%b.numChannels) % b.numChannels
+		b.dropped.Add(int64(over))
+		b.start = (b.start + over) % len(b.buf)
+		b.n -= over
+	}
+	end := (b.start + b.n) % len(b.buf)
+	m := copy(b.buf[end:], p)
+	copy(b.buf, p[m:])
+	b.n += len(p)
+
+	b.cond.Signal()
+	return n, nil
+}
+
+// ReadSamples implements the aio.SampleReader interface.
+func (b *ringBuffer) ReadSamples(p []float32) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.n == 0 && !b.closed {
+		b.cond.Wait()
+	}
+	if b.n == 0 {
+		return 0, io.EOF
+	}
+
+	n := min(len(p), b.n)
+	m := copy(p[:n], b.buf[b.start:])
+	copy(p[m:n], b.buf)
+	b.start = (b.start + n) % len(b.buf)
+	b.n -= n
+	return n, nil
+}
+
+// Close makes writes fail and reads return io.EOF once the buffer is empty.
+func (b *ringBuffer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.cond.Broadcast()
+}
@@ -0,0 +1,274 @@
+package capture_test
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/capture"
+	"github.com/MatusOllah/resona/capture/driver"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// fakeDriver writes the given samples to the reader in Init, captures at rate if it is set,
+// and then captures nothing.
+type fakeDriver struct {
+	samples []float32
+	rate    freq.Frequency
+	closed  bool
+}
+
+func (d *fakeDriver) Init(format afmt.Format, device string, dst aio.SampleWriter) (afmt.Format, error) {
+	d.closed = false
+	if d.rate != 0 {
+		format.SampleRate = d.rate
+	}
+	if _, err := dst.WriteSamples(d.samples); err != nil {
+		return afmt.Format{}, err
+	}
+	return format, nil
+}
+
+func (d *fakeDriver) Close() error {
+	d.closed = true
+	return nil
+}
+
+var fake = &fakeDriver{}
+
+// instancingDriver captures a constant, the number of its instance, until it is closed.
+type instancingDriver struct {
+	n    *atomic.Int32 // number of instances made so far
+	id   float32
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (d *instancingDriver) NewInstance() driver.Driver {
+	return &instancingDriver{n: d.n, id: float32(d.n.Add(1))}
+}
+
+func (d *instancingDriver) Init(format afmt.Format, device string, dst aio.SampleWriter) (afmt.Format, error) {
+	d.stop, d.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(d.done)
+		p := make([]float32, 64*format.NumChannels)
+		for i := range p {
+			p[i] = d.id
+		}
+		for {
+			select {
+			case <-d.stop:
+				return
+			default:
+				dst.WriteSamples(p)
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+	return format, nil
+}
+
+func (d *instancingDriver) Close() error {
+	close(d.stop)
+	<-d.done
+	return nil
+}
+
+var instancing = &instancingDriver{n: &atomic.Int32{}}
+
+func init() {
+	capture.Register("fake", fake)
+	capture.Register("instancing", instancing)
+}
+
+var stereo48k = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+// ramp returns n samples counting up from 0.
+func ramp(n int) []float32 {
+	p := make([]float32, n)
+	for i := range p {
+		p[i] = float32(i)
+	}
+	return p
+}
+
+func TestReader(t *testing.T) {
+	*fake = fakeDriver{samples: ramp(1000)}
+	r, err := capture.NewReader(stereo48k, capture.WithDriver("fake"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := make([]float32, 1000)
+	if _, err := aio.ReadFull(r, p); err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range p {
+		if x != float32(i) {
+			t.Fatalf("expected %v at %d, got %v", float32(i), i, x)
+		}
+	}
+	if got := r.Format(); got != stereo48k {
+		t.Errorf("expected format %v, got %v", stereo48k, got)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !fake.closed {
+		t.Error("expected the driver to be closed")
+	}
+	if _, err := r.ReadSamples(p); err != io.EOF {
+		t.Errorf("expected io.EOF after closing, got %v", err)
+	}
+}
+
+func TestReaderDropsOldest(t *testing.T) {
+	*fake = fakeDriver{samples: ramp(1001)} // with half a frame too much
+	r, err := capture.NewReader(stereo48k, capture.WithDriver("fake"), capture.WithBufferSize(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	p := make([]float32, 100)
+	n, err := r.ReadSamples(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped := r.Dropped(); int(dropped)+n != 1001 {
+		t.Errorf("expected %d samples read and dropped, got %d and %d", 1001, n, dropped)
+	}
+	// the newest samples are kept
+	if p[n-1] != 1000 {
+		t.Errorf("expected the newest sample to be kept, got %v", p[n-1])
+	}
+}
+
+func TestReaderResample(t *testing.T) {
+	const inFrames = 24000
+	in := make([]float32, 2*inFrames)
+	for i := range in {
+		in[i] = 0.5
+	}
+	*fake = fakeDriver{samples: in, rate: 24 * freq.KiloHertz}
+	r, err := capture.NewReader(stereo48k, capture.WithDriver("fake"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Format(); got != stereo48k {
+		t.Errorf("expected format %v, got %v", stereo48k, got)
+	}
+
+	// read about half of the upsampled output, which is past the start-up of the filter
+	p := make([]float32, 2*inFrames)
+	if _, err := aio.ReadFull(r, p); err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range p[len(p)/2:] {
+		if x < 0.49 || x > 0.51 {
+			t.Fatalf("expected 0.5 at %d, got %v", len(p)/2+i, x)
+		}
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReaderCloseWhileReading(t *testing.T) {
+	for _, rate := range []freq.Frequency{0, 44100 * freq.Hertz} {
+		*fake = fakeDriver{rate: rate}
+		r, err := capture.NewReader(stereo48k, capture.WithDriver("fake"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		errc := make(chan error)
+		go func() {
+			_, err := r.ReadSamples(make([]float32, 256))
+			errc <- err
+		}()
+
+		time.Sleep(10 * time.Millisecond) // let ReadSamples block
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case err := <-errc:
+			if err != io.EOF {
+				t.Errorf("expected io.EOF, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected Close to unblock ReadSamples")
+		}
+	}
+}
+
+func TestReaderInstances(t *testing.T) {
+	instancing.n.Store(0)
+
+	// open the readers concurrently, as nothing is shared between the instances
+	readers := make([]*capture.Reader, 4)
+	var wg sync.WaitGroup
+	for i := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := capture.NewReader(stereo48k, capture.WithDriver("instancing"))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			readers[i] = r
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		t.FailNow()
+	}
+	if n := instancing.n.Load(); n != int32(len(readers)) {
+		t.Fatalf("expected an instance per reader, got %d", n)
+	}
+
+	// every reader captures through its own instance, which keeps capturing when another reader is closed
+	ids := make(map[float32]bool)
+	p := make([]float32, 2)
+	for _, r := range readers {
+		if _, err := aio.ReadFull(r, p); err != nil {
+			t.Fatal(err)
+		}
+		ids[p[0]] = true
+	}
+	if len(ids) != len(readers) {
+		t.Errorf("expected the readers to capture from %d instances, got %v", len(readers), ids)
+	}
+	if err := readers[0].Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range readers[1:] {
+		if _, err := aio.ReadFull(r, p); err != nil {
+			t.Fatalf("expected the other readers to keep capturing, got %v", err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if instancing.stop != nil {
+		t.Error("expected the registered driver itself to stay unused")
+	}
+}
+
+func TestNewReaderInvalid(t *testing.T) {
+	if _, err := capture.NewReader(afmt.Format{SampleRate: 48 * freq.KiloHertz}); err == nil {
+		t.Error("expected an error for zero channels")
+	}
+	if _, err := capture.NewReader(stereo48k, capture.WithDriver("nope")); err == nil {
+		t.Error("expected an error for an unknown driver")
+	}
+}
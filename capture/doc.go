@@ -0,0 +1,10 @@
+// Package capture provides an interface for audio capture,
+// allowing audio to be recorded from microphones and other input devices through various drivers.
+//
+// Like in the playback package, drivers are enabled by importing them, such as
+//
+//	import _ "github.com/MatusOllah/resona/capture/driver/malgo"
+//
+// and implement the [driver.Driver] interface. The malgo driver captures from the native audio API of the platform
+// through miniaudio and requires cgo; the ffmpeg driver runs the ffmpeg command instead.
+package capture
@@ -0,0 +1,29 @@
+// Package driver provides the interface for capture drivers.
+package driver
+
+import (
+	"io"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+)
+
+// Driver is the interface that capture drivers must implement.
+type Driver interface {
+	// Init starts capturing from the named input device, or the default one if device is empty,
+	// and writes the interleaved samples to dst as they arrive, from any goroutine.
+	// WriteSamples of dst does not block, so it can be called from an audio callback.
+	//
+	// The samples must have the number of channels of format. If the device cannot capture at the sample rate of format,
+	// the driver may capture at another one; Init returns the format it actually captures in.
+	Init(format afmt.Format, device string, dst aio.SampleWriter) (afmt.Format, error)
+
+	io.Closer
+}
+
+// Instancer is implemented by drivers that can capture for several readers at once.
+// A reader captures through a new instance of such a driver instead of the registered driver itself.
+type Instancer interface {
+	// NewInstance returns a new, uninitialized instance of the driver.
+	NewInstance() Driver
+}
@@ -0,0 +1,130 @@
+// Package ffmpeg provides a FFmpeg-based capture driver.
+//
+// The driver runs the ffmpeg command, which must be in PATH, with the input device API of the platform:
+// PulseAudio on Linux, AVFoundation on macOS and DirectShow on Windows.
+// FFmpeg converts the audio to the requested format itself, so the driver always captures in that format.
+//
+// The device names are those of the input device API. DirectShow has no default device,
+// so on Windows a device must be given, such as "audio=Microphone (Realtek Audio)";
+// run "ffmpeg -list_devices true -f dshow -i dummy" to list them.
+package ffmpeg
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/capture"
+	"github.com/MatusOllah/resona/capture/driver"
+)
+
+// chunkFrames is the number of frames read from ffmpeg at a time.
+const chunkFrames = 256
+
+// Driver represents the driver. Every [capture.Reader] runs its own ffmpeg command through its own instance.
+type Driver struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewInstance implements the driver.Instancer interface.
+func (d *Driver) NewInstance() driver.Driver {
+	return &Driver{}
+}
+
+// Init starts the ffmpeg command capturing from the device.
+func (d *Driver) Init(format afmt.Format, device string, dst aio.SampleWriter) (afmt.Format, error) {
+	if inputFormat == "" {
+		return afmt.Format{}, errors.New("ffmpeg: capture is not supported on this platform")
+	}
+	if device == "" {
+		device = defaultDevice
+	}
+	if device == "" {
+		return afmt.Format{}, fmt.Errorf("ffmpeg: %s has no default device, one must be given", inputFormat)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(
+		ctx,
+		"ffmpeg",
+		"-hide_banner",
+		"-loglevel", "panic",
+		"-f", inputFormat,
+		"-i", device,
+		"-vn", // no video
+		"-ac", fmt.Sprint(format.NumChannels),
+		"-ar", fmt.Sprintf("%.0f", format.SampleRate.Hertz()),
+		"-f", "f32le", // float32 little-endian
+		"pipe:1",
+	)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return afmt.Format{}, fmt.Errorf("ffmpeg: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return afmt.Format{}, fmt.Errorf("ffmpeg: starting ffmpeg command: %w", err)
+	}
+
+	d.cancel = cancel
+	d.done = make(chan struct{})
+	go func() {
+		defer close(d.done)
+		copyPCM(dst, stdout, format.NumChannels)
+		cmd.Wait()
+	}()
+
+	return format, nil
+}
+
+// Close stops the ffmpeg command.
+func (d *Driver) Close() error {
+	if d.cancel == nil {
+		return nil
+	}
+	d.cancel()
+	<-d.done
+	d.cancel = nil
+	return nil
+}
+
+// copyPCM decodes float32 little endian PCM from r and writes it to dst in whole frames until r ends.
+func copyPCM(dst aio.SampleWriter, r io.Reader, numChannels int) {
+	const sampleSize = 4 // float32 size = 4 bytes
+	frameSize := numChannels * sampleSize
+
+	buf := make([]byte, chunkFrames*frameSize)
+	samples := make([]float32, chunkFrames*numChannels)
+	off := 0 // bytes of a partial frame left from the previous read
+	for {
+		n, err := r.Read(buf[off:])
+		n += off
+		whole := n - n%frameSize
+		for i := range whole / sampleSize {
+			samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*sampleSize:]))
+		}
+		if whole > 0 {
+			if _, werr := dst.WriteSamples(samples[:whole/sampleSize]); werr != nil {
+				return
+			}
+		}
+		off = copy(buf, buf[whole:n])
+		if err != nil {
+			return
+		}
+	}
+}
+
+func init() {
+	capture.Register("ffmpeg", &Driver{}) // register driver
+}
@@ -0,0 +1,85 @@
+//go:build unix
+
+package ffmpeg_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/capture"
+	_ "github.com/MatusOllah/resona/capture/driver/ffmpeg"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// fakeFFmpeg puts an ffmpeg command that captures silence first in PATH, and returns the file it writes its PIDs to.
+func fakeFFmpeg(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	pids := filepath.Join(dir, "pids")
+	script := "#!/bin/sh\necho $$ >> " + pids + "\nexec cat /dev/zero\n"
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return pids
+}
+
+func TestReaders(t *testing.T) {
+	pids := fakeFFmpeg(t)
+	format := afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+	r1, err := capture.NewReader(format, capture.WithDriver("ffmpeg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := capture.NewReader(format, capture.WithDriver("ffmpeg"))
+	if err != nil {
+		r1.Close()
+		t.Fatal(err)
+	}
+
+	p := make([]float32, 1024)
+	for _, r := range []*capture.Reader{r1, r2} {
+		if _, err := aio.ReadFull(r, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// closing one reader stops only its own ffmpeg command
+	if err := r1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		if _, err := aio.ReadFull(r2, p); err != nil {
+			t.Fatalf("expected the other reader to keep capturing, got %v", err)
+		}
+	}
+	if err := r2.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(pids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) != 2 {
+		t.Fatalf("expected 2 ffmpeg commands, got %d", len(fields))
+	}
+	for _, f := range fields {
+		pid, err := strconv.Atoi(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the commands were waited for, so their PIDs no longer exist
+		if err := syscall.Kill(pid, 0); err == nil {
+			t.Errorf("expected ffmpeg command %d to be stopped", pid)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+package ffmpeg
+
+const (
+	inputFormat   = "avfoundation"
+	defaultDevice = ":default" // no video device, default audio device
+)
@@ -0,0 +1,6 @@
+package ffmpeg
+
+const (
+	inputFormat   = "pulse"
+	defaultDevice = "default"
+)
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package ffmpeg
+
+const (
+	inputFormat   = "" // not supported
+	defaultDevice = ""
+)
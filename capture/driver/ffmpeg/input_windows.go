@@ -0,0 +1,6 @@
+package ffmpeg
+
+const (
+	inputFormat   = "dshow"
+	defaultDevice = "" // DirectShow has no default device
+)
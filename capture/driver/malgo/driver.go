@@ -0,0 +1,165 @@
+//go:build cgo
+
+// Package malgo provides a [miniaudio]-based capture driver, using the [malgo] bindings.
+//
+// The driver captures from the native audio API of the platform, such as PulseAudio or ALSA on Linux,
+// Core Audio on macOS and WASAPI on Windows. It requires cgo, but no libraries to build,
+// as miniaudio loads the audio API at run time.
+//
+// The device names are those listed by [Devices]; without one, the default input device of the system is used.
+// Miniaudio converts the audio to the requested number of channels itself, and captures at the requested
+// sample rate rounded to whole hertz.
+//
+// [miniaudio]: https://miniaud.io
+// [malgo]: https://github.com/gen2brain/malgo
+package malgo
+
+// #include <stdlib.h>
+import "C"
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"unsafe"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/capture"
+	"github.com/MatusOllah/resona/capture/driver"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/gen2brain/malgo"
+)
+
+// Driver represents the driver. Every [capture.Reader] captures through a device of its own instance.
+type Driver struct {
+	ctx      *malgo.AllocatedContext
+	dev      *malgo.Device
+	deviceID unsafe.Pointer // C copy of the ID of the device, or nil for the default device
+	dst      aio.SampleWriter
+	samples  []float32 // the captured samples, converted in the data callback
+}
+
+// NewInstance implements the driver.Instancer interface.
+func (d *Driver) NewInstance() driver.Driver {
+	return &Driver{}
+}
+
+// Init starts capturing from the device.
+func (d *Driver) Init(format afmt.Format, device string, dst aio.SampleWriter) (afmt.Format, error) {
+	if d.dev != nil {
+		return afmt.Format{}, errors.New("malgo: driver is already initialized")
+	}
+
+	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	if err != nil {
+		return afmt.Format{}, fmt.Errorf("malgo: failed to initialize context: %w", err)
+	}
+	d.ctx = ctx
+
+	cfg := malgo.DefaultDeviceConfig(malgo.Capture)
+	cfg.Capture.Format = malgo.FormatF32
+	cfg.Capture.Channels = uint32(format.NumChannels)
+	cfg.SampleRate = uint32(math.Round(format.SampleRate.Hertz()))
+	if device != "" {
+		info, err := findDevice(ctx.Context, device)
+		if err != nil {
+			d.Close()
+			return afmt.Format{}, err
+		}
+		d.deviceID = info.ID.Pointer()
+		cfg.Capture.DeviceID = d.deviceID
+	}
+
+	d.dst = dst
+	dev, err := malgo.InitDevice(ctx.Context, cfg, malgo.DeviceCallbacks{Data: d.onData})
+	if err != nil {
+		d.Close()
+		return afmt.Format{}, fmt.Errorf("malgo: failed to initialize device: %w", err)
+	}
+	d.dev = dev
+	if err := dev.Start(); err != nil {
+		d.Close()
+		return afmt.Format{}, fmt.Errorf("malgo: failed to start device: %w", err)
+	}
+
+	return afmt.Format{
+		SampleRate:  freq.Frequency(dev.SampleRate()) * freq.Hertz,
+		NumChannels: int(dev.CaptureChannels()),
+	}, nil
+}
+
+// onData is the data callback of the device, which converts the captured float32 samples and writes them to dst.
+func (d *Driver) onData(_, in []byte, _ uint32) {
+	n := len(in) / 4
+	if cap(d.samples) < n {
+		d.samples = make([]float32, n)
+	}
+	p := d.samples[:n]
+	for i := range p {
+		p[i] = math.Float32frombits(binary.NativeEndian.Uint32(in[4*i:]))
+	}
+	d.dst.WriteSamples(p) // never blocks
+}
+
+// Close stops capturing and frees the device.
+func (d *Driver) Close() error {
+	if d.dev != nil {
+		d.dev.Uninit() // waits for the data callback to return
+		d.dev = nil
+	}
+	if d.deviceID != nil {
+		C.free(d.deviceID)
+		d.deviceID = nil
+	}
+	if d.ctx != nil {
+		err := d.ctx.Uninit()
+		d.ctx.Free()
+		d.ctx = nil
+		if err != nil {
+			return fmt.Errorf("malgo: failed to uninitialize context: %w", err)
+		}
+	}
+	return nil
+}
+
+// Devices returns the names of the input devices, as understood by [capture.WithDevice].
+func Devices() ([]string, error) {
+	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("malgo: failed to initialize context: %w", err)
+	}
+	defer func() {
+		_ = ctx.Uninit()
+		ctx.Free()
+	}()
+
+	infos, err := ctx.Devices(malgo.Capture)
+	if err != nil {
+		return nil, fmt.Errorf("malgo: failed to list devices: %w", err)
+	}
+	names := make([]string, len(infos))
+	for i := range infos {
+		names[i] = infos[i].Name()
+	}
+	return names, nil
+}
+
+// findDevice returns the input device with the given name.
+func findDevice(ctx malgo.Context, name string) (*malgo.DeviceInfo, error) {
+	infos, err := ctx.Devices(malgo.Capture)
+	if err != nil {
+		return nil, fmt.Errorf("malgo: failed to list devices: %w", err)
+	}
+	for i := range infos {
+		if infos[i].Name() == name {
+			return &infos[i], nil
+		}
+	}
+	return nil, fmt.Errorf("malgo: unknown input device %q", name)
+}
+
+func init() {
+	capture.Register("malgo", &Driver{}) // register driver
+}
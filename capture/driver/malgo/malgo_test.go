@@ -0,0 +1,41 @@
+//go:build cgo
+
+package malgo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/capture"
+	_ "github.com/MatusOllah/resona/capture/driver/malgo"
+	"github.com/MatusOllah/resona/freq"
+)
+
+func TestCapture(t *testing.T) {
+	format := afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 1}
+	r, err := capture.NewReader(format, capture.WithDriver("malgo"))
+	if err != nil {
+		t.Skipf("no input device: %v", err)
+	}
+	defer r.Close()
+
+	if got := r.Format(); got != format {
+		t.Errorf("expected format %v, got %v", format, got)
+	}
+	p := make([]float32, afmt.DurationToNumFrames(format.SampleRate, 100*time.Millisecond))
+	if _, err := aio.ReadFull(r, p); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnknownDevice(t *testing.T) {
+	_, err := capture.NewReader(afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}, capture.WithDriver("malgo"), capture.WithDevice("no such device"))
+	if err == nil {
+		t.Error("expected an error for an unknown device")
+	}
+}
@@ -0,0 +1,47 @@
+package capture
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/MatusOllah/resona/capture/driver"
+)
+
+var (
+	driversMu     sync.RWMutex
+	drivers       = make(map[string]driver.Driver)
+	defaultDriver driver.Driver
+)
+
+// Register registers a capture driver with the given name.
+// Drivers usually register themselves in an init function, so that importing the driver package enables it.
+func Register(name string, drv driver.Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if drv == nil {
+		panic("capture: Register driver is nil")
+	}
+	if _, exists := drivers[name]; exists {
+		panic("capture: Register called twice for driver " + name)
+	}
+	drivers[name] = drv
+	if defaultDriver == nil {
+		defaultDriver = drv
+	}
+}
+
+// Drivers returns a sorted list of the names of the registered drivers.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	return slices.Sorted(maps.Keys(drivers))
+}
+
+// instance returns the driver a reader captures through for the registered driver drv.
+func instance(drv driver.Driver) driver.Driver {
+	if inst, ok := drv.(driver.Instancer); ok {
+		return inst.NewInstance()
+	}
+	return drv
+}
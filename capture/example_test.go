@@ -0,0 +1,44 @@
+package capture_test
+
+import (
+	"encoding/binary"
+	"os"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/capture"
+	_ "github.com/MatusOllah/resona/capture/driver/ffmpeg" // Enable FFmpeg driver
+	"github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/freq"
+)
+
+func Example() {
+	format := afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}
+
+	// Start capturing from the default input device with FFmpeg as the driver
+	r, err := capture.NewReader(format, capture.WithDriver("ffmpeg"))
+	if err != nil {
+		panic(err)
+	}
+	defer r.Close()
+
+	// Create the WAV file
+	f, err := os.Create("recording.wav")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	enc, err := wav.NewEncoder(f, format, afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}, wav.FormatInt)
+	if err != nil {
+		panic(err)
+	}
+	defer enc.Close()
+
+	// Record 5 seconds
+	seconds := 5
+	n := int64(seconds * int(format.SampleRate.Hertz()) * format.NumChannels)
+	if _, err := aio.CopyN(enc, r, n); err != nil {
+		panic(err)
+	}
+}
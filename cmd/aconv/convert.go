@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/encoders"
+	"github.com/MatusOllah/resona/internal/fmtconv"
+	"github.com/MatusOllah/resona/resample"
+)
+
+// options represents the settings of a conversion. The zero value of every field keeps the input as it is.
+type options struct {
+	// To is the name of the output format, such as "wav".
+	To string
+
+	// BitDepth is the bit depth of the output samples, and Float makes them floating-point.
+	BitDepth int
+	Float    bool
+
+	// SampleRate is the sample rate of the output, which is resampled if it differs from the input.
+	SampleRate freq.Frequency
+
+	// Channels is the number of channels of the output, which are mapped if it differs from the input.
+	Channels int
+
+	// Progress is called with the percentage of the input converted so far, if the length of the input is known.
+	Progress func(percent int)
+}
+
+// convert decodes the audio file src and encodes it to dst in the output format.
+func convert(dst io.WriteSeeker, src io.Reader, opts options) error {
+	enc, err := encoders.Lookup(opts.To)
+	if err != nil {
+		return err
+	}
+	if opts.SampleRate < 0 || opts.Channels < 0 {
+		return errors.New("invalid sample rate or number of channels")
+	}
+
+	dec, _, err := codec.Decode(src)
+	if err != nil {
+		return fmt.Errorf("decoding input: %w", err)
+	}
+	in := dec.Format()
+	out := in
+	if opts.SampleRate != 0 {
+		out.SampleRate = opts.SampleRate
+	}
+	if opts.Channels != 0 {
+		out.NumChannels = opts.Channels
+	}
+
+	sampleFmt, err := enc.SampleFormat(opts.BitDepth, opts.Float, dec.SampleFormat())
+	if err != nil {
+		return err
+	}
+
+	pr := &progressReader{r: dec, channels: in.NumChannels, total: dec.Len(), progress: opts.Progress}
+	r, rs, err := fmtconv.Convert(pr, in, out, resample.QualityHigh)
+	if err != nil {
+		return fmt.Errorf("creating resampler: %w", err)
+	}
+	if rs != nil {
+		defer rs.Close()
+	}
+
+	w, err := enc.New(dst, out, sampleFmt)
+	if err != nil {
+		return fmt.Errorf("creating %s encoder: %w", enc.Name, err)
+	}
+	if _, err := aio.Copy(w, r); err != nil {
+		w.Close()
+		return fmt.Errorf("converting: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("finishing %s output: %w", enc.Name, err)
+	}
+	return nil
+}
+
+// progressReader reports how much of the input has been read.
+type progressReader struct {
+	r        aio.SampleReader
+	channels int
+	total    int // in frames
+	read     int
+	last     int
+	progress func(percent int)
+}
+
+func (r *progressReader) ReadSamples(p []float32) (int, error) {
+	n, err := r.r.ReadSamples(p)
+	r.read += n / r.channels
+	if r.progress != nil && r.total > 0 {
+		if percent := min(r.read*100/r.total, 100); percent != r.last {
+			r.last = percent
+			r.progress(percent)
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/encoders"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+const fixtureFrames = 4000
+
+var fixtureFormat = afmt.Format{SampleRate: 8 * freq.KiloHertz, NumChannels: 2}
+
+// fixture encodes half a second of a stereo sine to the format with the given name and sample format.
+func fixture(t *testing.T, name string, bitDepth int, float bool) []byte {
+	t.Helper()
+	enc, err := encoders.Lookup(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampleFmt, err := enc.SampleFormat(bitDepth, float, afmt.SampleFormat{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := &testutil.WriteSeeker{}
+	w, err := enc.New(ws, fixtureFormat, sampleFmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make([]float32, fixtureFrames*2)
+	for i := range fixtureFrames {
+		v := float32(0.5 * math.Sin(2*math.Pi*440*float64(i)/8000))
+		p[2*i], p[2*i+1] = v, -v
+	}
+	if _, err := w.WriteSamples(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return ws.Bytes()
+}
+
+// decodeAll decodes an encoded file completely.
+func decodeAll(t *testing.T, b []byte) (afmt.Format, []float32) {
+	t.Helper()
+	dec, _, err := codec.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dec.Format(), p
+}
+
+func TestConvertMatrix(t *testing.T) {
+	fixtures := []struct {
+		name     string
+		bitDepth int
+		float    bool
+	}{
+		{"wav", 16, false},
+		{"wav", 32, true},
+		{"au", 24, false},
+		{"aiff", 16, false},
+		{"avr", 16, false},
+		{"qoa", 0, false},
+	}
+	for _, fx := range fixtures {
+		in := fixture(t, fx.name, fx.bitDepth, fx.float)
+		_, want := decodeAll(t, in)
+		for _, to := range encoders.Names() {
+			t.Run(fx.name+"-"+to, func(t *testing.T) {
+				ws := &testutil.WriteSeeker{}
+				if err := convert(ws, bytes.NewReader(in), options{To: to}); err != nil {
+					t.Fatal(err)
+				}
+				format, got := decodeAll(t, ws.Bytes())
+				if format != fixtureFormat {
+					t.Errorf("expected format %v, got %v", fixtureFormat, format)
+				}
+				if len(got) != len(want) {
+					t.Fatalf("expected %d samples, got %d", len(want), len(got))
+				}
+				tolerance := 1e-3
+				if to == "qoa" {
+					tolerance = 0.05 // lossy
+				}
+				if !testutil.EqualSliceWithinTolerance(got, want, tolerance) {
+					t.Error("converted samples differ from the input")
+				}
+			})
+		}
+	}
+}
+
+func TestConvertSampleFormat(t *testing.T) {
+	in := fixture(t, "wav", 32, true)
+	for _, tt := range []struct {
+		to       string
+		bitDepth int
+		float    bool
+		want     afmt.SampleFormat
+	}{
+		{"wav", 0, false, afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingFloat}},
+		{"aiff", 0, false, afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt}},
+		{"au", 24, false, afmt.SampleFormat{BitDepth: 24, Encoding: afmt.SampleEncodingInt}},
+		{"au", 0, true, afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingFloat}},
+	} {
+		ws := &testutil.WriteSeeker{}
+		if err := convert(ws, bytes.NewReader(in), options{To: tt.to, BitDepth: tt.bitDepth, Float: tt.float}); err != nil {
+			t.Fatalf("%s: %v", tt.to, err)
+		}
+		dec, _, err := codec.Decode(bytes.NewReader(ws.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := dec.SampleFormat(); got.BitDepth != tt.want.BitDepth || got.Encoding != tt.want.Encoding {
+			t.Errorf("%s with %d bits: expected sample format %v, got %v", tt.to, tt.bitDepth, tt.want, got)
+		}
+	}
+}
+
+func TestConvertResampleAndMapChannels(t *testing.T) {
+	ws := &testutil.WriteSeeker{}
+	opts := options{To: "wav", SampleRate: 16 * freq.KiloHertz, Channels: 1}
+	if err := convert(ws, bytes.NewReader(fixture(t, "wav", 16, false)), opts); err != nil {
+		t.Fatal(err)
+	}
+	format, got := decodeAll(t, ws.Bytes())
+	if want := (afmt.Format{SampleRate: 16 * freq.KiloHertz, NumChannels: 1}); format != want {
+		t.Errorf("expected format %v, got %v", want, format)
+	}
+	if frames := len(got); math.Abs(float64(frames-2*fixtureFrames)) > 64 {
+		t.Errorf("expected about %d frames, got %d", 2*fixtureFrames, frames)
+	}
+	// the channels are in antiphase, so they cancel out
+	for i, v := range got {
+		if math.Abs(float64(v)) > 1e-3 {
+			t.Fatalf("expected silence, got %v at sample %d", v, i)
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	in := fixture(t, "wav", 16, false)
+	for _, tt := range []struct {
+		name string
+		opts options
+		want string
+	}{
+		{"float AIFF", options{To: "aiff", Float: true}, "cannot store floating-point samples"},
+		{"12-bit WAVE", options{To: "wav", BitDepth: 12}, "cannot store 12-bit integer samples"},
+		{"24-bit AVR", options{To: "avr", BitDepth: 24}, "supported bit depths: 8 16"},
+		{"QOA bit depth", options{To: "qoa", BitDepth: 16}, "lossy"},
+		{"unknown format", options{To: "mp4"}, "unknown output format"},
+		{"negative channels", options{To: "wav", Channels: -1}, "invalid"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := convert(&testutil.WriteSeeker{}, bytes.NewReader(in), tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestConvertProgress(t *testing.T) {
+	var percents []int
+	opts := options{To: "au", Progress: func(percent int) { percents = append(percents, percent) }}
+	if err := convert(&testutil.WriteSeeker{}, bytes.NewReader(fixture(t, "wav", 16, false)), opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(percents) == 0 || percents[len(percents)-1] != 100 {
+		t.Fatalf("expected the progress to reach 100%%, got %v", percents)
+	}
+	for i := 1; i < len(percents); i++ {
+		if percents[i] <= percents[i-1] {
+			t.Fatalf("expected increasing progress, got %v", percents)
+		}
+	}
+}
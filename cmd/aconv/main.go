@@ -0,0 +1,88 @@
+// Command aconv converts audio files between the formats resona can decode and encode.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "github.com/MatusOllah/resona/codec/aiff"
+	_ "github.com/MatusOllah/resona/codec/au"
+	_ "github.com/MatusOllah/resona/codec/avr"
+	_ "github.com/MatusOllah/resona/codec/flac"
+	_ "github.com/MatusOllah/resona/codec/oggvorbis"
+	_ "github.com/MatusOllah/resona/codec/qoa"
+	_ "github.com/MatusOllah/resona/codec/svx"
+	_ "github.com/MatusOllah/resona/codec/voc"
+	_ "github.com/MatusOllah/resona/codec/wav"
+	_ "github.com/MatusOllah/resona/codec/wavpack"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/encoders"
+)
+
+func main() {
+	to := flag.String("to", "", "output format, one of "+strings.Join(encoders.Names(), ", ")+" (default: inferred from the output file name)")
+	bitDepth := flag.Int("bit-depth", 0, "bit depth of the output samples (default: the bit depth of the input, if the output format can store it, or 16)")
+	float := flag.Bool("float", false, "write floating-point samples")
+	sampleRate := flag.Int("sample-rate", 0, "sample rate of the output in Hz, resampling the input if it differs (default: the sample rate of the input)")
+	channels := flag.Int("channels", 0, "number of channels of the output, mapping the channels of the input if it differs (default: the number of channels of the input)")
+	quiet := flag.Bool("q", false, "do not print the progress")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <input file> <output file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := options{
+		To:         *to,
+		BitDepth:   *bitDepth,
+		Float:      *float,
+		SampleRate: freq.Frequency(*sampleRate) * freq.Hertz,
+		Channels:   *channels,
+	}
+	if opts.To == "" {
+		var err error
+		if opts.To, err = encoders.FormatFromName(flag.Arg(1)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v (use -to)\n", err)
+			os.Exit(2)
+		}
+	}
+	progressed := false
+	if !*quiet {
+		opts.Progress = func(percent int) {
+			fmt.Fprintf(os.Stderr, "\rConverting... %d%%", percent)
+			progressed = true
+		}
+	}
+
+	in, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening input file: %v\n", err)
+		os.Exit(1)
+	}
+	defer in.Close()
+
+	out, err := os.Create(flag.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = convert(out, in, opts)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if progressed {
+		fmt.Fprintln(os.Stderr)
+	}
+	if err != nil {
+		os.Remove(flag.Arg(1)) // do not leave a broken file behind
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/codec/aiff"
+	"github.com/MatusOllah/resona/codec/avr"
+	"github.com/MatusOllah/resona/codec/svx"
+	"github.com/MatusOllah/resona/codec/voc"
+)
+
+// Ways of finding out the length of a file.
+const (
+	pathHeader = "header" // the decoder knows the length from the header
+	pathDecode = "decode" // the file was decoded to count the frames
+)
+
+// info represents what is known about an audio file.
+type info struct {
+	File         string              `json:"file,omitempty"`
+	Codec        string              `json:"codec"`
+	Format       afmt.Format         `json:"format"`
+	SampleFormat afmt.SampleFormat   `json:"sample_format"`
+	Frames       int                 `json:"frames"`
+	Duration     float64             `json:"duration_seconds"`
+	Bitrate      int                 `json:"bitrate,omitempty"` // in bits per second
+	Loops        []loop              `json:"loops,omitempty"`
+	Tags         map[string][]string `json:"tags,omitempty"`
+	Path         string              `json:"path"` // how the length was found, pathHeader or pathDecode
+}
+
+// loop represents a loop stored in an audio file.
+type loop struct {
+	Name  string `json:"name,omitempty"` // such as "sustain" for formats with several kinds of loops
+	Start int    `json:"start"`          // in frames
+	End   int    `json:"end"`            // in frames, exclusive
+	Count int    `json:"count"`          // the number of repeats, -1 for endless, or 0 if not stored
+}
+
+// inspect decodes the header of the audio file r, and the whole file if the header does not tell its length.
+func inspect(r io.Reader) (*info, error) {
+	dec, name, err := codec.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &info{
+		Codec:        name,
+		Format:       dec.Format(),
+		SampleFormat: dec.SampleFormat(),
+		Frames:       dec.Len(),
+		Path:         pathHeader,
+		Loops:        loops(dec),
+	}
+	i.Bitrate, _ = codec.Bitrate(dec)
+	if md, ok := dec.(codec.Metadata); ok {
+		if tags := md.Tags(); len(tags) > 0 {
+			i.Tags = tags
+		}
+	}
+
+	if i.Frames <= 0 {
+		// the header does not tell the length, such as for streams
+		i.Path = pathDecode
+		if i.Frames, err = countFrames(dec, i.Format.NumChannels); err != nil {
+			return nil, fmt.Errorf("decoding: %w", err)
+		}
+	}
+	if i.Format.SampleRate > 0 {
+		i.Duration = afmt.NumFramesToDuration(i.Format.SampleRate, i.Frames).Seconds()
+	}
+	return i, nil
+}
+
+// countFrames reads r to the end and returns the number of frames read.
+func countFrames(r aio.SampleReader, numChannels int) (int, error) {
+	buf := make([]float32, 4096*max(numChannels, 1))
+	samples := 0
+	for {
+		n, err := r.ReadSamples(buf)
+		samples += n
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return samples / max(numChannels, 1), nil
+}
+
+// loops returns the loops of the formats that store them.
+func loops(dec codec.Decoder) []loop {
+	switch d := dec.(type) {
+	case *avr.Decoder:
+		if d.Loop() {
+			return []loop{{Start: d.LoopStart(), End: d.LoopEnd(), Count: -1}}
+		}
+	case *svx.Decoder:
+		// the loop follows the one-shot part and repeats until the note is released
+		if d.LoopLen() > 0 {
+			return []loop{{Start: d.OneShotLen(), End: d.OneShotLen() + d.LoopLen(), Count: -1}}
+		}
+	case *voc.Decoder:
+		var ls []loop
+		for _, l := range d.Loops() {
+			ls = append(ls, loop{Start: l.Start, End: l.End, Count: l.Count})
+		}
+		return ls
+	case *aiff.Decoder:
+		inst := d.Instrument()
+		if inst == nil {
+			return nil
+		}
+		var ls []loop
+		for _, l := range []struct {
+			name string
+			loop aiff.Loop
+		}{{"sustain", inst.SustainLoop}, {"release", inst.ReleaseLoop}} {
+			if start, end, ok := d.LoopRange(l.loop); ok {
+				ls = append(ls, loop{Name: l.name, Start: start, End: end, Count: -1})
+			}
+		}
+		return ls
+	}
+	return nil
+}
+
+// printText prints i in a human-readable form.
+func printText(w io.Writer, i *info) {
+	if i.File != "" {
+		fmt.Fprintf(w, "File:          %s\n", i.File)
+	}
+	fmt.Fprintf(w, "Codec:         %s\n", i.Codec)
+	fmt.Fprintf(w, "Sample rate:   %v\n", i.Format.SampleRate)
+	fmt.Fprintf(w, "Channels:      %d\n", i.Format.NumChannels)
+	fmt.Fprintf(w, "Sample format: %v\n", i.SampleFormat)
+	fmt.Fprintf(w, "Frames:        %d (from the %s)\n", i.Frames, i.Path)
+	fmt.Fprintf(w, "Duration:      %v\n", time.Duration(i.Duration*float64(time.Second)).Round(time.Millisecond))
+	if i.Bitrate > 0 {
+		fmt.Fprintf(w, "Bitrate:       %s\n", afmt.FormatBitRate(i.Bitrate))
+	}
+	for _, l := range i.Loops {
+		name := "Loop:"
+		if l.Name != "" {
+			name = "Loop (" + l.Name + "):"
+		}
+		count := "endless"
+		switch {
+		case l.Count > 0:
+			count = fmt.Sprintf("%d times", l.Count)
+		case l.Count == 0:
+			count = "repeat count not stored"
+		}
+		fmt.Fprintf(w, "%-15s%d-%d (%s)\n", name, l.Start, l.End, count)
+	}
+	if len(i.Tags) > 0 {
+		fmt.Fprintln(w, "Tags:")
+		keys := make([]string, 0, len(i.Tags))
+		for k := range i.Tags {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "  %s: %s\n", k, strings.Join(i.Tags[k], ", "))
+		}
+	}
+}
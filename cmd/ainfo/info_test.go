@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec/avr"
+	"github.com/MatusOllah/resona/codec/qoa"
+	"github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+var (
+	testFormat = afmt.Format{SampleRate: 8 * freq.KiloHertz, NumChannels: 2}
+	int16LE    = afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}
+)
+
+// encode writes a second of silence with the encoder newEncoder creates and returns the encoded file.
+func encode(t *testing.T, newEncoder func(ws io.WriteSeeker) (aio.SampleWriteCloser, error)) []byte {
+	t.Helper()
+	ws := &testutil.WriteSeeker{}
+	w, err := newEncoder(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteSamples(make([]float32, 8000*2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return ws.Bytes()
+}
+
+func newWAVEncoder(ws io.WriteSeeker) (aio.SampleWriteCloser, error) {
+	return wav.NewEncoder(ws, testFormat, int16LE, wav.FormatInt)
+}
+
+func TestInspectHeader(t *testing.T) {
+	i, err := inspect(bytes.NewReader(encode(t, newWAVEncoder)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &info{Codec: "wav", Format: testFormat, SampleFormat: int16LE, Frames: 8000, Duration: 1, Bitrate: 256000, Path: pathHeader}
+	if i.Codec != want.Codec || i.Format != want.Format || i.SampleFormat != want.SampleFormat ||
+		i.Frames != want.Frames || i.Duration != want.Duration || i.Bitrate != want.Bitrate || i.Path != want.Path {
+		t.Errorf("got %+v, want %+v", i, want)
+	}
+	if len(i.Loops) != 0 {
+		t.Errorf("expected no loops, got %v", i.Loops)
+	}
+}
+
+func TestInspectDecode(t *testing.T) {
+	// a streamed QOA file does not store its length
+	i, err := inspect(bytes.NewReader(encode(t, func(ws io.WriteSeeker) (aio.SampleWriteCloser, error) {
+		return qoa.NewStreamEncoder(ws, testFormat)
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.Path != pathDecode {
+		t.Errorf("expected the length to be found by decoding, got %q", i.Path)
+	}
+	if i.Frames != 8000 {
+		t.Errorf("expected 8000 frames, got %d", i.Frames)
+	}
+}
+
+func TestInspectLoops(t *testing.T) {
+	int16BE := afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.BigEndian}
+	i, err := inspect(bytes.NewReader(encode(t, func(ws io.WriteSeeker) (aio.SampleWriteCloser, error) {
+		return avr.NewEncoder(ws, testFormat, int16BE, avr.WithLoop(1000, 2000))
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (loop{Start: 1000, End: 2000, Count: -1}); len(i.Loops) != 1 || i.Loops[0] != want {
+		t.Errorf("expected loops [%+v], got %+v", want, i.Loops)
+	}
+
+	var text strings.Builder
+	printText(&text, i)
+	if want := "Loop:          1000-2000 (endless)\n"; !strings.Contains(text.String(), want) {
+		t.Errorf("expected the text to contain %q, got:\n%s", want, text.String())
+	}
+}
+
+func TestInspectJSON(t *testing.T) {
+	i, err := inspect(bytes.NewReader(encode(t, newWAVEncoder)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(i)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]any{
+		"codec":            "wav",
+		"sample_format":    "int16le",
+		"frames":           8000.0,
+		"duration_seconds": 1.0,
+		"path":             "header",
+	} {
+		if doc[key] != want {
+			t.Errorf("expected %q to be %v, got %v", key, want, doc[key])
+		}
+	}
+
+	// the document unmarshals back into the types it was made of
+	var back info
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Format != testFormat || back.SampleFormat != int16LE {
+		t.Errorf("expected %v and %v back, got %v and %v", testFormat, int16LE, back.Format, back.SampleFormat)
+	}
+}
+
+func TestInspectUnknownFormat(t *testing.T) {
+	if _, err := inspect(strings.NewReader("definitely not audio")); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,76 @@
+// Command ainfo prints the format, length, loops and tags of audio files, as resona's decoders see them.
+//
+// With -json, it prints a JSON document per file, in which the sample rate is in nanohertz, as freq.Frequency marshals.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/MatusOllah/resona/codec/aiff"
+	_ "github.com/MatusOllah/resona/codec/au"
+	_ "github.com/MatusOllah/resona/codec/avr"
+	_ "github.com/MatusOllah/resona/codec/flac"
+	_ "github.com/MatusOllah/resona/codec/oggvorbis"
+	_ "github.com/MatusOllah/resona/codec/qoa"
+	_ "github.com/MatusOllah/resona/codec/svx"
+	_ "github.com/MatusOllah/resona/codec/voc"
+	_ "github.com/MatusOllah/resona/codec/wav"
+	_ "github.com/MatusOllah/resona/codec/wavpack"
+)
+
+func main() {
+	jsonOut := flag.Bool("json", false, "print a JSON document per file instead of text")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-json] <audio file>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	failed := false
+	for n, name := range flag.Args() {
+		i, err := inspectFile(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", name, err)
+			failed = true
+			continue
+		}
+		if *jsonOut {
+			if err := enc.Encode(i); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", name, err)
+				failed = true
+			}
+			continue
+		}
+		if n > 0 {
+			fmt.Println()
+		}
+		printText(os.Stdout, i)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// inspectFile inspects the audio file with the given name.
+func inspectFile(name string) (*info, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	i, err := inspect(f)
+	if err != nil {
+		return nil, err
+	}
+	i.File = name
+	return i, nil
+}
@@ -0,0 +1,118 @@
+// Command arec records audio from an input device to an audio file, showing the level while it records:
+//
+//	arec -duration 10s -rate 44100 -channels 1 voice.wav
+//	arec -monitor -bit-depth 24 take1.aiff
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/capture"
+	_ "github.com/MatusOllah/resona/capture/driver/ffmpeg"
+	"github.com/MatusOllah/resona/playback"
+	_ "github.com/MatusOllah/resona/playback/driver/oto"
+)
+
+// monitorBufferDuration is the most the monitor lags behind the input before the oldest audio is dropped.
+const monitorBufferDuration = 200 * time.Millisecond
+
+func main() {
+	cfg, err := parseArgs(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	opts := []capture.Option{capture.WithDriver(cfg.driver)}
+	if cfg.device != "" {
+		opts = append(opts, capture.WithDevice(cfg.device))
+	}
+	r, err := capture.NewReader(cfg.format, opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting capture: %v\n", err)
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	// the monitor is set up first, so that failing to do so does not leave an unfinished file behind
+	var monitor *monitorBuffer
+	if cfg.monitor {
+		ctx, err := playback.NewContext(cfg.format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating playback context for monitoring: %v\n", err)
+			os.Exit(1)
+		}
+		defer ctx.Close()
+		frames := afmt.DurationToNumFrames(cfg.format.SampleRate, monitorBufferDuration)
+		monitor = newMonitorBuffer(frames*cfg.format.NumChannels, cfg.format.NumChannels)
+		defer monitor.Close()
+		ctx.NewPlayer(monitor).Play()
+	}
+
+	f, err := os.Create(cfg.output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	w, err := cfg.encoder.New(f, cfg.format, cfg.sampleFmt)
+	if err != nil {
+		f.Close()
+		os.Remove(cfg.output) // do not leave an empty file behind
+		fmt.Fprintf(os.Stderr, "Error creating %s encoder: %v\n", cfg.encoder.Name, err)
+		os.Exit(1)
+	}
+
+	rec := newRecorder(r, w, cfg.format, cfg.duration)
+	if monitor != nil {
+		rec.monitor = monitor
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- rec.Run() }()
+
+	// Ctrl+C ends the recording cleanly; closing the capture reader unblocks the read in progress
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	progress := func() {
+		peak, rms := rec.Level()
+		fmt.Fprintf(os.Stderr, "\rRecording... %v, peak %5.1f dBFS, RMS %5.1f dBFS   ", rec.Recorded().Truncate(100*time.Millisecond), peak, rms)
+	}
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for recording := true; recording; {
+		select {
+		case <-interrupt:
+			rec.Stop()
+			r.Close()
+		case <-ticker.C:
+			progress()
+		case err = <-done:
+			recording = false
+		}
+	}
+	progress()
+	fmt.Fprintln(os.Stderr)
+
+	if dropped := r.Dropped(); dropped > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d samples were dropped because the recording fell behind\n", dropped)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Saved %s\n", cfg.output)
+}
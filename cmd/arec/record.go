@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/capture"
+	"github.com/MatusOllah/resona/dsp/meter"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/encoders"
+)
+
+// config represents the settings parsed from the command line.
+type config struct {
+	output    string
+	encoder   *encoders.Encoder
+	sampleFmt afmt.SampleFormat
+
+	format   afmt.Format
+	duration time.Duration // 0 records until interrupted
+	driver   string        // the capture driver, or "" for the default one
+	device   string        // the input device, or "" for the default one
+	monitor  bool
+}
+
+// parseArgs parses the command line arguments, without the program name, and validates their combination.
+// The usage is printed to stderr on errors.
+func parseArgs(args []string, stderr io.Writer) (*config, error) {
+	cfg := &config{}
+	var to string
+	var rate, bitDepth int
+	var float bool
+
+	fs := flag.NewFlagSet("arec", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&to, "to", "", "output format, one of "+strings.Join(encoders.Names(), ", ")+" (default: inferred from the output file name)")
+	fs.IntVar(&rate, "rate", 48000, "sample rate to record at in Hz")
+	fs.IntVar(&cfg.format.NumChannels, "channels", 2, "number of channels to record")
+	fs.IntVar(&bitDepth, "bit-depth", 0, "bit depth of the output samples (default: 16, or the first one the output format can store as floating-point with -float)")
+	fs.BoolVar(&float, "float", false, "write floating-point samples")
+	fs.DurationVar(&cfg.duration, "duration", 0, "stop after recording this much audio, such as 10s (default: record until interrupted with Ctrl+C)")
+	fs.StringVar(&cfg.driver, "driver", "", "capture driver, one of "+strings.Join(capture.Drivers(), ", ")+" (default: the first one)")
+	fs.StringVar(&cfg.device, "device", "", "name of the input device, as understood by the driver (default: the default input device)")
+	fs.BoolVar(&cfg.monitor, "monitor", false, "play the input back while recording it")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: arec [flags] <output file>\n\n")
+		fmt.Fprintf(fs.Output(), "Records from an input device to an audio file until interrupted or for the given duration.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return nil, errors.New("expected exactly one output file")
+	}
+	cfg.output = fs.Arg(0)
+
+	if rate <= 0 || cfg.format.NumChannels <= 0 {
+		return nil, errors.New("invalid sample rate or number of channels")
+	}
+	cfg.format.SampleRate = freq.Frequency(rate) * freq.Hertz
+	if cfg.duration < 0 {
+		return nil, fmt.Errorf("invalid duration %v", cfg.duration)
+	}
+
+	if to == "" {
+		var err error
+		if to, err = encoders.FormatFromName(cfg.output); err != nil {
+			return nil, fmt.Errorf("%w (use -to)", err)
+		}
+	}
+	var err error
+	if cfg.encoder, err = encoders.Lookup(to); err != nil {
+		return nil, err
+	}
+	if cfg.sampleFmt, err = cfg.encoder.SampleFormat(bitDepth, float, afmt.SampleFormat{}); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// state is the state of a [recorder].
+type state int32
+
+const (
+	stateRecording state = iota
+	stateStopping        // a stop was requested, so the recording ends after the current read
+	stateDone            // the input ended or the duration was reached, and the output is finalized
+)
+
+// recorder copies the captured audio to the output, measuring its level on the way.
+//
+// It records until it is stopped, the input ends, such as when a capture reader is closed,
+// or the duration is reached. Either way, the output is closed at the end, so that the encoder
+// finalizes the file.
+type recorder struct {
+	meter   *meter.Meter
+	w       aio.SampleWriteCloser
+	monitor aio.SampleWriter // nil if the input is not monitored
+	format  afmt.Format
+	limit   int64 // in samples, or 0 if there is none
+
+	state   atomic.Int32 // state
+	written atomic.Int64 // samples
+}
+
+// newRecorder creates a new [recorder] of the audio of the given format read from r to w.
+func newRecorder(r aio.SampleReader, w aio.SampleWriteCloser, format afmt.Format, duration time.Duration) *recorder {
+	rec := &recorder{
+		meter:  meter.NewMeter(r, format),
+		w:      w,
+		format: format,
+	}
+	if duration > 0 {
+		rec.limit = int64(afmt.DurationToNumFrames(format.SampleRate, duration) * format.NumChannels)
+	}
+	return rec
+}
+
+// State returns the state of the recording.
+func (rec *recorder) State() state {
+	return state(rec.state.Load())
+}
+
+// Stop makes the recording end after the read in progress, if it has not ended already.
+// A read that blocks until there is more input, like that of a capture reader, must be unblocked separately,
+// such as by closing the reader.
+func (rec *recorder) Stop() {
+	rec.state.CompareAndSwap(int32(stateRecording), int32(stateStopping))
+}
+
+// Recorded returns the duration recorded so far.
+func (rec *recorder) Recorded() time.Duration {
+	return afmt.NumFramesToDuration(rec.format.SampleRate, int(rec.written.Load())/rec.format.NumChannels)
+}
+
+// Level returns the highest held peak and RMS level of the channels in dBFS.
+func (rec *recorder) Level() (peak, rms float64) {
+	peak, rms = meterFloor, meterFloor
+	for ch := range rec.format.NumChannels {
+		peak = max(peak, rec.meter.PeakDB(ch))
+		rms = max(rms, rec.meter.RMSDB(ch))
+	}
+	return peak, rms
+}
+
+// meterFloor is the lowest level shown, so that silence does not show as -Inf.
+const meterFloor = -99.9
+
+// Run records until the recording ends and finalizes the output. It returns the first error
+// of the input or output; the output is closed regardless.
+func (rec *recorder) Run() error {
+	buf := make([]float32, 1024*rec.format.NumChannels)
+	var err error
+	for rec.State() == stateRecording {
+		p := buf
+		if rec.limit > 0 {
+			left := rec.limit - rec.written.Load()
+			if left <= 0 {
+				break
+			}
+			p = p[:min(int64(len(p)), left)]
+		}
+
+		n, readErr := rec.meter.ReadSamples(p)
+		if n > 0 {
+			if _, err = rec.w.WriteSamples(p[:n]); err != nil {
+				break
+			}
+			rec.written.Add(int64(n))
+			if rec.monitor != nil {
+				rec.monitor.WriteSamples(p[:n]) // never blocks, so the monitor cannot hold up the recording
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			err = readErr
+			break
+		}
+	}
+	rec.state.Store(int32(stateDone))
+	if closeErr := rec.w.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// monitorBuffer passes the recorded audio on to a player. Writes never block; when the player falls behind,
+// the oldest frames are dropped, so that the delay of the monitor does not build up. Reads return nothing
+// while the buffer is empty, which the playback context fills with silence.
+type monitorBuffer struct {
+	mu          sync.Mutex
+	buf         []float32
+	numChannels int
+	start       int // index of the oldest sample
+	n           int // number of samples held
+	closed      bool
+}
+
+func newMonitorBuffer(size, numChannels int) *monitorBuffer {
+	return &monitorBuffer{
+		buf:         make([]float32, max(size-size%numChannels, numChannels)),
+		numChannels: numChannels,
+	}
+}
+
+// WriteSamples implements the aio.SampleWriter interface.
+func (b *monitorBuffer) WriteSamples(p []float32) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, io.ErrClosedPipe
+	}
+	written := len(p)
+	if len(p) > len(b.buf) {
+		p = p[len(p)-len(b.buf):]
+	}
+	if drop := b.n + len(p) - len(b.buf); drop > 0 {
+		b.start = (b.start + drop) % len(b.buf)
+		b.n -= drop
+	}
+	end := (b.start + b.n) % len(b.buf)
+	m := copy(b.buf[end:], p)
+	copy(b.buf, p[m:])
+	b.n += len(p)
+	return written, nil
+}
+
+// ReadSamples implements the aio.SampleReader interface. It reads whole frames only,
+// and returns io.EOF once the buffer is closed and empty.
+func (b *monitorBuffer) ReadSamples(p []float32) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := min(len(p), b.n)
+	n -= n % b.numChannels
+	if n == 0 {
+		if b.closed && b.n == 0 {
+			return 0, io.EOF
+		}
+		return 0, nil
+	}
+	m := copy(p[:n], b.buf[b.start:])
+	copy(p[m:n], b.buf)
+	b.start = (b.start + n) % len(b.buf)
+	b.n -= n
+	return n, nil
+}
+
+// Close makes the player finish once it has played the rest of the buffer.
+func (b *monitorBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
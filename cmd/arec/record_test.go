@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	_ "github.com/MatusOllah/resona/codec/aiff"
+	_ "github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+var format = afmt.Format{SampleRate: 8 * freq.KiloHertz, NumChannels: 2}
+
+// fakeCapture captures a constant until it is closed, which makes a pending read return io.EOF like a capture reader.
+type fakeCapture struct {
+	v      float32
+	reads  chan struct{} // receives every read, if not nil
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *fakeCapture) ReadSamples(p []float32) (int, error) {
+	if c.reads != nil {
+		c.reads <- struct{}{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, io.EOF
+	}
+	for i := range p {
+		p[i] = c.v
+	}
+	return len(p), nil
+}
+
+func (c *fakeCapture) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+// newWAVWriter creates a 16-bit WAV encoder writing to ws.
+func newWAVWriter(t *testing.T, ws io.WriteSeeker) aio.SampleWriteCloser {
+	t.Helper()
+	cfg, err := parseArgs([]string{"out.wav"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := cfg.encoder.New(ws, format, cfg.sampleFmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// decodeRecording decodes the recorded file, which must have valid header sizes.
+func decodeRecording(t *testing.T, b []byte) (codec.Decoder, []float32) {
+	t.Helper()
+	dec, _, err := codec.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dec, p
+}
+
+func TestParseArgs(t *testing.T) {
+	cfg, err := parseArgs([]string{"-rate", "44100", "-channels", "1", "-duration", "2s", "-monitor", "-bit-depth", "24", "take.aif"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.output != "take.aif" || cfg.encoder.Name != "aiff" {
+		t.Errorf("expected take.aif as aiff, got %s as %s", cfg.output, cfg.encoder.Name)
+	}
+	if want := (afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 1}); cfg.format != want {
+		t.Errorf("expected format %v, got %v", want, cfg.format)
+	}
+	if cfg.duration != 2*time.Second || !cfg.monitor || cfg.sampleFmt.BitDepth != 24 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	cfg, err = parseArgs([]string{"-to", "au", "-float", "recording"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.encoder.Name != "au" || cfg.sampleFmt.Encoding != afmt.SampleEncodingFloat {
+		t.Errorf("expected floating-point au, got %s with %v", cfg.encoder.Name, cfg.sampleFmt)
+	}
+	if want := (afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}); cfg.format != want {
+		t.Errorf("expected the default format %v, got %v", want, cfg.format)
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "exactly one output file"},
+		{[]string{"a.wav", "b.wav"}, "exactly one output file"},
+		{[]string{"out.xyz"}, "use -to"},
+		{[]string{"-to", "mp4", "out"}, "unknown output format"},
+		{[]string{"-rate", "0", "out.wav"}, "invalid sample rate"},
+		{[]string{"-channels", "-1", "out.wav"}, "number of channels"},
+		{[]string{"-duration", "-1s", "out.wav"}, "invalid duration"},
+		{[]string{"-bit-depth", "24", "out.avr"}, "bit depths"},
+	} {
+		if _, err := parseArgs(tt.args, io.Discard); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: expected an error containing %q, got %v", tt.args, tt.want, err)
+		}
+	}
+}
+
+func TestRecorderDuration(t *testing.T) {
+	ws := &testutil.WriteSeeker{}
+	rec := newRecorder(&fakeCapture{v: 0.5}, newWAVWriter(t, ws), format, 250*time.Millisecond)
+	if err := rec.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if rec.State() != stateDone {
+		t.Errorf("expected the recording to be done, got state %d", rec.State())
+	}
+	if got := rec.Recorded(); got != 250*time.Millisecond {
+		t.Errorf("expected 250ms recorded, got %v", got)
+	}
+
+	dec, p := decodeRecording(t, ws.Bytes())
+	if dec.Len() != 2000 || len(p) != 2*2000 {
+		t.Errorf("expected 2000 frames in the header and the data, got %d and %d", dec.Len(), len(p)/2)
+	}
+	if !testutil.EqualWithinTolerance(p[len(p)-1], 0.5, 1e-4) {
+		t.Errorf("expected the captured samples, got %v", p[len(p)-1])
+	}
+
+	peak, rms := rec.Level()
+	if want := 20 * math.Log10(0.5); math.Abs(peak-want) > 0.01 || math.Abs(rms-want) > 0.01 {
+		t.Errorf("expected a peak and RMS level of %.2f dBFS, got %.2f and %.2f dBFS", want, peak, rms)
+	}
+}
+
+func TestRecorderStop(t *testing.T) {
+	ws := &testutil.WriteSeeker{}
+	c := &fakeCapture{v: 0.25, reads: make(chan struct{})}
+	rec := newRecorder(c, newWAVWriter(t, ws), format, 0)
+	done := make(chan error, 1)
+	go func() { done <- rec.Run() }()
+
+	for range 3 {
+		<-c.reads
+	}
+	rec.Stop()
+	if s := rec.State(); s != stateStopping && s != stateDone {
+		t.Errorf("expected the recording to be stopping, got state %d", s)
+	}
+	go func() {
+		for range c.reads {
+		}
+	}()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	close(c.reads)
+
+	// the reads in progress when stopping are kept, and the header covers them
+	dec, p := decodeRecording(t, ws.Bytes())
+	if dec.Len() < 3*1024 || dec.Len() > 4*1024 || len(p) != 2*dec.Len() {
+		t.Errorf("expected 3 or 4 reads of 1024 frames, got %d frames in the header and %d in the data", dec.Len(), len(p)/2)
+	}
+	rec.Stop() // does nothing once done
+	if rec.State() != stateDone {
+		t.Errorf("expected the recording to stay done, got state %d", rec.State())
+	}
+}
+
+func TestRecorderCaptureClosed(t *testing.T) {
+	ws := &testutil.WriteSeeker{}
+	c := &fakeCapture{v: 0.25}
+	c.Close()
+	rec := newRecorder(c, newWAVWriter(t, ws), format, 0)
+	if err := rec.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if dec, _ := decodeRecording(t, ws.Bytes()); dec.Len() != 0 {
+		t.Errorf("expected an empty recording, got %d frames", dec.Len())
+	}
+}
+
+// failingWriter fails every write, like a full disk.
+type failingWriter struct {
+	closed bool
+}
+
+func (w *failingWriter) WriteSamples(p []float32) (int, error) { return 0, errors.New("disk full") }
+func (w *failingWriter) Close() error                          { w.closed = true; return nil }
+
+func TestRecorderWriteError(t *testing.T) {
+	w := &failingWriter{}
+	rec := newRecorder(&fakeCapture{v: 0.25}, w, format, 0)
+	if err := rec.Run(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("expected the write error, got %v", err)
+	}
+	if !w.closed {
+		t.Error("expected the output to be closed")
+	}
+}
+
+func TestRecorderMonitor(t *testing.T) {
+	ws := &testutil.WriteSeeker{}
+	rec := newRecorder(&fakeCapture{v: 0.5}, newWAVWriter(t, ws), format, 100*time.Millisecond)
+	monitor := newMonitorBuffer(2*300, 2)
+	rec.monitor = monitor
+	if err := rec.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the monitor keeps only the latest audio, as nothing played it meanwhile
+	p := make([]float32, 2*1000)
+	n, err := monitor.ReadSamples(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2*300 || p[0] != 0.5 {
+		t.Errorf("expected the last 300 frames, got %d samples starting with %v", n, p[0])
+	}
+	if n, err := monitor.ReadSamples(p); n != 0 || err != nil {
+		t.Errorf("expected nothing while empty, got %d, %v", n, err)
+	}
+	monitor.Close()
+	if _, err := monitor.ReadSamples(p); err != io.EOF {
+		t.Errorf("expected io.EOF once closed, got %v", err)
+	}
+}
+
+func TestMonitorBuffer(t *testing.T) {
+	b := newMonitorBuffer(6, 2)
+	b.WriteSamples([]float32{1, 1, 2, 2})
+	b.WriteSamples([]float32{3, 3, 4, 4}) // drops the oldest frame
+	p := make([]float32, 3)
+	if n, _ := b.ReadSamples(p); n != 2 || p[0] != 2 {
+		t.Errorf("expected one whole frame of 2, got %d samples of %v", n, p[:n])
+	}
+	p = make([]float32, 8)
+	if n, _ := b.ReadSamples(p); n != 4 || p[0] != 3 || p[2] != 4 {
+		t.Errorf("expected the frames 3 and 4, got %v", p[:n])
+	}
+}
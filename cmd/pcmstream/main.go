@@ -0,0 +1,64 @@
+// Command pcmstream decodes an audio file, or headerless PCM, and writes it to stdout as headerless PCM,
+// resampling and mapping the channels on the way if asked to. It bridges resona and tools such as FFmpeg or aplay:
+//
+//	pcmstream -format s16le -rate 48000 song.flac | aplay -f S16_LE -r 48000 -c 2
+//	ffmpeg -i song.mp4 -f f32le - | pcmstream -raw-input -in-format f32le -in-rate 44100 -format s16le > song.pcm
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	_ "github.com/MatusOllah/resona/codec/aiff"
+	_ "github.com/MatusOllah/resona/codec/au"
+	_ "github.com/MatusOllah/resona/codec/avr"
+	_ "github.com/MatusOllah/resona/codec/flac"
+	_ "github.com/MatusOllah/resona/codec/oggvorbis"
+	_ "github.com/MatusOllah/resona/codec/qoa"
+	_ "github.com/MatusOllah/resona/codec/svx"
+	_ "github.com/MatusOllah/resona/codec/voc"
+	_ "github.com/MatusOllah/resona/codec/wav"
+	_ "github.com/MatusOllah/resona/codec/wavpack"
+)
+
+func main() {
+	cfg, err := parseArgs(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	// stdin is not seekable even though it is an *os.File, so it is hidden behind a plain io.Reader
+	var in io.Reader = struct{ io.Reader }{os.Stdin}
+	if cfg.input != "" {
+		f, err := os.Open(cfg.input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening input file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	p, err := newPipeline(cfg, in, out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	err = p.run()
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
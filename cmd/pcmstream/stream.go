@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/codec/rawpcm"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/fmtconv"
+	"github.com/MatusOllah/resona/resample"
+)
+
+// config represents the settings parsed from the command line.
+type config struct {
+	input string // the input file, or "" to read stdin
+
+	outFormat afmt.SampleFormat
+	rate      freq.Frequency // 0 keeps the sample rate of the input
+	channels  int            // 0 keeps the number of channels of the input
+	duration  time.Duration  // 0 streams the whole input
+
+	rawInput   bool
+	inFormat   afmt.SampleFormat
+	inRate     freq.Frequency
+	inChannels int
+}
+
+// sampleFormatValue is a [flag.Value] that parses a sample format with [afmt.ParseSampleFormat].
+type sampleFormatValue struct {
+	f *afmt.SampleFormat
+}
+
+func (v sampleFormatValue) String() string {
+	if v.f == nil {
+		return ""
+	}
+	return v.f.String()
+}
+
+func (v sampleFormatValue) Set(s string) error {
+	f, err := afmt.ParseSampleFormat(s)
+	if err != nil {
+		return err
+	}
+	if f.BitDepth > 8 && f.Endian == nil {
+		f.Endian = binary.LittleEndian
+	}
+	*v.f = f
+	return nil
+}
+
+// defaultSampleFormat is the default sample format of the output and the raw input.
+var defaultSampleFormat = afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}
+
+// parseArgs parses the command line arguments, without the program name, and validates their combination.
+// The usage is printed to stderr on errors.
+func parseArgs(args []string, stderr io.Writer) (*config, error) {
+	cfg := &config{outFormat: defaultSampleFormat, inFormat: defaultSampleFormat}
+	var rate, inRate int
+
+	fs := flag.NewFlagSet("pcmstream", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Var(sampleFormatValue{&cfg.outFormat}, "format", "sample format of the output, such as int16le, float32le or s24be; multi-byte samples without an endianness are little-endian")
+	fs.IntVar(&rate, "rate", 0, "sample rate of the output in Hz, resampling the input if it differs (default: the sample rate of the input)")
+	fs.IntVar(&cfg.channels, "channels", 0, "number of channels of the output, mapping the channels of the input if it differs (default: the number of channels of the input)")
+	fs.DurationVar(&cfg.duration, "duration", 0, "stop after streaming this much audio, such as 10s (default: stream the whole input)")
+	fs.BoolVar(&cfg.rawInput, "raw-input", false, "read headerless PCM, described by the -in-* flags, instead of an audio file")
+	fs.Var(sampleFormatValue{&cfg.inFormat}, "in-format", "sample format of the raw input")
+	fs.IntVar(&inRate, "in-rate", 44100, "sample rate of the raw input in Hz")
+	fs.IntVar(&cfg.inChannels, "in-channels", 2, "number of channels of the raw input")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: pcmstream [flags] [input file]\n\n")
+		fmt.Fprintf(fs.Output(), "Decodes the input file, or stdin, and writes it to stdout as headerless PCM.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		if fs.Arg(0) != "-" {
+			cfg.input = fs.Arg(0)
+		}
+	default:
+		fs.Usage()
+		return nil, errors.New("too many arguments")
+	}
+
+	var inFlags []string
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "in-format", "in-rate", "in-channels":
+			inFlags = append(inFlags, "-"+f.Name)
+		}
+	})
+	if len(inFlags) > 0 && !cfg.rawInput {
+		return nil, fmt.Errorf("flags only valid with -raw-input: %s", strings.Join(inFlags, ", "))
+	}
+	if rate < 0 || cfg.channels < 0 {
+		return nil, errors.New("invalid output sample rate or number of channels")
+	}
+	if cfg.rawInput && (inRate <= 0 || cfg.inChannels <= 0) {
+		return nil, errors.New("invalid input sample rate or number of channels")
+	}
+	if cfg.duration < 0 {
+		return nil, fmt.Errorf("invalid duration %v", cfg.duration)
+	}
+	cfg.rate = freq.Frequency(rate) * freq.Hertz
+	cfg.inRate = freq.Frequency(inRate) * freq.Hertz
+	return cfg, nil
+}
+
+// pipeline represents the assembled stream from the input to the output.
+type pipeline struct {
+	in     codec.Decoder
+	r      aio.SampleReader // the input, converted and limited
+	rs     resample.Resampler
+	w      *rawpcm.Encoder
+	format afmt.Format // the format of the output
+}
+
+// newPipeline assembles the stream that decodes in and writes it to out as configured.
+func newPipeline(cfg *config, in io.Reader, out io.Writer) (*pipeline, error) {
+	p := &pipeline{}
+	var err error
+	if cfg.rawInput {
+		p.in, err = rawpcm.NewDecoder(in, afmt.Format{SampleRate: cfg.inRate, NumChannels: cfg.inChannels}, cfg.inFormat)
+	} else {
+		p.in, _, err = codec.Decode(in)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding input: %w", err)
+	}
+
+	inFormat := p.in.Format()
+	p.format = inFormat
+	if cfg.rate != 0 {
+		p.format.SampleRate = cfg.rate
+	}
+	if cfg.channels != 0 {
+		p.format.NumChannels = cfg.channels
+	}
+	p.r, p.rs, err = fmtconv.Convert(p.in, inFormat, p.format, resample.QualityHigh)
+	if err != nil {
+		return nil, fmt.Errorf("creating resampler: %w", err)
+	}
+	if cfg.duration > 0 {
+		frames := afmt.DurationToNumFrames(p.format.SampleRate, cfg.duration)
+		p.r = aio.LimitReader(p.r, int64(frames*p.format.NumChannels))
+	}
+
+	p.w, err = rawpcm.NewEncoder(out, p.format, cfg.outFormat)
+	if err != nil {
+		p.close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// run streams the whole input to the output.
+func (p *pipeline) run() error {
+	_, err := aio.Copy(p.w, p.r)
+	if closeErr := p.close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (p *pipeline) close() error {
+	if p.w != nil {
+		p.w.Close()
+	}
+	if p.rs != nil {
+		return p.rs.Close()
+	}
+	return nil
+}
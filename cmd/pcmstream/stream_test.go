@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+var (
+	int16LE   = afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}
+	float32LE = afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingFloat, Endian: binary.LittleEndian}
+	int24BE   = afmt.SampleFormat{BitDepth: 24, Encoding: afmt.SampleEncodingInt, Endian: binary.BigEndian}
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want config
+	}{
+		{"Defaults", nil, config{outFormat: int16LE, inFormat: int16LE}},
+		{"Stdin", []string{"-"}, config{outFormat: int16LE, inFormat: int16LE}},
+		{"Output", []string{"-format", "float32le", "-rate", "48000", "-channels", "1", "-duration", "2s", "in.flac"},
+			config{input: "in.flac", outFormat: float32LE, rate: 48 * freq.KiloHertz, channels: 1, duration: 2 * time.Second, inFormat: int16LE}},
+		{"FFmpegNames", []string{"-format", "s24be"}, config{outFormat: int24BE, inFormat: int16LE}},
+		{"NoEndianness", []string{"-format", "float32"}, config{outFormat: float32LE, inFormat: int16LE}},
+		{"RawInput", []string{"-raw-input", "-in-format", "f32le", "-in-rate", "8000", "-in-channels", "1"},
+			config{outFormat: int16LE, rawInput: true, inFormat: float32LE, inRate: 8 * freq.KiloHertz, inChannels: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseArgs(tt.args, io.Discard)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.want.rawInput {
+				// the defaults of the raw input do not matter then
+				cfg.inRate, cfg.inChannels = 0, 0
+			}
+			if *cfg != tt.want {
+				t.Errorf("got %+v, want %+v", *cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"InFlagsWithoutRawInput", []string{"-in-rate", "8000", "-in-channels", "1"}, "only valid with -raw-input: -in-channels, -in-rate"},
+		{"InvalidFormat", []string{"-format", "pcm16"}, "invalid sample format"},
+		{"TooManyArguments", []string{"a.wav", "b.wav"}, "too many arguments"},
+		{"NegativeDuration", []string{"-duration", "-1s"}, "invalid duration"},
+		{"NegativeChannels", []string{"-channels", "-2"}, "invalid output"},
+		{"ZeroInputRate", []string{"-raw-input", "-in-rate", "0"}, "invalid input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseArgs(tt.args, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// rawInt16 encodes samples in (-1, 1) as int16le.
+func rawInt16(samples []float32) []byte {
+	b := make([]byte, 2*len(samples))
+	for i, v := range samples {
+		binary.LittleEndian.PutUint16(b[2*i:], uint16(int16(v*32768)))
+	}
+	return b
+}
+
+// stream parses args and streams in through the pipeline.
+func stream(t *testing.T, args []string, in []byte) (*pipeline, []byte) {
+	t.Helper()
+	cfg, err := parseArgs(args, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	p, err := newPipeline(cfg, bytes.NewReader(in), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.run(); err != nil {
+		t.Fatal(err)
+	}
+	return p, out.Bytes()
+}
+
+func TestPipelineRawPassthrough(t *testing.T) {
+	in := rawInt16([]float32{0.5, -0.5, 0.25, -0.25, 0, 0.75})
+	p, out := stream(t, []string{"-raw-input", "-in-rate", "8000", "-in-channels", "2"}, in)
+	if want := (afmt.Format{SampleRate: 8 * freq.KiloHertz, NumChannels: 2}); p.format != want {
+		t.Errorf("expected format %v, got %v", want, p.format)
+	}
+	if p.rs != nil {
+		t.Error("expected no resampler")
+	}
+	if !bytes.Equal(out, in) {
+		t.Errorf("expected the input unchanged, got %v", out)
+	}
+}
+
+func TestPipelineConvert(t *testing.T) {
+	// a second of stereo at 8 kHz, with the channels in antiphase
+	samples := make([]float32, 2*8000)
+	for i := range 8000 {
+		v := float32(0.5 * math.Sin(2*math.Pi*440*float64(i)/8000))
+		samples[2*i], samples[2*i+1] = v, -v
+	}
+	p, out := stream(t, []string{"-raw-input", "-in-rate", "8000", "-format", "float32le", "-rate", "16000", "-channels", "1", "-duration", "500ms"}, rawInt16(samples))
+	if want := (afmt.Format{SampleRate: 16 * freq.KiloHertz, NumChannels: 1}); p.format != want {
+		t.Errorf("expected format %v, got %v", want, p.format)
+	}
+	if p.rs == nil {
+		t.Error("expected a resampler")
+	}
+	if want := 4 * 8000; len(out) != want {
+		t.Fatalf("expected %d bytes for half a second, got %d", want, len(out))
+	}
+	for i := 0; i < len(out); i += 4 {
+		if v := math.Float32frombits(binary.LittleEndian.Uint32(out[i:])); math.Abs(float64(v)) > 1e-3 {
+			t.Fatalf("expected the channels to cancel out, got %v at sample %d", v, i/4)
+		}
+	}
+}
+
+func TestPipelineContainerInput(t *testing.T) {
+	ws := &testutil.WriteSeeker{}
+	enc, err := wav.NewEncoder(ws, afmt.Format{SampleRate: 22050 * freq.Hertz, NumChannels: 1}, int16LE, wav.FormatInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := []float32{0.5, -0.5, 0.25}
+	if _, err := enc.WriteSamples(samples); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, out := stream(t, []string{"-channels", "2"}, ws.Bytes())
+	if want := (afmt.Format{SampleRate: 22050 * freq.Hertz, NumChannels: 2}); p.format != want {
+		t.Errorf("expected format %v, got %v", want, p.format)
+	}
+	if want := rawInt16([]float32{0.5, 0.5, -0.5, -0.5, 0.25, 0.25}); !bytes.Equal(out, want) {
+		t.Errorf("got %v, want %v", out, want)
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	cfg, err := parseArgs([]string{"-format", "int"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.rawInput, cfg.inRate, cfg.inChannels = true, 8*freq.KiloHertz, 1
+	if _, err := newPipeline(cfg, bytes.NewReader(rawInt16([]float32{0})), io.Discard); err == nil {
+		t.Error("expected an error for an output sample format without a bit depth")
+	}
+
+	cfg, err = parseArgs(nil, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newPipeline(cfg, strings.NewReader("not an audio file"), io.Discard); err == nil {
+		t.Error("expected an error decoding an unknown format")
+	}
+}
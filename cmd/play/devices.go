@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MatusOllah/resona/playback"
+)
+
+// checkFlags validates the combination of the driver and device flags.
+func checkFlags(driverName, device string, listDevices bool, buffer time.Duration) error {
+	drivers := playback.Drivers()
+	if !slices.Contains(drivers, driverName) {
+		if len(drivers) == 0 {
+			return fmt.Errorf("driver %q is not compiled in, and no driver is", driverName)
+		}
+		return fmt.Errorf("driver %q is not compiled in (available: %s)", driverName, strings.Join(drivers, ", "))
+	}
+	if listDevices && device != "" {
+		return errors.New("-device cannot be used with -list-devices")
+	}
+	if buffer < 0 {
+		return fmt.Errorf("invalid buffer duration %v", buffer)
+	}
+	if device == "" {
+		return nil
+	}
+	if err := playback.CheckDevice(driverName, device); err != nil {
+		return fmt.Errorf("invalid -device: %w", err)
+	}
+	return nil
+}
+
+// printDevices prints the devices of the driver with the given name, marking the default one.
+func printDevices(driverName string) error {
+	devices, err := playback.Devices(driverName)
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		mark := " "
+		if d.Default {
+			mark = "*"
+		}
+		fmt.Fprintf(os.Stdout, "%s %s\n", mark, d.ID)
+		if d.Name != "" {
+			fmt.Fprintf(os.Stdout, "      %s\n", d.Name)
+		}
+	}
+	return nil
+}
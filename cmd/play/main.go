@@ -1,69 +1,239 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
 	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
 	"github.com/MatusOllah/resona/audio"
 	"github.com/MatusOllah/resona/codec"
+	_ "github.com/MatusOllah/resona/codec/aiff"
 	_ "github.com/MatusOllah/resona/codec/au"
 	_ "github.com/MatusOllah/resona/codec/avr"
 	_ "github.com/MatusOllah/resona/codec/flac"
 	_ "github.com/MatusOllah/resona/codec/oggvorbis"
 	_ "github.com/MatusOllah/resona/codec/qoa"
 	_ "github.com/MatusOllah/resona/codec/svx"
+	_ "github.com/MatusOllah/resona/codec/voc"
 	_ "github.com/MatusOllah/resona/codec/wav"
+	_ "github.com/MatusOllah/resona/codec/wavpack"
+	"github.com/MatusOllah/resona/internal/term"
 	"github.com/MatusOllah/resona/playback"
 	_ "github.com/MatusOllah/resona/playback/driver/oto"
 )
 
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <audio file>\n", os.Args[0])
+	driverName := flag.String("driver", "oto", "playback driver, one of the compiled-in drivers listed below")
+	device := flag.String("device", "", "ID of the device to play to, as printed by -list-devices (default: the default device of the driver)")
+	listDevices := flag.Bool("list-devices", false, "print the devices of the driver and exit")
+	bufferDur := flag.Duration("buffer", 0, "buffer duration of the playback context, such as 50ms (default: the default buffer size)")
+	var normalize normalizeFlag
+	flag.Var(&normalize, "normalize", fmt.Sprintf("normalize the loudness of the files, to the given target in LUFS with -normalize=target (default: %v LUFS)", defaultTarget))
+	replayGain := flag.Bool("replaygain", false, "use the ReplayGain tags of the files instead of measuring their loudness where they have them; implies -normalize")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <audio file or .m3u playlist>...\n       %s [-driver name] -list-devices\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "Compiled-in drivers: %s\n", strings.Join(playback.Drivers(), ", "))
+	}
+	flag.Parse()
+	if err := checkFlags(*driverName, *device, *listDevices, *bufferDur); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	if *listDevices {
+		if flag.NArg() != 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		if err := printDevices(*driverName); err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing devices: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	f, err := os.Open(os.Args[1])
+	names, err := expandArgs(flag.Args())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading playlist: %v\n", err)
 		os.Exit(1)
 	}
-	defer f.Close()
-
-	dec, name, err := codec.Decode(f)
+	var prepare func(*track, afmt.Format) (func(aio.SampleReader) aio.SampleReader, error)
+	if normalize.enabled || *replayGain {
+		if !normalize.enabled {
+			normalize.Set("true")
+		}
+		n := &normalizer{target: normalize.target, replayGain: *replayGain, warn: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, "\nWarning: "+format, args...)
+		}}
+		prepare = n.prepare
+	}
+	pl, err := newPlaylist(names, openFile, func(name string, err error) {
+		fmt.Fprintf(os.Stderr, "\nError playing %s, skipping: %v\n", name, err)
+	}, prepare)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error decoding file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	format := pl.Format()
 
-	format := dec.Format()
-	fmt.Fprintf(os.Stderr, "Format: %s, %v, %d channels\n", name, format.SampleRate, format.NumChannels)
-
-	if bitrater, ok := dec.(codec.Bitrater); ok {
-		fmt.Fprintf(os.Stderr, "Bitrate: %d kbps\n", bitrater.Bitrate()/1000)
+	opts := []playback.ContextOption{playback.WithDriver(*driverName)}
+	if *device != "" {
+		opts = append(opts, playback.WithDevice(*device))
 	}
-
-	ctx, err := playback.NewContext(format, playback.WithDriver("oto"))
+	if *bufferDur > 0 {
+		opts = append(opts, playback.WithBufferDuration(*bufferDur))
+	}
+	ctx, err := playback.NewContext(format, opts...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating playback context: %v\n", err)
 		os.Exit(1)
 	}
 	defer ctx.Close()
 
-	go func() {
-		for {
-			pos, _ := dec.Seek(0, io.SeekCurrent)
-			fmt.Fprintf(os.Stderr, "\rPlaying... %v", afmt.NumFramesToDuration(format.SampleRate, int(pos)))
-			if int(pos) >= dec.Len() {
-				fmt.Println()
+	src := audio.NewSource(pl)
+	var playerOpts []playback.PlayerOption
+	if len(names) > 1 {
+		// the next file is opened while reading ahead, rather than while the driver waits
+		playerOpts = append(playerOpts, playback.WithPlayerBuffer(500*time.Millisecond))
+	}
+	player := ctx.NewPlayer(src, playerOpts...)
+	done := player.PlayWithDone()
+	fmt.Fprintf(os.Stderr, "Driver: %s, latency: %v\n", *driverName, ctx.Latency())
+
+	// keys stays nil if stdin is not a terminal, so that the controls are simply disabled
+	var keys chan term.Key
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err == nil {
+			defer term.Restore(fd, state)
+			keys = make(chan term.Key)
+			go term.ReadKeys(os.Stdin, keys)
+			fmt.Fprintln(os.Stderr, "Controls: space pause, left/right seek 5s, +/- volume, q quit")
+		}
+	}
+
+	const (
+		seekStep = 5 * time.Second
+		volStep  = 3.0 // dB
+		minVol   = -60.0
+		maxVol   = 12.0
+	)
+	volDB := 0.0
+	quit := false
+	seek := func(d time.Duration) {
+		pos, err := player.Position()
+		if err != nil {
+			return
+		}
+		t := pl.Track()
+		if t == nil {
+			return
+		}
+		pos += int64(d.Seconds() * format.SampleRate.Hertz())
+		player.Seek(min(max(pos, 0), int64(t.len)), io.SeekStart) // seeking to the end skips to the next track
+	}
+	setVolume := func(dB float64) {
+		volDB = min(max(dB, minVol), maxVol)
+		src.SetVolumeDB(volDB)
+	}
+	bindings := term.Bindings{
+		' ': func() {
+			if src.IsPaused() {
+				src.Resume()
+			} else {
+				src.Pause()
+			}
+		},
+		term.KeyRight: func() { seek(seekStep) },
+		term.KeyLeft:  func() { seek(-seekStep) },
+		'+':           func() { setVolume(volDB + volStep) },
+		'=':           func() { setVolume(volDB + volStep) }, // + without shift on most layouts
+		'-':           func() { setVolume(volDB - volStep) },
+		'q':           func() { quit = true },
+		'Q':           func() { quit = true },
+		term.KeyCtrlC: func() { quit = true }, // raw mode does not turn it into a signal
+	}
+
+	// report the position being heard, rather than the position of the decoder, which runs ahead by the buffers
+	var cur *track
+	var total time.Duration
+	progress := func() {
+		if t := pl.Track(); t != nil && t != cur {
+			if cur != nil {
+				fmt.Fprintln(os.Stderr)
+			}
+			cur = t
+			total = afmt.NumFramesToDuration(format.SampleRate, t.len)
+			printTrack(t, len(names))
+		}
+		pos, _ := player.PositionDur()
+		status := "Playing"
+		if src.IsPaused() {
+			status = "Paused "
+		}
+		fmt.Fprintf(os.Stderr, "\r%s... %v / %v, volume %+.0f dB   ", status, pos.Truncate(time.Millisecond), total, volDB)
+	}
+	progress()
+
+	// without raw mode, Ctrl+C is a signal, which stops the playback as cleanly as q does
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			fmt.Fprintf(os.Stderr, "\rPlaying... %v / %v, volume %+.0f dB   \n", total, total, volDB)
+			return
+		case <-interrupt:
+			fmt.Fprintln(os.Stderr)
+			return
+		case k, ok := <-keys:
+			if !ok {
+				keys = nil // stdin was closed
+				continue
+			}
+			bindings.Dispatch(k)
+			if quit {
+				fmt.Fprintln(os.Stderr)
 				return
 			}
+			progress()
+		case <-ticker.C:
+			progress()
 		}
-	}()
+	}
+}
 
-	src := audio.NewSource(dec)
-	player := ctx.NewPlayer(src)
-	player.PlayAndWait()
+// printTrack prints the information of a track as it starts playing.
+func printTrack(t *track, count int) {
+	if count > 1 {
+		fmt.Fprintf(os.Stderr, "Track %d/%d: %s\n", t.index+1, count, t.name)
+	}
+	format := t.dec.Format()
+	fmt.Fprintf(os.Stderr, "Format: %s, %v, %d channels\n", t.codec, format.SampleRate, format.NumChannels)
+
+	if md, ok := t.dec.(codec.Metadata); ok {
+		tags := md.Tags()
+		if title := tags["TITLE"]; len(title) > 0 {
+			fmt.Fprintf(os.Stderr, "Title: %s\n", strings.Join(title, ", "))
+		}
+		if artist := tags["ARTIST"]; len(artist) > 0 {
+			fmt.Fprintf(os.Stderr, "Artist: %s\n", strings.Join(artist, ", "))
+		}
+	}
+
+	if bps, ok := codec.Bitrate(t.dec); ok {
+		fmt.Fprintf(os.Stderr, "Bitrate: %s\n", afmt.FormatBitRate(bps))
+	}
 }
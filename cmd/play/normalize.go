@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/dsp/loudness"
+	"github.com/MatusOllah/resona/effect"
+)
+
+// defaultTarget is the loudness in LUFS that -normalize brings the files to without a target.
+const defaultTarget = -16.0
+
+// normalizeFlag is the value of -normalize, which can be given with or without a target.
+type normalizeFlag struct {
+	enabled bool
+	target  float64 // LUFS
+}
+
+func (f *normalizeFlag) String() string {
+	if f == nil || !f.enabled {
+		return "false"
+	}
+	return strconv.FormatFloat(f.target, 'g', -1, 64)
+}
+
+func (f *normalizeFlag) Set(s string) error {
+	switch s {
+	case "true":
+		f.enabled, f.target = true, defaultTarget
+		return nil
+	case "false":
+		f.enabled = false
+		return nil
+	}
+	target, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, "LUFS")), 64)
+	if err != nil {
+		return errors.New("expected a loudness in LUFS, such as -16")
+	}
+	if target > 0 {
+		return fmt.Errorf("loudness must not be above 0 LUFS, got %v", target)
+	}
+	f.enabled, f.target = true, target
+	return nil
+}
+
+// IsBoolFlag makes -normalize valid without a value.
+func (f *normalizeFlag) IsBoolFlag() bool { return true }
+
+// normalizer brings the tracks of a playlist to a target loudness.
+type normalizer struct {
+	target     float64 // LUFS
+	replayGain bool    // whether ReplayGain tags are preferred over measuring
+	warn       func(format string, args ...any)
+}
+
+// prepare returns a function that normalizes the loudness of the reader of t, whose decoder it leaves at the same position.
+// Seekable tracks are measured in a pre-pass and get a static gain. Other tracks are leveled while they play instead.
+func (n *normalizer) prepare(t *track, format afmt.Format) (func(aio.SampleReader) aio.SampleReader, error) {
+	if n.replayGain {
+		if md, ok := t.dec.(codec.Metadata); ok {
+			if m, ok := loudness.FromReplayGain(md.Tags()); ok {
+				return gain(m.Gain(n.target)), nil
+			}
+		}
+	}
+
+	m, err := loudness.MeasureSeeker(t.dec, t.dec.Format())
+	if errors.Is(err, loudness.ErrNotSeekable) {
+		n.warn("%s cannot be sought, so its loudness is adjusted while playing\n", t.name)
+		// the AGC levels the RMS level, which only approximates the loudness
+		agc := effect.NewAGC(format)
+		agc.Target = n.target
+		return agc.Reader, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("measuring loudness: %w", err)
+	}
+	return gain(m.Gain(n.target)), nil
+}
+
+// gain returns a function that applies a static gain in dB to a reader.
+func gain(db float64) func(aio.SampleReader) aio.SampleReader {
+	return func(r aio.SampleReader) aio.SampleReader {
+		return effect.Reader(r, effect.NewVolume(db))
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/dsp/loudness"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+// sineFixture encodes seconds of a 997 Hz sine with the given amplitude as a float WAV file.
+func sineFixture(t *testing.T, format afmt.Format, seconds, amplitude float64) []byte {
+	t.Helper()
+	ws := &testutil.WriteSeeker{}
+	enc, err := wav.NewEncoder(ws, format, afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingFloat, Endian: binary.LittleEndian}, wav.FormatFloat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate := format.SampleRate.Hertz()
+	frames := int(seconds * rate)
+	p := make([]float32, frames*format.NumChannels)
+	for i := range frames {
+		for ch := range format.NumChannels {
+			p[i*format.NumChannels+ch] = float32(amplitude * math.Sin(2*math.Pi*997*float64(i)/rate))
+		}
+	}
+	if _, err := enc.WriteSamples(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return ws.Bytes()
+}
+
+func TestPlaylistNormalize(t *testing.T) {
+	format := afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+	files := map[string][]byte{
+		"loud.wav":  sineFixture(t, format, 2, 0.1),  // -20 LUFS
+		"quiet.wav": sineFixture(t, format, 2, 0.02), // about -34 LUFS
+	}
+	n := &normalizer{target: -23, warn: func(format string, args ...any) { t.Errorf("unexpected warning: "+format, args...) }}
+	pl, err := newPlaylist([]string{"loud.wav", "quiet.wav"}, opener(files), nil, n.prepare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := aio.ReadAll(pl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p) != 2*2*2*48000 {
+		t.Fatalf("expected both tracks to be read in full after measuring, got %d samples", len(p))
+	}
+
+	half := len(p) / 2
+	for i, track := range [][]float32{p[:half], p[half:]} {
+		m, err := loudness.Measure(audio.NewBuffer(track), format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(m.Integrated+23) > 0.1 {
+			t.Errorf("track %d: expected -23 LUFS, got %.2f LUFS", i, m.Integrated)
+		}
+	}
+}
+
+func TestPlaylistReplayGain(t *testing.T) {
+	files := map[string][]byte{"a.wav": sineFixture(t, stereo, 1, 0.1)}
+	n := &normalizer{target: -16, replayGain: true}
+	pl, err := newPlaylist([]string{"a.wav"}, opener(files), nil, n.prepare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// WAV files have no ReplayGain tags, so the loudness is measured instead
+	m, err := loudness.Measure(pl, stereo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(m.Integrated+16) > 0.2 {
+		t.Errorf("expected -16 LUFS, got %.2f LUFS", m.Integrated)
+	}
+}
+
+func TestNormalizeFlag(t *testing.T) {
+	for _, tt := range []struct {
+		arg     string
+		enabled bool
+		target  float64
+		wantErr bool
+	}{
+		{"true", true, defaultTarget, false},
+		{"false", false, 0, false},
+		{"-23", true, -23, false},
+		{"-14 LUFS", true, -14, false},
+		{"6", false, 0, true},
+		{"loud", false, 0, true},
+	} {
+		var f normalizeFlag
+		err := f.Set(tt.arg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: expected an error: %v, got %v", tt.arg, tt.wantErr, err)
+			continue
+		}
+		if f.enabled != tt.enabled || (f.enabled && f.target != tt.target) {
+			t.Errorf("%q: expected %v at %v LUFS, got %+v", tt.arg, tt.enabled, tt.target, f)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+//go:build cgo && pipewire
+
+package main
+
+import _ "github.com/MatusOllah/resona/playback/driver/pipewire"
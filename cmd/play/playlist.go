@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/internal/fmtconv"
+	"github.com/MatusOllah/resona/resample"
+)
+
+// track represents an opened track of a [playlist].
+type track struct {
+	index int    // the index of the track in the playlist
+	name  string // the file name
+	codec string
+	dec   codec.Decoder
+	len   int // in frames of the playlist
+
+	f  io.Closer
+	r  aio.SampleReader // dec, converted to the format of the playlist
+	rs resample.Resampler
+	fx func(aio.SampleReader) aio.SampleReader // applied after the conversion, nil if none
+}
+
+// close closes the file of the track and its resampler.
+func (t *track) close() {
+	if t.rs != nil {
+		t.rs.Close()
+	}
+	t.f.Close()
+}
+
+// playlist plays audio files back to back as one stream, without gaps between them.
+// The stream is in the format of the first track that can be played; the other tracks are resampled
+// and their channels mapped as needed. Each file is opened when the previous one ends,
+// and files that cannot be played are skipped.
+type playlist struct {
+	names   []string
+	open    func(name string) (io.ReadSeekCloser, error)
+	onError func(name string, err error) // called for the files that are skipped
+
+	// prepare, if not nil, is called with every track before it is read, and returns a function that wraps
+	// the reader of the track, such as to normalize its loudness. format is the format of the playlist.
+	prepare func(t *track, format afmt.Format) (func(aio.SampleReader) aio.SampleReader, error)
+
+	mu     sync.Mutex
+	format afmt.Format
+	cur    *track // nil once the playlist has ended
+}
+
+// newPlaylist creates a new [playlist] of the files with the given names, which it opens with open.
+// It opens the first track that can be played, and fails if there is none.
+// If prepare is not nil, it is called with every track; see [playlist.prepare].
+func newPlaylist(names []string, open func(name string) (io.ReadSeekCloser, error), onError func(name string, err error),
+	prepare func(t *track, format afmt.Format) (func(aio.SampleReader) aio.SampleReader, error)) (*playlist, error) {
+	p := &playlist{names: names, open: open, onError: onError, prepare: prepare}
+	for i := range names {
+		t, err := p.openTrack(i, true)
+		if err != nil {
+			p.skip(names[i], err)
+			continue
+		}
+		p.cur = t
+		return p, nil
+	}
+	return nil, errors.New("no playable files")
+}
+
+// openTrack opens the track with the given index.
+// If first is true, the format of the track becomes the format of the playlist.
+func (p *playlist) openTrack(index int, first bool) (*track, error) {
+	f, err := p.open(p.names[index])
+	if err != nil {
+		return nil, err
+	}
+	dec, name, err := codec.Decode(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if first {
+		p.format = dec.Format()
+	}
+	t := &track{index: index, name: p.names[index], codec: name, dec: dec, f: f}
+	t.len = int(scaleFrames(int64(dec.Len()), dec.Format(), p.format))
+	if p.prepare != nil {
+		if t.fx, err = p.prepare(t, p.format); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if err := p.convert(t); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// convert sets up the reading of the decoder of t in the format of the playlist, dropping any audio buffered before.
+func (p *playlist) convert(t *track) error {
+	if t.rs != nil {
+		t.rs.Close()
+	}
+	var err error
+	if t.r, t.rs, err = fmtconv.Convert(t.dec, t.dec.Format(), p.format, resample.QualityHigh); err != nil {
+		return err
+	}
+	if t.fx != nil {
+		t.r = t.fx(t.r)
+	}
+	return nil
+}
+
+func (p *playlist) skip(name string, err error) {
+	if p.onError != nil {
+		p.onError(name, err)
+	}
+}
+
+// next closes the current track and opens the next one that can be played, if there is any.
+func (p *playlist) next() {
+	p.cur.close()
+	for i := p.cur.index + 1; i < len(p.names); i++ {
+		t, err := p.openTrack(i, false)
+		if err != nil {
+			p.skip(p.names[i], err)
+			continue
+		}
+		p.cur = t
+		return
+	}
+	p.cur = nil
+}
+
+// Format returns the format of the playlist.
+func (p *playlist) Format() afmt.Format {
+	return p.format
+}
+
+// Track returns the track being read, or nil once the playlist has ended.
+// Because of the buffers, the track heard may still be the previous one for a short while.
+func (p *playlist) Track() *track {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cur
+}
+
+// ReadSamples reads the tracks in turn, carrying on with the next track within the same read when one ends.
+func (p *playlist) ReadSamples(buf []float32) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := 0
+	for n < len(buf) && p.cur != nil {
+		m, err := p.cur.r.ReadSamples(buf[n:])
+		n += m
+		if err == nil {
+			if m == 0 {
+				break
+			}
+			continue // the decoder may report the end of the track only on the next read
+		}
+		if err != io.EOF {
+			p.skip(p.cur.name, err)
+		}
+		p.next()
+	}
+	if n == 0 && p.cur == nil {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// Seek seeks within the current track, in frames of the playlist. The other tracks cannot be sought to.
+func (p *playlist) Seek(offset int64, whence int) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t := p.cur
+	if t == nil {
+		return 0, nil
+	}
+
+	in := t.dec.Format()
+	pos, err := t.dec.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	pos = scaleFrames(pos, in, p.format)
+	if whence == io.SeekCurrent && offset == 0 {
+		return pos, nil // only asking for the position, so the resampler is not reset
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += pos
+	case io.SeekEnd:
+		offset += int64(t.len)
+	default:
+		return 0, errors.New("playlist: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("playlist: negative position")
+	}
+	if _, err := t.dec.Seek(scaleFrames(offset, p.format, in), io.SeekStart); err != nil {
+		return 0, err
+	}
+	if t.rs != nil {
+		// the resampler still holds audio from before the seek
+		if err := p.convert(t); err != nil {
+			return 0, err
+		}
+	}
+	return offset, nil
+}
+
+// scaleFrames converts a number of frames from one sample rate to another.
+func scaleFrames(frames int64, from, to afmt.Format) int64 {
+	if from.SampleRate == to.SampleRate {
+		return frames
+	}
+	return int64(float64(frames) * to.SampleRate.Hertz() / from.SampleRate.Hertz())
+}
+
+// openFile opens a file of a playlist.
+func openFile(name string) (io.ReadSeekCloser, error) {
+	return os.Open(name)
+}
+
+// expandArgs returns the files to play for the command line arguments, replacing M3U playlists with their entries.
+func expandArgs(args []string) ([]string, error) {
+	var names []string
+	for _, arg := range args {
+		switch strings.ToLower(filepath.Ext(arg)) {
+		case ".m3u", ".m3u8":
+			f, err := os.Open(arg)
+			if err != nil {
+				return nil, err
+			}
+			entries, err := parseM3U(f, filepath.Dir(arg))
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", arg, err)
+			}
+			names = append(names, entries...)
+		default:
+			names = append(names, arg)
+		}
+	}
+	return names, nil
+}
+
+// parseM3U returns the entries of an M3U playlist, resolving relative paths against dir.
+// Comments, including the directives of extended M3U, are skipped.
+func parseM3U(r io.Reader, dir string) ([]string, error) {
+	var names []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(s.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) && !strings.Contains(line, "://") {
+			line = filepath.Join(dir, line)
+		}
+		names = append(names, line)
+	}
+	return names, s.Err()
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+// nopCloser is an in-memory file of a playlist.
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error { return nil }
+
+// fixture encodes frames of the constant v as a float WAV file.
+func fixture(t *testing.T, format afmt.Format, frames int, v float32) []byte {
+	t.Helper()
+	ws := &testutil.WriteSeeker{}
+	enc, err := wav.NewEncoder(ws, format, afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingFloat, Endian: binary.LittleEndian}, wav.FormatFloat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make([]float32, frames*format.NumChannels)
+	for i := range p {
+		p[i] = v
+	}
+	if _, err := enc.WriteSamples(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return ws.Bytes()
+}
+
+// opener opens the in-memory files, failing for the names it does not have.
+func opener(files map[string][]byte) func(string) (io.ReadSeekCloser, error) {
+	return func(name string) (io.ReadSeekCloser, error) {
+		b, ok := files[name]
+		if !ok {
+			return nil, errors.New("file not found")
+		}
+		return nopCloser{bytes.NewReader(b)}, nil
+	}
+}
+
+var stereo = afmt.Format{SampleRate: 8 * freq.KiloHertz, NumChannels: 2}
+
+func TestPlaylistGapless(t *testing.T) {
+	files := map[string][]byte{
+		"a.wav": fixture(t, stereo, 1000, 0.25),
+		"b.wav": fixture(t, stereo, 500, 0.5),
+	}
+	pl, err := newPlaylist([]string{"a.wav", "b.wav"}, opener(files), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl.Format() != stereo {
+		t.Errorf("expected format %v, got %v", stereo, pl.Format())
+	}
+
+	// a read straddling the end of a track carries on with the next one
+	p := make([]float32, 2*1200)
+	n, err := pl.ReadSamples(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(p) {
+		t.Fatalf("expected a full read across the tracks, got %d samples", n)
+	}
+	if p[2*1000-1] != 0.25 || p[2*1000] != 0.5 {
+		t.Errorf("expected the second track right after the first, got %v, %v", p[2*1000-1], p[2*1000])
+	}
+	if tr := pl.Track(); tr == nil || tr.index != 1 {
+		t.Fatalf("expected the second track, got %+v", tr)
+	}
+
+	rest, err := aio.ReadAll(pl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 2*300 {
+		t.Errorf("expected 600 samples left, got %d", len(rest))
+	}
+	if pl.Track() != nil {
+		t.Error("expected no track once the playlist has ended")
+	}
+}
+
+func TestPlaylistConvert(t *testing.T) {
+	mono := afmt.Format{SampleRate: 16 * freq.KiloHertz, NumChannels: 1}
+	files := map[string][]byte{
+		"a.wav": fixture(t, stereo, 100, 0.25),
+		"b.wav": fixture(t, mono, 1600, 0.5),
+	}
+	pl, err := newPlaylist([]string{"a.wav", "b.wav"}, opener(files), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := aio.ReadAll(pl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second track is resampled to 8kHz and its channel duplicated
+	frames := len(p) / 2
+	if frames < 100+780 || frames > 100+820 {
+		t.Errorf("expected about 900 frames, got %d", frames)
+	}
+	mid := 2 * (100 + 400)
+	if !testutil.EqualWithinTolerance(p[mid], 0.5, 1e-3) || p[mid] != p[mid+1] {
+		t.Errorf("expected both channels of the second track to be 0.5, got %v, %v", p[mid], p[mid+1])
+	}
+}
+
+func TestPlaylistSkip(t *testing.T) {
+	files := map[string][]byte{
+		"bad.wav": []byte("not a wave file"),
+		"b.wav":   fixture(t, stereo, 100, 0.5),
+	}
+	var skipped []string
+	onError := func(name string, err error) { skipped = append(skipped, name) }
+
+	pl, err := newPlaylist([]string{"missing.wav", "b.wav", "bad.wav"}, opener(files), onError, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr := pl.Track(); tr.index != 1 {
+		t.Errorf("expected to start with the second track, got %d", tr.index)
+	}
+	p, err := aio.ReadAll(pl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p) != 2*100 {
+		t.Errorf("expected 200 samples, got %d", len(p))
+	}
+	if want := []string{"missing.wav", "bad.wav"}; !slices.Equal(skipped, want) {
+		t.Errorf("expected %v to be skipped, got %v", want, skipped)
+	}
+
+	if _, err := newPlaylist([]string{"missing.wav", "bad.wav"}, opener(files), nil, nil); err == nil {
+		t.Error("expected an error for a playlist without playable files")
+	}
+}
+
+func TestPlaylistSeek(t *testing.T) {
+	files := map[string][]byte{
+		"a.wav": fixture(t, stereo, 1000, 0.25),
+		"b.wav": fixture(t, stereo, 1000, 0.5),
+	}
+	pl, err := newPlaylist([]string{"a.wav", "b.wav"}, opener(files), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pl.Seek(900, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if pos, err := pl.Seek(0, io.SeekCurrent); err != nil || pos != 900 {
+		t.Fatalf("expected position 900, got %d (%v)", pos, err)
+	}
+
+	// the position is within the track being read
+	p := make([]float32, 2*200)
+	if _, err := aio.ReadFull(pl, p); err != nil {
+		t.Fatal(err)
+	}
+	if pos, err := pl.Seek(0, io.SeekCurrent); err != nil || pos != 100 {
+		t.Errorf("expected position 100 in the second track, got %d (%v)", pos, err)
+	}
+	if _, err := pl.Seek(-10, io.SeekStart); err == nil {
+		t.Error("expected an error seeking to a negative position")
+	}
+}
+
+func TestParseM3U(t *testing.T) {
+	const m3u = "\ufeff#EXTM3U\n#EXTINF:123,Artist - Title\nfirst.flac\n\n  /music/second.ogg  \r\nhttp://example.com/stream.mp3\n"
+	names, err := parseM3U(strings.NewReader(m3u), "lists")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join("lists", "first.flac"), "/music/second.ogg", "http://example.com/stream.mp3"}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected %q, got %q", want, names)
+	}
+}
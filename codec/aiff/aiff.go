@@ -0,0 +1,84 @@
+package aiff
+
+import (
+	"errors"
+	"math"
+
+	"github.com/MatusOllah/resona/codec/internal/iff"
+)
+
+// https://www.mmsp.ece.mcgill.ca/Documents/AudioFormats/AIFF/Docs/AIFF-1.3.pdf
+// https://www.mmsp.ece.mcgill.ca/Documents/AudioFormats/AIFF/Docs/AIFF-C.9.26.91.pdf
+
+// Chunk IDs.
+var (
+	AIFFID iff.FourCC = iff.FourCC{'A', 'I', 'F', 'F'}
+	AIFCID iff.FourCC = iff.FourCC{'A', 'I', 'F', 'C'}
+
+	CommID iff.FourCC = iff.FourCC{'C', 'O', 'M', 'M'}
+	SSNDID iff.FourCC = iff.FourCC{'S', 'S', 'N', 'D'}
+	MarkID iff.FourCC = iff.FourCC{'M', 'A', 'R', 'K'}
+	InstID iff.FourCC = iff.FourCC{'I', 'N', 'S', 'T'}
+)
+
+// AIFF-C compression types.
+var (
+	CompressionNone iff.FourCC = iff.FourCC{'N', 'O', 'N', 'E'} // Big-endian signed PCM
+	CompressionTwos iff.FourCC = iff.FourCC{'t', 'w', 'o', 's'} // Big-endian signed PCM
+	CompressionSowt iff.FourCC = iff.FourCC{'s', 'o', 'w', 't'} // Little-endian signed PCM
+	CompressionRaw  iff.FourCC = iff.FourCC{'r', 'a', 'w', ' '} // Unsigned PCM
+	CompressionFl32 iff.FourCC = iff.FourCC{'f', 'l', '3', '2'} // 32-bit IEEE float
+	CompressionFL32 iff.FourCC = iff.FourCC{'F', 'L', '3', '2'} // 32-bit IEEE float
+	CompressionFl64 iff.FourCC = iff.FourCC{'f', 'l', '6', '4'} // 64-bit IEEE float
+	CompressionFL64 iff.FourCC = iff.FourCC{'F', 'L', '6', '4'} // 64-bit IEEE float
+	CompressionUlaw iff.FourCC = iff.FourCC{'u', 'l', 'a', 'w'} // G.711 μ-law
+	CompressionULAW iff.FourCC = iff.FourCC{'U', 'L', 'A', 'W'} // G.711 μ-law
+	CompressionAlaw iff.FourCC = iff.FourCC{'a', 'l', 'a', 'w'} // G.711 A-law
+	CompressionALAW iff.FourCC = iff.FourCC{'A', 'L', 'A', 'W'} // G.711 A-law
+)
+
+// parseExtended parses an 80-bit IEEE 754 extended precision float (big-endian), as used for the sample rate.
+func parseExtended(b [10]byte) (float64, error) {
+	sign := b[0] >> 7
+	exp := int(b[0]&0x7f)<<8 | int(b[1])
+	var mant uint64
+	for _, c := range b[2:] {
+		mant = mant<<8 | uint64(c)
+	}
+
+	if exp == 0x7fff {
+		return 0, errors.New("sample rate is infinite or NaN")
+	}
+	if exp == 0 && mant == 0 {
+		return 0, nil
+	}
+
+	// the integer bit is explicit, so the value is mant * 2^(exp - bias - 63)
+	f := math.Ldexp(float64(mant), exp-16383-63)
+	if sign != 0 {
+		f = -f
+	}
+	return f, nil
+}
+
+// appendExtended appends f as an 80-bit IEEE 754 extended precision float (big-endian) to b.
+func appendExtended(b []byte, f float64) []byte {
+	var sign byte
+	if f < 0 {
+		sign = 0x80
+		f = -f
+	}
+	if f == 0 {
+		return append(b, make([]byte, 10)...)
+	}
+
+	frac, exp := math.Frexp(f) // f = frac * 2^exp, 0.5 <= frac < 1
+	exp += 16382
+	mant := uint64(math.Ldexp(frac, 64))
+
+	b = append(b, sign|byte(exp>>8), byte(exp))
+	for i := 56; i >= 0; i -= 8 {
+		b = append(b, byte(mant>>i))
+	}
+	return b
+}
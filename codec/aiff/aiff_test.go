@@ -0,0 +1,34 @@
+package aiff
+
+import "testing"
+
+func TestExtended(t *testing.T) {
+	tests := []struct {
+		rate float64
+		b    [10]byte
+	}{
+		{0, [10]byte{}},
+		{8000, [10]byte{0x40, 0x0B, 0xFA, 0x00, 0, 0, 0, 0, 0, 0}},
+		{22050, [10]byte{0x40, 0x0D, 0xAC, 0x44, 0, 0, 0, 0, 0, 0}},
+		{44100, [10]byte{0x40, 0x0E, 0xAC, 0x44, 0, 0, 0, 0, 0, 0}},
+		{48000, [10]byte{0x40, 0x0E, 0xBB, 0x80, 0, 0, 0, 0, 0, 0}},
+		{11025.5, [10]byte{0x40, 0x0C, 0xAC, 0x46, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		got, err := parseExtended(tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.rate {
+			t.Errorf("parseExtended(% x): expected %v, got %v", tt.b, tt.rate, got)
+		}
+
+		if b := appendExtended(nil, tt.rate); string(b) != string(tt.b[:]) {
+			t.Errorf("appendExtended(%v): expected % x, got % x", tt.rate, tt.b, b)
+		}
+	}
+
+	if _, err := parseExtended([10]byte{0x7F, 0xFF, 0x80}); err == nil {
+		t.Error("expected error for infinite sample rate")
+	}
+}
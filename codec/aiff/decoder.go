@@ -0,0 +1,397 @@
+package aiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/codec/internal/iff"
+	"github.com/MatusOllah/resona/encoding/g711"
+	"github.com/MatusOllah/resona/encoding/pcm"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var _ codec.Decoder = (*Decoder)(nil)
+
+// Decoder represents the decoder for the AIFF and AIFF-C file formats.
+// It implements codec.Decoder.
+type Decoder struct {
+	iffR *iff.Reader
+
+	// AIFC reports whether the file is an AIFF-C file.
+	AIFC bool
+
+	numChannels int16
+	numFrames   uint32
+	sampleSize  int16
+	sampleRate  float64
+
+	// CompressionType is the AIFF-C compression type.
+	// It's always [CompressionNone] for plain AIFF files.
+	CompressionType iff.FourCC
+
+	// CompressionName is the human-readable AIFF-C compression name.
+	CompressionName string
+
+	markers    []Marker
+	instrument *Instrument
+
+	commRead    bool
+	ssndChunk   *iff.Chunk
+	dataOffset  int64 // offset of the sample data within the SSND chunk
+	samplesRead int64
+
+	dec aio.SampleReader
+}
+
+// NewDecoder creates a new [Decoder] and decodes the headers.
+//
+// The MARK and INST chunks are only read if they precede the SSND chunk or if r is an [io.Seeker].
+func NewDecoder(r io.Reader) (_ codec.Decoder, err error) {
+	d := &Decoder{CompressionType: CompressionNone}
+
+	var id iff.FourCC
+	id, d.iffR, err = iff.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("aiff: failed to decode IFF stream: %w", err)
+	}
+
+	switch {
+	case bytes.Equal(id[:], AIFFID[:]):
+	case bytes.Equal(id[:], AIFCID[:]):
+		d.AIFC = true
+	default:
+		return nil, errors.New("aiff: invalid header")
+	}
+
+	for {
+		chunk, err := d.iffR.NextChunk()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("aiff: %w", err)
+		}
+
+		if bytes.Equal(chunk.ID[:], SSNDID[:]) {
+			if err := d.parseSSND(chunk); err != nil {
+				return nil, fmt.Errorf("aiff: failed to parse SSND chunk: %w", err)
+			}
+
+			// The COMM, MARK and INST chunks may also follow the SSND chunk.
+			if rs, ok := r.(io.ReadSeeker); ok {
+				if err := d.scanTrailer(rs); err != nil {
+					return nil, fmt.Errorf("aiff: failed to scan chunks after SSND: %w", err)
+				}
+			}
+			break
+		}
+
+		if err := d.parseChunk(chunk.ID, chunk.Reader); err != nil {
+			return nil, fmt.Errorf("aiff: failed to parse %s chunk: %w", chunk.ID, err)
+		}
+	}
+
+	if !d.commRead {
+		return nil, errors.New("aiff: invalid or missing COMM chunk")
+	}
+	if d.ssndChunk == nil {
+		return nil, errors.New("aiff: invalid or missing SSND chunk")
+	}
+
+	if err := d.ensureAudioDecoder(); err != nil {
+		return nil, fmt.Errorf("aiff: %w", err)
+	}
+
+	return d, nil
+}
+
+// parseChunk parses a COMM, MARK, or INST chunk and skips any other chunk.
+func (d *Decoder) parseChunk(id iff.FourCC, r io.Reader) (err error) {
+	switch {
+	case bytes.Equal(id[:], CommID[:]):
+		err = d.parseComm(r)
+	case bytes.Equal(id[:], MarkID[:]):
+		d.markers, err = parseMarkers(r)
+	case bytes.Equal(id[:], InstID[:]):
+		d.instrument, err = parseInstrument(r)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(io.Discard, r)
+	return err
+}
+
+// parseComm parses the COMM chunk.
+func (d *Decoder) parseComm(r io.Reader) error {
+	if err := binary.Read(r, binary.BigEndian, &d.numChannels); err != nil {
+		return fmt.Errorf("failed to read number of channels: %w", err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &d.numFrames); err != nil {
+		return fmt.Errorf("failed to read number of sample frames: %w", err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &d.sampleSize); err != nil {
+		return fmt.Errorf("failed to read sample size: %w", err)
+	}
+
+	var rate [10]byte
+	if _, err := io.ReadFull(r, rate[:]); err != nil {
+		return fmt.Errorf("failed to read sample rate: %w", err)
+	}
+	var err error
+	d.sampleRate, err = parseExtended(rate)
+	if err != nil {
+		return err
+	}
+
+	if d.numChannels <= 0 {
+		return fmt.Errorf("invalid number of channels: %d", d.numChannels)
+	}
+	if d.sampleSize <= 0 || d.sampleSize > 64 {
+		return fmt.Errorf("invalid sample size: %d", d.sampleSize)
+	}
+
+	if d.AIFC {
+		if _, err := io.ReadFull(r, d.CompressionType[:]); err != nil {
+			return fmt.Errorf("failed to read compression type: %w", err)
+		}
+
+		// pstring, padded to an even length including the count byte
+		var n [1]byte
+		if _, err := io.ReadFull(r, n[:]); err != nil {
+			return fmt.Errorf("failed to read compression name: %w", err)
+		}
+		name := make([]byte, int(n[0])+int(n[0]+1)&1)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return fmt.Errorf("failed to read compression name: %w", err)
+		}
+		d.CompressionName = string(name[:n[0]])
+	}
+
+	d.commRead = true
+	return nil
+}
+
+// parseSSND parses the SSND chunk header and skips to the start of the sample data.
+func (d *Decoder) parseSSND(chunk *iff.Chunk) error {
+	var hdr struct {
+		Offset    uint32
+		BlockSize uint32
+	}
+	if err := binary.Read(chunk.Reader, binary.BigEndian, &hdr); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(io.Discard, chunk.Reader, int64(hdr.Offset)); err != nil {
+		return fmt.Errorf("failed to skip to sample data: %w", err)
+	}
+
+	d.ssndChunk = chunk
+	d.dataOffset = 8 + int64(hdr.Offset)
+	return nil
+}
+
+// scanTrailer parses the chunks following the SSND chunk and seeks back to the start of the sample data.
+// A truncated trailing chunk is ignored.
+func (d *Decoder) scanTrailer(rs io.ReadSeeker) error {
+	dataStart, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	// chunks are padded to an even length
+	ssndLen := int64(d.ssndChunk.Len)
+	if _, err := rs.Seek(dataStart-d.dataOffset+ssndLen+ssndLen&1, io.SeekStart); err != nil {
+		return err
+	}
+
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(rs, hdr[:]); err != nil {
+			break
+		}
+		id := iff.FourCC{hdr[0], hdr[1], hdr[2], hdr[3]}
+		size := int64(binary.BigEndian.Uint32(hdr[4:]))
+
+		lr := &io.LimitedReader{R: rs, N: size}
+		if err := d.parseChunk(id, lr); err != nil {
+			return fmt.Errorf("failed to parse %s chunk: %w", id, err)
+		}
+		if lr.N != 0 {
+			break
+		}
+		if _, err := rs.Seek(size&1, io.SeekCurrent); err != nil {
+			return err
+		}
+	}
+
+	_, err = rs.Seek(dataStart, io.SeekStart)
+	return err
+}
+
+func (d *Decoder) ensureAudioDecoder() error {
+	switch d.CompressionType {
+	case CompressionNone, CompressionTwos, CompressionSowt, CompressionRaw,
+		CompressionFl32, CompressionFL32, CompressionFl64, CompressionFL64:
+		d.dec = pcm.NewDecoder(d.ssndChunk.Reader, d.SampleFormat())
+	case CompressionUlaw, CompressionULAW:
+		d.dec = g711.NewUlawDecoder(d.ssndChunk.Reader)
+	case CompressionAlaw, CompressionALAW:
+		d.dec = g711.NewAlawDecoder(d.ssndChunk.Reader)
+	default:
+		return fmt.Errorf("unsupported compression type: %q", d.CompressionType.String())
+	}
+	return nil
+}
+
+// Markers returns the markers from the MARK chunk.
+func (d *Decoder) Markers() []Marker {
+	return d.markers
+}
+
+// Marker returns the marker with the specified ID.
+func (d *Decoder) Marker(id int) (Marker, bool) {
+	for _, m := range d.markers {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return Marker{}, false
+}
+
+// Instrument returns the instrument data from the INST chunk, or nil if there is none.
+func (d *Decoder) Instrument() *Instrument {
+	return d.instrument
+}
+
+// LoopRange returns the start and end of the loop in frames, resolved from its markers.
+// It returns false if the loop is disabled or its markers don't exist.
+func (d *Decoder) LoopRange(l Loop) (start, end int, ok bool) {
+	if l.PlayMode == LoopOff {
+		return 0, 0, false
+	}
+	begin, ok := d.Marker(l.Begin)
+	if !ok {
+		return 0, 0, false
+	}
+	stop, ok := d.Marker(l.End)
+	if !ok {
+		return 0, 0, false
+	}
+	return begin.Position, stop.Position, true
+}
+
+// Format returns the audio stream format.
+func (d *Decoder) Format() afmt.Format {
+	return afmt.Format{
+		SampleRate:  freq.Frequency(math.Round(d.sampleRate * float64(freq.Hertz))),
+		NumChannels: int(d.numChannels),
+	}
+}
+
+// SampleFormat returns the sample format.
+//
+// Samples are stored left-justified in whole bytes, so the bit depth is rounded up to a multiple of 8.
+func (d *Decoder) SampleFormat() afmt.SampleFormat {
+	f := afmt.SampleFormat{
+		BitDepth: (int(d.sampleSize) + 7) / 8 * 8,
+		Encoding: afmt.SampleEncodingInt,
+		Endian:   binary.BigEndian,
+	}
+
+	switch d.CompressionType {
+	case CompressionSowt:
+		f.Endian = binary.LittleEndian
+	case CompressionRaw:
+		f.Encoding = afmt.SampleEncodingUint
+	case CompressionFl32, CompressionFL32:
+		f.BitDepth = 32
+		f.Encoding = afmt.SampleEncodingFloat
+	case CompressionFl64, CompressionFL64:
+		f.BitDepth = 64
+		f.Encoding = afmt.SampleEncodingFloat
+	case CompressionUlaw, CompressionULAW, CompressionAlaw, CompressionALAW:
+		f.BitDepth = 8
+		f.Encoding = afmt.SampleEncodingUint
+	}
+	if f.BitDepth == 8 {
+		f.Endian = nil
+	}
+
+	return f
+}
+
+// bytesPerFrame returns the size of a frame in bytes.
+func (d *Decoder) bytesPerFrame() int64 {
+	return int64(d.SampleFormat().BitDepth/8) * int64(d.numChannels)
+}
+
+// ReadSamples reads float32 samples from the SSND chunk into p.
+// It returns the number of samples read and/or an error.
+func (d *Decoder) ReadSamples(p []float32) (n int, err error) {
+	n, err = d.dec.ReadSamples(p)
+	d.samplesRead += int64(n)
+	return
+}
+
+// Len returns the total number of frames.
+func (d *Decoder) Len() int {
+	// don't trust the frame count beyond the actual sample data
+	dataFrames := (int64(d.ssndChunk.Len) - d.dataOffset) / d.bytesPerFrame()
+	return int(max(min(int64(d.numFrames), dataFrames), 0))
+}
+
+// Seek seeks to the specified frame.
+// It returns the new offset relative to the start and/or an error.
+// It will return an error if the source is not an [io.Seeker].
+func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
+	// Special case
+	if offset == 0 && whence == io.SeekCurrent {
+		return d.samplesRead / int64(d.numChannels), nil
+	}
+
+	totalFrames := int64(d.Len())
+
+	var targetFrame int64
+	switch whence {
+	case io.SeekStart:
+		targetFrame = offset
+	case io.SeekCurrent:
+		targetFrame = d.samplesRead/int64(d.numChannels) + offset
+	case io.SeekEnd:
+		targetFrame = totalFrames + offset
+	default:
+		return 0, fmt.Errorf("aiff: invalid seek whence")
+	}
+
+	if targetFrame < 0 || targetFrame > totalFrames {
+		return 0, fmt.Errorf("aiff: seek out of bounds")
+	}
+
+	if _, err := d.ssndChunk.Reader.Seek(d.dataOffset+targetFrame*d.bytesPerFrame(), io.SeekStart); err != nil {
+		return 0, fmt.Errorf("aiff: failed to seek: %w", err)
+	}
+	if pd, ok := d.dec.(*pcm.Decoder); ok {
+		pd.Reset(d.ssndChunk.Reader)
+	}
+
+	d.samplesRead = targetFrame * int64(d.numChannels)
+	return targetFrame, nil
+}
+
+// Bitrate returns the bitrate of the audio stream in bits per second.
+func (d *Decoder) Bitrate() int {
+	return int(math.Round(d.sampleRate)) * int(d.bytesPerFrame()) * 8
+}
+
+func init() {
+	codec.RegisterFormat("aiff", "FORM????AIFF", NewDecoder)
+	codec.RegisterFormat("aifc", "FORM????AIFC", NewDecoder)
+}
@@ -0,0 +1,297 @@
+package aiff_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/codec/aiff"
+	"github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+// The fixtures are assembled by hand from the sample data of WAV files encoded from the same material,
+// so that each AIFF variant can be checked sample-exactly against the WAV decoder.
+
+// rate44100 is 44100 as an 80-bit extended float.
+var rate44100 = []byte{0x40, 0x0E, 0xAC, 0x44, 0, 0, 0, 0, 0, 0}
+
+const numChannels = 2
+
+// material returns a deterministic stereo test signal.
+func material() []float32 {
+	p := make([]float32, 64*numChannels)
+	for i := range p {
+		p[i] = float32(math.Sin(float64(i)*0.37)) * 0.9
+	}
+	return p
+}
+
+// chunk returns an IFF chunk with the given ID and data, padded to an even length.
+func chunk(id string, data []byte) []byte {
+	b := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(data)))...)
+	b = append(b, data...)
+	if len(data)%2 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// form returns a FORM containing the given chunks.
+func form(formType string, chunks ...[]byte) []byte {
+	b := []byte(formType)
+	for _, c := range chunks {
+		b = append(b, c...)
+	}
+	return chunk("FORM", b)
+}
+
+// comm returns a COMM chunk. If compression is not empty, the AIFF-C fields are added.
+func comm(numFrames, sampleSize int, compression string) []byte {
+	var b []byte
+	b = binary.BigEndian.AppendUint16(b, numChannels)
+	b = binary.BigEndian.AppendUint32(b, uint32(numFrames))
+	b = binary.BigEndian.AppendUint16(b, uint16(sampleSize))
+	b = append(b, rate44100...)
+	if compression != "" {
+		b = append(b, compression...)
+		b = append(b, 3, 'a', 'b', 'c') // compression name, no padding needed
+	}
+	return chunk("COMM", b)
+}
+
+// ssnd returns an SSND chunk with the given offset (filled with garbage) and sample data.
+func ssnd(offset int, data []byte) []byte {
+	var b []byte
+	b = binary.BigEndian.AppendUint32(b, uint32(offset))
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = append(b, bytes.Repeat([]byte{0xAA}, offset)...)
+	b = append(b, data...)
+	return chunk("SSND", b)
+}
+
+// encodeWAV encodes the material as a WAV file and returns the file and its sample data.
+func encodeWAV(t *testing.T, sampleFmt afmt.SampleFormat, wavFormat uint16) (file, data []byte) {
+	t.Helper()
+
+	ws := &testutil.WriteSeeker{}
+	enc, err := wav.NewEncoder(ws, afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: numChannels}, sampleFmt, wavFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.WriteSamples(material()); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return ws.Bytes(), ws.Bytes()[44:]
+}
+
+// decode decodes all samples using codec.Decode.
+func decode(t *testing.T, b []byte) []float32 {
+	t.Helper()
+
+	dec, _, err := codec.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// swap reverses the byte order of each n-byte sample.
+func swap(data []byte, n int) []byte {
+	out := slices.Clone(data)
+	for i := 0; i+n <= len(out); i += n {
+		slices.Reverse(out[i : i+n])
+	}
+	return out
+}
+
+func TestDecoderAgainstWAV(t *testing.T) {
+	tests := []struct {
+		name        string
+		bitDepth    int
+		wavFormat   uint16
+		formType    string
+		compression string
+		convert     func([]byte) []byte
+		tolerance   float64
+	}{
+		{"Int8", 8, wav.FormatInt, "AIFF", "", func(b []byte) []byte {
+			out := slices.Clone(b)
+			for i := range out {
+				out[i] ^= 0x80 // WAV 8-bit is unsigned, AIFF 8-bit is signed
+			}
+			return out
+		}, 1.0 / 127}, // the PCM decoder scales signed and unsigned 8-bit samples slightly differently
+		{"Int16", 16, wav.FormatInt, "AIFF", "", func(b []byte) []byte { return swap(b, 2) }, 0},
+		{"Int24", 24, wav.FormatInt, "AIFF", "", func(b []byte) []byte { return swap(b, 3) }, 0},
+		{"Int32", 32, wav.FormatInt, "AIFF", "", func(b []byte) []byte { return swap(b, 4) }, 0},
+		{"AIFCNone", 16, wav.FormatInt, "AIFC", "NONE", func(b []byte) []byte { return swap(b, 2) }, 0},
+		{"AIFCSowt", 16, wav.FormatInt, "AIFC", "sowt", func(b []byte) []byte { return b }, 0},
+		{"AIFCFl32", 32, wav.FormatFloat, "AIFC", "fl32", func(b []byte) []byte { return swap(b, 4) }, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := afmt.SampleEncodingInt
+			if tt.wavFormat == wav.FormatFloat {
+				enc = afmt.SampleEncodingFloat
+			}
+			wavFile, wavData := encodeWAV(t, afmt.SampleFormat{BitDepth: tt.bitDepth, Encoding: enc, Endian: binary.LittleEndian}, tt.wavFormat)
+			want := decode(t, wavFile)
+
+			numFrames := len(wavData) / (tt.bitDepth / 8) / numChannels
+			file := form(tt.formType, comm(numFrames, tt.bitDepth, tt.compression), ssnd(6, tt.convert(wavData)))
+
+			dec, name, err := codec.Decode(bytes.NewReader(file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wantName := map[string]string{"AIFF": "aiff", "AIFC": "aifc"}[tt.formType]; name != wantName {
+				t.Errorf("expected format name %q, got %q", wantName, name)
+			}
+			if dec.Len() != numFrames {
+				t.Errorf("expected Len %d, got %d", numFrames, dec.Len())
+			}
+			if dec.Format().SampleRate != 44100*freq.Hertz || dec.Format().NumChannels != numChannels {
+				t.Errorf("unexpected format %v", dec.Format())
+			}
+
+			got, err := aio.ReadAll(dec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("expected %d samples, got %d", len(want), len(got))
+			}
+			for i := range got {
+				if math.Abs(float64(got[i]-want[i])) > tt.tolerance {
+					t.Fatalf("sample %d: expected %v, got %v", i, want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecoderSeek(t *testing.T) {
+	_, wavData := encodeWAV(t, afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}, wav.FormatInt)
+	numFrames := len(wavData) / 2 / numChannels
+	file := form("AIFF", comm(numFrames, 16, ""), ssnd(4, swap(wavData, 2)))
+	all := decode(t, file)
+
+	dec, err := aiff.NewDecoder(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []int64{10, 3, int64(numFrames), 0} {
+		pos, err := dec.Seek(target, io.SeekStart)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos != target {
+			t.Errorf("expected position %d, got %d", target, pos)
+		}
+		got, err := aio.ReadAll(dec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, all[target*numChannels:]) {
+			t.Errorf("wrong samples after seeking to frame %d", target)
+		}
+	}
+
+	if _, err := dec.Seek(int64(numFrames)+1, io.SeekStart); err == nil {
+		t.Error("expected error when seeking out of bounds")
+	}
+}
+
+// nonSeeker hides the io.Seeker implementation of the underlying reader.
+type nonSeeker struct {
+	io.Reader
+}
+
+func TestDecoderMarkers(t *testing.T) {
+	var mark []byte
+	mark = binary.BigEndian.AppendUint16(mark, 2)
+	mark = binary.BigEndian.AppendUint16(mark, 1)
+	mark = binary.BigEndian.AppendUint32(mark, 2)
+	mark = append(mark, 4, 'l', 'o', 'o', 'p', 0) // padded pstring
+	mark = binary.BigEndian.AppendUint16(mark, 2)
+	mark = binary.BigEndian.AppendUint32(mark, 6)
+	mark = append(mark, 3, 'e', 'n', 'd')
+
+	inst := []byte{
+		60, 0xFB, 0, 127, 1, 127, // base note, detune (-5), low/high note, low/high velocity
+		0xFF, 0xFA, // gain (-6)
+		0, 1, 0, 1, 0, 2, // sustain loop: forward, markers 1-2
+		0, 0, 0, 0, 0, 0, // release loop: off
+	}
+
+	data := make([]byte, 8*numChannels*2)
+	file := form("AIFF", chunk("MARK", mark), ssnd(0, data), chunk("INST", inst), comm(8, 16, ""))
+
+	t.Run("Seekable", func(t *testing.T) {
+		dec, err := aiff.NewDecoder(bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := dec.(*aiff.Decoder)
+
+		wantMarkers := []aiff.Marker{{ID: 1, Position: 2, Name: "loop"}, {ID: 2, Position: 6, Name: "end"}}
+		if !slices.Equal(d.Markers(), wantMarkers) {
+			t.Errorf("expected markers %v, got %v", wantMarkers, d.Markers())
+		}
+
+		inst := d.Instrument()
+		if inst == nil {
+			t.Fatal("expected instrument data")
+		}
+		if inst.BaseNote != 60 || inst.Detune != -5 || inst.HighNote != 127 || inst.LowVelocity != 1 || inst.Gain != -6 {
+			t.Errorf("unexpected instrument data %+v", inst)
+		}
+		if inst.SustainLoop != (aiff.Loop{PlayMode: aiff.LoopForward, Begin: 1, End: 2}) {
+			t.Errorf("unexpected sustain loop %+v", inst.SustainLoop)
+		}
+		if start, end, ok := d.LoopRange(inst.SustainLoop); !ok || start != 2 || end != 6 {
+			t.Errorf("expected sustain loop range 2-6, got %d-%d (%v)", start, end, ok)
+		}
+		if _, _, ok := d.LoopRange(inst.ReleaseLoop); ok {
+			t.Error("expected release loop to be disabled")
+		}
+
+		p, err := aio.ReadAll(dec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p) != len(data)/2 {
+			t.Errorf("expected %d samples, got %d", len(data)/2, len(p))
+		}
+	})
+
+	t.Run("NonSeekable", func(t *testing.T) {
+		// the COMM chunk follows the SSND chunk, so it can't be read
+		if _, err := aiff.NewDecoder(nonSeeker{bytes.NewReader(file)}); err == nil {
+			t.Error("expected error for COMM chunk after SSND chunk")
+		}
+	})
+}
+
+func TestDecoderUnsupportedCompression(t *testing.T) {
+	file := form("AIFC", comm(0, 16, "ima4"), ssnd(0, nil))
+	if _, err := aiff.NewDecoder(bytes.NewReader(file)); err == nil {
+		t.Error("expected error for unsupported compression type")
+	}
+}
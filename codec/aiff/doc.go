@@ -0,0 +1,6 @@
+// Package aiff implements encoding and decoding of Audio Interchange File Format (AIFF) and AIFF-C files.
+//
+// The decoder supports uncompressed big-endian PCM, as well as the AIFF-C "sowt" (little-endian PCM),
+// "fl32"/"fl64" (floating point), "raw " (unsigned PCM), "ulaw", and "alaw" compression types.
+// The encoder writes plain (uncompressed) AIFF files.
+package aiff
@@ -0,0 +1,145 @@
+package aiff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/encoding/pcm"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var _ aio.SampleWriteCloser = (*Encoder)(nil)
+
+// Encoder represents the encoder for the AIFF file format.
+//
+// The caller retains ownership of the writer; it will not be closed automatically.
+type Encoder struct {
+	w         io.WriteSeeker
+	format    afmt.Format
+	sampleFmt afmt.SampleFormat
+
+	enc            aio.SampleWriter
+	samplesWritten int64
+}
+
+// NewEncoder creates a new [Encoder] for the AIFF file format.
+//
+// The caller retains ownership of the writer; it will not be closed automatically.
+//
+// The sample format must be big-endian signed PCM with a bit depth of 8, 16, 24, or 32.
+// If the endianness is nil, big-endian is assumed.
+func NewEncoder(w io.WriteSeeker, format afmt.Format, sampleFmt afmt.SampleFormat) (*Encoder, error) {
+	// validate sample format
+	if sampleFmt.Encoding != afmt.SampleEncodingInt || (sampleFmt.Endian != nil && sampleFmt.Endian != binary.BigEndian) {
+		return nil, fmt.Errorf("aiff: invalid sample format: %s", sampleFmt.String())
+	}
+	switch sampleFmt.BitDepth {
+	case 8, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("aiff: invalid bit depth: %d", sampleFmt.BitDepth)
+	}
+	if sampleFmt.Endian == nil {
+		sampleFmt.Endian = binary.BigEndian
+	}
+
+	e := &Encoder{
+		w:         w,
+		format:    format,
+		sampleFmt: sampleFmt,
+	}
+
+	if err := e.writeHeader(); err != nil {
+		return nil, fmt.Errorf("aiff: failed to write header: %w", err)
+	}
+
+	e.enc = pcm.NewEncoder(w, sampleFmt)
+
+	return e, nil
+}
+
+func (e *Encoder) writeHeader() error {
+	var b []byte
+
+	// FORM header, size is patched in Close
+	b = append(b, "FORM"...)
+	b = binary.BigEndian.AppendUint32(b, 0xFFFFFFFF)
+	b = append(b, AIFFID[:]...)
+
+	// COMM chunk, number of sample frames is patched in Close
+	b = append(b, CommID[:]...)
+	b = binary.BigEndian.AppendUint32(b, 18)
+	b = binary.BigEndian.AppendUint16(b, uint16(e.format.NumChannels))
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = binary.BigEndian.AppendUint16(b, uint16(e.sampleFmt.BitDepth))
+	b = appendExtended(b, float64(e.format.SampleRate)/float64(freq.Hertz))
+
+	// SSND chunk, size is patched in Close
+	b = append(b, SSNDID[:]...)
+	b = binary.BigEndian.AppendUint32(b, 0xFFFFFFFF)
+	b = binary.BigEndian.AppendUint32(b, 0) // offset
+	b = binary.BigEndian.AppendUint32(b, 0) // block size
+
+	_, err := e.w.Write(b)
+	return err
+}
+
+// WriteSamples encodes and writes samples.
+func (e *Encoder) WriteSamples(p []float32) (int, error) {
+	n, err := e.enc.WriteSamples(p)
+	e.samplesWritten += int64(n)
+	return n, err
+}
+
+// Close finalizes the encoding process and writes the length values.
+//
+// It will NOT close the underlying writer, even if it implements [io.Closer].
+// Closing the underlying writer is the owner's responsibility.
+func (e *Encoder) Close() error {
+	dataSize := e.samplesWritten * int64(e.sampleFmt.BitDepth/8)
+	formSize := 4 + (8 + 18) + (8 + 8 + dataSize + dataSize&1)
+	if formSize > math.MaxUint32 {
+		return fmt.Errorf("aiff: data size %d bytes does not fit in the header", dataSize)
+	}
+
+	// chunks are padded to an even length
+	if dataSize&1 != 0 {
+		if _, err := e.w.Write([]byte{0}); err != nil {
+			return fmt.Errorf("aiff: failed to write pad byte: %w", err)
+		}
+	}
+
+	// Patch FORM size
+	if _, err := e.w.Seek(4, io.SeekStart); err != nil {
+		return fmt.Errorf("aiff: failed to seek to FORM size: %w", err)
+	}
+	if err := binary.Write(e.w, binary.BigEndian, uint32(formSize)); err != nil {
+		return fmt.Errorf("aiff: failed to write FORM size: %w", err)
+	}
+
+	// Patch number of sample frames
+	if _, err := e.w.Seek(22, io.SeekStart); err != nil {
+		return fmt.Errorf("aiff: failed to seek to number of sample frames: %w", err)
+	}
+	numFrames := e.samplesWritten / int64(max(e.format.NumChannels, 1))
+	if err := binary.Write(e.w, binary.BigEndian, uint32(numFrames)); err != nil {
+		return fmt.Errorf("aiff: failed to write number of sample frames: %w", err)
+	}
+
+	// Patch SSND chunk size
+	if _, err := e.w.Seek(42, io.SeekStart); err != nil {
+		return fmt.Errorf("aiff: failed to seek to SSND chunk size: %w", err)
+	}
+	if err := binary.Write(e.w, binary.BigEndian, uint32(8+dataSize)); err != nil {
+		return fmt.Errorf("aiff: failed to write SSND chunk size: %w", err)
+	}
+
+	if _, err := e.w.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("aiff: failed to restore file position: %w", err)
+	}
+
+	return nil
+}
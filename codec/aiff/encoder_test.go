@@ -0,0 +1,73 @@
+package aiff_test
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/codec/aiff"
+	"github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+func TestEncoderAgainstWAV(t *testing.T) {
+	for _, bitDepth := range []int{16, 24, 32} {
+		wavFile, _ := encodeWAV(t, afmt.SampleFormat{BitDepth: bitDepth, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}, wav.FormatInt)
+		want := decode(t, wavFile)
+
+		ws := &testutil.WriteSeeker{}
+		enc, err := aiff.NewEncoder(ws, afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: numChannels}, afmt.SampleFormat{BitDepth: bitDepth, Encoding: afmt.SampleEncodingInt})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := enc.WriteSamples(material()); err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := decode(t, ws.Bytes()); !slices.Equal(got, want) {
+			t.Errorf("%d-bit: decoded samples differ from the WAV decode", bitDepth)
+		}
+	}
+}
+
+func TestEncoderPadding(t *testing.T) {
+	ws := &testutil.WriteSeeker{}
+	enc, err := aiff.NewEncoder(ws, afmt.Format{SampleRate: 8000 * freq.Hertz, NumChannels: 1}, afmt.SampleFormat{BitDepth: 8, Encoding: afmt.SampleEncodingInt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.WriteSamples([]float32{0, 0.5, -0.5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b := ws.Bytes()
+	if len(b)%2 != 0 {
+		t.Errorf("expected even file size, got %d", len(b))
+	}
+	if formSize := binary.BigEndian.Uint32(b[4:]); int(formSize) != len(b)-8 {
+		t.Errorf("expected FORM size %d, got %d", len(b)-8, formSize)
+	}
+	if got := decode(t, b); len(got) != 3 {
+		t.Errorf("expected 3 samples, got %d", len(got))
+	}
+}
+
+func TestEncoderInvalidSampleFormat(t *testing.T) {
+	for _, sf := range []afmt.SampleFormat{
+		{BitDepth: 32, Encoding: afmt.SampleEncodingFloat},
+		{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian},
+		{BitDepth: 12, Encoding: afmt.SampleEncodingInt},
+	} {
+		if _, err := aiff.NewEncoder(&testutil.WriteSeeker{}, afmt.Format{SampleRate: 8000 * freq.Hertz, NumChannels: 1}, sf); err == nil {
+			t.Errorf("expected error for sample format %v", sf)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package aiff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Marker represents a marker from the MARK chunk.
+type Marker struct {
+	// ID is the unique marker ID, referenced by [Loop].
+	ID int
+
+	// Position is the marker position in frames.
+	Position int
+
+	// Name is the marker name.
+	Name string
+}
+
+// Loop play modes.
+const (
+	LoopOff             = 0
+	LoopForward         = 1
+	LoopForwardBackward = 2
+)
+
+// Loop represents a loop from the INST chunk.
+type Loop struct {
+	// PlayMode is one of [LoopOff], [LoopForward], or [LoopForwardBackward].
+	PlayMode int
+
+	// Begin and End are the IDs of the markers at the start and end of the loop.
+	Begin, End int
+}
+
+// Instrument represents the instrument data from the INST chunk.
+type Instrument struct {
+	BaseNote     int // MIDI root note
+	Detune       int // in cents (-50 to 50)
+	LowNote      int // lowest MIDI note to play the sound on
+	HighNote     int // highest MIDI note to play the sound on
+	LowVelocity  int // lowest MIDI velocity to play the sound with
+	HighVelocity int // highest MIDI velocity to play the sound with
+	Gain         int // in decibels
+
+	SustainLoop Loop
+	ReleaseLoop Loop
+}
+
+// parseMarkers parses the MARK chunk.
+func parseMarkers(r io.Reader) ([]Marker, error) {
+	var numMarkers uint16
+	if err := binary.Read(r, binary.BigEndian, &numMarkers); err != nil {
+		return nil, fmt.Errorf("failed to read number of markers: %w", err)
+	}
+
+	markers := make([]Marker, 0, numMarkers)
+	for range numMarkers {
+		var hdr struct {
+			ID       uint16
+			Position uint32
+			NameLen  uint8
+		}
+		if err := binary.Read(r, binary.BigEndian, &hdr); err != nil {
+			return nil, fmt.Errorf("failed to read marker: %w", err)
+		}
+
+		// pstrings are padded to an even length, including the count byte
+		name := make([]byte, int(hdr.NameLen)+int(hdr.NameLen+1)&1)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return nil, fmt.Errorf("failed to read marker name: %w", err)
+		}
+
+		markers = append(markers, Marker{
+			ID:       int(hdr.ID),
+			Position: int(hdr.Position),
+			Name:     string(name[:hdr.NameLen]),
+		})
+	}
+
+	return markers, nil
+}
+
+// parseInstrument parses the INST chunk.
+func parseInstrument(r io.Reader) (*Instrument, error) {
+	var inst struct {
+		BaseNote     int8
+		Detune       int8
+		LowNote      int8
+		HighNote     int8
+		LowVelocity  int8
+		HighVelocity int8
+		Gain         int16
+		SustainLoop  [3]int16
+		ReleaseLoop  [3]int16
+	}
+	if err := binary.Read(r, binary.BigEndian, &inst); err != nil {
+		return nil, err
+	}
+
+	loop := func(l [3]int16) Loop {
+		return Loop{PlayMode: int(l[0]), Begin: int(l[1]), End: int(l[2])}
+	}
+
+	return &Instrument{
+		BaseNote:     int(inst.BaseNote),
+		Detune:       int(inst.Detune),
+		LowNote:      int(inst.LowNote),
+		HighNote:     int(inst.HighNote),
+		LowVelocity:  int(inst.LowVelocity),
+		HighVelocity: int(inst.HighVelocity),
+		Gain:         int(inst.Gain),
+		SustainLoop:  loop(inst.SustainLoop),
+		ReleaseLoop:  loop(inst.ReleaseLoop),
+	}, nil
+}
@@ -2,3 +2,7 @@
 package au
 
 const magic = ".snd"
+
+// UnknownSize is the data size value indicating that the length of the audio data is unknown.
+// It is commonly used by streamed AU files.
+const UnknownSize uint32 = 0xFFFFFFFF
@@ -0,0 +1,70 @@
+package au_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec/au"
+	"github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+// TestWAVToAU decodes a WAV file, encodes it to AU and decodes it again, all through aio.Copy.
+func TestWAVToAU(t *testing.T) {
+	format := afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 2}
+	samples := make([]float32, 2000)
+	for i := range samples {
+		samples[i] = float32(0.8 * math.Sin(float64(i)/10))
+	}
+
+	// samples -> WAV
+	wavBuf := &testutil.WriteSeeker{}
+	wavEnc, err := wav.NewEncoder(wavBuf, format, afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingFloat, Endian: binary.LittleEndian}, wav.FormatFloat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wavEnc.WriteSamples(samples); err != nil {
+		t.Fatal(err)
+	}
+	if err := wavEnc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// WAV -> AU
+	wavDec, err := wav.NewDecoder(bytes.NewReader(wavBuf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auBuf := &testutil.WriteSeeker{}
+	auEnc, err := au.NewEncoder(auBuf, wavDec.Format(), au.LPCMInt16, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := aio.Copy(auEnc, wavDec); err != nil {
+		t.Fatal(err)
+	}
+	if err := auEnc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// AU -> samples
+	auDec, err := au.NewDecoder(bytes.NewReader(auBuf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auDec.Format() != format {
+		t.Errorf("expected format %v, got %v", format, auDec.Format())
+	}
+	decoded, err := aio.ReadAll(auDec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !testutil.EqualSliceWithinTolerance(decoded, samples, 1.0/(1<<14)) {
+		t.Errorf("decoded samples do not match original samples")
+	}
+}
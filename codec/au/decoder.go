@@ -1,6 +1,7 @@
 package au
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -10,6 +11,8 @@ import (
 	"github.com/MatusOllah/resona/aio"
 	"github.com/MatusOllah/resona/codec"
 	"github.com/MatusOllah/resona/encoding/g711"
+	"github.com/MatusOllah/resona/encoding/g722"
+	"github.com/MatusOllah/resona/encoding/g726"
 	"github.com/MatusOllah/resona/encoding/pcm"
 	"github.com/MatusOllah/resona/freq"
 )
@@ -17,51 +20,55 @@ import (
 // Decoder represents the decoder for the AU file format.
 // It implements codec.Decoder.
 type Decoder struct {
-	r        io.Reader
-	dataRead int
+	r           io.Reader
+	samplesRead int64
 
 	dec aio.SampleReader
 
+	dataOffset  uint32
 	dataSize    uint32
 	Encoding    uint32 // Encoding is the audio encoding type.
 	sampleRate  uint32
 	numChannels uint32
+	annotation  string
 }
 
+var _ codec.Decoder = (*Decoder)(nil)
+
 // NewDecoder creates a new [Decoder] and decodes the headers.
 func NewDecoder(r io.Reader) (codec.Decoder, error) {
 	d := &Decoder{r: r}
+	headerRead := 0
 
 	// Read magic
 	var buf [len(magic)]byte
 	if _, err := io.ReadFull(r, buf[:]); err != nil {
 		return nil, err
 	}
-	d.dataRead += len(buf)
+	headerRead += len(buf)
 	if string(buf[:]) != magic {
 		return nil, errors.New("au: invalid header")
 	}
 
 	// Read offset
-	var offset uint32
-	if err := binary.Read(r, binary.BigEndian, &offset); err != nil {
+	if err := binary.Read(r, binary.BigEndian, &d.dataOffset); err != nil {
 		return nil, fmt.Errorf("au: failed to read offset: %w", err)
 	}
-	d.dataRead += 4
+	headerRead += 4
 
 	// Read data size
 	if err := binary.Read(r, binary.BigEndian, &d.dataSize); err != nil {
 		return nil, fmt.Errorf("au: failed to read data size: %w", err)
 	}
-	d.dataRead += 4
+	headerRead += 4
 
 	// Read encoding
 	if err := binary.Read(r, binary.BigEndian, &d.Encoding); err != nil {
 		return nil, fmt.Errorf("au: failed to read encoding: %w", err)
 	}
-	d.dataRead += 4
+	headerRead += 4
 
-	if d.Encoding < 1 || (d.Encoding > 7 && d.Encoding != 27) {
+	if d.Encoding < 1 || (d.Encoding > 7 && (d.Encoding < G721 || d.Encoding > Alaw)) {
 		return nil, fmt.Errorf("au: unsupported encoding %d", d.Encoding)
 	}
 
@@ -69,32 +76,64 @@ func NewDecoder(r io.Reader) (codec.Decoder, error) {
 	if err := binary.Read(r, binary.BigEndian, &d.sampleRate); err != nil {
 		return nil, fmt.Errorf("au: failed to read sample rate: %w", err)
 	}
-	d.dataRead += 4
+	headerRead += 4
 
 	// Read number of channels
 	if err := binary.Read(r, binary.BigEndian, &d.numChannels); err != nil {
 		return nil, fmt.Errorf("au: failed to read number of channels: %w", err)
 	}
-	d.dataRead += 4
+	headerRead += 4
 
-	if _, err := io.CopyN(io.Discard, r, int64(offset)-int64(d.dataRead)); err != nil {
-		return nil, fmt.Errorf("au: failed to skip to data: %w", err)
+	// Read annotation
+	if int(d.dataOffset) < headerRead {
+		return nil, fmt.Errorf("au: invalid data offset %d", d.dataOffset)
+	}
+	annotation := make([]byte, int(d.dataOffset)-headerRead)
+	if _, err := io.ReadFull(r, annotation); err != nil {
+		return nil, fmt.Errorf("au: failed to read annotation: %w", err)
+	}
+	if i := bytes.IndexByte(annotation, 0); i >= 0 {
+		annotation = annotation[:i]
 	}
-	d.dataRead += int(offset) - d.dataRead
+	d.annotation = string(annotation)
 
 	switch d.Encoding {
 	case Ulaw:
 		d.dec = g711.NewUlawDecoder(r)
 	case Alaw:
 		d.dec = g711.NewAlawDecoder(r)
+	case G721:
+		d.dec = g726.NewDecoder(r, int(d.numChannels))
+	case G722:
+		if d.numChannels != 1 {
+			return nil, fmt.Errorf("au: G.722 supports only mono audio, got %d channels", d.numChannels)
+		}
+		d.dec = g722.NewDecoder(r)
+	case G723Bits3:
+		d.dec = g726.NewDecoder(r, int(d.numChannels), g726.WithBitrate(g726.Bitrate24k))
+	case G723Bits5:
+		d.dec = g726.NewDecoder(r, int(d.numChannels), g726.WithBitrate(g726.Bitrate40k))
 	default:
 		d.dec = pcm.NewDecoder(r, d.SampleFormat())
 	}
-	d.dataRead = 0
 
 	return d, nil
 }
 
+// Annotation returns the annotation (description) stored between the header and the audio data.
+// It is trimmed at the first NUL byte.
+func (d *Decoder) Annotation() string {
+	return d.annotation
+}
+
+// Tags returns the annotation as the "COMMENT" tag. It implements codec.Metadata.
+func (d *Decoder) Tags() map[string][]string {
+	if d.annotation == "" {
+		return map[string][]string{}
+	}
+	return map[string][]string{"COMMENT": {d.annotation}}
+}
+
 // Format returns the audio stream format.
 func (d *Decoder) Format() afmt.Format {
 	return afmt.Format{
@@ -131,6 +170,15 @@ func (d *Decoder) SampleFormat() afmt.SampleFormat {
 	case Alaw:
 		format.BitDepth = 8
 		format.Encoding = afmt.SampleEncodingUnknown
+	case G721, G722:
+		format.BitDepth = 4
+		format.Encoding = afmt.SampleEncodingUnknown
+	case G723Bits3:
+		format.BitDepth = 3
+		format.Encoding = afmt.SampleEncodingUnknown
+	case G723Bits5:
+		format.BitDepth = 5
+		format.Encoding = afmt.SampleEncodingUnknown
 	default:
 		panic(fmt.Errorf("au: unsupported encoding %d", d.Encoding))
 	}
@@ -138,15 +186,19 @@ func (d *Decoder) SampleFormat() afmt.SampleFormat {
 }
 
 // Len returns the total number of frames.
+// It returns 0 if the data size is [UnknownSize].
 func (d *Decoder) Len() int {
-	return int(d.dataSize) / d.SampleFormat().BitDepth
+	if d.dataSize == UnknownSize {
+		return 0
+	}
+	return int(int64(d.dataSize) * 8 / int64(d.SampleFormat().BitDepth*int(d.numChannels)))
 }
 
 // ReadSamples reads float32 samples into p.
 // It returns the number of samples read and/or an error.
 func (d *Decoder) ReadSamples(p []float32) (int, error) {
 	n, err := d.dec.ReadSamples(p)
-	d.dataRead += n * (d.SampleFormat().BitDepth / 8)
+	d.samplesRead += int64(n)
 	return n, err
 }
 
@@ -154,43 +206,57 @@ func (d *Decoder) ReadSamples(p []float32) (int, error) {
 // It returns the new offset relative to the start and/or an error.
 // It will return an error if the source is not an [io.Seeker].
 func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
+	// Special case
+	if offset == 0 && whence == io.SeekCurrent {
+		return d.samplesRead / int64(d.numChannels), nil
+	}
+
 	s, ok := d.r.(io.Seeker)
 	if !ok {
 		return 0, fmt.Errorf("au: resource does not support seeking")
 	}
-
-	// Special case
-	if offset == 0 && whence == io.SeekCurrent {
-		return int64(d.dataRead) / int64(d.SampleFormat().BytesPerFrame(int(d.numChannels))), nil
+	if d.Encoding >= G721 && d.Encoding <= G723Bits5 {
+		return 0, fmt.Errorf("au: seeking is not supported for ADPCM encodings")
 	}
 
-	frameSize := d.SampleFormat().BytesPerFrame(int(d.numChannels))
-	totalFrames := int64(d.dataSize) / int64(frameSize)
+	totalFrames := int64(d.Len())
+	unknownSize := d.dataSize == UnknownSize
 
 	var target int64
 	switch whence {
 	case io.SeekStart:
 		target = offset
 	case io.SeekCurrent:
-		target = int64(d.dataRead)/int64(frameSize) + offset
+		target = d.samplesRead/int64(d.numChannels) + offset
 	case io.SeekEnd:
-		target = int64(totalFrames) + offset
+		if unknownSize {
+			return 0, fmt.Errorf("au: cannot seek relative to end of stream with unknown size")
+		}
+		target = totalFrames + offset
 	default:
 		return 0, fmt.Errorf("au: invalid seek whence")
 	}
 
-	if target < 0 || target > totalFrames {
+	if target < 0 || (!unknownSize && target > totalFrames) {
 		return 0, fmt.Errorf("au: seek out of bounds")
 	}
 
-	byteOffset := target * int64(frameSize)
-
-	_, err := s.Seek(byteOffset, io.SeekStart)
+	var err error
+	if ds, ok := d.dec.(io.Seeker); ok {
+		// G.711 decoders seek by themselves, one byte per sample
+		_, err = ds.Seek(target*int64(d.numChannels), io.SeekStart)
+	} else {
+		byteOffset := target * int64(d.SampleFormat().BytesPerFrame(int(d.numChannels)))
+		_, err = s.Seek(int64(d.dataOffset)+byteOffset, io.SeekStart)
+		if pd, ok := d.dec.(*pcm.Decoder); ok {
+			pd.Reset(d.r)
+		}
+	}
 	if err != nil {
 		return 0, fmt.Errorf("au: failed to seek: %w", err)
 	}
 
-	d.dataRead = int(byteOffset)
+	d.samplesRead = target * int64(d.numChannels)
 	return target, nil
 }
 
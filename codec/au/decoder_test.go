@@ -0,0 +1,313 @@
+package au_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec/au"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/encoding/g722"
+	"github.com/MatusOllah/resona/encoding/g726"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+func encode(t *testing.T, encoding uint32, numChannels, numFrames int, opts ...au.EncoderOption) []byte {
+	t.Helper()
+
+	ws := &testutil.WriteSeeker{}
+	enc, err := au.NewEncoder(ws, afmt.Format{SampleRate: 8 * freq.KiloHertz, NumChannels: numChannels}, encoding, nil, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make([]float32, numFrames*numChannels)
+	for i := range samples {
+		samples[i] = float32(i%200)/100 - 1
+	}
+	if _, err := enc.WriteSamples(samples); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return ws.Bytes()
+}
+
+func drain(t *testing.T, dec interface {
+	ReadSamples([]float32) (int, error)
+}) int {
+	t.Helper()
+	buf := make([]float32, 333)
+	total := 0
+	for {
+		n, err := dec.ReadSamples(buf)
+		total += n
+		if errors.Is(err, io.EOF) {
+			return total
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			return total
+		}
+	}
+}
+
+func TestDecoderLen(t *testing.T) {
+	tests := []struct {
+		name        string
+		encoding    uint32
+		numChannels int
+	}{
+		{"Ulaw", au.Ulaw, 1},
+		{"Int16", au.LPCMInt16, 2},
+		{"Float32", au.LPCMFloat32, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const numFrames = 1000
+			dec, err := au.NewDecoder(bytes.NewReader(encode(t, tt.encoding, tt.numChannels, numFrames)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dec.Len() != numFrames {
+				t.Errorf("expected Len %d, got %d", numFrames, dec.Len())
+			}
+			if got := drain(t, dec); got != dec.Len()*tt.numChannels {
+				t.Errorf("expected %d samples, got %d", dec.Len()*tt.numChannels, got)
+			}
+		})
+	}
+}
+
+func TestDecoderUnknownSize(t *testing.T) {
+	b := encode(t, au.LPCMInt16, 1, 100)
+	binary.BigEndian.PutUint32(b[8:], au.UnknownSize)
+
+	dec, err := au.NewDecoder(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Len() != 0 {
+		t.Errorf("expected Len 0 for unknown size, got %d", dec.Len())
+	}
+	if got := drain(t, dec); got != 100 {
+		t.Errorf("expected 100 samples, got %d", got)
+	}
+	if _, err := dec.Seek(0, io.SeekEnd); err == nil {
+		t.Errorf("expected error when seeking relative to end with unknown size")
+	}
+}
+
+func TestDecoderSeek(t *testing.T) {
+	const numFrames = 1000
+	dec, err := au.NewDecoder(bytes.NewReader(encode(t, au.LPCMInt16, 2, numFrames)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pos, err := dec.Seek(-100, io.SeekEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != numFrames-100 {
+		t.Errorf("expected position %d, got %d", numFrames-100, pos)
+	}
+	if got := drain(t, dec); got != 100*2 {
+		t.Errorf("expected %d samples after seek, got %d", 100*2, got)
+	}
+
+	if _, err := dec.Seek(numFrames+1, io.SeekStart); err == nil {
+		t.Errorf("expected error when seeking out of bounds")
+	}
+}
+
+func TestDecoderSeekG711(t *testing.T) {
+	const numFrames = 1000
+	b := encode(t, au.Alaw, 2, numFrames, au.WithAnnotation("seek"))
+
+	dec, err := au.NewDecoder(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pos := range []int64{500, 0, 999} {
+		if got, err := dec.Seek(pos, io.SeekStart); err != nil || got != pos {
+			t.Fatalf("seek to %d: got %d, %v", pos, got, err)
+		}
+		got := make([]float32, 2)
+		if _, err := aio.ReadFull(dec, got); err != nil {
+			t.Fatal(err)
+		}
+		if got[0] != want[2*pos] || got[1] != want[2*pos+1] {
+			t.Errorf("frame %d: expected %v, got %v", pos, want[2*pos:2*pos+2], got)
+		}
+	}
+}
+
+func TestDecoderADPCM(t *testing.T) {
+	const numFrames = 1000
+
+	samples := make([]float32, numFrames)
+	for i := range samples {
+		samples[i] = float32(math.Sin(2 * math.Pi * 440 * float64(i) / 8000))
+	}
+
+	tests := []struct {
+		name       string
+		encoding   uint32
+		sampleRate uint32
+		bitDepth   int
+		newEncoder func(w io.Writer) aio.SampleWriteCloser
+	}{
+		{"G721", au.G721, 8000, 4, func(w io.Writer) aio.SampleWriteCloser { return g726.NewEncoder(w, 1) }},
+		{"G722", au.G722, 16000, 4, func(w io.Writer) aio.SampleWriteCloser { return g722.NewEncoder(w) }},
+		{"G723Bits3", au.G723Bits3, 8000, 3, func(w io.Writer) aio.SampleWriteCloser {
+			return g726.NewEncoder(w, 1, g726.WithBitrate(g726.Bitrate24k))
+		}},
+		{"G723Bits5", au.G723Bits5, 8000, 5, func(w io.Writer) aio.SampleWriteCloser {
+			return g726.NewEncoder(w, 1, g726.WithBitrate(g726.Bitrate40k))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data bytes.Buffer
+			enc := tt.newEncoder(&data)
+			if _, err := enc.WriteSamples(samples); err != nil {
+				t.Fatal(err)
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			b := []byte(".snd")
+			for _, v := range []uint32{24, uint32(data.Len()), tt.encoding, tt.sampleRate, 1} {
+				b = binary.BigEndian.AppendUint32(b, v)
+			}
+			b = append(b, data.Bytes()...)
+
+			dec, err := au.NewDecoder(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bd := dec.SampleFormat().BitDepth; bd != tt.bitDepth {
+				t.Errorf("expected bit depth %d, got %d", tt.bitDepth, bd)
+			}
+			if dec.Len() != numFrames {
+				t.Errorf("expected Len %d, got %d", numFrames, dec.Len())
+			}
+			if got := drain(t, dec); got < numFrames || got >= numFrames+8 {
+				t.Errorf("expected %d samples (plus padding), got %d", numFrames, got)
+			}
+			if _, err := dec.Seek(0, io.SeekStart); err == nil {
+				t.Errorf("expected error when seeking ADPCM audio")
+			}
+		})
+	}
+}
+
+// testdata/g721.au and its decoded samples are generated by testdata/generate.py with the reference coder
+// of encoding/g726/testdata, which is written after the block diagrams of ITU-T G.726.
+func TestDecoderG721Golden(t *testing.T) {
+	f, err := os.Open("testdata/g721.au")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	b, err := os.ReadFile("testdata/g721.pcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec, err := au.NewDecoder(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := dec.(*au.Decoder).Annotation(); got != "G.721 reference" {
+		t.Errorf("expected annotation %q, got %q", "G.721 reference", got)
+	}
+	if dec.Len() != len(b)/2 {
+		t.Errorf("expected Len %d, got %d", len(b)/2, dec.Len())
+	}
+
+	got, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(b)/2 {
+		t.Fatalf("expected %d samples, got %d", len(b)/2, len(got))
+	}
+	for i := range got {
+		sr := int16(binary.LittleEndian.Uint16(b[2*i:]))
+		if want := dsp.Clamp(float32(int(sr)<<2) / (1<<15 - 1)); got[i] != want {
+			t.Fatalf("sample %d: expected %v (SR %d), got %v", i, want, sr, got[i])
+		}
+	}
+}
+
+func TestDecoderG722Stereo(t *testing.T) {
+	b := []byte(".snd")
+	for _, v := range []uint32{24, 0, au.G722, 16000, 2} {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	if _, err := au.NewDecoder(bytes.NewReader(b)); err == nil {
+		t.Error("expected error for stereo G.722")
+	}
+}
+
+func TestAnnotationRoundTrip(t *testing.T) {
+	for _, annotation := range []string{"", "abc", "abcd", "Processed by SoX"} {
+		b := encode(t, au.LPCMInt16, 1, 100, au.WithAnnotation(annotation))
+
+		offset := binary.BigEndian.Uint32(b[4:])
+		if offset%4 != 0 || offset < 24+uint32(len(annotation))+1 {
+			t.Errorf("%q: invalid data offset %d", annotation, offset)
+		}
+
+		dec, err := au.NewDecoder(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := dec.(*au.Decoder).Annotation(); got != annotation {
+			t.Errorf("expected annotation %q, got %q", annotation, got)
+		}
+		if got := drain(t, dec); got != 100 {
+			t.Errorf("%q: expected 100 samples, got %d", annotation, got)
+		}
+	}
+}
+
+func TestAnnotation(t *testing.T) {
+	// annotation followed by garbage after the terminating NUL
+	b := []byte(".snd")
+	for _, v := range []uint32{32, 4, au.LPCMInt16, 8000, 1} {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	b = append(b, "hello\x00xy"...)
+	b = append(b, 0x12, 0x34, 0x56, 0x78)
+
+	dec, err := au.NewDecoder(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := dec.(*au.Decoder).Annotation(); got != "hello" {
+		t.Errorf("expected annotation %q, got %q", "hello", got)
+	}
+	if dec.Len() != 2 {
+		t.Errorf("expected Len 2, got %d", dec.Len())
+	}
+}
@@ -11,6 +11,19 @@ import (
 	"github.com/MatusOllah/resona/encoding/pcm"
 )
 
+// EncoderOption represents an option for configuring [Encoder].
+type EncoderOption func(*Encoder)
+
+// WithAnnotation sets the annotation (description) stored between the header and the audio data.
+// The annotation is NUL-terminated and padded to a 4-byte boundary. It replaces any extra data passed to [NewEncoder].
+func WithAnnotation(annotation string) EncoderOption {
+	return func(e *Encoder) {
+		size := (len(annotation) + 1 + 3) &^ 3
+		e.extraData = make([]byte, size)
+		copy(e.extraData, annotation)
+	}
+}
+
 // Encoder represents the encoder for the AU file format.
 //
 // The caller retains ownership of the writer; it will not be closed automatically.
@@ -23,8 +36,11 @@ type Encoder struct {
 	dataWritten int
 }
 
+var _ aio.SampleWriteCloser = (*Encoder)(nil)
+
 // NewEncoder creates a new [Encoder] for AU format.
-func NewEncoder(w io.WriteSeeker, format afmt.Format, encoding uint32, extraData []byte) (*Encoder, error) {
+// ExtraData is written as-is between the header and the audio data; use [WithAnnotation] to write a text annotation.
+func NewEncoder(w io.WriteSeeker, format afmt.Format, encoding uint32, extraData []byte, opts ...EncoderOption) (*Encoder, error) {
 	e := &Encoder{
 		w:         w,
 		format:    format,
@@ -32,6 +48,11 @@ func NewEncoder(w io.WriteSeeker, format afmt.Format, encoding uint32, extraData
 		extraData: extraData,
 	}
 
+	// apply options
+	for _, opt := range opts {
+		opt(e)
+	}
+
 	if err := e.writeHeader(); err != nil {
 		return nil, fmt.Errorf("au: failed to write header: %w", err)
 	}
@@ -98,7 +119,7 @@ func (e *Encoder) writeHeader() error {
 	}
 
 	// Write data size (placeholder)
-	if err := binary.Write(e.w, binary.BigEndian, uint32(0xFFFFFFFF)); err != nil {
+	if err := binary.Write(e.w, binary.BigEndian, UnknownSize); err != nil {
 		return err
 	}
 
@@ -130,7 +151,7 @@ func (e *Encoder) writeHeader() error {
 // WriteSamples encodes and writes samples.
 func (e *Encoder) WriteSamples(p []float32) (int, error) {
 	n, err := e.enc.WriteSamples(p)
-	e.dataWritten += n
+	e.dataWritten += n * (e.sampleFormat().BitDepth / 8)
 	return n, err
 }
 
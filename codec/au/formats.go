@@ -9,5 +9,9 @@ const (
 	LPCMInt32   uint32 = 5  // Linear PCM 32-bit integer
 	LPCMFloat32 uint32 = 6  // Linear PCM 32-bit float
 	LPCMFloat64 uint32 = 7  // Linear PCM 64-bit float
+	G721        uint32 = 23 // G.721 (G.726 32 kbit/s) 4-bit ADPCM, decoding only
+	G722        uint32 = 24 // G.722 wideband ADPCM (16 kHz mono, 64 kbit/s), decoding only
+	G723Bits3   uint32 = 25 // G.723 (G.726 24 kbit/s) 3-bit ADPCM, decoding only
+	G723Bits5   uint32 = 26 // G.723 (G.726 40 kbit/s) 5-bit ADPCM, decoding only
 	Alaw        uint32 = 27 // G.711 A-law 8-bit
 )
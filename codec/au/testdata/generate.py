@@ -0,0 +1,47 @@
+#!/usr/bin/env python3
+
+"""
+Generates a golden G.721 AU file with the G.726 reference coder of encoding/g726/testdata/generate.py,
+which is written after the block diagrams of ITU-T G.726, independently of the Go code.
+Do not edit the output files manually!
+
+Outputs:
+  g721.au   8 kHz mono G.721 (G.726 32 kbit/s) audio with an annotation, codewords packed least significant bits first
+  g721.pcm  the codewords decoded into little-endian 16-bit reconstructed signal samples (SR)
+"""
+
+import math
+import os
+import struct
+import sys
+
+sys.path.insert(0, os.path.join(os.path.dirname(__file__), "..", "..", "..", "encoding", "g726", "testdata"))
+
+from generate import Coder, pack  # noqa: E402
+
+ANNOTATION = b"G.721 reference\x00"
+
+
+def main():
+    # a speech-like mix of harmonics with a varying envelope, in 14-bit samples
+    sl = []
+    for k in range(3000):
+        t = k / 8000
+        env = 0.2 + 0.8 * abs(math.sin(2 * math.pi * 3 * t))
+        x = sum(2000 / h * math.sin(2 * math.pi * 150 * h * t) for h in range(1, 8))
+        sl.append(max(-8192, min(8191, int(env * x))))
+
+    enc = Coder(32)
+    codes = [enc.encode(x) for x in sl]
+    dec = Coder(32)
+    sr = [dec.decode(i) for i in codes]
+
+    data = pack(codes, 4)
+    header = struct.pack(">4sIIIII", b".snd", 24 + len(ANNOTATION), len(data), 23, 8000, 1)
+    with open("g721.au", "wb") as f:
+        f.write(header + ANNOTATION + data)
+    with open("g721.pcm", "wb") as f:
+        f.write(struct.pack(f"<{len(sr)}h", *sr))
+
+
+if __name__ == "__main__": main()
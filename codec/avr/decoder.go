@@ -22,18 +22,18 @@ type Decoder struct {
 
 	dec aio.SampleReader
 
-	title    [8]byte
-	stereo   int16
-	bitDepth int16
-	signed   int16
-	loop     int16
-	//midiNote int16
-	sampleRate uint32
-	length     uint32 // Length in frames (groups of samples)
-	loopStart  uint32
-	loopEnd    uint32
-	//keySplit int16
-	//compression int16
+	title       [8]byte
+	stereo      int16
+	bitDepth    int16
+	signed      int16
+	loop        int16
+	midiNote    uint16
+	sampleRate  uint32
+	length      uint32 // Length in frames (groups of samples)
+	loopStart   uint32
+	loopEnd     uint32
+	keySplit    int16
+	compression int16
 	//reserved int16
 	titleExtra [20]byte
 	comment    [64]byte
@@ -77,9 +77,9 @@ func NewDecoder(r io.Reader) (codec.Decoder, error) {
 		return nil, fmt.Errorf("avr: failed to read loop value: %w", err)
 	}
 
-	// Skip midiNote
-	if _, err := io.CopyN(io.Discard, r, 2); err != nil { // int16 = 2 bytes
-		return nil, err
+	// Read MIDI note
+	if err := binary.Read(r, binary.BigEndian, &d.midiNote); err != nil {
+		return nil, fmt.Errorf("avr: failed to read MIDI note: %w", err)
 	}
 
 	// Read sample rate
@@ -103,8 +103,21 @@ func NewDecoder(r io.Reader) (codec.Decoder, error) {
 		return nil, fmt.Errorf("avr: failed to read loop end")
 	}
 
-	// Skip key split, compression, and reserved
-	if _, err := io.CopyN(io.Discard, r, 6); err != nil { // int16 = 2 bytes
+	// Read key split
+	if err := binary.Read(r, binary.BigEndian, &d.keySplit); err != nil {
+		return nil, fmt.Errorf("avr: failed to read key split: %w", err)
+	}
+
+	// Read compression
+	if err := binary.Read(r, binary.BigEndian, &d.compression); err != nil {
+		return nil, fmt.Errorf("avr: failed to read compression: %w", err)
+	}
+	if d.Compressed() {
+		return nil, errors.New("avr: compressed sample data is not supported")
+	}
+
+	// Skip reserved
+	if _, err := io.CopyN(io.Discard, r, 2); err != nil { // int16 = 2 bytes
 		return nil, err
 	}
 
@@ -147,6 +160,30 @@ func (d *Decoder) Loop() bool {
 	}
 }
 
+// MIDINote returns the MIDI root note, or -1 if no MIDI note is assigned.
+// For key split assignments, it returns the lower note of the split.
+func (d *Decoder) MIDINote() int {
+	switch {
+	case d.midiNote == 0xffff:
+		return -1
+	case d.midiNote&0xff00 == 0xff00:
+		return int(d.midiNote & 0xff) // single key note assignment (0xffXX)
+	default:
+		return int(d.midiNote >> 8) // key split, low/high note (0xLLHH)
+	}
+}
+
+// KeySplit returns the key split value.
+func (d *Decoder) KeySplit() int {
+	return int(d.keySplit)
+}
+
+// Compressed returns whether the sample data is compressed.
+// Compressed files are rejected by [NewDecoder], so this always returns false for a successfully created [Decoder].
+func (d *Decoder) Compressed() bool {
+	return d.compression != 0
+}
+
 // LoopStart returns the loop start point in frames.
 func (d *Decoder) LoopStart() int {
 	return int(d.loopStart)
@@ -258,6 +295,7 @@ func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
 	if err != nil {
 		return 0, fmt.Errorf("avr: failed to seek: %w", err)
 	}
+	d.dec.(*pcm.Decoder).Reset(d.r)
 
 	d.dataRead = int(byteOffset)
 	return target, nil
@@ -0,0 +1,97 @@
+package avr_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec/avr"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+var sampleFmt = afmt.SampleFormat{BitDepth: 8, Encoding: afmt.SampleEncodingInt, Endian: binary.BigEndian}
+
+// encode encodes samples as a mono 8-bit AVR file.
+func encode(t *testing.T, samples []float32, opts ...avr.EncoderOption) []byte {
+	t.Helper()
+
+	ws := &testutil.WriteSeeker{}
+	enc, err := avr.NewEncoder(ws, afmt.Format{SampleRate: 22050 * freq.Hertz, NumChannels: 1}, sampleFmt, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.WriteSamples(samples); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return ws.Bytes()
+}
+
+func TestDecoderMIDINote(t *testing.T) {
+	samples := []float32{0, 0.5, -0.5, 0.25}
+
+	dec, err := avr.NewDecoder(bytes.NewReader(encode(t, samples, avr.WithMIDINote(60))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := dec.(*avr.Decoder)
+	if d.MIDINote() != 60 {
+		t.Errorf("expected MIDI note 60, got %d", d.MIDINote())
+	}
+	if d.KeySplit() != 0 {
+		t.Errorf("expected key split 0, got %d", d.KeySplit())
+	}
+	if d.Compressed() {
+		t.Error("expected uncompressed sample data")
+	}
+
+	got, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(samples) {
+		t.Errorf("expected %d samples, got %d", len(samples), len(got))
+	}
+}
+
+func TestDecoderNoMIDINote(t *testing.T) {
+	dec, err := avr.NewDecoder(bytes.NewReader(encode(t, []float32{0})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note := dec.(*avr.Decoder).MIDINote(); note != -1 {
+		t.Errorf("expected MIDI note -1, got %d", note)
+	}
+}
+
+func TestDecoderKeySplitNote(t *testing.T) {
+	b := encode(t, []float32{0})
+	b[20], b[21] = 36, 84 // 0xLLHH key split
+	dec, err := avr.NewDecoder(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note := dec.(*avr.Decoder).MIDINote(); note != 36 {
+		t.Errorf("expected MIDI note 36, got %d", note)
+	}
+}
+
+func TestDecoderCompressed(t *testing.T) {
+	b := encode(t, []float32{0})
+	b[40], b[41] = 0xff, 0xff // compression
+	if _, err := avr.NewDecoder(bytes.NewReader(b)); err == nil {
+		t.Error("expected error for compressed sample data")
+	}
+}
+
+func TestEncoderInvalidMIDINote(t *testing.T) {
+	_, err := avr.NewEncoder(&testutil.WriteSeeker{}, afmt.Format{SampleRate: 22050 * freq.Hertz, NumChannels: 1}, sampleFmt, avr.WithMIDINote(128))
+	if err == nil {
+		t.Error("expected error for invalid MIDI note")
+	}
+}
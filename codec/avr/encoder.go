@@ -2,8 +2,11 @@ package avr
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"unicode/utf8"
 
 	"github.com/MatusOllah/resona/afmt"
 	"github.com/MatusOllah/resona/aio"
@@ -27,6 +30,22 @@ func WithExtraTitle(extraTitle [20]byte) EncoderOption {
 	}
 }
 
+// WithTitleString sets the title from a string.
+// The first 8 bytes are stored in the title and the rest in the extra title, up to 28 bytes in total.
+// Longer strings are truncated and shorter ones are padded with 0s.
+// [NewEncoder] returns an error if the string is not valid UTF-8 or if truncation would split a character.
+func WithTitleString(title string) EncoderOption {
+	return func(e *Encoder) {
+		var buf [28]byte
+		if err := putString(buf[:], title); err != nil {
+			e.optErr = fmt.Errorf("invalid title: %w", err)
+			return
+		}
+		copy(e.title[:], buf[:8])
+		copy(e.titleExtra[:], buf[8:])
+	}
+}
+
 // WithComment sets the comment.
 func WithComment(comment [64]byte) EncoderOption {
 	return func(e *Encoder) {
@@ -34,7 +53,33 @@ func WithComment(comment [64]byte) EncoderOption {
 	}
 }
 
+// WithCommentString sets the comment from a string.
+// Strings longer than 64 bytes are truncated and shorter ones are padded with 0s.
+// [NewEncoder] returns an error if the string is not valid UTF-8 or if truncation would split a character.
+func WithCommentString(comment string) EncoderOption {
+	return func(e *Encoder) {
+		e.comment = [64]byte{}
+		if err := putString(e.comment[:], comment); err != nil {
+			e.optErr = fmt.Errorf("invalid comment: %w", err)
+		}
+	}
+}
+
+// putString copies s into dst, truncating it to len(dst) bytes.
+// The rest of dst is left as is.
+func putString(dst []byte, s string) error {
+	if !utf8.ValidString(s) {
+		return errors.New("not valid UTF-8")
+	}
+	n := copy(dst, s)
+	if n < len(s) && !utf8.RuneStart(s[n]) {
+		return fmt.Errorf("truncating to %d bytes splits a UTF-8 character", len(dst))
+	}
+	return nil
+}
+
 // WithLoop enables and configures the loop.
+// The start must be before the end, and the end must not exceed the number of frames written.
 func WithLoop(start, end int) EncoderOption {
 	return func(e *Encoder) {
 		e.loop = -1
@@ -43,6 +88,13 @@ func WithLoop(start, end int) EncoderOption {
 	}
 }
 
+// WithMIDINote sets the MIDI root note (0-127).
+func WithMIDINote(note int) EncoderOption {
+	return func(e *Encoder) {
+		e.midiNote = note
+	}
+}
+
 // Encoder represents the encoder for the AVR file format.
 //
 // The caller retains ownership of the writer; it will not be closed automatically.
@@ -55,9 +107,11 @@ type Encoder struct {
 	titleExtra  [20]byte
 	comment     [64]byte
 	loop        int16
+	midiNote    int
 	loopStart   uint32
 	loopEnd     uint32
 	dataWritten int
+	optErr      error
 }
 
 // NewEncoder creates a new [Encoder] for the AVR format.
@@ -66,12 +120,16 @@ func NewEncoder(w io.WriteSeeker, format afmt.Format, sampleFmt afmt.SampleForma
 		w:         w,
 		format:    format,
 		sampleFmt: sampleFmt,
+		midiNote:  -1,
 	}
 
 	// apply options
 	for _, opt := range opts {
 		opt(e)
 	}
+	if e.optErr != nil {
+		return nil, fmt.Errorf("avr: %w", e.optErr)
+	}
 
 	// validate sample format
 	if (sampleFmt.Encoding != afmt.SampleEncodingInt && sampleFmt.Encoding != afmt.SampleEncodingUint) ||
@@ -83,8 +141,13 @@ func NewEncoder(w io.WriteSeeker, format afmt.Format, sampleFmt afmt.SampleForma
 	}
 
 	// validate loop info
-	if e.loop == -1 && e.loopEnd <= e.loopStart {
-		return nil, fmt.Errorf("avr: invalid loop range (%d-%d)", e.loopStart, e.loopEnd)
+	if err := e.validateLoop(-1); err != nil {
+		return nil, err
+	}
+
+	// validate MIDI note
+	if e.midiNote < -1 || e.midiNote > 127 {
+		return nil, fmt.Errorf("avr: invalid MIDI note %d", e.midiNote)
 	}
 
 	// write header
@@ -97,6 +160,18 @@ func NewEncoder(w io.WriteSeeker, format afmt.Format, sampleFmt afmt.SampleForma
 	return e, nil
 }
 
+// validateLoop checks that start < end <= length when looping is enabled.
+// A negative length means the length is not yet known.
+func (e *Encoder) validateLoop(length int64) error {
+	if e.loop != -1 {
+		return nil
+	}
+	if e.loopStart >= e.loopEnd || (length >= 0 && int64(e.loopEnd) > length) {
+		return fmt.Errorf("avr: invalid loop range (%d-%d)", e.loopStart, e.loopEnd)
+	}
+	return nil
+}
+
 func (e *Encoder) writeHeader() error {
 	// write magic
 	if _, err := e.w.Write([]byte(magic)); err != nil {
@@ -136,8 +211,12 @@ func (e *Encoder) writeHeader() error {
 		return err
 	}
 
-	// write midiNote
-	if _, err := e.w.Write([]byte{0, 0}); err != nil {
+	// write MIDI note
+	midiNote := uint16(0xffff) // no MIDI note assigned
+	if e.midiNote >= 0 {
+		midiNote = 0xff00 | uint16(e.midiNote) // single key note assignment
+	}
+	if err := binary.Write(e.w, binary.BigEndian, midiNote); err != nil {
 		return err
 	}
 
@@ -187,16 +266,23 @@ func (e *Encoder) WriteSamples(p []float32) (int, error) {
 	return n, err
 }
 
-// Close finalizes the encoding process and writes the length value.
+// Close finalizes the encoding process and writes the length value, in frames.
+// It returns an error if the length does not fit in the header or if the loop end lies past the end of the data.
+// In the latter case, the length is still written, so that the data remains readable.
 //
 // It will NOT close the underlying writer, even if it implements [io.Closer].
 // Closing the underlying writer is the owner's responsibility.
 func (e *Encoder) Close() error {
+	length := int64(e.dataWritten) / int64(max(e.format.NumChannels, 1))
+	if length > math.MaxUint32 {
+		return fmt.Errorf("avr: length %d frames does not fit in the header", length)
+	}
+
 	if _, err := e.w.Seek(26, io.SeekStart); err != nil {
 		return fmt.Errorf("avr: failed to seek to length: %w", err)
 	}
 
-	if err := binary.Write(e.w, binary.BigEndian, uint32(e.dataWritten)); err != nil {
+	if err := binary.Write(e.w, binary.BigEndian, uint32(length)); err != nil {
 		return fmt.Errorf("avr: failed to write data size: %w", err)
 	}
 
@@ -204,5 +290,5 @@ func (e *Encoder) Close() error {
 		return fmt.Errorf("avr: failed to seek to end: %w", err)
 	}
 
-	return nil
+	return e.validateLoop(length)
 }
@@ -0,0 +1,105 @@
+package avr_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/codec/avr"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+func TestEncoderStrings(t *testing.T) {
+	tests := []struct {
+		name                 string
+		title, comment       string
+		wantTitle, wantExtra string
+		wantComment          string
+	}{
+		{"Padding", "kick", "short", "kick", "", "short"},
+		{"ExtraTitle", "snare drum 01", "", "snare dr", "um 01", ""},
+		{"Truncation", strings.Repeat("t", 40), strings.Repeat("c", 70), strings.Repeat("t", 8), strings.Repeat("t", 20), strings.Repeat("c", 64)},
+		{"MultiByte", "héllo", "čšž", "héllo", "", "čšž"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := encode(t, []float32{0}, avr.WithTitleString(tt.title), avr.WithCommentString(tt.comment))
+			dec, err := avr.NewDecoder(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := dec.(*avr.Decoder)
+			if got := d.TitleString(); got != tt.wantTitle {
+				t.Errorf("expected title %q, got %q", tt.wantTitle, got)
+			}
+			if got := d.ExtraTitleString(); got != tt.wantExtra {
+				t.Errorf("expected extra title %q, got %q", tt.wantExtra, got)
+			}
+			if got := d.CommentString(); got != tt.wantComment {
+				t.Errorf("expected comment %q, got %q", tt.wantComment, got)
+			}
+		})
+	}
+}
+
+func TestEncoderInvalidStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  avr.EncoderOption
+	}{
+		{"InvalidUTF8", avr.WithTitleString("\xff")},
+		{"SplitRune", avr.WithCommentString(strings.Repeat("a", 63) + "é")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := avr.NewEncoder(&testutil.WriteSeeker{}, afmt.Format{SampleRate: 22050 * freq.Hertz, NumChannels: 1}, sampleFmt, tt.opt)
+			if err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestEncoderLoop(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end int
+		newErr     bool
+		closeErr   bool
+	}{
+		{"Valid", 1, 4, false, false},
+		{"Empty", 0, 0, true, false},
+		{"Reversed", 3, 2, true, false},
+		{"EndAtLength", 0, 8, false, false},
+		{"EndPastLength", 0, 9, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := &testutil.WriteSeeker{}
+			enc, err := avr.NewEncoder(ws, afmt.Format{SampleRate: 22050 * freq.Hertz, NumChannels: 2}, sampleFmt, avr.WithLoop(tt.start, tt.end))
+			if (err != nil) != tt.newErr {
+				t.Fatalf("NewEncoder: expected error %v, got %v", tt.newErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if _, err := enc.WriteSamples(make([]float32, 16)); err != nil { // 8 stereo frames
+				t.Fatal(err)
+			}
+			if err := enc.Close(); (err != nil) != tt.closeErr {
+				t.Errorf("Close: expected error %v, got %v", tt.closeErr, err)
+			}
+
+			// the length is written even if the loop is invalid
+			dec, err := avr.NewDecoder(bytes.NewReader(ws.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dec.Len() != 8 {
+				t.Errorf("expected Len 8, got %d", dec.Len())
+			}
+		})
+	}
+}
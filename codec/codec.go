@@ -31,11 +31,33 @@ type Decoder interface {
 }
 
 // Bitrater is the interface with the Bitrate method.
+// Decoders of compressed formats implement it, as their sample format is not what is stored.
 type Bitrater interface {
-	// Bitrate returns the bitrate of the audio stream in bytes per second.
+	// Bitrate returns the bitrate of the audio stream in bits per second, or 0 if it is unknown.
 	Bitrate() int
 }
 
+// Bitrate returns the bitrate of the audio stream in bits per second. It uses the decoder's own
+// [Bitrater] implementation, and otherwise computes the uncompressed PCM bitrate from its format and
+// sample format (see [afmt.Format.BitRate]). It reports false if the bitrate is not known.
+func Bitrate(d Decoder) (int, bool) {
+	if b, ok := d.(Bitrater); ok {
+		bps := b.Bitrate()
+		return bps, bps > 0
+	}
+	if bps := d.Format().BitRate(d.SampleFormat()); bps > 0 {
+		return bps, true
+	}
+	return 0, false
+}
+
+// Metadata is the interface for decoders that expose textual metadata (tags) of the audio stream.
+type Metadata interface {
+	// Tags returns the metadata tags of the audio stream.
+	// Keys are upper-case field names (e.g. "TITLE", "ARTIST"), each of which may have multiple values.
+	Tags() map[string][]string
+}
+
 // ErrFormat indicates that decoding encountered an unknown format.
 var ErrFormat = errors.New("codec: unknown format")
 
@@ -0,0 +1,68 @@
+package codec_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+// pcmDecoder is a decoder of silence that does not implement [codec.Bitrater].
+type pcmDecoder struct {
+	format    afmt.Format
+	sampleFmt afmt.SampleFormat
+}
+
+func (d pcmDecoder) ReadSamples(p []float32) (int, error)         { clear(p); return len(p), nil }
+func (d pcmDecoder) Seek(offset int64, whence int) (int64, error) { return 0, nil }
+func (d pcmDecoder) Format() afmt.Format                          { return d.format }
+func (d pcmDecoder) SampleFormat() afmt.SampleFormat              { return d.sampleFmt }
+func (d pcmDecoder) Len() int                                     { return 0 }
+
+// unknownBitrateDecoder is a decoder of a compressed format whose bitrate is not known.
+type unknownBitrateDecoder struct{ pcmDecoder }
+
+func (d unknownBitrateDecoder) Bitrate() int { return 0 }
+
+func TestBitrate(t *testing.T) {
+	format := afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 3}
+	int24LE := afmt.SampleFormat{BitDepth: 24, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}
+
+	// the WAV decoder implements Bitrater
+	ws := &testutil.WriteSeeker{}
+	enc, err := wav.NewEncoder(ws, format, int24LE, wav.FormatInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.WriteSamples(make([]float32, 3*100)); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dec, _, err := codec.Decode(bytes.NewReader(ws.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bps, ok := codec.Bitrate(dec); !ok || bps != 3175200 {
+		t.Errorf("expected 3175200 bps from the WAV decoder, got %d, %v", bps, ok)
+	}
+
+	// otherwise, the PCM bitrate is computed
+	if bps, ok := codec.Bitrate(pcmDecoder{format, int24LE}); !ok || bps != 3175200 {
+		t.Errorf("expected a computed 3175200 bps, got %d, %v", bps, ok)
+	}
+	if bps, ok := codec.Bitrate(pcmDecoder{format, afmt.SampleFormat{}}); ok {
+		t.Errorf("expected an unknown bitrate without a bit depth, got %d", bps)
+	}
+
+	// an unknown bitrate from Bitrater is not replaced by the PCM bitrate of the decoded samples
+	if bps, ok := codec.Bitrate(unknownBitrateDecoder{pcmDecoder{format, int24LE}}); ok {
+		t.Errorf("expected an unknown bitrate from the Bitrater, got %d", bps)
+	}
+}
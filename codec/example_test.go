@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/MatusOllah/resona/afmt"
 	"github.com/MatusOllah/resona/codec"
 	_ "github.com/MatusOllah/resona/codec/flac" // Enable FLAC decoder
 )
@@ -23,8 +24,8 @@ func Example() {
 	fmt.Println(name) // Should print "flac"
 	_ = dec           // Do something with the audio
 
-	// Print out bitrate if the decoder supports it
-	if bitrater, ok := dec.(codec.Bitrater); ok {
-		fmt.Printf("Bitrate: %d kbps\n", bitrater.Bitrate()/1000)
+	// Print out the bitrate if it is known
+	if bps, ok := codec.Bitrate(dec); ok {
+		fmt.Printf("Bitrate: %s\n", afmt.FormatBitRate(bps))
 	}
 }
@@ -17,9 +17,12 @@ const magic = "fLaC"
 type Decoder struct {
 	stream   *flac.Stream
 	isSeeker bool
-	pos      int
+	size     int64 // size of the stream in bytes, 0 if unknown
+	pos      int64 // position in samples
+	eof      bool
 
-	buf []float32
+	buf      []float32
+	frameBuf []float32
 }
 
 // NewDecoder creates a new [Decoder] and decodes the headers.
@@ -29,6 +32,19 @@ func NewDecoder(r io.Reader) (_ codec.Decoder, err error) {
 	rs, ok := r.(io.ReadSeeker)
 	d.isSeeker = ok
 	if ok {
+		// measure the stream size for the compressed bitrate
+		var start, end int64
+		if start, err = rs.Seek(0, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		if end, err = rs.Seek(0, io.SeekEnd); err != nil {
+			return nil, err
+		}
+		if _, err = rs.Seek(start, io.SeekStart); err != nil {
+			return nil, err
+		}
+		d.size = end - start
+
 		d.stream, err = flac.NewSeek(rs)
 	} else {
 		d.stream, err = flac.New(r)
@@ -40,6 +56,17 @@ func NewDecoder(r io.Reader) (_ codec.Decoder, err error) {
 	return d, nil
 }
 
+// Bitrate returns the bitrate of the audio stream in bits per second.
+// If the source is an [io.Seeker], the average compressed bitrate is measured from the stream size.
+// Otherwise, the uncompressed PCM bitrate is returned.
+func (d *Decoder) Bitrate() int {
+	info := d.stream.Info
+	if d.size > 0 && info.NSamples > 0 && info.SampleRate > 0 {
+		return int(d.size * 8 * int64(info.SampleRate) / int64(info.NSamples))
+	}
+	return int(info.SampleRate) * int(info.NChannels) * int(info.BitsPerSample)
+}
+
 // Format returns the audio stream format.
 func (d *Decoder) Format() afmt.Format {
 	return afmt.Format{
@@ -64,47 +91,61 @@ func (d *Decoder) Len() int {
 // ReadSamples reads float32 samples into p.
 // It returns the number of samples read and/or an error.
 func (d *Decoder) ReadSamples(p []float32) (int, error) {
-	numChannels := int(d.stream.Info.NChannels)
-	bitsPerSample := int(d.stream.Info.BitsPerSample)
-	scale := float32(int64(1) << (bitsPerSample - 1))
-
 	var n int
+	for n < len(p) {
+		if len(d.buf) == 0 {
+			if d.eof {
+				break
+			}
+			if err := d.readFrame(); err != nil {
+				if err != io.EOF {
+					d.pos += int64(n)
+					return n, err
+				}
+				d.eof = true
+				break
+			}
+		}
 
-	// drain the buffer
-	for n < len(p) && len(d.buf) > 0 {
 		copied := copy(p[n:], d.buf)
 		n += copied
 		d.buf = d.buf[copied:]
 	}
+	d.pos += int64(n)
 
-	// refill the buffer
-	for n < len(p) {
-		frame, err := d.stream.ParseNext()
-		if err != nil {
-			if err == io.EOF && n > 0 {
-				return n, nil
-			}
-			return n, err
-		}
+	if d.eof && len(d.buf) == 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
 
-		numSamples := len(frame.Subframes[0].Samples)
-		buf := make([]float32, 0, numSamples*numChannels)
+// readFrame decodes the next FLAC frame into the sample buffer.
+func (d *Decoder) readFrame() error {
+	frame, err := d.stream.ParseNext()
+	if err != nil {
+		return err
+	}
 
-		for i := range numSamples {
-			for ch := range numChannels {
-				buf = append(buf, float32(frame.Subframes[ch].Samples[i])/scale)
-			}
-		}
+	numChannels := int(d.stream.Info.NChannels)
+	bitsPerSample := int(d.stream.Info.BitsPerSample)
+	scale := float32(int64(1) << (bitsPerSample - 1))
 
-		d.buf = buf
+	numSamples := int(frame.BlockSize)
+	if cap(d.frameBuf) < numSamples*numChannels {
+		d.frameBuf = make([]float32, numSamples*numChannels)
+	} else {
+		d.frameBuf = d.frameBuf[:numSamples*numChannels]
+	}
 
-		copied := copy(p[n:], d.buf)
-		n += copied
-		d.buf = d.buf[copied:]
+	for ch := range numChannels {
+		samples := frame.Subframes[ch].Samples
+		for i := range numSamples {
+			d.frameBuf[i*numChannels+ch] = float32(samples[i]) / scale
+		}
 	}
 
-	d.pos += n / numChannels
-	return n, nil
+	d.buf = d.frameBuf
+	return nil
 }
 
 // Seek seeks to the specified frame.
@@ -115,34 +156,57 @@ func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
 		return 0, errors.New("flac: resource does not support seeking")
 	}
 
+	numChannels := int64(d.stream.Info.NChannels)
+
 	// Special case
 	if offset == 0 && whence == io.SeekCurrent {
-		return int64(d.pos), nil
+		return d.pos / numChannels, nil
 	}
 
-	var target int
+	var target int64
 	switch whence {
 	case io.SeekStart:
-		target = int(offset)
+		target = offset
 	case io.SeekCurrent:
-		target = d.pos + int(offset)
+		target = d.pos/numChannels + offset
 	case io.SeekEnd:
-		target = int(d.stream.Info.NSamples) + int(offset)
+		target = int64(d.stream.Info.NSamples) + offset
 	default:
 		return 0, errors.New("flac: invalid seek whence")
 	}
 
-	if target < 0 || target >= int(d.stream.Info.NSamples) {
+	if target < 0 || target >= int64(d.stream.Info.NSamples) {
 		return 0, errors.New("flac: seek out of bounds")
 	}
 
-	_pos, err := d.stream.Seek(uint64(target))
+	frameStart, err := d.stream.Seek(uint64(target))
 	if err != nil {
-		return 0, err
+		// mewkiz/flac miscomputes the sample number of a short final block,
+		// so seek to a preceding frame and decode forward instead.
+		back := min(target, int64(d.stream.Info.BlockSizeMax))
+		frameStart, err = d.stream.Seek(uint64(target - back))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// decode frames until the one containing the target and skip to it
+	d.buf = nil
+	d.eof = false
+	for {
+		if err := d.readFrame(); err != nil {
+			return 0, err
+		}
+		frameLen := int64(len(d.buf)) / numChannels
+		if int64(frameStart)+frameLen > target {
+			break
+		}
+		frameStart += uint64(frameLen)
 	}
-	d.pos = int(_pos)
+	d.buf = d.buf[(target-int64(frameStart))*numChannels:]
+	d.pos = target * numChannels
 
-	return int64(d.pos), nil
+	return target, nil
 }
 
 func init() {
@@ -0,0 +1,188 @@
+package flac_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec/flac"
+	mflac "github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/meta"
+)
+
+// encodeFixture encodes a verbatim 16-bit FLAC stream with the given number of samples per channel.
+// The last block is shorter than the others if nsamples is not a multiple of blockSize.
+func encodeFixture(t *testing.T, nsamples, blockSize, channels int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	info := &meta.StreamInfo{
+		BlockSizeMin:  16,
+		BlockSizeMax:  uint16(blockSize),
+		SampleRate:    44100,
+		NChannels:     uint8(channels),
+		BitsPerSample: 16,
+		NSamples:      uint64(nsamples),
+	}
+	enc, err := mflac.NewEncoder(&buf, info)
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+
+	chans := frame.ChannelsMono
+	if channels == 2 {
+		chans = frame.ChannelsLR
+	}
+
+	for start := 0; start < nsamples; start += blockSize {
+		n := min(blockSize, nsamples-start)
+		f := &frame.Frame{
+			Header: frame.Header{
+				HasFixedBlockSize: true,
+				BlockSize:         uint16(n),
+				SampleRate:        info.SampleRate,
+				Channels:          chans,
+				BitsPerSample:     info.BitsPerSample,
+			},
+		}
+		for ch := range channels {
+			samples := make([]int32, n)
+			for i := range samples {
+				samples[i] = int32((start+i)%1000*(ch+1)) - 1000
+			}
+			f.Subframes = append(f.Subframes, &frame.Subframe{
+				SubHeader: frame.SubHeader{Pred: frame.PredVerbatim},
+				Samples:   samples,
+				NSamples:  n,
+			})
+		}
+		if err := enc.WriteFrame(f); err != nil {
+			t.Fatalf("failed to write frame: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("failed to close encoder: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// readChunks drains r using reads of size chunk and returns all samples read.
+func readChunks(t *testing.T, r aio.SampleReader, chunk int) []float32 {
+	t.Helper()
+
+	var out []float32
+	p := make([]float32, chunk)
+	for {
+		n, err := r.ReadSamples(p)
+		out = append(out, p[:n]...)
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("ReadSamples failed: %v", err)
+		}
+	}
+}
+
+func TestDecoderShortFinalBlock(t *testing.T) {
+	const (
+		nsamples  = 10000
+		blockSize = 4096
+		channels  = 2
+	)
+	data := encodeFixture(t, nsamples, blockSize, channels)
+
+	for _, chunk := range []int{1, 7, 512, 4096, 100000} {
+		dec, err := flac.NewDecoder(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("NewDecoder failed: %v", err)
+		}
+
+		got := readChunks(t, dec, chunk)
+		if len(got) != nsamples*channels {
+			t.Errorf("chunk %d: got %d samples, want %d", chunk, len(got), nsamples*channels)
+		}
+
+		pos, err := dec.Seek(0, io.SeekCurrent)
+		if err != nil {
+			t.Fatalf("Seek failed: %v", err)
+		}
+		if pos != nsamples {
+			t.Errorf("chunk %d: position after drain is %d, want %d", chunk, pos, nsamples)
+		}
+	}
+}
+
+func TestDecoderNonSeekable(t *testing.T) {
+	const nsamples = 5000
+	data := encodeFixture(t, nsamples, 1024, 1)
+
+	dec, err := flac.NewDecoder(struct{ io.Reader }{bytes.NewReader(data)})
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+
+	got := readChunks(t, dec, 333)
+	if len(got) != nsamples {
+		t.Errorf("got %d samples, want %d", len(got), nsamples)
+	}
+}
+
+func TestDecoderSeek(t *testing.T) {
+	const (
+		nsamples = 9000
+		channels = 2
+	)
+	data := encodeFixture(t, nsamples, 4096, channels)
+
+	dec, err := flac.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	want := readChunks(t, dec, 4096)
+
+	for _, target := range []int64{0, 1, 4095, 4096, 5000, nsamples - 1} {
+		pos, err := dec.Seek(target, io.SeekStart)
+		if err != nil {
+			t.Fatalf("Seek(%d) failed: %v", target, err)
+		}
+		if pos != target {
+			t.Errorf("Seek(%d) returned %d", target, pos)
+		}
+
+		got := readChunks(t, dec, 1000)
+		if !slicesEqual(got, want[target*channels:]) {
+			t.Errorf("samples after Seek(%d) do not match sequential decode", target)
+		}
+	}
+}
+
+func TestDecoderBitrate(t *testing.T) {
+	data := encodeFixture(t, 44100, 4096, 2)
+
+	dec, err := flac.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+
+	// verbatim frames are slightly larger than raw PCM (1411200 bps)
+	bitrate := dec.(*flac.Decoder).Bitrate()
+	if bitrate < 1411200 || bitrate > 1411200*11/10 {
+		t.Errorf("unexpected bitrate: %d", bitrate)
+	}
+}
+
+func slicesEqual(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -55,7 +55,7 @@ func NewDecoder(r io.Reader) (_ codec.Decoder, err error) {
 	return d, nil
 }
 
-// Bitrate returns the bitrate of the audio stream in bytes per second.
+// Bitrate returns the bitrate of the audio stream in bits per second. It implements codec.Bitrater.
 func (d *Decoder) Bitrate() int {
 	return d.frame.Bitrate()
 }
@@ -3,6 +3,7 @@ package oggvorbis
 import (
 	"errors"
 	"io"
+	"strings"
 
 	"github.com/MatusOllah/resona/afmt"
 	"github.com/MatusOllah/resona/codec"
@@ -13,7 +14,8 @@ import (
 // Decoder represents the decoder for the Ogg Vorbis file format.
 // It implements codec.Decoder.
 type Decoder struct {
-	oggR *oggvorbis.Reader
+	oggR     *oggvorbis.Reader
+	comments map[string][]string
 }
 
 // NewDecoder creates a new [Decoder] and decodes the headers.
@@ -24,11 +26,44 @@ func NewDecoder(r io.Reader) (_ codec.Decoder, err error) {
 	if err != nil {
 		return nil, err
 	}
+	d.comments = parseComments(d.oggR.CommentHeader().Comments)
 
 	return d, nil
 }
 
-// Bitrate returns the nominal bitrate of the audio stream in bytes per second.
+// parseComments parses "FIELD=value" Vorbis comments into a map.
+// Field names are case-insensitive per the Vorbis comment spec, so they are normalized to upper case.
+// Comments without a '=' separator are ignored.
+func parseComments(comments []string) map[string][]string {
+	m := make(map[string][]string)
+	for _, c := range comments {
+		field, value, ok := strings.Cut(c, "=")
+		if !ok || field == "" {
+			continue
+		}
+		field = strings.ToUpper(field)
+		m[field] = append(m[field], value)
+	}
+	return m
+}
+
+// Vendor returns the vendor string from the Vorbis comment header.
+func (d *Decoder) Vendor() string {
+	return d.oggR.CommentHeader().Vendor
+}
+
+// Comments returns the Vorbis comments.
+// Field names are upper-case, and each field may have multiple values in the order they appear in the stream.
+func (d *Decoder) Comments() map[string][]string {
+	return d.comments
+}
+
+// Tags returns the Vorbis comments. It implements codec.Metadata.
+func (d *Decoder) Tags() map[string][]string {
+	return d.comments
+}
+
+// Bitrate returns the nominal bitrate of the audio stream in bits per second. It implements codec.Bitrater.
 func (d *Decoder) Bitrate() int {
 	return d.oggR.Bitrate().Nominal
 }
@@ -52,13 +87,30 @@ func (d *Decoder) SampleFormat() afmt.SampleFormat {
 
 // Len returns the total number of frames.
 func (d *Decoder) Len() int {
-	return int(d.oggR.Length()) / d.oggR.Channels()
+	return int(d.oggR.Length())
 }
 
 // ReadSamples reads float32 samples into p.
 // It returns the number of samples read and/or an error.
+// If len(p) is not a multiple of the number of channels, only whole frames are read.
+// At the end of the stream it may return n > 0 together with [io.EOF].
 func (d *Decoder) ReadSamples(p []float32) (int, error) {
-	return d.oggR.Read(p)
+	return readFrames(d.oggR.Read, p, d.oggR.Channels())
+}
+
+// readFrames fills p with whole frames using read until p is full or read returns an error.
+// The samples read so far are always returned together with the error.
+func readFrames(read func([]float32) (int, error), p []float32, numChannels int) (n int, err error) {
+	p = p[:len(p)-len(p)%numChannels]
+	for n < len(p) && err == nil {
+		var nn int
+		nn, err = read(p[n:])
+		n += nn
+		if nn == 0 && err == nil {
+			break
+		}
+	}
+	return n, err
 }
 
 // Seek seeks to the specified frame.
@@ -67,10 +119,10 @@ func (d *Decoder) ReadSamples(p []float32) (int, error) {
 func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
 	// Special case
 	if offset == 0 && whence == io.SeekCurrent {
-		return d.oggR.Position() / int64(d.oggR.Channels()), nil
+		return d.oggR.Position(), nil
 	}
 
-	var target int64 = d.oggR.Position() / int64(d.oggR.Channels())
+	var target int64 = d.oggR.Position()
 	switch whence {
 	case io.SeekStart:
 		target = offset
@@ -94,5 +146,5 @@ func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
 }
 
 func init() {
-	codec.RegisterFormat("ogg", string([]byte{0x4F, 0x67, 0x67, 0x53}), NewDecoder)
+	codec.RegisterFormat("ogg", "OggS????????????????????????\x01vorbis", NewDecoder)
 }
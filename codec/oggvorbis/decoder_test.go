@@ -0,0 +1,207 @@
+package oggvorbis
+
+import (
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+)
+
+// packetReader mimics oggvorbis.Reader.Read: it returns at most one packet of samples per call
+// and reports io.EOF only on a call that returns no samples.
+type packetReader struct {
+	remaining  int // remaining samples
+	packetSize int // samples per packet
+}
+
+func (r *packetReader) Read(p []float32) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	n := min(len(p), r.packetSize, r.remaining)
+	for i := range p[:n] {
+		p[i] = 1
+	}
+	r.remaining -= n
+	return n, nil
+}
+
+func TestReadFrames(t *testing.T) {
+	const (
+		numChannels = 2
+		numFrames   = 1001
+	)
+	r := &packetReader{remaining: numFrames * numChannels, packetSize: 256}
+
+	buf := make([]float32, 301) // neither a multiple of the packet size nor of the channel count
+	total := 0
+	for {
+		n, err := readFrames(r.Read, buf, numChannels)
+		if n%numChannels != 0 {
+			t.Fatalf("read %d samples, not frame aligned", n)
+		}
+		total += n
+		if errors.Is(err, io.EOF) {
+			if n == 0 {
+				t.Errorf("expected n > 0 together with io.EOF at the tail")
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(buf)-len(buf)%numChannels {
+			t.Fatalf("short read of %d samples before EOF", n)
+		}
+	}
+
+	if total != numFrames*numChannels {
+		t.Errorf("expected %d samples, got %d", numFrames*numChannels, total)
+	}
+}
+
+func TestReadFramesError(t *testing.T) {
+	errBoom := errors.New("boom")
+	calls := 0
+	read := func(p []float32) (int, error) {
+		calls++
+		if calls == 1 {
+			return 4, nil
+		}
+		return 2, errBoom
+	}
+
+	n, err := readFrames(read, make([]float32, 16), 2)
+	if n != 6 {
+		t.Errorf("expected 6 samples, got %d", n)
+	}
+	if !errors.Is(err, errBoom) {
+		t.Errorf("expected %v, got %v", errBoom, err)
+	}
+}
+
+// testdata/stereo.ogg is eof_issue.ogg from the testdata of github.com/jfreymuth/oggvorbis (MIT License),
+// a stereo stream of 72384 frames.
+func TestDecoderFrames(t *testing.T) {
+	const numFrames = 72384
+
+	f, err := os.Open("testdata/stereo.ogg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dec, err := NewDecoder(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := dec.Format().NumChannels; n != 2 {
+		t.Fatalf("expected 2 channels, got %d", n)
+	}
+	if dec.Len() != numFrames {
+		t.Errorf("expected Len %d frames, got %d", numFrames, dec.Len())
+	}
+
+	want, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) != 2*numFrames {
+		t.Fatalf("expected %d samples, got %d", 2*numFrames, len(want))
+	}
+	if pos, err := dec.Seek(0, io.SeekCurrent); err != nil || pos != numFrames {
+		t.Errorf("expected position %d at the end, got %d, %v", numFrames, pos, err)
+	}
+
+	for _, target := range []int64{50000, 0, 1234, numFrames - 1} {
+		pos, err := dec.Seek(target, io.SeekStart)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos != target {
+			t.Errorf("expected position %d, got %d", target, pos)
+		}
+		got := make([]float32, 2)
+		if _, err := aio.ReadFull(dec, got); err != nil {
+			t.Fatal(err)
+		}
+		if got[0] != want[2*target] || got[1] != want[2*target+1] {
+			t.Errorf("frame %d: expected %v, got %v", target, want[2*target:2*target+2], got)
+		}
+		if pos, _ := dec.Seek(0, io.SeekCurrent); pos != target+1 {
+			t.Errorf("expected position %d after reading a frame, got %d", target+1, pos)
+		}
+	}
+
+	if _, err := dec.Seek(numFrames+1, io.SeekStart); err == nil {
+		t.Errorf("expected error when seeking past the last frame")
+	}
+}
+
+func TestParseComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments []string
+		want     map[string][]string
+	}{
+		{
+			name:     "Empty",
+			comments: nil,
+			want:     map[string][]string{},
+		},
+		{
+			name:     "Simple",
+			comments: []string{"TITLE=Parasol Cider", "ARTIST=MORE MORE JUMP!"},
+			want: map[string][]string{
+				"TITLE":  {"Parasol Cider"},
+				"ARTIST": {"MORE MORE JUMP!"},
+			},
+		},
+		{
+			name:     "CaseInsensitive",
+			comments: []string{"title=a", "Title=b", "TITLE=c"},
+			want: map[string][]string{
+				"TITLE": {"a", "b", "c"},
+			},
+		},
+		{
+			name:     "MultiValued",
+			comments: []string{"ARTIST=a", "GENRE=x", "ARTIST=b"},
+			want: map[string][]string{
+				"ARTIST": {"a", "b"},
+				"GENRE":  {"x"},
+			},
+		},
+		{
+			name:     "ValueContainsSeparator",
+			comments: []string{"COMMENT=a=b"},
+			want: map[string][]string{
+				"COMMENT": {"a=b"},
+			},
+		},
+		{
+			name:     "EmptyValue",
+			comments: []string{"ALBUM="},
+			want: map[string][]string{
+				"ALBUM": {""},
+			},
+		},
+		{
+			name:     "Malformed",
+			comments: []string{"no separator", "=no field"},
+			want:     map[string][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseComments(tt.comments)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
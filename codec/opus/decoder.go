@@ -0,0 +1,447 @@
+package opus
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/freq"
+)
+
+const (
+	sampleRate   = 48000 // Opus always decodes at 48 kHz
+	maxFrameSize = 5760  // 120 ms at 48 kHz
+	preRoll      = 3840  // 80 ms at 48 kHz, needed for the decoder to converge after seeking
+	bisectChunk  = 65536 // below this range size, seeking falls back to a linear scan
+)
+
+// ErrChained indicates that the stream is a chained (concatenated) Ogg Opus stream, which is not supported.
+var ErrChained = errors.New("opus: chained streams are not supported")
+
+// Decoder represents the decoder for the Ogg Opus file format.
+// It implements codec.Decoder.
+type Decoder struct {
+	ogg    *oggReader
+	dec    *opusDecoder
+	head   *header
+	serial uint32
+	vendor string
+	tags   map[string][]string
+	gain   float32
+
+	dataStart  int64 // offset of the first audio page
+	dataEnd    int64 // offset of the end of the stream, 0 if unknown
+	endGranule int64 // granule position of the end of the stream, -1 if unknown
+	eos        bool  // whether the end-of-stream page has been read
+
+	gran   int64 // granule position of the next decoded frame
+	skipTo int64 // frames before this granule position are discarded
+	pcm    []float32
+	buf    []float32 // decoded samples not yet returned
+}
+
+// NewDecoder creates a new [Decoder] and decodes the headers.
+func NewDecoder(r io.Reader) (codec.Decoder, error) {
+	d := &Decoder{
+		ogg:        newOggReader(r),
+		endGranule: -1,
+	}
+
+	pg, err := d.ogg.readPage()
+	if err != nil {
+		return nil, err
+	}
+	if pg.headerType&pageBOS == 0 || !bytes.HasPrefix(pg.data, []byte("OpusHead")) {
+		return nil, errors.New("opus: not an Ogg Opus stream")
+	}
+	d.serial = pg.serial
+	d.ogg.push(pg)
+	pkt, _ := d.ogg.nextPacket()
+	d.head, err = parseHeader(pkt)
+	if err != nil {
+		return nil, err
+	}
+
+	pkt, err = d.nextPacket()
+	if err != nil {
+		return nil, err
+	}
+	d.vendor, d.tags, err = parseTags(pkt)
+	if err != nil {
+		return nil, err
+	}
+	d.dataStart = d.ogg.off
+
+	d.gain = float32(math.Pow(10, float64(d.head.outputGain)/(20*256)))
+	d.skipTo = d.head.preSkip
+	d.pcm = make([]float32, maxFrameSize*d.head.numChannels)
+
+	d.dec, err = newOpusDecoder(d.head.numChannels)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.ogg.rs != nil {
+		if err := d.findEnd(); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// findEnd determines the granule position of the last page and rewinds to the first audio page.
+func (d *Decoder) findEnd() error {
+	end, err := d.ogg.rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	d.dataEnd = end
+
+	for off := end; off > d.dataStart && d.endGranule < 0; {
+		off = max(off-bisectChunk, d.dataStart)
+		if err := d.ogg.reset(off); err != nil {
+			return err
+		}
+		for {
+			pg, err := d.ogg.readPage()
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			} else if err != nil {
+				return err
+			}
+			if pg.serial != d.serial {
+				if pg.headerType&pageBOS != 0 && pg.offset >= d.dataStart {
+					return ErrChained
+				}
+				continue
+			}
+			if pg.granule != -1 {
+				d.endGranule = pg.granule
+			}
+		}
+	}
+
+	return d.ogg.reset(d.dataStart)
+}
+
+// readPage reads the next page belonging to the decoded logical stream.
+func (d *Decoder) readPage() (*page, error) {
+	for {
+		pg, err := d.ogg.readPage()
+		if err != nil {
+			return nil, err
+		}
+		if pg.serial != d.serial {
+			if pg.headerType&pageBOS != 0 && d.eos {
+				return nil, ErrChained
+			}
+			continue // multiplexed stream
+		}
+		if pg.headerType&pageEOS != 0 {
+			d.eos = true
+			if d.endGranule < 0 {
+				d.endGranule = pg.granule
+			}
+		}
+		return pg, nil
+	}
+}
+
+// nextPacket returns the next packet of the decoded logical stream.
+func (d *Decoder) nextPacket() ([]byte, error) {
+	for {
+		if pkt, ok := d.ogg.nextPacket(); ok {
+			return pkt, nil
+		}
+		pg, err := d.readPage()
+		if err != nil {
+			return nil, err
+		}
+		d.ogg.push(pg)
+	}
+}
+
+// decodeNext decodes the next packet into the internal buffer, applying pre-skip, seek and end trimming.
+func (d *Decoder) decodeNext() error {
+	pkt, err := d.nextPacket()
+	if err != nil {
+		return err
+	}
+	if len(pkt) == 0 {
+		return nil
+	}
+
+	n, err := d.dec.decode(pkt, d.pcm)
+	if err != nil {
+		return err
+	}
+	ch := d.head.numChannels
+	start := d.gran
+	d.gran += int64(n)
+	samples := d.pcm[:n*ch]
+
+	if start < d.skipTo {
+		skip := min(d.skipTo-start, int64(n))
+		samples = samples[skip*int64(ch):]
+	}
+	if d.endGranule >= 0 && d.gran > d.endGranule {
+		excess := min(d.gran-d.endGranule, int64(len(samples)/ch))
+		samples = samples[:len(samples)-int(excess)*ch]
+	}
+
+	if d.gain != 1 {
+		for i := range samples {
+			samples[i] *= d.gain
+		}
+	}
+	d.buf = samples
+	return nil
+}
+
+// Vendor returns the vendor string from the Opus comment header.
+func (d *Decoder) Vendor() string {
+	return d.vendor
+}
+
+// Tags returns the Opus comments. It implements codec.Metadata.
+// Field names are upper-case, and each field may have multiple values in the order they appear in the stream.
+func (d *Decoder) Tags() map[string][]string {
+	return d.tags
+}
+
+// PreSkip returns the number of frames discarded from the start of the decoded stream.
+func (d *Decoder) PreSkip() int {
+	return int(d.head.preSkip)
+}
+
+// Format returns the audio stream format.
+// The sample rate is always 48 kHz regardless of the original input sample rate.
+func (d *Decoder) Format() afmt.Format {
+	return afmt.Format{
+		SampleRate:  sampleRate * freq.Hertz,
+		NumChannels: d.head.numChannels,
+	}
+}
+
+// SampleFormat returns the sample format that samples are being decoded to internally.
+// Note that this isn't actually the audio stream's sample format, as it's compressed.
+func (d *Decoder) SampleFormat() afmt.SampleFormat {
+	return afmt.SampleFormat{
+		BitDepth: 32,
+		Encoding: afmt.SampleEncodingFloat,
+	}
+}
+
+// Bitrate returns the average bitrate of the audio stream in bits per second, measured from the size of the audio pages.
+// It implements codec.Bitrater. It returns 0 if the length is unknown, because the source is not an [io.Seeker].
+func (d *Decoder) Bitrate() int {
+	n := d.Len()
+	if n <= 0 || d.dataEnd <= d.dataStart {
+		return 0
+	}
+	return int((d.dataEnd - d.dataStart) * 8 * sampleRate / int64(n))
+}
+
+// Len returns the total number of frames.
+// It returns 0 if the length is unknown, because the source is not an [io.Seeker].
+func (d *Decoder) Len() int {
+	if d.ogg.rs == nil || d.endGranule < 0 {
+		return 0
+	}
+	return int(max(d.endGranule-d.head.preSkip, 0))
+}
+
+// ReadSamples reads float32 samples into p.
+// It returns the number of samples read and/or an error.
+// If len(p) is not a multiple of the number of channels, only whole frames are read.
+func (d *Decoder) ReadSamples(p []float32) (n int, err error) {
+	p = p[:len(p)-len(p)%d.head.numChannels]
+	for n < len(p) {
+		if len(d.buf) == 0 {
+			if d.endGranule >= 0 && d.gran >= d.endGranule {
+				return n, io.EOF
+			}
+			if err := d.decodeNext(); err != nil {
+				if errors.Is(err, io.ErrUnexpectedEOF) && d.endGranule < 0 {
+					err = io.EOF // truncated stream without an end-of-stream page
+				}
+				return n, err
+			}
+			continue
+		}
+		nn := copy(p[n:], d.buf)
+		d.buf = d.buf[nn:]
+		n += nn
+	}
+	return n, nil
+}
+
+// position returns the current position in frames.
+func (d *Decoder) position() int64 {
+	return max(d.gran-int64(len(d.buf)/d.head.numChannels), d.skipTo) - d.head.preSkip
+}
+
+// Seek seeks to the specified frame.
+// It returns the new offset relative to the start and/or an error.
+// It will return an error if the source is not an [io.Seeker].
+func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
+	// Special case
+	if offset == 0 && whence == io.SeekCurrent {
+		return d.position(), nil
+	}
+
+	if d.ogg.rs == nil {
+		return 0, errors.New("opus: source is not seekable")
+	}
+
+	target := d.position()
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target += offset
+	case io.SeekEnd:
+		target = int64(d.Len()) + offset
+	default:
+		return 0, errors.New("opus: invalid seek whence")
+	}
+
+	if target < 0 || target > int64(d.Len()) {
+		return 0, errors.New("opus: seek out of bounds")
+	}
+
+	if err := d.seekGranule(target + d.head.preSkip); err != nil {
+		return 0, err
+	}
+
+	return target, nil
+}
+
+// seekGranule positions the decoder so that the next returned frame is at granule position g.
+func (d *Decoder) seekGranule(g int64) error {
+	d.buf = nil
+	d.skipTo = g
+	d.eos = false
+	if err := d.dec.reset(); err != nil {
+		return err
+	}
+
+	// A packet continued from the previous page is dropped, so allow for one more maximum-length packet.
+	off, err := d.bisect(g - preRoll - maxFrameSize)
+	if err != nil {
+		return err
+	}
+	if err := d.ogg.reset(off); err != nil {
+		return err
+	}
+	if off == d.dataStart {
+		d.gran = 0
+		return nil
+	}
+
+	// The granule position of a page is the end of the last packet completed on it,
+	// so the start of the first queued packet is found by subtracting their durations.
+	for {
+		pg, err := d.readPage()
+		if err != nil {
+			return err
+		}
+		d.ogg.push(pg)
+		if len(d.ogg.packets) == 0 {
+			continue
+		}
+		d.gran = pg.granule
+		for _, pkt := range d.ogg.packets {
+			if len(pkt) == 0 {
+				continue
+			}
+			dur, err := packetDuration(pkt)
+			if err != nil {
+				return err
+			}
+			d.gran -= int64(dur)
+		}
+		d.gran = max(d.gran, 0)
+		return nil
+	}
+}
+
+// bisect returns the offset of the page following the last page whose granule position is below g,
+// or the offset of the first audio page if there is none.
+func (d *Decoder) bisect(g int64) (int64, error) {
+	best := d.dataStart
+	if g <= 0 {
+		return best, nil
+	}
+
+	end, err := d.ogg.rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	// Bisect over the byte range, narrowing it down to a small chunk.
+	lo, hi := d.dataStart, end
+	for hi-lo > bisectChunk {
+		mid := lo + (hi-lo)/2
+		pg, err := d.pageAt(mid)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, err
+		}
+		if err != nil || pg.offset >= hi {
+			hi = mid
+			continue
+		}
+		if pg.granule < g {
+			lo = pg.offset + pg.size
+			best = lo
+		} else {
+			hi = mid
+		}
+	}
+
+	// Scan the remaining range linearly.
+	if err := d.ogg.reset(lo); err != nil {
+		return 0, err
+	}
+	for {
+		pg, err := d.ogg.readPage()
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+		if pg.serial != d.serial || pg.granule == -1 {
+			continue
+		}
+		if pg.granule >= g {
+			break
+		}
+		best = pg.offset + pg.size
+	}
+
+	return best, nil
+}
+
+// pageAt returns the first page of the decoded logical stream at or after off that completes a packet.
+func (d *Decoder) pageAt(off int64) (*page, error) {
+	if err := d.ogg.reset(off); err != nil {
+		return nil, err
+	}
+	for {
+		pg, err := d.ogg.readPage()
+		if err != nil {
+			return nil, err
+		}
+		if pg.serial == d.serial && pg.granule != -1 {
+			return pg, nil
+		}
+	}
+}
+
+func init() {
+	codec.RegisterFormat("opus", "OggS????????????????????????OpusHead", NewDecoder)
+}
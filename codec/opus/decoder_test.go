@@ -0,0 +1,327 @@
+//go:build cgo
+
+package opus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MatusOllah/resona/freq"
+)
+
+// writePage appends an Ogg page to buf.
+func writePage(buf []byte, headerType byte, granule int64, serial, seq uint32, lacing, data []byte) []byte {
+	raw := make([]byte, pageHeaderSize, pageHeaderSize+len(lacing)+len(data))
+	copy(raw, capturePattern)
+	raw[5] = headerType
+	binary.LittleEndian.PutUint64(raw[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(raw[14:], serial)
+	binary.LittleEndian.PutUint32(raw[18:], seq)
+	raw[26] = byte(len(lacing))
+	raw = append(raw, lacing...)
+	raw = append(raw, data...)
+	binary.LittleEndian.PutUint32(raw[22:], pageChecksum(raw))
+	return append(buf, raw...)
+}
+
+// lace returns the lacing values for a packet of n bytes.
+func lace(n int) []byte {
+	l := bytes.Repeat([]byte{255}, n/255)
+	return append(l, byte(n%255))
+}
+
+// paddedPacket returns a 20 ms CELT packet with a single empty frame and about size bytes of padding.
+// Empty frames are decoded as silence, so no real encoder is needed.
+func paddedPacket(size int) []byte {
+	pkt := []byte{31<<3 | 3, 0x40 | 1}
+	for size > 254 {
+		pkt = append(pkt, 255)
+		size -= 254
+	}
+	pkt = append(pkt, byte(size))
+	return append(pkt, make([]byte, size)...)
+}
+
+// encodeFixture builds an Ogg Opus stream of numPackets 20 ms packets.
+// Pages hold at most segsPerPage lacing values, so packets are split across pages.
+func encodeFixture(numPackets, packetSize, segsPerPage, channels int, preSkip, trim int64) []byte {
+	const serial = 0x1234
+
+	head := []byte("OpusHead")
+	head = append(head, 1, byte(channels))
+	head = binary.LittleEndian.AppendUint16(head, uint16(preSkip))
+	head = binary.LittleEndian.AppendUint32(head, 44100)
+	head = append(head, 0, 0, 0)
+	buf := writePage(nil, pageBOS, 0, serial, 0, lace(len(head)), head)
+
+	tags := []byte("OpusTags")
+	tags = binary.LittleEndian.AppendUint32(tags, 4)
+	tags = append(tags, "test"...)
+	tags = binary.LittleEndian.AppendUint32(tags, 1)
+	tags = binary.LittleEndian.AppendUint32(tags, 10)
+	tags = append(tags, "title=Test"...)
+	buf = writePage(buf, 0, 0, serial, 1, lace(len(tags)), tags)
+
+	total := int64(numPackets) * 960
+	seq := uint32(2)
+	var lacing, data []byte
+	var granule int64 = -1
+	var continued bool
+	flush := func(last bool) {
+		var headerType byte
+		if continued {
+			headerType |= pageContinued
+		}
+		if last {
+			headerType |= pageEOS
+			granule = total - trim
+		}
+		buf = writePage(buf, headerType, granule, serial, seq, lacing, data)
+		seq++
+		continued = lacing[len(lacing)-1] == 255
+		lacing, data, granule = nil, nil, -1
+	}
+
+	pkt := paddedPacket(packetSize)
+	for i := range numPackets {
+		off := 0
+		for _, l := range lace(len(pkt)) {
+			lacing = append(lacing, l)
+			data = append(data, pkt[off:off+int(l)]...)
+			off += int(l)
+			if l < 255 {
+				granule = int64(i+1) * 960
+			}
+			if len(lacing) == segsPerPage && !(i == numPackets-1 && l < 255) {
+				flush(false)
+			}
+		}
+	}
+	flush(true)
+
+	return buf
+}
+
+func readAll(t *testing.T, d *Decoder, bufSize int) int {
+	t.Helper()
+	buf := make([]float32, bufSize)
+	total := 0
+	for {
+		n, err := d.ReadSamples(buf)
+		total += n
+		if errors.Is(err, io.EOF) {
+			return total
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDecoderLen(t *testing.T) {
+	const (
+		numPackets = 50
+		preSkip    = 312
+		trim       = 500
+	)
+	b := encodeFixture(numPackets, 10, 8, 2, preSkip, trim)
+	d, err := NewDecoder(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := numPackets*960 - preSkip - trim
+	if d.Len() != want {
+		t.Errorf("expected Len %d, got %d", want, d.Len())
+	}
+	if bps, want := d.(*Decoder).Bitrate(), int((int64(len(b))-d.(*Decoder).dataStart)*8*sampleRate/int64(want)); bps != want {
+		t.Errorf("expected %d bps, got %d", want, bps)
+	}
+	if got := readAll(t, d.(*Decoder), 1001); got != want*2 {
+		t.Errorf("expected %d samples, got %d", want*2, got)
+	}
+
+	tags := d.(*Decoder).Tags()
+	if len(tags["TITLE"]) != 1 || tags["TITLE"][0] != "Test" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+}
+
+// The fixtures in testdata are described in testdata/license.md.
+func TestDecoderFixtures(t *testing.T) {
+	tests := []struct {
+		name, vendor string
+		numFrames    int
+	}{
+		// opusenc encoded the 518400 frames of the source and ends the stream within the last packet
+		{"speech.opus", "libopus 1.1", 518400},
+		// a single 20 ms packet trimmed to 279 frames by the granule position of the last page
+		{"tiny.opus", "Lavf59.16.100", 279},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", tt.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dec, err := NewDecoder(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := dec.(*Decoder)
+
+			if f := d.Format(); f.NumChannels != 1 || f.SampleRate != 48*freq.KiloHertz {
+				t.Errorf("expected 1 channel at 48 kHz, got %v", f)
+			}
+			if d.PreSkip() != 312 {
+				t.Errorf("expected a pre-skip of 312 frames, got %d", d.PreSkip())
+			}
+			if d.Vendor() != tt.vendor {
+				t.Errorf("expected vendor %q, got %q", tt.vendor, d.Vendor())
+			}
+			if d.Len() != tt.numFrames {
+				t.Errorf("expected Len %d, got %d", tt.numFrames, d.Len())
+			}
+			if got := readAll(t, d, 4096); got != tt.numFrames {
+				t.Errorf("expected %d samples, got %d", tt.numFrames, got)
+			}
+
+			target := int64(tt.numFrames) / 2
+			if pos, err := d.Seek(target, io.SeekStart); err != nil || pos != target {
+				t.Fatalf("expected position %d, got %d, %v", target, pos, err)
+			}
+			if got := readAll(t, d, 4096); got != tt.numFrames-int(target) {
+				t.Errorf("after seeking: expected %d samples, got %d", tt.numFrames-int(target), got)
+			}
+
+			// without an io.Seeker, the pre-skip and the end are still trimmed
+			dec, err = NewDecoder(struct{ io.Reader }{bytes.NewReader(b)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readAll(t, dec.(*Decoder), 1000); got != tt.numFrames {
+				t.Errorf("non-seekable: expected %d samples, got %d", tt.numFrames, got)
+			}
+		})
+	}
+}
+
+func TestDecoderNonSeekable(t *testing.T) {
+	const (
+		numPackets = 50
+		preSkip    = 312
+		trim       = 500
+	)
+	d, err := NewDecoder(struct{ io.Reader }{bytes.NewReader(encodeFixture(numPackets, 300, 3, 1, preSkip, trim))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Len() != 0 {
+		t.Errorf("expected unknown Len, got %d", d.Len())
+	}
+	if bps := d.(*Decoder).Bitrate(); bps != 0 {
+		t.Errorf("expected unknown bitrate, got %d", bps)
+	}
+	want := numPackets*960 - preSkip - trim
+	if got := readAll(t, d.(*Decoder), 777); got != want {
+		t.Errorf("expected %d samples, got %d", want, got)
+	}
+	if _, err := d.Seek(0, io.SeekStart); err == nil {
+		t.Errorf("expected error when seeking a non-seekable source")
+	}
+}
+
+func TestDecoderSeek(t *testing.T) {
+	const (
+		numPackets = 1000
+		preSkip    = 312
+		trim       = 123
+	)
+	// large packets split across pages, so that bisection is exercised
+	d, err := NewDecoder(bytes.NewReader(encodeFixture(numPackets, 600, 5, 2, preSkip, trim)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	length := d.Len()
+
+	for _, target := range []int64{0, 1, 959, 5000, 123456, int64(length) / 2, int64(length) - 1, int64(length)} {
+		pos, err := d.Seek(target, io.SeekStart)
+		if err != nil {
+			t.Fatalf("seek to %d: %v", target, err)
+		}
+		if pos != target {
+			t.Errorf("expected position %d, got %d", target, pos)
+		}
+		if cur, _ := d.Seek(0, io.SeekCurrent); cur != target {
+			t.Errorf("expected current position %d, got %d", target, cur)
+		}
+		if got := readAll(t, d.(*Decoder), 4096); got != (length-int(target))*2 {
+			t.Errorf("after seek to %d: expected %d samples, got %d", target, (length-int(target))*2, got)
+		}
+	}
+
+	if _, err := d.Seek(int64(length)+1, io.SeekStart); err == nil {
+		t.Errorf("expected error when seeking out of bounds")
+	}
+}
+
+func TestDecoderChained(t *testing.T) {
+	stream := encodeFixture(10, 10, 8, 2, 312, 0)
+	chained := append(bytes.Clone(stream), bytes.ReplaceAll(stream, []byte{0x34, 0x12, 0, 0}, []byte{0x35, 0x12, 0, 0})...)
+
+	// fix up the checksums of the second stream
+	for off := len(stream); off < len(chained); {
+		o := newOggReader(bytes.NewReader(chained[off:]))
+		pg, err := o.readPage()
+		if err == nil {
+			off += int(pg.size)
+			continue
+		}
+		nsegs := int(chained[off+26])
+		size := pageHeaderSize + nsegs
+		for _, l := range chained[off+pageHeaderSize : off+size] {
+			size += int(l)
+		}
+		binary.LittleEndian.PutUint32(chained[off+22:], pageChecksum(chained[off:off+size]))
+	}
+
+	if _, err := NewDecoder(bytes.NewReader(chained)); !errors.Is(err, ErrChained) {
+		t.Errorf("expected %v, got %v", ErrChained, err)
+	}
+}
+
+func TestPacketDuration(t *testing.T) {
+	tests := []struct {
+		pkt  []byte
+		want int
+	}{
+		{[]byte{0 << 3}, 480},              // SILK 10 ms
+		{[]byte{3 << 3}, 2880},             // SILK 60 ms
+		{[]byte{13 << 3}, 960},             // Hybrid 20 ms
+		{[]byte{16 << 3}, 120},             // CELT 2.5 ms
+		{[]byte{31<<3 | 1}, 1920},          // CELT 2x20 ms
+		{[]byte{31<<3 | 3, 6}, 5760},       // CELT 6x20 ms
+		{[]byte{16<<3 | 3, 0x40 | 3}, 360}, // CELT 3x2.5 ms with padding
+	}
+	for _, tt := range tests {
+		got, err := packetDuration(tt.pkt)
+		if err != nil {
+			t.Errorf("%x: %v", tt.pkt, err)
+		}
+		if got != tt.want {
+			t.Errorf("%x: expected %d, got %d", tt.pkt, tt.want, got)
+		}
+	}
+
+	for _, pkt := range [][]byte{nil, {31<<3 | 3}, {31<<3 | 3, 0}, {31<<3 | 3, 7}} {
+		if _, err := packetDuration(pkt); err == nil {
+			t.Errorf("%x: expected error", pkt)
+		}
+	}
+}
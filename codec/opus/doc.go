@@ -0,0 +1,5 @@
+// Package opus implements decoding of Ogg Opus files.
+//
+// Opus packets are decoded using libopus via CGo; without cgo, [NewDecoder] returns an error.
+// Chained (concatenated) Ogg Opus streams are not supported.
+package opus
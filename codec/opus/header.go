@@ -0,0 +1,127 @@
+package opus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// header represents the Opus identification header ("OpusHead").
+type header struct {
+	version         byte
+	numChannels     int
+	preSkip         int64
+	inputSampleRate uint32
+	outputGain      int16 // Q7.8 in dB
+	mappingFamily   byte
+}
+
+func parseHeader(b []byte) (*header, error) {
+	if len(b) < 19 || !bytes.HasPrefix(b, []byte("OpusHead")) {
+		return nil, errors.New("opus: missing identification header")
+	}
+	h := &header{
+		version:         b[8],
+		numChannels:     int(b[9]),
+		preSkip:         int64(binary.LittleEndian.Uint16(b[10:12])),
+		inputSampleRate: binary.LittleEndian.Uint32(b[12:16]),
+		outputGain:      int16(binary.LittleEndian.Uint16(b[16:18])),
+		mappingFamily:   b[18],
+	}
+	if h.version>>4 != 0 {
+		return nil, fmt.Errorf("opus: unsupported version %d", h.version)
+	}
+	if h.numChannels == 0 {
+		return nil, errors.New("opus: invalid number of channels")
+	}
+	if h.mappingFamily != 0 || h.numChannels > 2 {
+		return nil, fmt.Errorf("opus: unsupported channel mapping family %d with %d channels", h.mappingFamily, h.numChannels)
+	}
+	return h, nil
+}
+
+// parseTags parses the Opus comment header ("OpusTags").
+// Field names are normalized to upper case. Comments without a '=' separator are ignored.
+func parseTags(b []byte) (vendor string, tags map[string][]string, err error) {
+	errMalformed := errors.New("opus: malformed comment header")
+	if !bytes.HasPrefix(b, []byte("OpusTags")) {
+		return "", nil, errors.New("opus: missing comment header")
+	}
+	b = b[8:]
+
+	readString := func() (string, bool) {
+		if len(b) < 4 {
+			return "", false
+		}
+		n := binary.LittleEndian.Uint32(b)
+		b = b[4:]
+		if uint64(n) > uint64(len(b)) {
+			return "", false
+		}
+		s := string(b[:n])
+		b = b[n:]
+		return s, true
+	}
+
+	vendor, ok := readString()
+	if !ok || len(b) < 4 {
+		return "", nil, errMalformed
+	}
+	count := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+
+	tags = make(map[string][]string)
+	for range count {
+		c, ok := readString()
+		if !ok {
+			return "", nil, errMalformed
+		}
+		field, value, ok := strings.Cut(c, "=")
+		if !ok || field == "" {
+			continue
+		}
+		field = strings.ToUpper(field)
+		tags[field] = append(tags[field], value)
+	}
+	return vendor, tags, nil
+}
+
+// packetDuration returns the number of frames (at 48 kHz) encoded in an Opus packet.
+func packetDuration(pkt []byte) (int, error) {
+	if len(pkt) == 0 {
+		return 0, errors.New("opus: empty packet")
+	}
+	toc := pkt[0]
+	config := toc >> 3
+
+	var frameSize int
+	switch {
+	case config < 12: // SILK-only: 10, 20, 40, 60 ms
+		frameSize = [...]int{480, 960, 1920, 2880}[config&3]
+	case config < 16: // Hybrid: 10, 20 ms
+		frameSize = [...]int{480, 960}[config&1]
+	default: // CELT-only: 2.5, 5, 10, 20 ms
+		frameSize = [...]int{120, 240, 480, 960}[config&3]
+	}
+
+	var numFrames int
+	switch toc & 3 {
+	case 0:
+		numFrames = 1
+	case 1, 2:
+		numFrames = 2
+	case 3:
+		if len(pkt) < 2 {
+			return 0, errors.New("opus: invalid packet")
+		}
+		numFrames = int(pkt[1] & 0x3F)
+	}
+
+	d := frameSize * numFrames
+	if numFrames == 0 || d > maxFrameSize {
+		return 0, errors.New("opus: invalid packet")
+	}
+	return d, nil
+}
@@ -0,0 +1,55 @@
+package opus
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// opusHead returns an identification header with the given version, number of channels and mapping family.
+func opusHead(version, numChannels, mappingFamily byte) []byte {
+	b := []byte("OpusHead")
+	b = append(b, version, numChannels)
+	b = binary.LittleEndian.AppendUint16(b, 312)
+	b = binary.LittleEndian.AppendUint32(b, 44100)
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	b = append(b, mappingFamily)
+	if mappingFamily != 0 {
+		// stream count, coupled count and channel mapping
+		b = append(b, numChannels, 0)
+		for i := range numChannels {
+			b = append(b, i)
+		}
+	}
+	return b
+}
+
+func TestParseHeader(t *testing.T) {
+	h, err := parseHeader(opusHead(1, 2, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.numChannels != 2 || h.preSkip != 312 || h.inputSampleRate != 44100 || h.mappingFamily != 0 {
+		t.Errorf("unexpected header: %+v", h)
+	}
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"Short", opusHead(1, 2, 0)[:18]},
+		{"Magic", append([]byte("OpusTags"), opusHead(1, 2, 0)[8:]...)},
+		{"Version", opusHead(0x10, 2, 0)},
+		{"NoChannels", opusHead(1, 0, 0)},
+		{"Family0Surround", opusHead(1, 3, 0)},
+		{"Family1", opusHead(1, 2, 1)},
+		{"Family1Surround", opusHead(1, 6, 1)},
+		{"Family255", opusHead(1, 1, 255)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseHeader(tt.b); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package opus
+
+//#cgo !windows pkg-config: opus
+//#cgo windows LDFLAGS: -lopus
+//#include <opus.h>
+//
+//static int resona_opus_reset(OpusDecoder *st) {
+//	return opus_decoder_ctl(st, OPUS_RESET_STATE);
+//}
+import "C"
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+// Version returns the libopus version as a string.
+func Version() string {
+	return C.GoString(C.opus_get_version_string())
+}
+
+// opusDecoder wraps a libopus decoder instance.
+type opusDecoder struct {
+	st          *C.OpusDecoder
+	numChannels int
+}
+
+func newOpusDecoder(numChannels int) (*opusDecoder, error) {
+	var cErr C.int
+	st := C.opus_decoder_create(C.opus_int32(sampleRate), C.int(numChannels), &cErr)
+	if cErr != C.OPUS_OK {
+		return nil, fmt.Errorf("opus: %s", C.GoString(C.opus_strerror(cErr)))
+	}
+
+	d := &opusDecoder{st: st, numChannels: numChannels}
+	runtime.SetFinalizer(d, (*opusDecoder).close)
+
+	return d, nil
+}
+
+func (d *opusDecoder) close() {
+	if d.st != nil {
+		runtime.SetFinalizer(d, nil) // prevent double close
+		C.opus_decoder_destroy(d.st)
+		d.st = nil
+	}
+}
+
+// decode decodes pkt into pcm, which must hold at least maxFrameSize frames.
+// It returns the number of frames decoded.
+func (d *opusDecoder) decode(pkt []byte, pcm []float32) (int, error) {
+	n := C.opus_decode_float(
+		d.st,
+		(*C.uchar)(unsafe.Pointer(&pkt[0])),
+		C.opus_int32(len(pkt)),
+		(*C.float)(unsafe.Pointer(&pcm[0])),
+		C.int(len(pcm)/d.numChannels),
+		0,
+	)
+	if n < 0 {
+		return 0, fmt.Errorf("opus: %s", C.GoString(C.opus_strerror(n)))
+	}
+	return int(n), nil
+}
+
+// reset resets the decoder state, e.g. after seeking.
+func (d *opusDecoder) reset() error {
+	if ret := C.resona_opus_reset(d.st); ret != C.OPUS_OK {
+		return fmt.Errorf("opus: %s", C.GoString(C.opus_strerror(ret)))
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+//go:build !cgo
+
+package opus
+
+import "errors"
+
+// errNoCgo is returned by [NewDecoder] when built without cgo, which libopus requires.
+var errNoCgo = errors.New("opus: decoding requires cgo and libopus")
+
+// Version returns the libopus version as a string, or an empty string when built without cgo.
+func Version() string {
+	return ""
+}
+
+// opusDecoder stands in for the libopus decoder, which cannot be created without cgo.
+type opusDecoder struct{}
+
+func newOpusDecoder(numChannels int) (*opusDecoder, error) {
+	return nil, errNoCgo
+}
+
+func (d *opusDecoder) close() {}
+
+func (d *opusDecoder) decode(pkt []byte, pcm []float32) (int, error) {
+	return 0, errNoCgo
+}
+
+func (d *opusDecoder) reset() error {
+	return errNoCgo
+}
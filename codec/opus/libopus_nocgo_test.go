@@ -0,0 +1,14 @@
+//go:build !cgo
+
+package opus
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecoderNoCgo(t *testing.T) {
+	if _, err := newOpusDecoder(2); !errors.Is(err, errNoCgo) {
+		t.Errorf("expected errNoCgo, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package opus
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Ogg page header type flags.
+const (
+	pageContinued byte = 0x01
+	pageBOS       byte = 0x02
+	pageEOS       byte = 0x04
+)
+
+const (
+	pageHeaderSize = 27
+	maxPageSize    = pageHeaderSize + 255 + 255*255
+)
+
+var capturePattern = []byte("OggS")
+
+// page represents a single Ogg page.
+type page struct {
+	headerType byte
+	granule    int64
+	serial     uint32
+	seq        uint32
+	lacing     []byte
+	data       []byte
+
+	offset int64 // byte offset of the page in the stream
+	size   int64 // size of the page in bytes including the header
+}
+
+// oggReader reads Ogg pages and assembles packets from them.
+type oggReader struct {
+	r   *bufio.Reader
+	rs  io.ReadSeeker // nil if the source is not seekable
+	off int64         // offset of the next unread byte
+
+	partial []byte   // incomplete packet continued on the next page
+	packets [][]byte // complete packets not yet consumed
+}
+
+func newOggReader(r io.Reader) *oggReader {
+	o := &oggReader{r: bufio.NewReaderSize(r, maxPageSize)}
+	if rs, ok := r.(io.ReadSeeker); ok {
+		o.rs = rs
+		if off, err := rs.Seek(0, io.SeekCurrent); err == nil {
+			o.off = off
+		} else {
+			o.rs = nil
+		}
+	}
+	return o
+}
+
+// reset positions the reader at off and discards all buffered pages and packets.
+func (o *oggReader) reset(off int64) error {
+	if o.rs == nil {
+		return errors.New("opus: source is not seekable")
+	}
+	if _, err := o.rs.Seek(off, io.SeekStart); err != nil {
+		return err
+	}
+	o.r.Reset(o.rs)
+	o.off = off
+	o.partial = nil
+	o.packets = nil
+	return nil
+}
+
+func (o *oggReader) discard(n int) {
+	n, _ = o.r.Discard(n)
+	o.off += int64(n)
+}
+
+// readPage reads the next valid page, skipping over any garbage or corrupted pages.
+func (o *oggReader) readPage() (*page, error) {
+	for {
+		b, err := o.r.Peek(pageHeaderSize)
+		if len(b) < len(capturePattern) {
+			return nil, io.EOF
+		}
+		if !bytes.HasPrefix(b, capturePattern) {
+			// resync to the next capture pattern
+			buf, _ := o.r.Peek(max(o.r.Buffered(), len(capturePattern)))
+			i := bytes.Index(buf[1:], capturePattern)
+			if i < 0 {
+				o.discard(len(buf) - len(capturePattern) + 1)
+			} else {
+				o.discard(i + 1)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if b[4] != 0 {
+			o.discard(1)
+			continue
+		}
+
+		nsegs := int(b[26])
+		hdr, err := o.r.Peek(pageHeaderSize + nsegs)
+		if err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		size := pageHeaderSize + nsegs
+		for _, l := range hdr[pageHeaderSize:] {
+			size += int(l)
+		}
+		raw, err := o.r.Peek(size)
+		if err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if pageChecksum(raw) != binary.LittleEndian.Uint32(raw[22:26]) {
+			o.discard(1)
+			continue
+		}
+
+		pg := &page{
+			headerType: raw[5],
+			granule:    int64(binary.LittleEndian.Uint64(raw[6:14])),
+			serial:     binary.LittleEndian.Uint32(raw[14:18]),
+			seq:        binary.LittleEndian.Uint32(raw[18:22]),
+			lacing:     bytes.Clone(raw[pageHeaderSize : pageHeaderSize+nsegs]),
+			data:       bytes.Clone(raw[pageHeaderSize+nsegs:]),
+			offset:     o.off,
+			size:       int64(size),
+		}
+		o.discard(size)
+		return pg, nil
+	}
+}
+
+// push splits pg into packets and queues the complete ones.
+// If pg continues a packet whose beginning was not seen (e.g. after a seek), that packet is dropped.
+func (o *oggReader) push(pg *page) {
+	cur := o.partial
+	drop := false
+	if pg.headerType&pageContinued == 0 {
+		cur = nil
+	} else if len(cur) == 0 {
+		drop = true
+	}
+
+	start := 0
+	for _, l := range pg.lacing {
+		end := start + int(l)
+		if !drop {
+			cur = append(cur, pg.data[start:end]...)
+		}
+		start = end
+		if l < 255 {
+			if !drop {
+				o.packets = append(o.packets, cur)
+			}
+			cur = nil
+			drop = false
+		}
+	}
+	o.partial = cur
+}
+
+// nextPacket returns the next queued packet, or false if there is none.
+func (o *oggReader) nextPacket() ([]byte, bool) {
+	if len(o.packets) == 0 {
+		return nil, false
+	}
+	pkt := o.packets[0]
+	o.packets = o.packets[1:]
+	return pkt, true
+}
+
+var crcTable = func() (t [256]uint32) {
+	for i := range t {
+		r := uint32(i) << 24
+		for range 8 {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return
+}()
+
+// pageChecksum computes the Ogg CRC-32 of a raw page, treating the checksum field as zero.
+func pageChecksum(raw []byte) uint32 {
+	var crc uint32
+	for i, b := range raw {
+		if i >= 22 && i < 26 {
+			b = 0
+		}
+		crc = crc<<8 ^ crcTable[byte(crc>>24)^b]
+	}
+	return crc
+}
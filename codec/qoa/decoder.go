@@ -1,11 +1,12 @@
 package qoa
 
 import (
+	"cmp"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
-	"math"
+	"slices"
 
 	"github.com/MatusOllah/resona/afmt"
 	"github.com/MatusOllah/resona/codec"
@@ -24,21 +25,25 @@ type Decoder struct {
 
 	lmsState []lms
 
-	frameBuf    []int16
-	framePos    int
-	readSamples int64 // read frames, not samples, but in the context of QOA, "frame" means something different
+	frameBuf   []int16
+	framePos   int
+	frameStart int64 // position of the first frame in frameBuf; "frame" meaning a sample per channel here, as QOA frames are something else
 
-	frameOffsets []frameIndex
+	dataStart    int64        // byte offset of the first frame
+	off          int64        // byte offset of the next unread byte
+	frameOffsets []frameIndex // seek index, built lazily while decoding and seeking
 }
 
 type frameIndex struct {
-	offset  int64
+	offset  int64 // byte offset
+	start   int64 // position of the first sample
 	samples uint16
+	size    uint16
 }
 
 func NewDecoder(r io.Reader) (codec.Decoder, error) {
 	d := &Decoder{r: r}
-	d.seeker = r.(io.Seeker)
+	d.seeker, _ = r.(io.Seeker)
 
 	// Read magic
 	var buf [len(magic)]byte
@@ -54,127 +59,80 @@ func NewDecoder(r io.Reader) (codec.Decoder, error) {
 		return nil, fmt.Errorf("qoa: failed to read samples per channel: %w", err)
 	}
 
-	if err := d.ensureFrameOffsets(); err != nil {
-		return nil, err
+	d.off = int64(len(magic)) + 4
+	if d.seeker != nil {
+		off, err := d.seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("qoa: failed to get data offset: %w", err)
+		}
+		d.off = off
 	}
+	d.dataStart = d.off
 
-	if err := d.init(); err != nil {
+	// Read first frame, get channels and sample rate, and fill buffer
+	if err := d.readFrame(); err != nil {
 		return nil, err
 	}
 
 	return d, nil
 }
 
-func (d *Decoder) ensureFrameOffsets() error {
-	if d.seeker == nil {
-		return nil
+// indexEnd returns the byte offset and position right after the last indexed frame.
+func (d *Decoder) indexEnd() (offset, start int64) {
+	if len(d.frameOffsets) == 0 {
+		return d.dataStart, 0
 	}
+	last := d.frameOffsets[len(d.frameOffsets)-1]
+	return last.offset + int64(last.size), last.start + int64(last.samples)
+}
 
-	d.frameOffsets = make([]frameIndex, int(math.Ceil(float64(d.samples)/(256*20))))
+// addFrameIndex records a frame in the seek index if it directly follows the last indexed frame.
+func (d *Decoder) addFrameIndex(offset int64, samples, size uint16) {
+	if end, start := d.indexEnd(); offset == end {
+		d.frameOffsets = append(d.frameOffsets, frameIndex{offset: offset, start: start, samples: samples, size: size})
+	}
+}
 
-	// populate frame offsets
-	for i := range d.frameOffsets {
-		byteOffset, err := d.seeker.Seek(0, io.SeekCurrent)
-		if err != nil {
-			return fmt.Errorf("qoa: failed to get frame byte offset: %w", err)
+// findFrame returns the index of the frame containing the specified position,
+// walking and indexing frame headers past the already indexed ones if needed.
+// It returns -1 if the position is past the last frame.
+func (d *Decoder) findFrame(pos int64) (int, error) {
+	i, _ := slices.BinarySearchFunc(d.frameOffsets, pos, func(f frameIndex, pos int64) int {
+		return cmp.Compare(f.start+int64(f.samples), pos+1)
+	})
+	if i < len(d.frameOffsets) {
+		return i, nil
+	}
+
+	for {
+		off, start := d.indexEnd()
+		if _, err := d.seeker.Seek(off, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("qoa: failed to seek: %w", err)
 		}
 
 		var hdr [8]byte
-		if _, err := io.ReadFull(d.r, hdr[:]); err != nil {
-			return fmt.Errorf("qoa: failed to read frame header: %w", err)
+		if _, err := io.ReadFull(d.r, hdr[:]); errors.Is(err, io.EOF) {
+			return -1, nil
+		} else if err != nil {
+			return 0, fmt.Errorf("qoa: failed to read frame header: %w", err)
 		}
 		samples := uint16(hdr[4])<<8 | uint16(hdr[5])
-
-		d.frameOffsets[i] = frameIndex{offset: byteOffset, samples: samples}
-
-		// skip frame data
 		fsize := uint16(hdr[6])<<8 | uint16(hdr[7])
-		if _, err := io.CopyN(io.Discard, d.r, int64(fsize-8)); err != nil {
-			return fmt.Errorf("qoa: failed to skip frame data: %w", err)
+		if hdr[0] != d.channels || samples == 0 || fsize <= 8 {
+			return 0, errors.New("qoa: invalid frame header values")
 		}
-	}
-
-	// seek back to start
-	// magic (4 bytes) + samples (4 bytes) = 8 bytes
-	_, err := d.seeker.Seek(int64(len(magic))+4, io.SeekStart)
-	return err
-}
-
-func (d *Decoder) init() error {
-	// Read first frame, get channels and sample rate, and fill buffer
-	var hdr [8]byte
-	if _, err := io.ReadFull(d.r, hdr[:]); err != nil {
-		return fmt.Errorf("qoa: failed to read frame header: %w", err)
-	}
-
-	d.channels = hdr[0]
-	d.sampleRate = uint32(hdr[1])<<16 | uint32(hdr[2])<<8 | uint32(hdr[3]) // uint24 big endian
-	samples := uint16(hdr[4])<<8 | uint16(hdr[5])
-	fsize := uint16(hdr[6])<<8 | uint16(hdr[7])
 
-	if d.channels == 0 || d.samples == 0 || d.sampleRate == 0 {
-		return errors.New("qoa: invalid frame header values")
-	}
-
-	d.lmsState = make([]lms, d.channels)
-	d.frameBuf = make([]int16, int(samples)*int(d.channels))
-
-	frameBytes := make([]byte, fsize-8)
-	if _, err := io.ReadFull(d.r, frameBytes); err != nil {
-		return fmt.Errorf("qoa: failed to read frame: %w", err)
-	}
-
-	offset := 0
-
-	// read LMS state
-	for ch := range d.channels {
-		// history
-		history := binary.BigEndian.Uint64(frameBytes[offset:])
-		weights := binary.BigEndian.Uint64(frameBytes[offset+8:])
-		for i := range lmsLen {
-			d.lmsState[ch].history[i] = int16(history >> 48)
-			history <<= 16
-			d.lmsState[ch].weights[i] = int16(weights >> 48)
-			weights <<= 16
+		d.addFrameIndex(off, samples, fsize)
+		if pos < start+int64(samples) {
+			return len(d.frameOffsets) - 1, nil
 		}
-		offset += 16
 	}
-
-	// decode all slices
-	for sampleIdx := uint32(0); sampleIdx < uint32(samples); sampleIdx += sliceLen {
-		for ch := range d.channels {
-			slice := binary.BigEndian.Uint64(frameBytes[offset:])
-			offset += 8
-
-			var scalefactor int = int((slice >> 60) & 0xf)
-			slice <<= 4
-
-			sliceStart := int(sampleIdx)*int(d.channels) + int(ch)
-			sliceEnd := clamp(int(sampleIdx)+sliceLen, 0, int(samples))*int(d.channels) + int(ch)
-
-			for si := sliceStart; si < sliceEnd; si += int(d.channels) {
-				predicted := d.lmsState[ch].predict()
-				quantized := int((slice >> 61) & 0x7)
-				dequantized := dequantTab[scalefactor][quantized]
-				reconstructed := clampS16(predicted + int(dequantized))
-
-				d.frameBuf[si] = reconstructed
-				slice <<= 3
-
-				d.lmsState[ch].update(reconstructed, dequantized)
-			}
-		}
-	}
-
-	d.readSamples += int64(samples)
-	d.framePos = 0
-
-	return nil
 }
 
-// decoding (again)
-
+// readFrame reads and decodes the next frame into frameBuf.
 func (d *Decoder) readFrame() error {
+	frameOffset := d.off
+
 	var hdr [8]byte
 	if _, err := io.ReadFull(d.r, hdr[:]); err != nil {
 		return fmt.Errorf("qoa: failed to read frame header: %w", err)
@@ -185,6 +143,19 @@ func (d *Decoder) readFrame() error {
 	samples := uint16(hdr[4])<<8 | uint16(hdr[5])
 	fsize := uint16(hdr[6])<<8 | uint16(hdr[7])
 
+	if d.channels == 0 {
+		// first frame
+		if channels == 0 || sampleRate == 0 {
+			return errors.New("qoa: invalid frame header values")
+		}
+		d.channels = channels
+		d.sampleRate = sampleRate
+		d.lmsState = make([]lms, d.channels)
+	}
+
+	if fsize < 8+lmsLen*4*uint16(channels) {
+		return errors.New("qoa: invalid frame header values")
+	}
 	dataSize := uint32(fsize) - 8 - lmsLen*4*uint32(channels)
 	numSlices := dataSize / 8
 	maxTotalSamples := numSlices * sliceLen
@@ -197,6 +168,18 @@ func (d *Decoder) readFrame() error {
 	if _, err := io.ReadFull(d.r, frameBytes); err != nil {
 		return fmt.Errorf("qoa: failed to read frame: %w", err)
 	}
+	d.off += int64(fsize)
+
+	if d.channels != 0 {
+		d.frameStart += int64(len(d.frameBuf) / int(d.channels))
+	}
+
+	// the last frame may be shorter
+	if n := int(samples) * int(d.channels); cap(d.frameBuf) < n {
+		d.frameBuf = make([]int16, n)
+	} else {
+		d.frameBuf = d.frameBuf[:n]
+	}
 
 	offset := 0
 
@@ -240,7 +223,9 @@ func (d *Decoder) readFrame() error {
 		}
 	}
 
-	d.readSamples += int64(samples)
+	if d.seeker != nil {
+		d.addFrameIndex(frameOffset, samples, fsize)
+	}
 	d.framePos = 0
 
 	return nil
@@ -263,7 +248,15 @@ func (d *Decoder) SampleFormat() afmt.SampleFormat {
 	}
 }
 
+// Bitrate returns the bitrate of the audio stream in bits per second. It implements codec.Bitrater.
+// QOA has a constant bitrate, which is computed from the size of a full frame.
+func (d *Decoder) Bitrate() int {
+	const frameLen = slicesPerFrame * sliceLen
+	return int(int64(frameSize(uint32(d.channels), slicesPerFrame)) * 8 * int64(d.sampleRate) / frameLen)
+}
+
 // Len returns the total number of frames.
+// It returns 0 if the number of frames is unknown, as in streamed QOA files.
 func (d *Decoder) Len() int {
 	return int(d.samples)
 }
@@ -295,60 +288,81 @@ func (d *Decoder) ReadSamples(p []float32) (int, error) {
 	return n, nil
 }
 
+// position returns the current position in frames.
+func (d *Decoder) position() int64 {
+	return d.frameStart + int64(d.framePos/int(d.channels))
+}
+
 // Seek seeks to the specified frame.
 // It returns the new offset relative to the start and/or an error.
 // It will return an error if the source is not an [io.Seeker].
+//
+// The seek index is built lazily, so the first seek far into the stream reads the frame headers up to the target.
 func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
 	// special case
 	if whence == io.SeekCurrent && offset == 0 {
-		return d.readSamples, nil
+		return d.position(), nil
 	}
 
 	if d.seeker == nil {
 		return 0, errors.New("qoa: resource is not seekable")
 	}
 
-	var targetSample int64
+	var target int64
 	switch whence {
 	case io.SeekStart:
-		targetSample = offset
+		target = offset
 	case io.SeekCurrent:
-		targetSample = int64(d.framePos) + offset
+		target = d.position() + offset
 	case io.SeekEnd:
-		targetSample = int64(d.samples) + offset
+		if d.samples == 0 {
+			return 0, errors.New("qoa: cannot seek relative to end of stream with unknown length")
+		}
+		target = int64(d.samples) + offset
 	default:
 		return 0, errors.New("qoa: invalid whence")
 	}
 
-	if targetSample < 0 || targetSample > int64(d.samples) {
+	if target < 0 || (d.samples != 0 && target > int64(d.samples)) {
 		return 0, errors.New("qoa: seek out of bounds")
 	}
 
-	d.readSamples = targetSample
-
-	// find the frame
-	var frameIdx int
-	for i := range d.frameOffsets {
-		if int64(d.frameOffsets[i].samples) > targetSample {
-			frameIdx = i
-			break
+	i, err := d.findFrame(target)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		// past the last frame, next read returns io.EOF
+		off, start := d.indexEnd()
+		if target > start {
+			return 0, errors.New("qoa: seek out of bounds")
+		}
+		if _, err := d.seeker.Seek(off, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("qoa: failed to seek: %w", err)
 		}
-		targetSample -= int64(d.frameOffsets[i].samples)
+		d.off = off
+		d.frameBuf = d.frameBuf[:0]
+		d.frameStart = start
+		d.framePos = 0
+		return target, nil
 	}
 
 	// seek to frame
-	_, err := d.seeker.Seek(d.frameOffsets[frameIdx].offset, io.SeekStart)
-	if err != nil {
+	frame := d.frameOffsets[i]
+	if _, err := d.seeker.Seek(frame.offset, io.SeekStart); err != nil {
 		return 0, fmt.Errorf("qoa: failed to seek: %w", err)
 	}
+	d.off = frame.offset
+	d.frameBuf = d.frameBuf[:0]
+	d.frameStart = frame.start
 
 	if err := d.readFrame(); err != nil {
 		return 0, err
 	}
 
-	d.framePos = int(targetSample) * int(d.channels)
+	d.framePos = int(target-frame.start) * int(d.channels)
 
-	return int64(d.framePos), nil
+	return target, nil
 }
 
 func init() {
@@ -0,0 +1,222 @@
+package qoa_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/codec/qoa"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+func encodeFixture(t *testing.T, numFrames, numChannels int) []byte {
+	t.Helper()
+
+	ws := &testutil.WriteSeeker{}
+	enc, err := qoa.NewEncoder(ws, afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: numChannels})
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make([]float32, numFrames*numChannels)
+	for i := range samples {
+		samples[i] = float32(0.5 * math.Sin(float64(i)/20))
+	}
+	if _, err := enc.WriteSamples(samples); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return ws.Bytes()
+}
+
+func TestDecoderNonSeekable(t *testing.T) {
+	const numFrames = 12345 // spans multiple QOA frames
+	b := encodeFixture(t, numFrames, 2)
+
+	dec, err := qoa.NewDecoder(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) != numFrames*2 {
+		t.Fatalf("expected %d samples, got %d", numFrames*2, len(want))
+	}
+
+	dec, err = qoa.NewDecoder(struct{ io.Reader }{bytes.NewReader(b)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("non-seekable decode output does not match seekable decode output")
+	}
+	if _, err := dec.Seek(0, io.SeekStart); err == nil {
+		t.Errorf("expected error when seeking a non-seekable source")
+	}
+
+	dec, name, err := codec.Decode(struct{ io.Reader }{bytes.NewReader(b)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "qoa" {
+		t.Errorf("expected format qoa, got %s", name)
+	}
+	got, err = aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("codec.Decode output does not match seekable decode output")
+	}
+}
+
+func TestDecoderBitrate(t *testing.T) {
+	for numChannels, want := range map[int]int{1: 142773, 2: 284996} {
+		dec, err := qoa.NewDecoder(bytes.NewReader(encodeFixture(t, 100, numChannels)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a full frame holds 5120 samples per channel in 8 + (16 + 2048) × channels bytes
+		if bps, ok := codec.Bitrate(dec); !ok || bps != want {
+			t.Errorf("%d channels: expected %d bps, got %d, %v", numChannels, want, bps, ok)
+		}
+	}
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	*bytes.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += n
+	return n, err
+}
+
+func TestDecoderReadsOnce(t *testing.T) {
+	b := encodeFixture(t, 12345, 2)
+	cr := &countingReader{Reader: bytes.NewReader(b)}
+
+	dec, err := qoa.NewDecoder(cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := aio.ReadAll(dec); err != nil {
+		t.Fatal(err)
+	}
+	if cr.n != len(b) {
+		t.Errorf("expected %d bytes read, got %d", len(b), cr.n)
+	}
+}
+
+func TestDecoderSeek(t *testing.T) {
+	const numFrames = 12345
+	b := encodeFixture(t, numFrames, 2)
+
+	dec, err := qoa.NewDecoder(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fresh decoder, so that the seek index has to be built by seeking
+	dec, err = qoa.NewDecoder(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []int64{10000, 0, 255, 256, 257, 5000, numFrames - 1, numFrames} {
+		pos, err := dec.Seek(target, io.SeekStart)
+		if err != nil {
+			t.Fatalf("seek to %d: %v", target, err)
+		}
+		if pos != target {
+			t.Errorf("expected position %d, got %d", target, pos)
+		}
+
+		buf := make([]float32, 100)
+		n, err := aio.ReadFull(dec, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatal(err)
+		}
+		if !slices.Equal(buf[:n], want[target*2:min(target*2+100, int64(len(want)))]) {
+			t.Errorf("samples after seek to %d do not match", target)
+		}
+		if cur, _ := dec.Seek(0, io.SeekCurrent); cur != target+int64(n/2) {
+			t.Errorf("expected current position %d, got %d", target+int64(n/2), cur)
+		}
+	}
+
+	if _, err := dec.Seek(numFrames+1, io.SeekStart); err == nil {
+		t.Errorf("expected error when seeking out of bounds")
+	}
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	const numFrames = 12345
+	want := encodeFixture(t, numFrames, 2)
+	dec, err := qoa.NewDecoder(bytes.NewReader(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSamples, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		enc, err := qoa.NewStreamEncoder(pw, afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 2})
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		samples := make([]float32, numFrames*2)
+		for i := range samples {
+			samples[i] = float32(0.5 * math.Sin(float64(i)/20))
+		}
+		// write in odd-sized chunks
+		for len(samples) > 0 {
+			n := min(len(samples), 999)
+			if _, err := enc.WriteSamples(samples[:n]); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			samples = samples[n:]
+		}
+		pw.CloseWithError(enc.Close())
+	}()
+
+	dec, err = qoa.NewDecoder(pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Len() != 0 {
+		t.Errorf("expected unknown Len, got %d", dec.Len())
+	}
+	got, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, wantSamples) {
+		t.Errorf("streamed samples do not match (got %d samples, want %d)", len(got), len(wantSamples))
+	}
+}
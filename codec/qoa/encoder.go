@@ -15,7 +15,8 @@ import (
 //
 // The caller retains ownership of the writer; it will not be closed automatically.
 type Encoder struct {
-	w        io.WriteSeeker
+	w        io.Writer
+	ws       io.WriteSeeker // nil in streaming mode
 	format   afmt.Format
 	samples  uint32
 	lmsState []lms
@@ -23,7 +24,20 @@ type Encoder struct {
 }
 
 // NewEncoder creates a new [Encoder] for QOA format.
+// The total number of samples is written to the file header on [Encoder.Close].
 func NewEncoder(w io.WriteSeeker, format afmt.Format) (*Encoder, error) {
+	e, err := NewStreamEncoder(w, format)
+	if err != nil {
+		return nil, err
+	}
+	e.ws = w
+	return e, nil
+}
+
+// NewStreamEncoder creates a new [Encoder] for QOA format in streaming mode.
+// Unlike [NewEncoder], it only needs an [io.Writer], as the number of samples in the file header
+// is left as 0 (unknown), so the output can be written to pipes, sockets, etc.
+func NewStreamEncoder(w io.Writer, format afmt.Format) (*Encoder, error) {
 	e := &Encoder{
 		w:      w,
 		format: format,
@@ -176,29 +190,34 @@ func (e *Encoder) WriteSamples(p []float32) (int, error) {
 	return written, nil
 }
 
-// Close encodes any remaining frames and writes the data size.
+// Close encodes any remaining frames and writes the data size, unless in streaming mode.
 //
 // It will NOT close the underlying writer, even if it implements [io.Closer].
 // Closing the underlying writer is the owner's responsibility.
 func (e *Encoder) Close() error {
-	if len(e.buf) != 0 {
-		frameSize := 256 * e.format.NumChannels
-		frame := e.buf[:frameSize]
-		if err := e.encodeFrame(frame); err != nil {
+	if frameLen := len(e.buf) / e.format.NumChannels; frameLen != 0 {
+		// encode the remaining samples as a shorter last frame
+		if err := e.encodeFrame(e.buf[:frameLen*e.format.NumChannels]); err != nil {
 			return fmt.Errorf("qoa: failed to encode frame: %w", err)
 		}
+		e.buf = nil
+		e.samples += uint32(frameLen)
+	}
+
+	if e.ws == nil {
+		return nil
 	}
 
 	// patch samples
-	if _, err := e.w.Seek(int64(len(magic)), io.SeekStart); err != nil {
+	if _, err := e.ws.Seek(int64(len(magic)), io.SeekStart); err != nil {
 		return fmt.Errorf("qoa: failed to seek to samples: %w", err)
 	}
-	if err := binary.Write(e.w, binary.BigEndian, e.samples); err != nil {
+	if err := binary.Write(e.ws, binary.BigEndian, e.samples); err != nil {
 		return fmt.Errorf("qoa: failed to write sampled: %w", err)
 	}
 
 	// seek to start
-	if _, err := e.w.Seek(0, io.SeekStart); err != nil {
+	if _, err := e.ws.Seek(0, io.SeekStart); err != nil {
 		return fmt.Errorf("qoa: failed to seek to start: %w", err)
 	}
 
@@ -1,4 +1,4 @@
-// Package qoa implements decoding of QOA (Quite OK Audio) files.
+// Package qoa implements encoding and decoding of QOA (Quite OK Audio) files.
 package qoa
 
 // https://qoaformat.org/qoa-specification.pdf
@@ -0,0 +1,145 @@
+package rawpcm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/encoding/pcm"
+)
+
+var _ codec.Decoder = (*Decoder)(nil)
+
+// Decoder represents the decoder for raw PCM audio.
+// It implements codec.Decoder.
+type Decoder struct {
+	r         io.Reader
+	format    afmt.Format
+	sampleFmt afmt.SampleFormat
+
+	start int64 // offset of the first frame
+	size  int64 // size of the audio data in bytes, or -1 if unknown
+
+	dec         aio.SampleReader
+	samplesRead int64
+}
+
+// NewDecoder creates a new [Decoder] for raw PCM audio with the specified format and sample format.
+// The audio data starts at the current position of r.
+//
+// If r is an [io.Seeker], the length is determined from its size and seeking is supported.
+func NewDecoder(r io.Reader, format afmt.Format, sampleFmt afmt.SampleFormat) (codec.Decoder, error) {
+	if err := validate(format, sampleFmt); err != nil {
+		return nil, err
+	}
+
+	d := &Decoder{
+		r:         r,
+		format:    format,
+		sampleFmt: sampleFmt,
+		size:      -1,
+		dec:       pcm.NewDecoder(r, sampleFmt),
+	}
+
+	if s, ok := r.(io.Seeker); ok {
+		var err error
+		if d.start, err = s.Seek(0, io.SeekCurrent); err != nil {
+			return nil, fmt.Errorf("rawpcm: failed to get position: %w", err)
+		}
+		end, err := s.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, fmt.Errorf("rawpcm: failed to get size: %w", err)
+		}
+		if _, err := s.Seek(d.start, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("rawpcm: failed to seek to start: %w", err)
+		}
+		d.size = end - d.start
+	}
+
+	return d, nil
+}
+
+// validate checks the format and sample format.
+func validate(format afmt.Format, sampleFmt afmt.SampleFormat) error {
+	if format.NumChannels <= 0 {
+		return fmt.Errorf("rawpcm: invalid number of channels: %d", format.NumChannels)
+	}
+	if sampleFmt.BytesPerSample() <= 0 {
+		return fmt.Errorf("rawpcm: invalid sample format: %s", sampleFmt.String())
+	}
+	return nil
+}
+
+// Format returns the audio stream format.
+func (d *Decoder) Format() afmt.Format {
+	return d.format
+}
+
+// SampleFormat returns the sample format.
+func (d *Decoder) SampleFormat() afmt.SampleFormat {
+	return d.sampleFmt
+}
+
+// ReadSamples reads float32 samples into p.
+// It returns the number of samples read and/or an error.
+func (d *Decoder) ReadSamples(p []float32) (n int, err error) {
+	n, err = d.dec.ReadSamples(p)
+	d.samplesRead += int64(n)
+	return
+}
+
+// Len returns the total number of frames.
+// It returns 0 if the source is not an [io.Seeker], as the length is unknown.
+func (d *Decoder) Len() int {
+	if d.size < 0 {
+		return 0
+	}
+	return int(d.size / int64(d.sampleFmt.BytesPerFrame(d.format.NumChannels)))
+}
+
+// Seek seeks to the specified frame.
+// It returns the new offset relative to the start and/or an error.
+// It will return an error if the source is not an [io.Seeker].
+func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
+	numChans := int64(d.format.NumChannels)
+
+	// Special case
+	if offset == 0 && whence == io.SeekCurrent {
+		return d.samplesRead / numChans, nil
+	}
+
+	s, ok := d.r.(io.Seeker)
+	if !ok {
+		return 0, errors.New("rawpcm: resource does not support seeking")
+	}
+
+	totalFrames := int64(d.Len())
+
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = d.samplesRead/numChans + offset
+	case io.SeekEnd:
+		target = totalFrames + offset
+	default:
+		return 0, errors.New("rawpcm: invalid seek whence")
+	}
+
+	if target < 0 || target > totalFrames {
+		return 0, errors.New("rawpcm: seek out of bounds")
+	}
+
+	frameSize := int64(d.sampleFmt.BytesPerFrame(d.format.NumChannels))
+	if _, err := s.Seek(d.start+target*frameSize, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("rawpcm: failed to seek: %w", err)
+	}
+	d.dec.(*pcm.Decoder).Reset(d.r)
+
+	d.samplesRead = target * numChans
+	return target, nil
+}
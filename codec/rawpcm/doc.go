@@ -0,0 +1,5 @@
+// Package rawpcm implements encoding and decoding of raw (headerless) PCM audio, such as ".raw", ".pcm", or ".s16le" files.
+//
+// As raw PCM has no header, the audio stream format and sample format must be specified explicitly,
+// and the format can't be detected by [github.com/MatusOllah/resona/codec.Decode].
+package rawpcm
@@ -0,0 +1,58 @@
+package rawpcm
+
+import (
+	"io"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/encoding/pcm"
+)
+
+var _ aio.SampleWriteCloser = (*Encoder)(nil)
+
+// Encoder represents the encoder for raw PCM audio.
+//
+// The caller retains ownership of the writer; it will not be closed automatically.
+type Encoder struct {
+	format    afmt.Format
+	sampleFmt afmt.SampleFormat
+	enc       aio.SampleWriter
+}
+
+// NewEncoder creates a new [Encoder] for raw PCM audio with the specified format and sample format.
+//
+// The caller retains ownership of the writer; it will not be closed automatically.
+func NewEncoder(w io.Writer, format afmt.Format, sampleFmt afmt.SampleFormat) (*Encoder, error) {
+	if err := validate(format, sampleFmt); err != nil {
+		return nil, err
+	}
+
+	return &Encoder{
+		format:    format,
+		sampleFmt: sampleFmt,
+		enc:       pcm.NewEncoder(w, sampleFmt),
+	}, nil
+}
+
+// Format returns the audio stream format.
+func (e *Encoder) Format() afmt.Format {
+	return e.format
+}
+
+// SampleFormat returns the sample format.
+func (e *Encoder) SampleFormat() afmt.SampleFormat {
+	return e.sampleFmt
+}
+
+// WriteSamples encodes and writes samples.
+func (e *Encoder) WriteSamples(p []float32) (int, error) {
+	return e.enc.WriteSamples(p)
+}
+
+// Close does nothing, as raw PCM has no header to finalize.
+//
+// It will NOT close the underlying writer, even if it implements [io.Closer].
+// Closing the underlying writer is the owner's responsibility.
+func (e *Encoder) Close() error {
+	return nil
+}
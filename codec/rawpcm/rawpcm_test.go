@@ -0,0 +1,192 @@
+package rawpcm_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec/rawpcm"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/internal/testutil"
+)
+
+var (
+	format    = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+	sampleFmt = afmt.SampleFormat{BitDepth: 24, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}
+)
+
+// encode encodes 100 frames of 24-bit stereo audio and returns the raw data and the decoded samples.
+func encode(t *testing.T) ([]byte, []float32) {
+	t.Helper()
+
+	p := make([]float32, 100*format.NumChannels)
+	for i := range p {
+		p[i] = float32(i%23)/23 - 0.5
+	}
+
+	var buf bytes.Buffer
+	enc, err := rawpcm.NewEncoder(&buf, format, sampleFmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.WriteSamples(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dec, err := rawpcm.NewDecoder(bytes.NewReader(buf.Bytes()), format, sampleFmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), want
+}
+
+func TestDecoderLen(t *testing.T) {
+	b, want := encode(t)
+	if len(b) != 100*6 {
+		t.Fatalf("expected %d bytes, got %d", 100*6, len(b))
+	}
+
+	dec, err := rawpcm.NewDecoder(bytes.NewReader(b), format, sampleFmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Len() != 100 {
+		t.Errorf("expected Len 100, got %d", dec.Len())
+	}
+	if dec.Format() != format || dec.SampleFormat() != sampleFmt {
+		t.Errorf("expected format passthrough")
+	}
+	if len(want) != 200 {
+		t.Errorf("expected 200 samples, got %d", len(want))
+	}
+}
+
+func TestDecoderSeek(t *testing.T) {
+	b, want := encode(t)
+
+	// prepend garbage to check that seeking is relative to the starting position
+	r := bytes.NewReader(append([]byte{1, 2, 3, 4, 5}, b...))
+	if _, err := r.Seek(5, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	dec, err := rawpcm.NewDecoder(r, format, sampleFmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Len() != 100 {
+		t.Errorf("expected Len 100, got %d", dec.Len())
+	}
+
+	tests := []struct {
+		offset int64
+		whence int
+		want   int64
+	}{
+		{33, io.SeekStart, 33},
+		{-7, io.SeekEnd, 93},
+		{0, io.SeekEnd, 100},
+		{-50, io.SeekCurrent, 50},
+		{1, io.SeekStart, 1},
+	}
+	for _, tt := range tests {
+		pos, err := dec.Seek(tt.offset, tt.whence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos != tt.want {
+			t.Errorf("expected position %d, got %d", tt.want, pos)
+		}
+
+		// read 3 frames, which don't align with any power of two
+		p := make([]float32, 6)
+		n, err := aio.ReadFull(dec, p)
+		if err != nil && pos != 100 {
+			t.Fatal(err)
+		}
+		if end := min(int(pos)*2+6, len(want)); !slices.Equal(p[:n], want[pos*2:end]) {
+			t.Errorf("wrong samples after seeking to frame %d", pos)
+		}
+		if pos, _ := dec.Seek(0, io.SeekCurrent); pos != tt.want+int64(n/2) {
+			t.Errorf("expected position %d after reading, got %d", tt.want+int64(n/2), pos)
+		}
+	}
+
+	if _, err := dec.Seek(101, io.SeekStart); err == nil {
+		t.Error("expected error when seeking out of bounds")
+	}
+}
+
+func TestDecoderSeekShortReads(t *testing.T) {
+	b, want := encode(t)
+
+	// 5 bytes per read leave an incomplete sample behind, which seeking must discard
+	dec, err := rawpcm.NewDecoder(&testutil.ShortReadSeeker{ReadSeeker: bytes.NewReader(b), N: 5}, format, sampleFmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.ReadSamples(make([]float32, 2*2)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pos := range []int64{0, 41} {
+		if _, err := dec.Seek(pos, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		p := make([]float32, 3*2)
+		if _, err := aio.ReadFull(dec, p); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(p, want[pos*2:pos*2+6]) {
+			t.Errorf("wrong samples after seeking to frame %d: expected %v, got %v", pos, want[pos*2:pos*2+6], p)
+		}
+	}
+}
+
+// nonSeeker hides the io.Seeker implementation of the underlying reader.
+type nonSeeker struct {
+	io.Reader
+}
+
+func TestDecoderNonSeekable(t *testing.T) {
+	b, want := encode(t)
+
+	dec, err := rawpcm.NewDecoder(nonSeeker{bytes.NewReader(b)}, format, sampleFmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Len() != 0 {
+		t.Errorf("expected unknown length (0), got %d", dec.Len())
+	}
+	if _, err := dec.Seek(10, io.SeekStart); err == nil {
+		t.Error("expected error when seeking")
+	}
+
+	got, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("unexpected samples")
+	}
+}
+
+func TestInvalidFormat(t *testing.T) {
+	if _, err := rawpcm.NewDecoder(bytes.NewReader(nil), afmt.Format{}, sampleFmt); err == nil {
+		t.Error("expected error for 0 channels")
+	}
+	if _, err := rawpcm.NewEncoder(io.Discard, format, afmt.SampleFormat{}); err == nil {
+		t.Error("expected error for invalid sample format")
+	}
+}
@@ -0,0 +1,71 @@
+package codec
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/MatusOllah/resona/afmt"
+)
+
+// readAllChunk is the number of frames [ReadAll] reads at a time, and so between progress callbacks.
+const readAllChunk = 8192
+
+// ReadAll decodes d until EOF and returns the interleaved samples it read, along with their format.
+// A successful call returns err == nil, not err == EOF.
+//
+// The buffer is preallocated from d.Len(), and grown if d turns out to be longer than that.
+// If progress is not nil, it is called after every chunk read with the number of frames decoded so far
+// and the total number of frames, which is 0 when d.Len() does not know it.
+// If the total turns out to be too small, it is raised to the number of frames decoded.
+func ReadAll(d Decoder, progress func(done, total int)) ([]float32, afmt.Format, error) {
+	return ReadAllContext(context.Background(), d, progress)
+}
+
+// ReadAllContext is like [ReadAll], but stops with the context's error once ctx is done,
+// returning the samples read until then. The context is checked between reads,
+// so a read that blocks is not interrupted.
+func ReadAllContext(ctx context.Context, d Decoder, progress func(done, total int)) ([]float32, afmt.Format, error) {
+	format := d.Format()
+	if format.NumChannels <= 0 {
+		return nil, format, errors.New("codec: invalid number of channels")
+	}
+	chunk := readAllChunk * format.NumChannels
+
+	total := max(d.Len(), 0)
+	b := make([]float32, 0, max(total*format.NumChannels, chunk))
+	var overflow []float32
+	for {
+		if err := ctx.Err(); err != nil {
+			return b, format, err
+		}
+
+		var n int
+		var err error
+		if len(b) < cap(b) {
+			n, err = d.ReadSamples(b[len(b):min(cap(b), len(b)+chunk)])
+			b = b[:len(b)+n]
+		} else {
+			// the buffer is only grown once d is known to be longer than it, so that an exact length
+			// does not make it grow just to read EOF
+			if overflow == nil {
+				overflow = make([]float32, chunk)
+			}
+			n, err = d.ReadSamples(overflow)
+			b = append(b, overflow[:n]...)
+		}
+		if n > 0 && progress != nil {
+			done := len(b) / format.NumChannels
+			if total > 0 && done > total {
+				total = done
+			}
+			progress(done, total)
+		}
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return b, format, err
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package codec_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// slowDecoder decodes frames stereo frames numbered from 0, at most 1000 frames per read, taking a while for each read.
+type slowDecoder struct {
+	frames, len int
+	pos         int
+}
+
+func (d *slowDecoder) ReadSamples(p []float32) (int, error) {
+	time.Sleep(100 * time.Microsecond)
+	if d.pos == d.frames {
+		return 0, io.EOF
+	}
+	n := min(len(p)/2, 1000, d.frames-d.pos)
+	for i := range n {
+		p[2*i], p[2*i+1] = float32(d.pos+i), float32(d.pos+i)
+	}
+	d.pos += n
+	return 2 * n, nil
+}
+
+func (d *slowDecoder) Seek(offset int64, whence int) (int64, error) { return 0, errors.ErrUnsupported }
+func (d *slowDecoder) Format() afmt.Format {
+	return afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 2}
+}
+func (d *slowDecoder) SampleFormat() afmt.SampleFormat { return afmt.SampleFormat{} }
+func (d *slowDecoder) Len() int                        { return d.len }
+
+type progressCall struct{ done, total int }
+
+func TestReadAll(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		len       int
+		wantTotal int
+	}{
+		{"ExactLen", 20000, 20000},
+		{"UnderestimatedLen", 5000, 20000},
+		{"UnknownLen", 0, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []progressCall
+			p, format, err := codec.ReadAll(&slowDecoder{frames: 20000, len: tt.len}, func(done, total int) {
+				calls = append(calls, progressCall{done, total})
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format.NumChannels != 2 || len(p) != 2*20000 {
+				t.Fatalf("expected 20000 stereo frames, got %d samples of %v", len(p), format)
+			}
+			for i := range 20000 {
+				if p[2*i] != float32(i) || p[2*i+1] != float32(i) {
+					t.Fatalf("expected frame %d to be %d, got %v", i, i, p[2*i:2*i+2])
+				}
+			}
+			if tt.len == 20000 && cap(p) != len(p) {
+				t.Errorf("expected the buffer to be preallocated exactly, got a capacity of %d", cap(p))
+			}
+
+			if len(calls) < 20 {
+				t.Fatalf("expected a progress callback per read of up to 1000 frames, got %v", calls)
+			}
+			for i, c := range calls {
+				if i > 0 && c.done <= calls[i-1].done {
+					t.Errorf("expected increasing progress, got %v after %v", c, calls[i-1])
+				}
+				if tt.wantTotal > 0 && c.done > c.total {
+					t.Errorf("expected the total to cover the progress, got %v", c)
+				}
+			}
+			if last := calls[len(calls)-1]; last != (progressCall{20000, tt.wantTotal}) {
+				t.Errorf("expected the last progress to be %v, got %v", progressCall{20000, tt.wantTotal}, last)
+			}
+		})
+	}
+}
+
+func TestReadAllContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p, _, err := codec.ReadAllContext(ctx, &slowDecoder{frames: 20000, len: 20000}, func(done, total int) {
+		if done >= 5000 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(p) != 2*5000 || p[len(p)-1] != 4999 {
+		t.Errorf("expected the 5000 frames read before cancelling, got %d", len(p)/2)
+	}
+
+	if _, _, err := codec.ReadAllContext(ctx, &slowDecoder{frames: 20000}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from a done context, got %v", err)
+	}
+}
@@ -20,14 +20,17 @@ var (
 	EightSVXID   iff.FourCC = iff.FourCC{'8', 'S', 'V', 'X'}
 	SixteenSVXID iff.FourCC = iff.FourCC{'1', '6', 'S', 'V'}
 
-	VHDRID iff.FourCC = iff.FourCC{'V', 'H', 'D', 'R'}
-	/*
-		NameID      iff.FourCC = iff.FourCC{'N', 'A', 'M', 'E'}
-		CopyrightID iff.FourCC = iff.FourCC{'(', 'c', ')', ' '}
-		AuthorID    iff.FourCC = iff.FourCC{'A', 'U', 'T', 'H'}
-		AnnoID      iff.FourCC = iff.FourCC{'A', 'N', 'N', 'O'}
-	*/
-	BodyID iff.FourCC = iff.FourCC{'B', 'O', 'D', 'Y'}
+	VHDRID      iff.FourCC = iff.FourCC{'V', 'H', 'D', 'R'}
+	NameID      iff.FourCC = iff.FourCC{'N', 'A', 'M', 'E'}
+	CopyrightID iff.FourCC = iff.FourCC{'(', 'c', ')', ' '}
+	AuthorID    iff.FourCC = iff.FourCC{'A', 'U', 'T', 'H'}
+	AnnoID      iff.FourCC = iff.FourCC{'A', 'N', 'N', 'O'}
+	BodyID      iff.FourCC = iff.FourCC{'B', 'O', 'D', 'Y'}
+)
+
+var (
+	_ codec.Decoder  = (*Decoder)(nil)
+	_ codec.Metadata = (*Decoder)(nil)
 )
 
 // Decoder represents the decoder for the Amiga IFF/8SVX/16SVX file format.
@@ -45,8 +48,14 @@ type Decoder struct {
 	CompressionMethod uint8
 	Volume            Fixed16_16
 
-	bodyChunk *iff.Chunk
-	dataRead  int
+	name        string
+	author      string
+	copyright   string
+	annotations []string
+
+	bodyChunk   *iff.Chunk
+	samplesRead int64
+	trailerRead bool
 
 	pcmDec aio.SampleReader
 }
@@ -86,8 +95,20 @@ func NewDecoder(r io.Reader) (_ codec.Decoder, err error) {
 		switch {
 		case bytes.Equal(chunk.ID[:], BodyID[:]):
 			d.bodyChunk = chunk
-			d.pcmDec = pcm.NewDecoder(d.bodyChunk.Reader, d.SampleFormat())
+			d.pcmDec = d.newBodyDecoder()
+
+			// Text chunks may also follow the BODY chunk. If we can seek, scan them now;
+			// otherwise they are read once the BODY chunk has been fully consumed.
+			if rs, ok := r.(io.ReadSeeker); ok {
+				if err := d.scanTrailer(rs); err != nil {
+					return nil, fmt.Errorf("failed to scan chunks after BODY: %w", err)
+				}
+			}
 			return d, nil
+		case d.isTextChunk(chunk.ID):
+			if err := d.readText(chunk.ID, chunk.Reader); err != nil {
+				return nil, fmt.Errorf("failed to read %s chunk: %w", chunk.ID, err)
+			}
 		default:
 			_, _ = io.Copy(io.Discard, chunk.Reader)
 		}
@@ -120,13 +141,165 @@ func (d *Decoder) parseVHDR() error {
 	if err := binary.Read(chunk.Reader, binary.BigEndian, &d.sampleRate); err != nil {
 		return fmt.Errorf("failed to read sample rate: %w", err)
 	}
+	if err := binary.Read(chunk.Reader, binary.BigEndian, &d.NumOctaves); err != nil {
+		return fmt.Errorf("failed to read number of octaves: %w", err)
+	}
+	if err := binary.Read(chunk.Reader, binary.BigEndian, &d.CompressionMethod); err != nil {
+		return fmt.Errorf("failed to read compression method: %w", err)
+	}
 	if err := binary.Read(chunk.Reader, binary.BigEndian, &d.Volume); err != nil {
 		return fmt.Errorf("failed to read volume: %w", err)
 	}
 
+	switch {
+	case d.CompressionMethod == CompressionFibonacci && d.bitDepth != 8:
+		return errors.New("Fibonacci-delta compression is only supported for 8SVX")
+	case d.CompressionMethod > CompressionFibonacci:
+		return fmt.Errorf("unsupported compression method %d", d.CompressionMethod)
+	}
+
 	return nil
 }
 
+// isTextChunk reports whether id is one of the text chunk IDs (NAME, AUTH, "(c) ", ANNO).
+func (d *Decoder) isTextChunk(id iff.FourCC) bool {
+	return id == NameID || id == AuthorID || id == CopyrightID || id == AnnoID
+}
+
+// readText reads a text chunk and stores its value.
+// The text is trimmed at the first NUL byte.
+func (d *Decoder) readText(id iff.FourCC, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	text := string(b)
+
+	switch id {
+	case NameID:
+		d.name = text
+	case AuthorID:
+		d.author = text
+	case CopyrightID:
+		d.copyright = text
+	case AnnoID:
+		d.annotations = append(d.annotations, text)
+	}
+	return nil
+}
+
+// scanTrailer reads the text chunks following the BODY chunk and seeks back to the start of the BODY chunk data.
+// A truncated trailing chunk is ignored.
+func (d *Decoder) scanTrailer(rs io.ReadSeeker) error {
+	bodyStart, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	// chunks are padded to an even length
+	bodyLen := int64(d.bodyChunk.Len)
+	if _, err := rs.Seek(bodyStart+bodyLen+bodyLen&1, io.SeekStart); err != nil {
+		return err
+	}
+
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(rs, hdr[:]); err != nil {
+			break
+		}
+		id := iff.FourCC{hdr[0], hdr[1], hdr[2], hdr[3]}
+		size := int64(binary.BigEndian.Uint32(hdr[4:]))
+
+		skip := size + size&1
+		if d.isTextChunk(id) {
+			lr := &io.LimitedReader{R: rs, N: size}
+			if err := d.readText(id, lr); err != nil {
+				return err
+			}
+			if lr.N != 0 {
+				break
+			}
+			skip = size & 1
+		}
+		if _, err := rs.Seek(skip, io.SeekCurrent); err != nil {
+			return err
+		}
+	}
+
+	d.trailerRead = true
+	_, err = rs.Seek(bodyStart, io.SeekStart)
+	return err
+}
+
+// readTrailer reads the text chunks following the BODY chunk from a non-seekable stream.
+// It must only be called once the BODY chunk has been fully consumed.
+func (d *Decoder) readTrailer() {
+	d.trailerRead = true
+	for {
+		chunk, err := d.iffR.NextChunk()
+		if err != nil {
+			return
+		}
+		if d.isTextChunk(chunk.ID) {
+			if err := d.readText(chunk.ID, chunk.Reader); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Name returns the name (title) of the sound from the NAME chunk.
+func (d *Decoder) Name() string {
+	return d.name
+}
+
+// Author returns the author of the sound from the AUTH chunk.
+func (d *Decoder) Author() string {
+	return d.author
+}
+
+// Copyright returns the copyright notice from the "(c) " chunk.
+func (d *Decoder) Copyright() string {
+	return d.copyright
+}
+
+// Annotations returns the annotations from the ANNO chunks, in file order.
+func (d *Decoder) Annotations() []string {
+	return d.annotations
+}
+
+// Tags returns the text chunks as tags. It implements codec.Metadata.
+//
+// NAME maps to "TITLE", AUTH to "ARTIST", "(c) " to "COPYRIGHT" and ANNO to "COMMENT".
+// If the stream is not seekable, chunks following the BODY chunk are only available once all samples have been read.
+func (d *Decoder) Tags() map[string][]string {
+	tags := map[string][]string{}
+	if d.name != "" {
+		tags["TITLE"] = []string{d.name}
+	}
+	if d.author != "" {
+		tags["ARTIST"] = []string{d.author}
+	}
+	if d.copyright != "" {
+		tags["COPYRIGHT"] = []string{d.copyright}
+	}
+	if len(d.annotations) > 0 {
+		tags["COMMENT"] = d.annotations
+	}
+	return tags
+}
+
+// newBodyDecoder returns a decoder reading from the start of the BODY chunk.
+func (d *Decoder) newBodyDecoder() aio.SampleReader {
+	if d.CompressionMethod == CompressionFibonacci {
+		return newFibDecoder(d.bodyChunk.Reader)
+	}
+	return pcm.NewDecoder(d.bodyChunk.Reader, d.SampleFormat())
+}
+
 // Format returns the audio stream format.
 func (d *Decoder) Format() afmt.Format {
 	return afmt.Format{
@@ -148,12 +321,19 @@ func (d *Decoder) SampleFormat() afmt.SampleFormat {
 // It returns the number of samples read and/or an error.
 func (d *Decoder) ReadSamples(p []float32) (n int, err error) {
 	n, err = d.pcmDec.ReadSamples(p)
-	d.dataRead += n * int(d.bitDepth/8)
+	d.samplesRead += int64(n)
+	if err == io.EOF && !d.trailerRead {
+		d.readTrailer()
+	}
 	return n, err
 }
 
 // Len returns the total number of frames (mono samples).
 func (d *Decoder) Len() int {
+	if d.CompressionMethod == CompressionFibonacci {
+		// 2 header bytes followed by 2 samples per byte
+		return max(d.bodyChunk.Len-2, 0) * 2
+	}
 	frameSize := int(d.bitDepth / 8)
 	if frameSize == 0 {
 		return 0
@@ -161,24 +341,34 @@ func (d *Decoder) Len() int {
 	return d.bodyChunk.Len / frameSize
 }
 
+// Bitrate returns the bitrate of the audio stream in bits per second. It implements codec.Bitrater.
+// Fibonacci-delta compressed bodies store 4 bits per sample.
+func (d *Decoder) Bitrate() int {
+	if d.CompressionMethod == CompressionFibonacci {
+		return int(d.sampleRate) * 4
+	}
+	return int(d.sampleRate) * int(d.bitDepth)
+}
+
 // Seek seeks to the specified frame (mono sample).
 // It returns the new offset relative to the start and/or an error.
 // It will return an error if the source is not an [io.Seeker].
+//
+// For Fibonacci-delta compressed bodies, seeking decodes forward from the start, as each sample depends on the previous one.
 func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
 	// Special case
 	if offset == 0 && whence == io.SeekCurrent {
-		return int64(d.dataRead) / int64(d.SampleFormat().BytesPerSample()), nil
+		return d.samplesRead, nil
 	}
 
-	frameSize := int64(d.bitDepth / 8)
-	totalFrames := int64(d.bodyChunk.Len) / frameSize
+	totalFrames := int64(d.Len())
 
 	var targetFrame int64
 	switch whence {
 	case io.SeekStart:
 		targetFrame = offset
 	case io.SeekCurrent:
-		targetFrame = int64(d.dataRead)/frameSize + offset
+		targetFrame = d.samplesRead + offset
 	case io.SeekEnd:
 		targetFrame = totalFrames + offset
 	default:
@@ -189,14 +379,30 @@ func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
 		return 0, fmt.Errorf("svx: seek out of bounds")
 	}
 
+	if d.CompressionMethod == CompressionFibonacci {
+		if _, err := d.bodyChunk.Reader.Seek(0, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("svx: failed to seek: %w", err)
+		}
+		d.pcmDec = d.newBodyDecoder()
+		d.samplesRead = 0
+		if _, err := aio.CopyN(aio.Discard, d, targetFrame); err != nil {
+			return 0, fmt.Errorf("svx: failed to seek: %w", err)
+		}
+		return targetFrame, nil
+	}
+
+	frameSize := int64(d.bitDepth / 8)
 	byteOffset := targetFrame * frameSize
 
 	_, err := d.bodyChunk.Reader.Seek(byteOffset, io.SeekStart)
 	if err != nil {
 		return 0, fmt.Errorf("svx: failed to seek: %w", err)
 	}
+	if pd, ok := d.pcmDec.(*pcm.Decoder); ok {
+		pd.Reset(d.bodyChunk.Reader)
+	}
 
-	d.dataRead = int(byteOffset)
+	d.samplesRead = targetFrame
 	return targetFrame, nil
 }
 
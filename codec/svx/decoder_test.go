@@ -0,0 +1,199 @@
+package svx_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/codec/svx"
+)
+
+// chunk returns an IFF chunk with the given ID and data, padded to an even length.
+func chunk(id string, data []byte) []byte {
+	b := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(data)))...)
+	b = append(b, data...)
+	if len(data)%2 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// vhdr returns a VHDR chunk with the given compression method and one-shot length.
+func vhdr(compression uint8, oneShotLen int) []byte {
+	var b []byte
+	b = binary.BigEndian.AppendUint32(b, uint32(oneShotLen)) // one-shot length
+	b = binary.BigEndian.AppendUint32(b, 0)                  // loop length
+	b = binary.BigEndian.AppendUint32(b, 0)                  // number of loops
+	b = binary.BigEndian.AppendUint16(b, 8363)               // sample rate
+	b = append(b, 1, compression)                            // octaves, compression
+	b = binary.BigEndian.AppendUint32(b, 0x10000)            // volume
+	return chunk("VHDR", b)
+}
+
+// form returns an 8SVX FORM containing the given chunks.
+func form(chunks ...[]byte) []byte {
+	b := []byte("8SVX")
+	for _, c := range chunks {
+		b = append(b, c...)
+	}
+	return chunk("FORM", b)
+}
+
+// build8SVX builds an 8SVX file with the given compression method and BODY chunk data.
+func build8SVX(compression uint8, body []byte) []byte {
+	return form(vhdr(compression, len(body)), chunk("BODY", body))
+}
+
+func TestDecoderFibonacci(t *testing.T) {
+	// pad byte, initial value 10, then nibbles 9 (+1), F (+21), 0 (-34), 8 (0), 7 (-1), 1 (-21)
+	body := []byte{0, 10, 0x9F, 0x08, 0x71}
+	want := []int8{11, 32, -2, -2, -3, -24}
+
+	dec, err := svx.NewDecoder(bytes.NewReader(build8SVX(svx.CompressionFibonacci, body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Len() != len(want) {
+		t.Errorf("expected Len %d, got %d", len(want), dec.Len())
+	}
+	if bps, ok := codec.Bitrate(dec); !ok || bps != 8363*4 {
+		t.Errorf("expected %d bps, got %d, %v", 8363*4, bps, ok)
+	}
+
+	wantSamples := make([]float32, len(want))
+	for i, v := range want {
+		wantSamples[i] = float32(v) / (1<<7 - 1)
+	}
+
+	// read one sample at a time to exercise nibble splitting
+	var got []float32
+	buf := make([]float32, 1)
+	for {
+		n, err := dec.ReadSamples(buf)
+		got = append(got, buf[:n]...)
+		if err == io.EOF || (err == nil && n == 0) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !slices.Equal(got, wantSamples) {
+		t.Errorf("expected %v, got %v", wantSamples, got)
+	}
+
+	// seek backwards and forwards
+	for _, target := range []int64{3, 1, 5, 0} {
+		pos, err := dec.Seek(target, io.SeekStart)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos != target {
+			t.Errorf("expected position %d, got %d", target, pos)
+		}
+		rest, err := aio.ReadAll(dec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(rest, wantSamples[target:]) {
+			t.Errorf("after seek to %d: expected %v, got %v", target, wantSamples[target:], rest)
+		}
+	}
+}
+
+func TestDecoderVHDR(t *testing.T) {
+	dec, err := svx.NewDecoder(bytes.NewReader(build8SVX(svx.CompressionNone, []byte{0, 64, 128})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := dec.(*svx.Decoder)
+	if d.NumOctaves != 1 || d.CompressionMethod != svx.CompressionNone {
+		t.Errorf("unexpected octaves %d and compression %d", d.NumOctaves, d.CompressionMethod)
+	}
+	if d.Volume != 0x10000 {
+		t.Errorf("expected volume 0x10000, got %#x", d.Volume)
+	}
+	if d.Len() != 3 || d.OneShotLen() != 3 {
+		t.Errorf("expected Len and OneShotLen 3, got %d and %d", d.Len(), d.OneShotLen())
+	}
+}
+
+// nonSeeker hides the io.Seeker implementation of the underlying reader.
+type nonSeeker struct {
+	io.Reader
+}
+
+func TestDecoderText(t *testing.T) {
+	body := []byte{0, 16, 32, 48, 64}
+	file := form(
+		vhdr(svx.CompressionNone, len(body)),
+		chunk("NAME", []byte("Bass Drum\x00")),
+		chunk("(c) ", []byte("1989")),
+		chunk("BODY", body),
+		chunk("AUTH", []byte("Unknown Artist")),
+		chunk("ANNO", []byte("sampled")), // odd length, padded
+		chunk("ANNO", []byte("from vinyl")),
+	)
+
+	check := func(t *testing.T, d *svx.Decoder) {
+		t.Helper()
+		if d.Name() != "Bass Drum" {
+			t.Errorf("expected name %q, got %q", "Bass Drum", d.Name())
+		}
+		if d.Copyright() != "1989" {
+			t.Errorf("expected copyright %q, got %q", "1989", d.Copyright())
+		}
+		if d.Author() != "Unknown Artist" {
+			t.Errorf("expected author %q, got %q", "Unknown Artist", d.Author())
+		}
+		wantAnno := []string{"sampled", "from vinyl"}
+		if !slices.Equal(d.Annotations(), wantAnno) {
+			t.Errorf("expected annotations %q, got %q", wantAnno, d.Annotations())
+		}
+		tags := d.Tags()
+		if tags["TITLE"][0] != "Bass Drum" || tags["ARTIST"][0] != "Unknown Artist" || tags["COPYRIGHT"][0] != "1989" || len(tags["COMMENT"]) != 2 {
+			t.Errorf("unexpected tags %v", tags)
+		}
+	}
+
+	t.Run("Seekable", func(t *testing.T) {
+		dec, err := svx.NewDecoder(bytes.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// chunks after BODY are available before reading any samples
+		check(t, dec.(*svx.Decoder))
+
+		samples, err := aio.ReadAll(dec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(samples) != len(body) {
+			t.Errorf("expected %d samples, got %d", len(body), len(samples))
+		}
+	})
+
+	t.Run("NonSeekable", func(t *testing.T) {
+		dec, err := svx.NewDecoder(nonSeeker{bytes.NewReader(file)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := dec.(*svx.Decoder)
+		if d.Name() != "Bass Drum" || d.Author() != "" {
+			t.Errorf("expected only chunks before BODY, got name %q and author %q", d.Name(), d.Author())
+		}
+
+		samples, err := aio.ReadAll(dec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(samples) != len(body) {
+			t.Errorf("expected %d samples, got %d", len(body), len(samples))
+		}
+		check(t, d)
+	})
+}
@@ -0,0 +1,85 @@
+package svx
+
+import (
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+)
+
+// Compression methods.
+const (
+	CompressionNone      uint8 = 0 // No compression
+	CompressionFibonacci uint8 = 1 // Fibonacci-delta encoding
+)
+
+// fibDeltaTab maps 4-bit codes to deltas.
+var fibDeltaTab = [16]int8{-34, -21, -13, -8, -5, -3, -2, -1, 0, 1, 2, 3, 5, 8, 13, 21}
+
+// fibDecoder decodes a Fibonacci-delta compressed 8SVX body.
+// The body starts with a pad byte and the initial 8-bit sample value, followed by 4-bit deltas, high nibble first.
+type fibDecoder struct {
+	r      io.Reader
+	buf    []byte
+	x      int8
+	header bool // whether the 2-byte header has been read
+	nibble int  // pending low nibble, -1 if none
+}
+
+func newFibDecoder(r io.Reader) aio.SampleReader {
+	return &fibDecoder{r: r, nibble: -1}
+}
+
+func (d *fibDecoder) delta(code int) float32 {
+	d.x += fibDeltaTab[code]
+	return float32(d.x) / (1<<7 - 1)
+}
+
+func (d *fibDecoder) ReadSamples(p []float32) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if !d.header {
+		var hdr [2]byte
+		if _, err := io.ReadFull(d.r, hdr[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		d.x = int8(hdr[1])
+		d.header = true
+	}
+
+	n := 0
+	if d.nibble >= 0 {
+		p[0] = d.delta(d.nibble)
+		d.nibble = -1
+		n++
+	}
+
+	numBytes := (len(p) - n + 1) / 2
+	if cap(d.buf) < numBytes {
+		d.buf = make([]byte, numBytes)
+	} else {
+		d.buf = d.buf[:numBytes]
+	}
+
+	nb, err := d.r.Read(d.buf)
+	if err != nil && err != io.EOF {
+		return n, err
+	}
+
+	for _, b := range d.buf[:nb] {
+		p[n] = d.delta(int(b >> 4))
+		n++
+		if n == len(p) {
+			d.nibble = int(b & 0xF)
+			break
+		}
+		p[n] = d.delta(int(b & 0xF))
+		n++
+	}
+
+	return n, err
+}
@@ -0,0 +1,408 @@
+package voc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/encoding/g711"
+	"github.com/MatusOllah/resona/encoding/pcm"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var _ codec.Decoder = (*Decoder)(nil)
+
+// Loop represents a repeated section, delimited by a repeat start (type 6) and repeat end (type 7) block.
+type Loop struct {
+	// Start and End are the loop start and end points in frames.
+	Start, End int
+
+	// Count is the repeat count, or -1 for an endless loop.
+	Count int
+}
+
+// segment represents a sound data or silence block found by the pre-scan.
+type segment struct {
+	start   int64 // start frame
+	frames  int64 // length in frames
+	offset  int64 // offset of the sample data (sound) or the next block (silence) in the file
+	size    int64 // size of the sample data in bytes
+	silence bool
+}
+
+// Decoder represents the decoder for the Creative Voice (VOC) file format.
+// It implements codec.Decoder.
+//
+// Multiple sound data blocks are presented as one continuous stream and silence blocks are rendered as zero samples.
+// All sound data blocks must have the same format.
+//
+// If the source is an [io.Seeker], the file is pre-scanned to determine the length and the loops, and seeking is supported.
+// Otherwise, the length is unknown and loops are only available once they have been read.
+type Decoder struct {
+	r  io.Reader
+	rs io.ReadSeeker
+
+	version uint16
+
+	format voiceFormat
+	ext    voiceFormat // pending extra information (type 8) for the next sound data block
+
+	lr          *io.LimitedReader // current sound data block
+	dec         aio.SampleReader
+	silenceLeft int64 // frames
+	samplesRead int64
+	eof         bool
+
+	// pre-scan state
+	scanned  bool
+	segments []segment
+	end      int64 // offset of the terminator (or end of file)
+
+	framesParsed int64 // frames in all blocks parsed so far (used for loop points)
+	loops        []Loop
+	loopStart    int // index of the open loop in loops, or -1
+}
+
+// NewDecoder creates a new [Decoder] and decodes the headers.
+func NewDecoder(r io.Reader) (codec.Decoder, error) {
+	d := &Decoder{r: r, loopStart: -1}
+
+	var hdr struct {
+		Magic      [len(magic)]byte
+		HeaderSize uint16
+		Version    uint16
+		Checksum   uint16
+	}
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("voc: failed to read header: %w", err)
+	}
+	if string(hdr.Magic[:]) != magic {
+		return nil, errors.New("voc: invalid header")
+	}
+	if hdr.HeaderSize < 26 {
+		return nil, fmt.Errorf("voc: invalid header size: %d", hdr.HeaderSize)
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(hdr.HeaderSize)-26); err != nil {
+		return nil, fmt.Errorf("voc: failed to read header: %w", err)
+	}
+	d.version = hdr.Version
+
+	if rs, ok := r.(io.ReadSeeker); ok {
+		d.rs = rs
+		if err := d.scan(); err != nil {
+			return nil, fmt.Errorf("voc: %w", err)
+		}
+	}
+
+	// read blocks until the format is known
+	d.lr = &io.LimitedReader{R: r}
+	for d.format.sampleRate == 0 {
+		if err := d.nextBlock(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("voc: missing sound data block")
+			}
+			return nil, fmt.Errorf("voc: %w", err)
+		}
+	}
+
+	switch d.format.codec {
+	case CodecPCM8, CodecPCM16:
+		d.dec = pcm.NewDecoder(d.lr, d.SampleFormat())
+	case CodecAlaw:
+		d.dec = g711.NewAlawDecoder(d.lr)
+	case CodecUlaw:
+		d.dec = g711.NewUlawDecoder(d.lr)
+	}
+
+	return d, nil
+}
+
+// setFormat sets the stream format from the first sound data block and checks that later blocks match it.
+func (d *Decoder) setFormat(f voiceFormat) error {
+	if d.format.sampleRate == 0 {
+		if err := f.validate(); err != nil {
+			return err
+		}
+		d.format = f
+		return nil
+	}
+	if f != d.format {
+		return fmt.Errorf("mixed formats are not supported (%+v, then %+v)", d.format, f)
+	}
+	return nil
+}
+
+// addLoop records a repeat start or end block at the current position.
+func (d *Decoder) addLoop(b block) {
+	switch b.typ {
+	case BlockRepeatStart:
+		d.loopStart = len(d.loops)
+		d.loops = append(d.loops, Loop{Start: int(d.framesParsed), End: int(d.framesParsed), Count: b.repeat})
+	case BlockRepeatEnd:
+		if d.loopStart >= 0 {
+			d.loops[d.loopStart].End = int(d.framesParsed)
+			d.loopStart = -1
+		}
+	}
+}
+
+// scan pre-scans all blocks, recording the sound data and silence segments and the loops,
+// and seeks back to the first block.
+func (d *Decoder) scan() error {
+	first, err := d.rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	var ext voiceFormat
+	for {
+		offset, err := d.rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+
+		b, err := readBlock(d.rs, &ext)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				d.end = offset
+				break
+			}
+			return err
+		}
+		if b.typ == BlockTerminator {
+			d.end = offset
+			break
+		}
+
+		switch b.typ {
+		case BlockSoundData, BlockSoundDataV2:
+			if err := d.setFormat(b.format); err != nil {
+				return err
+			}
+			fallthrough
+		case BlockSoundCont:
+			if d.format.sampleRate == 0 {
+				return errors.New("sound continuation block before sound data block")
+			}
+			dataStart, err := d.rs.Seek(b.dataSize, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
+			dataStart -= b.dataSize
+			frames := b.dataSize / d.format.bytesPerFrame()
+			d.segments = append(d.segments, segment{start: d.framesParsed, frames: frames, offset: dataStart, size: b.dataSize})
+			d.framesParsed += frames
+		case BlockSilence:
+			next, err := d.rs.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
+			d.segments = append(d.segments, segment{start: d.framesParsed, frames: b.silence, offset: next, silence: true})
+			d.framesParsed += b.silence
+		case BlockRepeatStart, BlockRepeatEnd:
+			d.addLoop(b)
+		}
+	}
+
+	d.scanned = true
+	d.format = voiceFormat{} // set again by the first nextBlock call
+	_, err = d.rs.Seek(first, io.SeekStart)
+	return err
+}
+
+// nextBlock reads the next block and sets up the decoder state for it.
+// It returns io.EOF at the terminator block or the end of the file.
+func (d *Decoder) nextBlock() error {
+	// skip the rest of the current sound data block
+	if d.lr.N > 0 {
+		if _, err := io.Copy(io.Discard, d.lr); err != nil {
+			return err
+		}
+	}
+
+	b, err := readBlock(d.r, &d.ext)
+	if err != nil {
+		return err
+	}
+
+	switch b.typ {
+	case BlockTerminator:
+		return io.EOF
+	case BlockSoundData, BlockSoundDataV2:
+		if err := d.setFormat(b.format); err != nil {
+			return err
+		}
+		d.lr.N = b.dataSize
+	case BlockSoundCont:
+		if d.format.sampleRate == 0 {
+			return errors.New("sound continuation block before sound data block")
+		}
+		d.lr.N = b.dataSize
+	case BlockSilence:
+		d.silenceLeft += b.silence
+	}
+
+	if !d.scanned {
+		switch b.typ {
+		case BlockSoundData, BlockSoundDataV2, BlockSoundCont:
+			d.framesParsed += b.dataSize / d.format.bytesPerFrame()
+		case BlockSilence:
+			d.framesParsed += b.silence
+		case BlockRepeatStart, BlockRepeatEnd:
+			d.addLoop(b)
+		}
+	}
+
+	return nil
+}
+
+// Version returns the file format version.
+func (d *Decoder) Version() (major, minor int) {
+	return int(d.version >> 8), int(d.version & 0xFF)
+}
+
+// Loops returns the repeated sections.
+// If the source is not an [io.Seeker], only the loops read so far are returned.
+func (d *Decoder) Loops() []Loop {
+	return d.loops
+}
+
+// Format returns the audio stream format.
+func (d *Decoder) Format() afmt.Format {
+	return afmt.Format{
+		SampleRate:  freq.Frequency(d.format.sampleRate) * freq.Hertz,
+		NumChannels: d.format.numChannels,
+	}
+}
+
+// SampleFormat returns the sample format.
+func (d *Decoder) SampleFormat() afmt.SampleFormat {
+	switch d.format.codec {
+	case CodecPCM16:
+		return afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}
+	default:
+		return afmt.SampleFormat{BitDepth: 8, Encoding: afmt.SampleEncodingUint}
+	}
+}
+
+// ReadSamples reads float32 samples into p.
+// It returns the number of samples read and/or an error.
+func (d *Decoder) ReadSamples(p []float32) (n int, err error) {
+	numChans := d.format.numChannels
+
+	p = p[:len(p)/numChans*numChans] // whole frames only
+	for n < len(p) && !d.eof {
+		switch {
+		case d.silenceLeft > 0:
+			m := int(min(int64(len(p)-n)/int64(numChans), d.silenceLeft)) * numChans
+			clear(p[n : n+m])
+			d.silenceLeft -= int64(m / numChans)
+			n += m
+		case d.lr.N >= d.format.bytesPerFrame():
+			m := int(min(int64(len(p)-n), d.lr.N/d.format.bytesPerFrame()*int64(numChans)))
+			m, err = d.dec.ReadSamples(p[n : n+m])
+			n += m
+			if errors.Is(err, io.EOF) && d.lr.N > 0 {
+				err = io.ErrUnexpectedEOF // truncated file
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				d.samplesRead += int64(n)
+				return n, err
+			}
+		default:
+			if err := d.nextBlock(); err != nil {
+				if !errors.Is(err, io.EOF) {
+					d.samplesRead += int64(n)
+					return n, fmt.Errorf("voc: %w", err)
+				}
+				d.eof = true
+			}
+		}
+	}
+	d.samplesRead += int64(n)
+
+	if n == 0 && d.eof {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// Len returns the total number of frames.
+// It returns 0 if the source is not an [io.Seeker], as the length is unknown.
+func (d *Decoder) Len() int {
+	if !d.scanned {
+		return 0
+	}
+	return int(d.framesParsed)
+}
+
+// Seek seeks to the specified frame.
+// It returns the new offset relative to the start and/or an error.
+// It will return an error if the source is not an [io.Seeker].
+func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
+	numChans := int64(d.format.numChannels)
+
+	// Special case
+	if offset == 0 && whence == io.SeekCurrent {
+		return d.samplesRead / numChans, nil
+	}
+
+	if !d.scanned {
+		return 0, errors.New("voc: resource does not support seeking")
+	}
+
+	totalFrames := int64(d.Len())
+
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = d.samplesRead/numChans + offset
+	case io.SeekEnd:
+		target = totalFrames + offset
+	default:
+		return 0, errors.New("voc: invalid seek whence")
+	}
+
+	if target < 0 || target > totalFrames {
+		return 0, errors.New("voc: seek out of bounds")
+	}
+
+	// find the segment containing the target, or seek to the terminator
+	pos, lrN, silenceLeft := d.end, int64(0), int64(0)
+	for _, seg := range d.segments {
+		if target >= seg.start+seg.frames {
+			continue
+		}
+		if seg.silence {
+			pos = seg.offset
+			silenceLeft = seg.start + seg.frames - target
+		} else {
+			pos = seg.offset + (target-seg.start)*d.format.bytesPerFrame()
+			lrN = seg.offset + seg.size - pos
+		}
+		break
+	}
+
+	if _, err := d.rs.Seek(pos, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("voc: failed to seek: %w", err)
+	}
+	d.lr.N = lrN
+	if pd, ok := d.dec.(*pcm.Decoder); ok {
+		pd.Reset(d.lr)
+	}
+	d.silenceLeft = silenceLeft
+	d.ext = voiceFormat{}
+	d.eof = false
+	d.samplesRead = target * numChans
+	return target, nil
+}
+
+func init() {
+	codec.RegisterFormat("voc", magic, NewDecoder)
+}
@@ -0,0 +1,253 @@
+package voc_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/codec/voc"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// The fixtures are synthesized block by block, as no VOC files are checked into the repository.
+
+// file returns a VOC file (version 1.10) containing the given blocks, followed by a terminator.
+func file(blocks ...[]byte) []byte {
+	b := []byte("Creative Voice File\x1A")
+	b = binary.LittleEndian.AppendUint16(b, 26)
+	b = binary.LittleEndian.AppendUint16(b, 0x010A)
+	b = binary.LittleEndian.AppendUint16(b, 0x1129) // ^version + 0x1234
+	for _, blk := range blocks {
+		b = append(b, blk...)
+	}
+	return append(b, voc.BlockTerminator)
+}
+
+// blk returns a block with the given type and data.
+func blk(typ byte, data ...[]byte) []byte {
+	body := bytes.Join(data, nil)
+	return append([]byte{typ, byte(len(body)), byte(len(body) >> 8), byte(len(body) >> 16)}, body...)
+}
+
+// sound returns a sound data block (type 1) with 8-bit PCM data.
+func sound(divisor byte, data []byte) []byte {
+	return blk(voc.BlockSoundData, []byte{divisor, voc.CodecPCM8}, data)
+}
+
+// silence returns a silence block (type 3) with the given number of frames.
+func silence(frames int) []byte {
+	return blk(voc.BlockSilence, binary.LittleEndian.AppendUint16(nil, uint16(frames-1)), []byte{0x9C})
+}
+
+// repeat returns a repeat start block (type 6).
+func repeat(count uint16) []byte {
+	return blk(voc.BlockRepeatStart, binary.LittleEndian.AppendUint16(nil, count))
+}
+
+// nonSeeker hides the io.Seeker implementation of the underlying reader.
+type nonSeeker struct {
+	io.Reader
+}
+
+// full returns n bytes of 8-bit PCM at full scale.
+func full(n int) []byte {
+	return bytes.Repeat([]byte{0xFF}, n)
+}
+
+// fixture has 1000 frames of sound data, 500 frames of silence, and 500 frames of sound continuation
+// at 10 kHz (divisor 0x9C), i.e. 0.2 seconds.
+var fixture = file(
+	sound(0x9C, full(1000)),
+	silence(500),
+	blk(voc.BlockSoundCont, full(500)),
+)
+
+// want returns the samples of fixture from the specified frame.
+func want(from int) []float32 {
+	p := make([]float32, 2000)
+	for i := range p {
+		if i < 1000 || i >= 1500 {
+			p[i] = 1
+		}
+	}
+	return p[from:]
+}
+
+func TestDecoderDuration(t *testing.T) {
+	dec, name, err := codec.Decode(bytes.NewReader(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "voc" {
+		t.Errorf("expected format name %q, got %q", "voc", name)
+	}
+	if f := dec.Format(); f.SampleRate != 10*freq.KiloHertz || f.NumChannels != 1 {
+		t.Errorf("unexpected format %v", f)
+	}
+	if dur := f2d(dec.Len(), 10000); dur != 200*time.Millisecond {
+		t.Errorf("expected duration 200ms, got %v", dur)
+	}
+
+	p, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p, want(0)) {
+		t.Errorf("unexpected samples")
+	}
+}
+
+// f2d converts a number of frames to a duration.
+func f2d(frames, sampleRate int) time.Duration {
+	return time.Duration(frames) * time.Second / time.Duration(sampleRate)
+}
+
+func TestDecoderNonSeekable(t *testing.T) {
+	dec, err := voc.NewDecoder(nonSeeker{bytes.NewReader(fixture)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Len() != 0 {
+		t.Errorf("expected unknown length (0), got %d", dec.Len())
+	}
+	if _, err := dec.Seek(0, io.SeekStart); err == nil {
+		t.Error("expected error when seeking")
+	}
+
+	p, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p, want(0)) {
+		t.Errorf("unexpected samples")
+	}
+}
+
+func TestDecoderSeek(t *testing.T) {
+	dec, err := voc.NewDecoder(bytes.NewReader(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []int64{1200, 500, 1700, 2000, 999, 0} {
+		pos, err := dec.Seek(target, io.SeekStart)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos != target {
+			t.Errorf("expected position %d, got %d", target, pos)
+		}
+		p, err := aio.ReadAll(dec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(p, want(int(target))) {
+			t.Errorf("wrong samples after seeking to frame %d", target)
+		}
+	}
+}
+
+func TestDecoderSoundDataV2(t *testing.T) {
+	var hdr []byte
+	hdr = binary.LittleEndian.AppendUint32(hdr, 8000)
+	hdr = append(hdr, 16, 2)
+	hdr = binary.LittleEndian.AppendUint16(hdr, voc.CodecPCM16)
+	hdr = append(hdr, 0, 0, 0, 0)
+
+	var data []byte
+	for _, v := range []int16{1<<15 - 1, -(1<<15 - 1), 0, 1<<15 - 1} {
+		data = binary.LittleEndian.AppendUint16(data, uint16(v))
+	}
+
+	dec, err := voc.NewDecoder(bytes.NewReader(file(blk(voc.BlockSoundDataV2, hdr, data))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := dec.Format(); f.SampleRate != 8*freq.KiloHertz || f.NumChannels != 2 {
+		t.Errorf("unexpected format %v", f)
+	}
+	if dec.Len() != 2 {
+		t.Errorf("expected Len 2, got %d", dec.Len())
+	}
+
+	p, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantP := []float32{1, -1, 0, 1}; !slices.Equal(p, wantP) {
+		t.Errorf("expected %v, got %v", wantP, p)
+	}
+}
+
+func TestDecoderExtraInfo(t *testing.T) {
+	// time constant for 8 kHz stereo: 65536 - 256000000/(2*8000)
+	ext := blk(voc.BlockExtraInfo, binary.LittleEndian.AppendUint16(nil, 65536-16000), []byte{voc.CodecPCM8, 1})
+
+	dec, err := voc.NewDecoder(bytes.NewReader(file(ext, sound(0, full(8)))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := dec.Format(); f.SampleRate != 8*freq.KiloHertz || f.NumChannels != 2 {
+		t.Errorf("unexpected format %v", f)
+	}
+	if dec.Len() != 4 {
+		t.Errorf("expected Len 4, got %d", dec.Len())
+	}
+}
+
+func TestDecoderLoops(t *testing.T) {
+	b := file(
+		silence(100),
+		repeat(2),
+		sound(0x9C, full(200)),
+		blk(voc.BlockRepeatEnd),
+		repeat(0xFFFF),
+		blk(voc.BlockSoundCont, full(50)),
+		blk(voc.BlockRepeatEnd),
+	)
+	wantLoops := []voc.Loop{{Start: 100, End: 300, Count: 2}, {Start: 300, End: 350, Count: -1}}
+
+	t.Run("Seekable", func(t *testing.T) {
+		dec, err := voc.NewDecoder(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loops := dec.(*voc.Decoder).Loops(); !slices.Equal(loops, wantLoops) {
+			t.Errorf("expected loops %v, got %v", wantLoops, loops)
+		}
+	})
+
+	t.Run("NonSeekable", func(t *testing.T) {
+		dec, err := voc.NewDecoder(nonSeeker{bytes.NewReader(b)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := aio.ReadAll(dec); err != nil {
+			t.Fatal(err)
+		}
+		if loops := dec.(*voc.Decoder).Loops(); !slices.Equal(loops, wantLoops) {
+			t.Errorf("expected loops %v, got %v", wantLoops, loops)
+		}
+	})
+}
+
+func TestDecoderMixedFormats(t *testing.T) {
+	b := file(sound(0x9C, full(10)), sound(0xA5, full(10)))
+
+	if _, err := voc.NewDecoder(bytes.NewReader(b)); err == nil {
+		t.Error("expected error for mixed formats")
+	}
+
+	dec, err := voc.NewDecoder(nonSeeker{bytes.NewReader(b)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := aio.ReadAll(dec); err == nil {
+		t.Error("expected error for mixed formats")
+	}
+}
@@ -0,0 +1,7 @@
+// Package voc implements decoding of Creative Voice (VOC) files.
+//
+// Supported codecs are 8-bit unsigned PCM, 16-bit signed PCM, A-law, and μ-law.
+// Creative ADPCM is not supported.
+package voc
+
+// https://moddingwiki.shikadi.net/wiki/VOC_Format
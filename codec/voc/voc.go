@@ -0,0 +1,201 @@
+package voc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const magic = "Creative Voice File\x1A"
+
+// Block types.
+const (
+	BlockTerminator  = 0
+	BlockSoundData   = 1
+	BlockSoundCont   = 2
+	BlockSilence     = 3
+	BlockMarker      = 4
+	BlockText        = 5
+	BlockRepeatStart = 6
+	BlockRepeatEnd   = 7
+	BlockExtraInfo   = 8
+	BlockSoundDataV2 = 9
+)
+
+// Codecs.
+const (
+	CodecPCM8  = 0x0000 // 8-bit unsigned PCM
+	CodecPCM16 = 0x0004 // 16-bit signed PCM
+	CodecAlaw  = 0x0006 // G.711 A-law
+	CodecUlaw  = 0x0007 // G.711 μ-law
+)
+
+// voiceFormat is the format of a sound data block.
+type voiceFormat struct {
+	sampleRate  int
+	bitDepth    int
+	numChannels int
+	codec       uint16
+}
+
+// bytesPerFrame returns the size of a frame in bytes.
+func (f voiceFormat) bytesPerFrame() int64 {
+	return int64(f.bitDepth/8) * int64(f.numChannels)
+}
+
+// validate checks whether the format is supported.
+func (f voiceFormat) validate() error {
+	switch {
+	case f.codec == CodecPCM8 && f.bitDepth == 8,
+		f.codec == CodecPCM16 && f.bitDepth == 16,
+		(f.codec == CodecAlaw || f.codec == CodecUlaw) && f.bitDepth == 8:
+	default:
+		return fmt.Errorf("unsupported codec %#x with bit depth %d", f.codec, f.bitDepth)
+	}
+	if f.numChannels < 1 || f.numChannels > 2 {
+		return fmt.Errorf("unsupported number of channels: %d", f.numChannels)
+	}
+	if f.sampleRate <= 0 {
+		return fmt.Errorf("invalid sample rate: %d", f.sampleRate)
+	}
+	return nil
+}
+
+// block holds the parsed header of a block.
+type block struct {
+	typ byte
+
+	// format is the format of a sound data block (types 1 and 9).
+	format voiceFormat
+
+	// dataSize is the size of the sample data in bytes (types 1, 2, and 9).
+	dataSize int64
+
+	// silence is the length of a silence block in frames (type 3).
+	silence int64
+
+	// repeat is the repeat count of a repeat start block (type 6).
+	repeat int
+}
+
+// readBlock reads the next block header from r.
+//
+// For sound data blocks, r is left at the start of the sample data.
+// All other blocks are read entirely.
+// Extra information blocks (type 8) are stored in ext and applied to the following sound data block.
+func readBlock(r io.Reader, ext *voiceFormat) (b block, err error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:1]); err != nil {
+		return block{}, err
+	}
+	b.typ = hdr[0]
+	if b.typ == BlockTerminator {
+		return b, nil
+	}
+
+	if _, err := io.ReadFull(r, hdr[1:]); err != nil {
+		return block{}, noEOF(err)
+	}
+	size := int64(hdr[1]) | int64(hdr[2])<<8 | int64(hdr[3])<<16
+
+	// headerSize is the number of header bytes within the block data
+	var headerSize int64
+	switch b.typ {
+	case BlockSoundData:
+		headerSize = 2
+		var buf [2]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return block{}, noEOF(err)
+		}
+		b.format = voiceFormat{
+			sampleRate:  1000000 / (256 - int(buf[0])),
+			bitDepth:    8,
+			numChannels: 1,
+			codec:       uint16(buf[1]),
+		}
+		if ext.sampleRate != 0 {
+			// extra information overrides the sample rate, codec, and number of channels
+			b.format = *ext
+			*ext = voiceFormat{}
+		}
+		if b.format.codec == CodecPCM16 {
+			b.format.bitDepth = 16
+		}
+	case BlockSoundDataV2:
+		headerSize = 12
+		var buf struct {
+			SampleRate  uint32
+			BitDepth    uint8
+			NumChannels uint8
+			Codec       uint16
+			_           uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &buf); err != nil {
+			return block{}, noEOF(err)
+		}
+		b.format = voiceFormat{
+			sampleRate:  int(buf.SampleRate),
+			bitDepth:    int(buf.BitDepth),
+			numChannels: int(buf.NumChannels),
+			codec:       buf.Codec,
+		}
+	case BlockSilence:
+		headerSize = 3
+		var buf [3]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return block{}, noEOF(err)
+		}
+		b.silence = int64(binary.LittleEndian.Uint16(buf[:])) + 1
+	case BlockRepeatStart:
+		headerSize = 2
+		var count uint16
+		if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+			return block{}, noEOF(err)
+		}
+		b.repeat = int(count)
+		if count == 0xFFFF {
+			b.repeat = -1 // endless
+		}
+	case BlockExtraInfo:
+		headerSize = 4
+		var buf struct {
+			TimeConstant uint16
+			Codec        uint8
+			Mode         uint8
+		}
+		if err := binary.Read(r, binary.LittleEndian, &buf); err != nil {
+			return block{}, noEOF(err)
+		}
+		numChannels := int(buf.Mode) + 1
+		*ext = voiceFormat{
+			sampleRate:  256000000 / (65536 - int(buf.TimeConstant)) / numChannels,
+			bitDepth:    8,
+			numChannels: numChannels,
+			codec:       uint16(buf.Codec),
+		}
+	}
+	if size < headerSize {
+		return block{}, fmt.Errorf("block type %d too short (%d bytes)", b.typ, size)
+	}
+
+	switch b.typ {
+	case BlockSoundData, BlockSoundCont, BlockSoundDataV2:
+		b.dataSize = size - headerSize
+	default:
+		// skip the rest of the block (markers, text, and unknown blocks)
+		if _, err := io.CopyN(io.Discard, r, size-headerSize); err != nil {
+			return block{}, noEOF(err)
+		}
+	}
+
+	return b, nil
+}
+
+// noEOF converts io.EOF to io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
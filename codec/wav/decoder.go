@@ -37,7 +37,6 @@ type Decoder struct {
 
 	numChannels   uint16
 	sampleRate    uint32
-	bytesPerSec   uint32
 	bytesPerBlock uint16
 	bitsPerSample uint16
 
@@ -122,7 +121,9 @@ func (d *Decoder) parseFmt() error {
 	if err := binary.Read(chunk.Reader, binary.LittleEndian, &d.sampleRate); err != nil {
 		return fmt.Errorf("failed to read sample rate: %w", err)
 	}
-	if err := binary.Read(chunk.Reader, binary.LittleEndian, &d.bytesPerSec); err != nil {
+	// the byte rate is redundant, and computed from the rest of the format when needed
+	var bytesPerSec uint32
+	if err := binary.Read(chunk.Reader, binary.LittleEndian, &bytesPerSec); err != nil {
 		return fmt.Errorf("failed to read bytes pre second: %w", err)
 	}
 	if err := binary.Read(chunk.Reader, binary.LittleEndian, &d.bytesPerBlock); err != nil {
@@ -195,13 +196,18 @@ func (d *Decoder) ensureAudioDecoder() error {
 	return nil
 }
 
-// Bitrate returns the bitrate of the audio stream in bytes per second.
+// Bitrate returns the bitrate of the audio stream in bits per second.
+// For PCM, it counts the samples as they are stored, including the padding of e.g. 12-bit samples to 2 bytes.
 func (d *Decoder) Bitrate() int {
 	if bitrater, ok := d.dec.(codec.Bitrater); ok {
 		return bitrater.Bitrate()
 	}
 
-	return int(d.bytesPerSec) * 8
+	sampleFmt := d.SampleFormat()
+	if sampleFmt.BitDepth < 8 {
+		return d.Format().BitRate(sampleFmt) // packed, such as 1-bit DFPWM
+	}
+	return d.Format().ByteRate(sampleFmt) * 8
 }
 
 // Format returns the audio stream format.
@@ -291,7 +297,7 @@ func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
 	case io.SeekStart:
 		targetFrame = offset
 	case io.SeekCurrent:
-		targetFrame = int64(d.dataRead) + offset
+		targetFrame = int64(d.dataRead)/int64(d.numChannels) + offset
 	case io.SeekEnd:
 		targetFrame = totalFrames + offset
 	default:
@@ -302,14 +308,21 @@ func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
 		return 0, fmt.Errorf("wav: seek out of bounds")
 	}
 
-	byteOffset := targetFrame * frameSize
-
-	_, err := d.dataChunk.Reader.Seek(byteOffset, io.SeekStart)
+	var err error
+	if s, ok := d.dec.(io.Seeker); ok {
+		// G.711 decoders seek by themselves, one byte per sample
+		_, err = s.Seek(targetFrame*int64(d.numChannels), io.SeekStart)
+	} else {
+		_, err = d.dataChunk.Reader.Seek(targetFrame*frameSize, io.SeekStart)
+		if pd, ok := d.dec.(*pcm.Decoder); ok {
+			pd.Reset(d.dataChunk.Reader)
+		}
+	}
 	if err != nil {
 		return 0, fmt.Errorf("wav: failed to seek: %w", err)
 	}
 
-	d.dataRead = int(byteOffset) / int(frameSize)
+	d.dataRead = int(targetFrame) * int(d.numChannels)
 	return targetFrame, nil
 }
 
@@ -99,8 +99,7 @@ func (e *Encoder) writeHeader() error {
 	}
 
 	// Write bytes per second
-	byterate := int32(int(e.format.SampleRate.Hertz()) * e.format.NumChannels * e.sampleFmt.BytesPerSample())
-	if err := binary.Write(e.w, binary.LittleEndian, byterate); err != nil {
+	if err := binary.Write(e.w, binary.LittleEndian, int32(e.format.ByteRate(e.sampleFmt))); err != nil {
 		return err
 	}
 
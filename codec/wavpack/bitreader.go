@@ -0,0 +1,79 @@
+package wavpack
+
+// bitReader reads bits LSB-first from a byte slice.
+// Reading past the end returns zero bits and sets overrun.
+type bitReader struct {
+	b       []byte
+	pos     int // bit position
+	overrun bool
+}
+
+// bit reads a single bit.
+func (br *bitReader) bit() uint32 {
+	i := br.pos >> 3
+	if i >= len(br.b) {
+		br.overrun = true
+		return 0
+	}
+	v := uint32(br.b[i]>>(br.pos&7)) & 1
+	br.pos++
+	return v
+}
+
+// bits reads n bits (n <= 32), with the first bit read as the least significant bit.
+func (br *bitReader) bits(n int) uint32 {
+	var v uint32
+	for i := range n {
+		v |= br.bit() << i
+	}
+	return v
+}
+
+// ones counts the 1 bits up to the next 0 bit (which is consumed) or until limit 1 bits have been read.
+func (br *bitReader) ones(limit int) int {
+	n := 0
+	for n < limit && br.bit() == 1 {
+		n++
+	}
+	return n
+}
+
+// eliasGamma reads a value encoded as a unary bit count followed by the bits below the implicit top bit.
+// It returns false if the value is too large.
+func (br *bitReader) eliasGamma() (uint32, bool) {
+	cbits := br.ones(33)
+	if cbits == 33 {
+		return 0, false
+	}
+	if cbits < 2 {
+		return uint32(cbits), true
+	}
+	return br.bits(cbits-1) | 1<<(cbits-1), true
+}
+
+// code reads a value in the range [0, maxCode] using the minimum number of bits.
+func (br *bitReader) code(maxCode uint32) uint32 {
+	if maxCode < 2 {
+		if maxCode == 0 {
+			return 0
+		}
+		return br.bit()
+	}
+
+	n := bitLen(maxCode)
+	extras := uint32(1)<<n - maxCode - 1
+	v := br.bits(n - 1)
+	if v >= extras {
+		v = v<<1 - extras + br.bit()
+	}
+	return v
+}
+
+// bitLen returns the number of bits required to represent v.
+func bitLen(v uint32) int {
+	n := 0
+	for ; v != 0; v >>= 1 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,413 @@
+package wavpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// maxTerms is the maximum number of decorrelation passes.
+const maxTerms = 16
+
+// decorrPass is the state of a decorrelation pass.
+type decorrPass struct {
+	term     int32
+	delta    int32
+	weightA  int32
+	weightB  int32
+	samplesA [8]int32
+	samplesB [8]int32
+}
+
+// applyWeight applies a decorrelation weight (scaled by 1024) to a sample.
+func applyWeight(weight, sample int32) int32 {
+	return int32((int64(weight)*int64(sample) + 512) >> 10)
+}
+
+// updateWeight adapts a weight towards predicting result from source.
+func updateWeight(weight *int32, delta, source, result int32) {
+	if source != 0 && result != 0 {
+		if (source ^ result) < 0 {
+			*weight -= delta
+		} else {
+			*weight += delta
+		}
+	}
+}
+
+// updateWeightClip is like updateWeight, but clips the weight to [-1024, 1024].
+func updateWeightClip(weight *int32, delta, source, result int32) {
+	if source != 0 && result != 0 {
+		if (source ^ result) < 0 {
+			*weight = max(*weight-delta, -1024)
+		} else {
+			*weight = min(*weight+delta, 1024)
+		}
+	}
+}
+
+// restoreWeight restores a weight stored in 8 bits.
+func restoreWeight(v int8) int32 {
+	w := int32(v) << 3
+	if w > 0 {
+		w += (w + 64) >> 7
+	}
+	return w
+}
+
+// block holds the decoded audio of a block.
+type block struct {
+	numChannels int
+	sampleRate  int
+	samples     []int32 // interleaved
+}
+
+// decodeBlock decodes the audio of a block from its header and data (the metadata sub-blocks).
+func decodeBlock(hdr *blockHeader, data []byte) (*block, error) {
+	flags := hdr.Flags
+	switch {
+	case flags&flagHybrid != 0:
+		return nil, errors.New("hybrid (lossy) mode is not supported")
+	case flags&flagDSD != 0:
+		return nil, errors.New("DSD audio is not supported")
+	case flags&flagFloat != 0:
+		return nil, errors.New("floating point audio is not supported")
+	}
+
+	mono := flags&flagMono != 0 || flags&flagFalseStereo != 0 // mono data
+	b := &block{numChannels: 2}
+	if flags&flagMono != 0 {
+		b.numChannels = 1
+	}
+	if i := (flags & srateMask) >> srateLSB; i < 15 {
+		b.sampleRate = sampleRates[i]
+	}
+
+	var (
+		passes    []decorrPass
+		w         words
+		haveBits  bool
+		int32Info [4]byte
+	)
+
+	// parse metadata sub-blocks
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, errors.New("truncated metadata sub-block")
+		}
+		id := data[0]
+		size := int(data[1]) * 2
+		data = data[2:]
+		if id&idLarge != 0 {
+			if len(data) < 2 {
+				return nil, errors.New("truncated metadata sub-block")
+			}
+			size += int(data[0])<<9 | int(data[1])<<17
+			data = data[2:]
+		}
+		if size > len(data) {
+			return nil, errors.New("truncated metadata sub-block")
+		}
+		payload := data[:size]
+		data = data[size:]
+		if id&idOddSize != 0 && size > 0 {
+			payload = payload[:size-1]
+		}
+
+		switch id & idMask {
+		case idDecorrTerms:
+			if len(payload) > maxTerms {
+				return nil, fmt.Errorf("too many decorrelation terms: %d", len(payload))
+			}
+			passes = make([]decorrPass, len(payload))
+			// the terms are stored in reverse order
+			for i, v := range payload {
+				dp := &passes[len(passes)-1-i]
+				dp.term = int32(v&0x1f) - 5
+				dp.delta = int32(v>>5) & 7
+				switch {
+				case dp.term >= 1 && dp.term <= 8, dp.term == 17, dp.term == 18:
+				case dp.term >= -3 && dp.term <= -1:
+					if mono {
+						return nil, fmt.Errorf("invalid decorrelation term %d for mono audio", dp.term)
+					}
+				default:
+					return nil, fmt.Errorf("invalid decorrelation term %d", dp.term)
+				}
+			}
+		case idDecorrWeights:
+			n := len(payload)
+			if !mono {
+				n /= 2
+			}
+			if n > len(passes) {
+				return nil, errors.New("too many decorrelation weights")
+			}
+			for i := range n {
+				dp := &passes[len(passes)-1-i]
+				if mono {
+					dp.weightA = restoreWeight(int8(payload[i]))
+				} else {
+					dp.weightA = restoreWeight(int8(payload[2*i]))
+					dp.weightB = restoreWeight(int8(payload[2*i+1]))
+				}
+			}
+		case idDecorrSamples:
+			if err := readDecorrSamples(passes, payload, mono); err != nil {
+				return nil, err
+			}
+		case idEntropyVars:
+			numChans := 2
+			if mono {
+				numChans = 1
+			}
+			if len(payload) != 6*numChans {
+				return nil, errors.New("invalid entropy variables")
+			}
+			for ch := range numChans {
+				for i := range 3 {
+					w.median[ch][i] = uint32(exp2(int16(binary.LittleEndian.Uint16(payload[(ch*3+i)*2:]))))
+				}
+			}
+		case idInt32Info:
+			if len(payload) != 4 {
+				return nil, errors.New("invalid int32 info")
+			}
+			copy(int32Info[:], payload)
+		case idWVBitstream:
+			w.br = bitReader{b: payload}
+			haveBits = true
+		case idSampleRate:
+			if len(payload) >= 3 {
+				b.sampleRate = int(payload[0]) | int(payload[1])<<8 | int(payload[2])<<16
+			}
+		case idChannelInfo:
+			// channel masks are not exposed
+		default:
+			if id&idOptionalData == 0 {
+				return nil, fmt.Errorf("unknown metadata sub-block %#x", id)
+			}
+		}
+	}
+
+	if hdr.BlockSamples == 0 {
+		return b, nil
+	}
+	if !haveBits {
+		return nil, errors.New("missing bitstream")
+	}
+
+	// int32 info: sent bits, zeros, ones, dups
+	if int32Info[0] != 0 {
+		return nil, errors.New("extended 32-bit integer precision is not supported")
+	}
+	var and, or, shift int32
+	switch {
+	case int32Info[1] != 0:
+		shift = int32(int32Info[1])
+	case int32Info[2] != 0:
+		and, or, shift = 1, 1, int32(int32Info[2])
+	case int32Info[3] != 0:
+		and, shift = 1, int32(int32Info[3])
+	}
+	postShift := int32(flags&shiftMask) >> shiftLSB
+	if shift+postShift > 31 {
+		return nil, fmt.Errorf("invalid shift: %d", shift+postShift)
+	}
+
+	n := int(hdr.BlockSamples)
+	b.samples = make([]int32, n*b.numChannels)
+
+	crc := uint32(0xFFFFFFFF)
+	var err error
+	if mono {
+		crc, err = unpackMono(&w, passes, b.samples[:n])
+	} else {
+		crc, err = unpackStereo(&w, passes, b.samples, flags&flagJointStereo != 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if crc != hdr.CRC {
+		return nil, errors.New("CRC mismatch")
+	}
+
+	if and != 0 || shift != 0 || postShift != 0 {
+		dataLen := n * b.numChannels
+		if mono {
+			dataLen = n
+		}
+		for i, s := range b.samples[:dataLen] {
+			bit := (s & and) | or
+			b.samples[i] = ((s+bit)<<shift - bit) << postShift
+		}
+	}
+
+	if mono && b.numChannels == 2 {
+		// false stereo: both channels are identical
+		for i := n - 1; i >= 0; i-- {
+			b.samples[2*i], b.samples[2*i+1] = b.samples[i], b.samples[i]
+		}
+	}
+
+	return b, nil
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// readDecorrSamples restores the decorrelation samples.
+func readDecorrSamples(passes []decorrPass, p []byte, mono bool) error {
+	next := func() int32 {
+		v := exp2(int16(binary.LittleEndian.Uint16(p)))
+		p = p[2:]
+		return v
+	}
+
+	for i := len(passes) - 1; i >= 0 && len(p) > 0; i-- {
+		dp := &passes[i]
+		switch {
+		case dp.term > 8:
+			if len(p) < 4*(2-btoi(mono)) {
+				return errors.New("invalid decorrelation samples")
+			}
+			dp.samplesA[0], dp.samplesA[1] = next(), next()
+			if !mono {
+				dp.samplesB[0], dp.samplesB[1] = next(), next()
+			}
+		case dp.term < 0:
+			if len(p) < 4 {
+				return errors.New("invalid decorrelation samples")
+			}
+			dp.samplesA[0], dp.samplesB[0] = next(), next()
+		default:
+			if len(p) < int(dp.term)*2*(2-btoi(mono)) {
+				return errors.New("invalid decorrelation samples")
+			}
+			for j := range dp.term {
+				dp.samplesA[j] = next()
+				if !mono {
+					dp.samplesB[j] = next()
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// unpackMono decodes mono samples into p and returns the CRC.
+func unpackMono(w *words, passes []decorrPass, p []int32) (uint32, error) {
+	crc := uint32(0xFFFFFFFF)
+	pos := 0
+	for i := range p {
+		s, err := w.value(0)
+		if err != nil {
+			return 0, err
+		}
+
+		for k := range passes {
+			dp := &passes[k]
+			var a int32
+			j := 0
+			if dp.term > 8 {
+				if dp.term&1 == 1 {
+					a = 2*dp.samplesA[0] - dp.samplesA[1]
+				} else {
+					a = (3*dp.samplesA[0] - dp.samplesA[1]) >> 1
+				}
+				dp.samplesA[1] = dp.samplesA[0]
+			} else {
+				a = dp.samplesA[pos]
+				j = (pos + int(dp.term)) & 7
+			}
+			out := s + applyWeight(dp.weightA, a)
+			updateWeight(&dp.weightA, dp.delta, a, s)
+			dp.samplesA[j] = out
+			s = out
+		}
+
+		pos = (pos + 1) & 7
+		crc = crc*3 + uint32(s)
+		p[i] = s
+	}
+	return crc, nil
+}
+
+// unpackStereo decodes interleaved stereo samples into p and returns the CRC.
+func unpackStereo(w *words, passes []decorrPass, p []int32, joint bool) (uint32, error) {
+	crc := uint32(0xFFFFFFFF)
+	pos := 0
+	for i := 0; i < len(p); i += 2 {
+		l, err := w.value(0)
+		if err != nil {
+			return 0, err
+		}
+		r, err := w.value(1)
+		if err != nil {
+			return 0, err
+		}
+
+		for k := range passes {
+			dp := &passes[k]
+			switch {
+			case dp.term > 0:
+				var a, b int32
+				j := 0
+				if dp.term > 8 {
+					if dp.term&1 == 1 {
+						a = 2*dp.samplesA[0] - dp.samplesA[1]
+						b = 2*dp.samplesB[0] - dp.samplesB[1]
+					} else {
+						a = (3*dp.samplesA[0] - dp.samplesA[1]) >> 1
+						b = (3*dp.samplesB[0] - dp.samplesB[1]) >> 1
+					}
+					dp.samplesA[1] = dp.samplesA[0]
+					dp.samplesB[1] = dp.samplesB[0]
+				} else {
+					a = dp.samplesA[pos]
+					b = dp.samplesB[pos]
+					j = (pos + int(dp.term)) & 7
+				}
+				l2 := l + applyWeight(dp.weightA, a)
+				r2 := r + applyWeight(dp.weightB, b)
+				updateWeight(&dp.weightA, dp.delta, a, l)
+				updateWeight(&dp.weightB, dp.delta, b, r)
+				dp.samplesA[j], l = l2, l2
+				dp.samplesB[j], r = r2, r2
+			case dp.term == -1:
+				l2 := l + applyWeight(dp.weightA, dp.samplesA[0])
+				updateWeightClip(&dp.weightA, dp.delta, dp.samplesA[0], l)
+				l = l2
+				r2 := r + applyWeight(dp.weightB, l2)
+				updateWeightClip(&dp.weightB, dp.delta, l2, r)
+				r = r2
+				dp.samplesA[0] = r
+			default: // -2, -3
+				r2 := r + applyWeight(dp.weightB, dp.samplesB[0])
+				updateWeightClip(&dp.weightB, dp.delta, dp.samplesB[0], r)
+				r = r2
+				if dp.term == -3 {
+					r2 = dp.samplesA[0]
+					dp.samplesA[0] = r
+				}
+				l2 := l + applyWeight(dp.weightA, r2)
+				updateWeightClip(&dp.weightA, dp.delta, r2, l)
+				l = l2
+				dp.samplesB[0] = l
+			}
+		}
+
+		pos = (pos + 1) & 7
+		if joint {
+			r -= l >> 1
+			l += r
+		}
+		crc = (crc*3+uint32(l))*3 + uint32(r)
+		p[i], p[i+1] = l, r
+	}
+	return crc, nil
+}
@@ -0,0 +1,337 @@
+package wavpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var _ codec.Decoder = (*Decoder)(nil)
+
+// Decoder represents the decoder for the WavPack file format.
+// It implements codec.Decoder.
+type Decoder struct {
+	r io.Reader
+
+	numChannels int
+	sampleRate  int
+	bitDepth    int
+	scale       float32
+
+	firstIndex   int64 // block index of the first block
+	totalSamples int64 // or -1 if unknown
+	dataStart    int64 // offset of the first block, if r is an io.Seeker
+	dataEnd      int64 // offset of the end of the stream, if r is an io.Seeker
+
+	buf         []int32 // decoded samples of the current frame
+	bufPos      int
+	samplesRead int64
+	eof         bool
+}
+
+// NewDecoder creates a new [Decoder] and decodes the first block.
+func NewDecoder(r io.Reader) (codec.Decoder, error) {
+	d := &Decoder{r: r}
+
+	if s, ok := r.(io.Seeker); ok {
+		var err error
+		if d.dataStart, err = s.Seek(0, io.SeekCurrent); err != nil {
+			return nil, fmt.Errorf("wavpack: failed to get position: %w", err)
+		}
+	}
+
+	hdr, samples, err := d.readFrame()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("wavpack: %w", err)
+	}
+	d.buf = samples
+	d.firstIndex = hdr.blockIndex()
+	d.totalSamples = hdr.totalSamples()
+	d.scale = float32(int64(1)<<(d.bitDepth-1) - 1)
+
+	if s, ok := r.(io.Seeker); ok {
+		if err := d.findEnd(s); err != nil {
+			return nil, fmt.Errorf("wavpack: failed to get stream size: %w", err)
+		}
+	}
+
+	return d, nil
+}
+
+// findEnd determines the offset of the end of the stream and seeks back to the current position.
+func (d *Decoder) findEnd(s io.Seeker) error {
+	cur, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if d.dataEnd, err = s.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	_, err = s.Seek(cur, io.SeekStart)
+	return err
+}
+
+// readHeader reads the next block header.
+// It returns io.EOF at the end of the stream, including when trailing APEv2 or ID3v1 tags are found.
+func (d *Decoder) readHeader() (*blockHeader, error) {
+	var hdr blockHeader
+	if err := binary.Read(d.r, binary.LittleEndian, &hdr); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) && d.numChannels != 0 {
+			return nil, io.EOF // tags shorter than a header
+		}
+		return nil, err
+	}
+
+	switch {
+	case string(hdr.ID[:]) == magic:
+	case d.numChannels != 0 && (string(hdr.ID[:]) == "APET" || string(hdr.ID[:3]) == "TAG"):
+		return nil, io.EOF
+	default:
+		return nil, errors.New("invalid block header")
+	}
+	if hdr.Version < minVersion || hdr.Version > maxVersion {
+		return nil, fmt.Errorf("unsupported version %#x", hdr.Version)
+	}
+	if hdr.Size < headerSize-8 || hdr.Size > maxBlockSize {
+		return nil, fmt.Errorf("invalid block size: %d", hdr.Size)
+	}
+	return &hdr, nil
+}
+
+// readFrame reads and decodes the blocks of the next frame (from an initial block to a final block)
+// and returns the header of the initial block and the interleaved samples of all channels.
+// Blocks without audio are skipped.
+func (d *Decoder) readFrame() (*blockHeader, []int32, error) {
+	var (
+		first    *blockHeader
+		blocks   []*block
+		numChans int
+	)
+	for {
+		hdr, err := d.readHeader()
+		if err != nil {
+			if first != nil && errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, nil, err
+		}
+
+		data := make([]byte, hdr.Size-(headerSize-8))
+		if _, err := io.ReadFull(d.r, data); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, nil, err
+		}
+
+		if hdr.BlockSamples == 0 && first == nil {
+			continue // metadata-only block
+		}
+		if first == nil && hdr.Flags&flagInitialBlock == 0 {
+			return nil, nil, errors.New("missing initial block")
+		}
+		if first != nil && (hdr.BlockSamples != first.BlockSamples || hdr.blockIndex() != first.blockIndex()) {
+			return nil, nil, errors.New("mismatched blocks in frame")
+		}
+
+		b, err := decodeBlock(hdr, data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if first == nil {
+			first = hdr
+			if d.bitDepth == 0 {
+				// first frame determines the format
+				d.bitDepth = hdr.bitDepth()
+				d.sampleRate = b.sampleRate
+			}
+		}
+		if hdr.bitDepth() != d.bitDepth {
+			return nil, nil, errors.New("bit depth changes within the stream")
+		}
+		blocks = append(blocks, b)
+		numChans += b.numChannels
+
+		if hdr.Flags&flagFinalBlock != 0 {
+			break
+		}
+	}
+
+	if d.numChannels == 0 {
+		d.numChannels = numChans
+	} else if numChans != d.numChannels {
+		return nil, nil, errors.New("number of channels changes within the stream")
+	}
+
+	if len(blocks) == 1 {
+		return first, blocks[0].samples, nil
+	}
+
+	// interleave the channels of all blocks
+	n := int(first.BlockSamples)
+	samples := make([]int32, n*numChans)
+	ch := 0
+	for _, b := range blocks {
+		for i := range n {
+			copy(samples[i*numChans+ch:], b.samples[i*b.numChannels:(i+1)*b.numChannels])
+		}
+		ch += b.numChannels
+	}
+	return first, samples, nil
+}
+
+// Format returns the audio stream format.
+func (d *Decoder) Format() afmt.Format {
+	return afmt.Format{
+		SampleRate:  freq.Frequency(d.sampleRate) * freq.Hertz,
+		NumChannels: d.numChannels,
+	}
+}
+
+// SampleFormat returns the sample format.
+func (d *Decoder) SampleFormat() afmt.SampleFormat {
+	return afmt.SampleFormat{
+		BitDepth: d.bitDepth,
+		Encoding: afmt.SampleEncodingInt,
+	}
+}
+
+// ReadSamples reads float32 samples into p.
+// It returns the number of samples read and/or an error.
+func (d *Decoder) ReadSamples(p []float32) (n int, err error) {
+	for n < len(p) {
+		if d.bufPos >= len(d.buf) {
+			if d.eof {
+				break
+			}
+			_, d.buf, err = d.readFrame()
+			d.bufPos = 0
+			if err != nil {
+				d.buf = nil
+				if errors.Is(err, io.EOF) {
+					d.eof = true
+					break
+				}
+				d.samplesRead += int64(n)
+				return n, fmt.Errorf("wavpack: %w", err)
+			}
+			continue
+		}
+
+		m := min(len(p)-n, len(d.buf)-d.bufPos)
+		for i, s := range d.buf[d.bufPos : d.bufPos+m] {
+			p[n+i] = float32(s) / d.scale
+		}
+		d.bufPos += m
+		n += m
+	}
+	d.samplesRead += int64(n)
+
+	if n == 0 && d.eof && len(p) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// Bitrate returns the average bitrate of the audio stream in bits per second, measured from the size of the stream.
+// It implements codec.Bitrater. It returns 0 if the length is unknown or the source is not an [io.Seeker].
+func (d *Decoder) Bitrate() int {
+	if d.totalSamples <= 0 || d.dataEnd <= d.dataStart {
+		return 0
+	}
+	return int((d.dataEnd - d.dataStart) * 8 * int64(d.sampleRate) / d.totalSamples)
+}
+
+// Len returns the total number of frames.
+// It returns 0 if the length is unknown.
+func (d *Decoder) Len() int {
+	return int(max(d.totalSamples, 0))
+}
+
+// Seek seeks to the specified frame.
+// It returns the new offset relative to the start and/or an error.
+// It will return an error if the source is not an [io.Seeker].
+//
+// Seeking scans the block headers from the start of the stream and decodes the frame containing the target.
+func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
+	numChans := int64(d.numChannels)
+
+	// Special case
+	if offset == 0 && whence == io.SeekCurrent {
+		return d.samplesRead / numChans, nil
+	}
+
+	s, ok := d.r.(io.Seeker)
+	if !ok {
+		return 0, errors.New("wavpack: resource does not support seeking")
+	}
+
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = d.samplesRead/numChans + offset
+	case io.SeekEnd:
+		if d.totalSamples < 0 {
+			return 0, errors.New("wavpack: cannot seek from end with unknown length")
+		}
+		target = d.totalSamples + offset
+	default:
+		return 0, errors.New("wavpack: invalid seek whence")
+	}
+
+	if target < 0 || (d.totalSamples >= 0 && target > d.totalSamples) {
+		return 0, errors.New("wavpack: seek out of bounds")
+	}
+
+	// find the initial block of the frame containing the target
+	off := d.dataStart
+	for {
+		if _, err := s.Seek(off, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("wavpack: failed to seek: %w", err)
+		}
+		hdr, err := d.readHeader()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return 0, fmt.Errorf("wavpack: failed to seek: %w", err)
+			}
+			// past the last frame
+			if d.totalSamples < 0 && target > d.samplesRead/numChans {
+				return 0, errors.New("wavpack: seek out of bounds")
+			}
+			d.buf, d.bufPos, d.eof = nil, 0, true
+			break
+		}
+
+		start := hdr.blockIndex() - d.firstIndex
+		if hdr.Flags&flagInitialBlock != 0 && target >= start && target < start+int64(hdr.BlockSamples) {
+			if _, err := s.Seek(off, io.SeekStart); err != nil {
+				return 0, fmt.Errorf("wavpack: failed to seek: %w", err)
+			}
+			_, samples, err := d.readFrame()
+			if err != nil {
+				return 0, fmt.Errorf("wavpack: failed to seek: %w", err)
+			}
+			d.buf, d.bufPos, d.eof = samples, int(target-start)*d.numChannels, false
+			break
+		}
+		off += int64(hdr.Size) + 8
+	}
+
+	d.samplesRead = target * numChans
+	return target, nil
+}
+
+func init() {
+	codec.RegisterFormat("wavpack", magic, NewDecoder)
+}
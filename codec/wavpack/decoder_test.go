@@ -0,0 +1,691 @@
+package wavpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// No reference encoder (wavpack/wvunpack) is available to generate fixtures, so the tests
+// use a minimal encoder which mirrors the decoder: it applies the decorrelation passes
+// in reverse and codes the residuals with the same adaptive Golomb scheme.
+
+// bitWriter writes bits LSB-first.
+type bitWriter struct {
+	b []byte
+	n int
+}
+
+func (bw *bitWriter) bit(v uint32) {
+	if bw.n&7 == 0 {
+		bw.b = append(bw.b, 0)
+	}
+	bw.b[len(bw.b)-1] |= byte(v&1) << (bw.n & 7)
+	bw.n++
+}
+
+func (bw *bitWriter) bits(v uint32, n int) {
+	for i := range n {
+		bw.bit(v >> i)
+	}
+}
+
+func (bw *bitWriter) ones(n uint32) {
+	for range n {
+		bw.bit(1)
+	}
+}
+
+func (bw *bitWriter) eliasGamma(v uint32) {
+	if v < 2 {
+		bw.ones(v)
+		bw.bit(0)
+		return
+	}
+	n := bitLen(v)
+	bw.ones(uint32(n))
+	bw.bit(0)
+	bw.bits(v, n-1)
+}
+
+func (bw *bitWriter) code(v, maxCode uint32) {
+	if maxCode < 2 {
+		if maxCode == 1 {
+			bw.bit(v)
+		}
+		return
+	}
+	n := bitLen(maxCode)
+	extras := uint32(1)<<n - maxCode - 1
+	if v < extras {
+		bw.bits(v, n-1)
+		return
+	}
+	v += extras
+	bw.bits(v>>1, n-1)
+	bw.bit(v)
+}
+
+// pendingWord is a coded value whose ones count has not been written yet,
+// because its parity depends on the next value.
+type pendingWord struct {
+	ones      uint32
+	low, high uint32
+	mag       uint32
+	sign      uint32
+}
+
+// wordsEncoder is the inverse of words.
+type wordsEncoder struct {
+	bw      bitWriter
+	w       words // only the medians are used
+	pending *pendingWord
+	holdOne bool
+	holdZ   bool
+}
+
+func (e *wordsEncoder) flush(next bool) {
+	p := e.pending
+	if p == nil {
+		return
+	}
+	e.pending = nil
+
+	ones := p.ones*2 + uint32(btoi(next))
+	if ones < limitOnes {
+		e.bw.ones(ones)
+		e.bw.bit(0)
+	} else {
+		e.bw.ones(limitOnes)
+		e.bw.bit(0)
+		e.bw.eliasGamma(ones - limitOnes)
+	}
+	e.bw.code(p.mag-p.low, p.high-p.low)
+	e.bw.bit(p.sign)
+
+	e.holdOne = next
+	e.holdZ = !next
+}
+
+// encode codes the residuals, with chans holding the channel of each residual.
+func (e *wordsEncoder) encode(values []int32, chans []int) []byte {
+	afterRun := false
+	for i := 0; i < len(values); i++ {
+		if e.pending == nil && !e.holdZ && !e.holdOne && !afterRun && e.w.median[0][0] < 2 && e.w.median[1][0] < 2 {
+			n := 0
+			for i+n < len(values) && values[i+n] == 0 {
+				n++
+			}
+			e.bw.eliasGamma(uint32(n))
+			if n > 0 {
+				e.w.median = [2][3]uint32{}
+				i += n - 1
+				afterRun = true
+				continue
+			}
+		}
+		afterRun = false
+
+		ch := chans[i]
+		v := values[i]
+		var p pendingWord
+		p.mag = uint32(v)
+		if v < 0 {
+			p.mag, p.sign = uint32(^v), 1
+		}
+
+		m0, m1, m2 := e.w.med(ch, 0), e.w.med(ch, 1), e.w.med(ch, 2)
+		switch {
+		case p.mag < m0:
+			p.ones, p.high = 0, m0-1
+			e.w.decMed(ch, 0, div0)
+		case p.mag < m0+m1:
+			p.ones, p.low = 1, m0
+			p.high = p.low + m1 - 1
+			e.w.incMed(ch, 0, div0)
+			e.w.decMed(ch, 1, div1)
+		default:
+			p.ones = 2 + (p.mag-m0-m1)/m2
+			p.low = m0 + m1 + (p.ones-2)*m2
+			p.high = p.low + m2 - 1
+			e.w.incMed(ch, 0, div0)
+			e.w.incMed(ch, 1, div1)
+			if p.ones == 2 {
+				e.w.decMed(ch, 2, div2)
+			} else {
+				e.w.incMed(ch, 2, div2)
+			}
+		}
+
+		e.flush(p.ones > 0)
+		if e.holdZ {
+			// the ones count is implied
+			e.holdZ = false
+			e.bw.code(p.mag-p.low, p.high-p.low)
+			e.bw.bit(p.sign)
+			continue
+		}
+		if e.holdOne {
+			p.ones--
+		}
+		e.holdOne = false
+		e.pending = &p
+	}
+	e.flush(false)
+	return e.bw.b
+}
+
+// testPass describes a decorrelation pass, in decoding order.
+type testPass struct {
+	term, delta      int32
+	weightA, weightB int8
+}
+
+// testBlock describes a block to encode.
+type testBlock struct {
+	samples    []int32 // interleaved
+	stereo     bool
+	joint      bool
+	bitDepth   int
+	shift      int // int32 info zeros
+	passes     []testPass
+	medians    int16 // log2 of the initial medians
+	history    int16 // log2 of the initial decorrelation samples
+	index      int64
+	total      int64
+	initial    bool
+	final      bool
+	sampleRate int
+}
+
+// subBlock returns a metadata sub-block.
+func subBlock(id byte, payload []byte) []byte {
+	if len(payload)&1 == 1 {
+		id |= idOddSize
+		payload = append(payload, 0)
+	}
+	n := len(payload) / 2
+	if n > 0xFF {
+		id |= idLarge
+		return append([]byte{id, byte(n), byte(n >> 8), byte(n >> 16)}, payload...)
+	}
+	return append([]byte{id, byte(n)}, payload...)
+}
+
+// encodeBlock encodes a block.
+func encodeBlock(tb testBlock) []byte {
+	numChans := 1
+	if tb.stereo {
+		numChans = 2
+	}
+	n := len(tb.samples) / numChans
+
+	passes := make([]decorrPass, len(tb.passes))
+	var terms, weights, history []byte
+	for i := len(tb.passes) - 1; i >= 0; i-- {
+		tp := tb.passes[i]
+		dp := &passes[i]
+		dp.term, dp.delta = tp.term, tp.delta
+		dp.weightA, dp.weightB = restoreWeight(tp.weightA), restoreWeight(tp.weightB)
+		terms = append(terms, byte(tp.term+5)|byte(tp.delta<<5))
+		weights = append(weights, byte(tp.weightA))
+		if tb.stereo {
+			weights = append(weights, byte(tp.weightB))
+		}
+
+		h := exp2(tb.history)
+		count := int(tp.term)
+		switch {
+		case tp.term > 8:
+			count = 2
+		case tp.term < 0:
+			count = 1
+		}
+		for j := range count {
+			dp.samplesA[j] = h
+			history = binary.LittleEndian.AppendUint16(history, uint16(tb.history))
+			if tb.stereo || tp.term < 0 {
+				dp.samplesB[j] = h
+				history = binary.LittleEndian.AppendUint16(history, uint16(tb.history))
+			}
+		}
+	}
+
+	var enc wordsEncoder
+	var vars []byte
+	for ch := range numChans {
+		for i := range 3 {
+			enc.w.median[ch][i] = uint32(exp2(tb.medians))
+			vars = binary.LittleEndian.AppendUint16(vars, uint16(tb.medians))
+		}
+	}
+
+	// decorrelate
+	residuals := make([]int32, len(tb.samples))
+	chans := make([]int, len(tb.samples))
+	crc := uint32(0xFFFFFFFF)
+	pos := 0
+	for i := range n {
+		if !tb.stereo {
+			s := tb.samples[i] >> tb.shift
+			crc = crc*3 + uint32(s)
+			for k := len(passes) - 1; k >= 0; k-- {
+				dp := &passes[k]
+				var a int32
+				j := 0
+				if dp.term > 8 {
+					if dp.term&1 == 1 {
+						a = 2*dp.samplesA[0] - dp.samplesA[1]
+					} else {
+						a = (3*dp.samplesA[0] - dp.samplesA[1]) >> 1
+					}
+					dp.samplesA[1] = dp.samplesA[0]
+				} else {
+					a = dp.samplesA[pos]
+					j = (pos + int(dp.term)) & 7
+				}
+				in := s - applyWeight(dp.weightA, a)
+				updateWeight(&dp.weightA, dp.delta, a, in)
+				dp.samplesA[j] = s
+				s = in
+			}
+			residuals[i] = s
+			pos = (pos + 1) & 7
+			continue
+		}
+
+		l, r := tb.samples[2*i]>>tb.shift, tb.samples[2*i+1]>>tb.shift
+		crc = (crc*3+uint32(l))*3 + uint32(r)
+		if tb.joint {
+			l -= r
+			r += l >> 1
+		}
+		for k := len(passes) - 1; k >= 0; k-- {
+			dp := &passes[k]
+			switch {
+			case dp.term > 0:
+				var a, b int32
+				j := 0
+				if dp.term > 8 {
+					if dp.term&1 == 1 {
+						a = 2*dp.samplesA[0] - dp.samplesA[1]
+						b = 2*dp.samplesB[0] - dp.samplesB[1]
+					} else {
+						a = (3*dp.samplesA[0] - dp.samplesA[1]) >> 1
+						b = (3*dp.samplesB[0] - dp.samplesB[1]) >> 1
+					}
+					dp.samplesA[1] = dp.samplesA[0]
+					dp.samplesB[1] = dp.samplesB[0]
+				} else {
+					a = dp.samplesA[pos]
+					b = dp.samplesB[pos]
+					j = (pos + int(dp.term)) & 7
+				}
+				l2 := l - applyWeight(dp.weightA, a)
+				r2 := r - applyWeight(dp.weightB, b)
+				updateWeight(&dp.weightA, dp.delta, a, l2)
+				updateWeight(&dp.weightB, dp.delta, b, r2)
+				dp.samplesA[j], dp.samplesB[j] = l, r
+				l, r = l2, r2
+			case dp.term == -1:
+				l2 := l - applyWeight(dp.weightA, dp.samplesA[0])
+				updateWeightClip(&dp.weightA, dp.delta, dp.samplesA[0], l2)
+				r2 := r - applyWeight(dp.weightB, l)
+				updateWeightClip(&dp.weightB, dp.delta, l, r2)
+				dp.samplesA[0] = r
+				l, r = l2, r2
+			default:
+				r2 := r - applyWeight(dp.weightB, dp.samplesB[0])
+				updateWeightClip(&dp.weightB, dp.delta, dp.samplesB[0], r2)
+				pred := r
+				if dp.term == -3 {
+					pred = dp.samplesA[0]
+					dp.samplesA[0] = r
+				}
+				l2 := l - applyWeight(dp.weightA, pred)
+				updateWeightClip(&dp.weightA, dp.delta, pred, l2)
+				dp.samplesB[0] = l
+				l, r = l2, r2
+			}
+		}
+		residuals[2*i], residuals[2*i+1] = l, r
+		chans[2*i+1] = 1
+		pos = (pos + 1) & 7
+	}
+
+	var data []byte
+	data = append(data, subBlock(idDecorrTerms, terms)...)
+	data = append(data, subBlock(idDecorrWeights, weights)...)
+	data = append(data, subBlock(idDecorrSamples, history)...)
+	data = append(data, subBlock(idEntropyVars, vars)...)
+	if tb.shift != 0 {
+		data = append(data, subBlock(idInt32Info, []byte{0, byte(tb.shift), 0, 0})...)
+	}
+	data = append(data, subBlock(idWVBitstream, enc.encode(residuals, chans))...)
+
+	flags := uint32(tb.bitDepth/8-1) | 15<<srateLSB
+	if !tb.stereo {
+		flags |= flagMono
+	}
+	if tb.joint {
+		flags |= flagJointStereo
+	}
+	if tb.initial {
+		flags |= flagInitialBlock
+	}
+	if tb.final {
+		flags |= flagFinalBlock
+	}
+	for i, rate := range sampleRates {
+		if rate == tb.sampleRate {
+			flags = flags&^srateMask | uint32(i)<<srateLSB
+		}
+	}
+	if flags&srateMask == srateMask {
+		data = append(data, subBlock(idSampleRate, []byte{byte(tb.sampleRate), byte(tb.sampleRate >> 8), byte(tb.sampleRate >> 16)})...)
+	}
+
+	total := uint32(0xFFFFFFFF)
+	if tb.total >= 0 {
+		total = uint32(tb.total)
+	}
+	hdr := blockHeader{
+		Version:      0x407,
+		TotalSamples: total,
+		BlockIndex:   uint32(tb.index),
+		BlockSamples: uint32(n),
+		Flags:        flags,
+		CRC:          crc,
+		Size:         uint32(headerSize - 8 + len(data)),
+	}
+	copy(hdr.ID[:], magic)
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, &hdr)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// signal returns interleaved test samples within the given bit depth,
+// containing a noisy tone, a silent section and a few full-scale spikes.
+func signal(frames, numChans, bitDepth int, seed uint64) []int32 {
+	rng := rand.New(rand.NewPCG(seed, 0))
+	peak := float64(int64(1)<<(bitDepth-1) - 1)
+	s := make([]int32, frames*numChans)
+	for i := range frames {
+		for ch := range numChans {
+			var v float64
+			switch {
+			case i >= frames/2 && i < frames*3/4:
+				// silence
+			case i%97 == 0:
+				v = peak * float64(1-2*(i/97%2))
+			default:
+				v = 0.5*peak*math.Sin(float64(i)*(0.05+0.02*float64(ch))) + rng.NormFloat64()*peak/1000
+			}
+			s[i*numChans+ch] = int32(max(min(v, peak), -peak-1))
+		}
+	}
+	return s
+}
+
+// file returns a file of blocks with the given parameters, split into frames of frameLen frames each.
+func file(samples []int32, numChans int, frameLen int, tb testBlock) []byte {
+	frames := len(samples) / numChans
+	var b []byte
+	for start := 0; start < frames; start += frameLen {
+		end := min(start+frameLen, frames)
+		tb.index = int64(start)
+		tb.total = int64(frames)
+		tb.initial = true
+		tb.final = true
+		tb.samples = samples[start*numChans : end*numChans]
+		b = append(b, encodeBlock(tb)...)
+	}
+	return b
+}
+
+var testPasses = []testPass{
+	{term: 18, delta: 2, weightA: 48, weightB: 40},
+	{term: 17, delta: 2, weightA: 20, weightB: -12},
+	{term: 3, delta: 2, weightA: 8, weightB: 4},
+	{term: 2, delta: 2, weightA: -8, weightB: 16},
+	{term: 1, delta: 2, weightA: 60, weightB: 64},
+}
+
+var testCrossPasses = []testPass{
+	{term: 18, delta: 2, weightA: 48, weightB: 40},
+	{term: -1, delta: 2, weightA: 24, weightB: 16},
+	{term: -2, delta: 3, weightA: -16, weightB: 8},
+	{term: -3, delta: 1, weightA: 8, weightB: 32},
+	{term: 2, delta: 2, weightA: -8, weightB: 16},
+}
+
+func toFloat(samples []int32, bitDepth int) []float32 {
+	scale := float32(int64(1)<<(bitDepth-1) - 1)
+	out := make([]float32, len(samples))
+	for i, s := range samples {
+		out[i] = float32(s) / scale
+	}
+	return out
+}
+
+func TestDecoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		numChans int
+		bitDepth int
+		tb       testBlock
+	}{
+		{"Mono8", 1, 8, testBlock{passes: testPasses[:3], medians: 0x300}},
+		{"Mono16", 1, 16, testBlock{passes: testPasses, medians: 0x400, history: 0x200}},
+		{"Stereo16", 2, 16, testBlock{stereo: true, passes: testPasses}},
+		{"Joint16", 2, 16, testBlock{stereo: true, joint: true, passes: testCrossPasses, history: -0x300}},
+		{"Joint24", 2, 24, testBlock{stereo: true, joint: true, passes: testPasses, medians: 0x800}},
+		{"Stereo32", 2, 32, testBlock{stereo: true, passes: testCrossPasses, medians: 0xc00}},
+		{"NoPasses", 1, 16, testBlock{}},
+		{"SampleRate", 2, 16, testBlock{stereo: true, sampleRate: 22050}},
+		{"CustomSampleRate", 2, 16, testBlock{stereo: true, sampleRate: 37800}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := signal(3000, tt.numChans, tt.bitDepth, 1)
+			tt.tb.bitDepth = tt.bitDepth
+			rate := tt.tb.sampleRate
+			if rate == 0 {
+				tt.tb.sampleRate = 44100
+				rate = 44100
+			}
+
+			b := file(samples, tt.numChans, 1024, tt.tb)
+			dec, _, err := codec.Decode(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := dec.Format(), (afmt.Format{SampleRate: freq.Frequency(rate) * freq.Hertz, NumChannels: tt.numChans}); got != want {
+				t.Errorf("expected format %v, got %v", want, got)
+			}
+			if got, want := dec.SampleFormat(), (afmt.SampleFormat{BitDepth: tt.bitDepth, Encoding: afmt.SampleEncodingInt}); got != want {
+				t.Errorf("expected sample format %v, got %v", want, got)
+			}
+			if dec.Len() != 3000 {
+				t.Errorf("expected length 3000, got %d", dec.Len())
+			}
+			if bps, ok := codec.Bitrate(dec); !ok || bps != len(b)*8*rate/3000 {
+				t.Errorf("expected %d bps, got %d, %v", len(b)*8*rate/3000, bps, ok)
+			}
+
+			got, err := aio.ReadAll(dec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := toFloat(samples, tt.bitDepth)
+			if len(got) != len(want) {
+				t.Fatalf("expected %d samples, got %d", len(want), len(got))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("sample %d: expected %v, got %v", i, want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecoderShift(t *testing.T) {
+	samples := signal(500, 1, 16, 2)
+	for i := range samples {
+		samples[i] &^= 3
+	}
+
+	dec, err := NewDecoder(bytes.NewReader(file(samples, 1, 500, testBlock{bitDepth: 16, shift: 2, passes: testPasses[:1]})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := toFloat(samples, 16)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestDecoderMultichannel(t *testing.T) {
+	// 3 channels: a stereo block followed by a mono block in each frame
+	const frames = 700
+	samples := signal(frames, 3, 16, 3)
+	var b []byte
+	for start := 0; start < frames; start += 256 {
+		end := min(start+256, frames)
+		var stereo, mono []int32
+		for i := start; i < end; i++ {
+			stereo = append(stereo, samples[3*i], samples[3*i+1])
+			mono = append(mono, samples[3*i+2])
+		}
+		tb := testBlock{bitDepth: 16, sampleRate: 48000, index: int64(start), total: frames, passes: testPasses[2:]}
+		b = append(b, encodeBlock(testBlock{samples: stereo, stereo: true, joint: true, initial: true, bitDepth: tb.bitDepth, sampleRate: tb.sampleRate, index: tb.index, total: tb.total, passes: tb.passes})...)
+		b = append(b, encodeBlock(testBlock{samples: mono, final: true, bitDepth: tb.bitDepth, sampleRate: tb.sampleRate, index: tb.index, total: tb.total, passes: tb.passes})...)
+	}
+
+	dec, err := NewDecoder(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Format().NumChannels != 3 {
+		t.Fatalf("expected 3 channels, got %d", dec.Format().NumChannels)
+	}
+	got, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := toFloat(samples, 16)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d samples, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+// nonSeeker hides the io.Seeker implementation of the underlying reader.
+type nonSeeker struct {
+	io.Reader
+}
+
+func TestDecoderSeek(t *testing.T) {
+	samples := signal(5000, 2, 16, 4)
+	want := toFloat(samples, 16)
+	b := file(samples, 2, 1024, testBlock{stereo: true, joint: true, bitDepth: 16, sampleRate: 44100, passes: testPasses})
+	b = append(b, "APETAGEX"...) // trailing tags are ignored
+
+	dec, err := NewDecoder(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pos := range []int64{2500, 0, 1023, 1024, 4999, 3000} {
+		if n, err := dec.Seek(pos, io.SeekStart); err != nil || n != pos {
+			t.Fatalf("seek to %d: got %d, %v", pos, n, err)
+		}
+		buf := make([]float32, 200)
+		n, err := aio.ReadFull(dec, buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			t.Fatal(err)
+		}
+		for i := range buf[:n] {
+			if buf[i] != want[int(pos)*2+i] {
+				t.Fatalf("after seek to %d: sample %d: expected %v, got %v", pos, i, want[int(pos)*2+i], buf[i])
+			}
+		}
+	}
+
+	if n, err := dec.Seek(-100, io.SeekEnd); err != nil || n != 4900 {
+		t.Fatalf("seek from end: got %d, %v", n, err)
+	}
+	if n, _ := dec.Seek(0, io.SeekCurrent); n != 4900 {
+		t.Errorf("expected position 4900, got %d", n)
+	}
+	if n, err := dec.Seek(0, io.SeekEnd); err != nil || n != 5000 {
+		t.Fatalf("seek to end: got %d, %v", n, err)
+	}
+	if n, err := dec.ReadSamples(make([]float32, 10)); n != 0 || err != io.EOF {
+		t.Errorf("expected EOF at end, got %d, %v", n, err)
+	}
+	if n, err := dec.Seek(10, io.SeekStart); err != nil || n != 10 {
+		t.Fatalf("seek after EOF: got %d, %v", n, err)
+	}
+	if _, err := dec.Seek(5001, io.SeekStart); err == nil {
+		t.Error("expected error when seeking past the end")
+	}
+
+	dec, err = NewDecoder(nonSeeker{bytes.NewReader(b)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.Seek(100, io.SeekStart); err == nil {
+		t.Error("expected error when seeking a non-seekable reader")
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	samples := signal(100, 1, 16, 5)
+	b := file(samples, 1, 100, testBlock{bitDepth: 16, sampleRate: 44100})
+
+	t.Run("CRC", func(t *testing.T) {
+		bad := bytes.Clone(b)
+		bad[28] ^= 1
+		if _, err := NewDecoder(bytes.NewReader(bad)); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("Hybrid", func(t *testing.T) {
+		bad := bytes.Clone(b)
+		bad[24] |= flagHybrid
+		if _, err := NewDecoder(bytes.NewReader(bad)); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		if _, err := NewDecoder(bytes.NewReader(b[:len(b)-10])); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
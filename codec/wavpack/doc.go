@@ -0,0 +1,7 @@
+// Package wavpack implements decoding of WavPack (.wv) files.
+//
+// Only the standard lossless mode with integer samples is supported.
+// Hybrid (lossy), floating point, and DSD files are rejected with an error.
+package wavpack
+
+// https://www.wavpack.com/WavPack5FileFormat.pdf
@@ -0,0 +1,126 @@
+package wavpack
+
+import "math"
+
+const magic = "wvpk"
+
+// headerSize is the size of a block header in bytes.
+const headerSize = 32
+
+// maxBlockSize is the maximum size of a block in bytes.
+const maxBlockSize = 1 << 24
+
+// Supported stream versions.
+const (
+	minVersion = 0x402
+	maxVersion = 0x410
+)
+
+// Block header flags.
+const (
+	flagBytesStored  = 0x3 // bytes per sample - 1
+	flagMono         = 0x4
+	flagHybrid       = 0x8
+	flagJointStereo  = 0x10
+	flagCrossDecorr  = 0x20
+	flagFloat        = 0x80
+	flagInt32        = 0x100
+	flagInitialBlock = 0x800
+	flagFinalBlock   = 0x1000
+	flagFalseStereo  = 0x40000000
+	flagDSD          = 0x80000000
+
+	shiftLSB  = 13
+	shiftMask = 0x1f << shiftLSB
+	srateLSB  = 23
+	srateMask = 0xf << srateLSB
+)
+
+// sampleRates are the sample rates indexed by the sample rate field of the flags.
+// Index 15 means the sample rate is stored in a metadata sub-block.
+var sampleRates = [15]int{6000, 8000, 9600, 11025, 12000, 16000, 22050, 24000, 32000, 44100, 48000, 64000, 88200, 96000, 192000}
+
+// Metadata sub-block IDs.
+const (
+	idOptionalData = 0x20
+	idOddSize      = 0x40
+	idLarge        = 0x80
+	idMask         = 0x3f
+
+	idDecorrTerms   = 0x2
+	idDecorrWeights = 0x3
+	idDecorrSamples = 0x4
+	idEntropyVars   = 0x5
+	idInt32Info     = 0x9
+	idWVBitstream   = 0xa
+	idChannelInfo   = 0xd
+	idSampleRate    = 0x27
+)
+
+// blockHeader represents a block header.
+type blockHeader struct {
+	ID             [4]byte
+	Size           uint32 // size of the block minus 8 bytes
+	Version        uint16
+	BlockIndexU8   uint8
+	TotalSamplesU8 uint8
+	TotalSamples   uint32
+	BlockIndex     uint32
+	BlockSamples   uint32
+	Flags          uint32
+	CRC            uint32
+}
+
+// blockIndex returns the index of the first sample in the block.
+func (h *blockHeader) blockIndex() int64 {
+	return int64(h.BlockIndexU8)<<32 | int64(h.BlockIndex)
+}
+
+// totalSamples returns the total number of samples (frames) in the file, or -1 if unknown.
+func (h *blockHeader) totalSamples() int64 {
+	if h.TotalSamples == 0xFFFFFFFF {
+		return -1
+	}
+	// the upper 8 bits are stored such that the field stays compatible with older decoders
+	return int64(h.TotalSamplesU8)<<32 + int64(h.TotalSamples) - int64(h.TotalSamplesU8)
+}
+
+// bitDepth returns the number of bits per sample.
+func (h *blockHeader) bitDepth() int {
+	return (int(h.Flags&flagBytesStored) + 1) * 8
+}
+
+// exp2Table holds 256 * (2^(i/256) - 1), rounded to the nearest integer.
+var exp2Table [256]int32
+
+func init() {
+	for i := range exp2Table {
+		exp2Table[i] = int32(math.Round((math.Exp2(float64(i)/256) - 1) * 256))
+	}
+}
+
+// exp2 converts a value from the 8.8 fixed-point logarithmic representation
+// used for stored decorrelation samples and medians.
+func exp2(v int16) int32 {
+	neg := v < 0
+	val := int32(v)
+	if neg {
+		val = -val
+	}
+
+	res := exp2Table[val&0xff] | 0x100
+	val >>= 8
+	if val > 31 {
+		return math.MinInt32
+	}
+	if val > 9 {
+		res <<= val - 9
+	} else {
+		res >>= 9 - val
+	}
+
+	if neg {
+		return -res
+	}
+	return res
+}
@@ -0,0 +1,23 @@
+package wavpack
+
+import "testing"
+
+func TestExp2(t *testing.T) {
+	tests := []struct {
+		in   int16
+		want int32
+	}{
+		{0, 0},
+		{0x100, 1},
+		{0x800, 128},
+		{0x880, 181},
+		{0xa00, 512},
+		{-0x800, -128},
+	}
+
+	for _, tt := range tests {
+		if got := exp2(tt.in); got != tt.want {
+			t.Errorf("exp2(%#x): expected %d, got %d", tt.in, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package wavpack
+
+import "errors"
+
+// Entropy decoder parameters.
+const (
+	limitOnes = 16
+	div0      = 128
+	div1      = 64
+	div2      = 32
+)
+
+var errBitstream = errors.New("invalid bitstream")
+
+// words is the state of the entropy decoder of a block.
+type words struct {
+	br      bitReader
+	median  [2][3]uint32
+	zeros   uint32 // remaining zeros of a run
+	holdOne bool   // the next value has a ones count of at least 1
+	holdZ   bool   // the next value has a ones count of 0
+}
+
+// median returns the median n of channel ch, which is used as a step size.
+func (w *words) med(ch, n int) uint32 {
+	return w.median[ch][n]>>4 + 1
+}
+
+func (w *words) incMed(ch, n int, div uint32) {
+	w.median[ch][n] += (w.median[ch][n] + div) / div * 5
+}
+
+func (w *words) decMed(ch, n int, div uint32) {
+	w.median[ch][n] -= (w.median[ch][n] + div - 2) / div * 2
+}
+
+// value decodes the next residual of channel ch.
+func (w *words) value(ch int) (int32, error) {
+	// runs of zeros are coded when the medians of both channels are very small
+	if w.median[0][0] < 2 && w.median[1][0] < 2 && !w.holdZ && !w.holdOne {
+		if w.zeros != 0 {
+			w.zeros--
+			if w.zeros != 0 {
+				return 0, nil
+			}
+		} else {
+			n, ok := w.br.eliasGamma()
+			if !ok {
+				return 0, errBitstream
+			}
+			if n != 0 {
+				w.zeros = n
+				w.median = [2][3]uint32{}
+				return 0, nil
+			}
+		}
+	}
+
+	var ones uint32
+	if w.holdZ {
+		w.holdZ = false
+	} else {
+		n := w.br.ones(limitOnes + 1)
+		if n == limitOnes+1 {
+			return 0, errBitstream
+		}
+		ones = uint32(n)
+		if ones == limitOnes {
+			n, ok := w.br.eliasGamma()
+			if !ok {
+				return 0, errBitstream
+			}
+			ones += n
+		}
+
+		if w.holdOne {
+			w.holdOne = ones&1 == 1
+			ones = ones>>1 + 1
+		} else {
+			w.holdOne = ones&1 == 1
+			ones >>= 1
+		}
+		w.holdZ = !w.holdOne
+	}
+
+	var low, high uint32
+	switch {
+	case ones == 0:
+		high = w.med(ch, 0) - 1
+		w.decMed(ch, 0, div0)
+	case ones == 1:
+		low = w.med(ch, 0)
+		w.incMed(ch, 0, div0)
+		high = low + w.med(ch, 1) - 1
+		w.decMed(ch, 1, div1)
+	default:
+		low = w.med(ch, 0)
+		w.incMed(ch, 0, div0)
+		low += w.med(ch, 1)
+		w.incMed(ch, 1, div1)
+		if ones == 2 {
+			high = low + w.med(ch, 2) - 1
+			w.decMed(ch, 2, div2)
+		} else {
+			low += (ones - 2) * w.med(ch, 2)
+			high = low + w.med(ch, 2) - 1
+			w.incMed(ch, 2, div2)
+		}
+	}
+
+	low += w.br.code(high - low)
+	sign := w.br.bit()
+	if w.br.overrun {
+		return 0, errBitstream
+	}
+	if sign == 1 {
+		return int32(^low), nil
+	}
+	return int32(low), nil
+}
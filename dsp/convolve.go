@@ -0,0 +1,68 @@
+package dsp
+
+import "github.com/MatusOllah/resona/dsp/fft"
+
+// fftSize returns the smallest power of two of at least n.
+func fftSize(n int) int {
+	size := 1
+	for size < n {
+		size *= 2
+	}
+	return size
+}
+
+// ConvolveFFT returns the linear convolution of a and b, of length len(a)+len(b)-1,
+// computed with fast Fourier transforms in O(n log n) time.
+//
+// Direct convolution is faster for short inputs; FFT convolution wins once
+// both inputs have more than about 128 samples (see BenchmarkConvolve).
+// For streaming convolution with a fixed kernel, see the filter package.
+func ConvolveFFT(a, b []float64) []float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+
+	n := len(a) + len(b) - 1
+	plan := fft.NewReal(fftSize(n))
+
+	buf := make([]float64, plan.Len())
+	specA := make([]complex128, plan.Len()/2+1)
+	specB := make([]complex128, plan.Len()/2+1)
+	copy(buf, a)
+	plan.Forward(specA, buf)
+	clear(buf)
+	copy(buf, b)
+	plan.Forward(specB, buf)
+
+	for i := range specA {
+		specA[i] *= specB[i]
+	}
+	plan.Inverse(buf, specA)
+	return buf[:n:n]
+}
+
+// AutoCorrelate returns the autocorrelation of x for lags from 0 to maxLag,
+// that is r[k] = x[0]*x[k] + x[1]*x[k+1] + ... + x[len(x)-1-k]*x[len(x)-1], computed with fast Fourier transforms.
+// maxLag is limited to len(x)-1. It panics if maxLag is negative.
+func AutoCorrelate(x []float64, maxLag int) []float64 {
+	if maxLag < 0 {
+		panic("dsp: negative lag")
+	}
+	if len(x) == 0 {
+		return nil
+	}
+	maxLag = min(maxLag, len(x)-1)
+
+	// padding by maxLag keeps the circular correlation from wrapping around into the lags of interest
+	plan := fft.NewReal(fftSize(len(x) + maxLag))
+	buf := make([]float64, plan.Len())
+	spec := make([]complex128, plan.Len()/2+1)
+	copy(buf, x)
+	plan.Forward(spec, buf)
+
+	for i, c := range spec {
+		spec[i] = complex(real(c)*real(c)+imag(c)*imag(c), 0)
+	}
+	plan.Inverse(buf, spec)
+	return buf[: maxLag+1 : maxLag+1]
+}
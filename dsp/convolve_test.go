@@ -0,0 +1,95 @@
+package dsp_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/MatusOllah/resona/dsp"
+)
+
+func randomSignal(rng *rand.Rand, n int) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = rng.Float64()*2 - 1
+	}
+	return x
+}
+
+func convolveDirect(a, b []float64) []float64 {
+	out := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		for j, y := range b {
+			out[i+j] += x * y
+		}
+	}
+	return out
+}
+
+func maxDiff(a, b []float64) float64 {
+	var d float64
+	for i := range a {
+		d = max(d, math.Abs(a[i]-b[i]))
+	}
+	return d
+}
+
+func TestConvolveFFT(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, size := range [][2]int{{1, 1}, {1, 100}, {7, 5}, {64, 64}, {1000, 33}, {513, 2049}} {
+		a, b := randomSignal(rng, size[0]), randomSignal(rng, size[1])
+		want := convolveDirect(a, b)
+		got := dsp.ConvolveFFT(a, b)
+		if len(got) != len(want) {
+			t.Fatalf("%v: expected %d samples, got %d", size, len(want), len(got))
+		}
+		if d := maxDiff(got, want); d > 1e-9 {
+			t.Errorf("%v: expected the direct convolution, got a difference of %v", size, d)
+		}
+	}
+
+	if got := dsp.ConvolveFFT(nil, []float64{1}); len(got) != 0 {
+		t.Errorf("expected no samples for an empty input, got %v", got)
+	}
+}
+
+func TestAutoCorrelate(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	x := randomSignal(rng, 1000)
+
+	for _, maxLag := range []int{0, 1, 100, 999, 5000} {
+		got := dsp.AutoCorrelate(x, maxLag)
+		if want := min(maxLag, len(x)-1) + 1; len(got) != want {
+			t.Fatalf("maxLag %d: expected %d lags, got %d", maxLag, want, len(got))
+		}
+		for k, r := range got {
+			var want float64
+			for i := 0; i+k < len(x); i++ {
+				want += x[i] * x[i+k]
+			}
+			if math.Abs(r-want) > 1e-9 {
+				t.Fatalf("maxLag %d: lag %d: expected %v, got %v", maxLag, k, want, r)
+			}
+		}
+	}
+}
+
+// BenchmarkConvolve compares direct and FFT convolution of two signals of the same length.
+// On a typical desktop machine, FFT convolution wins from a length of about 128.
+func BenchmarkConvolve(b *testing.B) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, n := range []int{16, 32, 64, 128, 256, 1024} {
+		x, y := randomSignal(rng, n), randomSignal(rng, n)
+		b.Run(fmt.Sprintf("Direct/%d", n), func(b *testing.B) {
+			for b.Loop() {
+				convolveDirect(x, y)
+			}
+		})
+		b.Run(fmt.Sprintf("FFT/%d", n), func(b *testing.B) {
+			for b.Loop() {
+				dsp.ConvolveFFT(x, y)
+			}
+		})
+	}
+}
@@ -1,11 +1,50 @@
 // Package dsp provides digital signal processing (DSP) math primitives.
 package dsp
 
+import "math"
+
 // Clamp clamps the value x to the range [-1, 1].
 func Clamp(x float32) float32 {
 	return max(-1, min(1, x))
 }
 
+// ClampSlice clamps every sample of s in place to the range [-1, 1].
+func ClampSlice(s []float32) {
+	for i, x := range s {
+		s[i] = max(-1, min(1, x))
+	}
+}
+
+// DBToAmplitude converts a level in decibels (dB) to a linear amplitude gain.
+// 0 dB is unity gain and -Inf dB is 0.
+func DBToAmplitude(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// AmplitudeToDB converts a linear amplitude gain to a level in decibels (dB).
+// Zero and negative amplitudes have no level in decibels; they return -Inf,
+// which is below every finite level and converts back to 0.
+func AmplitudeToDB(a float64) float64 {
+	if a <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(a)
+}
+
+// DBToPower converts a level in decibels (dB) to a linear power ratio.
+func DBToPower(db float64) float64 {
+	return math.Pow(10, db/10)
+}
+
+// PowerToDB converts a linear power ratio to a level in decibels (dB).
+// Like [AmplitudeToDB], it returns -Inf for zero and negative input.
+func PowerToDB(p float64) float64 {
+	if p <= 0 {
+		return math.Inf(-1)
+	}
+	return 10 * math.Log10(p)
+}
+
 // ToComplexSlice converts a slice of float32 to a slice of complex64 with zero imaginary parts.
 func ToComplexSlice(f []float32) []complex64 {
 	c := make([]complex64, len(f))
@@ -1,9 +1,12 @@
 package dsp_test
 
 import (
+	"math"
+	"math/cmplx"
 	"testing"
 
 	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/freq"
 	"github.com/MatusOllah/resona/internal/testutil"
 )
 
@@ -14,6 +17,55 @@ func TestClamp(t *testing.T) {
 	}
 }
 
+func TestClampSlice(t *testing.T) {
+	s := []float32{0, 0.5, -0.5, 1, -1, 1.5, -39, float32(math.Inf(1)), float32(math.Inf(-1))}
+	want := []float32{0, 0.5, -0.5, 1, -1, 1, -1, 1, -1}
+	dsp.ClampSlice(s)
+	if !testutil.EqualSliceWithinTolerance(s, want, 0) {
+		t.Errorf("ClampSlice() = %v; want %v", s, want)
+	}
+}
+
+func TestDBConversion(t *testing.T) {
+	for _, x := range []float64{1e-6, 0.001, 0.5, 1, 2, 1000} {
+		if got := dsp.DBToAmplitude(dsp.AmplitudeToDB(x)); !testutil.EqualWithinTolerance(got, x, 1e-12*x) {
+			t.Errorf("DBToAmplitude(AmplitudeToDB(%v)) = %v", x, got)
+		}
+		if got := dsp.DBToPower(dsp.PowerToDB(x)); !testutil.EqualWithinTolerance(got, x, 1e-12*x) {
+			t.Errorf("DBToPower(PowerToDB(%v)) = %v", x, got)
+		}
+	}
+
+	for _, tt := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"DBToAmplitude(0)", dsp.DBToAmplitude(0), 1},
+		{"DBToAmplitude(-6)", dsp.DBToAmplitude(-6), 0.501187233627272},
+		{"DBToAmplitude(20)", dsp.DBToAmplitude(20), 10},
+		{"DBToPower(-3)", dsp.DBToPower(-3), 0.501187233627272},
+		{"DBToPower(10)", dsp.DBToPower(10), 10},
+		{"AmplitudeToDB(10)", dsp.AmplitudeToDB(10), 20},
+		{"PowerToDB(10)", dsp.PowerToDB(10), 10},
+		{"DBToAmplitude(-Inf)", dsp.DBToAmplitude(math.Inf(-1)), 0},
+		{"DBToPower(-Inf)", dsp.DBToPower(math.Inf(-1)), 0},
+	} {
+		if !testutil.EqualWithinTolerance(tt.got, tt.want, 1e-12) {
+			t.Errorf("%s = %v; want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// zero and negative input
+	for _, x := range []float64{0, math.Copysign(0, -1), -0.5, math.Inf(-1)} {
+		if got := dsp.AmplitudeToDB(x); !math.IsInf(got, -1) {
+			t.Errorf("AmplitudeToDB(%v) = %v; want -Inf", x, got)
+		}
+		if got := dsp.PowerToDB(x); !math.IsInf(got, -1) {
+			t.Errorf("PowerToDB(%v) = %v; want -Inf", x, got)
+		}
+	}
+}
+
 func TestComplexFloatRoundtrip(t *testing.T) {
 	want := []float32{1, 2, 3, 4, 5}
 
@@ -24,3 +76,67 @@ func TestComplexFloatRoundtrip(t *testing.T) {
 		t.Errorf("Roundtrip failed: got %v; want %v", got, want)
 	}
 }
+
+func TestMeasurePeakDB(t *testing.T) {
+	if got := dsp.MeasurePeakDB([]float32{0.1, -0.5, 0.25}); !testutil.EqualWithinTolerance(got, 20*math.Log10(0.5), 1e-9) {
+		t.Errorf("MeasurePeakDB() = %v; want %v", got, 20*math.Log10(0.5))
+	}
+	if got := dsp.MeasurePeakDB(make([]float32, 10)); !math.IsInf(got, -1) {
+		t.Errorf("MeasurePeakDB(silence) = %v; want -Inf", got)
+	}
+}
+
+func TestNormalizePeak(t *testing.T) {
+	// a sine with a DC offset, which peaks at 0.5 on the positive side only
+	s := make([]float32, 1000)
+	for i := range s {
+		s[i] = 0.3 + 0.2*float32(math.Sin(2*math.Pi*float64(i)/100))
+	}
+
+	gain := dsp.NormalizePeak(s, -1)
+	want := math.Pow(10, -1.0/20) / 0.5
+	if !testutil.EqualWithinTolerance(gain, want, 1e-6) {
+		t.Errorf("NormalizePeak() = %v; want %v", gain, want)
+	}
+	if got := dsp.MeasurePeakDB(s); !testutil.EqualWithinTolerance(got, -1, 1e-5) {
+		t.Errorf("expected a peak of -1 dBFS, got %v dBFS", got)
+	}
+
+	silence := make([]float32, 10)
+	if gain := dsp.NormalizePeak(silence, -1); gain != 1 {
+		t.Errorf("NormalizePeak(silence) = %v; want 1", gain)
+	}
+	for _, x := range silence {
+		if x != 0 {
+			t.Fatalf("expected silence to stay silent, got %v", silence)
+		}
+	}
+}
+
+func TestGoertzel(t *testing.T) {
+	const (
+		n          = 200
+		sampleRate = 8 * freq.KiloHertz
+	)
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = 0.5*math.Sin(2*math.Pi*1000*float64(i)/8000) + 0.1
+	}
+
+	// compare with a naive DFT at frequencies on and off the bins
+	for _, f := range []freq.Frequency{0, 697 * freq.Hertz, 1000 * freq.Hertz, 1633 * freq.Hertz, 3000 * freq.Hertz} {
+		var x complex128
+		for i, v := range s {
+			x += complex(v, 0) * cmplx.Exp(complex(0, -2*math.Pi*f.Hertz()*float64(i)/8000))
+		}
+		want := real(x)*real(x) + imag(x)*imag(x)
+		if got := dsp.Goertzel(s, sampleRate, f); !testutil.EqualWithinTolerance(got, want, 1e-6*max(want, 1)) {
+			t.Errorf("Goertzel(%v) = %v; want %v", f, got, want)
+		}
+	}
+
+	// 25 whole periods
+	if got, want := dsp.Goertzel(s, sampleRate, freq.KiloHertz), math.Pow(0.5*n/2, 2); !testutil.EqualWithinTolerance(got, want, 1e-6) {
+		t.Errorf("Goertzel(1 kHz) = %v; want %v", got, want)
+	}
+}
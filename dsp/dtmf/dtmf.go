@@ -0,0 +1,241 @@
+// Package dtmf implements detection of DTMF (Dual-Tone Multi-Frequency) telephone signaling.
+//
+// Every DTMF digit is the sum of one tone from the low (row) group and one from the high (column) group:
+//
+//	        1209 Hz  1336 Hz  1477 Hz  1633 Hz
+//	697 Hz     1        2        3        A
+//	770 Hz     4        5        6        B
+//	852 Hz     7        8        9        C
+//	941 Hz     *        0        #        D
+//
+// The [Detector] looks for these tones with the Goertzel algorithm (see [dsp.Goertzel]).
+package dtmf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// Frequencies of the low (row) and high (column) tone groups.
+var (
+	RowFrequencies    = [4]freq.Frequency{697 * freq.Hertz, 770 * freq.Hertz, 852 * freq.Hertz, 941 * freq.Hertz}
+	ColumnFrequencies = [4]freq.Frequency{1209 * freq.Hertz, 1336 * freq.Hertz, 1477 * freq.Hertz, 1633 * freq.Hertz}
+)
+
+var digits = [4][4]rune{
+	{'1', '2', '3', 'A'},
+	{'4', '5', '6', 'B'},
+	{'7', '8', '9', 'C'},
+	{'*', '0', '#', 'D'},
+}
+
+// Frequencies returns the row and column frequencies of the DTMF digit d.
+// It reports false if d is not a DTMF digit.
+func Frequencies(d rune) (row, col freq.Frequency, ok bool) {
+	for i, r := range digits {
+		for j, c := range r {
+			if c == d {
+				return RowFrequencies[i], ColumnFrequencies[j], true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// Tone represents a detected DTMF digit.
+type Tone struct {
+	// Digit is the detected digit: '0' to '9', '*', '#' or 'A' to 'D'.
+	Digit rune
+
+	// Start is the time the digit started, relative to the start of the stream.
+	// It is accurate to one block (about 13 ms).
+	Start time.Duration
+}
+
+func (t Tone) String() string {
+	return fmt.Sprintf("%c@%v", t.Digit, t.Start)
+}
+
+const (
+	// blockSize is the length of a detection block at 8 kHz.
+	// 105 samples give bins about 76 Hz apart, enough to tell the tones apart, in about 13 ms.
+	blockSize = 105
+
+	minAmplitude  = 0.01 // about -40 dBFS for each tone
+	normalTwist   = 6.3  // column tone up to 8 dB stronger than the row tone
+	reverseTwist  = 2.5  // row tone up to 4 dB stronger than the column tone
+	relativePeak  = 6.3  // the strongest tone of a group must be 8 dB above the others
+	maxHarmonic   = 0.5  // the second harmonic must be 3 dB below the fundamental
+	minToneEnergy = 0.8  // the two tones must make up most of the signal energy
+	minSampleRate = 7000 * freq.Hertz
+	defaultRateHz = 8000.0
+)
+
+var _ aio.SampleReader = (*Detector)(nil)
+
+// Detector wraps an aio.SampleReader of mono audio and detects DTMF digits in the samples read through it.
+//
+// Samples are analyzed in blocks of 105 samples at 8 kHz, or the same duration at other sample rates.
+// A digit is reported once it is present for two consecutive blocks;
+// a digit is reported again only after a pause or another digit.
+type Detector struct {
+	r          aio.SampleReader
+	sampleRate freq.Frequency
+	fn         func(Tone)
+	err        error
+
+	block []float64
+	n     int // samples in block
+	pos   int // blocks analyzed
+
+	last    rune // digit of the previous block, 0 if none
+	current rune // digit currently reported, 0 if none
+	misses  int  // consecutive blocks without the current digit
+}
+
+// NewDetector creates a new [Detector] reading from r and calling fn for every detected digit.
+// fn is called from [Detector.ReadSamples].
+func NewDetector(r aio.SampleReader, sampleRate freq.Frequency, fn func(Tone)) *Detector {
+	d := &Detector{
+		r:          r,
+		sampleRate: sampleRate,
+		fn:         fn,
+	}
+	if sampleRate < minSampleRate {
+		// the second harmonics of the column tones must lie below the Nyquist frequency
+		d.err = fmt.Errorf("dtmf: sample rate too low: %v", sampleRate)
+		return d
+	}
+	d.block = make([]float64, max(1, int(blockSize*sampleRate.Hertz()/defaultRateHz+0.5)))
+	return d
+}
+
+// Detect reads r to the end and returns the detected digits.
+func Detect(r aio.SampleReader, sampleRate freq.Frequency) ([]Tone, error) {
+	var tones []Tone
+	d := NewDetector(r, sampleRate, func(t Tone) {
+		tones = append(tones, t)
+	})
+	buf := make([]float32, 4096)
+	for {
+		_, err := d.ReadSamples(buf)
+		if errors.Is(err, io.EOF) {
+			return tones, nil
+		}
+		if err != nil {
+			return tones, err
+		}
+	}
+}
+
+// ReadSamples reads samples into p, unchanged, and analyzes them.
+// It returns the number of samples read and/or an error.
+func (d *Detector) ReadSamples(p []float32) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	n, err := d.r.ReadSamples(p)
+	for _, s := range p[:n] {
+		d.block[d.n] = float64(s)
+		d.n++
+		if d.n == len(d.block) {
+			d.analyze()
+			d.n = 0
+		}
+	}
+	return n, err
+}
+
+// analyze runs detection on a full block.
+func (d *Detector) analyze() {
+	digit := d.detect()
+	d.pos++
+
+	switch {
+	case digit != 0 && digit == d.current:
+		d.misses = 0
+	case digit != 0 && digit == d.last:
+		// two blocks in a row: a new digit, which started in the previous block
+		d.current = digit
+		d.misses = 0
+		if d.fn != nil {
+			d.fn(Tone{Digit: digit, Start: d.blockStart(d.pos - 2)})
+		}
+	default:
+		// a single bad block does not end a digit
+		d.misses++
+		if d.misses >= 2 {
+			d.current = 0
+		}
+	}
+	d.last = digit
+}
+
+// blockStart returns the start time of the i-th block.
+func (d *Detector) blockStart(i int) time.Duration {
+	return time.Duration(float64(i*len(d.block)) / d.sampleRate.Hertz() * float64(time.Second))
+}
+
+// detect returns the digit present in the current block, or 0 if there is none.
+func (d *Detector) detect() rune {
+	var rows, cols [4]float64
+	for i := range 4 {
+		rows[i] = dsp.Goertzel(d.block, d.sampleRate, RowFrequencies[i])
+		cols[i] = dsp.Goertzel(d.block, d.sampleRate, ColumnFrequencies[i])
+	}
+	row, col := strongest(rows), strongest(cols)
+	rowPower, colPower := rows[row], cols[col]
+
+	// a tone with amplitude A gives a power of about (A*N/2)²
+	n := float64(len(d.block))
+	minPower := minAmplitude * minAmplitude * n * n / 4
+	if rowPower < minPower || colPower < minPower {
+		return 0
+	}
+
+	// twist: the levels of the two tones must be similar
+	if colPower > rowPower*normalTwist || rowPower > colPower*reverseTwist {
+		return 0
+	}
+
+	// the tones must stand out from the rest of their group
+	for i := range 4 {
+		if (i != row && rows[i]*relativePeak > rowPower) || (i != col && cols[i]*relativePeak > colPower) {
+			return 0
+		}
+	}
+
+	// the tones must be pure, unlike speech or music
+	if dsp.Goertzel(d.block, d.sampleRate, 2*RowFrequencies[row]) > rowPower*maxHarmonic ||
+		dsp.Goertzel(d.block, d.sampleRate, 2*ColumnFrequencies[col]) > colPower*maxHarmonic {
+		return 0
+	}
+
+	// and make up most of the signal: the energy of a sine is N/2 times its power over the Goertzel power
+	var energy float64
+	for _, x := range d.block {
+		energy += x * x
+	}
+	if (rowPower+colPower)*2/n < minToneEnergy*energy {
+		return 0
+	}
+
+	return digits[row][col]
+}
+
+func strongest(p [4]float64) int {
+	best := 0
+	for i, v := range p {
+		if v > p[best] {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,140 @@
+package dtmf_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp/dtmf"
+	"github.com/MatusOllah/resona/freq"
+)
+
+const allDigits = "123A456B789C*0#D"
+
+// dialTones returns the given digits as DTMF tones of toneLen, each followed by a pause of the same length.
+// Every tone has the given amplitude.
+func dialTones(t *testing.T, digits string, sampleRate freq.Frequency, toneLen time.Duration, amplitude float64) []float32 {
+	t.Helper()
+
+	n := int(toneLen.Seconds() * sampleRate.Hertz())
+	var p []float32
+	for _, d := range digits {
+		row, col, ok := dtmf.Frequencies(d)
+		if !ok {
+			t.Fatalf("invalid digit %q", d)
+		}
+		for i := range n {
+			x := float64(i) / sampleRate.Hertz()
+			p = append(p, float32(amplitude*(math.Sin(2*math.Pi*row.Hertz()*x)+math.Sin(2*math.Pi*col.Hertz()*x))))
+		}
+		p = append(p, make([]float32, n)...)
+	}
+	return p
+}
+
+// addNoise adds white Gaussian noise to p at the given signal-to-noise ratio, for a signal of two tones with the given amplitude.
+func addNoise(p []float32, amplitude, snrDB float64) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	sigma := math.Sqrt(amplitude * amplitude / math.Pow(10, snrDB/10))
+	for i := range p {
+		p[i] += float32(sigma * rng.NormFloat64())
+	}
+}
+
+func detect(t *testing.T, p []float32, sampleRate freq.Frequency) []dtmf.Tone {
+	t.Helper()
+
+	tones, err := dtmf.Detect(audio.NewBuffer(p), sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tones
+}
+
+func checkTones(t *testing.T, tones []dtmf.Tone, digits string, toneLen time.Duration) {
+	t.Helper()
+
+	var got []rune
+	for _, tone := range tones {
+		got = append(got, tone.Digit)
+	}
+	if !slices.Equal(got, []rune(digits)) {
+		t.Fatalf("expected %q, got %q", digits, string(got))
+	}
+
+	for i, tone := range tones {
+		want := time.Duration(2*i) * toneLen
+		if d := tone.Start - want; d < -15*time.Millisecond || d > 15*time.Millisecond {
+			t.Errorf("digit %c: expected start at %v, got %v", tone.Digit, want, tone.Start)
+		}
+	}
+}
+
+func TestDetector(t *testing.T) {
+	for _, sampleRate := range []freq.Frequency{8 * freq.KiloHertz, 44100 * freq.Hertz} {
+		t.Run(sampleRate.String(), func(t *testing.T) {
+			p := dialTones(t, allDigits, sampleRate, 50*time.Millisecond, 0.25)
+			checkTones(t, detect(t, p, sampleRate), allDigits, 50*time.Millisecond)
+		})
+	}
+}
+
+func TestDetectorNoise(t *testing.T) {
+	for _, snr := range []float64{30, 20, 10} {
+		t.Run(fmt.Sprintf("%vdB", snr), func(t *testing.T) {
+			p := dialTones(t, allDigits, 8*freq.KiloHertz, 50*time.Millisecond, 0.25)
+			addNoise(p, 0.25, snr)
+			checkTones(t, detect(t, p, 8*freq.KiloHertz), allDigits, 50*time.Millisecond)
+		})
+	}
+}
+
+func TestDetectorReject(t *testing.T) {
+	const sampleRate = 8 * freq.KiloHertz
+
+	// noise alone
+	noise := make([]float32, 8000)
+	addNoise(noise, 0.25, 0)
+	if tones := detect(t, noise, sampleRate); len(tones) != 0 {
+		t.Errorf("noise: expected no digits, got %v", tones)
+	}
+
+	// tones too short to be held for two blocks
+	if tones := detect(t, dialTones(t, allDigits, sampleRate, 15*time.Millisecond, 0.25), sampleRate); len(tones) != 0 {
+		t.Errorf("short tones: expected no digits, got %v", tones)
+	}
+
+	// too quiet
+	if tones := detect(t, dialTones(t, allDigits, sampleRate, 50*time.Millisecond, 0.001), sampleRate); len(tones) != 0 {
+		t.Errorf("quiet tones: expected no digits, got %v", tones)
+	}
+
+	// strong second harmonics, as in speech
+	p := dialTones(t, "5", sampleRate, 100*time.Millisecond, 0.25)
+	for i := range p[:800] {
+		x := float64(i) / sampleRate.Hertz()
+		p[i] += float32(0.25 * (math.Sin(2*math.Pi*2*770*x) + math.Sin(2*math.Pi*2*1336*x)))
+	}
+	if tones := detect(t, p, sampleRate); len(tones) != 0 {
+		t.Errorf("harmonics: expected no digits, got %v", tones)
+	}
+
+	// a single tone
+	single := make([]float32, 800)
+	for i := range single {
+		single[i] = float32(0.25 * math.Sin(2*math.Pi*1336*float64(i)/sampleRate.Hertz()))
+	}
+	if tones := detect(t, single, sampleRate); len(tones) != 0 {
+		t.Errorf("single tone: expected no digits, got %v", tones)
+	}
+}
+
+func TestDetectorInvalid(t *testing.T) {
+	if _, err := dtmf.Detect(audio.NewBuffer(nil), 4*freq.KiloHertz); err == nil {
+		t.Error("expected error for a sample rate too low")
+	}
+}
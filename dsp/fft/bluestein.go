@@ -0,0 +1,68 @@
+package fft
+
+import "math"
+
+// bluestein computes transforms of arbitrary size n as a convolution,
+// which is in turn computed with power-of-two transforms of size m >= 2n-1.
+type bluestein struct {
+	n       int
+	chirp   []complex128 // exp(-πi k²/n)
+	filter  []complex128 // transform of the conjugate chirp, wrapped around, scaled by 1/m
+	sub     *Complex
+	scratch []complex128
+}
+
+func newBluestein(n int) *bluestein {
+	m := 1
+	for m < 2*n-1 {
+		m *= 2
+	}
+
+	b := &bluestein{
+		n:       n,
+		chirp:   make([]complex128, n),
+		filter:  make([]complex128, m),
+		sub:     NewComplex(m),
+		scratch: make([]complex128, m),
+	}
+	for k := range n {
+		// k² mod 2n keeps the angle small, which keeps it accurate for large k
+		k2 := (k * k) % (2 * n)
+		b.chirp[k] = expi(-math.Pi * float64(k2) / float64(n))
+	}
+
+	b.filter[0] = conj(b.chirp[0])
+	for k := 1; k < n; k++ {
+		b.filter[k] = conj(b.chirp[k])
+		b.filter[m-k] = conj(b.chirp[k])
+	}
+	b.sub.Forward(b.filter, b.filter)
+	scale := 1 / float64(m)
+	for i := range b.filter {
+		b.filter[i] *= complex(scale, 0)
+	}
+	return b
+}
+
+func (b *bluestein) transform(dst, src []complex128) {
+	a := b.scratch
+	for k, v := range src {
+		a[k] = v * b.chirp[k]
+	}
+	clear(a[b.n:])
+
+	// convolve with the chirp: IDFT(DFT(a) * DFT(filter)), using the conjugate trick for the inverse
+	b.sub.Forward(a, a)
+	for i := range a {
+		a[i] = conj(a[i] * b.filter[i])
+	}
+	b.sub.Forward(a, a)
+
+	for k := range dst {
+		dst[k] = conj(a[k]) * b.chirp[k]
+	}
+}
+
+func conj(v complex128) complex128 {
+	return complex(real(v), -imag(v))
+}
@@ -0,0 +1,156 @@
+package fft
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Complex is a plan for complex-to-complex transforms of a fixed size.
+type Complex struct {
+	n        int
+	twiddles []complex128 // exp(-2πi k/n) for k < n/2 (power-of-two sizes)
+	bitrev   []int        // bit-reversal permutation (power-of-two sizes)
+	bs       *bluestein   // used for other sizes
+}
+
+// NewComplex returns a plan for complex transforms of size n.
+// It panics if n < 1.
+func NewComplex(n int) *Complex {
+	if n < 1 {
+		panic("fft: invalid size")
+	}
+
+	p := &Complex{n: n}
+	if !isPowerOfTwo(n) {
+		p.bs = newBluestein(n)
+		return p
+	}
+
+	p.twiddles = make([]complex128, n/2)
+	for k := range p.twiddles {
+		p.twiddles[k] = expi(-2 * math.Pi * float64(k) / float64(n))
+	}
+
+	p.bitrev = make([]int, n)
+	shift := bits.UintSize - bits.Len(uint(n)) + 1
+	for i := range p.bitrev {
+		if n > 1 {
+			p.bitrev[i] = int(bits.Reverse(uint(i)) >> shift)
+		}
+	}
+	return p
+}
+
+// Len returns the size of the transform.
+func (p *Complex) Len() int {
+	return p.n
+}
+
+// Forward computes the discrete Fourier transform of src into dst.
+// dst and src must have a length of p.Len() and may be the same slice.
+func (p *Complex) Forward(dst, src []complex128) {
+	p.check(dst, src)
+	if p.bs != nil {
+		p.bs.transform(dst, src)
+		return
+	}
+	p.permute(dst, src)
+	p.radix4(dst)
+}
+
+// Inverse computes the inverse discrete Fourier transform of src into dst, scaled by 1/n.
+// dst and src must have a length of p.Len() and may be the same slice.
+func (p *Complex) Inverse(dst, src []complex128) {
+	p.check(dst, src)
+
+	// IDFT(x) = conj(DFT(conj(x))) / n
+	if p.bs != nil {
+		conjInto(dst, src)
+		p.bs.transform(dst, dst)
+	} else {
+		p.permute(dst, src)
+		conjInto(dst, dst)
+		p.radix4(dst)
+	}
+
+	scale := 1 / float64(p.n)
+	for i, v := range dst {
+		dst[i] = complex(real(v)*scale, -imag(v)*scale)
+	}
+}
+
+func (p *Complex) check(dst, src []complex128) {
+	if len(dst) != p.n || len(src) != p.n {
+		panic("fft: slice length does not match the size of the transform")
+	}
+}
+
+// permute copies src into dst in bit-reversed order.
+func (p *Complex) permute(dst, src []complex128) {
+	if &dst[0] == &src[0] {
+		for i, j := range p.bitrev {
+			if i < j {
+				dst[i], dst[j] = dst[j], dst[i]
+			}
+		}
+		return
+	}
+	for i, j := range p.bitrev {
+		dst[j] = src[i]
+	}
+}
+
+// radix4 computes the transform of x, which is in bit-reversed order, in place.
+// Pairs of radix-2 stages are fused into radix-4 butterflies, with a single radix-2 stage first if log2(n) is odd.
+func (p *Complex) radix4(x []complex128) {
+	n := p.n
+	m := 1 // size of the sub-transforms already done
+
+	if bits.TrailingZeros(uint(n))%2 == 1 {
+		for i := 0; i < n; i += 2 {
+			a, b := x[i], x[i+1]
+			x[i], x[i+1] = a+b, a-b
+		}
+		m = 2
+	}
+
+	for ; m < n; m *= 4 {
+		// combine four sub-transforms of size m into one of size 4m
+		stride := n / (4 * m)
+		for start := 0; start < n; start += 4 * m {
+			for k := range m {
+				w := p.twiddles[k*stride]    // exp(-2πi k/4m)
+				w2 := p.twiddles[2*k*stride] // exp(-2πi k/2m)
+
+				a := x[start+k]
+				b := x[start+k+m] * w2
+				c := x[start+k+2*m]
+				d := x[start+k+3*m] * w2
+
+				a0, a1 := a+b, a-b
+				c0, c1 := (c+d)*w, (c-d)*w
+				c1 = complex(imag(c1), -real(c1)) // -i * c1
+
+				x[start+k] = a0 + c0
+				x[start+k+2*m] = a0 - c0
+				x[start+k+m] = a1 + c1
+				x[start+k+3*m] = a1 - c1
+			}
+		}
+	}
+}
+
+func conjInto(dst, src []complex128) {
+	for i, v := range src {
+		dst[i] = complex(real(v), -imag(v))
+	}
+}
+
+func expi(theta float64) complex128 {
+	s, c := math.Sincos(theta)
+	return complex(c, s)
+}
+
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
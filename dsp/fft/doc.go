@@ -0,0 +1,13 @@
+// Package fft implements fast Fourier transforms of any size.
+//
+// A transform of a given size is set up once with [NewComplex] or [NewReal], which precompute
+// the twiddle factors and allocate scratch space, so that repeated transforms do not allocate.
+// Power-of-two sizes use an iterative radix-2/4 algorithm, other sizes use Bluestein's algorithm
+// on top of a larger power-of-two transform.
+//
+// The forward transform is unnormalized and the inverse transform is scaled by 1/n,
+// so that Inverse(Forward(x)) == x.
+//
+// Plans are not safe for concurrent use, as they share scratch space between calls.
+// Use a separate plan per goroutine.
+package fft
@@ -0,0 +1,182 @@
+package fft_test
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/MatusOllah/resona/dsp/fft"
+)
+
+var sizes = []int{1, 2, 3, 4, 5, 6, 7, 8, 12, 15, 16, 17, 31, 32, 64, 100, 127, 128, 243, 256, 1000, 1024}
+
+func naiveDFT(x []complex128) []complex128 {
+	n := len(x)
+	y := make([]complex128, n)
+	for k := range n {
+		var sum complex128
+		for j, v := range x {
+			// reduce the angle first to keep it accurate
+			sum += v * cmplx.Exp(complex(0, -2*math.Pi*float64((j*k)%n)/float64(n)))
+		}
+		y[k] = sum
+	}
+	return y
+}
+
+func randomComplex(rng *rand.Rand, n int) []complex128 {
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(rng.Float64()*2-1, rng.Float64()*2-1)
+	}
+	return x
+}
+
+// maxError returns the largest absolute difference between a and b, relative to the largest magnitude in b.
+func maxError(a, b []complex128) float64 {
+	var diff, scale float64
+	for i := range a {
+		diff = max(diff, cmplx.Abs(a[i]-b[i]))
+		scale = max(scale, cmplx.Abs(b[i]))
+	}
+	return diff / max(scale, 1)
+}
+
+func TestComplexForward(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, n := range sizes {
+		x := randomComplex(rng, n)
+		want := naiveDFT(x)
+
+		got := make([]complex128, n)
+		fft.NewComplex(n).Forward(got, x)
+		if err := maxError(got, want); err > 1e-12 {
+			t.Errorf("n = %d: relative error %g", n, err)
+		}
+	}
+}
+
+func TestComplexRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for _, n := range sizes {
+		x := randomComplex(rng, n)
+		p := fft.NewComplex(n)
+
+		// in place
+		y := make([]complex128, n)
+		copy(y, x)
+		p.Forward(y, y)
+		p.Inverse(y, y)
+		if err := maxError(y, x); err > 1e-13 {
+			t.Errorf("n = %d: relative round-trip error %g", n, err)
+		}
+	}
+}
+
+func TestComplexImpulse(t *testing.T) {
+	// the transform of a unit impulse at 1 is exp(-2πi k/n)
+	const n = 12
+	x := make([]complex128, n)
+	x[1] = 1
+	y := make([]complex128, n)
+	fft.NewComplex(n).Forward(y, x)
+	for k, v := range y {
+		want := cmplx.Exp(complex(0, -2*math.Pi*float64(k)/n))
+		if cmplx.Abs(v-want) > 1e-14 {
+			t.Errorf("bin %d: expected %v, got %v", k, want, v)
+		}
+	}
+}
+
+func TestReal(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	for _, n := range sizes {
+		x := make([]float64, n)
+		cx := make([]complex128, n)
+		for i := range x {
+			x[i] = rng.Float64()*2 - 1
+			cx[i] = complex(x[i], 0)
+		}
+		want := naiveDFT(cx)[:n/2+1]
+
+		p := fft.NewReal(n)
+		got := make([]complex128, n/2+1)
+		p.Forward(got, x)
+		if err := maxError(got, want); err > 1e-12 {
+			t.Errorf("n = %d: relative error %g", n, err)
+		}
+
+		back := make([]float64, n)
+		p.Inverse(back, got)
+		for i := range x {
+			if math.Abs(back[i]-x[i]) > 1e-13 {
+				t.Errorf("n = %d: round trip: sample %d: expected %v, got %v", n, i, x[i], back[i])
+				break
+			}
+		}
+	}
+}
+
+func TestNoAllocs(t *testing.T) {
+	for _, n := range []int{1024, 1000} {
+		c := fft.NewComplex(n)
+		x := make([]complex128, n)
+		if allocs := testing.AllocsPerRun(10, func() { c.Forward(x, x); c.Inverse(x, x) }); allocs != 0 {
+			t.Errorf("n = %d: complex transform allocates %v times", n, allocs)
+		}
+
+		r := fft.NewReal(n)
+		in := make([]float64, n)
+		out := make([]complex128, n/2+1)
+		if allocs := testing.AllocsPerRun(10, func() { r.Forward(out, in); r.Inverse(in, out) }); allocs != 0 {
+			t.Errorf("n = %d: real transform allocates %v times", n, allocs)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for name, f := range map[string]func(){
+		"NewComplex": func() { fft.NewComplex(0) },
+		"NewReal":    func() { fft.NewReal(-1) },
+		"Forward":    func() { fft.NewComplex(8).Forward(make([]complex128, 8), make([]complex128, 4)) },
+		"RealInverse": func() {
+			fft.NewReal(8).Inverse(make([]float64, 8), make([]complex128, 8))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func benchmarkComplex(b *testing.B, n int) {
+	p := fft.NewComplex(n)
+	x := randomComplex(rand.New(rand.NewPCG(1, 2)), n)
+	for b.Loop() {
+		p.Forward(x, x)
+	}
+}
+
+func benchmarkReal(b *testing.B, n int) {
+	p := fft.NewReal(n)
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = math.Sin(float64(i))
+	}
+	y := make([]complex128, n/2+1)
+	for b.Loop() {
+		p.Forward(y, x)
+	}
+}
+
+func BenchmarkComplex1024(b *testing.B)          { benchmarkComplex(b, 1024) }
+func BenchmarkComplex4096(b *testing.B)          { benchmarkComplex(b, 4096) }
+func BenchmarkComplexBluestein1000(b *testing.B) { benchmarkComplex(b, 1000) }
+func BenchmarkReal1024(b *testing.B)             { benchmarkReal(b, 1024) }
+func BenchmarkReal4096(b *testing.B)             { benchmarkReal(b, 4096) }
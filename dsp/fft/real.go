@@ -0,0 +1,124 @@
+package fft
+
+import "math"
+
+// Real is a plan for transforms of real signals of a fixed size.
+//
+// The transform of a real signal of size n is Hermitian-symmetric,
+// so only the first n/2+1 coefficients are computed.
+type Real struct {
+	n        int
+	sub      *Complex     // of size n/2 for even n, or n for odd n
+	twiddles []complex128 // exp(-2πi k/n) for k < n/2 (even sizes)
+	scratch  []complex128
+}
+
+// NewReal returns a plan for real transforms of size n.
+// It panics if n < 1.
+func NewReal(n int) *Real {
+	if n < 1 {
+		panic("fft: invalid size")
+	}
+
+	p := &Real{n: n}
+	if n%2 == 1 {
+		// no packing trick for odd sizes, do a complex transform
+		p.sub = NewComplex(n)
+		p.scratch = make([]complex128, n)
+		return p
+	}
+
+	h := n / 2
+	p.sub = NewComplex(h)
+	p.scratch = make([]complex128, h)
+	p.twiddles = make([]complex128, h)
+	for k := range p.twiddles {
+		p.twiddles[k] = expi(-2 * math.Pi * float64(k) / float64(n))
+	}
+	return p
+}
+
+// Len returns the size of the transform.
+func (p *Real) Len() int {
+	return p.n
+}
+
+// Forward computes the discrete Fourier transform of the real signal src into dst.
+// src must have a length of p.Len() and dst a length of p.Len()/2+1.
+func (p *Real) Forward(dst []complex128, src []float64) {
+	if len(src) != p.n || len(dst) != p.n/2+1 {
+		panic("fft: slice length does not match the size of the transform")
+	}
+
+	z := p.scratch
+	if p.n%2 == 1 {
+		for i, v := range src {
+			z[i] = complex(v, 0)
+		}
+		p.sub.Forward(z, z)
+		copy(dst, z)
+		return
+	}
+
+	// transform the even samples as the real part and the odd samples as the imaginary part, then separate them
+	h := p.n / 2
+	for k := range h {
+		z[k] = complex(src[2*k], src[2*k+1])
+	}
+	p.sub.Forward(z, z)
+
+	z0 := z[0]
+	dst[0] = complex(real(z0)+imag(z0), 0)
+	dst[h] = complex(real(z0)-imag(z0), 0)
+	for k := 1; k < h; k++ {
+		zk, zr := z[k], conj(z[h-k])
+		even := 0.5 * (zk + zr)
+		d := zk - zr
+		odd := complex(0.5*imag(d), -0.5*real(d)) // (zk - zr) / 2i
+		dst[k] = even + p.twiddles[k]*odd
+	}
+}
+
+// Inverse computes the inverse discrete Fourier transform of src into the real signal dst, scaled by 1/n.
+// src must have a length of p.Len()/2+1 and dst a length of p.Len().
+// The imaginary parts of src[0] and, for even sizes, src[p.Len()/2] are ignored.
+func (p *Real) Inverse(dst []float64, src []complex128) {
+	if len(dst) != p.n || len(src) != p.n/2+1 {
+		panic("fft: slice length does not match the size of the transform")
+	}
+
+	z := p.scratch
+	if p.n%2 == 1 {
+		// rebuild the full Hermitian-symmetric spectrum
+		z[0] = complex(real(src[0]), 0)
+		for k := 1; k < len(src); k++ {
+			z[k] = src[k]
+			z[p.n-k] = conj(src[k])
+		}
+		p.sub.Inverse(z, z)
+		for i, v := range z {
+			dst[i] = real(v)
+		}
+		return
+	}
+
+	h := p.n / 2
+	x0 := complex(real(src[0]), 0)
+	xh := complex(real(src[h]), 0)
+	for k := range h {
+		xk := src[k]
+		xr := conj(src[h-k])
+		if k == 0 {
+			xk, xr = x0, xh
+		}
+		even := (xk + xr) * 0.5
+		odd := (xk - xr) * 0.5 * conj(p.twiddles[k])
+		z[k] = even + complex(0, 1)*odd
+	}
+	p.sub.Inverse(z, z)
+
+	for k, v := range z {
+		dst[2*k] = real(v)
+		dst[2*k+1] = imag(v)
+	}
+}
@@ -0,0 +1,246 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/MatusOllah/resona/freq"
+)
+
+// Biquad represents a second-order IIR (Infinite Impulse Response) filter,
+// implemented in transposed direct form II.
+//
+// The constructors compute the coefficients with the formulas from
+// Robert Bristow-Johnson's Audio EQ Cookbook. They panic if the frequency is not between 0 and
+// the Nyquist frequency, or if q or slope is not positive.
+type Biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64 // normalized so that a0 == 1
+	z1, z2     float64
+}
+
+// NewBiquad creates a new [Biquad] filter from the given coefficients.
+// The coefficients are normalized by a0.
+func NewBiquad(b0, b1, b2, a0, a1, a2 float64) *Biquad {
+	return &Biquad{
+		b0: b0 / a0,
+		b1: b1 / a0,
+		b2: b2 / a0,
+		a1: a1 / a0,
+		a2: a2 / a0,
+	}
+}
+
+// biquadParams returns the cosine and sine of the normalized angular frequency.
+func biquadParams(f, sampleRate freq.Frequency) (cos, sin float64) {
+	if f <= 0 || f >= sampleRate/2 {
+		panic("filter: frequency out of range")
+	}
+	w0 := 2 * math.Pi * f.Hertz() / sampleRate.Hertz()
+	sin, cos = math.Sincos(w0)
+	return cos, sin
+}
+
+func checkQ(q float64) {
+	if !(q > 0) {
+		panic("filter: q must be positive")
+	}
+}
+
+// NewLowpass creates a new second-order low-pass [Biquad] filter.
+// A q of 1/√2 gives a Butterworth response, which is 3 dB down at the cutoff frequency.
+func NewLowpass(cutoff, sampleRate freq.Frequency, q float64) *Biquad {
+	checkQ(q)
+	cos, sin := biquadParams(cutoff, sampleRate)
+	alpha := sin / (2 * q)
+	return NewBiquad((1-cos)/2, 1-cos, (1-cos)/2, 1+alpha, -2*cos, 1-alpha)
+}
+
+// NewHighpass creates a new second-order high-pass [Biquad] filter.
+// A q of 1/√2 gives a Butterworth response, which is 3 dB down at the cutoff frequency.
+func NewHighpass(cutoff, sampleRate freq.Frequency, q float64) *Biquad {
+	checkQ(q)
+	cos, sin := biquadParams(cutoff, sampleRate)
+	alpha := sin / (2 * q)
+	return NewBiquad((1+cos)/2, -(1 + cos), (1+cos)/2, 1+alpha, -2*cos, 1-alpha)
+}
+
+// NewBandpass creates a new band-pass [Biquad] filter with a peak gain of 0 dB at the center frequency.
+func NewBandpass(center, sampleRate freq.Frequency, q float64) *Biquad {
+	checkQ(q)
+	cos, sin := biquadParams(center, sampleRate)
+	alpha := sin / (2 * q)
+	return NewBiquad(alpha, 0, -alpha, 1+alpha, -2*cos, 1-alpha)
+}
+
+// NewNotch creates a new notch (band-stop) [Biquad] filter.
+func NewNotch(center, sampleRate freq.Frequency, q float64) *Biquad {
+	checkQ(q)
+	cos, sin := biquadParams(center, sampleRate)
+	alpha := sin / (2 * q)
+	return NewBiquad(1, -2*cos, 1, 1+alpha, -2*cos, 1-alpha)
+}
+
+// NewAllpass creates a new all-pass [Biquad] filter, which shifts the phase by 180° at the center frequency.
+func NewAllpass(center, sampleRate freq.Frequency, q float64) *Biquad {
+	checkQ(q)
+	cos, sin := biquadParams(center, sampleRate)
+	alpha := sin / (2 * q)
+	return NewBiquad(1-alpha, -2*cos, 1+alpha, 1+alpha, -2*cos, 1-alpha)
+}
+
+// NewPeaking creates a new peaking EQ [Biquad] filter, which boosts or cuts by gainDB at the center frequency.
+func NewPeaking(center, sampleRate freq.Frequency, q, gainDB float64) *Biquad {
+	checkQ(q)
+	cos, sin := biquadParams(center, sampleRate)
+	alpha := sin / (2 * q)
+	a := math.Pow(10, gainDB/40)
+	return NewBiquad(1+alpha*a, -2*cos, 1-alpha*a, 1+alpha/a, -2*cos, 1-alpha/a)
+}
+
+// shelfParams returns the parameters common to both shelving filters: A, cos(w0) and 2*sqrt(A)*alpha.
+func shelfParams(f, sampleRate freq.Frequency, slope, gainDB float64) (a, cos, k float64) {
+	if !(slope > 0) {
+		panic("filter: slope must be positive")
+	}
+	cos, sin := biquadParams(f, sampleRate)
+	a = math.Pow(10, gainDB/40)
+	v := (a+1/a)*(1/slope-1) + 2
+	if v < 0 {
+		panic("filter: shelf slope too steep for the gain")
+	}
+	alpha := sin / 2 * math.Sqrt(v)
+	return a, cos, 2 * math.Sqrt(a) * alpha
+}
+
+// NewLowShelf creates a new low-shelf [Biquad] filter, which boosts or cuts frequencies below the corner frequency by gainDB.
+// A slope of 1 is the steepest slope without overshoot; steeper slopes are only possible with small gains.
+func NewLowShelf(corner, sampleRate freq.Frequency, slope, gainDB float64) *Biquad {
+	a, cos, k := shelfParams(corner, sampleRate, slope, gainDB)
+	return NewBiquad(
+		a*((a+1)-(a-1)*cos+k),
+		2*a*((a-1)-(a+1)*cos),
+		a*((a+1)-(a-1)*cos-k),
+		(a+1)+(a-1)*cos+k,
+		-2*((a-1)+(a+1)*cos),
+		(a+1)+(a-1)*cos-k,
+	)
+}
+
+// NewHighShelf creates a new high-shelf [Biquad] filter, which boosts or cuts frequencies above the corner frequency by gainDB.
+// A slope of 1 is the steepest slope without overshoot; steeper slopes are only possible with small gains.
+func NewHighShelf(corner, sampleRate freq.Frequency, slope, gainDB float64) *Biquad {
+	a, cos, k := shelfParams(corner, sampleRate, slope, gainDB)
+	return NewBiquad(
+		a*((a+1)+(a-1)*cos+k),
+		-2*a*((a-1)+(a+1)*cos),
+		a*((a+1)+(a-1)*cos-k),
+		(a+1)-(a-1)*cos+k,
+		2*((a-1)-(a+1)*cos),
+		(a+1)-(a-1)*cos-k,
+	)
+}
+
+// BiquadType represents the kind of response of a [Biquad] filter.
+type BiquadType int
+
+const (
+	Lowpass BiquadType = iota
+	Highpass
+	Bandpass
+	Notch
+	Allpass
+	Peaking
+	LowShelf
+	HighShelf
+)
+
+var biquadTypeNames = [...]string{"Lowpass", "Highpass", "Bandpass", "Notch", "Allpass", "Peaking", "LowShelf", "HighShelf"}
+
+// String returns the name of the type.
+func (t BiquadType) String() string {
+	if t < 0 || int(t) >= len(biquadTypeNames) {
+		return fmt.Sprintf("BiquadType(%d)", int(t))
+	}
+	return biquadTypeNames[t]
+}
+
+// HasGain reports whether filters of the type take a gain, which is true for [Peaking], [LowShelf] and [HighShelf].
+func (t BiquadType) HasGain() bool {
+	return t == Peaking || t == LowShelf || t == HighShelf
+}
+
+// NewBiquadOfType creates a new [Biquad] filter of the given type with the matching constructor,
+// such as [NewPeaking] for [Peaking]. For the shelving filters, q is the slope.
+// The gain is ignored by types that do not take one.
+func NewBiquadOfType(t BiquadType, f, sampleRate freq.Frequency, q, gainDB float64) *Biquad {
+	switch t {
+	case Lowpass:
+		return NewLowpass(f, sampleRate, q)
+	case Highpass:
+		return NewHighpass(f, sampleRate, q)
+	case Bandpass:
+		return NewBandpass(f, sampleRate, q)
+	case Notch:
+		return NewNotch(f, sampleRate, q)
+	case Allpass:
+		return NewAllpass(f, sampleRate, q)
+	case Peaking:
+		return NewPeaking(f, sampleRate, q, gainDB)
+	case LowShelf:
+		return NewLowShelf(f, sampleRate, q, gainDB)
+	case HighShelf:
+		return NewHighShelf(f, sampleRate, q, gainDB)
+	default:
+		panic("filter: invalid biquad type")
+	}
+}
+
+// SetCoefficients copies the coefficients of c to b, keeping the state of b.
+// This changes the response of a running filter without the click of resetting it.
+func (b *Biquad) SetCoefficients(c *Biquad) {
+	b.b0, b.b1, b.b2 = c.b0, c.b1, c.b2
+	b.a1, b.a2 = c.a1, c.a2
+}
+
+// ProcessSingle processes a single input sample and returns the filtered output.
+func (b *Biquad) ProcessSingle(x float32) float32 {
+	return float32(b.process(float64(x)))
+}
+
+func (b *Biquad) process(x float64) float64 {
+	y := b.b0*x + b.z1
+	b.z1 = b.b1*x - b.a1*y + b.z2
+	b.z2 = b.b2*x - b.a2*y
+	return y
+}
+
+// Process filters the mono signal src and writes the result to dst.
+// dst must be at least as long as src. dst and src may be the same slice.
+func (b *Biquad) Process(dst, src []float32) {
+	dst = dst[:len(src)]
+	for i, x := range src {
+		dst[i] = float32(b.process(float64(x)))
+	}
+}
+
+// Reset resets internal state.
+func (b *Biquad) Reset() {
+	b.z1, b.z2 = 0, 0
+}
+
+// Response returns the complex frequency response of the filter at the frequency f.
+func (b *Biquad) Response(f, sampleRate freq.Frequency) complex128 {
+	w := 2 * math.Pi * f.Hertz() / sampleRate.Hertz()
+	z1 := cmplx.Exp(complex(0, -w)) // z^-1
+	z2 := z1 * z1
+	num := complex(b.b0, 0) + complex(b.b1, 0)*z1 + complex(b.b2, 0)*z2
+	den := 1 + complex(b.a1, 0)*z1 + complex(b.a2, 0)*z2
+	return num / den
+}
+
+// Stable reports whether the poles of the filter lie inside the unit circle.
+func (b *Biquad) Stable() bool {
+	return math.Abs(b.a2) < 1 && math.Abs(b.a1) < 1+b.a2
+}
@@ -0,0 +1,222 @@
+package filter_test
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp/filter"
+	"github.com/MatusOllah/resona/freq"
+)
+
+const sampleRate = 48 * freq.KiloHertz
+
+func dB(v float64) float64 {
+	return 20 * math.Log10(v)
+}
+
+// warp returns the analog frequency, relative to fc, that the bilinear transform maps to f.
+func warp(f, fc freq.Frequency) float64 {
+	return math.Tan(math.Pi*f.Hertz()/sampleRate.Hertz()) / math.Tan(math.Pi*fc.Hertz()/sampleRate.Hertz())
+}
+
+// measureGain filters a sine at f and returns the peak amplitude of the output after the filter has settled.
+func measureGain(b *filter.Biquad, f freq.Frequency) float64 {
+	const n = 48000
+	w := 2 * math.Pi * f.Hertz() / sampleRate.Hertz()
+	src := make([]float32, n)
+	for i := range src {
+		src[i] = float32(math.Sin(w * float64(i)))
+	}
+	b.Reset()
+	b.Process(src, src)
+
+	var peak float64
+	for _, s := range src[n/2:] {
+		peak = max(peak, math.Abs(float64(s)))
+	}
+	return peak
+}
+
+func TestBiquadResponse(t *testing.T) {
+	const fc = 1 * freq.KiloHertz
+	butterworth := 1 / math.Sqrt2
+
+	// analog prototypes: second-order Butterworth low-pass and band-pass with Q = 2
+	w := warp(10*freq.KiloHertz, fc)
+	lowpassStop := dB(1 / math.Sqrt(1+w*w*w*w))
+	w = warp(2*fc, fc)
+	bandpassOctave := dB((w / 2) / math.Hypot(1-w*w, w/2))
+
+	tests := []struct {
+		name   string
+		filter *filter.Biquad
+		f      freq.Frequency
+		wantDB float64
+	}{
+		{"Lowpass/fc", filter.NewLowpass(fc, sampleRate, butterworth), fc, -3.01},
+		{"Lowpass/passband", filter.NewLowpass(fc, sampleRate, butterworth), 50 * freq.Hertz, 0},
+		{"Lowpass/stopband", filter.NewLowpass(fc, sampleRate, butterworth), 10 * freq.KiloHertz, lowpassStop},
+		{"Lowpass/resonant", filter.NewLowpass(fc, sampleRate, 4), fc, dB(4)},
+		{"Highpass/fc", filter.NewHighpass(fc, sampleRate, butterworth), fc, -3.01},
+		{"Highpass/passband", filter.NewHighpass(fc, sampleRate, butterworth), 15 * freq.KiloHertz, 0},
+		{"Bandpass/fc", filter.NewBandpass(fc, sampleRate, 2), fc, 0},
+		{"Bandpass/octave", filter.NewBandpass(fc, sampleRate, 2), 2 * fc, bandpassOctave},
+		{"Notch/fc", filter.NewNotch(fc, sampleRate, 2), fc, math.Inf(-1)},
+		{"Notch/far", filter.NewNotch(fc, sampleRate, 2), 10 * freq.KiloHertz, 0},
+		{"Allpass/fc", filter.NewAllpass(fc, sampleRate, 2), fc, 0},
+		{"Allpass/far", filter.NewAllpass(fc, sampleRate, 2), 5 * freq.KiloHertz, 0},
+		{"Peaking/boost", filter.NewPeaking(fc, sampleRate, 1, 6), fc, 6},
+		{"Peaking/cut", filter.NewPeaking(fc, sampleRate, 1, -12), fc, -12},
+		{"Peaking/far", filter.NewPeaking(fc, sampleRate, 4, 6), 10 * freq.KiloHertz, 0},
+		{"LowShelf/dc", filter.NewLowShelf(fc, sampleRate, 1, 6), 10 * freq.Hertz, 6},
+		{"LowShelf/corner", filter.NewLowShelf(fc, sampleRate, 1, 6), fc, 3},
+		{"LowShelf/high", filter.NewLowShelf(fc, sampleRate, 1, 6), 20 * freq.KiloHertz, 0},
+		{"HighShelf/high", filter.NewHighShelf(fc, sampleRate, 1, -6), 23 * freq.KiloHertz, -6},
+		{"HighShelf/corner", filter.NewHighShelf(fc, sampleRate, 1, -6), fc, -3},
+		{"HighShelf/dc", filter.NewHighShelf(fc, sampleRate, 1, -6), 10 * freq.Hertz, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dB(cmplx.Abs(tt.filter.Response(tt.f, sampleRate)))
+			if math.IsInf(tt.wantDB, -1) {
+				if got > -100 {
+					t.Errorf("expected no output, got %.2f dB", got)
+				}
+			} else if math.Abs(got-tt.wantDB) > 0.1 {
+				t.Errorf("expected %.2f dB, got %.2f dB", tt.wantDB, got)
+			}
+
+			// the filter applied to a sine should match the computed response
+			measured := dB(measureGain(tt.filter, tt.f))
+			if got > -60 && math.Abs(measured-got) > 0.1 {
+				t.Errorf("measured %.2f dB, expected %.2f dB", measured, got)
+			}
+		})
+	}
+}
+
+func TestBiquadAllpassPhase(t *testing.T) {
+	b := filter.NewAllpass(2*freq.KiloHertz, sampleRate, 0.7)
+	if phase := cmplx.Phase(b.Response(2*freq.KiloHertz, sampleRate)); math.Abs(math.Abs(phase)-math.Pi) > 1e-9 {
+		t.Errorf("expected a phase of 180°, got %.2f°", phase*180/math.Pi)
+	}
+}
+
+func TestBiquadStability(t *testing.T) {
+	for _, q := range []float64{1e-3, 0.1, 1 / math.Sqrt2, 10, 100, 1e4} {
+		for _, f := range []freq.Frequency{freq.Hertz, 20 * freq.Hertz, 1 * freq.KiloHertz, 20 * freq.KiloHertz, 23990 * freq.Hertz} {
+			for name, b := range map[string]*filter.Biquad{
+				"Lowpass":   filter.NewLowpass(f, sampleRate, q),
+				"Highpass":  filter.NewHighpass(f, sampleRate, q),
+				"Bandpass":  filter.NewBandpass(f, sampleRate, q),
+				"Notch":     filter.NewNotch(f, sampleRate, q),
+				"Allpass":   filter.NewAllpass(f, sampleRate, q),
+				"Peaking":   filter.NewPeaking(f, sampleRate, q, 24),
+				"LowShelf":  filter.NewLowShelf(f, sampleRate, min(q, 1), -24),
+				"HighShelf": filter.NewHighShelf(f, sampleRate, min(q, 1), 24),
+			} {
+				if !b.Stable() {
+					t.Errorf("%s at %v with q = %g: unstable", name, f, q)
+					continue
+				}
+
+				// the impulse response must stay finite
+				out := make([]float32, 1<<16)
+				out[0] = 1
+				b.Process(out, out)
+				for i, s := range out {
+					if math.IsNaN(float64(s)) || math.IsInf(float64(s), 0) {
+						t.Errorf("%s at %v with q = %g: sample %d is %v", name, f, q, i, s)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBiquadReader(t *testing.T) {
+	// a stereo signal with an impulse in the left channel and silence in the right one
+	const numFrames = 64
+	src := make([]float32, 2*numFrames)
+	src[0] = 1
+
+	b := filter.NewLowpass(2*freq.KiloHertz, sampleRate, 0.7)
+	r := filter.NewBiquadReader(audio.NewBuffer(src), b, 2)
+
+	// read with odd sizes so that the reads do not line up with the frames
+	var got []float32
+	buf := make([]float32, 7)
+	for {
+		n, err := r.ReadSamples(buf)
+		got = append(got, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	if len(got) != len(src) {
+		t.Fatalf("expected %d samples, got %d", len(src), len(got))
+	}
+
+	impulse := make([]float32, numFrames)
+	impulse[0] = 1
+	b.Process(impulse, impulse)
+	for i := range numFrames {
+		if got[2*i] != impulse[i] {
+			t.Errorf("left frame %d: expected %v, got %v", i, impulse[i], got[2*i])
+		}
+		if got[2*i+1] != 0 {
+			t.Errorf("right frame %d: expected silence, got %v", i, got[2*i+1])
+		}
+	}
+}
+
+func TestNewBiquadOfType(t *testing.T) {
+	const f = 1 * freq.KiloHertz
+	for _, tt := range []struct {
+		typ  filter.BiquadType
+		want *filter.Biquad
+	}{
+		{filter.Lowpass, filter.NewLowpass(f, sampleRate, 0.7)},
+		{filter.Highpass, filter.NewHighpass(f, sampleRate, 0.7)},
+		{filter.Bandpass, filter.NewBandpass(f, sampleRate, 0.7)},
+		{filter.Notch, filter.NewNotch(f, sampleRate, 0.7)},
+		{filter.Allpass, filter.NewAllpass(f, sampleRate, 0.7)},
+		{filter.Peaking, filter.NewPeaking(f, sampleRate, 0.7, 6)},
+		{filter.LowShelf, filter.NewLowShelf(f, sampleRate, 0.7, 6)},
+		{filter.HighShelf, filter.NewHighShelf(f, sampleRate, 0.7, 6)},
+	} {
+		got := filter.NewBiquadOfType(tt.typ, f, sampleRate, 0.7, 6)
+		for _, probe := range []freq.Frequency{100 * freq.Hertz, f, 5 * freq.KiloHertz} {
+			if g, w := got.Response(probe, sampleRate), tt.want.Response(probe, sampleRate); cmplx.Abs(g-w) > 1e-12 {
+				t.Errorf("%v: response at %v: expected %v, got %v", tt.typ, probe, w, g)
+			}
+		}
+	}
+
+	if got := filter.BiquadType(42).String(); got != "BiquadType(42)" {
+		t.Errorf("String() = %q; want %q", got, "BiquadType(42)")
+	}
+}
+
+func TestBiquadSetCoefficients(t *testing.T) {
+	// filtering in two halves with the same coefficients set in between must match filtering in one go
+	src := make([]float32, 256)
+	src[0] = 1
+	want := make([]float32, len(src))
+	filter.NewLowpass(2*freq.KiloHertz, sampleRate, 0.7).Process(want, src)
+
+	b := filter.NewLowpass(2*freq.KiloHertz, sampleRate, 0.7)
+	got := make([]float32, len(src))
+	b.Process(got[:100], src[:100])
+	b.SetCoefficients(filter.NewLowpass(2*freq.KiloHertz, sampleRate, 0.7))
+	b.Process(got[100:], src[100:])
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package filter
+
+import (
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp/fft"
+)
+
+// directMaxTaps is the longest kernel convolved directly; longer kernels use FFT-based overlap-save.
+const directMaxTaps = 64
+
+var _ aio.SampleReader = (*Convolver)(nil)
+
+// Convolver wraps an aio.SampleReader and convolves every channel of its interleaved output
+// with an FIR kernel, such as one designed by [DesignLowpass].
+//
+// Short kernels are convolved directly. Longer kernels use the overlap-save method with [fft.Real],
+// which reads the source in blocks of roughly the kernel length.
+// The output has the same length as the input; the tail of the convolution is not flushed at the end.
+type Convolver struct {
+	r           aio.SampleReader
+	kernel      []float64
+	numChannels int
+	blockFrames int
+
+	// channel state: the last len(kernel)-1 input samples, followed by the current block
+	hist [][]float64
+
+	in     []float32 // interleaved input block
+	out    []float32 // interleaved output block
+	outPos int
+	err    error
+
+	// overlap-save
+	plan     *fft.Real
+	spectrum []complex128 // transform of the kernel
+	fftBuf   []float64
+	specBuf  []complex128
+}
+
+// NewConvolver creates a new [Convolver] reading numChannels interleaved channels from r.
+func NewConvolver(r aio.SampleReader, kernel []float64, numChannels int) *Convolver {
+	if len(kernel) == 0 {
+		panic("filter: empty kernel")
+	}
+	if numChannels <= 0 {
+		panic("filter: invalid number of channels")
+	}
+
+	c := &Convolver{
+		r:           r,
+		kernel:      kernel,
+		numChannels: numChannels,
+		blockFrames: directMaxTaps,
+	}
+
+	m := len(kernel)
+	if m > directMaxTaps {
+		fftSize := 1
+		for fftSize < 2*m {
+			fftSize *= 2
+		}
+		c.blockFrames = fftSize - (m - 1)
+
+		c.plan = fft.NewReal(fftSize)
+		c.fftBuf = make([]float64, fftSize)
+		c.specBuf = make([]complex128, fftSize/2+1)
+		c.spectrum = make([]complex128, fftSize/2+1)
+		copy(c.fftBuf, kernel)
+		c.plan.Forward(c.spectrum, c.fftBuf)
+	}
+
+	c.hist = make([][]float64, numChannels)
+	for ch := range c.hist {
+		c.hist[ch] = make([]float64, m-1+c.blockFrames)
+	}
+	c.in = make([]float32, c.blockFrames*numChannels)
+	return c
+}
+
+// ReadSamples reads filtered samples into p.
+// It returns the number of samples read and/or an error.
+func (c *Convolver) ReadSamples(p []float32) (int, error) {
+	n := 0
+	for n < len(p) {
+		if c.outPos == len(c.out) {
+			if c.err != nil {
+				break
+			}
+			c.fill()
+			if len(c.out) == 0 {
+				break
+			}
+		}
+		copied := copy(p[n:], c.out[c.outPos:])
+		c.outPos += copied
+		n += copied
+	}
+
+	if n == 0 && c.err != nil {
+		return 0, c.err
+	}
+	return n, nil
+}
+
+// fill reads and filters the next block.
+func (c *Convolver) fill() {
+	n, err := aio.ReadFull(c.r, c.in)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	c.err = err
+
+	// the output is written over the input, which is fine as every channel only touches its own samples
+	numFrames := n / c.numChannels
+	c.out = c.in[:numFrames*c.numChannels]
+	c.outPos = 0
+	if numFrames == 0 {
+		return
+	}
+
+	m := len(c.kernel)
+	for ch, x := range c.hist {
+		block := x[m-1 : m-1+numFrames]
+		for i := range block {
+			block[i] = float64(c.in[i*c.numChannels+ch])
+		}
+
+		if c.plan != nil {
+			c.convolveFFT(ch, x[:m-1+numFrames])
+		} else {
+			c.convolveDirect(ch, x[:m-1+numFrames])
+		}
+
+		// keep the last m-1 samples for the next block
+		copy(x, x[numFrames:numFrames+m-1])
+	}
+}
+
+// convolveDirect writes the convolution of the block in x, preceded by its history, to c.out.
+func (c *Convolver) convolveDirect(ch int, x []float64) {
+	m := len(c.kernel)
+	for i := range len(x) - (m - 1) {
+		var y float64
+		for k, h := range c.kernel {
+			y += h * x[m-1+i-k]
+		}
+		c.out[i*c.numChannels+ch] = float32(y)
+	}
+}
+
+// convolveFFT is like convolveDirect, but uses overlap-save.
+func (c *Convolver) convolveFFT(ch int, x []float64) {
+	m := len(c.kernel)
+	copy(c.fftBuf, x)
+	clear(c.fftBuf[len(x):])
+
+	c.plan.Forward(c.specBuf, c.fftBuf)
+	for i, h := range c.spectrum {
+		c.specBuf[i] *= h
+	}
+	c.plan.Inverse(c.fftBuf, c.specBuf)
+
+	// the first m-1 outputs are wrapped around and discarded
+	for i, y := range c.fftBuf[m-1 : len(x)] {
+		c.out[i*c.numChannels+ch] = float32(y)
+	}
+}
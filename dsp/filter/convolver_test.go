@@ -0,0 +1,156 @@
+package filter_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp/filter"
+	"github.com/MatusOllah/resona/dsp/window"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// readAll reads everything from r in odd-sized chunks.
+func readAll(t *testing.T, r aio.SampleReader) []float32 {
+	t.Helper()
+	var out []float32
+	buf := make([]float32, 77)
+	for {
+		n, err := r.ReadSamples(buf)
+		out = append(out, buf[:n]...)
+		if err != nil {
+			return out
+		}
+	}
+}
+
+func interleave(left, right []float32) []float32 {
+	out := make([]float32, 0, 2*len(left))
+	for i := range left {
+		out = append(out, left[i], right[i])
+	}
+	return out
+}
+
+func naiveConvolve(x []float32, h []float64) []float32 {
+	y := make([]float32, len(x))
+	for i := range y {
+		var sum float64
+		for k, c := range h {
+			if i-k >= 0 {
+				sum += c * float64(x[i-k])
+			}
+		}
+		y[i] = float32(sum)
+	}
+	return y
+}
+
+func TestConvolverImpulseResponse(t *testing.T) {
+	for _, taps := range []int{1, 15, 64, 65, 255, 1001} {
+		kernel := filter.DesignLowpass(taps, 5*freq.KiloHertz, sampleRate, window.Blackman)
+
+		impulse := make([]float32, 3000)
+		impulse[0] = 1
+		got := readAll(t, filter.NewConvolver(audio.NewBuffer(impulse), kernel, 1))
+		if len(got) != len(impulse) {
+			t.Fatalf("%d taps: expected %d samples, got %d", taps, len(impulse), len(got))
+		}
+
+		for i, s := range got {
+			var want float64
+			if i < taps {
+				want = kernel[i]
+			}
+			if math.Abs(float64(s)-want) > 1e-6 {
+				t.Errorf("%d taps: sample %d: expected %v, got %v", taps, i, want, s)
+				break
+			}
+		}
+	}
+}
+
+func TestConvolverMultichannel(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	const numFrames = 5000
+	left := make([]float32, numFrames)
+	right := make([]float32, numFrames)
+	for i := range numFrames {
+		left[i] = rng.Float32()*2 - 1
+		right[i] = float32(math.Sin(float64(i) / 7))
+	}
+
+	for _, taps := range []int{31, 301} {
+		kernel := filter.DesignBandpass(taps, 300*freq.Hertz, 3*freq.KiloHertz, sampleRate, window.Hann)
+		got := readAll(t, filter.NewConvolver(audio.NewBuffer(interleave(left, right)), kernel, 2))
+
+		want := interleave(naiveConvolve(left, kernel), naiveConvolve(right, kernel))
+		if len(got) != len(want) {
+			t.Fatalf("%d taps: expected %d samples, got %d", taps, len(want), len(got))
+		}
+		for i := range got {
+			if math.Abs(float64(got[i]-want[i])) > 1e-5 {
+				t.Errorf("%d taps: sample %d: expected %v, got %v", taps, i, want[i], got[i])
+				break
+			}
+		}
+	}
+}
+
+// kernelGain returns the magnitude of the frequency response of kernel at f.
+func kernelGain(kernel []float64, f freq.Frequency) float64 {
+	w := 2 * math.Pi * f.Hertz() / sampleRate.Hertz()
+	var re, im float64
+	for n, c := range kernel {
+		re += c * math.Cos(w*float64(n))
+		im -= c * math.Sin(w*float64(n))
+	}
+	return math.Hypot(re, im)
+}
+
+func TestConvolverStopband(t *testing.T) {
+	// a Blackman window gives about 74 dB of stopband attenuation,
+	// with a transition band of about 5.5 * 48 kHz / 511 ≈ 520 Hz above the 200 Hz cutoff
+	kernel := filter.DesignLowpass(511, 200*freq.Hertz, sampleRate, window.Blackman)
+	designed := dB(kernelGain(kernel, freq.KiloHertz))
+	if designed > -70 {
+		t.Fatalf("expected at least 70 dB of attenuation at 1 kHz, designed %.1f dB", designed)
+	}
+
+	const n = 48000
+	tone := make([]float32, n)
+	for i := range tone {
+		tone[i] = float32(math.Sin(2 * math.Pi * 1000 * float64(i) / n))
+	}
+	got := readAll(t, filter.NewConvolver(audio.NewBuffer(tone), kernel, 1))
+
+	var peak float64
+	for _, s := range got[len(kernel):] {
+		peak = max(peak, math.Abs(float64(s)))
+	}
+	if measured := dB(peak); math.Abs(measured-designed) > 1 {
+		t.Errorf("expected %.1f dB at 1 kHz, measured %.1f dB", designed, measured)
+	}
+}
+
+func TestDesignHighpassBandstop(t *testing.T) {
+	hp := filter.DesignHighpass(255, 2*freq.KiloHertz, sampleRate, window.Blackman)
+	if g := dB(kernelGain(hp, 100*freq.Hertz)); g > -70 {
+		t.Errorf("high-pass: expected at least 70 dB of attenuation at 100 Hz, got %.1f dB", g)
+	}
+	if g := dB(kernelGain(hp, 10*freq.KiloHertz)); math.Abs(g) > 0.1 {
+		t.Errorf("high-pass: expected 0 dB at 10 kHz, got %.2f dB", g)
+	}
+
+	bs := filter.DesignBandstop(511, 900*freq.Hertz, 3*freq.KiloHertz, sampleRate, window.Blackman)
+	if g := dB(kernelGain(bs, 2*freq.KiloHertz)); g > -70 {
+		t.Errorf("band-stop: expected at least 70 dB of attenuation at 2 kHz, got %.1f dB", g)
+	}
+	for _, f := range []freq.Frequency{50 * freq.Hertz, 10 * freq.KiloHertz} {
+		if g := dB(kernelGain(bs, f)); math.Abs(g) > 0.1 {
+			t.Errorf("band-stop: expected 0 dB at %v, got %.2f dB", f, g)
+		}
+	}
+}
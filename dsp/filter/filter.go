@@ -2,7 +2,11 @@
 //
 // Filters here operate on a sample-by-sample basis and are intended as
 // low-level building blocks for signal processing. They do not perform
-// buffer-based processing, error handling, or higher-level musical effects.
+// error handling or higher-level musical effects; buffer-based processing
+// is limited to simple helpers such as [Biquad.Process] and [NewBiquadReader].
+//
+// [Decimator] and [Interpolator] change the sample rate by integer factors with polyphase filtering,
+// which is cheaper than general-purpose resampling.
 //
 // For buffer-oriented processing and effect chaining, see the effects package.
 package filter
@@ -43,26 +43,46 @@ func (f *FIR) Reset() {
 	f.buf = make([]float32, len(f.coeffs))
 }
 
-// DesignFIRLowpass designs a low-pass FIR filter using the windowed-sinc method.
+// DesignFIRLowpass designs a low-pass FIR filter using the windowed-sinc method with a Hamming window.
 // It returns the filter coefficients for use in [NewFIR].
 func DesignFIRLowpass(cutoff, sampleRate freq.Frequency, taps int) []float64 {
-	fc := cutoff.Hertz()
-	fs := sampleRate.Hertz()
+	return DesignLowpass(taps, cutoff, sampleRate, window.Hamming)
+}
 
-	coeffs := make([]float64, taps)
-	m := taps - 1
+// DesignFIRHighpass designs a high-pass FIR filter using spectral inversion.
+// It returns the filter coefficients for use in [NewFIR].
+func DesignFIRHighpass(cutoff, sampleRate freq.Frequency, taps int) []float64 {
+	lpf := DesignFIRLowpass(cutoff, sampleRate, taps)
+	hpf := make([]float64, taps)
+	center := (taps - 1) / 2
+	for n := range taps {
+		if n == center {
+			hpf[n] = 1.0 - lpf[n]
+		} else {
+			hpf[n] = -lpf[n]
+		}
+	}
+	return hpf
+}
 
+// DesignLowpass designs a linear-phase low-pass FIR filter with the given number of taps
+// using the windowed-sinc method. The coefficients are normalized to unity gain at DC.
+// It returns the filter coefficients for use in [NewFIR] or [NewConvolver].
+func DesignLowpass(taps int, cutoff, sampleRate freq.Frequency, fn window.WindowFunc) []float64 {
+	fc := cutoff.Hertz() / sampleRate.Hertz()
+
+	coeffs := make([]float64, taps)
+	m := float64(taps-1) / 2
 	for n := range taps {
-		if n == m/2 {
-			coeffs[n] = 2 * fc / fs
+		x := float64(n) - m
+		if x == 0 {
+			coeffs[n] = 2 * fc
 		} else {
-			x := math.Pi * (float64(n) - float64(m)/2.0)
-			coeffs[n] = math.Sin(2*math.Pi*fc/fs*(float64(n)-float64(m)/2.0)) / x
+			coeffs[n] = math.Sin(2*math.Pi*fc*x) / (math.Pi * x)
 		}
 	}
 
-	// Apply Hamming window
-	window.MustApply(coeffs, window.Hamming)
+	window.MustApply(coeffs, fn)
 
 	// Normalize
 	sum := 0.0
@@ -76,18 +96,36 @@ func DesignFIRLowpass(cutoff, sampleRate freq.Frequency, taps int) []float64 {
 	return coeffs
 }
 
-// DesignFIRHighpass designs a high-pass FIR filter using spectral inversion.
-// It returns the filter coefficients for use in [NewFIR].
-func DesignFIRHighpass(cutoff, sampleRate freq.Frequency, taps int) []float64 {
-	lpf := DesignFIRLowpass(cutoff, sampleRate, taps)
-	hpf := make([]float64, taps)
-	center := (taps - 1) / 2
-	for n := range taps {
-		if n == center {
-			hpf[n] = 1.0 - lpf[n]
-		} else {
-			hpf[n] = -lpf[n]
-		}
+// invert turns a low-pass or band-pass kernel into its complement by spectral inversion.
+func invert(coeffs []float64) []float64 {
+	if len(coeffs)%2 == 0 {
+		panic("filter: spectral inversion needs an odd number of taps")
 	}
-	return hpf
+	for i := range coeffs {
+		coeffs[i] = -coeffs[i]
+	}
+	coeffs[len(coeffs)/2] += 1
+	return coeffs
+}
+
+// DesignHighpass designs a linear-phase high-pass FIR filter using spectral inversion of a windowed-sinc low-pass filter.
+// The number of taps must be odd, as an even-length linear-phase filter always has a zero at the Nyquist frequency.
+func DesignHighpass(taps int, cutoff, sampleRate freq.Frequency, fn window.WindowFunc) []float64 {
+	return invert(DesignLowpass(taps, cutoff, sampleRate, fn))
+}
+
+// DesignBandpass designs a linear-phase band-pass FIR filter passing frequencies between low and high,
+// as the difference of two windowed-sinc low-pass filters.
+func DesignBandpass(taps int, low, high, sampleRate freq.Frequency, fn window.WindowFunc) []float64 {
+	coeffs := DesignLowpass(taps, high, sampleRate, fn)
+	for i, c := range DesignLowpass(taps, low, sampleRate, fn) {
+		coeffs[i] -= c
+	}
+	return coeffs
+}
+
+// DesignBandstop designs a linear-phase band-stop FIR filter rejecting frequencies between low and high,
+// using spectral inversion of a band-pass filter. The number of taps must be odd.
+func DesignBandstop(taps int, low, high, sampleRate freq.Frequency, fn window.WindowFunc) []float64 {
+	return invert(DesignBandpass(taps, low, high, sampleRate, fn))
 }
@@ -0,0 +1,174 @@
+package filter
+
+import (
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp/fft"
+)
+
+var _ aio.SampleReader = (*PartitionedConvolver)(nil)
+
+// PartitionedConvolver wraps an aio.SampleReader and convolves every channel of its interleaved output
+// with a long FIR kernel, such as the impulse response of a room.
+//
+// Unlike [Convolver], which reads blocks about as long as the kernel, it splits the kernel into partitions
+// of a fixed block size and convolves them with uniformly partitioned overlap-save in the frequency domain.
+// The source is read one block at a time, so the latency in a real-time chain is one block,
+// regardless of the length of the kernel.
+// The output has the same length as the input; the tail of the convolution is not flushed at the end.
+type PartitionedConvolver struct {
+	r           aio.SampleReader
+	numChannels int
+	blockSize   int
+
+	plan       *fft.Real
+	partitions [][]complex128 // transforms of the kernel partitions
+	channels   []partitionedChannel
+
+	fftBuf  []float64
+	specBuf []complex128
+
+	in     []float32 // interleaved input block
+	out    []float32 // interleaved output block
+	outPos int
+	err    error
+}
+
+type partitionedChannel struct {
+	prev  []float64      // the previous input block
+	delay [][]complex128 // transforms of the last input blocks, a frequency-domain delay line
+	head  int            // index of the newest block in delay
+}
+
+// NewPartitionedConvolver creates a new [PartitionedConvolver] reading numChannels interleaved channels from r,
+// with blocks of blockSize frames.
+func NewPartitionedConvolver(r aio.SampleReader, kernel []float64, numChannels, blockSize int) *PartitionedConvolver {
+	if len(kernel) == 0 {
+		panic("filter: empty kernel")
+	}
+	if numChannels <= 0 {
+		panic("filter: invalid number of channels")
+	}
+	if blockSize <= 0 {
+		panic("filter: invalid block size")
+	}
+
+	c := &PartitionedConvolver{
+		r:           r,
+		numChannels: numChannels,
+		blockSize:   blockSize,
+		plan:        fft.NewReal(2 * blockSize),
+		fftBuf:      make([]float64, 2*blockSize),
+		specBuf:     make([]complex128, blockSize+1),
+		in:          make([]float32, blockSize*numChannels),
+	}
+
+	numPartitions := (len(kernel) + blockSize - 1) / blockSize
+	c.partitions = make([][]complex128, numPartitions)
+	for i := range c.partitions {
+		clear(c.fftBuf)
+		copy(c.fftBuf, kernel[i*blockSize:min((i+1)*blockSize, len(kernel))])
+		c.partitions[i] = make([]complex128, blockSize+1)
+		c.plan.Forward(c.partitions[i], c.fftBuf)
+	}
+
+	c.channels = make([]partitionedChannel, numChannels)
+	for ch := range c.channels {
+		delay := make([][]complex128, numPartitions)
+		for i := range delay {
+			delay[i] = make([]complex128, blockSize+1)
+		}
+		c.channels[ch] = partitionedChannel{
+			prev:  make([]float64, blockSize),
+			delay: delay,
+		}
+	}
+	return c
+}
+
+// Latency returns the number of frames the source is read ahead, which is the block size.
+func (c *PartitionedConvolver) Latency() int {
+	return c.blockSize
+}
+
+// ReadSamples reads filtered samples into p.
+// It returns the number of samples read and/or an error.
+func (c *PartitionedConvolver) ReadSamples(p []float32) (int, error) {
+	n := 0
+	for n < len(p) {
+		if c.outPos == len(c.out) {
+			if c.err != nil {
+				break
+			}
+			c.fill()
+			if len(c.out) == 0 {
+				break
+			}
+		}
+		copied := copy(p[n:], c.out[c.outPos:])
+		c.outPos += copied
+		n += copied
+	}
+
+	if n == 0 && c.err != nil {
+		return 0, c.err
+	}
+	return n, nil
+}
+
+// fill reads and filters the next block.
+func (c *PartitionedConvolver) fill() {
+	n, err := aio.ReadFull(c.r, c.in)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	c.err = err
+
+	numFrames := n / c.numChannels
+	c.out = c.in[:numFrames*c.numChannels]
+	c.outPos = 0
+	if numFrames == 0 {
+		return
+	}
+	// a short last block is padded with silence
+	clear(c.in[numFrames*c.numChannels:])
+
+	for ch := range c.channels {
+		c.convolve(ch, numFrames)
+	}
+}
+
+// convolve filters the current block of channel ch and writes numFrames frames of it to c.out.
+func (c *PartitionedConvolver) convolve(ch, numFrames int) {
+	s := &c.channels[ch]
+	b := c.blockSize
+
+	// transform the previous and the current block
+	copy(c.fftBuf, s.prev)
+	for i := range b {
+		s.prev[i] = float64(c.in[i*c.numChannels+ch])
+	}
+	copy(c.fftBuf[b:], s.prev)
+
+	s.head--
+	if s.head < 0 {
+		s.head = len(s.delay) - 1
+	}
+	c.plan.Forward(s.delay[s.head], c.fftBuf)
+
+	// multiply and accumulate every partition with the block it lines up with
+	clear(c.specBuf)
+	for i, h := range c.partitions {
+		x := s.delay[(s.head+i)%len(s.delay)]
+		for k := range c.specBuf {
+			c.specBuf[k] += x[k] * h[k]
+		}
+	}
+	c.plan.Inverse(c.fftBuf, c.specBuf)
+
+	// the first half is wrapped around and discarded
+	for i, y := range c.fftBuf[b : b+numFrames] {
+		c.out[i*c.numChannels+ch] = float32(y)
+	}
+}
@@ -0,0 +1,74 @@
+package filter_test
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp/filter"
+	"github.com/MatusOllah/resona/generator"
+)
+
+func TestPartitionedConvolver(t *testing.T) {
+	tests := []struct {
+		kernelLen, blockSize int
+	}{
+		{1, 64},
+		{50, 64},     // shorter than a block
+		{64, 64},     // exactly one partition
+		{10000, 256}, // a long response with a partial last partition
+		{3000, 100},  // a block size that is not a power of two
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%d", tt.kernelLen, tt.blockSize), func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(uint64(tt.kernelLen), uint64(tt.blockSize)))
+			kernel := make([]float64, tt.kernelLen)
+			for i := range kernel {
+				// a decaying response, like a reverb
+				kernel[i] = (rng.Float64()*2 - 1) * math.Exp(-float64(i)/2000)
+			}
+			left, right := make([]float32, 20011), make([]float32, 20011)
+			for i := range left {
+				left[i] = float32(rng.Float64()*2 - 1)
+				right[i] = float32(math.Sin(float64(i) / 10))
+			}
+
+			c := filter.NewPartitionedConvolver(audio.NewBuffer(interleave(left, right)), kernel, 2, tt.blockSize)
+			got := readAll(t, c)
+			if len(got) != 2*len(left) {
+				t.Fatalf("expected %d samples, got %d", 2*len(left), len(got))
+			}
+
+			for ch, x := range [][]float32{left, right} {
+				want := naiveConvolve(x, kernel)
+				for i := range x {
+					if d := math.Abs(float64(got[2*i+ch] - want[i])); d > 1e-4 {
+						t.Fatalf("channel %d, frame %d: expected %v, got %v", ch, i, want[i], got[2*i+ch])
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPartitionedConvolver(b *testing.B) {
+	// a second of reverb at 48 kHz
+	kernel := make([]float64, 48000)
+	for i := range kernel {
+		kernel[i] = math.Exp(-float64(i) / 10000)
+	}
+	for _, blockSize := range []int{128, 512, 2048} {
+		b.Run(fmt.Sprint(blockSize), func(b *testing.B) {
+			r := filter.NewPartitionedConvolver(generator.NewNoise(), kernel, 1, blockSize)
+			buf := make([]float32, blockSize)
+			for b.Loop() {
+				if _, err := r.ReadSamples(buf); err != nil && err != io.EOF {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package filter
+
+import (
+	"io"
+	"slices"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp/window"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// DesignAntiAlias designs a low-pass kernel for a [Decimator] or [Interpolator] with the given factor,
+// using the windowed-sinc method with a Kaiser window for the given stopband attenuation in dB.
+//
+// The cutoff lies at 90% of the Nyquist frequency of the lower sample rate. More taps make the transition band narrower;
+// with about 32 taps per unit of factor, it is about a tenth of the lower sample rate wide at 80 dB attenuation.
+func DesignAntiAlias(factor, taps int, attenuationDB float64) []float64 {
+	if factor <= 0 {
+		panic("filter: invalid factor")
+	}
+	// the kernel runs at the higher rate; any sample rate works as only the ratio matters
+	sampleRate := freq.Frequency(2*factor) * freq.KiloHertz
+	return DesignLowpass(taps, 900*freq.Hertz, sampleRate, window.Kaiser(window.KaiserForAttenuation(attenuationDB)))
+}
+
+// polyphaseHistory holds the last samples of a channel twice in a row, so that they can be read as one contiguous slice.
+type polyphaseHistory struct {
+	buf []float64
+	pos int
+}
+
+func newPolyphaseHistory(n int) polyphaseHistory {
+	return polyphaseHistory{buf: make([]float64, 2*n)}
+}
+
+// push adds x as the newest sample.
+func (h *polyphaseHistory) push(x float64) {
+	n := len(h.buf) / 2
+	h.buf[h.pos] = x
+	h.buf[h.pos+n] = x
+	h.pos++
+	if h.pos == n {
+		h.pos = 0
+	}
+}
+
+// samples returns the last samples, oldest first.
+func (h *polyphaseHistory) samples() []float64 {
+	return h.buf[h.pos : h.pos+len(h.buf)/2]
+}
+
+func (h *polyphaseHistory) reset() {
+	clear(h.buf)
+	h.pos = 0
+}
+
+// dot returns the dot product of a and b, which must be the same length.
+func dot(a, b []float64) float64 {
+	b = b[:len(a)]
+	var sum float64
+	for i, x := range a {
+		sum += x * b[i]
+	}
+	return sum
+}
+
+// Decimator lowers the sample rate of an interleaved signal by an integer factor,
+// filtering it with an anti-aliasing kernel first. Only the kept output samples are computed.
+type Decimator struct {
+	factor      int
+	taps        []float64 // reversed
+	numChannels int
+
+	hist  []polyphaseHistory
+	phase int // input frames since the last output frame
+}
+
+// NewDecimator creates a new [Decimator] with the given factor, kernel and number of channels.
+// A suitable kernel can be designed with [DesignAntiAlias].
+func NewDecimator(factor int, taps []float64, numChannels int) *Decimator {
+	if factor <= 0 {
+		panic("filter: invalid factor")
+	}
+	if len(taps) == 0 {
+		panic("filter: empty kernel")
+	}
+	if numChannels <= 0 {
+		panic("filter: invalid number of channels")
+	}
+
+	d := &Decimator{
+		factor:      factor,
+		taps:        slices.Clone(taps),
+		numChannels: numChannels,
+		hist:        make([]polyphaseHistory, numChannels),
+	}
+	slices.Reverse(d.taps)
+	for ch := range d.hist {
+		d.hist[ch] = newPolyphaseHistory(len(taps))
+	}
+	return d
+}
+
+// Factor returns the decimation factor.
+func (d *Decimator) Factor() int {
+	return d.factor
+}
+
+// Process decimates the interleaved samples src, which must contain whole frames, and writes the result to dst.
+// It returns the number of samples written, which is at most len(src)/factor rounded up to whole frames.
+// dst may be the same slice as src.
+func (d *Decimator) Process(dst, src []float32) int {
+	if len(src)%d.numChannels != 0 {
+		panic("filter: incomplete frame")
+	}
+
+	n := 0
+	for i := 0; i < len(src); i += d.numChannels {
+		for ch := range d.hist {
+			d.hist[ch].push(float64(src[i+ch]))
+		}
+		if d.phase == 0 {
+			for ch := range d.hist {
+				dst[n+ch] = float32(dot(d.taps, d.hist[ch].samples()))
+			}
+			n += d.numChannels
+		}
+		d.phase++
+		if d.phase == d.factor {
+			d.phase = 0
+		}
+	}
+	return n
+}
+
+// Reset resets internal state.
+func (d *Decimator) Reset() {
+	for ch := range d.hist {
+		d.hist[ch].reset()
+	}
+	d.phase = 0
+}
+
+// Interpolator raises the sample rate of an interleaved signal by an integer factor,
+// filtering out the images of the spectrum. Every output sample is computed from
+// its own phase of the kernel, skipping the zeros a naive upsampler would insert.
+type Interpolator struct {
+	factor      int
+	phases      [][]float64 // every factor-th tap, reversed and scaled by factor
+	numChannels int
+
+	hist []polyphaseHistory
+}
+
+// NewInterpolator creates a new [Interpolator] with the given factor, kernel and number of channels.
+// The kernel is scaled by the factor, so a kernel with unity gain at DC, such as one designed
+// with [DesignAntiAlias], keeps the level of the signal.
+func NewInterpolator(factor int, taps []float64, numChannels int) *Interpolator {
+	if factor <= 0 {
+		panic("filter: invalid factor")
+	}
+	if len(taps) == 0 {
+		panic("filter: empty kernel")
+	}
+	if numChannels <= 0 {
+		panic("filter: invalid number of channels")
+	}
+
+	phaseLen := (len(taps) + factor - 1) / factor
+	it := &Interpolator{
+		factor:      factor,
+		phases:      make([][]float64, factor),
+		numChannels: numChannels,
+		hist:        make([]polyphaseHistory, numChannels),
+	}
+	for p := range it.phases {
+		// y[n*factor+p] = sum over j of taps[j*factor+p] * x[n-j]
+		phase := make([]float64, phaseLen)
+		for j := range phaseLen {
+			if k := j*factor + p; k < len(taps) {
+				phase[phaseLen-1-j] = taps[k] * float64(factor)
+			}
+		}
+		it.phases[p] = phase
+	}
+	for ch := range it.hist {
+		it.hist[ch] = newPolyphaseHistory(phaseLen)
+	}
+	return it
+}
+
+// Factor returns the interpolation factor.
+func (it *Interpolator) Factor() int {
+	return it.factor
+}
+
+// Process interpolates the interleaved samples src, which must contain whole frames, and writes the result to dst.
+// dst must be at least factor times as long as src. It returns the number of samples written.
+func (it *Interpolator) Process(dst, src []float32) int {
+	if len(src)%it.numChannels != 0 {
+		panic("filter: incomplete frame")
+	}
+
+	n := 0
+	for i := 0; i < len(src); i += it.numChannels {
+		for ch := range it.hist {
+			it.hist[ch].push(float64(src[i+ch]))
+		}
+		for _, phase := range it.phases {
+			for ch := range it.hist {
+				dst[n+ch] = float32(dot(phase, it.hist[ch].samples()))
+			}
+			n += it.numChannels
+		}
+	}
+	return n
+}
+
+// Reset resets internal state.
+func (it *Interpolator) Reset() {
+	for ch := range it.hist {
+		it.hist[ch].reset()
+	}
+}
+
+// rateProcessor is implemented by [Decimator] and [Interpolator].
+type rateProcessor interface {
+	Process(dst, src []float32) int
+
+	// channels returns the number of channels.
+	channels() int
+
+	// inputFor returns the number of input frames to read to fill about n output samples.
+	inputFor(n int) int
+
+	// outputFor returns the largest number of output samples for n input samples.
+	outputFor(n int) int
+}
+
+func (d *Decimator) channels() int { return d.numChannels }
+
+func (d *Decimator) inputFor(n int) int {
+	return max(n/d.numChannels, 1) * d.factor
+}
+
+func (d *Decimator) outputFor(n int) int {
+	return (n/d.numChannels + d.factor - 1) / d.factor * d.numChannels
+}
+
+func (it *Interpolator) channels() int { return it.numChannels }
+
+func (it *Interpolator) inputFor(n int) int {
+	return max(n/(it.numChannels*it.factor), 1)
+}
+
+func (it *Interpolator) outputFor(n int) int {
+	return n * it.factor
+}
+
+type rateReader struct {
+	r aio.SampleReader
+	p rateProcessor
+
+	in     []float32
+	out    []float32
+	outPos int
+	err    error
+}
+
+// NewDecimatorReader wraps an aio.SampleReader and decimates its interleaved output with d.
+func NewDecimatorReader(r aio.SampleReader, d *Decimator) aio.SampleReader {
+	return &rateReader{r: r, p: d}
+}
+
+// NewInterpolatorReader wraps an aio.SampleReader and interpolates its interleaved output with it.
+func NewInterpolatorReader(r aio.SampleReader, it *Interpolator) aio.SampleReader {
+	return &rateReader{r: r, p: it}
+}
+
+func (rr *rateReader) ReadSamples(p []float32) (int, error) {
+	n := 0
+	for n < len(p) {
+		if rr.outPos == len(rr.out) {
+			if rr.err != nil {
+				break
+			}
+			rr.fill(len(p) - n)
+			continue
+		}
+		copied := copy(p[n:], rr.out[rr.outPos:])
+		rr.outPos += copied
+		n += copied
+	}
+
+	if n == 0 && rr.err != nil {
+		return 0, rr.err
+	}
+	return n, nil
+}
+
+// fill reads and processes enough input for about n output samples.
+func (rr *rateReader) fill(n int) {
+	numChannels := rr.p.channels()
+	size := rr.p.inputFor(n) * numChannels
+	if cap(rr.in) < size {
+		rr.in = make([]float32, size)
+	}
+	m, err := aio.ReadFull(rr.r, rr.in[:size])
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	rr.err = err
+
+	in := rr.in[:m/numChannels*numChannels]
+	if out := rr.p.outputFor(len(in)); cap(rr.out) < out {
+		rr.out = make([]float32, out)
+	}
+	rr.out = rr.out[:rr.p.Process(rr.out[:cap(rr.out)], in)]
+	rr.outPos = 0
+}
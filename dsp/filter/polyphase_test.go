@@ -0,0 +1,138 @@
+package filter_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp/filter"
+)
+
+const antiAliasDB = 80
+
+func tone(f, sampleRate, amplitude float64, n int) []float32 {
+	p := make([]float32, n)
+	for i := range p {
+		p[i] = float32(amplitude * math.Sin(2*math.Pi*f*float64(i)/sampleRate))
+	}
+	return p
+}
+
+func peak(p []float32) float64 {
+	var m float64
+	for _, x := range p {
+		m = max(m, math.Abs(float64(x)))
+	}
+	return m
+}
+
+func TestDecimatorAlias(t *testing.T) {
+	taps := filter.DesignAntiAlias(3, 97, antiAliasDB)
+
+	// 10 kHz is above the Nyquist frequency of 16 kHz and would alias to 6 kHz
+	d := filter.NewDecimator(3, taps, 1)
+	out := make([]float32, 16000)
+	n := d.Process(out, tone(10000, 48000, 0.5, 48000))
+	if n != 16000 {
+		t.Fatalf("expected 16000 samples, got %d", n)
+	}
+	if db := 20 * math.Log10(peak(out[100:])/0.5); db > -antiAliasDB {
+		t.Errorf("expected the alias to be attenuated by %d dB, got %.1f dB", antiAliasDB, -db)
+	}
+
+	// 1 kHz passes
+	d.Reset()
+	d.Process(out, tone(1000, 48000, 0.5, 48000))
+	if db := 20 * math.Log10(peak(out[100:])/0.5); math.Abs(db) > 0.01 {
+		t.Errorf("expected 1 kHz to pass unchanged, got %.3f dB", db)
+	}
+}
+
+func TestInterpolatorImages(t *testing.T) {
+	taps := filter.DesignAntiAlias(3, 97, antiAliasDB)
+	it := filter.NewInterpolator(3, taps, 1)
+	out := make([]float32, 48000)
+	if n := it.Process(out, tone(1000, 16000, 0.5, 16000)); n != 48000 {
+		t.Fatalf("expected 48000 samples, got %d", n)
+	}
+
+	// the output is a clean 1 kHz tone, delayed by half the kernel; images at 15 and 17 kHz would show up in the difference
+	const delay = 48
+	want := tone(1000, 48000, 0.5, 48000)
+	var maxErr float64
+	for i := 300; i < len(out); i++ {
+		maxErr = max(maxErr, math.Abs(float64(out[i]-want[i-delay])))
+	}
+	if db := 20 * math.Log10(maxErr/0.5); db > -antiAliasDB+6 {
+		t.Errorf("expected images to be attenuated by about %d dB, got %.1f dB", antiAliasDB, -db)
+	}
+}
+
+func TestPolyphaseRoundTrip(t *testing.T) {
+	const factor = 4
+	taps := filter.DesignAntiAlias(factor, 97, antiAliasDB)
+
+	src := tone(1000, 16000, 0.3, 4000)
+	for i, x := range tone(3000, 16000, 0.3, 4000) {
+		src[i] += x
+	}
+
+	up := make([]float32, factor*len(src))
+	filter.NewInterpolator(factor, taps, 1).Process(up, src)
+	down := make([]float32, len(src))
+	filter.NewDecimator(factor, taps, 1).Process(down, up)
+
+	// each filter delays by half of the kernel at the higher rate
+	const delay = 2 * 48 / factor
+	for i := 200; i < len(src)-delay; i++ {
+		if d := math.Abs(float64(down[i+delay] - src[i])); d > 1e-3 {
+			t.Fatalf("sample %d: expected %v, got %v", i, src[i], down[i+delay])
+		}
+	}
+}
+
+func TestPolyphaseReaders(t *testing.T) {
+	taps := filter.DesignAntiAlias(2, 33, 60)
+
+	// stereo with different content in each channel
+	src := make([]float32, 2*1001)
+	for i := range src {
+		src[i] = float32(math.Sin(float64(i) * float64(1+i%2)))
+	}
+
+	t.Run("Decimator", func(t *testing.T) {
+		want := make([]float32, len(src))
+		want = want[:filter.NewDecimator(2, taps, 2).Process(want, src)]
+		got := readAll(t, filter.NewDecimatorReader(audio.NewBuffer(slices.Clone(src)), filter.NewDecimator(2, taps, 2)))
+		if !slices.Equal(got, want) {
+			t.Errorf("expected %d samples matching Process, got %d samples", len(want), len(got))
+		}
+	})
+
+	t.Run("Interpolator", func(t *testing.T) {
+		want := make([]float32, 2*len(src))
+		filter.NewInterpolator(2, taps, 2).Process(want, src)
+		got := readAll(t, filter.NewInterpolatorReader(audio.NewBuffer(slices.Clone(src)), filter.NewInterpolator(2, taps, 2)))
+		if !slices.Equal(got, want) {
+			t.Errorf("expected %d samples matching Process, got %d samples", len(want), len(got))
+		}
+	})
+
+	t.Run("Channels", func(t *testing.T) {
+		// channels are filtered separately: the left channel alone gives the same result
+		left := make([]float32, len(src)/2)
+		for i := range left {
+			left[i] = src[2*i]
+		}
+		mono := make([]float32, 2*len(left))
+		filter.NewInterpolator(2, taps, 1).Process(mono, left)
+		stereo := make([]float32, 2*len(src))
+		filter.NewInterpolator(2, taps, 2).Process(stereo, src)
+		for i, x := range mono {
+			if stereo[2*i] != x {
+				t.Fatalf("frame %d: expected %v, got %v", i, x, stereo[2*i])
+			}
+		}
+	})
+}
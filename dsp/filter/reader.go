@@ -0,0 +1,35 @@
+package filter
+
+import "github.com/MatusOllah/resona/aio"
+
+type biquadReader struct {
+	r       aio.SampleReader
+	filters []Biquad // one per channel
+	ch      int      // channel of the next sample
+}
+
+// NewBiquadReader wraps an aio.SampleReader and filters its interleaved output with b.
+// Every channel is filtered separately with its own state; b itself is left untouched.
+func NewBiquadReader(r aio.SampleReader, b *Biquad, numChannels int) aio.SampleReader {
+	br := &biquadReader{
+		r:       r,
+		filters: make([]Biquad, numChannels),
+	}
+	for i := range br.filters {
+		br.filters[i] = *b
+		br.filters[i].Reset()
+	}
+	return br
+}
+
+func (br *biquadReader) ReadSamples(p []float32) (int, error) {
+	n, err := br.r.ReadSamples(p)
+	for i := range p[:n] {
+		p[i] = br.filters[br.ch].ProcessSingle(p[i])
+		br.ch++
+		if br.ch == len(br.filters) {
+			br.ch = 0
+		}
+	}
+	return n, err
+}
@@ -1,2 +1,5 @@
 // Package fourier provides functions for performing Fourier Transforms and related operations.
+//
+// The transforms in this package are limited to power-of-two sizes and allocate on every call.
+// For transforms of any size, or repeated transforms of the same size, see package [github.com/MatusOllah/resona/dsp/fft].
 package fourier
@@ -0,0 +1,24 @@
+package dsp
+
+import (
+	"math"
+
+	"github.com/MatusOllah/resona/freq"
+)
+
+// Goertzel computes the power of samples at the target frequency with the Goertzel algorithm.
+// This is much cheaper than a full DFT when only a few frequencies are of interest.
+//
+// The result is the squared magnitude of the DFT evaluated at the target frequency,
+// which need not lie exactly on a DFT bin. A sine with amplitude A at the target frequency
+// spanning a whole number of periods gives a power of (A*len(samples)/2)².
+func Goertzel(samples []float64, sampleRate, target freq.Frequency) float64 {
+	w := 2 * math.Pi * target.Hertz() / sampleRate.Hertz()
+	coeff := 2 * math.Cos(w)
+
+	var s1, s2 float64
+	for _, x := range samples {
+		s1, s2 = x+coeff*s1-s2, s1
+	}
+	return s1*s1 + s2*s2 - coeff*s1*s2
+}
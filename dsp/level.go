@@ -0,0 +1,42 @@
+package dsp
+
+import "math"
+
+// MeasurePeakDB returns the peak absolute sample value of s in dBFS, or -Inf if s is silent.
+func MeasurePeakDB(s []float32) float64 {
+	return AmplitudeToDB(peak(s))
+}
+
+// NormalizePeak scales s in place so that its peak absolute sample value is targetDB dBFS
+// and returns the linear gain used.
+// Silence can not be normalized and is left unchanged with a gain of 1.
+func NormalizePeak(s []float32, targetDB float64) float64 {
+	g := PeakGain(peak(s), targetDB)
+	if g != 1 {
+		for i := range s {
+			s[i] *= float32(g)
+		}
+	}
+	return g
+}
+
+// PeakGain returns the linear gain that brings a signal with the given peak absolute sample value to targetDB dBFS.
+// It returns 1 if the peak is zero.
+func PeakGain(peak, targetDB float64) float64 {
+	if peak == 0 {
+		return 1
+	}
+	return DBToAmplitude(targetDB) / peak
+}
+
+func peak(s []float32) float64 {
+	var p float32
+	for _, x := range s {
+		p = max(p, abs(x))
+	}
+	return float64(p)
+}
+
+func abs(x float32) float32 {
+	return math.Float32frombits(math.Float32bits(x) &^ (1 << 31))
+}
@@ -0,0 +1,252 @@
+// Package loudness implements loudness measurement according to ITU-R BS.1770-4 and EBU R 128,
+// and loudness normalization built on it.
+package loudness
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/dsp/filter"
+)
+
+const (
+	// offset is the constant of the loudness formula, which makes a full-scale 997 Hz sine in one channel -3.01 LUFS.
+	offset = -0.691
+
+	absoluteGate = -70.0 // LUFS
+	relativeGate = -10.0 // LU below the ungated loudness
+
+	subBlocksPerSecond = 10 // the gating blocks overlap by 75%, so they start every 100 ms
+	momentaryBlocks    = 4  // 400 ms
+	shortTermBlocks    = 30 // 3 s
+)
+
+// kWeighting returns the two stages of the K-weighting filter for the given sample rate:
+// a high shelf modelling the acoustic effect of the head, and a high-pass filter.
+// The coefficients are derived from the analog prototypes, so that they match the ones given by BS.1770 at 48 kHz.
+func kWeighting(sampleRate float64) (shelf, highpass *filter.Biquad) {
+	const (
+		shelfFreq = 1681.974450955533
+		shelfGain = 3.999843853973347 // dB
+		shelfQ    = 0.7071752369554196
+		hpFreq    = 38.13547087602444
+		hpQ       = 0.5003270373238773
+	)
+	k := math.Tan(math.Pi * shelfFreq / sampleRate)
+	vh := math.Pow(10, shelfGain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	shelf = filter.NewBiquad(vh+vb*k/shelfQ+k*k, 2*(k*k-vh), vh-vb*k/shelfQ+k*k, 1+k/shelfQ+k*k, 2*(k*k-1), 1-k/shelfQ+k*k)
+
+	k = math.Tan(math.Pi * hpFreq / sampleRate)
+	highpass = filter.NewBiquad(1, -2, 1, 1+k/hpQ+k*k, 2*(k*k-1), 1-k/hpQ+k*k)
+	return shelf, highpass
+}
+
+// channelWeights returns the weights of the channels in the loudness, which are 1 except for the surround channels
+// of 5.0 (L, R, C, Ls, Rs) and 5.1 (L, R, C, LFE, Ls, Rs) audio, which weigh 1.41, and the LFE channel, which is left out.
+func channelWeights(numChannels int) []float64 {
+	w := make([]float64, numChannels)
+	for i := range w {
+		w[i] = 1
+	}
+	switch numChannels {
+	case 5:
+		w[3], w[4] = 1.41, 1.41
+	case 6:
+		w[3], w[4], w[5] = 0, 1.41, 1.41
+	}
+	return w
+}
+
+type channel struct {
+	shelf, highpass *filter.Biquad
+	weight          float64
+}
+
+// Meter wraps an aio.SampleReader and measures the loudness of the samples read through it,
+// which it passes through unchanged.
+//
+// The loudness is measured in LUFS (loudness units relative to full scale) as specified by ITU-R BS.1770-4:
+// the channels are K-weighted, which roughly models how loud different frequencies sound,
+// and their mean square is summed over blocks of 400 ms. The integrated loudness averages the blocks,
+// leaving out silence and quiet passages with the absolute and relative gates of EBU R 128,
+// so that it reflects the loudness of the programme as a whole.
+//
+// Like [meter.Meter], a Meter is safe for concurrent use.
+type Meter struct {
+	r           aio.SampleReader
+	numChannels int
+	err         error
+
+	mu           sync.Mutex
+	channels     []channel
+	ch           int     // channel of the next sample
+	frame        float64 // weighted sum of the squares of the current frame
+	sum          float64 // weighted sum of the squares of the current sub-block
+	frames       int     // frames in the current sub-block
+	subBlockSize int     // frames per sub-block
+	subBlocks    []float64
+	blocks       []float64 // mean square of every gating block so far
+	peak         float64
+}
+
+// NewMeter creates a new [Meter] reading samples of the given format from r.
+func NewMeter(r aio.SampleReader, format afmt.Format) *Meter {
+	m := &Meter{
+		r:           r,
+		numChannels: format.NumChannels,
+	}
+	if format.NumChannels <= 0 {
+		m.err = fmt.Errorf("loudness: invalid number of channels: %d", format.NumChannels)
+		return m
+	}
+	if format.SampleRate <= 0 {
+		m.err = fmt.Errorf("loudness: invalid sample rate: %v", format.SampleRate)
+		return m
+	}
+
+	sampleRate := format.SampleRate.Hertz()
+	m.subBlockSize = max(int(math.Round(sampleRate/subBlocksPerSecond)), 1)
+	m.channels = make([]channel, format.NumChannels)
+	for i, w := range channelWeights(format.NumChannels) {
+		shelf, highpass := kWeighting(sampleRate)
+		m.channels[i] = channel{shelf: shelf, highpass: highpass, weight: w}
+	}
+	return m
+}
+
+// ReadSamples reads samples into p and updates the loudness.
+// It returns the number of samples read and/or an error.
+func (m *Meter) ReadSamples(p []float32) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+
+	n, err := m.r.ReadSamples(p)
+
+	m.mu.Lock()
+	for _, x := range p[:n] {
+		m.process(x)
+	}
+	m.mu.Unlock()
+
+	return n, err
+}
+
+// process adds a sample of the current channel.
+// m.mu must be held.
+func (m *Meter) process(x float32) {
+	c := &m.channels[m.ch]
+	m.peak = max(m.peak, math.Abs(float64(x)))
+	y := float64(c.highpass.ProcessSingle(c.shelf.ProcessSingle(x)))
+	m.frame += c.weight * y * y
+
+	m.ch++
+	if m.ch < m.numChannels {
+		return
+	}
+	m.ch = 0
+	m.sum += m.frame
+	m.frame = 0
+	m.frames++
+	if m.frames < m.subBlockSize {
+		return
+	}
+
+	if len(m.subBlocks) == shortTermBlocks {
+		m.subBlocks = append(m.subBlocks[:0], m.subBlocks[1:]...)
+	}
+	m.subBlocks = append(m.subBlocks, m.sum/float64(m.frames))
+	m.sum, m.frames = 0, 0
+	if len(m.subBlocks) >= momentaryBlocks {
+		m.blocks = append(m.blocks, mean(m.subBlocks[len(m.subBlocks)-momentaryBlocks:]))
+	}
+}
+
+func mean(s []float64) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range s {
+		sum += x
+	}
+	return sum / float64(len(s))
+}
+
+// toLUFS converts a weighted mean square to a loudness in LUFS, which is -Inf for silence.
+func toLUFS(ms float64) float64 {
+	return offset + dsp.PowerToDB(ms)
+}
+
+// recent returns the loudness of the last n sub-blocks, or of as many as there are.
+func (m *Meter) recent(n int) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return toLUFS(mean(m.subBlocks[max(len(m.subBlocks)-n, 0):]))
+}
+
+// Momentary returns the momentary loudness in LUFS, that is, the loudness of the last 400 ms.
+// It is -Inf until the first 100 ms have been read.
+func (m *Meter) Momentary() float64 {
+	return m.recent(momentaryBlocks)
+}
+
+// ShortTerm returns the short-term loudness in LUFS, that is, the loudness of the last 3 s.
+func (m *Meter) ShortTerm() float64 {
+	return m.recent(shortTermBlocks)
+}
+
+// Integrated returns the gated loudness in LUFS of everything read since the meter was created or reset.
+// It is -Inf if there is nothing above the absolute gate of -70 LUFS, such as for silence
+// or audio shorter than 400 ms.
+func (m *Meter) Integrated() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var gated []float64
+	for _, b := range m.blocks {
+		if toLUFS(b) > absoluteGate {
+			gated = append(gated, b)
+		}
+	}
+	threshold := toLUFS(mean(gated)) + relativeGate
+	var sum float64
+	var n int
+	for _, b := range gated {
+		if toLUFS(b) > threshold {
+			sum += b
+			n++
+		}
+	}
+	if n == 0 {
+		return math.Inf(-1)
+	}
+	return toLUFS(sum / float64(n))
+}
+
+// Peak returns the highest absolute sample value of all channels since the meter was created or reset.
+// Unlike [meter.TruePeak], it does not account for peaks between the samples.
+func (m *Meter) Peak() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.peak
+}
+
+// Reset clears the loudness.
+func (m *Meter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.channels {
+		m.channels[i].shelf.Reset()
+		m.channels[i].highpass.Reset()
+	}
+	m.ch, m.frame, m.sum, m.frames = 0, 0, 0, 0
+	m.subBlocks = m.subBlocks[:0]
+	m.blocks = m.blocks[:0]
+	m.peak = 0
+}
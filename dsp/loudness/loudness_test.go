@@ -0,0 +1,176 @@
+package loudness_test
+
+import (
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp/loudness"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var stereo = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+// sine returns seconds of a 997 Hz sine with the given amplitude in every channel of the format.
+func sine(format afmt.Format, amplitude, seconds float64) []float32 {
+	rate := format.SampleRate.Hertz()
+	n := int(seconds * rate)
+	p := make([]float32, n*format.NumChannels)
+	for i := range n {
+		s := float32(amplitude * math.Sin(2*math.Pi*997*float64(i)/rate))
+		for ch := range format.NumChannels {
+			p[i*format.NumChannels+ch] = s
+		}
+	}
+	return p
+}
+
+func measure(t *testing.T, p []float32, format afmt.Format) loudness.Measurement {
+	t.Helper()
+	m, err := loudness.Measure(audio.NewBuffer(p), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMeasureSine(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		format    afmt.Format
+		amplitude float64
+		want      float64
+	}{
+		// a full-scale sine in one channel is -3.01 LUFS, and every channel adds up
+		{"mono full scale", afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}, 1, -3.01},
+		{"stereo -20 dBFS", stereo, 0.1, -20},
+		{"stereo 44.1kHz", afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 2}, 0.1, -20},
+		{"5.1 without LFE", afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 6}, 0.1, -20 + 10*math.Log10(1.5+1.41)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := measure(t, sine(tt.format, tt.amplitude, 5), tt.format)
+			if math.Abs(m.Integrated-tt.want) > 0.1 {
+				t.Errorf("expected %.2f LUFS, got %.2f LUFS", tt.want, m.Integrated)
+			}
+			if math.Abs(m.Peak-tt.amplitude) > 1e-3 {
+				t.Errorf("expected a peak of %v, got %v", tt.amplitude, m.Peak)
+			}
+		})
+	}
+}
+
+func TestMeasureGating(t *testing.T) {
+	// silence is left out by the absolute gate; the blocks straddling the change are a little quieter
+	p := append(make([]float32, 2*48000*5), sine(stereo, 0.1, 5)...)
+	if got := measure(t, p, stereo).Integrated; math.Abs(got+20) > 0.25 {
+		t.Errorf("expected silence to be gated, got %.2f LUFS", got)
+	}
+
+	// a passage 20 LU quieter than the rest is left out by the relative gate
+	p = append(sine(stereo, 0.1, 5), sine(stereo, 0.01, 5)...)
+	if got := measure(t, p, stereo).Integrated; math.Abs(got+20) > 0.25 {
+		t.Errorf("expected the quiet passage to be gated, got %.2f LUFS", got)
+	}
+
+	if got := measure(t, make([]float32, 2*48000), stereo).Integrated; !math.IsInf(got, -1) {
+		t.Errorf("expected -Inf LUFS for silence, got %.2f LUFS", got)
+	}
+}
+
+func TestMeterMomentary(t *testing.T) {
+	p := append(sine(stereo, 0.1, 4), sine(stereo, 0.01, 1)...)
+	m := loudness.NewMeter(audio.NewBuffer(p), stereo)
+	if _, err := aio.ReadAll(m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Momentary(); math.Abs(got+40) > 0.1 {
+		t.Errorf("expected a momentary loudness of -40 LUFS, got %.2f LUFS", got)
+	}
+	if got := m.ShortTerm(); got < -40 || got > -20 {
+		t.Errorf("expected a short-term loudness between the passages, got %.2f LUFS", got)
+	}
+
+	m.Reset()
+	if got := m.Integrated(); !math.IsInf(got, -1) {
+		t.Errorf("expected -Inf LUFS after a reset, got %.2f LUFS", got)
+	}
+}
+
+func TestMeasurementGain(t *testing.T) {
+	m := loudness.Measurement{Integrated: -20, Peak: 0.1}
+	if got := m.Gain(-16); math.Abs(got-4) > 1e-9 {
+		t.Errorf("expected a gain of 4 dB, got %v", got)
+	}
+	if got := m.Gain(-23); math.Abs(got+3) > 1e-9 {
+		t.Errorf("expected a gain of -3 dB, got %v", got)
+	}
+
+	// the gain is limited by the peak
+	m.Peak = 0.5
+	if got, want := m.Gain(0), -20*math.Log10(0.5); math.Abs(got-want) > 1e-9 {
+		t.Errorf("expected the gain to be limited to %v dB, got %v", want, got)
+	}
+
+	if got := (loudness.Measurement{Integrated: math.Inf(-1)}).Gain(-16); got != 0 {
+		t.Errorf("expected no gain for silence, got %v", got)
+	}
+}
+
+// unseekable is a reader that cannot be sought.
+type unseekable struct {
+	aio.SampleReader
+}
+
+func (unseekable) Seek(int64, int) (int64, error) { return 0, errors.New("not seekable") }
+
+func TestMeasureSeeker(t *testing.T) {
+	p := sine(stereo, 0.1, 2)
+	r := audio.NewReader(p)
+	if _, err := r.Seek(100, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	m, err := loudness.MeasureSeeker(r, stereo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(m.Integrated+20) > 0.1 {
+		t.Errorf("expected -20 LUFS, got %.2f LUFS", m.Integrated)
+	}
+	if pos, _ := r.Seek(0, io.SeekCurrent); pos != 100 {
+		t.Errorf("expected to be sought back to 100, got %d", pos)
+	}
+
+	if _, err := loudness.MeasureSeeker(unseekable{audio.NewBuffer(p)}, stereo); !errors.Is(err, loudness.ErrNotSeekable) {
+		t.Errorf("expected ErrNotSeekable, got %v", err)
+	}
+}
+
+func TestFromReplayGain(t *testing.T) {
+	m, ok := loudness.FromReplayGain(map[string][]string{
+		"replaygain_track_gain": {"-6.50 dB"},
+		"REPLAYGAIN_TRACK_PEAK": {"0.988"},
+	})
+	if !ok {
+		t.Fatal("expected the tags to be found")
+	}
+	if m.Integrated != -11.5 || m.Peak != 0.988 {
+		t.Errorf("expected -11.5 LUFS and a peak of 0.988, got %+v", m)
+	}
+	if got := m.Gain(-16); math.Abs(got+4.5) > 1e-9 {
+		t.Errorf("expected a gain of -4.5 dB, got %v", got)
+	}
+
+	for _, tags := range []map[string][]string{
+		nil,
+		{"REPLAYGAIN_ALBUM_GAIN": {"-3 dB"}},
+		{"REPLAYGAIN_TRACK_GAIN": {"loud"}},
+	} {
+		if _, ok := loudness.FromReplayGain(tags); ok {
+			t.Errorf("expected no gain from %v", tags)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package loudness
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+)
+
+// ReplayGainReference is the loudness in LUFS that ReplayGain 2.0 tags adjust the audio to.
+const ReplayGainReference = -18.0
+
+// Measurement is the result of measuring the loudness of some audio.
+type Measurement struct {
+	// Integrated is the integrated loudness in LUFS; see [Meter.Integrated].
+	Integrated float64
+
+	// Peak is the highest absolute sample value, or 0 if it is not known.
+	Peak float64
+}
+
+// Gain returns the gain in dB that brings the audio to the target loudness in LUFS, as far as it can be raised
+// without the peak exceeding full scale. It returns 0 for silence.
+func (m Measurement) Gain(target float64) float64 {
+	if math.IsInf(m.Integrated, -1) {
+		return 0
+	}
+	gain := target - m.Integrated
+	if m.Peak > 0 {
+		gain = min(gain, -dsp.AmplitudeToDB(m.Peak))
+	}
+	return gain
+}
+
+// Measure reads r, whose samples are in the given format, until it ends and returns its loudness.
+func Measure(r aio.SampleReader, format afmt.Format) (Measurement, error) {
+	m := NewMeter(r, format)
+	if m.err != nil {
+		return Measurement{}, m.err
+	}
+	buf := make([]float32, 4096/format.NumChannels*format.NumChannels)
+	for {
+		_, err := m.ReadSamples(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Measurement{}, err
+		}
+	}
+	return Measurement{Integrated: m.Integrated(), Peak: m.Peak()}, nil
+}
+
+// ErrNotSeekable is returned by [MeasureSeeker] if the reader cannot be sought back after measuring it.
+var ErrNotSeekable = errors.New("loudness: reader is not seekable")
+
+// MeasureSeeker is like [Measure], but measures rs from its current position and seeks it back to that position afterwards,
+// so that it can be played or processed with the measured gain. It checks that rs can be sought before reading it,
+// and returns an error wrapping [ErrNotSeekable] otherwise, leaving rs untouched.
+func MeasureSeeker(rs aio.SampleReadSeeker, format afmt.Format) (Measurement, error) {
+	pos, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return Measurement{}, fmt.Errorf("%w: %w", ErrNotSeekable, err)
+	}
+	m, err := Measure(rs, format)
+	if err != nil {
+		return Measurement{}, err
+	}
+	if _, err := rs.Seek(pos, io.SeekStart); err != nil {
+		return Measurement{}, fmt.Errorf("loudness: failed to seek back: %w", err)
+	}
+	return m, nil
+}
+
+// FromReplayGain returns the loudness described by the ReplayGain track tags REPLAYGAIN_TRACK_GAIN
+// and REPLAYGAIN_TRACK_PEAK, assuming the ReplayGain 2.0 reference level. The keys are matched case-insensitively.
+// It reports false if there is no valid gain tag.
+func FromReplayGain(tags map[string][]string) (Measurement, bool) {
+	gain, ok := tagValue(tags, "REPLAYGAIN_TRACK_GAIN")
+	if !ok {
+		return Measurement{}, false
+	}
+	m := Measurement{Integrated: ReplayGainReference - gain}
+	if peak, ok := tagValue(tags, "REPLAYGAIN_TRACK_PEAK"); ok && peak > 0 {
+		m.Peak = peak
+	}
+	return m, true
+}
+
+// tagValue parses the first value of the tag with the given key, such as "-6.48 dB".
+func tagValue(tags map[string][]string, key string) (float64, bool) {
+	for k, values := range tags {
+		if !strings.EqualFold(k, key) || len(values) == 0 {
+			continue
+		}
+		s := strings.TrimSpace(values[0])
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(s, "dB"), "DB"))
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0, false
+		}
+		return v, true
+	}
+	return 0, false
+}
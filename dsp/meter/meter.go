@@ -0,0 +1,210 @@
+// Package meter implements audio level metering.
+package meter
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+)
+
+// Default settings of a [Meter].
+const (
+	DefaultWindow = 300 * time.Millisecond
+	DefaultDecay  = 20.0 // dB per second
+)
+
+// Option configures a [Meter].
+type Option func(*options)
+
+type options struct {
+	window time.Duration
+	decay  float64
+	err    error
+}
+
+// WithWindow sets the length of the window the RMS level is computed over. The default is [DefaultWindow].
+func WithWindow(d time.Duration) Option {
+	return func(o *options) {
+		if d <= 0 {
+			o.err = fmt.Errorf("meter: invalid window: %v", d)
+			return
+		}
+		o.window = d
+	}
+}
+
+// WithDecay sets how fast the held peak level falls, in dB per second. The default is [DefaultDecay].
+func WithDecay(dbPerSecond float64) Option {
+	return func(o *options) {
+		if dbPerSecond < 0 {
+			o.err = fmt.Errorf("meter: invalid decay: %v", dbPerSecond)
+			return
+		}
+		o.decay = dbPerSecond
+	}
+}
+
+type channel struct {
+	peak    float64 // held peak, decaying over time
+	maxPeak float64 // highest peak since the last reset
+
+	squares []float64 // ring buffer of the squared samples in the RMS window
+	pos     int
+	filled  int
+	sum     float64
+}
+
+func (c *channel) process(x, decay float64) {
+	a := math.Abs(x)
+	c.peak = max(a, c.peak*decay)
+	c.maxPeak = max(a, c.maxPeak)
+
+	sq := x * x
+	c.sum += sq - c.squares[c.pos]
+	c.squares[c.pos] = sq
+	c.pos++
+	if c.pos == len(c.squares) {
+		c.pos = 0
+
+		// recompute the sum once per window so that rounding errors do not accumulate
+		c.sum = 0
+		for _, v := range c.squares {
+			c.sum += v
+		}
+	}
+	c.filled = min(c.filled+1, len(c.squares))
+}
+
+func (c *channel) rms() float64 {
+	if c.filled == 0 {
+		return 0
+	}
+	return math.Sqrt(max(c.sum, 0) / float64(c.filled))
+}
+
+func (c *channel) reset() {
+	c.peak, c.maxPeak = 0, 0
+	clear(c.squares)
+	c.pos, c.filled, c.sum = 0, 0, 0
+}
+
+// Meter wraps an aio.SampleReader and measures the levels of every channel of the samples read through it,
+// which it passes through unchanged.
+//
+// A Meter is safe for concurrent use, so levels can be read from a UI goroutine while
+// ReadSamples is called from the audio goroutine.
+type Meter struct {
+	r           aio.SampleReader
+	numChannels int
+	decay       float64 // per-frame multiplier of the held peak
+	err         error
+
+	mu       sync.Mutex
+	channels []channel
+	ch       int // channel of the next sample
+}
+
+// NewMeter creates a new [Meter] reading samples of the given format from r.
+func NewMeter(r aio.SampleReader, format afmt.Format, opts ...Option) *Meter {
+	o := options{window: DefaultWindow, decay: DefaultDecay}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	m := &Meter{
+		r:           r,
+		numChannels: format.NumChannels,
+		decay:       dsp.DBToAmplitude(-o.decay / format.SampleRate.Hertz()),
+		err:         o.err,
+	}
+	if m.err == nil && format.NumChannels <= 0 {
+		m.err = fmt.Errorf("meter: invalid number of channels: %d", format.NumChannels)
+	}
+	if m.err == nil && format.SampleRate <= 0 {
+		m.err = fmt.Errorf("meter: invalid sample rate: %v", format.SampleRate)
+	}
+	if m.err != nil {
+		return m
+	}
+
+	windowFrames := max(afmt.DurationToNumFrames(format.SampleRate, o.window), 1)
+	m.channels = make([]channel, format.NumChannels)
+	for i := range m.channels {
+		m.channels[i].squares = make([]float64, windowFrames)
+	}
+	return m
+}
+
+// ReadSamples reads samples into p and updates the levels.
+// It returns the number of samples read and/or an error.
+func (m *Meter) ReadSamples(p []float32) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+
+	n, err := m.r.ReadSamples(p)
+
+	m.mu.Lock()
+	for _, x := range p[:n] {
+		m.channels[m.ch].process(float64(x), m.decay)
+		m.ch++
+		if m.ch == m.numChannels {
+			m.ch = 0
+		}
+	}
+	m.mu.Unlock()
+
+	return n, err
+}
+
+// Peak returns the held peak level of channel ch as a linear amplitude.
+// The held peak follows new peaks instantly and falls at the configured decay rate.
+func (m *Meter) Peak(ch int) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.channels[ch].peak
+}
+
+// PeakDB returns the held peak level of channel ch in dBFS. See [Meter.Peak].
+func (m *Meter) PeakDB(ch int) float64 {
+	return dsp.AmplitudeToDB(m.Peak(ch))
+}
+
+// MaxPeak returns the highest peak level of channel ch since the meter was created or reset, as a linear amplitude.
+func (m *Meter) MaxPeak(ch int) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.channels[ch].maxPeak
+}
+
+// MaxPeakDB returns the highest peak level of channel ch in dBFS. See [Meter.MaxPeak].
+func (m *Meter) MaxPeakDB(ch int) float64 {
+	return dsp.AmplitudeToDB(m.MaxPeak(ch))
+}
+
+// RMS returns the RMS level of channel ch over the configured window as a linear amplitude.
+func (m *Meter) RMS(ch int) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.channels[ch].rms()
+}
+
+// RMSDB returns the RMS level of channel ch in dBFS. See [Meter.RMS].
+// A full-scale sine wave has an RMS level of about -3 dBFS.
+func (m *Meter) RMSDB(ch int) float64 {
+	return dsp.AmplitudeToDB(m.RMS(ch))
+}
+
+// Reset clears all levels.
+func (m *Meter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.channels {
+		m.channels[i].reset()
+	}
+}
@@ -0,0 +1,125 @@
+package meter_test
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp/meter"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var format = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+// stereoSine returns a second of a 1 kHz sine with the given amplitude in each channel.
+func stereoSine(left, right float64) []float32 {
+	const n = 48000
+	p := make([]float32, 2*n)
+	for i := range n {
+		s := math.Sin(2 * math.Pi * 1000 * float64(i) / n)
+		p[2*i] = float32(left * s)
+		p[2*i+1] = float32(right * s)
+	}
+	return p
+}
+
+func TestMeterSine(t *testing.T) {
+	src := stereoSine(0.5, 0.1)
+	m := meter.NewMeter(audio.NewBuffer(slices.Clone(src)), format)
+
+	got, err := aio.ReadAll(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, src) {
+		t.Error("samples are not passed through unchanged")
+	}
+
+	for ch, amplitude := range []float64{0.5, 0.1} {
+		if rms := m.RMS(ch); math.Abs(rms-amplitude/math.Sqrt2) > 1e-4 {
+			t.Errorf("channel %d: expected an RMS level of %v, got %v", ch, amplitude/math.Sqrt2, rms)
+		}
+		if db, want := m.RMSDB(ch), 20*math.Log10(amplitude/math.Sqrt2); math.Abs(db-want) > 0.01 {
+			t.Errorf("channel %d: expected %.2f dBFS RMS, got %.2f dBFS", ch, want, db)
+		}
+		if peak := m.MaxPeak(ch); math.Abs(peak-amplitude) > 1e-6 {
+			t.Errorf("channel %d: expected a peak of %v, got %v", ch, amplitude, peak)
+		}
+		// the held peak decays a little between the peaks of the sine
+		if db, want := m.PeakDB(ch), 20*math.Log10(amplitude); db > want || db < want-0.1 {
+			t.Errorf("channel %d: expected a held peak of about %.2f dBFS, got %.2f dBFS", ch, want, db)
+		}
+	}
+}
+
+func TestMeterDecay(t *testing.T) {
+	// a full-scale click, followed by a second of silence
+	src := make([]float32, 2*48000)
+	src[0], src[1] = 1, 1
+	m := meter.NewMeter(audio.NewBuffer(src), format, meter.WithDecay(30), meter.WithWindow(100*time.Millisecond))
+	if _, err := aio.ReadAll(m); err != nil {
+		t.Fatal(err)
+	}
+
+	if db := m.PeakDB(0); math.Abs(db+30) > 0.01 {
+		t.Errorf("expected the held peak to fall to -30 dBFS, got %.2f dBFS", db)
+	}
+	if db := m.MaxPeakDB(0); db != 0 {
+		t.Errorf("expected a maximum peak of 0 dBFS, got %.2f dBFS", db)
+	}
+	// the click is long out of the RMS window
+	if db := m.RMSDB(1); !math.IsInf(db, -1) {
+		t.Errorf("expected silence, got %.2f dBFS RMS", db)
+	}
+
+	m.Reset()
+	if db := m.MaxPeakDB(0); !math.IsInf(db, -1) {
+		t.Errorf("expected silence after Reset, got %.2f dBFS", db)
+	}
+}
+
+func TestMeterConcurrent(t *testing.T) {
+	m := meter.NewMeter(audio.NewBuffer(stereoSine(0.5, 0.5)), format)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = m.PeakDB(0)
+				_ = m.RMSDB(1)
+			}
+		}
+	}()
+
+	buf := make([]float32, 256)
+	for {
+		if _, err := m.ReadSamples(buf); err != nil {
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestMeterInvalid(t *testing.T) {
+	for name, m := range map[string]*meter.Meter{
+		"Window":   meter.NewMeter(audio.NewBuffer(nil), format, meter.WithWindow(0)),
+		"Decay":    meter.NewMeter(audio.NewBuffer(nil), format, meter.WithDecay(-1)),
+		"Channels": meter.NewMeter(audio.NewBuffer(nil), afmt.Format{SampleRate: format.SampleRate}),
+	} {
+		if _, err := m.ReadSamples(make([]float32, 2)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package meter_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/dsp/meter"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var monoFormat = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}
+
+// truePeakSine returns a second of a sine with the given frequency, amplitude and phase in degrees at 48 kHz.
+// It fades in over 10 ms, so that switching it on does not overshoot.
+func truePeakSine(f, amplitude, phase float64) []float32 {
+	p := make([]float32, 48000)
+	for i := range p {
+		gain := amplitude
+		if i < 480 {
+			gain *= 0.5 - 0.5*math.Cos(math.Pi*float64(i)/480)
+		}
+		p[i] = float32(gain * math.Sin(2*math.Pi*f*float64(i)/48000+phase*math.Pi/180))
+	}
+	return p
+}
+
+func measureTruePeak(t *testing.T, p []float32, format afmt.Format) *meter.TruePeak {
+	t.Helper()
+
+	tp := meter.NewTruePeak(audio.NewBuffer(p), format)
+	if _, err := aio.ReadAll(tp); err != nil {
+		t.Fatal(err)
+	}
+	return tp
+}
+
+func TestTruePeak(t *testing.T) {
+	// Signals in the spirit of the true-peak test signals of ITU-R BS.2217 and EBU Tech 3341,
+	// with their samples placed away from the peaks of the waveform so that the sample peak is well below the true peak.
+	//
+	// The gain of the phases of the interpolator ripples by about ±0.2 dB, so the true peak is measured within 0.2 dB.
+	// Above fs/4 the interpolator reads up to 0.4 dB low, which is the tolerance EBU Tech 3341 allows for true-peak meters.
+	tests := []struct {
+		f, amplitude, phase float64
+		samplePeakDB        float64
+		tolBelow            float64
+	}{
+		{997, 1, 0, 0, 0.2},                // no inter-sample peaks to speak of
+		{8000, 1, 15, -0.30, 0.2},          // fs/6, samples at 15° and 75°
+		{9600, 1, 0, -0.44, 0.2},           // fs/5, samples at 0°, 72° and 144°
+		{12000, 1, 45, -3.01, 0.2},         // fs/4, samples at ±45°
+		{12000, 0.5, 45, -9.03, 0.2},       // the same 6 dB lower
+		{16000, 1, 0, -1.25, 0.4},          // fs/3, samples at 0° and ±120°
+		{19000, 0.25, 30, math.NaN(), 0.4}, // close to the end of the passband
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%vHz/%v/%v°", tt.f, tt.amplitude, tt.phase), func(t *testing.T) {
+			p := truePeakSine(tt.f, tt.amplitude, tt.phase)
+			if !math.IsNaN(tt.samplePeakDB) {
+				if got := dsp.MeasurePeakDB(p); math.Abs(got-tt.samplePeakDB) > 0.01 {
+					t.Fatalf("expected a sample peak of %v dBFS, got %v dBFS", tt.samplePeakDB, got)
+				}
+			}
+
+			want := dsp.AmplitudeToDB(tt.amplitude)
+			got := measureTruePeak(t, p, monoFormat).PeakDB(0)
+			if got > want+0.2 || got < want-tt.tolBelow {
+				t.Errorf("expected a true peak of %.2f dBTP, got %.2f dBTP", want, got)
+			}
+		})
+	}
+}
+
+func TestTruePeakChannels(t *testing.T) {
+	left, right := truePeakSine(12000, 1, 45), truePeakSine(1000, 0.1, 0)
+	p := make([]float32, 2*len(left))
+	for i := range left {
+		p[2*i], p[2*i+1] = left[i], right[i]
+	}
+
+	tp := measureTruePeak(t, p, afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2})
+	if got := tp.PeakDB(0); math.Abs(got) > 0.2 {
+		t.Errorf("left: expected 0 dBTP, got %.2f dBTP", got)
+	}
+	if got := tp.PeakDB(1); math.Abs(got+20) > 0.2 {
+		t.Errorf("right: expected -20 dBTP, got %.2f dBTP", got)
+	}
+
+	tp.Reset()
+	if got := tp.PeakDB(0); !math.IsInf(got, -1) {
+		t.Errorf("expected silence after Reset, got %.2f dBTP", got)
+	}
+}
+
+func TestTruePeakInvalid(t *testing.T) {
+	if _, err := meter.NewTruePeak(audio.NewBuffer(nil), afmt.Format{SampleRate: 48 * freq.KiloHertz}).ReadSamples(make([]float32, 2)); err == nil {
+		t.Error("expected error for invalid number of channels")
+	}
+}
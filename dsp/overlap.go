@@ -0,0 +1,224 @@
+package dsp
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp/window"
+)
+
+// OverlapOption configures an [OverlapProcessor].
+type OverlapOption func(*OverlapProcessor)
+
+// WithAnalysisWindow sets the window applied to frames before they are passed to the callback.
+func WithAnalysisWindow(fn window.WindowFunc) OverlapOption {
+	return func(p *OverlapProcessor) {
+		p.analysis = fn(p.frameSize)
+	}
+}
+
+// WithSynthesisWindow sets the window applied to frames returned by the callback before they are overlap-added.
+func WithSynthesisWindow(fn window.WindowFunc) OverlapOption {
+	return func(p *OverlapProcessor) {
+		p.synthesis = fn(p.frameSize)
+	}
+}
+
+// OverlapProcessor implements windowed block processing with overlap-add, as used by spectral effects.
+//
+// The signal is cut into overlapping frames, one every hop samples. Every frame is multiplied by the analysis window
+// and passed to a callback, which may modify it in place. The result is multiplied by the synthesis window and
+// added to the output, which is normalized by the overlapping sum of the windows.
+// Any pair of windows whose product does not sum to 0 anywhere therefore reconstructs the input if the callback
+// does nothing. By default, both windows are the square root of a periodic Hann window, which also keeps the
+// transitions between modified frames smooth.
+//
+// Every channel of the interleaved signal is processed separately; the callback is called for each channel in turn.
+type OverlapProcessor struct {
+	frameSize   int
+	hopSize     int
+	numChannels int
+	fn          func(frame []float64)
+
+	analysis  []float64
+	synthesis []float64
+	norm      []float64 // reciprocal of the overlapping sum of the windows at every position of a hop
+
+	channels []overlapChannel
+	frame    []float64
+	pos      int // position within the current hop
+}
+
+type overlapChannel struct {
+	in    []float64 // the last frameSize input samples
+	acc   []float64 // overlap-add accumulator
+	ready []float64 // the finished output of the last hop
+}
+
+// NewOverlapProcessor creates a new [OverlapProcessor] with the given frame and hop size.
+// It panics if the sizes are invalid or if the windows do not overlap.
+func NewOverlapProcessor(frameSize, hopSize, numChannels int, fn func(frame []float64), opts ...OverlapOption) *OverlapProcessor {
+	if frameSize <= 0 || hopSize <= 0 || hopSize > frameSize {
+		panic(fmt.Sprintf("dsp: invalid frame size %d and hop size %d", frameSize, hopSize))
+	}
+	if numChannels <= 0 {
+		panic("dsp: invalid number of channels")
+	}
+
+	p := &OverlapProcessor{
+		frameSize:   frameSize,
+		hopSize:     hopSize,
+		numChannels: numChannels,
+		fn:          fn,
+		analysis:    sqrtHann(frameSize),
+		synthesis:   sqrtHann(frameSize),
+		frame:       make([]float64, frameSize),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.norm = make([]float64, hopSize)
+	for i := range p.norm {
+		var sum float64
+		for j := i; j < frameSize; j += hopSize {
+			sum += p.analysis[j] * p.synthesis[j]
+		}
+		if math.Abs(sum) < 1e-9 {
+			panic("dsp: overlap-add windows sum to zero")
+		}
+		p.norm[i] = 1 / sum
+	}
+
+	p.channels = make([]overlapChannel, numChannels)
+	for ch := range p.channels {
+		p.channels[ch] = overlapChannel{
+			in:    make([]float64, frameSize),
+			acc:   make([]float64, frameSize),
+			ready: make([]float64, hopSize),
+		}
+	}
+	return p
+}
+
+// sqrtHann returns the square root of an n-point periodic Hann window.
+func sqrtHann(n int) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = math.Sin(math.Pi * float64(i) / float64(n))
+	}
+	return w
+}
+
+// Latency returns the delay of the output relative to the input in frames, which is one less than the frame size.
+func (p *OverlapProcessor) Latency() int {
+	return p.frameSize - 1
+}
+
+// Process processes the interleaved samples src and writes the result, delayed by [OverlapProcessor.Latency], to dst.
+// dst must be at least as long as src and src must contain whole frames. dst and src may be the same slice.
+func (p *OverlapProcessor) Process(dst, src []float32) {
+	if len(src)%p.numChannels != 0 {
+		panic("dsp: incomplete frame")
+	}
+
+	for i := 0; i < len(src); i += p.numChannels {
+		for ch := range p.channels {
+			p.channels[ch].in[p.frameSize-p.hopSize+p.pos] = float64(src[i+ch])
+		}
+		p.pos++
+		if p.pos == p.hopSize {
+			p.hop()
+			p.pos = 0
+		}
+		for ch := range p.channels {
+			dst[i+ch] = float32(p.channels[ch].ready[p.pos])
+		}
+	}
+}
+
+// hop processes the current frame of every channel.
+func (p *OverlapProcessor) hop() {
+	for ch := range p.channels {
+		c := &p.channels[ch]
+
+		for i, x := range c.in {
+			p.frame[i] = x * p.analysis[i]
+		}
+		if p.fn != nil {
+			p.fn(p.frame)
+		}
+		for i, x := range p.frame {
+			c.acc[i] += x * p.synthesis[i]
+		}
+
+		for i := range c.ready {
+			c.ready[i] = c.acc[i] * p.norm[i]
+		}
+		copy(c.acc, c.acc[p.hopSize:])
+		clear(c.acc[p.frameSize-p.hopSize:])
+		copy(c.in, c.in[p.hopSize:])
+	}
+}
+
+// Reset resets internal state.
+func (p *OverlapProcessor) Reset() {
+	for _, c := range p.channels {
+		clear(c.in)
+		clear(c.acc)
+		clear(c.ready)
+	}
+	p.pos = 0
+}
+
+// Reader returns an aio.SampleReader that reads from r and processes the samples with p.
+// The output is delayed by [OverlapProcessor.Latency] and followed by as many frames of the processed tail,
+// so that it is that much longer than the input.
+func (p *OverlapProcessor) Reader(r aio.SampleReader) aio.SampleReader {
+	return &overlapReader{r: r, p: p, tail: p.Latency() * p.numChannels}
+}
+
+type overlapReader struct {
+	r    aio.SampleReader
+	p    *OverlapProcessor
+	buf  []float32
+	tail int // samples of the tail left to flush after the end of r
+	eof  bool
+}
+
+func (r *overlapReader) ReadSamples(p []float32) (int, error) {
+	p = p[:len(p)/r.p.numChannels*r.p.numChannels]
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if !r.eof {
+		if cap(r.buf) < len(p) {
+			r.buf = make([]float32, len(p))
+		}
+		n, err := aio.ReadFull(r.r, r.buf[:len(p)])
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			r.eof = true
+			err = nil
+			n = n / r.p.numChannels * r.p.numChannels
+		} else if err != nil {
+			return 0, err
+		}
+		if n > 0 {
+			r.p.Process(p[:n], r.buf[:n])
+			return n, nil
+		}
+	}
+
+	// flush the tail by feeding silence
+	if r.tail == 0 {
+		return 0, io.EOF
+	}
+	n := min(len(p), r.tail)
+	clear(p[:n])
+	r.p.Process(p[:n], p[:n])
+	r.tail -= n
+	return n, nil
+}
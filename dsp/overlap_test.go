@@ -0,0 +1,136 @@
+package dsp_test
+
+import (
+	"io"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/dsp/fft"
+	"github.com/MatusOllah/resona/dsp/window"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// readOverlap processes src through p.Reader, reading in chunks of an odd number of frames.
+func readOverlap(t *testing.T, p *dsp.OverlapProcessor, src []float32, numChannels int) []float32 {
+	t.Helper()
+
+	r := p.Reader(audio.NewBuffer(src))
+	var out []float32
+	buf := make([]float32, 37*numChannels)
+	for {
+		n, err := r.ReadSamples(buf)
+		out = append(out, buf[:n]...)
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOverlapProcessorReconstruction(t *testing.T) {
+	tests := []struct {
+		name               string
+		frameSize, hopSize int
+		numChannels        int
+		opts               []dsp.OverlapOption
+	}{
+		{"Default/Half", 512, 256, 1, nil},
+		{"Default/Quarter", 512, 128, 2, nil},
+		{"Default/NotDividing", 300, 70, 2, nil},
+		{"Hann/Rectangular", 256, 64, 1, []dsp.OverlapOption{dsp.WithAnalysisWindow(window.Hann), dsp.WithSynthesisWindow(window.Rectangular)}},
+		{"Hamming/Hamming", 256, 128, 3, []dsp.OverlapOption{dsp.WithAnalysisWindow(window.Hamming), dsp.WithSynthesisWindow(window.Hamming)}},
+		{"NoOverlap", 64, 64, 1, []dsp.OverlapOption{dsp.WithAnalysisWindow(window.Rectangular), dsp.WithSynthesisWindow(window.Rectangular)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(1, 2))
+			src := make([]float32, 5000*tt.numChannels)
+			for i := range src {
+				src[i] = float32(rng.Float64()*2 - 1)
+			}
+
+			p := dsp.NewOverlapProcessor(tt.frameSize, tt.hopSize, tt.numChannels, func([]float64) {}, tt.opts...)
+			got := readOverlap(t, p, append([]float32(nil), src...), tt.numChannels)
+
+			latency := p.Latency() * tt.numChannels
+			if len(got) != len(src)+latency {
+				t.Fatalf("expected %d samples, got %d", len(src)+latency, len(got))
+			}
+			for i, s := range got[:latency] {
+				if s != 0 {
+					t.Fatalf("sample %d: expected silence during the latency, got %v", i, s)
+				}
+			}
+			for i, want := range src {
+				if d := math.Abs(float64(got[latency+i] - want)); d > 1e-6 {
+					t.Fatalf("sample %d: expected %v, got %v", i, want, got[latency+i])
+				}
+			}
+		})
+	}
+}
+
+func TestOverlapProcessorSpectralGain(t *testing.T) {
+	const (
+		frameSize  = 1024
+		hopSize    = 256
+		sampleRate = 44100 * freq.Hertz
+	)
+
+	// a brick-wall low-pass filter at 1 kHz in the frequency domain
+	plan := fft.NewReal(frameSize)
+	spectrum := make([]complex128, frameSize/2+1)
+	cutoff := int(1000 * frameSize / sampleRate.Hertz())
+	p := dsp.NewOverlapProcessor(frameSize, hopSize, 1, func(frame []float64) {
+		plan.Forward(spectrum, frame)
+		clear(spectrum[cutoff:])
+		plan.Inverse(frame, spectrum)
+	})
+
+	src := make([]float32, 44100)
+	for i := range src {
+		x := float64(i) / sampleRate.Hertz()
+		src[i] = float32(0.5*math.Sin(2*math.Pi*200*x) + 0.5*math.Sin(2*math.Pi*5000*x))
+	}
+	got := readOverlap(t, p, src, 1)
+
+	// compare the levels in the middle, away from the start
+	level := func(s []float32, f freq.Frequency) float64 {
+		x := make([]float64, 8820) // a whole number of periods of both tones
+		for i := range x {
+			x[i] = float64(s[10000+i])
+		}
+		return 10 * math.Log10(dsp.Goertzel(x, sampleRate, f))
+	}
+	out := got[p.Latency():]
+	if d := level(out, 200*freq.Hertz) - level(src, 200*freq.Hertz); math.Abs(d) > 0.1 {
+		t.Errorf("expected 200 Hz to pass unchanged, got %.2f dB", d)
+	}
+	if d := level(out, 5*freq.KiloHertz) - level(src, 5*freq.KiloHertz); d > -40 {
+		t.Errorf("expected 5 kHz to be removed, got %.2f dB", d)
+	}
+}
+
+func TestOverlapProcessorInvalid(t *testing.T) {
+	for name, fn := range map[string]func(){
+		"HopTooLong": func() { dsp.NewOverlapProcessor(64, 128, 1, nil) },
+		"ZeroHop":    func() { dsp.NewOverlapProcessor(64, 0, 1, nil) },
+		"Channels":   func() { dsp.NewOverlapProcessor(64, 32, 0, nil) },
+		// a Hann window is zero at its ends, so it can not be used without overlap
+		"NoOverlap": func() { dsp.NewOverlapProcessor(64, 64, 1, nil, dsp.WithAnalysisWindow(window.Hann)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
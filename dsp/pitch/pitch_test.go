@@ -0,0 +1,124 @@
+package pitch_test
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp/pitch"
+	"github.com/MatusOllah/resona/freq"
+)
+
+const sampleRate = 44100 * freq.Hertz
+
+func sine(f float64, n int) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = 0.5 * math.Sin(2*math.Pi*f*float64(i)/sampleRate.Hertz())
+	}
+	return s
+}
+
+// sawtooth returns a band-limited sawtooth wave.
+func sawtooth(f float64, n int) []float64 {
+	s := make([]float64, n)
+	for k := 1; float64(k)*f < sampleRate.Hertz()/2; k++ {
+		for i := range s {
+			s[i] += 0.3 / float64(k) * math.Sin(2*math.Pi*float64(k)*f*float64(i)/sampleRate.Hertz())
+		}
+	}
+	return s
+}
+
+func cents(got freq.Frequency, want float64) float64 {
+	return 1200 * math.Log2(got.Hertz()/want)
+}
+
+func TestDetect(t *testing.T) {
+	for _, wave := range []struct {
+		name string
+		fn   func(f float64, n int) []float64
+	}{
+		{"Sine", sine},
+		{"Sawtooth", sawtooth},
+	} {
+		for _, f := range []float64{80, 110, 196, 261.63, 440, 523.25, 777, 1000} {
+			t.Run(fmt.Sprintf("%s/%vHz", wave.name, f), func(t *testing.T) {
+				got, confidence := pitch.Detect(wave.fn(f, 2048), sampleRate)
+				if c := cents(got, f); math.Abs(c) > 1 {
+					t.Errorf("expected %v Hz, got %v (%.2f cents off)", f, got, c)
+				}
+				if confidence < 0.9 {
+					t.Errorf("expected high confidence, got %v", confidence)
+				}
+			})
+		}
+	}
+}
+
+func TestDetectUnvoiced(t *testing.T) {
+	if f, confidence := pitch.Detect(make([]float64, 2048), sampleRate); f != 0 || confidence != 0 {
+		t.Errorf("silence: expected no pitch, got %v with confidence %v", f, confidence)
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	noise := make([]float64, 2048)
+	for i := range noise {
+		noise[i] = rng.Float64()*2 - 1
+	}
+	if f, confidence := pitch.Detect(noise, sampleRate); f != 0 {
+		t.Errorf("noise: expected no pitch, got %v with confidence %v", f, confidence)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	// half a second of A4, then half a second of silence
+	s := append(sine(440, 22050), make([]float64, 22050)...)
+	p := make([]float32, len(s))
+	for i, x := range s {
+		p[i] = float32(x)
+	}
+
+	tr := pitch.NewTracker(audio.NewBuffer(p), sampleRate, pitch.WithFrameSize(2048), pitch.WithHopSize(1024), pitch.WithMedianSize(3))
+	var n int
+	for ; ; n++ {
+		e, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := time.Duration(float64(n*1024) / sampleRate.Hertz() * float64(time.Second)); e.Time != want {
+			t.Errorf("frame %d: expected time %v, got %v", n, want, e.Time)
+		}
+		switch {
+		case e.Time < 400*time.Millisecond:
+			if c := cents(e.Frequency, 440); math.Abs(c) > 1 {
+				t.Errorf("frame %d: expected 440 Hz, got %v", n, e.Frequency)
+			}
+		case e.Time > 600*time.Millisecond:
+			if e.Frequency != 0 {
+				t.Errorf("frame %d: expected no pitch, got %v", n, e.Frequency)
+			}
+		}
+	}
+
+	// whole frames only
+	if want := (len(p)-2048)/1024 + 1; n != want {
+		t.Errorf("expected %d frames, got %d", want, n)
+	}
+}
+
+func TestTrackerInvalid(t *testing.T) {
+	for _, opt := range []pitch.Option{pitch.WithFrameSize(0), pitch.WithHopSize(0), pitch.WithMedianSize(0)} {
+		if _, err := pitch.NewTracker(audio.NewBuffer(nil), sampleRate, opt).Next(); err == nil || err == io.EOF {
+			t.Errorf("expected error, got %v", err)
+		}
+	}
+}
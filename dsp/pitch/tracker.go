@@ -0,0 +1,204 @@
+package pitch
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// Default settings of a [Tracker].
+const (
+	DefaultFrameSize  = 2048
+	DefaultHopSize    = 512
+	DefaultMedianSize = 5
+)
+
+// Option configures a [Tracker].
+type Option func(*options)
+
+type options struct {
+	frameSize  int
+	hopSize    int
+	medianSize int
+	err        error
+}
+
+// WithFrameSize sets the number of samples analyzed per estimate. The default is [DefaultFrameSize].
+// The longest detectable period is half of the frame size.
+func WithFrameSize(n int) Option {
+	return func(o *options) {
+		if n < 6 {
+			o.err = fmt.Errorf("pitch: invalid frame size: %d", n)
+			return
+		}
+		o.frameSize = n
+	}
+}
+
+// WithHopSize sets the number of samples between the starts of consecutive frames. The default is [DefaultHopSize].
+func WithHopSize(n int) Option {
+	return func(o *options) {
+		if n <= 0 {
+			o.err = fmt.Errorf("pitch: invalid hop size: %d", n)
+			return
+		}
+		o.hopSize = n
+	}
+}
+
+// WithMedianSize sets the number of consecutive estimates smoothed by the median filter.
+// The default is [DefaultMedianSize]; 1 disables smoothing.
+func WithMedianSize(n int) Option {
+	return func(o *options) {
+		if n <= 0 {
+			o.err = fmt.Errorf("pitch: invalid median size: %d", n)
+			return
+		}
+		o.medianSize = n
+	}
+}
+
+// Estimate represents a pitch estimate of a frame.
+type Estimate struct {
+	// Time is the start of the frame, relative to the start of the stream.
+	Time time.Duration
+
+	// Frequency is the estimated fundamental frequency, or 0 if the frame is unvoiced.
+	Frequency freq.Frequency
+
+	// Confidence is the confidence of the estimate between 0 and 1.
+	Confidence float64
+}
+
+// Tracker estimates the pitch of the mono signal read from an aio.SampleReader frame by frame.
+//
+// Estimates are smoothed with a median filter over the last few frames,
+// which removes isolated octave errors and dropouts.
+type Tracker struct {
+	r          aio.SampleReader
+	sampleRate freq.Frequency
+	o          options
+	err        error
+
+	yin   *yin
+	frame []float64
+	buf   []float32
+	pos   int // start of the current frame in samples, -1 before the first frame
+
+	history []Estimate // last raw estimates, oldest first
+	sorted  []Estimate
+}
+
+// NewTracker creates a new [Tracker] reading from r.
+func NewTracker(r aio.SampleReader, sampleRate freq.Frequency, opts ...Option) *Tracker {
+	o := options{
+		frameSize:  DefaultFrameSize,
+		hopSize:    DefaultHopSize,
+		medianSize: DefaultMedianSize,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	t := &Tracker{
+		r:          r,
+		sampleRate: sampleRate,
+		o:          o,
+		err:        o.err,
+		pos:        -1,
+	}
+	if t.err != nil {
+		return t
+	}
+	if sampleRate <= 0 {
+		t.err = fmt.Errorf("pitch: invalid sample rate: %v", sampleRate)
+		return t
+	}
+
+	t.yin = newYIN(o.frameSize)
+	t.frame = make([]float64, o.frameSize)
+	t.buf = make([]float32, max(o.frameSize, o.hopSize))
+	return t
+}
+
+// Next estimates the pitch of the next frame.
+// It returns [io.EOF] once there are no more whole frames.
+func (t *Tracker) Next() (Estimate, error) {
+	if t.err != nil {
+		return Estimate{}, t.err
+	}
+	if err := t.advance(); err != nil {
+		t.err = err
+		return Estimate{}, err
+	}
+
+	f, confidence := t.yin.detect(t.frame, t.sampleRate)
+	e := Estimate{
+		Time:       time.Duration(float64(t.pos) / t.sampleRate.Hertz() * float64(time.Second)),
+		Frequency:  f,
+		Confidence: confidence,
+	}
+
+	if len(t.history) == t.o.medianSize {
+		t.history = t.history[1:]
+	}
+	t.history = append(t.history, e)
+	if len(t.history) == 1 {
+		return e, nil
+	}
+
+	t.sorted = append(t.sorted[:0], t.history...)
+	slices.SortFunc(t.sorted, func(a, b Estimate) int {
+		return cmp.Compare(a.Frequency, b.Frequency)
+	})
+	median := t.sorted[len(t.sorted)/2]
+	return Estimate{Time: e.Time, Frequency: median.Frequency, Confidence: median.Confidence}, nil
+}
+
+// advance reads the next frame into t.frame.
+func (t *Tracker) advance() error {
+	n := t.o.frameSize // new samples in the frame
+	if t.pos >= 0 {
+		// samples between frames are skipped if the hop is longer than the frame
+		for skip := t.o.hopSize - t.o.frameSize; skip > 0; {
+			m := min(skip, len(t.buf))
+			if err := t.read(t.buf[:m]); err != nil {
+				return err
+			}
+			skip -= m
+		}
+		n = min(t.o.hopSize, t.o.frameSize)
+	}
+
+	if err := t.read(t.buf[:n]); err != nil {
+		return err
+	}
+	copy(t.frame, t.frame[n:])
+	for i, s := range t.buf[:n] {
+		t.frame[len(t.frame)-n+i] = float64(s)
+	}
+
+	if t.pos < 0 {
+		t.pos = 0
+	} else {
+		t.pos += t.o.hopSize
+	}
+	return nil
+}
+
+// read fills p, returning io.EOF if there are not enough samples left.
+func (t *Tracker) read(p []float32) error {
+	n, err := aio.ReadFull(t.r, p)
+	if n == len(p) {
+		return nil
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return err
+}
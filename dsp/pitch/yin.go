@@ -0,0 +1,153 @@
+// Package pitch implements fundamental frequency (pitch) estimation.
+package pitch
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/MatusOllah/resona/freq"
+)
+
+// threshold is the absolute threshold of the cumulative mean normalized difference below which a period is accepted.
+const threshold = 0.15
+
+// Detect estimates the fundamental frequency of the mono signal samples with the YIN algorithm.
+// The longest detectable period is half of the length of samples.
+// For pitches high enough to fit several periods in half of samples,
+// the estimate is refined from the phase advance of the fundamental.
+//
+// The confidence is between 0 and 1, where 1 means the signal is perfectly periodic.
+// Detect returns a frequency of 0 if samples are silent or not periodic (unvoiced), such as noise.
+func Detect(samples []float64, sampleRate freq.Frequency) (f freq.Frequency, confidence float64) {
+	return newYIN(len(samples)).detect(samples, sampleRate)
+}
+
+// yin holds the buffers of the YIN algorithm, so that they can be reused between frames.
+type yin struct {
+	diff []float64 // difference function
+	cmnd []float64 // cumulative mean normalized difference function
+
+	window []float64 // Hann window over half a frame for refine
+}
+
+func newYIN(frameSize int) *yin {
+	w := frameSize / 2
+	return &yin{
+		diff: make([]float64, w),
+		cmnd: make([]float64, w),
+	}
+}
+
+func (y *yin) detect(samples []float64, sampleRate freq.Frequency) (freq.Frequency, float64) {
+	w := len(y.diff)
+	if w < 3 || len(samples) < 2*w {
+		return 0, 0
+	}
+
+	// step 1: difference function
+	for tau := 1; tau < w; tau++ {
+		var d float64
+		for i := range w {
+			delta := samples[i] - samples[i+tau]
+			d += delta * delta
+		}
+		y.diff[tau] = d
+	}
+
+	// step 2: cumulative mean normalization, which removes the dip at tau = 0
+	y.cmnd[0] = 1
+	var sum float64
+	for tau := 1; tau < w; tau++ {
+		sum += y.diff[tau]
+		if sum == 0 {
+			y.cmnd[tau] = 1
+		} else {
+			y.cmnd[tau] = y.diff[tau] * float64(tau) / sum
+		}
+	}
+
+	// step 3: absolute threshold; take the first dip below it, which avoids choosing a multiple of the period
+	tau := 0
+	for t := 2; t < w; t++ {
+		if y.cmnd[t] < threshold {
+			for t+1 < w && y.cmnd[t+1] < y.cmnd[t] {
+				t++
+			}
+			tau = t
+			break
+		}
+	}
+	if tau == 0 {
+		return 0, 0
+	}
+	confidence := 1 - y.cmnd[tau]
+
+	// step 4: parabolic interpolation of the difference function around the dip
+	period := float64(tau)
+	if tau+1 < w {
+		a, b, c := y.diff[tau-1], y.diff[tau], y.diff[tau+1]
+		if den := a - 2*b + c; den > 0 {
+			period += (a - c) / (2 * den)
+		}
+	}
+
+	f := sampleRate.Hertz() / period
+
+	// the parabola only fits the dip well for smooth signals; for bright signals,
+	// such as a sawtooth, refine the estimate with the phase of the fundamental
+	if float64(w)*f/sampleRate.Hertz() >= minRefinePeriods {
+		if g, ok := y.refine(samples, f/sampleRate.Hertz()); ok {
+			f = g * sampleRate.Hertz()
+		}
+	}
+
+	return freq.Frequency(f * float64(freq.Hertz)), confidence
+}
+
+// minRefinePeriods is the number of periods in half a frame needed to resolve the fundamental from its harmonics.
+const minRefinePeriods = 4
+
+// refine refines the estimate f, in cycles per sample, from the phase advance of the fundamental
+// between the two halves of the frame.
+// The estimate must be close enough for the phase advance to be unwrapped unambiguously.
+func (y *yin) refine(samples []float64, f float64) (float64, bool) {
+	w := len(y.diff)
+	if len(y.window) != w {
+		y.window = make([]float64, w)
+		for i := range y.window {
+			y.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(w))
+		}
+	}
+
+	a := y.phasor(samples[:w], f)
+	b := y.phasor(samples[w:2*w], f)
+	if a == 0 || b == 0 {
+		return 0, false
+	}
+
+	// the phase advance expected from f, and how far off it is
+	expected := 2 * math.Pi * f * float64(w)
+	delta := cmplx.Phase(b/a) - math.Remainder(expected, 2*math.Pi)
+	delta = math.Remainder(delta, 2*math.Pi)
+
+	g := f + delta/(2*math.Pi*float64(w))
+	// reject refinements far beyond the accuracy of the parabola, such as with a missing fundamental
+	if math.Abs(g-f) > maxRefine*f {
+		return 0, false
+	}
+	return g, true
+}
+
+// maxRefine is the largest relative change of the estimate accepted from refine, about 17 cents.
+const maxRefine = 0.01
+
+// phasor returns the complex amplitude of samples at the frequency f, in cycles per sample, with a Hann window.
+func (y *yin) phasor(samples []float64, f float64) complex128 {
+	var re, im float64
+	for i, x := range samples {
+		sin, cos := math.Sincos(2 * math.Pi * f * float64(i))
+		re += x * y.window[i] * cos
+		im -= x * y.window[i] * sin
+	}
+	return complex(re, im)
+}
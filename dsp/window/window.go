@@ -215,3 +215,309 @@ func ExactBlackman(n int) []float64 {
 	}
 	return w
 }
+
+// cosineSum returns an n-point window defined as a sum of cosines with the given coefficients:
+//
+//	w[i] = a0 - a1*cos(2πi/(n-1)) + a2*cos(4πi/(n-1)) - a3*cos(6πi/(n-1)) + ...
+func cosineSum(n int, a ...float64) []float64 {
+	if n <= 0 {
+		return nil
+	}
+
+	w := make([]float64, n)
+
+	// Special case
+	if n == 1 {
+		w[0] = 1
+		return w
+	}
+
+	for i := range w {
+		x := 2 * math.Pi * float64(i) / float64(n-1)
+		sign := 1.0
+		for k, ak := range a {
+			w[i] += sign * ak * math.Cos(float64(k)*x)
+			sign = -sign
+		}
+	}
+	return w
+}
+
+// Bartlett returns an n-point Bartlett (triangular) window, which is zero at both ends:
+//
+//	w[i] = 1 - |2i/(n-1) - 1|
+//
+// For n == 1, the window is defined as [1.0].
+//
+// Reference: https://en.wikipedia.org/wiki/Window_function#Triangular_window
+func Bartlett(n int) []float64 {
+	if n <= 0 {
+		return nil
+	}
+
+	w := make([]float64, n)
+
+	// Special case
+	if n == 1 {
+		w[0] = 1
+		return w
+	}
+
+	for i := range w {
+		w[i] = 1 - math.Abs(2*float64(i)/float64(n-1)-1)
+	}
+	return w
+}
+
+// BartlettHann returns an n-point Bartlett-Hann window:
+//
+//	w[i] = 0.62 - 0.48*|i/(n-1) - 0.5| - 0.38*cos(2πi/(n-1))
+//
+// For n == 1, the window is defined as [1.0].
+//
+// Reference: https://en.wikipedia.org/wiki/Window_function#Bartlett%E2%80%93Hann_window
+func BartlettHann(n int) []float64 {
+	if n <= 0 {
+		return nil
+	}
+
+	w := make([]float64, n)
+
+	// Special case
+	if n == 1 {
+		w[0] = 1
+		return w
+	}
+
+	for i := range w {
+		x := float64(i) / float64(n-1)
+		w[i] = 0.62 - 0.48*math.Abs(x-0.5) - 0.38*math.Cos(2*math.Pi*x)
+	}
+	return w
+}
+
+// Nuttall returns an n-point Nuttall window, a 4-term cosine-sum window with a continuous first derivative.
+//
+// The coefficients are:
+//
+//	a0 = 0.355768
+//	a1 = 0.487396
+//	a2 = 0.144232
+//	a3 = 0.012604
+//
+// For n == 1, the window is defined as [1.0].
+//
+// Reference: https://en.wikipedia.org/wiki/Window_function#Nuttall_window,_continuous_first_derivative
+func Nuttall(n int) []float64 {
+	return cosineSum(n, 0.355768, 0.487396, 0.144232, 0.012604)
+}
+
+// BlackmanNuttall returns an n-point Blackman-Nuttall window.
+//
+// The coefficients are:
+//
+//	a0 = 0.3635819
+//	a1 = 0.4891775
+//	a2 = 0.1365995
+//	a3 = 0.0106411
+//
+// For n == 1, the window is defined as [1.0].
+//
+// Reference: https://en.wikipedia.org/wiki/Window_function#Blackman%E2%80%93Nuttall_window
+func BlackmanNuttall(n int) []float64 {
+	return cosineSum(n, 0.3635819, 0.4891775, 0.1365995, 0.0106411)
+}
+
+// BlackmanHarris returns an n-point 4-term Blackman-Harris window.
+//
+// The coefficients are:
+//
+//	a0 = 0.35875
+//	a1 = 0.48829
+//	a2 = 0.14128
+//	a3 = 0.01168
+//
+// For n == 1, the window is defined as [1.0].
+//
+// Reference: https://en.wikipedia.org/wiki/Window_function#Blackman%E2%80%93Harris_window
+func BlackmanHarris(n int) []float64 {
+	return cosineSum(n, 0.35875, 0.48829, 0.14128, 0.01168)
+}
+
+// FlatTop returns an n-point flat-top window. Its very flat main lobe keeps the amplitude error of a
+// sinusoid between two FFT bins small, which makes it suitable for amplitude measurements.
+// The window has small negative values near its ends.
+//
+// The coefficients are:
+//
+//	a0 = 0.21557895
+//	a1 = 0.41663158
+//	a2 = 0.277263158
+//	a3 = 0.083578947
+//	a4 = 0.006947368
+//
+// For n == 1, the window is defined as [1.0].
+//
+// Reference: https://en.wikipedia.org/wiki/Window_function#Flat_top_window
+func FlatTop(n int) []float64 {
+	return cosineSum(n, 0.21557895, 0.41663158, 0.277263158, 0.083578947, 0.006947368)
+}
+
+// Gaussian returns a function computing an n-point Gaussian window:
+//
+//	w[i] = exp(-1/2 * ((i - (n-1)/2) / (sigma * (n-1)/2))^2)
+//
+// sigma is the standard deviation relative to half the window length and should be at most 0.5.
+//
+// For n == 1, the window is defined as [1.0].
+//
+// Reference: https://en.wikipedia.org/wiki/Window_function#Gaussian_window
+func Gaussian(sigma float64) WindowFunc {
+	return func(n int) []float64 {
+		if n <= 0 {
+			return nil
+		}
+
+		w := make([]float64, n)
+
+		// Special case
+		if n == 1 {
+			w[0] = 1
+			return w
+		}
+
+		half := float64(n-1) / 2
+		for i := range w {
+			x := (float64(i) - half) / (sigma * half)
+			w[i] = math.Exp(-0.5 * x * x)
+		}
+		return w
+	}
+}
+
+// Tukey returns a function computing an n-point Tukey (tapered cosine) window,
+// which is flat in the middle and tapers off with a cosine over a fraction alpha of its length:
+//
+//	w[i] = 1/2 * (1 - cos(2πi / (alpha*(n-1))))  for i < alpha*(n-1)/2
+//	w[i] = 1                                    in the middle
+//
+// with the second taper mirroring the first one. An alpha of 0 gives a rectangular window
+// and an alpha of 1 gives a Hann window.
+//
+// For n == 1, the window is defined as [1.0].
+//
+// Reference: https://en.wikipedia.org/wiki/Window_function#Tukey_window
+func Tukey(alpha float64) WindowFunc {
+	alpha = min(max(alpha, 0), 1)
+
+	return func(n int) []float64 {
+		if n <= 0 {
+			return nil
+		}
+
+		w := make([]float64, n)
+
+		// Special case
+		if n == 1 {
+			w[0] = 1
+			return w
+		}
+
+		width := alpha * float64(n-1) / 2
+		for i := range w {
+			// distance from the nearest end
+			d := min(float64(i), float64(n-1-i))
+			if d < width {
+				w[i] = 0.5 * (1 - math.Cos(math.Pi*d/width))
+			} else {
+				w[i] = 1
+			}
+		}
+		return w
+	}
+}
+
+// Sum returns the sum of the window values.
+// It is the gain of the window at DC, used to normalize the amplitudes of a windowed spectrum.
+func Sum(w []float64) float64 {
+	var sum float64
+	for _, v := range w {
+		sum += v
+	}
+	return sum
+}
+
+// CoherentGain returns the coherent gain of the window, the mean of its values.
+// Dividing the spectrum of a windowed signal by len(w) * CoherentGain(w) restores the amplitudes of sinusoids.
+func CoherentGain(w []float64) float64 {
+	if len(w) == 0 {
+		return 0
+	}
+	return Sum(w) / float64(len(w))
+}
+
+// Kaiser returns a function computing an n-point Kaiser window with the given beta parameter.
+// Beta trades main-lobe width against sidelobe level: 0 gives a rectangular window,
+// about 5 is similar to a Hamming window and about 8.6 is similar to a Blackman window.
+// See [KaiserForAttenuation] for choosing beta for FIR filter design.
+//
+// For n == 1, the window is defined as [1.0].
+//
+// Reference: https://en.wikipedia.org/wiki/Kaiser_window
+func Kaiser(beta float64) WindowFunc {
+	i0Beta := besselI0(beta)
+
+	return func(n int) []float64 {
+		if n <= 0 {
+			return nil
+		}
+
+		w := make([]float64, n)
+
+		// Special case
+		if n == 1 {
+			w[0] = 1
+			return w
+		}
+
+		for i := range w {
+			r := 2*float64(i)/float64(n-1) - 1
+			w[i] = besselI0(beta*math.Sqrt(max(0, 1-r*r))) / i0Beta
+		}
+		return w
+	}
+}
+
+// KaiserForAttenuation returns the Kaiser window beta parameter for an FIR filter
+// with the given stopband attenuation (or sidelobe level) in dB, using Kaiser's empirical formula.
+//
+// Reference: https://en.wikipedia.org/wiki/Kaiser_window#Filter_design
+func KaiserForAttenuation(sidelobeDB float64) float64 {
+	a := math.Abs(sidelobeDB)
+	switch {
+	case a > 50:
+		return 0.1102 * (a - 8.7)
+	case a >= 21:
+		return 0.5842*math.Pow(a-21, 0.4) + 0.07886*(a-21)
+	default:
+		return 0
+	}
+}
+
+// besselI0 computes the zeroth-order modified Bessel function of the first kind.
+//
+// It sums the power series sum((x/2)^k / k!)^2. All terms are positive, so there is no cancellation
+// and the relative error grows only slowly with x; it stays below 1e-14 for |x| <= 50,
+// which covers the beta values used in practice. The result overflows for |x| above about 700.
+func besselI0(x float64) float64 {
+	q := x * x / 4
+	sum, term := 1.0, 1.0
+	for k := 1.0; k < 1000; k++ {
+		term *= q / (k * k)
+		sum += term
+		if term < sum*1e-17 {
+			break
+		}
+	}
+	return sum
+}
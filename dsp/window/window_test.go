@@ -3,7 +3,9 @@ package window_test
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/MatusOllah/resona/dsp/window"
@@ -65,3 +67,164 @@ func TestWindows(t *testing.T) {
 		})
 	}
 }
+
+func TestKaiser(t *testing.T) {
+	// testdata/kaiser.json is generated by testdata/gen_reference_kaiser.py and keyed by "beta/n".
+	// The values are those of scipy.signal.windows.kaiser(n, beta, sym=True); the checked-in file was
+	// generated with the script's 50-digit decimal fallback, as SciPy was not available at the time.
+	f, err := os.Open("testdata/kaiser.json")
+	if err != nil {
+		t.Fatalf("failed to open testdata file: %v", err)
+	}
+	defer f.Close()
+
+	var data map[string][]float64
+	if err := json.NewDecoder(f).Decode(&data); err != nil {
+		t.Fatalf("failed to decode testdata file: %v", err)
+	}
+
+	for _, beta := range []float64{0, 2.5, 5, 8.6, 14} {
+		for _, n := range []int{0, 1, 2, 5, 16, 64, 255} {
+			key := strconv.FormatFloat(beta, 'f', 1, 64) + "/" + strconv.Itoa(n)
+			t.Run(key, func(t *testing.T) {
+				expected, ok := data[key]
+				if !ok {
+					t.Fatalf("missing testdata for %s", key)
+				}
+				w := window.Kaiser(beta)(n)
+				if len(w) != len(expected) {
+					t.Fatalf("expected %d values, got %d", len(expected), len(w))
+				}
+				if !testutil.EqualSliceWithinTolerance(expected, w, 1e-12) {
+					t.Errorf("Kaiser(%v)(%d) does not match expected slice", beta, n)
+				}
+			})
+		}
+	}
+}
+
+func TestKaiserForAttenuation(t *testing.T) {
+	tests := []struct {
+		attenuation float64
+		beta        float64
+	}{
+		// values of Kaiser's formula, as used by scipy.signal.kaiser_beta
+		{10, 0},
+		{21, 0},
+		{30, 2.1166248611409806},
+		{50, 4.533514120981248},
+		{60, 5.65326},
+		{-60, 5.65326},
+		{100, 10.06126},
+	}
+
+	for _, tt := range tests {
+		if got := window.KaiserForAttenuation(tt.attenuation); !testutil.EqualWithinTolerance(tt.beta, got, 1e-9) {
+			t.Errorf("KaiserForAttenuation(%v) = %v; want %v", tt.attenuation, got, tt.beta)
+		}
+	}
+}
+
+func TestWindowShapes(t *testing.T) {
+	funcs := map[string]window.WindowFunc{
+		"Bartlett":        window.Bartlett,
+		"BartlettHann":    window.BartlettHann,
+		"Nuttall":         window.Nuttall,
+		"BlackmanNuttall": window.BlackmanNuttall,
+		"BlackmanHarris":  window.BlackmanHarris,
+		"FlatTop":         window.FlatTop,
+		"Gaussian":        window.Gaussian(0.4),
+		"Tukey":           window.Tukey(0.5),
+	}
+
+	for name, fn := range funcs {
+		t.Run(name, func(t *testing.T) {
+			if w := fn(0); w != nil {
+				t.Errorf("expected nil for n = 0, got %v", w)
+			}
+			if w := fn(1); len(w) != 1 || w[0] != 1 {
+				t.Errorf("expected [1] for n = 1, got %v", w)
+			}
+
+			for _, n := range []int{2, 7, 64, 255} {
+				w := fn(n)
+				if len(w) != n {
+					t.Fatalf("expected %d values, got %d", n, len(w))
+				}
+
+				// symmetric, with the peak in the middle
+				for i := range w {
+					if !testutil.EqualWithinTolerance(w[i], w[n-1-i], 1e-12) {
+						t.Errorf("n = %d: not symmetric at %d: %v != %v", n, i, w[i], w[n-1-i])
+						break
+					}
+					if w[i] > w[n/2]+1e-12 {
+						t.Errorf("n = %d: value at %d is larger than in the middle: %v > %v", n, i, w[i], w[n/2])
+						break
+					}
+				}
+				if n%2 == 1 {
+					if !testutil.EqualWithinTolerance(w[n/2], 1, 1e-6) {
+						t.Errorf("n = %d: expected a peak value of 1, got %v", n, w[n/2])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWindowValues(t *testing.T) {
+	tests := []struct {
+		name string
+		w    []float64
+		want []float64
+	}{
+		{"Bartlett", window.Bartlett(5), []float64{0, 0.5, 1, 0.5, 0}},
+		{"Bartlett/even", window.Bartlett(4), []float64{0, 2.0 / 3, 2.0 / 3, 0}},
+		{"BartlettHann", window.BartlettHann(3), []float64{0, 1, 0}},
+		// the end values of cosine-sum windows are a0 - a1 + a2 - a3 + ...
+		{"Nuttall", window.Nuttall(3), []float64{0.355768 - 0.487396 + 0.144232 - 0.012604, 1, 0.355768 - 0.487396 + 0.144232 - 0.012604}},
+		{"BlackmanNuttall", window.BlackmanNuttall(3), []float64{0.0003628, 1, 0.0003628}},
+		{"BlackmanHarris", window.BlackmanHarris(3), []float64{0.00006, 1, 0.00006}},
+		{"FlatTop", window.FlatTop(3), []float64{-0.000421051, 1.000000003, -0.000421051}},
+		{"Gaussian", window.Gaussian(0.4)(5), []float64{math.Exp(-3.125), math.Exp(-0.78125), 1, math.Exp(-0.78125), math.Exp(-3.125)}},
+		{"Tukey", window.Tukey(0.5)(9), []float64{0, 0.5, 1, 1, 1, 1, 1, 0.5, 0}},
+		{"Tukey/rectangular", window.Tukey(0)(5), window.Rectangular(5)},
+		{"Tukey/hann", window.Tukey(1)(16), window.Hann(16)},
+	}
+
+	for _, tt := range tests {
+		if !testutil.EqualSliceWithinTolerance(tt.want, tt.w, 1e-9) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, tt.w)
+		}
+	}
+}
+
+func TestCoherentGain(t *testing.T) {
+	const n = 1 << 16
+	tests := []struct {
+		name string
+		fn   window.WindowFunc
+		want float64 // a0 for cosine-sum windows
+	}{
+		{"Rectangular", window.Rectangular, 1},
+		{"Hann", window.Hann, 0.5},
+		{"Bartlett", window.Bartlett, 0.5},
+		{"BlackmanHarris", window.BlackmanHarris, 0.35875},
+		{"FlatTop", window.FlatTop, 0.21557895},
+	}
+
+	for _, tt := range tests {
+		w := tt.fn(n)
+		if got := window.CoherentGain(w); !testutil.EqualWithinTolerance(tt.want, got, 1e-4) {
+			t.Errorf("%s: expected a coherent gain of %v, got %v", tt.name, tt.want, got)
+		}
+		if got := window.Sum(w); !testutil.EqualWithinTolerance(tt.want*n, got, 1e-4*n) {
+			t.Errorf("%s: expected a sum of %v, got %v", tt.name, tt.want*n, got)
+		}
+	}
+
+	if got := window.CoherentGain(nil); got != 0 {
+		t.Errorf("expected 0 for an empty window, got %v", got)
+	}
+}
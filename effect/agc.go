@@ -0,0 +1,147 @@
+package effect
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+)
+
+// Default settings of an [AGC].
+const (
+	DefaultAGCTarget  = -20.0 // dBFS
+	DefaultAGCMaxGain = 30.0  // dB
+	DefaultAGCGate    = -50.0 // dBFS
+	DefaultAGCAttack  = 500 * time.Millisecond
+	DefaultAGCRelease = 2 * time.Second
+)
+
+// agcGateRelease is the release time of the peak level the gate of an [AGC] is controlled by,
+// which drops below the gate long before the RMS level does once the signal stops.
+const agcGateRelease = 5 * time.Millisecond
+
+// AGC (automatic gain control) slowly rides the gain of the audio signal to bring its RMS level to a target,
+// such as to even out voices in a call or a recording.
+//
+// The level is measured by an RMS envelope follower over a short window, and the gain moves towards
+// the one that brings that level to the target with the slow Attack and Release time constants,
+// so that it follows the overall level rather than individual syllables.
+// While the peak level is below the gate, the gain is frozen, so that pauses and background noise are not amplified.
+// All channels of a frame are linked.
+//
+// The current gain can be read with [AGC.Gain] from another goroutine, such as for metering.
+type AGC struct {
+	// Target is the RMS level in dBFS the AGC aims for.
+	Target float64
+
+	// MaxGain is the highest gain in dB the AGC applies.
+	MaxGain float64
+
+	// Gate is the peak level in dBFS below which the gain is frozen.
+	Gate float64
+
+	// Attack and Release are the time constants of the gain for falling and rising gains, respectively,
+	// that is, for levels above and below the target.
+	Attack, Release time.Duration
+
+	numChannels int
+	sampleRate  float64
+	env         envelope
+	peak        envelope
+	err         error
+
+	mu   sync.Mutex
+	gain float64 // dB
+}
+
+// NewAGC creates a new [AGC] for samples of the given format with the default settings.
+// It starts at a gain of 0 dB.
+func NewAGC(format afmt.Format) *AGC {
+	a := &AGC{
+		Target:      DefaultAGCTarget,
+		MaxGain:     DefaultAGCMaxGain,
+		Gate:        DefaultAGCGate,
+		Attack:      DefaultAGCAttack,
+		Release:     DefaultAGCRelease,
+		numChannels: format.NumChannels,
+		sampleRate:  format.SampleRate.Hertz(),
+		env:         envelope{mode: DetectRMS},
+	}
+	if format.NumChannels <= 0 {
+		a.err = fmt.Errorf("effect: invalid number of channels: %d", format.NumChannels)
+	} else if format.SampleRate <= 0 {
+		a.err = fmt.Errorf("effect: invalid sample rate: %v", format.SampleRate)
+	}
+	return a
+}
+
+// Gain returns the gain in dB currently applied.
+func (a *AGC) Gain() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.gain
+}
+
+// Reset returns the gain to 0 dB and clears the level.
+func (a *AGC) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.gain = 0
+	a.env = envelope{mode: DetectRMS}
+	a.peak = envelope{}
+}
+
+// Process applies the gain to the interleaved samples p.
+func (a *AGC) Process(p []float32) error {
+	if a.err != nil {
+		return a.err
+	}
+	if len(p)%a.numChannels != 0 {
+		return errors.New("effect: AGC needs whole frames")
+	}
+
+	attack := smoothingCoef(a.Attack, a.sampleRate)
+	release := smoothingCoef(a.Release, a.sampleRate)
+	gateRelease := smoothingCoef(agcGateRelease, a.sampleRate)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.env.rmsCoef = smoothingCoef(rmsWindow, a.sampleRate)
+	for i := 0; i < len(p); i += a.numChannels {
+		var x float64
+		for _, s := range p[i : i+a.numChannels] {
+			x = max(x, math.Abs(float64(s)))
+		}
+		// the RMS envelope itself follows instantly, the gain is smoothed instead
+		level := dsp.AmplitudeToDB(a.env.follow(x, 0, 0))
+
+		if dsp.AmplitudeToDB(a.peak.follow(x, 0, gateRelease)) >= a.Gate {
+			target := min(a.Target-level, a.MaxGain)
+			coef := release
+			if target < a.gain {
+				coef = attack
+			}
+			a.gain = target + coef*(a.gain-target)
+		}
+
+		gain := float32(dsp.DBToAmplitude(a.gain))
+		for ch := range a.numChannels {
+			p[i+ch] *= gain
+		}
+	}
+	return nil
+}
+
+// Reader returns an aio.SampleReader that reads from r and applies the gain of a to the samples.
+func (a *AGC) Reader(r aio.SampleReader) aio.SampleReader {
+	if a.err != nil {
+		return errReader{a.err}
+	}
+	return newFrameReader(r, a, a.numChannels)
+}
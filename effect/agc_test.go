@@ -0,0 +1,144 @@
+package effect_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var agcFormat = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}
+
+// speechNoise returns numFrames frames of noise at 48 kHz with an RMS level of levelDB,
+// whose spectrum falls off above about 500 Hz like the long-term spectrum of speech.
+func speechNoise(seed uint64, numFrames int, levelDB float64) []float32 {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	a := math.Exp(-2 * math.Pi * 500 / 48000)
+	x := make([]float64, numFrames)
+	var lp1, lp2, sum float64
+	for i := range x {
+		lp1 = (1-a)*rng.NormFloat64() + a*lp1
+		lp2 = (1-a)*lp1 + a*lp2
+		x[i] = lp1 + 0.5*lp2
+		sum += x[i] * x[i]
+	}
+
+	gain := dsp.DBToAmplitude(levelDB) / math.Sqrt(sum/float64(numFrames))
+	p := make([]float32, numFrames)
+	for i, v := range x {
+		p[i] = float32(v * gain)
+	}
+	return p
+}
+
+func rmsDB(p []float32) float64 {
+	var sum float64
+	for _, x := range p {
+		sum += float64(x) * float64(x)
+	}
+	return dsp.PowerToDB(sum / float64(len(p)))
+}
+
+func TestAGC(t *testing.T) {
+	const chunk = 480 // 10 ms
+
+	a := effect.NewAGC(agcFormat)
+	a.Release = time.Second
+	if got := a.Gain(); got != 0 {
+		t.Fatalf("expected to start at 0 dB, got %v dB", got)
+	}
+
+	// 4 s of speech at -30 dBFS, 2 s of silence, 2 s of speech
+	p := speechNoise(1, 4*48000, -30)
+	p = append(p, make([]float32, 2*48000)...)
+	p = append(p, speechNoise(2, 2*48000, -30)...)
+
+	gains := make([]float64, len(p)/chunk)
+	for i := range gains {
+		if err := a.Process(p[i*chunk : (i+1)*chunk]); err != nil {
+			t.Fatal(err)
+		}
+		gains[i] = a.Gain()
+	}
+
+	// the gain approaches +10 dB with a time constant of 1 s, so it is within 1 dB after 2.3 s
+	if got := gains[300]; math.Abs(got-10) > 1 {
+		t.Errorf("expected a gain within 1 dB of 10 dB after 3 s, got %.2f dB", got)
+	}
+	if got := rmsDB(p[3*48000 : 4*48000]); math.Abs(got-effect.DefaultAGCTarget) > 1 {
+		t.Errorf("expected a level within 1 dB of %v dBFS, got %.2f dBFS", effect.DefaultAGCTarget, got)
+	}
+
+	// the level falls below the gate within a few ms of silence, after which the gain stays put
+	speech := gains[399]
+	frozen := gains[402]
+	if math.Abs(frozen-speech) > 0.1 {
+		t.Errorf("expected the gain to stay near %.2f dB after the speech ends, got %.2f dB", speech, frozen)
+	}
+	for i, got := range gains[402:600] {
+		if got != frozen {
+			t.Fatalf("%d ms into the silence: expected the gain to stay at %v dB, got %v dB", 10*(i+2), frozen, got)
+		}
+	}
+	if got := rmsDB(p[7*48000 : 8*48000]); math.Abs(got-effect.DefaultAGCTarget) > 1 {
+		t.Errorf("after the silence: expected a level within 1 dB of %v dBFS, got %.2f dBFS", effect.DefaultAGCTarget, got)
+	}
+}
+
+func TestAGCLimits(t *testing.T) {
+	a := effect.NewAGC(agcFormat)
+	a.Attack = 100 * time.Millisecond
+	a.Release = 100 * time.Millisecond
+	a.MaxGain = 20
+
+	// too quiet to reach the target, but above the gate
+	p := speechNoise(3, 48000, -45)
+	if err := a.Process(p); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Gain(); math.Abs(got-20) > 0.5 {
+		t.Errorf("expected the gain to stop at 20 dB, got %.2f dB", got)
+	}
+
+	// too loud, so it is turned down
+	p = speechNoise(4, 48000, -6)
+	if err := a.Process(p); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Gain(); math.Abs(got+14) > 0.5 {
+		t.Errorf("expected a gain of -14 dB, got %.2f dB", got)
+	}
+
+	a.Reset()
+	if got := a.Gain(); got != 0 {
+		t.Errorf("expected 0 dB after Reset, got %v dB", got)
+	}
+}
+
+func TestAGCReader(t *testing.T) {
+	a := effect.NewAGC(agcFormat)
+	a.Attack = 100 * time.Millisecond
+	out, err := aio.ReadAll(a.Reader(audio.NewBuffer(speechNoise(5, 3*48000, -6))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rmsDB(out[2*48000:]); math.Abs(got-effect.DefaultAGCTarget) > 1 {
+		t.Errorf("expected a level within 1 dB of %v dBFS, got %.2f dBFS", effect.DefaultAGCTarget, got)
+	}
+}
+
+func TestAGCInvalid(t *testing.T) {
+	if err := effect.NewAGC(afmt.Format{SampleRate: 48 * freq.KiloHertz}).Process(make([]float32, 2)); err == nil {
+		t.Error("expected error for invalid number of channels")
+	}
+	if err := effect.NewAGC(afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}).Process(make([]float32, 3)); err == nil {
+		t.Error("expected error for a partial frame")
+	}
+}
@@ -0,0 +1,136 @@
+package effect
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+)
+
+// Bitcrush degrades the audio signal on purpose by reducing its bit depth and sample rate,
+// for a lo-fi sound.
+//
+// The bit depth is reduced by rounding to the levels of signed PCM with that many bits,
+// and the sample rate by holding every DownsampleFactor-th frame, so all channels of a frame are held together.
+//
+// A Bitcrush is safe for concurrent use, so its parameters can be changed during playback.
+type Bitcrush struct {
+	numChannels int
+	err         error
+
+	mu       sync.Mutex
+	bitDepth float64
+	factor   float64
+	mix      float64
+
+	held  []float32 // the frame being held
+	phase float64   // frames until the next frame is taken
+}
+
+// NewBitcrush creates a new [Bitcrush] for samples of the given format,
+// with a bit depth of 8 bits, no downsampling and only the crushed signal in the output.
+func NewBitcrush(format afmt.Format) *Bitcrush {
+	b := &Bitcrush{
+		numChannels: format.NumChannels,
+		bitDepth:    8,
+		factor:      1,
+		mix:         1,
+	}
+	if format.NumChannels <= 0 {
+		b.err = fmt.Errorf("effect: invalid number of channels: %d", format.NumChannels)
+		return b
+	}
+	b.held = make([]float32, format.NumChannels)
+	return b
+}
+
+// SetBitDepth sets the bit depth, which can be fractional, such as 4.5. Values below 1 mean 1.
+func (b *Bitcrush) SetBitDepth(bits float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bitDepth = max(bits, 1)
+}
+
+// BitDepth returns the bit depth.
+func (b *Bitcrush) BitDepth() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.bitDepth
+}
+
+// SetDownsampleFactor sets by how much the sample rate is reduced: every factor-th frame is held
+// for factor frames. The factor can be fractional. Values below 1 mean 1, which turns downsampling off.
+func (b *Bitcrush) SetDownsampleFactor(factor float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.factor = max(factor, 1)
+}
+
+// DownsampleFactor returns the downsample factor.
+func (b *Bitcrush) DownsampleFactor() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.factor
+}
+
+// SetMix sets the amount of the crushed signal in the output, from 0 (only the dry signal) to 1 (only the crushed signal).
+func (b *Bitcrush) SetMix(mix float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mix = max(0, min(mix, 1))
+}
+
+// Mix returns the amount of the crushed signal in the output.
+func (b *Bitcrush) Mix() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.mix
+}
+
+// Process crushes the interleaved samples p.
+func (b *Bitcrush) Process(p []float32) error {
+	if b.err != nil {
+		return b.err
+	}
+	if len(p)%b.numChannels != 0 {
+		return errors.New("effect: bitcrush needs whole frames")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// signed PCM has levels from -scale to scale-1
+	scale := math.Exp2(b.bitDepth - 1)
+	lo, hi := -math.Floor(scale), math.Ceil(scale)-1
+	wet := float32(b.mix)
+	dry := 1 - wet
+
+	for i := 0; i < len(p); i += b.numChannels {
+		frame := p[i : i+b.numChannels]
+
+		if b.phase <= 0 {
+			for ch, x := range frame {
+				q := math.Round(float64(x) * scale)
+				b.held[ch] = float32(max(lo, min(q, hi)) / scale)
+			}
+			b.phase += b.factor
+		}
+		b.phase--
+
+		for ch, x := range frame {
+			frame[ch] = dry*x + wet*b.held[ch]
+		}
+	}
+	return nil
+}
+
+// Reader returns an aio.SampleReader that reads from r and crushes the samples with b.
+func (b *Bitcrush) Reader(r aio.SampleReader) aio.SampleReader {
+	if b.err != nil {
+		return errReader{b.err}
+	}
+	return newFrameReader(r, b, b.numChannels)
+}
@@ -0,0 +1,103 @@
+package effect_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var bitcrushMono = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}
+
+func TestBitcrushBitDepth(t *testing.T) {
+	for _, bits := range []float64{1, 2, 3, 4, 8} {
+		// a ramp over the full range
+		p := make([]float32, 10000)
+		for i := range p {
+			p[i] = -1 + 2*float32(i)/float32(len(p)-1)
+		}
+
+		b := effect.NewBitcrush(bitcrushMono)
+		b.SetBitDepth(bits)
+		processChunks(t, b, p, 1)
+
+		levels := map[float32]bool{}
+		for _, x := range p {
+			levels[x] = true
+		}
+		if want := 1 << int(bits); len(levels) != want {
+			t.Errorf("%v bits: expected %d levels, got %d", bits, want, len(levels))
+		}
+		if !levels[0] || !levels[-1] {
+			t.Errorf("%v bits: expected levels at 0 and -1", bits)
+		}
+	}
+}
+
+func TestBitcrushDownsample(t *testing.T) {
+	// stereo sine, the right channel inverted
+	p := make([]float32, 2*1000)
+	for i := range 1000 {
+		x := float32(math.Sin(2 * math.Pi * 440 * float64(i) / 48000))
+		p[2*i], p[2*i+1] = x, -x
+	}
+	in := append([]float32(nil), p...)
+
+	b := effect.NewBitcrush(afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2})
+	b.SetBitDepth(24)
+	b.SetDownsampleFactor(4)
+	processChunks(t, b, p, 2)
+
+	for i := range 1000 {
+		held := i / 4 * 4
+		if math.Abs(float64(p[2*i]-in[2*held])) > 1e-6 || math.Abs(float64(p[2*i+1]-in[2*held+1])) > 1e-6 {
+			t.Fatalf("frame %d: expected frame %d held in both channels, got %v and %v", i, held, p[2*i], p[2*i+1])
+		}
+	}
+}
+
+func TestBitcrushFractionalFactor(t *testing.T) {
+	p := make([]float32, 20)
+	for i := range p {
+		p[i] = float32(i) / 32
+	}
+
+	b := effect.NewBitcrush(bitcrushMono)
+	b.SetDownsampleFactor(2.5)
+	if err := b.Process(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// frames are taken at 0, 2.5, 5, ... rounded up
+	for i, x := range p {
+		held := int(math.Ceil(math.Floor(float64(i)/2.5) * 2.5))
+		if x != float32(held)/32 {
+			t.Errorf("frame %d: expected frame %d, got %v", i, held, x)
+		}
+	}
+}
+
+func TestBitcrushMix(t *testing.T) {
+	b := effect.NewBitcrush(bitcrushMono)
+	b.SetBitDepth(1)
+	b.SetMix(0.5)
+
+	out, err := aio.ReadAll(b.Reader(audio.NewBuffer([]float32{0.25, -0.25, 0.75})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one bit has the levels -1 and 0
+	want := []float32{0.125, -0.125, 0.375}
+	for i := range want {
+		if math.Abs(float64(out[i]-want[i])) > 1e-6 {
+			t.Errorf("sample %d: expected %v, got %v", i, want[i], out[i])
+		}
+	}
+	if b.BitDepth() != 1 || b.DownsampleFactor() != 1 || b.Mix() != 0.5 {
+		t.Errorf("unexpected parameters: %v, %v, %v", b.BitDepth(), b.DownsampleFactor(), b.Mix())
+	}
+}
@@ -1,7 +1,17 @@
 package effect
 
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
 // Chain represents a sequence of effects that will be applied one after another.
 // If any effect returns an error, processing stops and the error is returned.
+//
+// A Chain must not be modified while it is processing; use [DynamicChain] to edit the effects
+// while the audio is playing.
 type Chain []Effect
 
 func (c Chain) Process(p []float32) error {
@@ -12,3 +22,130 @@ func (c Chain) Process(p []float32) error {
 	}
 	return nil
 }
+
+// Handle refers to an effect in a [DynamicChain], as returned by [DynamicChain.Append] and [DynamicChain.InsertAt].
+type Handle struct {
+	fx       atomic.Pointer[Effect]
+	bypassed atomic.Bool
+}
+
+// Effect returns the effect h refers to.
+func (h *Handle) Effect() Effect {
+	return *h.fx.Load()
+}
+
+// Bypassed reports whether the effect is bypassed.
+func (h *Handle) Bypassed() bool {
+	return h.bypassed.Load()
+}
+
+// DynamicChain is like [Chain], but it is safe for concurrent use,
+// so effects can be added, removed, replaced and bypassed from another goroutine while the chain is processing.
+//
+// Edits are copy-on-write: Process works on a snapshot of the effects and takes no lock,
+// and an edit takes effect from the next call to Process.
+type DynamicChain struct {
+	mu      sync.Mutex // serializes edits
+	handles atomic.Pointer[[]*Handle]
+}
+
+// NewDynamicChain creates a new [DynamicChain] with the given effects.
+//
+// In most cases, new([DynamicChain]) (or just declaring a [DynamicChain] variable) is sufficient
+// to create an empty [DynamicChain].
+func NewDynamicChain(effects ...Effect) *DynamicChain {
+	c := &DynamicChain{}
+	for _, fx := range effects {
+		c.Append(fx)
+	}
+	return c
+}
+
+func (c *DynamicChain) load() []*Handle {
+	if handles := c.handles.Load(); handles != nil {
+		return *handles
+	}
+	return nil
+}
+
+func newHandle(fx Effect) *Handle {
+	h := &Handle{}
+	h.fx.Store(&fx)
+	return h
+}
+
+// Append adds fx to the end of the chain and returns its handle.
+func (c *DynamicChain) Append(fx Effect) *Handle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := newHandle(fx)
+	handles := append(slices.Clone(c.load()), h)
+	c.handles.Store(&handles)
+	return h
+}
+
+// InsertAt inserts fx at index i of the chain, so that it is processed before the effect previously at i,
+// and returns its handle. An index equal to [DynamicChain.Len] appends fx.
+func (c *DynamicChain) InsertAt(i int, fx Effect) (*Handle, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old := c.load()
+	if i < 0 || i > len(old) {
+		return nil, fmt.Errorf("effect: index out of range: %d", i)
+	}
+	h := newHandle(fx)
+	handles := slices.Insert(slices.Clone(old), i, h)
+	c.handles.Store(&handles)
+	return h, nil
+}
+
+// Remove removes the effect h refers to from the chain. It does nothing if h is not in the chain.
+func (c *DynamicChain) Remove(h *Handle) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old := c.load()
+	i := slices.Index(old, h)
+	if i < 0 {
+		return
+	}
+	handles := slices.Delete(slices.Clone(old), i, i+1)
+	c.handles.Store(&handles)
+}
+
+// Replace replaces the effect h refers to with fx, keeping its position and bypass state.
+func (c *DynamicChain) Replace(h *Handle, fx Effect) {
+	h.fx.Store(&fx)
+}
+
+// Bypass sets whether the effect h refers to is bypassed. A bypassed effect is skipped,
+// such as to compare the sound with and without it.
+func (c *DynamicChain) Bypass(h *Handle, bypass bool) {
+	h.bypassed.Store(bypass)
+}
+
+// Handles returns the handles of the effects in processing order.
+func (c *DynamicChain) Handles() []*Handle {
+	return slices.Clone(c.load())
+}
+
+// Len returns the number of effects in the chain, including bypassed ones.
+func (c *DynamicChain) Len() int {
+	return len(c.load())
+}
+
+// Process applies the effects that are not bypassed to p, one after another.
+// If any effect returns an error, processing stops and the error is returned.
+func (c *DynamicChain) Process(p []float32) error {
+	for _, h := range c.load() {
+		if h.bypassed.Load() {
+			continue
+		}
+		if err := h.Effect().Process(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
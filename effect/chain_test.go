@@ -0,0 +1,110 @@
+package effect_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/effect"
+)
+
+// adder returns an effect that appends the digit v to every sample, so that the order of effects shows in the result.
+func adder(v float32) effect.Effect {
+	return effect.EffectFunc(func(p []float32) error {
+		for i := range p {
+			p[i] = p[i]*10 + v
+		}
+		return nil
+	})
+}
+
+func processOne(t *testing.T, fx effect.Effect) float32 {
+	t.Helper()
+	p := []float32{0}
+	if err := fx.Process(p); err != nil {
+		t.Fatal(err)
+	}
+	return p[0]
+}
+
+func TestDynamicChain(t *testing.T) {
+	c := effect.NewDynamicChain(adder(1), adder(2))
+	if got := processOne(t, c); got != 12 {
+		t.Fatalf("expected 12, got %v", got)
+	}
+
+	h3 := c.Append(adder(3))
+	h0, err := c.InsertAt(0, adder(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := processOne(t, c); got != 4123 {
+		t.Errorf("after Append and InsertAt: expected 4123, got %v", got)
+	}
+	if _, err := c.InsertAt(5, adder(5)); err == nil {
+		t.Error("expected error for an index out of range")
+	}
+
+	c.Bypass(h0, true)
+	if got := processOne(t, c); got != 123 || !h0.Bypassed() {
+		t.Errorf("after Bypass: expected 123, got %v", got)
+	}
+	c.Bypass(h0, false)
+
+	c.Replace(h3, adder(5))
+	if got := processOne(t, c); got != 4125 {
+		t.Errorf("after Replace: expected 4125, got %v", got)
+	}
+
+	c.Remove(h0)
+	c.Remove(h0) // no longer in the chain
+	if got := processOne(t, c); got != 125 {
+		t.Errorf("after Remove: expected 125, got %v", got)
+	}
+	if got := c.Len(); got != 3 {
+		t.Errorf("expected 3 effects, got %d", got)
+	}
+	if got := c.Handles()[2]; got != h3 {
+		t.Errorf("expected the replaced effect to keep its handle, got %v", got)
+	}
+
+	var empty effect.DynamicChain
+	if got := processOne(t, &empty); got != 0 {
+		t.Errorf("empty chain: expected 0, got %v", got)
+	}
+}
+
+func TestDynamicChainConcurrent(t *testing.T) {
+	src := audio.NewSource(audio.NewReader(make([]float32, 1<<20)))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c := src.Effects()
+		for i := range 1000 {
+			h := c.Append(&effect.Mute{})
+			if _, err := c.InsertAt(1, &effect.Gain{}); err != nil {
+				t.Error(err)
+				return
+			}
+			c.Bypass(h, i%2 == 0)
+			c.Replace(h, &effect.Gain{})
+			c.Remove(c.Handles()[1])
+			c.Remove(h)
+		}
+	}()
+
+	buf := make([]float32, 256)
+	for range 2000 {
+		if _, err := aio.ReadFull(src, buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	if got := src.Effects().Len(); got != 2 {
+		t.Errorf("expected the mute and gain effects to remain, got %d effects", got)
+	}
+}
@@ -0,0 +1,124 @@
+package effect
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+)
+
+// ChannelGain applies a separate linear gain to every channel of the audio signal,
+// such as to correct a lopsided recording or to adjust the levels of a multichannel stream.
+//
+// The samples are interleaved: the i-th sample processed gets the gain of channel i mod the number of channels.
+// The channel of the next sample is tracked across calls, so Process can be called with partial frames.
+//
+// Changes of the gains take effect instantly unless a smoothing time is set, in which case they ramp
+// linearly to the new values.
+//
+// A ChannelGain is safe for concurrent use, so the gains can be changed during playback.
+type ChannelGain struct {
+	err error
+
+	mu        sync.Mutex
+	gains     []float32
+	smoothing int // frames
+	smoothers []smoother
+	ch        int // channel of the next sample
+}
+
+// NewChannelGain creates a new [ChannelGain] for numChannels interleaved channels with the given linear gains.
+// Channels without a gain in gains are left unchanged.
+func NewChannelGain(numChannels int, gains []float64) *ChannelGain {
+	c := &ChannelGain{}
+	if numChannels <= 0 {
+		c.err = fmt.Errorf("effect: invalid number of channels: %d", numChannels)
+		return c
+	}
+	c.gains = make([]float32, numChannels)
+	c.smoothers = make([]smoother, numChannels)
+	for i := range c.gains {
+		c.gains[i] = 1
+	}
+	c.setGains(gains)
+	return c
+}
+
+// Balance creates a new [ChannelGain] for stereo samples that balances the channels by b in the range [-1, 1].
+// Unlike [Pan], balance only ever attenuates one side: a negative b turns down the right channel and a positive b
+// the left one, down to silence at ±1, while the other channel is left unchanged.
+// Values outside of the range are clamped.
+func Balance(b float64) *ChannelGain {
+	left, right := balanceGains(b)
+	return NewChannelGain(2, []float64{left, right})
+}
+
+func balanceGains(b float64) (left, right float64) {
+	b = max(-1, min(b, 1))
+	return min(1-b, 1), min(1+b, 1)
+}
+
+func (c *ChannelGain) setGains(gains []float64) {
+	for i, g := range gains[:min(len(gains), len(c.gains))] {
+		c.gains[i] = float32(g)
+	}
+}
+
+// SetGains sets the linear gains of the channels. Channels without a gain in gains keep theirs.
+func (c *ChannelGain) SetGains(gains []float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setGains(gains)
+}
+
+// SetGain sets the linear gain of channel ch.
+func (c *ChannelGain) SetGain(ch int, gain float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gains[ch] = float32(gain)
+}
+
+// SetBalance sets the gains of the first two channels from the balance b, as [Balance] does.
+func (c *ChannelGain) SetBalance(b float64) {
+	left, right := balanceGains(b)
+	c.SetGains([]float64{left, right})
+}
+
+// Gains returns the linear gains of the channels.
+func (c *ChannelGain) Gains() []float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	gains := make([]float64, len(c.gains))
+	for i, g := range c.gains {
+		gains[i] = float64(g)
+	}
+	return gains
+}
+
+// SetSmoothingTime sets the time over which changes of the gains ramp to their new values for samples of the given format.
+// A time of 0 applies changes instantly.
+func (c *ChannelGain) SetSmoothingTime(d time.Duration, format afmt.Format) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.smoothing = afmt.DurationToNumFrames(format.SampleRate, d)
+}
+
+// Process applies the gains to the interleaved samples p.
+func (c *ChannelGain) Process(p []float32) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range p {
+		p[i] *= c.smoothers[c.ch].next(c.gains[c.ch], c.smoothing)
+		c.ch++
+		if c.ch == len(c.gains) {
+			c.ch = 0
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package effect_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// counter returns numFrames frames of numChannels channels in which every sample holds its frame number.
+func counter(numFrames, numChannels int) []float32 {
+	p := make([]float32, numFrames*numChannels)
+	for i := range p {
+		p[i] = float32(i / numChannels)
+	}
+	return p
+}
+
+func TestChannelGain(t *testing.T) {
+	gains := []float64{1, 0.5, 0, -2}
+	c := effect.NewChannelGain(4, gains)
+
+	// chunks that split frames
+	p := counter(100, 4)
+	for i := 0; i < len(p); i += 7 {
+		if err := c.Process(p[i:min(i+7, len(p))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, x := range p {
+		if want := float32(i/4) * float32(gains[i%4]); x != want {
+			t.Fatalf("frame %d, channel %d: expected %v, got %v", i/4, i%4, want, x)
+		}
+	}
+}
+
+func TestChannelGainSmoothing(t *testing.T) {
+	c := effect.NewChannelGain(4, nil)
+	c.SetSmoothingTime(10*time.Millisecond, afmt.Format{SampleRate: 1 * freq.KiloHertz, NumChannels: 4})
+	if err := c.Process(make([]float32, 4)); err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetGain(2, 0)
+	p := make([]float32, 4*20)
+	for i := range p {
+		p[i] = 1
+	}
+	if err := c.Process(p); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, x := range p {
+		frame, ch := i/4, i%4
+		want := float32(1)
+		if ch == 2 {
+			want = max(0, 1-float32(frame+1)/10)
+		}
+		if diff := x - want; diff > 1e-6 || diff < -1e-6 {
+			t.Fatalf("frame %d, channel %d: expected %v, got %v", frame, ch, want, x)
+		}
+	}
+}
+
+func TestBalance(t *testing.T) {
+	tests := []struct {
+		b           float64
+		left, right float32
+	}{
+		{0, 1, 1},
+		{-0.5, 1, 0.5},
+		{0.25, 0.75, 1},
+		{1, 0, 1},
+		{-3, 1, 0}, // clamped
+	}
+	for _, tt := range tests {
+		p := []float32{1, 1, 1}
+		fx := effect.Balance(tt.b)
+		if err := fx.Process(p[:1]); err != nil {
+			t.Fatal(err)
+		}
+		if err := fx.Process(p[1:]); err != nil {
+			t.Fatal(err)
+		}
+		if p[0] != tt.left || p[1] != tt.right || p[2] != tt.left {
+			t.Errorf("Balance(%v): expected %v, %v, %v; got %v", tt.b, tt.left, tt.right, tt.left, p)
+		}
+	}
+
+	fx := effect.Balance(0)
+	fx.SetBalance(-1)
+	if got := fx.Gains(); got[0] != 1 || got[1] != 0 {
+		t.Errorf("expected gains of 1 and 0 after SetBalance(-1), got %v", got)
+	}
+}
+
+func TestChannelGainInvalid(t *testing.T) {
+	if err := effect.NewChannelGain(0, nil).Process(make([]float32, 2)); err == nil {
+		t.Error("expected error for invalid number of channels")
+	}
+}
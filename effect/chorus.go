@@ -0,0 +1,241 @@
+package effect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// chorusMaxDelay is the longest delay of a [Chorus], including the modulation depth.
+const chorusMaxDelay = 50 * time.Millisecond
+
+// Chorus mixes the audio signal with copies of itself delayed by short times that an LFO
+// (low-frequency oscillator) sweeps up and down, which thickens the sound. With a very short delay
+// and feedback, as set up by [NewFlanger], it becomes a flanger.
+//
+// Every voice is a tap into the delay line whose LFO is offset by an equal part of a cycle.
+// Every channel has its own delay line, and the LFOs of consecutive channels are a quarter of a cycle apart,
+// which widens the stereo image. The delay line is read with cubic interpolation.
+type Chorus struct {
+	// Delay is the delay around which the LFO sweeps, typically 5 to 30 ms for a chorus and 1 to 5 ms for a flanger.
+	// Delay plus Depth is clamped to 50 ms.
+	Delay time.Duration
+
+	// Depth is how far the LFO sweeps the delay up and down.
+	Depth time.Duration
+
+	// Rate is the frequency of the LFO. A Rate of 0 freezes the LFO.
+	Rate freq.Frequency
+
+	// Feedback is the amount of the delayed signal fed back into the delay line, from -1 to 1 exclusive.
+	// Values outside of the range are clamped.
+	Feedback float64
+
+	// Mix is the amount of the delayed signal in the output, from 0 (only the dry signal) to 1 (only the delayed signal).
+	Mix float64
+
+	// Voices is the number of delayed copies. Values below 1 mean 1.
+	Voices int
+
+	// Invert, if true, inverts the polarity of the delayed signal, which moves the notches of a flanger
+	// to where its peaks were.
+	Invert bool
+
+	numChannels int
+	sampleRate  float64
+	err         error
+
+	buf   []float32 // interleaved circular buffer
+	pos   int       // frame written next
+	phase float64   // phase of the LFO in cycles
+}
+
+// NewChorus creates a new [Chorus] for samples of the given format with settings for a typical chorus.
+func NewChorus(format afmt.Format) *Chorus {
+	c := &Chorus{
+		Delay:       15 * time.Millisecond,
+		Depth:       5 * time.Millisecond,
+		Rate:        800 * freq.MilliHertz,
+		Mix:         0.5,
+		Voices:      3,
+		numChannels: format.NumChannels,
+		sampleRate:  format.SampleRate.Hertz(),
+	}
+	switch {
+	case format.NumChannels <= 0:
+		c.err = fmt.Errorf("effect: invalid number of channels: %d", format.NumChannels)
+	case format.SampleRate <= 0:
+		c.err = fmt.Errorf("effect: invalid sample rate: %v", format.SampleRate)
+	}
+	if c.err != nil {
+		return c
+	}
+
+	// room for the cubic interpolation on both sides
+	numFrames := afmt.DurationToNumFrames(format.SampleRate, chorusMaxDelay) + 4
+	c.buf = make([]float32, numFrames*format.NumChannels)
+	return c
+}
+
+// NewFlanger creates a new [Chorus] for samples of the given format with settings for a typical flanger:
+// a single voice with a short delay and feedback.
+func NewFlanger(format afmt.Format) *Chorus {
+	c := NewChorus(format)
+	c.Delay = 2 * time.Millisecond
+	c.Depth = 1500 * time.Microsecond
+	c.Rate = 250 * freq.MilliHertz
+	c.Feedback = 0.7
+	c.Voices = 1
+	return c
+}
+
+// Latency returns the longest delay of the delayed signal in frames.
+// It is how long the delayed signal keeps sounding after the input has ended, not counting the feedback.
+func (c *Chorus) Latency() int {
+	return int(math.Ceil(c.delayRange(c.Delay + c.Depth)))
+}
+
+// delayRange converts d to frames and clamps it to the range the delay line can be read at.
+func (c *Chorus) delayRange(d time.Duration) float64 {
+	numFrames := len(c.buf) / max(c.numChannels, 1)
+	return min(max(d.Seconds()*c.sampleRate, 2), float64(numFrames-3))
+}
+
+// Reset clears the delay line and restarts the LFO.
+func (c *Chorus) Reset() {
+	clear(c.buf)
+	c.pos = 0
+	c.phase = 0
+}
+
+// read returns the sample of channel ch delay frames ago, interpolating with a cubic Hermite spline.
+func (c *Chorus) read(ch int, delay float64) float32 {
+	numFrames := len(c.buf) / c.numChannels
+	whole := int(delay)
+	t := float32(delay - float64(whole))
+
+	at := func(back int) float32 {
+		i := c.pos - back
+		if i < 0 {
+			i += numFrames
+		}
+		return c.buf[i*c.numChannels+ch]
+	}
+	return hermite(at(whole-1), at(whole), at(whole+1), at(whole+2), t)
+}
+
+// hermite interpolates between x0 and x1 at t in [0, 1) with a cubic Hermite spline through
+// the neighboring samples xm1 and x2.
+func hermite(xm1, x0, x1, x2, t float32) float32 {
+	c1 := 0.5 * (x1 - xm1)
+	c2 := xm1 - 2.5*x0 + 2*x1 - 0.5*x2
+	c3 := 0.5*(x2-xm1) + 1.5*(x0-x1)
+	return ((c3*t+c2)*t+c1)*t + x0
+}
+
+// Process applies the chorus to the interleaved samples p.
+func (c *Chorus) Process(p []float32) error {
+	if c.err != nil {
+		return c.err
+	}
+	if len(p)%c.numChannels != 0 {
+		return errors.New("effect: chorus needs whole frames")
+	}
+
+	numFrames := len(c.buf) / c.numChannels
+	voices := max(c.Voices, 1)
+	center := c.Delay.Seconds() * c.sampleRate
+	depth := c.Depth.Seconds() * c.sampleRate
+	// the cubic interpolation reads one frame newer than the delay
+	lo, hi := 2.0, float64(numFrames-3)
+	step := c.Rate.Hertz() / c.sampleRate
+	fb := float32(max(-0.99, min(c.Feedback, 0.99)))
+	wet := float32(max(0, min(c.Mix, 1)))
+	dry := 1 - wet
+	if c.Invert {
+		wet, fb = -wet, -fb
+	}
+
+	for i := 0; i < len(p); i += c.numChannels {
+		frame := p[i : i+c.numChannels]
+		line := c.buf[c.pos*c.numChannels : (c.pos+1)*c.numChannels]
+
+		for ch, x := range frame {
+			var y float32
+			for v := range voices {
+				lfo := math.Sin(2 * math.Pi * (c.phase + float64(v)/float64(voices) + float64(ch)/4))
+				y += c.read(ch, max(lo, min(center+depth*lfo, hi)))
+			}
+			y /= float32(voices)
+
+			line[ch] = x + fb*y
+			frame[ch] = dry*x + wet*y
+		}
+
+		c.pos++
+		if c.pos == numFrames {
+			c.pos = 0
+		}
+		c.phase += step
+		c.phase -= math.Floor(c.phase)
+	}
+	return nil
+}
+
+// silent reports whether the delay line has died out.
+func (c *Chorus) silent() bool {
+	for _, x := range c.buf {
+		if math.Abs(float64(x)) > delaySilence {
+			return false
+		}
+	}
+	return true
+}
+
+// Reader returns an aio.SampleReader that reads from r and applies c to the samples.
+// After the end of r, it keeps playing the delayed signal until it has died out.
+func (c *Chorus) Reader(r aio.SampleReader) aio.SampleReader {
+	return &chorusReader{r: r, c: c}
+}
+
+type chorusReader struct {
+	r   aio.SampleReader
+	c   *Chorus
+	eof bool
+}
+
+func (r *chorusReader) ReadSamples(p []float32) (int, error) {
+	if r.c.err != nil {
+		return 0, r.c.err
+	}
+	p = p[:len(p)/r.c.numChannels*r.c.numChannels]
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if !r.eof {
+		n, err := aio.ReadFull(r.r, p)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			r.eof = true
+			n = n / r.c.numChannels * r.c.numChannels
+		} else if err != nil {
+			return 0, err
+		}
+		if n > 0 {
+			return n, r.c.Process(p[:n])
+		}
+	}
+
+	// play the delayed signal by feeding silence
+	if r.c.silent() {
+		return 0, io.EOF
+	}
+	clear(p)
+	return len(p), r.c.Process(p)
+}
@@ -0,0 +1,136 @@
+package effect_test
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp/fft"
+	"github.com/MatusOllah/resona/dsp/window"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var chorusMono = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}
+
+// powerSpectrum returns the averaged power spectrum of x over Hann-windowed segments of size n.
+func powerSpectrum(x []float32, n int) []float64 {
+	plan := fft.NewReal(n)
+	win := window.Hann(n)
+	seg := make([]float64, n)
+	spec := make([]complex128, n/2+1)
+	power := make([]float64, n/2+1)
+	for start := 0; start+n <= len(x); start += n / 2 {
+		for i := range seg {
+			seg[i] = float64(x[start+i]) * win[i]
+		}
+		plan.Forward(spec, seg)
+		for i, c := range spec {
+			a := cmplx.Abs(c)
+			power[i] += a * a
+		}
+	}
+	return power
+}
+
+func TestFlangerNotches(t *testing.T) {
+	const (
+		n       = 4096
+		binSize = 48000.0 / n
+	)
+	rng := rand.New(rand.NewPCG(7, 8))
+	noise := make([]float32, 1<<17)
+	for i := range noise {
+		noise[i] = float32(2*rng.Float64() - 1)
+	}
+
+	// a frozen LFO at a delay of 1 ms comb-filters the noise with notches every 1 kHz
+	for _, invert := range []bool{false, true} {
+		fl := effect.NewFlanger(chorusMono)
+		fl.Delay, fl.Rate, fl.Feedback, fl.Invert = time.Millisecond, 0, 0, invert
+
+		p := append([]float32(nil), noise...)
+		processChunks(t, fl, p, 1)
+		power := powerSpectrum(p, n)
+
+		level := func(f float64) float64 {
+			return 10 * math.Log10(power[int(math.Round(f/binSize))])
+		}
+		for k := 1; k < 20; k++ {
+			notch, peak := 1000*float64(k)-500, 1000*float64(k)
+			if invert {
+				notch, peak = peak, notch
+			}
+			if level(notch) > level(peak)-30 {
+				t.Errorf("invert %v: expected a notch at %v Hz, got %.1f dB against %.1f dB at %v Hz",
+					invert, notch, level(notch), level(peak), peak)
+			}
+		}
+	}
+}
+
+func TestChorusInterpolation(t *testing.T) {
+	// a delay between frames is interpolated smoothly
+	c := effect.NewChorus(chorusMono)
+	c.Delay, c.Depth, c.Rate, c.Mix, c.Voices = 213541*time.Nanosecond, 0, 0, 1, 1 // 10.25 frames
+	const f = 1000.0
+
+	p := make([]float32, 4800)
+	for i := range p {
+		p[i] = float32(math.Sin(2 * math.Pi * f * float64(i) / 48000))
+	}
+	processChunks(t, c, p, 1)
+
+	for i := 100; i < len(p); i++ {
+		want := math.Sin(2 * math.Pi * f * (float64(i) - 10.25) / 48000)
+		if math.Abs(float64(p[i])-want) > 1e-3 {
+			t.Fatalf("sample %d: expected %v, got %v", i, want, p[i])
+		}
+	}
+}
+
+func TestChorusStereo(t *testing.T) {
+	// the LFOs of the channels are apart, so identical channels come out different
+	c := effect.NewChorus(afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2})
+	p := make([]float32, 2*48000)
+	for i := range len(p) / 2 {
+		x := float32(math.Sin(2 * math.Pi * 440 * float64(i) / 48000))
+		p[2*i], p[2*i+1] = x, x
+	}
+	processChunks(t, c, p, 2)
+
+	var diff float64
+	for i := 0; i < len(p); i += 2 {
+		diff = max(diff, math.Abs(float64(p[i]-p[i+1])))
+	}
+	if diff < 0.01 {
+		t.Errorf("expected the channels to differ, got a difference of at most %v", diff)
+	}
+}
+
+func TestChorusReader(t *testing.T) {
+	c := effect.NewFlanger(chorusMono)
+	if got, want := c.Latency(), 168; got != want {
+		t.Errorf("expected a latency of %d frames, got %d", want, got)
+	}
+
+	in := make([]float32, 1000)
+	in[0] = 1
+	out, err := aio.ReadAll(c.Reader(audio.NewBuffer(in)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) <= len(in) {
+		t.Fatalf("expected the output to be longer than the input, got %d samples", len(out))
+	}
+	for i, x := range out {
+		if math.IsNaN(float64(x)) || math.Abs(float64(x)) > 1 {
+			t.Fatalf("sample %d: expected a bounded output, got %v", i, x)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package effect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+)
+
+// Default settings of a [Compressor].
+const (
+	DefaultCompressorThreshold = -20.0 // dB
+	DefaultCompressorRatio     = 4.0
+	DefaultCompressorKnee      = 6.0 // dB
+	DefaultCompressorAttack    = 10 * time.Millisecond
+	DefaultCompressorRelease   = 100 * time.Millisecond
+)
+
+// Compressor reduces the dynamic range of the audio signal by attenuating it
+// when its level rises above a threshold.
+//
+// The level is measured by an envelope follower and the gain is computed in dB,
+// with a soft knee around the threshold. All channels of a frame are linked:
+// the loudest channel drives a single gain, which keeps the stereo image in place.
+//
+// Use [SidechainReader] to drive a Compressor with another signal.
+type Compressor struct {
+	// Threshold is the level in dBFS above which the signal is compressed.
+	Threshold float64
+
+	// Ratio is the compression ratio. A ratio of 4 turns a level 8 dB above the threshold into 2 dB above it.
+	// Ratios of 1 or less leave the signal unchanged.
+	Ratio float64
+
+	// Knee is the width of the soft knee in dB, centered at the threshold. A Knee of 0 is a hard knee.
+	Knee float64
+
+	// Attack and Release are the time constants of the envelope follower
+	// for rising and falling levels, respectively.
+	Attack, Release time.Duration
+
+	// MakeUp is the gain in dB applied after compression.
+	MakeUp float64
+
+	// Mode selects how the level is measured.
+	Mode DetectionMode
+
+	numChannels int
+	sampleRate  float64
+	env         envelope
+	err         error
+}
+
+// NewCompressor creates a new [Compressor] for samples of the given format
+// with the default settings.
+func NewCompressor(format afmt.Format) *Compressor {
+	c := &Compressor{
+		Threshold:   DefaultCompressorThreshold,
+		Ratio:       DefaultCompressorRatio,
+		Knee:        DefaultCompressorKnee,
+		Attack:      DefaultCompressorAttack,
+		Release:     DefaultCompressorRelease,
+		numChannels: format.NumChannels,
+		sampleRate:  format.SampleRate.Hertz(),
+	}
+	if format.NumChannels <= 0 {
+		c.err = fmt.Errorf("effect: invalid number of channels: %d", format.NumChannels)
+	} else if format.SampleRate <= 0 {
+		c.err = fmt.Errorf("effect: invalid sample rate: %v", format.SampleRate)
+	}
+	return c
+}
+
+// GainReduction returns the static gain change in dB (zero or negative) for an input level in dBFS,
+// not including the make-up gain.
+func (c *Compressor) GainReduction(level float64) float64 {
+	if c.Ratio <= 1 {
+		return 0
+	}
+	slope := 1/c.Ratio - 1
+	over := level - c.Threshold
+	switch {
+	case 2*over <= -c.Knee:
+		return 0
+	case 2*over < c.Knee:
+		x := over + c.Knee/2
+		return slope * x * x / (2 * c.Knee)
+	default:
+		return slope * over
+	}
+}
+
+// Process compresses the interleaved samples p.
+func (c *Compressor) Process(p []float32) error {
+	return c.process(p, p)
+}
+
+// process compresses p using the level of key, which has the same layout.
+func (c *Compressor) process(p, key []float32) error {
+	if c.err != nil {
+		return c.err
+	}
+	if len(p)%c.numChannels != 0 {
+		return errors.New("effect: compressor needs whole frames")
+	}
+
+	c.env.mode = c.Mode
+	c.env.rmsCoef = smoothingCoef(rmsWindow, c.sampleRate)
+	attack := smoothingCoef(c.Attack, c.sampleRate)
+	release := smoothingCoef(c.Release, c.sampleRate)
+
+	for i := 0; i < len(p); i += c.numChannels {
+		var x float64
+		for _, s := range key[i : i+c.numChannels] {
+			x = max(x, math.Abs(float64(s)))
+		}
+		level := dsp.AmplitudeToDB(c.env.follow(x, attack, release))
+
+		gain := float32(dsp.DBToAmplitude(c.GainReduction(level) + c.MakeUp))
+		for ch := range c.numChannels {
+			p[i+ch] *= gain
+		}
+	}
+	return nil
+}
+
+type sidechainReader struct {
+	r, key aio.SampleReader
+	c      *Compressor
+	keyBuf []float32
+	keyEOF bool
+}
+
+// SidechainReader wraps an aio.SampleReader and compresses its output with c,
+// whose level is measured on key instead, such as to duck music under a voice.
+// Both readers must produce samples of the format c was created with.
+// Once key ends, it is treated as silence.
+func SidechainReader(r, key aio.SampleReader, c *Compressor) aio.SampleReader {
+	return &sidechainReader{r: r, key: key, c: c}
+}
+
+func (sr *sidechainReader) ReadSamples(p []float32) (int, error) {
+	n, err := sr.r.ReadSamples(p)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	if cap(sr.keyBuf) < n {
+		sr.keyBuf = make([]float32, n)
+	}
+	key := sr.keyBuf[:n]
+
+	m := 0
+	if !sr.keyEOF {
+		var keyErr error
+		m, keyErr = aio.ReadFull(sr.key, key)
+		if keyErr == io.EOF || keyErr == io.ErrUnexpectedEOF {
+			sr.keyEOF = true
+		} else if keyErr != nil {
+			return 0, keyErr
+		}
+	}
+	clear(key[m:])
+
+	if procErr := sr.c.process(p[:n], key); procErr != nil {
+		return 0, procErr
+	}
+	return n, err
+}
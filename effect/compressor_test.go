@@ -0,0 +1,181 @@
+package effect_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var compressorFormat = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}
+
+// squareSteps returns a 1 kHz square wave at 48 kHz whose amplitude steps through amplitudes,
+// holding each for numFrames frames. Its absolute value is constant, so a peak detector sees the steps exactly.
+func squareSteps(numFrames int, amplitudes ...float64) []float32 {
+	var s []float32
+	for _, a := range amplitudes {
+		for i := range numFrames {
+			if i%48 < 24 {
+				s = append(s, float32(a))
+			} else {
+				s = append(s, float32(-a))
+			}
+		}
+	}
+	return s
+}
+
+func TestCompressorGainReduction(t *testing.T) {
+	c := effect.NewCompressor(compressorFormat)
+	tests := []struct {
+		level, want float64
+	}{
+		{-40, 0},
+		{-23, 0},                // below the knee
+		{-20, -0.75 * 9.0 / 12}, // center of the knee
+		{-17, -0.75 * 3},        // above the knee
+		{-10, -0.75 * 10},       // 10 dB over, compressed to 2.5 dB over
+		{0, -0.75 * 20},         // 20 dB over, compressed to 5 dB over
+	}
+	for _, tt := range tests {
+		if got := c.GainReduction(tt.level); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("GainReduction(%v) = %v; want %v", tt.level, got, tt.want)
+		}
+	}
+
+	c.Ratio = 1
+	if got := c.GainReduction(0); got != 0 {
+		t.Errorf("GainReduction(0) with a ratio of 1 = %v; want 0", got)
+	}
+}
+
+func TestCompressorStatic(t *testing.T) {
+	for _, levelDB := range []float64{-40, -25, -20, -15, -10, -3} {
+		// a sine whose RMS level is levelDB
+		a := dsp.DBToAmplitude(levelDB) * math.Sqrt2
+		s := make([]float32, 48000)
+		for i := range s {
+			s[i] = float32(a * math.Sin(2*math.Pi*1000*float64(i)/48000))
+		}
+
+		c := effect.NewCompressor(compressorFormat)
+		c.Mode = effect.DetectRMS
+		c.MakeUp = 3
+		if err := c.Process(s); err != nil {
+			t.Fatal(err)
+		}
+
+		// the last 100 ms, well after the attack
+		var sum float64
+		for _, x := range s[len(s)-4800:] {
+			sum += float64(x) * float64(x)
+		}
+		got := dsp.PowerToDB(sum / 4800)
+		want := levelDB + c.GainReduction(levelDB) + 3
+		if math.Abs(got-want) > 0.1 {
+			t.Errorf("%v dB: expected an output level of %.2f dB, got %.2f dB", levelDB, want, got)
+		}
+	}
+}
+
+func TestCompressorTiming(t *testing.T) {
+	const (
+		attack  = 5 * time.Millisecond
+		release = 50 * time.Millisecond
+		hold    = 24000
+	)
+	s := squareSteps(hold, 0.01, 1, 0.01)
+	in := append([]float32(nil), s...)
+
+	// hard knee with the threshold below the signal, so the gain follows the envelope everywhere
+	c := effect.NewCompressor(compressorFormat)
+	c.Threshold, c.Ratio, c.Knee = -60, 2, 0
+	c.Attack, c.Release = attack, release
+	if err := c.Process(s); err != nil {
+		t.Fatal(err)
+	}
+
+	// recover the envelope from the gain: gain = (1/ratio-1) * (level-threshold)
+	envelope := func(i int) float64 {
+		gain := dsp.AmplitudeToDB(float64(s[i] / in[i]))
+		return dsp.DBToAmplitude(-60 - 2*gain)
+	}
+
+	// after one time constant, the envelope has covered 1-1/e of the step
+	tests := []struct {
+		name  string
+		start int
+		tau   time.Duration
+		want  float64
+	}{
+		{"attack", hold, attack, 1 - 0.99/math.E},
+		{"release", 2 * hold, release, 0.01 + 0.99/math.E},
+	}
+	for _, tt := range tests {
+		i := tt.start + afmt.DurationToNumFrames(compressorFormat.SampleRate, tt.tau) - 1
+		if got := envelope(i); math.Abs(got-tt.want) > 0.01*tt.want {
+			t.Errorf("%s: expected an envelope of %.4f after %v, got %.4f", tt.name, tt.want, tt.tau, got)
+		}
+	}
+}
+
+func TestCompressorStereoLink(t *testing.T) {
+	// loud left channel, quiet right channel
+	s := make([]float32, 2*4800)
+	for i := 0; i < len(s); i += 2 {
+		s[i], s[i+1] = 1, 0.01
+	}
+
+	c := effect.NewCompressor(afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2})
+	c.Attack = 0
+	if err := c.Process(s); err != nil {
+		t.Fatal(err)
+	}
+
+	want := float32(dsp.DBToAmplitude(c.GainReduction(0)))
+	if math.Abs(float64(s[len(s)-2]-want)) > 1e-6 || math.Abs(float64(s[len(s)-1]-0.01*want)) > 1e-6 {
+		t.Errorf("expected both channels to get a gain of %v, got %v and %v", want, s[len(s)-2], s[len(s)-1]/0.01)
+	}
+}
+
+func TestSidechain(t *testing.T) {
+	const n = 4800
+	music := make([]float32, 3*n)
+	for i := range music {
+		music[i] = 0.1
+	}
+	// silent, loud and then ending early
+	key := squareSteps(n, 0, 1)
+
+	c := effect.NewCompressor(compressorFormat)
+	c.Attack, c.Release = 0, 0
+	out, err := aio.ReadAll(effect.SidechainReader(audio.NewBuffer(music), audio.NewBuffer(key), c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(music) {
+		t.Fatalf("expected %d samples, got %d", len(music), len(out))
+	}
+
+	ducked := float32(0.1 * dsp.DBToAmplitude(c.GainReduction(0)))
+	for i, want := range []float32{0.1, ducked, 0.1} {
+		if got := out[i*n+n/2]; math.Abs(float64(got-want)) > 1e-6 {
+			t.Errorf("section %d: expected %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestCompressorInvalid(t *testing.T) {
+	if err := effect.NewCompressor(afmt.Format{SampleRate: 48 * freq.KiloHertz}).Process(make([]float32, 2)); err == nil {
+		t.Error("expected error for invalid number of channels")
+	}
+	if err := effect.NewCompressor(afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}).Process(make([]float32, 3)); err == nil {
+		t.Error("expected error for partial frame")
+	}
+}
@@ -0,0 +1,196 @@
+package effect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+)
+
+// delayGlide is the time constant of the delay time following changes of [Delay.Time].
+const delayGlide = 50 * time.Millisecond
+
+// delaySilence is the level below which the echoes of a [Delay] are considered to have died out.
+const delaySilence = 1e-5 // -100 dBFS
+
+// Delay repeats the audio signal after a delay, feeding the echoes back to repeat them again.
+//
+// Every channel has its own delay line. Changes of [Delay.Time] glide to the new delay time
+// like a tape echo instead of jumping, which would click.
+type Delay struct {
+	// Time is the delay time. It is clamped to the maximum delay time the Delay was created with.
+	Time time.Duration
+
+	// Feedback is the amount of the echoes fed back into the delay line, from 0 (a single echo) to below 1.
+	// Values outside of the range are clamped.
+	Feedback float64
+
+	// Mix is the amount of the delayed signal in the output, from 0 (only the dry signal) to 1 (only the echoes).
+	Mix float64
+
+	// PingPong, if true, bounces the echoes of stereo signals between the left and right channels.
+	// It has no effect on other numbers of channels.
+	PingPong bool
+
+	numChannels int
+	sampleRate  float64
+	err         error
+
+	buf   []float32 // interleaved circular buffer
+	pos   int       // frame written next
+	delay float64   // current delay time in frames, -1 before the first frame
+	y     []float32 // delayed frame
+}
+
+// NewDelay creates a new [Delay] for samples of the given format, which can delay by up to maxTime.
+// The delay time is initially maxTime, with no feedback and an equal mix of the dry and delayed signals.
+func NewDelay(format afmt.Format, maxTime time.Duration) *Delay {
+	d := &Delay{
+		Time:        maxTime,
+		Mix:         0.5,
+		numChannels: format.NumChannels,
+		sampleRate:  format.SampleRate.Hertz(),
+		delay:       -1,
+	}
+	switch {
+	case format.NumChannels <= 0:
+		d.err = fmt.Errorf("effect: invalid number of channels: %d", format.NumChannels)
+	case format.SampleRate <= 0:
+		d.err = fmt.Errorf("effect: invalid sample rate: %v", format.SampleRate)
+	case maxTime <= 0:
+		d.err = fmt.Errorf("effect: invalid maximum delay time: %v", maxTime)
+	}
+	if d.err != nil {
+		return d
+	}
+
+	// two more frames for interpolation and for the frame being written
+	numFrames := afmt.DurationToNumFrames(format.SampleRate, maxTime) + 2
+	d.buf = make([]float32, numFrames*format.NumChannels)
+	d.y = make([]float32, format.NumChannels)
+	return d
+}
+
+// Reset clears the delay line.
+func (d *Delay) Reset() {
+	clear(d.buf)
+	d.pos = 0
+	d.delay = -1
+}
+
+// read writes the frame delay frames ago to d.y, interpolating linearly between frames.
+func (d *Delay) read(delay float64) {
+	numFrames := len(d.buf) / d.numChannels
+	whole := int(delay)
+	frac := float32(delay - float64(whole))
+
+	a := (d.pos - whole + numFrames) % numFrames
+	b := (a - 1 + numFrames) % numFrames
+	for ch := range d.y {
+		x0, x1 := d.buf[a*d.numChannels+ch], d.buf[b*d.numChannels+ch]
+		d.y[ch] = x0 + frac*(x1-x0)
+	}
+}
+
+// Process applies the delay to the interleaved samples p.
+func (d *Delay) Process(p []float32) error {
+	if d.err != nil {
+		return d.err
+	}
+	if len(p)%d.numChannels != 0 {
+		return errors.New("effect: delay needs whole frames")
+	}
+
+	numFrames := len(d.buf) / d.numChannels
+	target := min(max(d.Time.Seconds()*d.sampleRate, 1), float64(numFrames-2))
+	if d.delay < 0 {
+		d.delay = target
+	}
+	glide := smoothingCoef(delayGlide, d.sampleRate)
+	fb := float32(max(0, min(d.Feedback, 1)))
+	wet := float32(max(0, min(d.Mix, 1)))
+	dry := 1 - wet
+	pingPong := d.PingPong && d.numChannels == 2
+
+	for i := 0; i < len(p); i += d.numChannels {
+		frame := p[i : i+d.numChannels]
+		d.delay = target + glide*(d.delay-target)
+		d.read(d.delay)
+
+		line := d.buf[d.pos*d.numChannels : (d.pos+1)*d.numChannels]
+		if pingPong {
+			// the input enters on the left, and every echo crosses over to the other side
+			line[0] = (frame[0]+frame[1])/2 + fb*d.y[1]
+			line[1] = fb * d.y[0]
+		} else {
+			for ch, x := range frame {
+				line[ch] = x + fb*d.y[ch]
+			}
+		}
+		d.pos++
+		if d.pos == numFrames {
+			d.pos = 0
+		}
+
+		for ch, x := range frame {
+			frame[ch] = dry*x + wet*d.y[ch]
+		}
+	}
+	return nil
+}
+
+// silent reports whether the echoes in the delay line have died out.
+func (d *Delay) silent() bool {
+	for _, x := range d.buf {
+		if math.Abs(float64(x)) > delaySilence {
+			return false
+		}
+	}
+	return true
+}
+
+// Reader returns an aio.SampleReader that reads from r and applies d to the samples.
+// After the end of r, it keeps playing the echoes until they have died out.
+func (d *Delay) Reader(r aio.SampleReader) aio.SampleReader {
+	return &delayReader{r: r, d: d}
+}
+
+type delayReader struct {
+	r   aio.SampleReader
+	d   *Delay
+	eof bool
+}
+
+func (r *delayReader) ReadSamples(p []float32) (int, error) {
+	if r.d.err != nil {
+		return 0, r.d.err
+	}
+	p = p[:len(p)/r.d.numChannels*r.d.numChannels]
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if !r.eof {
+		n, err := aio.ReadFull(r.r, p)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			r.eof = true
+			n = n / r.d.numChannels * r.d.numChannels
+		} else if err != nil {
+			return 0, err
+		}
+		if n > 0 {
+			return n, r.d.Process(p[:n])
+		}
+	}
+
+	// play the echoes by feeding silence
+	if r.d.silent() {
+		return 0, io.EOF
+	}
+	clear(p)
+	return len(p), r.d.Process(p)
+}
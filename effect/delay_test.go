@@ -0,0 +1,155 @@
+package effect_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var delayMono = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}
+
+// processChunks processes p with fx in uneven chunks of whole frames.
+func processChunks(t *testing.T, fx effect.Effect, p []float32, numChannels int) {
+	t.Helper()
+
+	for i := 0; len(p) > 0; i++ {
+		n := min(len(p), numChannels*(100+37*(i%7)))
+		if err := fx.Process(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+}
+
+func TestDelayEchoes(t *testing.T) {
+	d := effect.NewDelay(delayMono, 100*time.Millisecond)
+	d.Feedback, d.Mix = 0.5, 1
+
+	p := make([]float32, 6*4800)
+	p[0] = 1
+	processChunks(t, d, p, 1)
+
+	for i, x := range p {
+		var want float32
+		if i > 0 && i%4800 == 0 {
+			want = float32(math.Pow(0.5, float64(i/4800-1)))
+		}
+		if x != want {
+			t.Fatalf("sample %d: expected %v, got %v", i, want, x)
+		}
+	}
+}
+
+func TestDelayMix(t *testing.T) {
+	d := effect.NewDelay(delayMono, 10*time.Millisecond)
+	p := make([]float32, 1000)
+	p[0] = 1
+	processChunks(t, d, p, 1)
+
+	if p[0] != 0.5 || p[480] != 0.5 || p[960] != 0 {
+		t.Errorf("expected the dry and delayed impulses at half level and a single echo, got %v, %v and %v", p[0], p[480], p[960])
+	}
+}
+
+func TestDelayChannels(t *testing.T) {
+	stereo := afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+	t.Run("Independent", func(t *testing.T) {
+		d := effect.NewDelay(stereo, 10*time.Millisecond)
+		d.Feedback, d.Mix = 0.5, 1
+
+		p := make([]float32, 2*2000)
+		p[0], p[2*100+1] = 1, -1 // left at 0, right at 100
+		processChunks(t, d, p, 2)
+
+		for _, tt := range []struct {
+			i    int
+			want float32
+		}{
+			{2 * 480, 1}, {2 * 960, 0.5}, {2*580 + 1, -1}, {2*1060 + 1, -0.5},
+			{2*480 + 1, 0}, {2 * 580, 0},
+		} {
+			if p[tt.i] != tt.want {
+				t.Errorf("sample %d: expected %v, got %v", tt.i, tt.want, p[tt.i])
+			}
+		}
+	})
+
+	t.Run("PingPong", func(t *testing.T) {
+		d := effect.NewDelay(stereo, 10*time.Millisecond)
+		d.Feedback, d.Mix, d.PingPong = 0.5, 1, true
+
+		p := make([]float32, 2*2000)
+		p[0], p[1] = 1, 1
+		processChunks(t, d, p, 2)
+
+		// echoes alternate between left and right
+		for k, want := range []float32{1, 0.5, 0.25} {
+			i := 2 * 480 * (k + 1)
+			left, right := p[i], p[i+1]
+			if k%2 == 1 {
+				left, right = right, left
+			}
+			if left != want || right != 0 {
+				t.Errorf("echo %d: expected %v on one side only, got %v and %v", k+1, want, p[i], p[i+1])
+			}
+		}
+	})
+}
+
+func TestDelayTimeChange(t *testing.T) {
+	d := effect.NewDelay(delayMono, 200*time.Millisecond)
+	d.Time, d.Mix = 100*time.Millisecond, 1
+
+	const f = 450 // 22.5 periods in 50 ms, so jumping to the new time would flip the phase
+	p := make([]float32, 48000)
+	for i := range p {
+		p[i] = float32(math.Sin(2 * math.Pi * f * float64(i) / 48000))
+	}
+	processChunks(t, d, p[:24000], 1)
+	d.Time = 50 * time.Millisecond
+	processChunks(t, d, p[24000:], 1)
+
+	// the delay glides to the new time, which shifts the pitch for a moment but never jumps
+	maxStep := 3 * 2 * math.Pi * f / 48000
+	for i := 4801; i < len(p); i++ {
+		if step := math.Abs(float64(p[i] - p[i-1])); step > maxStep {
+			t.Fatalf("sample %d: expected a smooth change, got a step of %v", i, step)
+		}
+	}
+}
+
+func TestDelayReader(t *testing.T) {
+	d := effect.NewDelay(delayMono, 100*time.Millisecond)
+	d.Feedback, d.Mix = 0.5, 1
+
+	in := make([]float32, 100)
+	in[0] = 1
+	out, err := aio.ReadAll(d.Reader(audio.NewBuffer(in)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the echoes decay below -100 dBFS after 17 repeats
+	if len(out) < 17*4800 || len(out) > 19*4800 {
+		t.Fatalf("expected the tail to end after about 17 echoes, got %d samples", len(out))
+	}
+	if want := float32(math.Pow(0.5, 16)); out[17*4800] != want {
+		t.Errorf("expected the 17th echo to be %v, got %v", want, out[17*4800])
+	}
+}
+
+func TestDelayInvalid(t *testing.T) {
+	if err := effect.NewDelay(delayMono, 0).Process(make([]float32, 1)); err == nil {
+		t.Error("expected error for zero maximum delay time")
+	}
+	if err := effect.NewDelay(afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}, time.Second).Process(make([]float32, 3)); err == nil {
+		t.Error("expected error for partial frame")
+	}
+}
@@ -54,6 +54,38 @@ func (r *reader) ReadSamples(p []float32) (int, error) {
 	return n, err
 }
 
+type frameReader struct {
+	r           aio.SampleReader
+	fx          Effect
+	numChannels int
+}
+
+// newFrameReader is like [Reader], but only passes whole frames of numChannels interleaved channels to fx.
+func newFrameReader(r aio.SampleReader, fx Effect, numChannels int) *frameReader {
+	return &frameReader{r: r, fx: fx, numChannels: numChannels}
+}
+
+func (r *frameReader) ReadSamples(p []float32) (int, error) {
+	p = p[:len(p)/r.numChannels*r.numChannels]
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	n, err := aio.ReadFull(r.r, p)
+	n = n / r.numChannels * r.numChannels
+	if err == io.ErrUnexpectedEOF {
+		// a partial frame at the end is dropped
+		err = nil
+		if n == 0 {
+			err = io.EOF
+		}
+	}
+	if fxErr := r.fx.Process(p[:n]); fxErr != nil {
+		return 0, fxErr
+	}
+	return n, err
+}
+
 // Apply applies the given [Effect] to the input sample slice and returns the processed ones.
 // To apply the effect in-place, use [Effect.Process] directly instead.
 func Apply(p []float32, fx Effect) ([]float32, error) {
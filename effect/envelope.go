@@ -0,0 +1,61 @@
+package effect
+
+import (
+	"math"
+	"time"
+)
+
+// DetectionMode selects how an envelope follower measures the level of the signal.
+type DetectionMode int
+
+const (
+	// DetectPeak follows the absolute value of the samples, reacting to every transient.
+	DetectPeak DetectionMode = iota
+
+	// DetectRMS follows the root mean square of the samples over a short window, which is closer to perceived loudness.
+	DetectRMS
+)
+
+// rmsWindow is the time constant of the mean of the squared samples in [DetectRMS] mode.
+const rmsWindow = 20 * time.Millisecond
+
+// smoothingCoef returns the coefficient of a one-pole smoother with the time constant d,
+// which is the time it takes to cover 1-1/e (about 63%) of a step.
+func smoothingCoef(d time.Duration, sampleRate float64) float64 {
+	if d <= 0 || sampleRate <= 0 {
+		return 0
+	}
+	return math.Exp(-1 / (d.Seconds() * sampleRate))
+}
+
+// envelope follows the level of a signal with separate attack and release time constants.
+//
+// In RMS mode, the squared samples are averaged with the symmetric time constant rmsWindow first,
+// as following them with a fast attack and a slow release would track the peaks instead.
+//
+// The zero value for envelope starts at silence in peak mode.
+type envelope struct {
+	mode    DetectionMode
+	rmsCoef float64 // coefficient of the mean square, from smoothingCoef(rmsWindow, sampleRate)
+
+	meanSquare float64
+	level      float64
+}
+
+// follow feeds the detector input x to the envelope and returns the current level as a linear amplitude.
+// attack and release are coefficients from smoothingCoef.
+func (e *envelope) follow(x, attack, release float64) float64 {
+	if e.mode == DetectRMS {
+		e.meanSquare = x*x + e.rmsCoef*(e.meanSquare-x*x)
+		x = math.Sqrt(e.meanSquare)
+	} else {
+		x = math.Abs(x)
+	}
+
+	coef := release
+	if x > e.level {
+		coef = attack
+	}
+	e.level = x + coef*(e.level-x)
+	return e.level
+}
@@ -0,0 +1,202 @@
+package effect
+
+import (
+	"errors"
+	"fmt"
+	"math/cmplx"
+	"slices"
+	"sync"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/dsp/filter"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// Band is a band of an [Equalizer], created by [Equalizer.AddBand].
+//
+// Changes of a band take effect atomically between two buffers processed by the Equalizer,
+// and keep the state of its filters, so they do not click.
+type Band struct {
+	eq *Equalizer
+
+	typ     filter.BiquadType
+	freq    freq.Frequency
+	q       float64
+	gainDB  float64
+	enabled bool
+
+	design  *filter.Biquad
+	filters []filter.Biquad // one per channel
+}
+
+// Equalizer shapes the frequency response of the audio signal with a series of biquad filter bands,
+// such as a parametric EQ.
+//
+// Every band filters every channel with its own state.
+// An Equalizer is safe for concurrent use, so bands can be changed from a UI goroutine while
+// Process is called from the audio goroutine.
+type Equalizer struct {
+	format afmt.Format
+	err    error
+
+	mu    sync.Mutex
+	bands []*Band
+}
+
+// NewEqualizer creates a new [Equalizer] without any bands for samples of the given format.
+func NewEqualizer(format afmt.Format) *Equalizer {
+	eq := &Equalizer{format: format}
+	switch {
+	case format.NumChannels <= 0:
+		eq.err = fmt.Errorf("effect: invalid number of channels: %d", format.NumChannels)
+	case format.SampleRate <= 0:
+		eq.err = fmt.Errorf("effect: invalid sample rate: %v", format.SampleRate)
+	}
+	return eq
+}
+
+// designBand designs the filter of a band, turning the panics of the filter constructors into errors.
+func (eq *Equalizer) designBand(typ filter.BiquadType, f freq.Frequency, q, gainDB float64) (b *filter.Biquad, err error) {
+	if eq.err != nil {
+		return nil, eq.err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("effect: invalid %v band at %v: %v", typ, f, r)
+		}
+	}()
+	return filter.NewBiquadOfType(typ, f, eq.format.SampleRate, q, gainDB), nil
+}
+
+// AddBand adds an enabled band to the end of the equalizer and returns it.
+// For the shelving filters, q is the slope. The gain is ignored by types that do not take one.
+func (eq *Equalizer) AddBand(typ filter.BiquadType, f freq.Frequency, q, gainDB float64) (*Band, error) {
+	design, err := eq.designBand(typ, f, q, gainDB)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Band{
+		eq:      eq,
+		typ:     typ,
+		freq:    f,
+		q:       q,
+		gainDB:  gainDB,
+		enabled: true,
+		design:  design,
+		filters: make([]filter.Biquad, eq.format.NumChannels),
+	}
+	for i := range b.filters {
+		b.filters[i] = *design
+	}
+
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	eq.bands = append(eq.bands, b)
+	return b, nil
+}
+
+// RemoveBand removes b from the equalizer.
+func (eq *Equalizer) RemoveBand(b *Band) {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	eq.bands = slices.DeleteFunc(eq.bands, func(x *Band) bool { return x == b })
+}
+
+// Bands returns the bands of the equalizer in processing order.
+func (eq *Equalizer) Bands() []*Band {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	return slices.Clone(eq.bands)
+}
+
+// Response returns the gain of the enabled bands at the frequency f in dB, such as for drawing the curve of the equalizer.
+func (eq *Equalizer) Response(f freq.Frequency) float64 {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+
+	h := complex(1, 0)
+	for _, b := range eq.bands {
+		if b.enabled {
+			h *= b.design.Response(f, eq.format.SampleRate)
+		}
+	}
+	return dsp.AmplitudeToDB(cmplx.Abs(h))
+}
+
+// Process equalizes the interleaved samples p.
+func (eq *Equalizer) Process(p []float32) error {
+	if eq.err != nil {
+		return eq.err
+	}
+	if len(p)%eq.format.NumChannels != 0 {
+		return errors.New("effect: equalizer needs whole frames")
+	}
+
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+
+	for _, b := range eq.bands {
+		if !b.enabled {
+			continue
+		}
+		for ch := range b.filters {
+			f := &b.filters[ch]
+			for i := ch; i < len(p); i += len(b.filters) {
+				p[i] = f.ProcessSingle(p[i])
+			}
+		}
+	}
+	return nil
+}
+
+// Set changes all parameters of the band. On error, the band is left unchanged.
+func (b *Band) Set(typ filter.BiquadType, f freq.Frequency, q, gainDB float64) error {
+	design, err := b.eq.designBand(typ, f, q, gainDB)
+	if err != nil {
+		return err
+	}
+
+	b.eq.mu.Lock()
+	defer b.eq.mu.Unlock()
+	b.typ, b.freq, b.q, b.gainDB = typ, f, q, gainDB
+	b.design = design
+	for i := range b.filters {
+		b.filters[i].SetCoefficients(design)
+	}
+	return nil
+}
+
+// SetGain changes the gain of the band in dB.
+func (b *Band) SetGain(gainDB float64) error {
+	typ, f, q, _ := b.Params()
+	return b.Set(typ, f, q, gainDB)
+}
+
+// Params returns the parameters of the band.
+func (b *Band) Params() (typ filter.BiquadType, f freq.Frequency, q, gainDB float64) {
+	b.eq.mu.Lock()
+	defer b.eq.mu.Unlock()
+	return b.typ, b.freq, b.q, b.gainDB
+}
+
+// SetEnabled enables or disables the band. A disabled band passes the signal through unchanged.
+// Enabling a band starts its filters from silence.
+func (b *Band) SetEnabled(enabled bool) {
+	b.eq.mu.Lock()
+	defer b.eq.mu.Unlock()
+	if enabled && !b.enabled {
+		for i := range b.filters {
+			b.filters[i].Reset()
+		}
+	}
+	b.enabled = enabled
+}
+
+// Enabled reports whether the band is enabled.
+func (b *Band) Enabled() bool {
+	b.eq.mu.Lock()
+	defer b.eq.mu.Unlock()
+	return b.enabled
+}
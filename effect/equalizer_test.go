@@ -0,0 +1,188 @@
+package effect_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/dsp/filter"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var eqFormat = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+// peakingDB returns the analytic response in dB of a peaking filter at f,
+// which is the analog prototype (s² + s·A/Q + 1) / (s² + s/(A·Q) + 1) under the bilinear transform.
+func peakingDB(f, center freq.Frequency, q, gainDB float64) float64 {
+	fs := eqFormat.SampleRate.Hertz()
+	w := math.Tan(math.Pi*f.Hertz()/fs) / math.Tan(math.Pi*center.Hertz()/fs)
+	a := math.Pow(10, gainDB/40)
+	num := (1-w*w)*(1-w*w) + (w*a/q)*(w*a/q)
+	den := (1-w*w)*(1-w*w) + (w/(a*q))*(w/(a*q))
+	return 10 * math.Log10(num/den)
+}
+
+func TestEqualizerResponse(t *testing.T) {
+	eq := effect.NewEqualizer(eqFormat)
+	if _, err := eq.AddBand(filter.Peaking, freq.KiloHertz, 2, 9); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []freq.Frequency{20 * freq.Hertz, 500 * freq.Hertz, 900 * freq.Hertz, freq.KiloHertz, 1200 * freq.Hertz, 5 * freq.KiloHertz, 20 * freq.KiloHertz} {
+		want := peakingDB(f, freq.KiloHertz, 2, 9)
+		if got := eq.Response(f); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Response(%v) = %v dB; want %v dB", f, got, want)
+		}
+	}
+	if got := eq.Response(freq.KiloHertz); math.Abs(got-9) > 1e-9 {
+		t.Errorf("expected a gain of 9 dB at the center, got %v dB", got)
+	}
+
+	// the processed signal follows the response
+	for _, f := range []freq.Frequency{500 * freq.Hertz, freq.KiloHertz, 3 * freq.KiloHertz} {
+		p := make([]float32, 2*24000)
+		for i := range len(p) / 2 {
+			x := float32(0.1 * math.Sin(2*math.Pi*f.Hertz()*float64(i)/48000))
+			p[2*i], p[2*i+1] = x, x
+		}
+		processChunks(t, eq, p, 2)
+
+		// RMS over whole periods of the second half
+		var sum float64
+		for _, x := range p[len(p)/2:] {
+			sum += float64(x) * float64(x)
+		}
+		rms := math.Sqrt(sum / float64(len(p)/2))
+		if got, want := 20*math.Log10(rms*math.Sqrt2/0.1), eq.Response(f); math.Abs(got-want) > 0.01 {
+			t.Errorf("%v: expected a gain of %.2f dB, got %.2f dB", f, want, got)
+		}
+	}
+}
+
+func TestEqualizerChannels(t *testing.T) {
+	eq := effect.NewEqualizer(eqFormat)
+	if _, err := eq.AddBand(filter.LowShelf, 200*freq.Hertz, 1, -6); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eq.AddBand(filter.Highpass, 50*freq.Hertz, 0.7, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// an impulse on the left and a step on the right, each filtered on its own
+	p := make([]float32, 2*500)
+	left, right := make([]float32, 500), make([]float32, 500)
+	left[0] = 1
+	for i := range right {
+		right[i] = 0.5
+	}
+	for i := range left {
+		p[2*i], p[2*i+1] = left[i], right[i]
+	}
+	processChunks(t, eq, p, 2)
+
+	for _, ch := range [][]float32{left, right} {
+		filter.NewLowShelf(200*freq.Hertz, eqFormat.SampleRate, 1, -6).Process(ch, ch)
+		filter.NewHighpass(50*freq.Hertz, eqFormat.SampleRate, 0.7).Process(ch, ch)
+	}
+	for i := range left {
+		if p[2*i] != left[i] || p[2*i+1] != right[i] {
+			t.Fatalf("frame %d: expected %v and %v, got %v and %v", i, left[i], right[i], p[2*i], p[2*i+1])
+		}
+	}
+}
+
+func TestEqualizerUpdate(t *testing.T) {
+	eq := effect.NewEqualizer(afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1})
+	band, err := eq.AddBand(filter.Peaking, freq.KiloHertz, 1, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := make([]float32, 400)
+	for i := range p {
+		p[i] = float32(math.Sin(float64(i) / 5))
+	}
+	want := append([]float32(nil), p...)
+
+	// changing the gain keeps the state of the filter
+	if err := eq.Process(p[:200]); err != nil {
+		t.Fatal(err)
+	}
+	if err := band.SetGain(-6); err != nil {
+		t.Fatal(err)
+	}
+	if err := eq.Process(p[200:]); err != nil {
+		t.Fatal(err)
+	}
+
+	ref := filter.NewPeaking(freq.KiloHertz, 48*freq.KiloHertz, 1, 6)
+	ref.Process(want[:200], want[:200])
+	ref.SetCoefficients(filter.NewPeaking(freq.KiloHertz, 48*freq.KiloHertz, 1, -6))
+	ref.Process(want[200:], want[200:])
+	for i := range p {
+		if p[i] != want[i] {
+			t.Fatalf("sample %d: expected %v, got %v", i, want[i], p[i])
+		}
+	}
+	if typ, f, q, gain := band.Params(); typ != filter.Peaking || f != freq.KiloHertz || q != 1 || gain != -6 {
+		t.Errorf("unexpected parameters: %v, %v, %v, %v", typ, f, q, gain)
+	}
+
+	// invalid parameters leave the band unchanged
+	if err := band.Set(filter.Peaking, 30*freq.KiloHertz, 1, 0); err == nil {
+		t.Error("expected error for a frequency above the Nyquist frequency")
+	}
+	if _, _, _, gain := band.Params(); gain != -6 {
+		t.Errorf("expected the band to keep its gain, got %v", gain)
+	}
+}
+
+func TestEqualizerEnable(t *testing.T) {
+	eq := effect.NewEqualizer(eqFormat)
+	band, err := eq.AddBand(filter.HighShelf, 4*freq.KiloHertz, 1, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	band.SetEnabled(false)
+	if band.Enabled() {
+		t.Error("expected the band to be disabled")
+	}
+	if got := eq.Response(10 * freq.KiloHertz); got != 0 {
+		t.Errorf("expected a flat response, got %v dB", got)
+	}
+	p := []float32{0.1, -0.2, 0.3, -0.4}
+	if err := eq.Process(p); err != nil {
+		t.Fatal(err)
+	}
+	if p[0] != 0.1 || p[1] != -0.2 || p[2] != 0.3 || p[3] != -0.4 {
+		t.Errorf("expected the signal to pass unchanged, got %v", p)
+	}
+
+	band.SetEnabled(true)
+	if got := eq.Response(20 * freq.KiloHertz); math.Abs(got-12) > 0.1 {
+		t.Errorf("expected about 12 dB at 20 kHz, got %v dB", got)
+	}
+
+	eq.RemoveBand(band)
+	if len(eq.Bands()) != 0 {
+		t.Errorf("expected no bands, got %d", len(eq.Bands()))
+	}
+}
+
+func TestEqualizerInvalid(t *testing.T) {
+	eq := effect.NewEqualizer(eqFormat)
+	if _, err := eq.AddBand(filter.Peaking, 0, 1, 0); err == nil {
+		t.Error("expected error for a frequency of 0")
+	}
+	if _, err := eq.AddBand(filter.Lowpass, freq.KiloHertz, 0, 0); err == nil {
+		t.Error("expected error for a q of 0")
+	}
+	if _, err := eq.AddBand(filter.BiquadType(42), freq.KiloHertz, 1, 0); err == nil {
+		t.Error("expected error for an invalid type")
+	}
+	if err := eq.Process(make([]float32, 3)); err == nil {
+		t.Error("expected error for partial frame")
+	}
+}
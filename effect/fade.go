@@ -0,0 +1,179 @@
+package effect
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+)
+
+// FadeCurve represents the shape of the gain over a fade.
+type FadeCurve int
+
+const (
+	// FadeLinear changes the gain linearly.
+	FadeLinear FadeCurve = iota
+
+	// FadeExponential changes the gain exponentially over a range of 60 dB,
+	// which sounds even to the ear, as perceived loudness is roughly logarithmic.
+	FadeExponential
+
+	// FadeEqualPower changes the gain along a quarter of a sine, which keeps the power
+	// of a fade-out and a fade-in of unrelated signals constant when they overlap.
+	FadeEqualPower
+)
+
+// gain returns the gain at the position x in [0, 1] of a fade-in.
+func (c FadeCurve) gain(x float64) float64 {
+	switch c {
+	case FadeExponential:
+		return (math.Pow(1000, x) - 1) / 999
+	case FadeEqualPower:
+		return math.Sin(x * math.Pi / 2)
+	default:
+		return x
+	}
+}
+
+// Fade fades the audio signal in and out over a given duration.
+//
+// Its gain starts at unity. [Fade.FadeIn] and [Fade.FadeOut] ramp the gain from wherever it is,
+// so reversing a fade halfway through continues smoothly from the current gain.
+// The gain is applied equally to all channels of a frame.
+//
+// A Fade is safe for concurrent use, so fades can be started from another goroutine than the one processing audio.
+type Fade struct {
+	numChannels int
+	sampleRate  float64
+	curve       FadeCurve
+
+	mu       sync.Mutex
+	pos      float64 // position along the curve, 0 is silent and 1 is unity gain
+	from, to float64 // positions at the start and end of the current fade
+	n, k     int     // length of the current fade and frames faded so far, in frames
+	ch       int     // channel of the next sample
+	done     chan struct{}
+}
+
+// NewFade creates a new [Fade] for samples of the given format, fading along the given curve.
+func NewFade(format afmt.Format, curve FadeCurve) *Fade {
+	return &Fade{
+		numChannels: max(format.NumChannels, 1),
+		sampleRate:  format.SampleRate.Hertz(),
+		curve:       curve,
+		pos:         1,
+		done:        make(chan struct{}),
+	}
+}
+
+// FadeIn ramps the gain from its current value to unity over d.
+func (f *Fade) FadeIn(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.start(1, d)
+}
+
+// FadeOut ramps the gain from its current value to silence over d.
+// Once the fade-out completes, the channel returned by [Fade.Done] is closed.
+func (f *Fade) FadeOut(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	select {
+	case <-f.done:
+		f.done = make(chan struct{})
+	default:
+	}
+	f.start(0, d)
+}
+
+// start ramps pos to target over d. f.mu must be held.
+func (f *Fade) start(target float64, d time.Duration) {
+	f.from, f.to = f.pos, target
+	f.n, f.k = int(math.Round(d.Seconds()*f.sampleRate)), 0
+	if f.n <= 0 || f.pos == target {
+		f.pos = target
+		f.n = 0
+		f.finish()
+	}
+}
+
+// finish is called when a fade reaches its target. f.mu must be held.
+func (f *Fade) finish() {
+	if f.pos == 0 {
+		select {
+		case <-f.done:
+		default:
+			close(f.done)
+		}
+	}
+}
+
+// remaining returns the number of frames left in the current fade.
+func (f *Fade) remaining() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.n == 0 {
+		return 0
+	}
+	return f.n - f.k
+}
+
+// SetGain stops any fade and sets the position along the curve, from 0 (silent) to 1 (unity gain).
+// For example, SetGain(0) followed by FadeIn starts a sound from silence.
+func (f *Fade) SetGain(pos float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pos = max(0, min(1, pos))
+	f.n = 0
+}
+
+// Gain returns the current gain.
+func (f *Fade) Gain() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.curve.gain(f.pos)
+}
+
+// Done returns a channel that is closed when the most recent fade-out completes.
+// If a fade-out is interrupted by [Fade.FadeIn] or [Fade.SetGain], its channel stays open.
+func (f *Fade) Done() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.done
+}
+
+// Process fades the interleaved samples p.
+func (f *Fade) Process(p []float32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.curve < FadeLinear || f.curve > FadeEqualPower {
+		return fmt.Errorf("effect: invalid fade curve: %d", f.curve)
+	}
+
+	gain := float32(f.curve.gain(f.pos))
+	for i := range p {
+		p[i] *= gain
+
+		f.ch++
+		if f.ch < f.numChannels {
+			continue
+		}
+		f.ch = 0
+
+		// advance once per frame
+		if f.n > 0 {
+			f.k++
+			f.pos = f.from + (f.to-f.from)*float64(f.k)/float64(f.n)
+			if f.k == f.n {
+				f.pos = f.to
+				f.n = 0
+				f.finish()
+			}
+			gain = float32(f.curve.gain(f.pos))
+		}
+	}
+	return nil
+}
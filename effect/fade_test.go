@@ -0,0 +1,158 @@
+package effect_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// 1 kHz makes every millisecond a frame.
+var fadeFormat = afmt.Format{SampleRate: freq.KiloHertz, NumChannels: 2}
+
+// processFrames runs n stereo frames of ones through f and returns the gain of every frame.
+func processFrames(t *testing.T, f *effect.Fade, n int) []float64 {
+	t.Helper()
+
+	s := ones(2 * n)
+	if err := f.Process(s); err != nil {
+		t.Fatal(err)
+	}
+	gains := make([]float64, n)
+	for i := range gains {
+		if s[2*i] != s[2*i+1] {
+			t.Fatalf("frame %d: channels differ: %v and %v", i, s[2*i], s[2*i+1])
+		}
+		gains[i] = float64(s[2*i])
+	}
+	return gains
+}
+
+func TestFadeCurves(t *testing.T) {
+	tests := []struct {
+		name  string
+		curve effect.FadeCurve
+		gain  func(x float64) float64
+	}{
+		{"Linear", effect.FadeLinear, func(x float64) float64 { return x }},
+		{"Exponential", effect.FadeExponential, func(x float64) float64 { return (math.Pow(1000, x) - 1) / 999 }},
+		{"EqualPower", effect.FadeEqualPower, func(x float64) float64 { return math.Sin(x * math.Pi / 2) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := effect.NewFade(fadeFormat, tt.curve)
+			f.SetGain(0)
+			f.FadeIn(100 * time.Millisecond)
+
+			// split into uneven buffers to check that the envelope carries over
+			gains := append(processFrames(t, f, 37), processFrames(t, f, 83)...)
+			for i, got := range gains {
+				want := tt.gain(min(float64(i)/100, 1))
+				if math.Abs(got-want) > 1e-5 {
+					t.Fatalf("fade-in: frame %d: expected gain %v, got %v", i, want, got)
+				}
+			}
+
+			f.FadeOut(50 * time.Millisecond)
+			for i, got := range processFrames(t, f, 60) {
+				want := tt.gain(max(1-float64(i)/50, 0))
+				if math.Abs(got-want) > 1e-5 {
+					t.Fatalf("fade-out: frame %d: expected gain %v, got %v", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFadeReverse(t *testing.T) {
+	for _, curve := range []effect.FadeCurve{effect.FadeLinear, effect.FadeExponential, effect.FadeEqualPower} {
+		f := effect.NewFade(fadeFormat, curve)
+		f.FadeOut(100 * time.Millisecond)
+		out := processFrames(t, f, 40)
+
+		// the fade-in starts from where the fade-out stopped
+		current := f.Gain()
+		f.FadeIn(100 * time.Millisecond)
+		in := processFrames(t, f, 120)
+
+		if math.Abs(in[0]-current) > 1e-6 {
+			t.Errorf("curve %d: expected the fade-in to start at %v, got %v", curve, current, in[0])
+		}
+		if last := out[len(out)-1]; in[0] >= last || last-in[0] > 0.05 {
+			t.Errorf("curve %d: expected the gain to continue from %v, got %v", curve, last, in[0])
+		}
+		for i := 1; i < len(in); i++ {
+			if in[i] < in[i-1] {
+				t.Fatalf("curve %d: expected the gain to rise, got %v after %v", curve, in[i], in[i-1])
+			}
+		}
+		if in[100] != 1 || in[len(in)-1] != 1 {
+			t.Errorf("curve %d: expected unity gain after the fade-in, got %v", curve, in[len(in)-1])
+		}
+
+		select {
+		case <-f.Done():
+			t.Errorf("curve %d: Done closed by an interrupted fade-out", curve)
+		default:
+		}
+	}
+}
+
+func TestFadeDone(t *testing.T) {
+	f := effect.NewFade(fadeFormat, effect.FadeEqualPower)
+	f.FadeOut(10 * time.Millisecond)
+	done := f.Done()
+
+	processFrames(t, f, 5)
+	select {
+	case <-done:
+		t.Fatal("Done closed before the fade-out completed")
+	default:
+	}
+
+	processFrames(t, f, 5)
+	select {
+	case <-done:
+	default:
+		t.Fatal("Done not closed after the fade-out completed")
+	}
+	if gains := processFrames(t, f, 10); gains[9] != 0 {
+		t.Errorf("expected silence after the fade-out, got %v", gains[9])
+	}
+
+	// a new fade-out gets a new channel
+	f.FadeIn(0)
+	f.FadeOut(time.Millisecond)
+	select {
+	case <-f.Done():
+		t.Fatal("Done of a new fade-out already closed")
+	default:
+	}
+}
+
+func TestFadeChain(t *testing.T) {
+	f := effect.NewFade(fadeFormat, effect.FadeLinear)
+	f.SetGain(0)
+	f.FadeIn(4 * time.Millisecond)
+
+	double := effect.EffectFunc(func(p []float32) error {
+		for i := range p {
+			p[i] *= 2
+		}
+		return nil
+	})
+
+	s := ones(12)
+	if err := (effect.Chain{f, double}).Process(s); err != nil {
+		t.Fatal(err)
+	}
+	want := []float32{0, 0, 0.5, 0.5, 1, 1, 1.5, 1.5, 2, 2, 2, 2}
+	for i := range s {
+		if math.Abs(float64(s[i]-want[i])) > 1e-6 {
+			t.Fatalf("expected %v, got %v", want, s)
+		}
+	}
+}
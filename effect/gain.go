@@ -1,17 +1,33 @@
 package effect
 
+import (
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+)
+
 // Gain amplifies the audio signal. The output gets multiplies by (1 + Gain).
 //
 // Note that gain is not equivalent to volume. Human perception of volume is
 // roughly exponential, while gain only amplifies linearly.
 // To adjust volume, use [Volume] instead.
 //
+// Changes of Gain take effect instantly unless Smoothing is set, in which case they ramp
+// linearly to the new value, which avoids clicks during playback.
+//
 // The zero value for Gain is ready to use.
 type Gain struct {
 	// Gain is the linear gain factor.
 	// A value of 0 means no change, negative values attenuate the signal,
 	// and positive values amplify it.
 	Gain float64
+
+	// Smoothing is the number of samples over which changes of Gain ramp to the new value.
+	// For interleaved audio, that is the number of frames times the number of channels; see [Gain.SetSmoothingTime].
+	// A value of 0 applies changes instantly.
+	Smoothing int
+
+	smoother smoother
 }
 
 // NewGain creates a new [Gain] effect using gain as its initial gain.
@@ -22,9 +38,23 @@ func NewGain(gain float64) *Gain {
 	return &Gain{Gain: gain}
 }
 
+// SetSmoothingTime sets Smoothing to the number of samples of the given format in d.
+func (g *Gain) SetSmoothingTime(d time.Duration, format afmt.Format) {
+	g.Smoothing = smoothingSamples(d, format)
+}
+
 func (g *Gain) Process(p []float32) error {
+	target := float32(1 + g.Gain)
+	if g.Smoothing <= 0 || g.smoother.steady(target) {
+		g.smoother.next(target, 0)
+		for i := range p {
+			p[i] *= target
+		}
+		return nil
+	}
+
 	for i := range p {
-		p[i] *= float32(1 + g.Gain)
+		p[i] *= g.smoother.next(target, g.Smoothing)
 	}
 	return nil
 }
@@ -0,0 +1,68 @@
+package effect_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+func TestGainInstant(t *testing.T) {
+	g := effect.NewGain(0)
+	s := ones(4)
+	if err := g.Process(s); err != nil {
+		t.Fatal(err)
+	}
+
+	g.Gain = -0.5
+	s = ones(4)
+	if err := g.Process(s); err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range s {
+		if x != 0.5 {
+			t.Errorf("sample %d: expected the new gain instantly, got %v", i, x)
+		}
+	}
+}
+
+func TestGainSmoothing(t *testing.T) {
+	g := effect.NewGain(0)
+	g.Smoothing = 10
+
+	// the first buffer starts at the gain without a ramp
+	s := ones(5)
+	if err := g.Process(s); err != nil {
+		t.Fatal(err)
+	}
+	if s[0] != 1 || s[4] != 1 {
+		t.Errorf("expected unity gain from the start, got %v", s)
+	}
+
+	// the ramp carries over buffers
+	g.Gain = -1
+	s = ones(16)
+	if err := g.Process(s[:7]); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Process(s[7:]); err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range s {
+		want := max(1-float64(i+1)/10, 0)
+		if math.Abs(float64(x)-want) > 1e-6 {
+			t.Errorf("sample %d: expected %v, got %v", i, want, x)
+		}
+	}
+}
+
+func TestGainSetSmoothingTime(t *testing.T) {
+	var g effect.Gain
+	g.SetSmoothingTime(10*time.Millisecond, afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2})
+	if g.Smoothing != 960 {
+		t.Errorf("expected 960 samples, got %d", g.Smoothing)
+	}
+}
@@ -0,0 +1,244 @@
+package effect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+)
+
+// Default settings of a [Limiter].
+const (
+	DefaultLimiterCeiling   = -0.3 // dBFS
+	DefaultLimiterLookahead = 5 * time.Millisecond
+	DefaultLimiterRelease   = 50 * time.Millisecond
+)
+
+// Limiter keeps the peaks of the audio signal at or below a ceiling.
+//
+// It delays the signal by its lookahead, so that it can see a peak coming and lower the gain smoothly
+// before the peak reaches the output. The gain falls over the lookahead, which is its attack time,
+// and rises again with the release time constant. All channels of a frame share a single gain.
+// A signal that stays below the ceiling passes through unchanged apart from the delay.
+//
+// Because of the delay, the first [Limiter.Latency] frames processed come out as silence.
+// Use [Limiter.Reader] to also flush the last frames at the end of a stream.
+type Limiter struct {
+	// Ceiling is the highest level in dBFS the output may reach.
+	Ceiling float64
+
+	// Release is the time constant of the gain recovering after a peak.
+	Release time.Duration
+
+	numChannels int
+	sampleRate  float64
+	lookahead   int // frames
+	err         error
+
+	delay    []float32 // the last lookahead-1 input frames
+	delayPos int
+
+	// sliding minimum of the required gains of the last lookahead frames, as a monotonic ring buffer
+	minIndex []int
+	minValue []float64
+	minHead  int
+	minLen   int
+	frame    int
+
+	held float64 // required gain held by the release
+
+	// moving average of the held gain over the last lookahead frames
+	avg    []float64
+	avgPos int
+	avgSum float64
+}
+
+// NewLimiter creates a new [Limiter] for samples of the given format using the given lookahead
+// and the default ceiling and release.
+func NewLimiter(format afmt.Format, lookahead time.Duration) *Limiter {
+	l := &Limiter{
+		Ceiling:     DefaultLimiterCeiling,
+		Release:     DefaultLimiterRelease,
+		numChannels: format.NumChannels,
+		sampleRate:  format.SampleRate.Hertz(),
+	}
+	switch {
+	case format.NumChannels <= 0:
+		l.err = fmt.Errorf("effect: invalid number of channels: %d", format.NumChannels)
+	case format.SampleRate <= 0:
+		l.err = fmt.Errorf("effect: invalid sample rate: %v", format.SampleRate)
+	case lookahead < 0:
+		l.err = fmt.Errorf("effect: invalid lookahead: %v", lookahead)
+	}
+	if l.err != nil {
+		return l
+	}
+
+	l.lookahead = max(afmt.DurationToNumFrames(format.SampleRate, lookahead), 1)
+	l.delay = make([]float32, (l.lookahead-1)*l.numChannels)
+	l.minIndex = make([]int, l.lookahead)
+	l.minValue = make([]float64, l.lookahead)
+	l.avg = make([]float64, l.lookahead)
+	l.Reset()
+	return l
+}
+
+// Latency returns the delay of the output relative to the input in frames, which is one less than the lookahead.
+func (l *Limiter) Latency() int {
+	return max(l.lookahead-1, 0)
+}
+
+// Reset clears the delay line and the gain, as if the limiter was just created.
+func (l *Limiter) Reset() {
+	clear(l.delay)
+	l.delayPos = 0
+	l.minHead, l.minLen, l.frame = 0, 0, 0
+	l.held = 1
+	for i := range l.avg {
+		l.avg[i] = 1
+	}
+	l.avgPos = 0
+	l.avgSum = float64(len(l.avg))
+}
+
+// gain takes the gain the frame that enters the limiter needs to stay at or below ceiling
+// and returns the gain of the frame that leaves it.
+//
+// The gain of a frame is the average of the held gains of the lookahead frames from it onward,
+// each of which is at most the required gain of the frame, so the output never exceeds the ceiling.
+func (l *Limiter) gain(required, release float64) float64 {
+	n := len(l.minIndex)
+
+	// sliding minimum: drop the expired value from the front and larger values from the back
+	if l.minLen > 0 && l.minIndex[l.minHead] <= l.frame-n {
+		l.minHead = (l.minHead + 1) % n
+		l.minLen--
+	}
+	for l.minLen > 0 && l.minValue[(l.minHead+l.minLen-1)%n] >= required {
+		l.minLen--
+	}
+	tail := (l.minHead + l.minLen) % n
+	l.minIndex[tail], l.minValue[tail] = l.frame, required
+	l.minLen++
+	l.frame++
+	w := l.minValue[l.minHead]
+
+	if w < l.held {
+		l.held = w
+	} else {
+		l.held = w + release*(l.held-w)
+	}
+
+	l.avgSum += l.held - l.avg[l.avgPos]
+	l.avg[l.avgPos] = l.held
+	l.avgPos++
+	if l.avgPos == n {
+		l.avgPos = 0
+
+		// recompute the sum once per window so that rounding errors do not accumulate
+		l.avgSum = 0
+		for _, v := range l.avg {
+			l.avgSum += v
+		}
+	}
+	return min(l.avgSum/float64(n), 1)
+}
+
+// Process limits the interleaved samples p in place, delaying them by [Limiter.Latency].
+func (l *Limiter) Process(p []float32) error {
+	if l.err != nil {
+		return l.err
+	}
+	if len(p)%l.numChannels != 0 {
+		return errors.New("effect: limiter needs whole frames")
+	}
+
+	ceiling := dsp.DBToAmplitude(l.Ceiling)
+	release := smoothingCoef(l.Release, l.sampleRate)
+
+	for i := 0; i < len(p); i += l.numChannels {
+		frame := p[i : i+l.numChannels]
+
+		var peak float64
+		for _, x := range frame {
+			peak = max(peak, math.Abs(float64(x)))
+		}
+		required := 1.0
+		if peak > ceiling {
+			required = ceiling / peak
+		}
+		g := l.gain(required, release)
+
+		if len(l.delay) > 0 {
+			delayed := l.delay[l.delayPos : l.delayPos+l.numChannels]
+			for ch, x := range frame {
+				frame[ch], delayed[ch] = delayed[ch], x
+			}
+			l.delayPos += l.numChannels
+			if l.delayPos == len(l.delay) {
+				l.delayPos = 0
+			}
+		}
+
+		if g == 1 {
+			continue
+		}
+		for ch, x := range frame {
+			// the gain keeps the output at the ceiling already, clamping only catches rounding errors
+			frame[ch] = float32(max(-ceiling, min(ceiling, float64(x)*g)))
+		}
+	}
+	return nil
+}
+
+// Reader returns an aio.SampleReader that reads from r and limits the samples with l.
+// The output is delayed by [Limiter.Latency] and followed by as many frames flushed from the delay line,
+// so that no samples are lost.
+func (l *Limiter) Reader(r aio.SampleReader) aio.SampleReader {
+	return &limiterReader{r: r, l: l, tail: l.Latency() * l.numChannels}
+}
+
+type limiterReader struct {
+	r    aio.SampleReader
+	l    *Limiter
+	tail int // samples left to flush after the end of r
+	eof  bool
+}
+
+func (r *limiterReader) ReadSamples(p []float32) (int, error) {
+	if r.l.err != nil {
+		return 0, r.l.err
+	}
+	p = p[:len(p)/r.l.numChannels*r.l.numChannels]
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if !r.eof {
+		n, err := aio.ReadFull(r.r, p)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			r.eof = true
+			err = nil
+			n = n / r.l.numChannels * r.l.numChannels
+		} else if err != nil {
+			return 0, err
+		}
+		if n > 0 {
+			return n, r.l.Process(p[:n])
+		}
+	}
+
+	// flush the delay line by feeding silence
+	if r.tail == 0 {
+		return 0, io.EOF
+	}
+	n := min(len(p), r.tail)
+	clear(p[:n])
+	r.tail -= n
+	return n, r.l.Process(p[:n])
+}
@@ -0,0 +1,126 @@
+package effect_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var limiterFormat = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+func TestLimiterBurst(t *testing.T) {
+	// 100 ms of a quiet sine, a 100 ms burst at +6 dBFS and 900 ms of the quiet sine again
+	in := make([]float32, 2*48000)
+	for i := range len(in) / 2 {
+		a := 0.1
+		if i >= 4800 && i < 9600 {
+			a = 2
+		}
+		x := float32(a * math.Sin(2*math.Pi*1000*float64(i)/48000))
+		in[2*i], in[2*i+1] = x, -x
+	}
+
+	l := effect.NewLimiter(limiterFormat, effect.DefaultLimiterLookahead)
+	if got := l.Latency(); got != 239 {
+		t.Fatalf("expected a latency of 239 frames, got %d", got)
+	}
+	out, err := aio.ReadAll(l.Reader(audio.NewBuffer(in)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(in)+2*l.Latency() {
+		t.Fatalf("expected %d samples, got %d", len(in)+2*l.Latency(), len(out))
+	}
+
+	ceiling := float32(dsp.DBToAmplitude(effect.DefaultLimiterCeiling))
+	var peak float32
+	for i, x := range out {
+		if x > ceiling || x < -ceiling {
+			t.Fatalf("sample %d exceeds the ceiling: %v", i, x)
+		}
+		peak = max(peak, x)
+	}
+	if peak < 0.95*ceiling {
+		t.Errorf("expected the burst to reach the ceiling, got a peak of %v", peak)
+	}
+
+	// the quiet part comes back after the release
+	latency := 2 * l.Latency()
+	for i := 2 * 40000; i < len(in); i++ {
+		if got, want := out[i+latency], in[i]; math.Abs(float64(got-want)) > 1e-4 {
+			t.Fatalf("sample %d: expected %v after the release, got %v", i, want, got)
+		}
+	}
+}
+
+func TestLimiterRandomPeaks(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	in := make([]float32, 2*48000)
+	for i := range in {
+		in[i] = float32(rng.NormFloat64() * 0.3)
+		if rng.IntN(1000) == 0 {
+			in[i] *= 20
+		}
+	}
+
+	l := effect.NewLimiter(limiterFormat, time.Millisecond)
+	l.Ceiling, l.Release = -1, 10*time.Millisecond
+	ceiling := float32(dsp.DBToAmplitude(-1))
+
+	// uneven buffers
+	for p := in; len(p) > 0; {
+		n := min(len(p), 2*(1+rng.IntN(500)))
+		if err := l.Process(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	for i, x := range in {
+		if x > ceiling || x < -ceiling {
+			t.Fatalf("sample %d exceeds the ceiling: %v", i, x)
+		}
+	}
+}
+
+func TestLimiterTransparent(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	in := make([]float32, 2*10000)
+	for i := range in {
+		in[i] = float32(rng.Float64() - 0.5)
+	}
+	out := append([]float32(nil), in...)
+
+	l := effect.NewLimiter(limiterFormat, effect.DefaultLimiterLookahead)
+	if err := l.Process(out); err != nil {
+		t.Fatal(err)
+	}
+
+	latency := 2 * l.Latency()
+	for i, x := range out[:latency] {
+		if x != 0 {
+			t.Fatalf("sample %d: expected silence during the latency, got %v", i, x)
+		}
+	}
+	for i, x := range out[latency:] {
+		if x != in[i] {
+			t.Fatalf("sample %d: expected %v unchanged, got %v", i, in[i], x)
+		}
+	}
+}
+
+func TestLimiterInvalid(t *testing.T) {
+	if err := effect.NewLimiter(limiterFormat, -time.Millisecond).Process(make([]float32, 2)); err == nil {
+		t.Error("expected error for negative lookahead")
+	}
+	if err := effect.NewLimiter(limiterFormat, time.Millisecond).Process(make([]float32, 3)); err == nil {
+		t.Error("expected error for partial frame")
+	}
+}
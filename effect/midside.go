@@ -0,0 +1,129 @@
+package effect
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+)
+
+var errNotStereo = errors.New("effect: mid-side processing needs stereo frames")
+
+// MidSideEncode converts interleaved left/right stereo samples to mid/side,
+// where mid = (left+right)/2 is the center of the stereo image and side = (left-right)/2 is what differs between the channels.
+// Use it with [MidSideDecode] and [OnChannel] to process mid and side differently:
+//
+//	effect.Chain{effect.MidSideEncode, effect.OnChannel(fx, 1, 2), effect.MidSideDecode}
+var MidSideEncode Effect = EffectFunc(func(p []float32) error {
+	if len(p)%2 != 0 {
+		return errNotStereo
+	}
+	for i := 0; i < len(p); i += 2 {
+		l, r := float64(p[i]), float64(p[i+1])
+		p[i], p[i+1] = float32((l+r)/2), float32((l-r)/2)
+	}
+	return nil
+})
+
+// MidSideDecode converts interleaved mid/side samples from [MidSideEncode] back to left/right stereo.
+var MidSideDecode Effect = EffectFunc(func(p []float32) error {
+	if len(p)%2 != 0 {
+		return errNotStereo
+	}
+	for i := 0; i < len(p); i += 2 {
+		m, s := float64(p[i]), float64(p[i+1])
+		p[i], p[i+1] = float32(m+s), float32(m-s)
+	}
+	return nil
+})
+
+type onChannel struct {
+	fx          Effect
+	ch          int
+	numChannels int
+	buf         []float32
+}
+
+// OnChannel returns an [Effect] that applies fx to channel ch of interleaved samples with numChannels channels only,
+// leaving the other channels unchanged.
+func OnChannel(fx Effect, ch, numChannels int) Effect {
+	return &onChannel{fx: fx, ch: ch, numChannels: numChannels}
+}
+
+func (o *onChannel) Process(p []float32) error {
+	if o.numChannels <= 0 || o.ch < 0 || o.ch >= o.numChannels {
+		return fmt.Errorf("effect: invalid channel %d of %d", o.ch, o.numChannels)
+	}
+	if len(p)%o.numChannels != 0 {
+		return errors.New("effect: channel effect needs whole frames")
+	}
+
+	numFrames := len(p) / o.numChannels
+	if cap(o.buf) < numFrames {
+		o.buf = make([]float32, numFrames)
+	}
+	buf := o.buf[:numFrames]
+	for i := range buf {
+		buf[i] = p[i*o.numChannels+o.ch]
+	}
+	if err := o.fx.Process(buf); err != nil {
+		return err
+	}
+	for i, x := range buf {
+		p[i*o.numChannels+o.ch] = x
+	}
+	return nil
+}
+
+// StereoWidth narrows or widens the stereo image by scaling the side signal (see [MidSideEncode]).
+// Changes of the width are smoothed over one buffer.
+//
+// StereoWidth processes interleaved stereo samples only. Note that the zero value for StereoWidth collapses the signal to mono;
+// use [NewStereoWidth] to start from another width.
+type StereoWidth struct {
+	// Width is the scale of the side signal: 0 is mono, 1 leaves the signal unchanged and values above 1 widen it.
+	// Negative values are treated as 0.
+	Width float64
+
+	ramp ramp
+}
+
+// NewStereoWidth creates a new [StereoWidth] effect using width as its initial width.
+func NewStereoWidth(width float64) *StereoWidth {
+	return &StereoWidth{Width: width}
+}
+
+// Process changes the width of the interleaved stereo samples p.
+func (w *StereoWidth) Process(p []float32) error {
+	if len(p)%2 != 0 {
+		return errNotStereo
+	}
+
+	width, dWidth := w.ramp.step(float32(max(w.Width, 0)), len(p)/2)
+	if width == 1 && dWidth == 0 {
+		return nil
+	}
+	for i := 0; i < len(p); i += 2 {
+		l, r := float64(p[i]), float64(p[i+1])
+		m, s := (l+r)/2, (l-r)/2*float64(width)
+		p[i], p[i+1] = float32(m+s), float32(m-s)
+		width += dWidth
+	}
+	return nil
+}
+
+type errReader struct{ err error }
+
+func (r errReader) ReadSamples([]float32) (int, error) {
+	return 0, r.err
+}
+
+// NewStereoWidthReader wraps an aio.SampleReader of the given format and changes the width of its output with w.
+// Reading fails if the format is not stereo.
+func NewStereoWidthReader(r aio.SampleReader, format afmt.Format, w *StereoWidth) aio.SampleReader {
+	if format.NumChannels != 2 {
+		return errReader{fmt.Errorf("effect: stereo width needs 2 channels, got %d", format.NumChannels)}
+	}
+	return newFrameReader(r, w, 2)
+}
@@ -0,0 +1,133 @@
+package effect_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// randomStereo returns numFrames frames of random stereo samples, quantized to 16 bits like most audio.
+func randomStereo(seed uint64, numFrames int) []float32 {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	p := make([]float32, 2*numFrames)
+	for i := range p {
+		p[i] = float32(rng.IntN(1<<16)-1<<15) / (1 << 15)
+	}
+	return p
+}
+
+func sideEnergy(p []float32) float64 {
+	var sum float64
+	for i := 0; i < len(p); i += 2 {
+		s := (float64(p[i]) - float64(p[i+1])) / 2
+		sum += s * s
+	}
+	return sum
+}
+
+func TestMidSideRoundtrip(t *testing.T) {
+	in := randomStereo(1, 1000)
+	p := append([]float32(nil), in...)
+	if err := (effect.Chain{effect.MidSideEncode, effect.MidSideDecode}).Process(p); err != nil {
+		t.Fatal(err)
+	}
+	for i := range p {
+		if p[i] != in[i] {
+			t.Fatalf("sample %d: expected %v, got %v", i, in[i], p[i])
+		}
+	}
+
+	p = []float32{0.75, 0.25}
+	if err := effect.MidSideEncode.Process(p); err != nil {
+		t.Fatal(err)
+	}
+	if p[0] != 0.5 || p[1] != 0.25 {
+		t.Errorf("expected mid 0.5 and side 0.25, got %v", p)
+	}
+}
+
+func TestMidSideOnChannel(t *testing.T) {
+	// silencing the side channel collapses the signal to mono
+	p := randomStereo(2, 1000)
+	fx := effect.Chain{effect.MidSideEncode, effect.OnChannel(&effect.Mute{Mute: true}, 1, 2), effect.MidSideDecode}
+	if err := fx.Process(p); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(p); i += 2 {
+		if p[i] != p[i+1] {
+			t.Fatalf("frame %d: expected identical channels, got %v and %v", i/2, p[i], p[i+1])
+		}
+	}
+
+	if err := effect.OnChannel(&effect.Mute{}, 2, 2).Process(p); err == nil {
+		t.Error("expected error for invalid channel")
+	}
+}
+
+func TestStereoWidth(t *testing.T) {
+	in := randomStereo(3, 1000)
+
+	t.Run("Mono", func(t *testing.T) {
+		p := append([]float32(nil), in...)
+		if err := effect.NewStereoWidth(0).Process(p); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < len(p); i += 2 {
+			if p[i] != p[i+1] {
+				t.Fatalf("frame %d: expected identical channels, got %v and %v", i/2, p[i], p[i+1])
+			}
+		}
+	})
+
+	t.Run("Unchanged", func(t *testing.T) {
+		p := append([]float32(nil), in...)
+		if err := effect.NewStereoWidth(1).Process(p); err != nil {
+			t.Fatal(err)
+		}
+		for i := range p {
+			if p[i] != in[i] {
+				t.Fatalf("sample %d: expected %v, got %v", i, in[i], p[i])
+			}
+		}
+	})
+
+	t.Run("Wide", func(t *testing.T) {
+		p := append([]float32(nil), in...)
+		if err := effect.NewStereoWidth(2).Process(p); err != nil {
+			t.Fatal(err)
+		}
+		// twice the side signal is four times its energy
+		if got, want := sideEnergy(p), 4*sideEnergy(in); math.Abs(got-want) > 1e-6*want {
+			t.Errorf("expected a side energy of %v, got %v", want, got)
+		}
+		for i := 0; i < len(p); i += 2 {
+			if got, want := p[i]+p[i+1], in[i]+in[i+1]; math.Abs(float64(got-want)) > 1e-6 {
+				t.Fatalf("frame %d: expected the mid signal to stay, got %v instead of %v", i/2, got, want)
+			}
+		}
+	})
+}
+
+func TestStereoWidthReader(t *testing.T) {
+	in := randomStereo(4, 100)
+	out, err := aio.ReadAll(effect.NewStereoWidthReader(audio.NewBuffer(in), afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}, effect.NewStereoWidth(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(in) || out[0] != out[1] {
+		t.Errorf("expected %d mono samples, got %d", len(in), len(out))
+	}
+
+	if _, err := effect.NewStereoWidthReader(audio.NewBuffer(in), afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}, effect.NewStereoWidth(0)).ReadSamples(make([]float32, 2)); err == nil {
+		t.Error("expected error for mono input")
+	}
+	if err := effect.NewStereoWidth(1).Process(make([]float32, 3)); err == nil {
+		t.Error("expected error for partial frame")
+	}
+}
@@ -1,11 +1,27 @@
 package effect
 
+import (
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+)
+
 // Mute mutes the audio signal. While muted, it outputs silence.
 //
+// Muting and unmuting switch instantly unless Smoothing is set, in which case they fade out and in
+// quickly, which avoids clicks during playback.
+//
 // The zero value for Mute is ready to use.
 type Mute struct {
 	// Mute, if true, silences the audio signal.
 	Mute bool
+
+	// Smoothing is the number of samples of the fade when muting or unmuting.
+	// For interleaved audio, that is the number of frames times the number of channels; see [Mute.SetSmoothingTime].
+	// A value of 0 switches instantly.
+	Smoothing int
+
+	smoother smoother
 }
 
 // NewMute creates a new [Mute] effect with mute being its initial state.
@@ -16,9 +32,26 @@ func NewMute(mute bool) *Mute {
 	return &Mute{Mute: mute}
 }
 
+// SetSmoothingTime sets Smoothing to the number of samples of the given format in d.
+func (m *Mute) SetSmoothingTime(d time.Duration, format afmt.Format) {
+	m.Smoothing = smoothingSamples(d, format)
+}
+
 func (m *Mute) Process(p []float32) error {
+	var target float32 = 1
 	if m.Mute {
-		clear(p)
+		target = 0
+	}
+	if m.Smoothing <= 0 || m.smoother.steady(target) {
+		m.smoother.next(target, 0)
+		if m.Mute {
+			clear(p)
+		}
+		return nil
+	}
+
+	for i := range p {
+		p[i] *= m.smoother.next(target, m.Smoothing)
 	}
 	return nil
 }
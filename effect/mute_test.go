@@ -0,0 +1,68 @@
+package effect_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+func TestMuteInstant(t *testing.T) {
+	var m effect.Mute
+	m.Mute = true
+	s := ones(4)
+	if err := m.Process(s); err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range s {
+		if x != 0 {
+			t.Errorf("sample %d: expected silence, got %v", i, x)
+		}
+	}
+}
+
+func TestMuteSmoothing(t *testing.T) {
+	format := afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}
+	const f = 1000.0
+
+	var m effect.Mute
+	m.SetSmoothingTime(5*time.Millisecond, format)
+
+	// toggle the mute in the middle of a full-scale sine, with buffers of 100 samples
+	s := make([]float32, 4800)
+	for i := range s {
+		s[i] = float32(math.Sin(2 * math.Pi * f * float64(i) / 48000))
+	}
+	for i := 0; i < len(s); i += 100 {
+		switch i {
+		case 1000, 3000:
+			m.Mute = true
+		case 2000:
+			m.Mute = false
+		}
+		if err := m.Process(s[i : i+100]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the steps stay about as small as those of the sine itself
+	maxStep := 2 * math.Pi * f / 48000 * 1.1
+	for i := 1; i < len(s); i++ {
+		if step := math.Abs(float64(s[i] - s[i-1])); step > maxStep {
+			t.Fatalf("sample %d: expected a smooth output, got a step of %v", i, step)
+		}
+	}
+
+	// the fades end in silence and full level
+	for _, i := range []int{1500, 3500, 4799} {
+		if s[i] != 0 {
+			t.Errorf("sample %d: expected silence, got %v", i, s[i])
+		}
+	}
+	if s[2500] == 0 {
+		t.Error("expected sound after unmuting")
+	}
+}
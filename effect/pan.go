@@ -0,0 +1,95 @@
+package effect
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/MatusOllah/resona/aio"
+)
+
+// Pan positions the audio signal in the stereo field using the constant-power law,
+// so that the total power stays the same at every position.
+//
+// Pan processes interleaved stereo samples; use [PanReader] to place a mono signal into the stereo field.
+// In the center, both channels are 3 dB down. Changes of the position are smoothed over one buffer.
+//
+// The zero value for Pan is ready to use and centered.
+type Pan struct {
+	// Pan is the position in the range [-1, 1]: -1 is hard left, 0 is center and 1 is hard right.
+	// Values outside of the range are clamped.
+	Pan float64
+
+	left, right ramp
+}
+
+// NewPan creates a new [Pan] effect using pan as its initial position.
+//
+// In most cases, new([Pan]) (or just declaring a [Pan] variable) is sufficient
+// to create a new [Pan].
+func NewPan(pan float64) *Pan {
+	return &Pan{Pan: pan}
+}
+
+// Gains returns the gains of the left and right channels at the current position.
+func (p *Pan) Gains() (left, right float64) {
+	theta := (max(-1, min(1, p.Pan)) + 1) * math.Pi / 4
+	right, left = math.Sincos(theta)
+	return left, right
+}
+
+// ramps returns the gains of the first frame and their increments per frame for n frames.
+func (p *Pan) ramps(n int) (left, dLeft, right, dRight float32) {
+	l, r := p.Gains()
+	left, dLeft = p.left.step(float32(l), n)
+	right, dRight = p.right.step(float32(r), n)
+	return
+}
+
+// Process pans the interleaved stereo samples s.
+func (p *Pan) Process(s []float32) error {
+	if len(s)%2 != 0 {
+		return errors.New("effect: pan needs stereo frames")
+	}
+
+	left, dLeft, right, dRight := p.ramps(len(s) / 2)
+	for i := 0; i < len(s); i += 2 {
+		s[i] *= left
+		s[i+1] *= right
+		left += dLeft
+		right += dRight
+	}
+	return nil
+}
+
+type panReader struct {
+	r   aio.SampleReader
+	pan *Pan
+	buf []float32
+}
+
+// PanReader wraps an aio.SampleReader of mono samples and places them into the stereo field with pan.
+// It reads one mono sample for every interleaved stereo frame it returns, so it doubles the number of samples.
+func PanReader(r aio.SampleReader, pan *Pan) aio.SampleReader {
+	return &panReader{r: r, pan: pan}
+}
+
+func (pr *panReader) ReadSamples(p []float32) (int, error) {
+	numFrames := len(p) / 2
+	if numFrames == 0 {
+		return 0, io.ErrShortBuffer
+	}
+	if cap(pr.buf) < numFrames {
+		pr.buf = make([]float32, numFrames)
+	}
+
+	n, err := pr.r.ReadSamples(pr.buf[:numFrames])
+	left, dLeft, right, dRight := pr.pan.ramps(n)
+	for i, s := range pr.buf[:n] {
+		p[2*i] = s * left
+		p[2*i+1] = s * right
+		left += dLeft
+		right += dRight
+	}
+	return 2 * n, err
+}
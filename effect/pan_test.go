@@ -0,0 +1,108 @@
+package effect_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/effect"
+)
+
+func ones(n int) []float32 {
+	s := make([]float32, n)
+	for i := range s {
+		s[i] = 1
+	}
+	return s
+}
+
+func TestPan(t *testing.T) {
+	tests := []struct {
+		pan         float64
+		left, right float64
+	}{
+		{-1, 1, 0},
+		{-0.5, math.Cos(math.Pi / 8), math.Sin(math.Pi / 8)},
+		{0, math.Sqrt2 / 2, math.Sqrt2 / 2},
+		{0.5, math.Cos(3 * math.Pi / 8), math.Sin(3 * math.Pi / 8)},
+		{1, 0, 1},
+		{2, 0, 1}, // clamped
+	}
+	for _, tt := range tests {
+		s := ones(8)
+		if err := effect.NewPan(tt.pan).Process(s); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < len(s); i += 2 {
+			l, r := float64(s[i]), float64(s[i+1])
+			if math.Abs(l-tt.left) > 1e-6 || math.Abs(r-tt.right) > 1e-6 {
+				t.Fatalf("pan %v: expected L %.4f, R %.4f, got L %.4f, R %.4f", tt.pan, tt.left, tt.right, l, r)
+			}
+			if power := l*l + r*r; math.Abs(power-1) > 1e-6 {
+				t.Errorf("pan %v: expected constant power, got %v", tt.pan, power)
+			}
+		}
+	}
+
+	// the ratio of the channels in dB
+	l, r := effect.NewPan(0.5).Gains()
+	if db := 20 * math.Log10(r/l); math.Abs(db-7.66) > 0.01 {
+		t.Errorf("expected the right channel 7.66 dB louder at 0.5, got %.2f dB", db)
+	}
+}
+
+func TestPanSmoothing(t *testing.T) {
+	pan := effect.NewPan(-1)
+	if err := pan.Process(ones(8)); err != nil {
+		t.Fatal(err)
+	}
+
+	// moving hard right ramps over the next buffer instead of jumping
+	pan.Pan = 1
+	s := ones(200)
+	if err := pan.Process(s); err != nil {
+		t.Fatal(err)
+	}
+	for i := 2; i < len(s); i += 2 {
+		if s[i] > s[i-2] || s[i+1] < s[i-1] {
+			t.Fatalf("frame %d: expected the left channel to fall and the right channel to rise", i/2)
+		}
+		if math.Abs(float64(s[i]-s[i-2])) > 0.011 {
+			t.Fatalf("frame %d: expected a smooth ramp, got a step of %v", i/2, s[i]-s[i-2])
+		}
+	}
+
+	s = ones(8)
+	if err := pan.Process(s); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(float64(s[0])) > 1e-6 || s[1] != 1 {
+		t.Errorf("expected the ramp to end hard right, got L %v, R %v", s[0], s[1])
+	}
+}
+
+func TestPanReader(t *testing.T) {
+	mono := []float32{0.5, -0.5, 1, 0}
+	got, err := aio.ReadAll(effect.PanReader(audio.NewReader(mono), effect.NewPan(0.5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2*len(mono) {
+		t.Fatalf("expected %d samples, got %d", 2*len(mono), len(got))
+	}
+
+	l, r := effect.NewPan(0.5).Gains()
+	for i, s := range mono {
+		if math.Abs(float64(got[2*i])-float64(s)*l) > 1e-6 || math.Abs(float64(got[2*i+1])-float64(s)*r) > 1e-6 {
+			t.Errorf("frame %d: expected %v, %v, got %v, %v", i, float64(s)*l, float64(s)*r, got[2*i], got[2*i+1])
+		}
+	}
+}
+
+func TestPanOdd(t *testing.T) {
+	if err := effect.NewPan(0).Process(make([]float32, 3)); err == nil {
+		t.Error("expected error for an incomplete stereo frame")
+	}
+}
@@ -0,0 +1,71 @@
+package effect
+
+import (
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+)
+
+// ramp smooths changes of a parameter by moving linearly to its new value over one buffer,
+// which avoids the zipper noise of sudden jumps.
+//
+// The zero value for ramp jumps straight to the first target.
+type ramp struct {
+	value float32
+	set   bool
+}
+
+// step starts a ramp to target over n frames and returns the value of the first frame
+// and the increment per frame. The ramp ends at target on the frame after the last one.
+func (r *ramp) step(target float32, n int) (start, delta float32) {
+	if !r.set || n == 0 {
+		r.value, r.set = target, true
+		return target, 0
+	}
+	start = r.value
+	r.value = target
+	return start, (target - start) / float32(n)
+}
+
+// smoother smooths changes of a parameter by moving linearly to its new value over a number of samples,
+// independent of the buffer size.
+//
+// The zero value for smoother jumps straight to the first target.
+type smoother struct {
+	value, target float32
+	delta         float32
+	left          int // samples left in the current ramp
+	set           bool
+}
+
+// next returns the value of the next sample, starting a ramp to target over n samples if the target has changed.
+// An n of 0 or less jumps to target immediately.
+func (s *smoother) next(target float32, n int) float32 {
+	if !s.set || n <= 0 {
+		s.value, s.target, s.left, s.set = target, target, 0, true
+		return target
+	}
+	if target != s.target {
+		s.target = target
+		s.delta = (target - s.value) / float32(n)
+		s.left = n
+	}
+	if s.left > 0 {
+		s.left--
+		s.value += s.delta
+		if s.left == 0 {
+			s.value = s.target
+		}
+	}
+	return s.value
+}
+
+// steady reports whether the smoother has reached target and will stay there.
+func (s *smoother) steady(target float32) bool {
+	return s.set && s.left == 0 && s.target == target
+}
+
+// smoothingSamples converts a smoothing time to a number of interleaved samples of the given format.
+func smoothingSamples(d time.Duration, format afmt.Format) int {
+	return afmt.DurationToNumFrames(format.SampleRate, d) * format.NumChannels
+}
@@ -0,0 +1,256 @@
+package effect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+)
+
+// Tuning of the Freeverb algorithm by Jezar at Dreampoint, for 44.1 kHz.
+var (
+	reverbCombTuning    = [...]int{1116, 1188, 1277, 1356, 1422, 1491, 1557, 1617}
+	reverbAllpassTuning = [...]int{556, 441, 341, 225}
+)
+
+const (
+	reverbStereoSpread = 23 // extra delay of the right channel in samples
+	reverbFixedGain    = 0.015
+	reverbScaleWet     = 3
+	reverbScaleDry     = 2
+	reverbScaleDamp    = 0.4
+	reverbScaleRoom    = 0.28
+	reverbOffsetRoom   = 0.7
+	reverbAllpassGain  = 0.5
+)
+
+// reverbComb is a lowpass-feedback comb filter.
+type reverbComb struct {
+	buf   []float32
+	pos   int
+	store float32
+}
+
+func (c *reverbComb) process(x, feedback, damp float32) float32 {
+	y := c.buf[c.pos]
+	c.store = y*(1-damp) + c.store*damp
+	c.buf[c.pos] = x + c.store*feedback
+	c.pos++
+	if c.pos == len(c.buf) {
+		c.pos = 0
+	}
+	return y
+}
+
+// reverbAllpass is a Schroeder allpass filter.
+type reverbAllpass struct {
+	buf []float32
+	pos int
+}
+
+func (a *reverbAllpass) process(x float32) float32 {
+	b := a.buf[a.pos]
+	a.buf[a.pos] = x + b*reverbAllpassGain
+	a.pos++
+	if a.pos == len(a.buf) {
+		a.pos = 0
+	}
+	return b - x
+}
+
+// reverbChannel is the reverberator of one channel: parallel combs followed by allpasses in series.
+type reverbChannel struct {
+	combs     [len(reverbCombTuning)]reverbComb
+	allpasses [len(reverbAllpassTuning)]reverbAllpass
+}
+
+func newReverbChannel(scale float64, spread int) reverbChannel {
+	var c reverbChannel
+	for i, n := range reverbCombTuning {
+		c.combs[i].buf = make([]float32, max(int(float64(n+spread)*scale), 1))
+	}
+	for i, n := range reverbAllpassTuning {
+		c.allpasses[i].buf = make([]float32, max(int(float64(n+spread)*scale), 1))
+	}
+	return c
+}
+
+func (c *reverbChannel) process(x, feedback, damp float32) float32 {
+	var y float32
+	for i := range c.combs {
+		y += c.combs[i].process(x, feedback, damp)
+	}
+	for i := range c.allpasses {
+		y = c.allpasses[i].process(y)
+	}
+	return y
+}
+
+func (c *reverbChannel) reset() {
+	for i := range c.combs {
+		clear(c.combs[i].buf)
+		c.combs[i].store = 0
+	}
+	for i := range c.allpasses {
+		clear(c.allpasses[i].buf)
+	}
+}
+
+// Reverb is an algorithmic reverb after Freeverb, which simulates a room with
+// 8 parallel lowpass-feedback comb filters followed by 4 allpass filters in series per channel.
+//
+// Reverb processes mono or interleaved stereo samples. In stereo, the delay lines of the right channel
+// are 23 samples longer than those of the left one, which decorrelates the channels.
+// The delay lines are scaled from the original 44.1 kHz tuning to the sample rate.
+//
+// The parameters can be changed while processing: the filters keep their contents,
+// and the wet and dry levels are smoothed over one buffer.
+type Reverb struct {
+	// RoomSize is the size of the room from 0 to 1. Larger rooms have longer reverberation times.
+	RoomSize float64
+
+	// Damping is the damping of high frequencies from 0 to 1.
+	Damping float64
+
+	// Width is the stereo width of the reverberation from 0 (mono) to 1.
+	Width float64
+
+	// WetLevel and DryLevel are the levels of the reverberation and the original signal from 0 to 1.
+	WetLevel, DryLevel float64
+
+	format   afmt.Format
+	err      error
+	channels []reverbChannel
+
+	wet1, wet2, dry ramp
+}
+
+// NewReverb creates a new [Reverb] for samples of the given format with the initial settings of Freeverb.
+func NewReverb(format afmt.Format) *Reverb {
+	rv := &Reverb{
+		RoomSize: 0.5,
+		Damping:  0.5,
+		Width:    1,
+		WetLevel: 1.0 / reverbScaleWet,
+		DryLevel: 0,
+		format:   format,
+	}
+	switch {
+	case format.NumChannels != 1 && format.NumChannels != 2:
+		rv.err = fmt.Errorf("effect: reverb needs mono or stereo samples, got %d channels", format.NumChannels)
+	case format.SampleRate <= 0:
+		rv.err = fmt.Errorf("effect: invalid sample rate: %v", format.SampleRate)
+	}
+	if rv.err != nil {
+		return rv
+	}
+
+	scale := format.SampleRate.Hertz() / 44100
+	rv.channels = make([]reverbChannel, format.NumChannels)
+	for ch := range rv.channels {
+		rv.channels[ch] = newReverbChannel(scale, ch*reverbStereoSpread)
+	}
+	return rv
+}
+
+// Reset clears the reverberation.
+func (rv *Reverb) Reset() {
+	for ch := range rv.channels {
+		rv.channels[ch].reset()
+	}
+}
+
+// Process applies the reverb to the interleaved samples p.
+func (rv *Reverb) Process(p []float32) error {
+	if rv.err != nil {
+		return rv.err
+	}
+	if len(p)%rv.format.NumChannels != 0 {
+		return errors.New("effect: reverb needs whole frames")
+	}
+	numFrames := len(p) / rv.format.NumChannels
+
+	clamp := func(x float64) float64 { return max(0, min(x, 1)) }
+	feedback := float32(clamp(rv.RoomSize)*reverbScaleRoom + reverbOffsetRoom)
+	damp := float32(clamp(rv.Damping) * reverbScaleDamp)
+	wet := clamp(rv.WetLevel) * reverbScaleWet
+	width := clamp(rv.Width)
+
+	wet1, dWet1 := rv.wet1.step(float32(wet*(width/2+0.5)), numFrames)
+	wet2, dWet2 := rv.wet2.step(float32(wet*(1-width)/2), numFrames)
+	dry, dDry := rv.dry.step(float32(clamp(rv.DryLevel)*reverbScaleDry), numFrames)
+
+	if rv.format.NumChannels == 1 {
+		left := &rv.channels[0]
+		for i, x := range p {
+			y := left.process(x*2*reverbFixedGain, feedback, damp)
+			p[i] = y*(wet1+wet2) + x*dry
+			wet1 += dWet1
+			wet2 += dWet2
+			dry += dDry
+		}
+		return nil
+	}
+
+	left, right := &rv.channels[0], &rv.channels[1]
+	for i := 0; i < len(p); i += 2 {
+		inL, inR := p[i], p[i+1]
+		x := (inL + inR) * reverbFixedGain
+		outL := left.process(x, feedback, damp)
+		outR := right.process(x, feedback, damp)
+		p[i] = outL*wet1 + outR*wet2 + inL*dry
+		p[i+1] = outR*wet1 + outL*wet2 + inR*dry
+		wet1 += dWet1
+		wet2 += dWet2
+		dry += dDry
+	}
+	return nil
+}
+
+// Reader returns an aio.SampleReader that reads from r and applies rv to the samples.
+// After the end of r, it keeps playing the reverberation for tail.
+func (rv *Reverb) Reader(r aio.SampleReader, tail time.Duration) aio.SampleReader {
+	return &reverbReader{r: r, rv: rv, tail: afmt.DurationToNumFrames(rv.format.SampleRate, tail) * rv.format.NumChannels}
+}
+
+type reverbReader struct {
+	r    aio.SampleReader
+	rv   *Reverb
+	tail int // samples left to play after the end of r
+	eof  bool
+}
+
+func (r *reverbReader) ReadSamples(p []float32) (int, error) {
+	if r.rv.err != nil {
+		return 0, r.rv.err
+	}
+	p = p[:len(p)/r.rv.format.NumChannels*r.rv.format.NumChannels]
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if !r.eof {
+		n, err := aio.ReadFull(r.r, p)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			r.eof = true
+			n = n / r.rv.format.NumChannels * r.rv.format.NumChannels
+		} else if err != nil {
+			return 0, err
+		}
+		if n > 0 {
+			return n, r.rv.Process(p[:n])
+		}
+	}
+
+	// play the tail by feeding silence
+	if r.tail <= 0 {
+		return 0, io.EOF
+	}
+	n := min(len(p), r.tail)
+	clear(p[:n])
+	r.tail -= n
+	return n, r.rv.Process(p[:n])
+}
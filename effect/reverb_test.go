@@ -0,0 +1,144 @@
+package effect_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var (
+	reverbMono   = afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 1}
+	reverbStereo = afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 2}
+)
+
+// rt60 estimates the reverberation time of the impulse response h at 44.1 kHz
+// from the -5 to -35 dB range of its Schroeder decay curve.
+func rt60(h []float32) float64 {
+	energy := make([]float64, len(h))
+	var sum float64
+	for i := len(h) - 1; i >= 0; i-- {
+		sum += float64(h[i]) * float64(h[i])
+		energy[i] = sum
+	}
+
+	t5, t35 := -1, -1
+	for i, e := range energy {
+		db := 10 * math.Log10(e/energy[0])
+		if t5 < 0 && db <= -5 {
+			t5 = i
+		}
+		if db <= -35 {
+			t35 = i
+			break
+		}
+	}
+	if t5 < 0 || t35 < 0 {
+		return math.Inf(1)
+	}
+	return 2 * float64(t35-t5) / 44100
+}
+
+func TestReverbRT60(t *testing.T) {
+	prev := 0.0
+	for _, size := range []float64{0, 0.3, 0.6, 0.9} {
+		rv := effect.NewReverb(reverbMono)
+		rv.RoomSize, rv.WetLevel, rv.DryLevel = size, 1, 0
+
+		h := make([]float32, 6*44100)
+		h[0] = 1
+		processChunks(t, rv, h, 1)
+
+		got := rt60(h)
+		t.Logf("RoomSize %v: RT60 %.2f s", size, got)
+		if math.IsInf(got, 1) || got <= prev {
+			t.Errorf("RoomSize %v: expected RT60 above %.2f s, got %.2f s", size, prev, got)
+		}
+		prev = got
+	}
+}
+
+func TestReverbStable(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	p := make([]float32, 2*5*44100)
+	for i := range p[:len(p)/2] {
+		p[i] = float32(2*rng.Float64() - 1)
+	}
+
+	rv := effect.NewReverb(reverbStereo)
+	rv.RoomSize, rv.Damping, rv.Width, rv.WetLevel, rv.DryLevel = 10, -1, 1, 1, 1 // clamped to the extremes
+	processChunks(t, rv, p, 2)
+
+	for i, x := range p {
+		if math.IsNaN(float64(x)) || math.Abs(float64(x)) > 100 {
+			t.Fatalf("sample %d: expected a bounded output, got %v", i, x)
+		}
+	}
+	// the tail dies away
+	var tail, body float64
+	for _, x := range p[len(p)/2-44100 : len(p)/2] {
+		body = max(body, math.Abs(float64(x)))
+	}
+	for _, x := range p[len(p)-4410:] {
+		tail = max(tail, math.Abs(float64(x)))
+	}
+	if tail >= body/2 {
+		t.Errorf("expected the tail to decay, got a peak of %v after %v", tail, body)
+	}
+}
+
+func TestReverbWidth(t *testing.T) {
+	for _, width := range []float64{0, 1} {
+		rv := effect.NewReverb(reverbStereo)
+		rv.Width = width
+
+		p := make([]float32, 2*44100)
+		p[0], p[1] = 1, 1
+		processChunks(t, rv, p, 2)
+
+		same := true
+		for i := 0; i < len(p); i += 2 {
+			if p[i] != p[i+1] {
+				same = false
+				break
+			}
+		}
+		if same != (width == 0) {
+			t.Errorf("Width %v: expected identical channels to be %v, got %v", width, width == 0, same)
+		}
+	}
+}
+
+func TestReverbReader(t *testing.T) {
+	rv := effect.NewReverb(reverbStereo)
+	out, err := aio.ReadAll(rv.Reader(audio.NewBuffer([]float32{1, 1}), 500*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2 * (1 + 22050); len(out) != want {
+		t.Fatalf("expected %d samples, got %d", want, len(out))
+	}
+
+	var energy float64
+	for _, x := range out[len(out)/2:] {
+		energy += float64(x) * float64(x)
+	}
+	if energy == 0 {
+		t.Error("expected the tail to contain the reverberation")
+	}
+}
+
+func TestReverbInvalid(t *testing.T) {
+	if err := effect.NewReverb(afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 3}).Process(make([]float32, 3)); err == nil {
+		t.Error("expected error for 3 channels")
+	}
+	if err := effect.NewReverb(reverbStereo).Process(make([]float32, 3)); err == nil {
+		t.Error("expected error for partial frame")
+	}
+}
@@ -0,0 +1,95 @@
+package effect
+
+import (
+	"math"
+
+	"github.com/MatusOllah/resona/dsp"
+)
+
+// SaturationCurve represents the transfer curve of a [Saturator].
+type SaturationCurve int
+
+const (
+	// SaturateTanh uses the hyperbolic tangent, a smooth curve that approaches ±1 gradually
+	// and starts to saturate noticeably early.
+	SaturateTanh SaturationCurve = iota
+
+	// SaturateArctan uses a scaled arctangent, which is softer than tanh and approaches ±1 much more slowly.
+	SaturateArctan
+
+	// SaturateCubic stays linear up to 1-Knee and then bends into ±1 along a cubic curve,
+	// which leaves quieter signals completely clean.
+	SaturateCubic
+)
+
+// Saturator softly clips the audio signal, as an alternative to harsh hard clipping or as a creative effect.
+//
+// The input is amplified by Drive, shaped by the curve and attenuated by Drive again, so that low-level signals pass
+// nearly unchanged and only the peaks are rounded off. All curves are symmetric, so the distortion they add consists of
+// odd harmonics only, mostly the third; it grows with the level and with the drive.
+// With a Trim of 0 dB or less, the output never exceeds ±1.
+//
+// The zero value for Saturator is ready to use and applies the tanh curve without extra drive.
+type Saturator struct {
+	// Curve is the transfer curve.
+	Curve SaturationCurve
+
+	// Drive is the gain in dB applied before the curve, which pushes the signal further into saturation.
+	// Values below 0 dB mean 0 dB.
+	Drive float64
+
+	// Trim is the gain in dB applied to the output.
+	Trim float64
+
+	// Knee is the width of the curved part of [SaturateCubic] from 0 (a hard clip) to 1 (curved all the way from 0).
+	// Values outside of the range are clamped.
+	Knee float64
+}
+
+// NewSaturator creates a new [Saturator] effect with the given curve and drive in dB.
+//
+// In most cases, new([Saturator]) (or just declaring a [Saturator] variable) is sufficient
+// to create a new [Saturator].
+func NewSaturator(curve SaturationCurve, drive float64) *Saturator {
+	return &Saturator{Curve: curve, Drive: drive}
+}
+
+// cubicClip is a soft clipper with slope 1 at 0 that is linear up to 1-knee and reaches 1 at 1+knee/2.
+func cubicClip(x, knee float64) float64 {
+	a := math.Abs(x)
+	t := 1 - knee
+	if a <= t {
+		return x
+	}
+	if knee == 0 {
+		return math.Copysign(1, x)
+	}
+
+	// y = t + u - u³/(3w²) has slope 0 and reaches 1 at u = w = 1.5*knee
+	w := 1.5 * knee
+	u := min(a-t, w)
+	return math.Copysign(t+u-u*u*u/(3*w*w), x)
+}
+
+// Process saturates the samples p.
+func (s *Saturator) Process(p []float32) error {
+	drive := dsp.DBToAmplitude(max(s.Drive, 0))
+	out := dsp.DBToAmplitude(s.Trim) / drive
+	knee := max(0, min(s.Knee, 1))
+
+	switch s.Curve {
+	case SaturateArctan:
+		for i, x := range p {
+			p[i] = float32(2 / math.Pi * math.Atan(math.Pi/2*float64(x)*drive) * out)
+		}
+	case SaturateCubic:
+		for i, x := range p {
+			p[i] = float32(cubicClip(float64(x)*drive, knee) * out)
+		}
+	default:
+		for i, x := range p {
+			p[i] = float32(math.Tanh(float64(x)*drive) * out)
+		}
+	}
+	return nil
+}
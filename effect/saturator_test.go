@@ -0,0 +1,97 @@
+package effect_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var saturationCurves = []struct {
+	name  string
+	curve effect.SaturationCurve
+	knee  float64
+}{
+	{"Tanh", effect.SaturateTanh, 0},
+	{"Arctan", effect.SaturateArctan, 0},
+	{"Cubic", effect.SaturateCubic, 0.5},
+	{"Cubic/hard", effect.SaturateCubic, 0},
+	{"Cubic/full", effect.SaturateCubic, 1},
+}
+
+func TestSaturatorBounded(t *testing.T) {
+	p := make([]float32, 2001)
+	for _, c := range saturationCurves {
+		for _, drive := range []float64{-6, 0, 12, 40, 200} {
+			for i := range p {
+				p[i] = float32(i-1000) / 100 // -10 to 10
+			}
+			s := &effect.Saturator{Curve: c.curve, Drive: drive, Knee: c.knee}
+			if err := s.Process(p); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, x := range p {
+				if math.IsNaN(float64(x)) || x > 1 || x < -1 {
+					t.Fatalf("%s, %v dB: sample %d: expected at most ±1, got %v", c.name, drive, i, x)
+				}
+				// monotonic
+				if i > 0 && x < p[i-1] {
+					t.Fatalf("%s, %v dB: sample %d: expected a monotonic curve, got %v after %v", c.name, drive, i, x, p[i-1])
+				}
+			}
+		}
+	}
+}
+
+func TestSaturatorLowLevel(t *testing.T) {
+	const (
+		n  = 4800
+		f0 = 1000
+	)
+	sampleRate := 48 * freq.KiloHertz
+	for _, c := range saturationCurves {
+		for _, drive := range []float64{0, 12} {
+			t.Run(fmt.Sprintf("%s/%vdB", c.name, drive), func(t *testing.T) {
+				// a sine at -40 dBFS
+				p := make([]float32, n)
+				for i := range p {
+					p[i] = float32(0.01 * math.Sin(2*math.Pi*f0*float64(i)/48000))
+				}
+				s := &effect.Saturator{Curve: c.curve, Drive: drive, Knee: c.knee}
+				if err := s.Process(p); err != nil {
+					t.Fatal(err)
+				}
+
+				x := make([]float64, n)
+				for i, v := range p {
+					x[i] = float64(v)
+				}
+				fundamental := dsp.Goertzel(x, sampleRate, f0*freq.Hertz)
+				var harmonics float64
+				for k := 2; k <= 9; k++ {
+					harmonics += dsp.Goertzel(x, sampleRate, freq.Frequency(k)*f0*freq.Hertz)
+				}
+
+				// the level stays within 0.1 dB and the THD below 0.1%
+				if got := dsp.PowerToDB(fundamental) - dsp.PowerToDB(0.01*0.01*n*n/4); math.Abs(got) > 0.1 {
+					t.Errorf("expected the level to stay, got %.3f dB", got)
+				}
+				if thd := math.Sqrt(harmonics / fundamental); thd > 1e-3 {
+					t.Errorf("expected a THD below 0.1%%, got %.4f%%", 100*thd)
+				}
+			})
+		}
+	}
+}
+
+func TestSaturatorAllocs(t *testing.T) {
+	s := effect.NewSaturator(effect.SaturateTanh, 6)
+	p := make([]float32, 512)
+	if allocs := testing.AllocsPerRun(100, func() { _ = s.Process(p) }); allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
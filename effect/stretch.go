@@ -0,0 +1,356 @@
+package effect
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+)
+
+// Settings of the WSOLA algorithm of [TimeStretch].
+const (
+	stretchSegment   = 40 * time.Millisecond // length of the overlapping segments
+	stretchTolerance = 10 * time.Millisecond // how far a segment may move to line up with the previous one
+)
+
+var _ aio.SampleReader = (*TimeStretch)(nil)
+
+// TimeStretch wraps an aio.SampleReader and changes its tempo without changing its pitch.
+//
+// It uses WSOLA (waveform similarity overlap-add): the output is put together from 40 ms segments of the input
+// that overlap by half, each taken from about where the tempo says, but moved by up to 10 ms
+// to where it lines up best with the waveform of the previous one. The search runs on a mono mixdown,
+// so all channels are cut at the same places and stay aligned.
+//
+// The quality is good for speech and most music, though sharp transients can smear or repeat.
+type TimeStretch struct {
+	// Ratio is the tempo ratio: 2 plays twice as fast, 0.5 half as fast.
+	// It is clamped to the range [0.5, 2] and can be changed while reading.
+	Ratio float64
+
+	r           aio.SampleReader
+	numChannels int
+	err         error
+
+	segment   int       // segment length in frames, even
+	hop       int       // output hop, half the segment
+	tolerance int       // search range in frames
+	window    []float32 // periodic Hann window, which sums to 1 at half overlap
+
+	// Input, with hop frames of silence in front so that the first segment fades in over silence.
+	// Positions are counted in frames of this padded input.
+	in      []float32 // interleaved frames from inStart onward
+	mono    []float32 // mixdown of in
+	inStart int
+	inEnd   int // padded position of the end of the input, or -1 before it is known
+	readBuf []float32
+
+	tau     float64 // nominal position of the next segment
+	lastTau float64 // nominal position of the previous segment
+	prev    int     // position of the previous segment, or -1 before the first one
+	acc     []float32
+
+	out    []float32 // finished output frames
+	outPos int
+	done   bool
+}
+
+// NewTimeStretch creates a new [TimeStretch] reading samples of the given format from r,
+// changing the tempo by ratio.
+func NewTimeStretch(r aio.SampleReader, format afmt.Format, ratio float64) *TimeStretch {
+	s := &TimeStretch{
+		Ratio:       ratio,
+		r:           r,
+		numChannels: format.NumChannels,
+		inEnd:       -1,
+		prev:        -1,
+	}
+	switch {
+	case format.NumChannels <= 0:
+		s.err = fmt.Errorf("effect: invalid number of channels: %d", format.NumChannels)
+	case format.SampleRate <= 0:
+		s.err = fmt.Errorf("effect: invalid sample rate: %v", format.SampleRate)
+	}
+	if s.err != nil {
+		return s
+	}
+
+	s.hop = max(afmt.DurationToNumFrames(format.SampleRate, stretchSegment)/2, 1)
+	s.segment = 2 * s.hop
+	s.tolerance = afmt.DurationToNumFrames(format.SampleRate, stretchTolerance)
+	s.window = make([]float32, s.segment)
+	for i := range s.window {
+		s.window[i] = float32(0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(s.segment)))
+	}
+
+	s.in = make([]float32, s.hop*s.numChannels)
+	s.mono = make([]float32, s.hop)
+	s.acc = make([]float32, s.segment*s.numChannels)
+	return s
+}
+
+// ReadSamples reads stretched samples into p.
+// It returns the number of samples read and/or an error.
+func (s *TimeStretch) ReadSamples(p []float32) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	n := 0
+	for n < len(p) {
+		if s.outPos == len(s.out) {
+			if s.done {
+				break
+			}
+			if err := s.step(); err != nil {
+				s.err = err
+				break
+			}
+			continue
+		}
+		copied := copy(p[n:], s.out[s.outPos:])
+		s.outPos += copied
+		n += copied
+	}
+
+	if n == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// fill reads input until it reaches the padded position end or the end of the input.
+func (s *TimeStretch) fill(end int) error {
+	for s.inEnd < 0 && s.inStart+len(s.mono) < end {
+		want := (end - s.inStart - len(s.mono)) * s.numChannels
+		if cap(s.readBuf) < want {
+			s.readBuf = make([]float32, want)
+		}
+		n, err := aio.ReadFull(s.r, s.readBuf[:want])
+		n -= n % s.numChannels
+		s.in = append(s.in, s.readBuf[:n]...)
+		for i := 0; i < n; i += s.numChannels {
+			var sum float32
+			for _, x := range s.readBuf[i : i+s.numChannels] {
+				sum += x
+			}
+			s.mono = append(s.mono, sum/float32(s.numChannels))
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			s.inEnd = s.inStart + len(s.mono)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// monoAt returns the mixdown at the padded position i, which is silent outside of the buffered input.
+func (s *TimeStretch) monoAt(i int) float32 {
+	i -= s.inStart
+	if i < 0 || i >= len(s.mono) {
+		return 0
+	}
+	return s.mono[i]
+}
+
+// search returns the position within the tolerance around tau whose start lines up best with
+// the natural continuation of the previous segment, by normalized cross-correlation.
+func (s *TimeStretch) search(tau, natural int) int {
+	lo := max(tau-s.tolerance, s.inStart)
+	hi := tau + s.tolerance
+	if s.inEnd >= 0 {
+		hi = min(hi, max(s.inEnd-s.hop, lo))
+	}
+
+	var energy float64
+	for i := range s.hop {
+		x := float64(s.monoAt(lo + i))
+		energy += x * x
+	}
+
+	best, bestScore := tau, math.Inf(-1)
+	for c := lo; c <= hi; c++ {
+		var dot float64
+		for i := range s.hop {
+			dot += float64(s.monoAt(c+i)) * float64(s.monoAt(natural+i))
+		}
+		score := dot / math.Sqrt(max(energy, 1e-12))
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+
+		x0, x1 := float64(s.monoAt(c)), float64(s.monoAt(c+s.hop))
+		energy += x1*x1 - x0*x0
+	}
+	return best
+}
+
+// step adds the next segment and moves the finished frames to s.out.
+func (s *TimeStretch) step() error {
+	ratio := max(0.5, min(s.Ratio, 2))
+	tau := int(math.Round(s.tau))
+	natural := s.prev + s.hop
+	if err := s.fill(max(tau+s.tolerance, natural) + s.segment); err != nil {
+		return err
+	}
+
+	pos := 0
+	if s.prev >= 0 {
+		pos = s.search(tau, natural)
+	}
+
+	// overlap-add the segment
+	for i, w := range s.window {
+		j := pos + i - s.inStart
+		if j < 0 || j >= len(s.mono) {
+			continue
+		}
+		for ch := range s.numChannels {
+			s.acc[i*s.numChannels+ch] += w * s.in[j*s.numChannels+ch]
+		}
+	}
+
+	// The first half is finished. It holds the input from the previous nominal position to this one,
+	// as the segments start hop frames early in the padded input; that of the first segment is only the silence in front.
+	numFrames := s.hop
+	if s.prev < 0 {
+		numFrames = 0
+	} else if end := float64(s.inEnd - s.hop); s.inEnd >= 0 && s.tau >= end {
+		// only the frames for the input up to its end
+		numFrames = min(numFrames, max(int(math.Round((end-s.lastTau)/ratio)), 0))
+		s.done = true
+	}
+	s.out = append(s.out[:0], s.acc[:numFrames*s.numChannels]...)
+	s.outPos = 0
+
+	copy(s.acc, s.acc[s.hop*s.numChannels:])
+	clear(s.acc[s.hop*s.numChannels:])
+	s.prev = pos
+	s.lastTau = s.tau
+	s.tau += float64(s.hop) * ratio
+
+	// drop the input no longer needed
+	keep := min(int(math.Round(s.tau))-s.tolerance, s.prev+s.hop)
+	if drop := keep - s.inStart; drop > 0 {
+		drop = min(drop, len(s.mono))
+		s.in = append(s.in[:0], s.in[drop*s.numChannels:]...)
+		s.mono = append(s.mono[:0], s.mono[drop:]...)
+		s.inStart += drop
+	}
+	return nil
+}
+
+var _ aio.SampleReader = (*PitchShift)(nil)
+
+// PitchShift wraps an aio.SampleReader and changes its pitch without changing its tempo.
+//
+// It stretches the signal with a [TimeStretch] by the pitch ratio and then plays the result back
+// faster or slower by the same ratio, resampling with cubic interpolation,
+// which brings the tempo back to the original and leaves the pitch changed.
+type PitchShift struct {
+	// Semitones is the pitch change in semitones. It is clamped to one octave up or down
+	// and can be changed while reading.
+	Semitones float64
+
+	stretch     *TimeStretch
+	numChannels int
+	err         error
+
+	buf []float32 // stretched frames, starting one frame before pos
+	pos float64   // position of the next output frame in buf, at least 1
+	eof bool
+}
+
+// NewPitchShift creates a new [PitchShift] reading samples of the given format from r,
+// changing the pitch by semitones.
+func NewPitchShift(r aio.SampleReader, format afmt.Format, semitones float64) *PitchShift {
+	ps := &PitchShift{
+		Semitones:   semitones,
+		stretch:     NewTimeStretch(r, format, 1),
+		numChannels: format.NumChannels,
+		pos:         1,
+	}
+	ps.err = ps.stretch.err
+	if ps.err == nil {
+		// silence before the first frame for the interpolation
+		ps.buf = make([]float32, ps.numChannels)
+	}
+	return ps
+}
+
+// ReadSamples reads pitch-shifted samples into p.
+// It returns the number of samples read and/or an error.
+func (ps *PitchShift) ReadSamples(p []float32) (int, error) {
+	if ps.err != nil {
+		return 0, ps.err
+	}
+
+	factor := math.Exp2(max(-12, min(ps.Semitones, 12)) / 12)
+	ps.stretch.Ratio = 1 / factor
+
+	n := 0
+	for ; n+ps.numChannels <= len(p); n += ps.numChannels {
+		whole := int(ps.pos)
+		if err := ps.fill(whole + 3); err != nil {
+			ps.err = err
+			break
+		}
+		numFrames := len(ps.buf) / ps.numChannels
+		if ps.eof && whole >= numFrames {
+			break
+		}
+
+		at := func(i, ch int) float32 {
+			if i >= numFrames {
+				return 0
+			}
+			return ps.buf[i*ps.numChannels+ch]
+		}
+		t := float32(ps.pos - float64(whole))
+		for ch := range ps.numChannels {
+			p[n+ch] = hermite(at(whole-1, ch), at(whole, ch), at(whole+1, ch), at(whole+2, ch), t)
+		}
+		ps.pos += factor
+	}
+
+	// drop the frames no longer needed
+	if drop := int(ps.pos) - 1; drop > 0 {
+		drop = min(drop, len(ps.buf)/ps.numChannels)
+		ps.buf = append(ps.buf[:0], ps.buf[drop*ps.numChannels:]...)
+		ps.pos -= float64(drop)
+	}
+
+	if n == 0 {
+		if ps.err != nil {
+			return 0, ps.err
+		}
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// fill reads stretched frames until buf holds numFrames frames or the stretched signal has ended.
+func (ps *PitchShift) fill(numFrames int) error {
+	for !ps.eof && len(ps.buf) < numFrames*ps.numChannels {
+		start := len(ps.buf)
+		want := max(numFrames*ps.numChannels-start, 1024*ps.numChannels)
+		ps.buf = slices.Grow(ps.buf, want)[:start+want]
+		n, err := aio.ReadFull(ps.stretch, ps.buf[start:])
+		ps.buf = ps.buf[:start+n]
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			ps.eof = true
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package effect_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp/pitch"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var stretchFormat = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+// stereoTone returns numFrames stereo frames of a tone at f with a few harmonics, the right channel at half the level.
+func stereoTone(f float64, numFrames int) []float32 {
+	p := make([]float32, 2*numFrames)
+	for i := range numFrames {
+		w := 2 * math.Pi * f * float64(i) / 48000
+		x := 0.5*math.Sin(w) + 0.2*math.Sin(2*w) + 0.1*math.Sin(3*w)
+		p[2*i], p[2*i+1] = float32(x), float32(x/2)
+	}
+	return p
+}
+
+// measurePitch detects the pitch of the left channel in the middle of the stereo samples p.
+func measurePitch(t *testing.T, p []float32) float64 {
+	t.Helper()
+
+	mid := len(p) / 4
+	s := make([]float64, 4096)
+	for i := range s {
+		s[i] = float64(p[2*(mid+i)])
+	}
+	f, _ := pitch.Detect(s, stretchFormat.SampleRate)
+	return f.Hertz()
+}
+
+func TestTimeStretch(t *testing.T) {
+	const numFrames = 96000
+	in := stereoTone(220, numFrames)
+	for _, ratio := range []float64{0.5, 0.8, 1, 1.25, 2} {
+		t.Run(fmt.Sprint(ratio), func(t *testing.T) {
+			out, err := aio.ReadAll(effect.NewTimeStretch(audio.NewBuffer(in), stretchFormat, ratio))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := numFrames / ratio
+			if got := float64(len(out) / 2); math.Abs(got-want) > 0.01*want {
+				t.Errorf("expected %v frames, got %v", want, got)
+			}
+			if len(out)%2 != 0 {
+				t.Errorf("expected whole frames, got %d samples", len(out))
+			}
+			if got := measurePitch(t, out); math.Abs(got-220) > 2.2 {
+				t.Errorf("expected a pitch of 220 Hz, got %v Hz", got)
+			}
+
+			// the channels stay aligned
+			mid := len(out) / 2 &^ 1
+			for i := mid; i < mid+2000; i += 2 {
+				if math.Abs(float64(out[i]/2-out[i+1])) > 1e-6 {
+					t.Fatalf("frame %d: expected the right channel at half the left one, got %v and %v", i/2, out[i], out[i+1])
+				}
+			}
+		})
+	}
+}
+
+func TestPitchShift(t *testing.T) {
+	const numFrames = 96000
+	in := stereoTone(220, numFrames)
+	for _, semitones := range []float64{-12, -5, 0, 7, 12} {
+		t.Run(fmt.Sprint(semitones), func(t *testing.T) {
+			out, err := aio.ReadAll(effect.NewPitchShift(audio.NewBuffer(in), stretchFormat, semitones))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := float64(len(out) / 2); math.Abs(got-numFrames) > 0.01*numFrames {
+				t.Errorf("expected %v frames, got %v", numFrames, got)
+			}
+			want := 220 * math.Exp2(semitones/12)
+			if got := measurePitch(t, out); math.Abs(got-want) > 0.01*want {
+				t.Errorf("expected a pitch of %.2f Hz, got %.2f Hz", want, got)
+			}
+		})
+	}
+}
+
+func TestTimeStretchEmpty(t *testing.T) {
+	out, err := aio.ReadAll(effect.NewTimeStretch(audio.NewBuffer(nil), stretchFormat, 1.5))
+	if err != nil || len(out) != 0 {
+		t.Errorf("expected no samples, got %d and %v", len(out), err)
+	}
+}
+
+func TestTimeStretchInvalid(t *testing.T) {
+	if _, err := effect.NewTimeStretch(audio.NewBuffer(nil), afmt.Format{SampleRate: 48 * freq.KiloHertz}, 1).ReadSamples(make([]float32, 2)); err == nil {
+		t.Error("expected error for invalid number of channels")
+	}
+	if _, err := effect.NewPitchShift(audio.NewBuffer(nil), afmt.Format{NumChannels: 1}, 1).ReadSamples(make([]float32, 2)); err == nil {
+		t.Error("expected error for invalid sample rate")
+	}
+}
@@ -0,0 +1,145 @@
+package effect
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+)
+
+var _ aio.SampleReader = (*Transition)(nil)
+
+type voice struct {
+	r    aio.SampleReader
+	fade *Fade
+	eof  bool
+}
+
+// Transition is an aio.SampleReader that plays one source at a time and crossfades to the next one on [Transition.Switch],
+// such as when changing background music.
+//
+// The crossfade uses an equal-power ramp. A source that ends during a crossfade is treated as silence,
+// and the outgoing source is dropped once it has faded out. Switching again during a crossfade
+// fades every source that is still audible out from its current gain.
+//
+// A Transition is safe for concurrent use, so Switch can be called from another goroutine than the one reading samples.
+type Transition struct {
+	format afmt.Format
+
+	mu     sync.Mutex
+	voices []*voice
+	cur    *voice // the current source, the other voices are fading out
+	buf    []float32
+}
+
+// NewTransition creates a new [Transition] playing r, which produces samples of the given format.
+// If r is nil, the Transition starts with silence.
+func NewTransition(r aio.SampleReader, format afmt.Format) *Transition {
+	t := &Transition{format: format}
+	if r != nil {
+		t.cur = &voice{r: r, fade: NewFade(format, FadeEqualPower)}
+		t.voices = append(t.voices, t.cur)
+	}
+	return t
+}
+
+// Switch crossfades from the current source to next over d.
+// If next is nil, the current source fades out to silence.
+func (t *Transition) Switch(next aio.SampleReader, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, v := range t.voices {
+		v.fade.FadeOut(d)
+	}
+	t.cur = nil
+	if next == nil {
+		return
+	}
+
+	fade := NewFade(t.format, FadeEqualPower)
+	fade.SetGain(0)
+	fade.FadeIn(d)
+	t.cur = &voice{r: next, fade: fade}
+	t.voices = append(t.voices, t.cur)
+}
+
+// ReadSamples reads the mix of the sources into p.
+// It returns the number of samples read and/or an error.
+// It returns [io.EOF] once the current source has ended and no other source is fading out.
+func (t *Transition) ReadSamples(p []float32) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	numChannels := max(t.format.NumChannels, 1)
+	p = p[:len(p)-len(p)%numChannels]
+	if len(p) == 0 {
+		return 0, io.ErrShortBuffer
+	}
+	if cap(t.buf) < len(p) {
+		t.buf = make([]float32, len(p))
+	}
+	buf := t.buf[:len(p)]
+
+	clear(p)
+	n := 0
+	for _, v := range t.voices {
+		// the outgoing sources are only read until they have faded out
+		want := len(buf)
+		if v != t.cur {
+			want = min(want, v.fade.remaining()*numChannels)
+		}
+
+		m := 0
+		if !v.eof {
+			var err error
+			m, err = aio.ReadFull(v.r, buf[:want])
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				v.eof = true
+			} else if err != nil {
+				return 0, err
+			}
+		}
+		// silence after the end keeps the fade in step with the other sources
+		clear(buf[m:])
+		if err := v.fade.Process(buf); err != nil {
+			return 0, err
+		}
+		for i, x := range buf[:m] {
+			p[i] += x
+		}
+		n = max(n, m)
+	}
+
+	// drop the sources that have ended or faded out
+	voices := t.voices[:0]
+	for _, v := range t.voices {
+		if v.eof {
+			if v == t.cur {
+				t.cur = nil
+			}
+			continue
+		}
+		if v != t.cur {
+			select {
+			case <-v.fade.Done():
+				continue
+			default:
+			}
+		}
+		voices = append(voices, v)
+	}
+	clear(t.voices[len(voices):])
+	t.voices = voices
+
+	if len(t.voices) == 0 {
+		if n == 0 {
+			return 0, io.EOF
+		}
+		return n - n%numChannels, nil
+	}
+	// keep going while another source is still fading out or in, even if this one ended early
+	return len(p), nil
+}
@@ -0,0 +1,120 @@
+package effect_test
+
+import (
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+)
+
+var transitionFormat = afmt.Format{SampleRate: freq.KiloHertz, NumChannels: 2}
+
+// constantSource returns numFrames stereo frames with the value v.
+func constantSource(v float32, numFrames int) aio.SampleReader {
+	s := make([]float32, 2*numFrames)
+	for i := range s {
+		s[i] = v
+	}
+	return audio.NewBuffer(s)
+}
+
+// readFrames reads numFrames stereo frames from r and returns the left channel.
+func readFrames(t *testing.T, r aio.SampleReader, numFrames int) []float64 {
+	t.Helper()
+
+	p := make([]float32, 2*numFrames)
+	if _, err := aio.ReadFull(r, p); err != nil {
+		t.Fatal(err)
+	}
+	out := make([]float64, numFrames)
+	for i := range out {
+		if p[2*i] != p[2*i+1] {
+			t.Fatalf("frame %d: channels differ: %v and %v", i, p[2*i], p[2*i+1])
+		}
+		out[i] = float64(p[2*i])
+	}
+	return out
+}
+
+func TestTransition(t *testing.T) {
+	tr := effect.NewTransition(constantSource(1, 1000), transitionFormat)
+	for i, got := range readFrames(t, tr, 50) {
+		if got != 1 {
+			t.Fatalf("frame %d: expected 1 before the switch, got %v", i, got)
+		}
+	}
+
+	tr.Switch(constantSource(0.5, 1000), 100*time.Millisecond)
+	for i, got := range readFrames(t, tr, 150) {
+		x := min(float64(i)/100, 1) * math.Pi / 2
+		want := math.Cos(x) + 0.5*math.Sin(x)
+		if math.Abs(got-want) > 1e-5 {
+			t.Fatalf("frame %d: expected %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestTransitionOutgoingEOF(t *testing.T) {
+	// the outgoing source ends halfway through the fade
+	tr := effect.NewTransition(constantSource(1, 80), transitionFormat)
+	readFrames(t, tr, 30)
+
+	tr.Switch(constantSource(0.5, 1000), 100*time.Millisecond)
+	for i, got := range readFrames(t, tr, 120) {
+		x := min(float64(i)/100, 1) * math.Pi / 2
+		want := 0.5 * math.Sin(x)
+		if i < 50 {
+			want += math.Cos(x)
+		}
+		if math.Abs(got-want) > 1e-5 {
+			t.Fatalf("frame %d: expected %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestTransitionRetarget(t *testing.T) {
+	tr := effect.NewTransition(constantSource(1, 1000), transitionFormat)
+	tr.Switch(constantSource(1, 1000), 100*time.Millisecond)
+	readFrames(t, tr, 50)
+
+	// switch again halfway, both sources fade out from where they are
+	tr.Switch(constantSource(0.25, 1000), 100*time.Millisecond)
+	for i, got := range readFrames(t, tr, 150) {
+		x := min(float64(i)/100, 1)
+		want := 2*math.Sin(0.5*(1-x)*math.Pi/2) + 0.25*math.Sin(x*math.Pi/2)
+		if math.Abs(got-want) > 1e-5 {
+			t.Fatalf("frame %d: expected %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestTransitionEOF(t *testing.T) {
+	tr := effect.NewTransition(constantSource(1, 100), transitionFormat)
+	readFrames(t, tr, 50)
+
+	// fading out to nothing ends the stream after the fade
+	tr.Switch(nil, 20*time.Millisecond)
+	p := make([]float32, 200)
+	n, err := aio.ReadFull(tr, p)
+	if n != 40 || err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected 20 frames and io.ErrUnexpectedEOF, got %d samples and %v", n, err)
+	}
+	if p[38] == 0 || p[40] != 0 {
+		t.Errorf("expected the fade to end after 20 frames, got %v", p[:42])
+	}
+	if _, err := tr.ReadSamples(p); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	// a source that ends on its own ends the stream as well
+	tr = effect.NewTransition(constantSource(1, 10), transitionFormat)
+	if got, err := aio.ReadAll(tr); err != nil || len(got) != 20 {
+		t.Errorf("expected 20 samples, got %d and %v", len(got), err)
+	}
+}
@@ -1,7 +1,7 @@
 package effect
 
 import (
-	"math"
+	"github.com/MatusOllah/resona/dsp"
 )
 
 // Volume adjusts the volume of the audio signal in decibels (dB).
@@ -23,7 +23,7 @@ func NewVolume(vol float64) *Volume {
 }
 
 func (v *Volume) Process(p []float32) error {
-	var gain float32 = float32(math.Pow(10, v.Volume/20.0))
+	gain := float32(dsp.DBToAmplitude(v.Volume))
 	for i := range p {
 		p[i] *= gain
 	}
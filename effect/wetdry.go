@@ -0,0 +1,145 @@
+package effect
+
+import (
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+)
+
+// dryPath delays the dry signal of a wet/dry mix by the latency of the wet path.
+type dryPath struct {
+	buf []float32
+	pos int
+}
+
+// next feeds x to the delay line and returns the sample from latency samples ago.
+// It starts over from silence if the latency has changed.
+func (d *dryPath) next(x float32, latency int) float32 {
+	if latency <= 0 {
+		return x
+	}
+	if len(d.buf) != latency {
+		d.buf = make([]float32, latency)
+		d.pos = 0
+	}
+	y := d.buf[d.pos]
+	d.buf[d.pos] = x
+	d.pos++
+	if d.pos == len(d.buf) {
+		d.pos = 0
+	}
+	return y
+}
+
+// mixWetDry blends the wet samples in wet with the dry samples in p, writing the result to p.
+func mixWetDry(p, wet []float32, dry *dryPath, latency int, mix *ramp, target float64) {
+	m, dm := mix.step(float32(max(0, min(target, 1))), len(p))
+	for i, x := range p {
+		p[i] = dry.next(x, latency)*(1-m) + wet[i]*m
+		m += dm
+	}
+}
+
+// WetDryMix blends the output of an effect (the wet signal) with its input (the dry signal).
+//
+// The wrapped effect processes a copy of the samples, so it does not need a mix control of its own.
+// If it delays the signal, set Latency to the delay, so that the dry signal is delayed to match.
+// Changes of Mix are smoothed over one buffer.
+type WetDryMix struct {
+	// Mix is the amount of the wet signal in the output, from 0 (only the dry signal) to 1 (only the wet signal).
+	// Values outside of the range are clamped.
+	Mix float64
+
+	// Latency is the delay of the wrapped effect in samples, by which the dry signal is delayed.
+	// For interleaved audio, that is the number of frames times the number of channels.
+	Latency int
+
+	fx  Effect
+	wet []float32
+	dry dryPath
+	mix ramp
+}
+
+// WetDry creates a new [WetDryMix] that blends the output of fx with its input by mix.
+func WetDry(fx Effect, mix float64) *WetDryMix {
+	return &WetDryMix{Mix: mix, fx: fx}
+}
+
+// Process applies the wrapped effect to a copy of p and blends it with p.
+func (w *WetDryMix) Process(p []float32) error {
+	if cap(w.wet) < len(p) {
+		w.wet = make([]float32, len(p))
+	}
+	wet := w.wet[:len(p)]
+	copy(wet, p)
+	if err := w.fx.Process(wet); err != nil {
+		return err
+	}
+
+	mixWetDry(p, wet, &w.dry, w.Latency, &w.mix, w.Mix)
+	return nil
+}
+
+// WetDryReader is like [WetDryMix], but for effects that wrap an aio.SampleReader,
+// blending the output of the wrapped reader with the samples it reads from the source.
+//
+// The wrapped reader must return one sample for every sample it reads, apart from its latency,
+// so effects that change the length, such as [TimeStretch], cannot be mixed.
+// If it returns more samples than it read at the end, such as the tail of a reverb, they are blended with silence.
+type WetDryReader struct {
+	// Mix is the amount of the wet signal in the output, from 0 (only the dry signal) to 1 (only the wet signal).
+	// Values outside of the range are clamped.
+	Mix float64
+
+	// Latency is the delay of the wrapped reader in samples, by which the dry signal is delayed.
+	// For interleaved audio, that is the number of frames times the number of channels.
+	Latency int
+
+	wet     aio.SampleReader
+	pending []float32 // samples read from the source that have not been blended yet
+	buf     []float32
+	dry     dryPath
+	mix     ramp
+}
+
+type wetDryTap struct {
+	r aio.SampleReader
+	w *WetDryReader
+}
+
+func (t *wetDryTap) ReadSamples(p []float32) (int, error) {
+	n, err := t.r.ReadSamples(p)
+	t.w.pending = append(t.w.pending, p[:n]...)
+	return n, err
+}
+
+// NewWetDryReader creates a new [WetDryReader] that reads from r, passes it through the reader returned by wrap
+// and blends the result with the samples read from r by mix.
+func NewWetDryReader(r aio.SampleReader, wrap func(aio.SampleReader) aio.SampleReader, mix float64) *WetDryReader {
+	w := &WetDryReader{Mix: mix}
+	w.wet = wrap(&wetDryTap{r: r, w: w})
+	return w
+}
+
+// ReadSamples reads samples from the wrapped reader into p and blends them with the dry samples.
+// It returns the number of samples read and/or an error.
+func (w *WetDryReader) ReadSamples(p []float32) (int, error) {
+	n, err := w.wet.ReadSamples(p)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	if cap(w.buf) < n {
+		w.buf = make([]float32, n)
+	}
+	wet := w.buf[:n]
+	copy(wet, p)
+
+	// the dry samples are taken from the front of pending and padded with silence once the source has ended
+	m := copy(p[:n], w.pending)
+	clear(p[m:n])
+	w.pending = append(w.pending[:0], w.pending[m:]...)
+
+	mixWetDry(p[:n], wet, &w.dry, w.Latency, &w.mix, w.Mix)
+	return n, err
+}
@@ -0,0 +1,143 @@
+package effect_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/effect"
+)
+
+// halfInvert is a latency-free effect for testing wet/dry mixing.
+var halfInvert = effect.EffectFunc(func(p []float32) error {
+	for i := range p {
+		p[i] *= -0.5
+	}
+	return nil
+})
+
+func TestWetDry(t *testing.T) {
+	in := randomStereo(1, 1000)
+	wet := slices.Clone(in)
+	if err := halfInvert.Process(wet); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mix := range []float64{0, 0.5, 1} {
+		p := slices.Clone(in)
+		processChunks(t, effect.WetDry(halfInvert, mix), p, 2)
+		for i, x := range p {
+			var want float32
+			switch mix {
+			case 0:
+				want = in[i]
+			case 0.5:
+				want = (in[i] + wet[i]) / 2
+			case 1:
+				want = wet[i]
+			}
+			if x != want {
+				t.Fatalf("mix %v: sample %d: expected %v, got %v", mix, i, want, x)
+			}
+		}
+	}
+}
+
+func TestWetDryLatency(t *testing.T) {
+	in := randomStereo(2, 2000)
+
+	newLimiter := func() *effect.Limiter {
+		lim := effect.NewLimiter(limiterFormat, 5*time.Millisecond)
+		lim.Ceiling = -12
+		return lim
+	}
+	wet := slices.Clone(in)
+	if err := newLimiter().Process(wet); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mix := range []float64{0, 1} {
+		lim := newLimiter()
+		fx := effect.WetDry(lim, mix)
+		fx.Latency = lim.Latency() * 2
+		p := slices.Clone(in)
+		processChunks(t, fx, p, 2)
+
+		for i, x := range p {
+			want := wet[i]
+			if mix == 0 {
+				want = 0
+				if i >= fx.Latency {
+					want = in[i-fx.Latency]
+				}
+			}
+			if x != want {
+				t.Fatalf("mix %v: sample %d: expected %v, got %v", mix, i, want, x)
+			}
+		}
+	}
+}
+
+func TestWetDryReader(t *testing.T) {
+	in := randomStereo(3, 2000)
+
+	newLimiter := func() *effect.Limiter {
+		lim := effect.NewLimiter(limiterFormat, 5*time.Millisecond)
+		lim.Ceiling = -12
+		return lim
+	}
+	want, err := aio.ReadAll(newLimiter().Reader(audio.NewReader(in)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mix := range []float64{0, 1} {
+		lim := newLimiter()
+		w := effect.NewWetDryReader(audio.NewReader(in), lim.Reader, mix)
+		w.Latency = lim.Latency() * 2
+		got, err := aio.ReadAll(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("mix %v: expected %d samples, got %d", mix, len(want), len(got))
+		}
+
+		for i, x := range got {
+			expected := want[i]
+			if mix == 0 {
+				expected = 0
+				if j := i - w.Latency; j >= 0 && j < len(in) {
+					expected = in[j]
+				}
+			}
+			if x != expected {
+				t.Fatalf("mix %v: sample %d: expected %v, got %v", mix, i, expected, x)
+			}
+		}
+	}
+}
+
+func TestWetDrySmoothing(t *testing.T) {
+	fx := effect.WetDry(effect.EffectFunc(func(p []float32) error {
+		clear(p)
+		return nil
+	}), 0)
+
+	p := ones(10)
+	if err := fx.Process(p); err != nil {
+		t.Fatal(err)
+	}
+	fx.Mix = 1
+	p = ones(10)
+	if err := fx.Process(p); err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range p {
+		if want := 1 - float32(i)/10; x < want-1e-6 || x > want+1e-6 {
+			t.Fatalf("sample %d: expected the mix to ramp to %v, got %v", i, want, x)
+		}
+	}
+}
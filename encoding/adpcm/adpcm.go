@@ -0,0 +1,199 @@
+// Package adpcm implements the IMA ADPCM audio codec, which stores 16-bit samples as 4-bit codewords.
+//
+// Samples are grouped into blocks as in Microsoft IMA ADPCM WAV files (format tag 0x0011).
+// Each block starts with a 4-byte header per channel holding the first sample (the initial predictor) and the initial step index,
+// followed by the codewords of the remaining samples. Channels are interleaved in chunks of 4 bytes (8 codewords),
+// and codewords are packed least significant nibble first.
+// The predictor is reset by every block header, so blocks can be decoded independently.
+package adpcm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Reference: IMA Digital Audio Focus and Technical Working Groups, "Recommended Practices for Enhancing Digital Audio Compatibility in Multimedia Systems" (1992)
+
+// maxStepIndex is the largest index into stepTable.
+const maxStepIndex = 88
+
+var stepTable = [maxStepIndex + 1]int32{
+	7, 8, 9, 10, 11, 12, 13, 14, 16, 17,
+	19, 21, 23, 25, 28, 31, 34, 37, 41, 45,
+	50, 55, 60, 66, 73, 80, 88, 97, 107, 118,
+	130, 143, 157, 173, 190, 209, 230, 253, 279, 307,
+	337, 371, 408, 449, 494, 544, 598, 658, 724, 796,
+	876, 963, 1060, 1166, 1282, 1411, 1552, 1707, 1878, 2066,
+	2272, 2499, 2749, 3024, 3327, 3660, 4026, 4428, 4871, 5358,
+	5894, 6484, 7132, 7845, 8630, 9493, 10442, 11487, 12635, 13899,
+	15289, 16818, 18500, 20350, 22385, 24623, 27086, 29794, 32767,
+}
+
+var indexTable = [16]int8{
+	-1, -1, -1, -1, 2, 4, 6, 8,
+	-1, -1, -1, -1, 2, 4, 6, 8,
+}
+
+// channelState holds the state of a single channel.
+type channelState struct {
+	predictor int32
+	index     int32
+}
+
+// decode decodes a 4-bit codeword and returns the reconstructed sample.
+func (s *channelState) decode(code byte) int16 {
+	step := stepTable[s.index]
+	diff := step >> 3
+	if code&4 != 0 {
+		diff += step
+	}
+	if code&2 != 0 {
+		diff += step >> 1
+	}
+	if code&1 != 0 {
+		diff += step >> 2
+	}
+	if code&8 != 0 {
+		s.predictor -= diff
+	} else {
+		s.predictor += diff
+	}
+	s.predictor = min(max(s.predictor, -32768), 32767)
+	s.index = min(max(s.index+int32(indexTable[code&0xF]), 0), maxStepIndex)
+	return int16(s.predictor)
+}
+
+// encode encodes a sample into a 4-bit codeword, updating the state as the decoder would.
+func (s *channelState) encode(sample int16) byte {
+	step := stepTable[s.index]
+	diff := int32(sample) - s.predictor
+	var code byte
+	if diff < 0 {
+		code = 8
+		diff = -diff
+	}
+	if diff >= step {
+		code |= 4
+		diff -= step
+	}
+	step >>= 1
+	if diff >= step {
+		code |= 2
+		diff -= step
+	}
+	step >>= 1
+	if diff >= step {
+		code |= 1
+	}
+	s.decode(code)
+	return code
+}
+
+// headerSize is the size of the block header of a single channel in bytes.
+const headerSize = 4
+
+// BlockFrames returns the number of frames (samples per channel) in a block of blockSize bytes.
+// It returns 0 if the block size is not a positive multiple of 4*numChannels.
+func BlockFrames(blockSize, numChannels int) int {
+	if numChannels < 1 || blockSize <= 0 || blockSize%(4*numChannels) != 0 {
+		return 0
+	}
+	return (blockSize/numChannels-headerSize)*2 + 1
+}
+
+// BlockSize returns the size in bytes of a block holding the given number of frames (samples per channel).
+// It returns 0 if frames-1 is not a non-negative multiple of 8.
+func BlockSize(frames, numChannels int) int {
+	if numChannels < 1 || frames < 1 || (frames-1)%8 != 0 {
+		return 0
+	}
+	return (headerSize + (frames-1)/2) * numChannels
+}
+
+// DecodeBlock decodes a block of numChannels interleaved channels and appends the 16-bit samples to dst.
+// The block may be shorter than a full block, as long as it holds the headers and whole 4-byte chunks of each channel.
+func DecodeBlock(dst []int16, block []byte, numChannels int) ([]int16, error) {
+	if numChannels < 1 {
+		return dst, fmt.Errorf("adpcm: invalid number of channels: %d", numChannels)
+	}
+	if len(block) < headerSize*numChannels || len(block)%(4*numChannels) != 0 {
+		return dst, fmt.Errorf("adpcm: invalid block size: %d", len(block))
+	}
+
+	states := make([]channelState, numChannels)
+	for ch := range states {
+		h := block[ch*headerSize:]
+		if h[2] > maxStepIndex {
+			return dst, fmt.Errorf("adpcm: invalid step index: %d", h[2])
+		}
+		states[ch] = channelState{predictor: int32(int16(binary.LittleEndian.Uint16(h))), index: int32(h[2])}
+		dst = append(dst, int16(states[ch].predictor))
+	}
+
+	data := block[headerSize*numChannels:]
+	frames := len(data) / numChannels * 2
+	start := len(dst)
+	dst = append(dst, make([]int16, frames*numChannels)...)
+	out := dst[start:]
+
+	// each chunk holds 8 codewords of a channel
+	for i := 0; i < len(data); i += 4 * numChannels {
+		frame := i / numChannels * 2
+		for ch := range states {
+			for j, b := range data[i+ch*4 : i+ch*4+4] {
+				out[(frame+2*j)*numChannels+ch] = states[ch].decode(b & 0xF)
+				out[(frame+2*j+1)*numChannels+ch] = states[ch].decode(b >> 4)
+			}
+		}
+	}
+	return dst, nil
+}
+
+// EncodeBlock encodes numChannels interleaved 16-bit samples into a block and appends it to dst.
+// The number of frames must be 1 more than a multiple of 8 (see [BlockFrames]).
+//
+// stepIndex holds the initial step index of each channel and is updated to the final step indices,
+// so passing the same slice to consecutive calls carries the adapted step size over to the next block.
+// A nil stepIndex starts every channel with the smallest step.
+func EncodeBlock(dst []byte, samples []int16, numChannels int, stepIndex []uint8) ([]byte, error) {
+	if numChannels < 1 {
+		return dst, fmt.Errorf("adpcm: invalid number of channels: %d", numChannels)
+	}
+	if len(samples)%numChannels != 0 {
+		return dst, errors.New("adpcm: number of samples is not a multiple of the number of channels")
+	}
+	frames := len(samples) / numChannels
+	if BlockSize(frames, numChannels) == 0 {
+		return dst, fmt.Errorf("adpcm: invalid number of frames: %d", frames)
+	}
+	if stepIndex != nil && len(stepIndex) != numChannels {
+		return dst, errors.New("adpcm: number of step indices does not match the number of channels")
+	}
+
+	states := make([]channelState, numChannels)
+	for ch := range states {
+		states[ch].predictor = int32(samples[ch])
+		if stepIndex != nil {
+			states[ch].index = min(int32(stepIndex[ch]), maxStepIndex)
+		}
+		dst = binary.LittleEndian.AppendUint16(dst, uint16(samples[ch]))
+		dst = append(dst, byte(states[ch].index), 0)
+	}
+
+	samples = samples[numChannels:]
+	for frame := 0; frame < frames-1; frame += 8 {
+		for ch := range states {
+			for j := range 4 {
+				lo := states[ch].encode(samples[(frame+2*j)*numChannels+ch])
+				hi := states[ch].encode(samples[(frame+2*j+1)*numChannels+ch])
+				dst = append(dst, lo|hi<<4)
+			}
+		}
+	}
+
+	for ch := range stepIndex {
+		stepIndex[ch] = uint8(states[ch].index)
+	}
+	return dst, nil
+}
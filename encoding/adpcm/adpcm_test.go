@@ -0,0 +1,206 @@
+package adpcm_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/encoding/adpcm"
+)
+
+// readInt16s reads a file of little-endian 16-bit samples.
+func readInt16s(t *testing.T, name string) []int16 {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := make([]int16, len(b)/2)
+	for i := range s {
+		s[i] = int16(binary.LittleEndian.Uint16(b[2*i:]))
+	}
+	return s
+}
+
+// The golden data in testdata is generated by testdata/generate.py with the IMA ADPCM codec of Python's audioop module.
+var goldenTests = []struct {
+	name                   string
+	numChannels, blockSize int
+}{
+	{"mono", 1, 256},
+	{"stereo", 2, 512},
+}
+
+func TestDecodeBlock(t *testing.T) {
+	for _, tt := range goldenTests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := os.ReadFile("testdata/" + tt.name + ".ima")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := readInt16s(t, "testdata/"+tt.name+".dec.pcm")
+
+			var got []int16
+			for block := range slices.Chunk(blocks, tt.blockSize) {
+				got, err = adpcm.DecodeBlock(got, block, tt.numChannels)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(got) != len(want) {
+				t.Fatalf("expected %d samples, got %d", len(want), len(got))
+			}
+			if i := firstMismatch(got, want); i >= 0 {
+				t.Errorf("sample %d differs: expected %d, got %d", i, want[i], got[i])
+			}
+		})
+	}
+
+	// the predictor is clamped to 16 bits
+	got, err := adpcm.DecodeBlock(nil, []byte{0xF8, 0x7F, 88, 0x00, 0x07, 0x00, 0x00, 0x00}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[1] != math.MaxInt16 {
+		t.Errorf("expected clamped sample, got %d", got[1])
+	}
+
+	for _, b := range [][]byte{
+		{0x00, 0x00},
+		{0x00, 0x00, 89, 0x00},
+		{0x00, 0x00, 0x00, 0x00, 0x77},
+	} {
+		if _, err := adpcm.DecodeBlock(nil, b, 1); err == nil {
+			t.Errorf("expected error decoding %x", b)
+		}
+	}
+}
+
+func TestEncodeBlock(t *testing.T) {
+	for _, tt := range goldenTests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := os.ReadFile("testdata/" + tt.name + ".ima")
+			if err != nil {
+				t.Fatal(err)
+			}
+			samples := readInt16s(t, "testdata/"+tt.name+".pcm")
+
+			// the step index is carried over from block to block
+			var got []byte
+			stepIndex := make([]uint8, tt.numChannels)
+			for block := range slices.Chunk(samples, adpcm.BlockFrames(tt.blockSize, tt.numChannels)*tt.numChannels) {
+				got, err = adpcm.EncodeBlock(got, block, tt.numChannels, stepIndex)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(got) != len(want) {
+				t.Fatalf("expected %d bytes, got %d", len(want), len(got))
+			}
+			if i := firstMismatch(got, want); i >= 0 {
+				t.Errorf("byte %d differs: expected %#02x, got %#02x", i, want[i], got[i])
+			}
+		})
+	}
+
+	if _, err := adpcm.EncodeBlock(nil, make([]int16, 8), 1, nil); err == nil {
+		t.Error("expected error for invalid number of frames")
+	}
+}
+
+// firstMismatch returns the index of the first element that differs between got and want of the same length, or -1 if they are equal.
+func firstMismatch[T comparable](got, want []T) int {
+	for i := range got {
+		if got[i] != want[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestBlockFrames(t *testing.T) {
+	tests := []struct {
+		blockSize, numChannels, frames int
+	}{
+		{256, 1, 505},
+		{512, 1, 1017},
+		{1024, 2, 1017},
+		{2048, 2, 2041},
+		{100, 2, 0},
+		{0, 1, 0},
+	}
+
+	for _, tt := range tests {
+		if got := adpcm.BlockFrames(tt.blockSize, tt.numChannels); got != tt.frames {
+			t.Errorf("BlockFrames(%d, %d): expected %d, got %d", tt.blockSize, tt.numChannels, tt.frames, got)
+		}
+		if tt.frames != 0 {
+			if got := adpcm.BlockSize(tt.frames, tt.numChannels); got != tt.blockSize {
+				t.Errorf("BlockSize(%d, %d): expected %d, got %d", tt.frames, tt.numChannels, tt.blockSize, got)
+			}
+		}
+	}
+}
+
+func snr(ref, x []float32) float64 {
+	var sig, noise float64
+	for i := range ref {
+		sig += float64(ref[i]) * float64(ref[i])
+		d := float64(ref[i]) - float64(x[i])
+		noise += d * d
+	}
+	return 10 * math.Log10(sig/noise)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, numChannels := range []int{1, 2} {
+		const frames = 5000
+		samples := make([]float32, frames*numChannels)
+		for i := range frames {
+			for ch := range numChannels {
+				samples[i*numChannels+ch] = float32(0.5 * math.Sin(2*math.Pi*float64(440*(ch+1))*float64(i)/44100))
+			}
+		}
+
+		var buf bytes.Buffer
+		enc := adpcm.NewEncoder(&buf, numChannels, 512*numChannels)
+		if _, err := enc.WriteSamples(samples[:1234]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := enc.WriteSamples(samples[1234:]); err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// 4 full blocks of 1017 frames and a last block of 932 frames padded to 937
+		if want := 4*512*numChannels + adpcm.BlockSize(937, numChannels); buf.Len() != want {
+			t.Errorf("%d channels: expected %d bytes, got %d", numChannels, want, buf.Len())
+		}
+
+		decoded, err := aio.ReadAll(adpcm.NewDecoder(&buf, numChannels, 512*numChannels))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := 5005 * numChannels; len(decoded) != want {
+			t.Fatalf("%d channels: expected %d samples, got %d", numChannels, want, len(decoded))
+		}
+		if got := snr(samples, decoded[:len(samples)]); got < 30 {
+			t.Errorf("%d channels: SNR too low: %.2f dB", numChannels, got)
+		}
+	}
+}
+
+func TestInvalidBlockSize(t *testing.T) {
+	if _, err := adpcm.NewDecoder(bytes.NewReader(make([]byte, 12)), 2, 12).ReadSamples(make([]float32, 8)); err == nil {
+		t.Error("expected decoder error")
+	}
+	if _, err := adpcm.NewEncoder(&bytes.Buffer{}, 2, 12).WriteSamples(make([]float32, 8)); err == nil {
+		t.Error("expected encoder error")
+	}
+}
@@ -0,0 +1,75 @@
+package adpcm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+)
+
+type decoder struct {
+	r           io.Reader
+	numChannels int
+	block       []byte
+	samples     []int16 // decoded samples of the current block
+	pos         int
+	err         error
+}
+
+// NewDecoder returns an aio.SampleReader that reads and decodes IMA ADPCM blocks of blockSize bytes from the provided [io.Reader].
+// The samples of numChannels channels are interleaved. The last block may be shorter than blockSize.
+func NewDecoder(r io.Reader, numChannels int, blockSize int) aio.SampleReader {
+	d := &decoder{r: r, numChannels: numChannels}
+	if BlockFrames(blockSize, numChannels) == 0 {
+		d.err = fmt.Errorf("adpcm: invalid block size %d for %d channels", blockSize, numChannels)
+	} else {
+		d.block = make([]byte, blockSize)
+	}
+	return d
+}
+
+func (d *decoder) ReadSamples(p []float32) (n int, err error) {
+	for n < len(p) {
+		if d.pos >= len(d.samples) {
+			if d.err != nil {
+				break
+			}
+			if err := d.readBlock(); err != nil {
+				d.err = err
+				break
+			}
+			continue
+		}
+
+		m := min(len(p)-n, len(d.samples)-d.pos)
+		for i, s := range d.samples[d.pos : d.pos+m] {
+			p[n+i] = float32(s) / (1<<15 - 1)
+		}
+		d.pos += m
+		n += m
+	}
+
+	if n == 0 && d.err != nil && len(p) > 0 {
+		return 0, d.err
+	}
+	return n, nil
+}
+
+// readBlock reads and decodes the next block.
+func (d *decoder) readBlock() error {
+	n, err := io.ReadFull(d.r, d.block)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		// short last block
+		n -= n % (4 * d.numChannels)
+		if n == 0 {
+			return io.EOF
+		}
+	} else if err != nil {
+		return err
+	}
+
+	d.samples, err = DecodeBlock(d.samples[:0], d.block[:n], d.numChannels)
+	d.pos = 0
+	return err
+}
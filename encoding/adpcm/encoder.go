@@ -0,0 +1,88 @@
+package adpcm
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+)
+
+type encoder struct {
+	w           io.Writer
+	numChannels int
+	frames      int     // frames per block
+	samples     []int16 // buffered samples of the current block
+	stepIndex   []uint8
+	buf         []byte
+	err         error
+}
+
+// NewEncoder returns an aio.SampleWriteCloser that encodes and writes IMA ADPCM blocks of blockSize bytes to the provided [io.Writer].
+// The samples of numChannels channels are expected to be interleaved.
+// The step size adapted in a block carries over to the header of the next block.
+//
+// Close must be called to flush the last block, which is shortened to the fewest whole chunks
+// and padded by repeating the last frame. It will NOT close the underlying writer.
+func NewEncoder(w io.Writer, numChannels, blockSize int) aio.SampleWriteCloser {
+	e := &encoder{w: w, numChannels: numChannels}
+	e.frames = BlockFrames(blockSize, numChannels)
+	if e.frames == 0 {
+		e.err = fmt.Errorf("adpcm: invalid block size %d for %d channels", blockSize, numChannels)
+		return e
+	}
+	e.samples = make([]int16, 0, e.frames*numChannels)
+	e.stepIndex = make([]uint8, numChannels)
+	return e
+}
+
+func (e *encoder) WriteSamples(p []float32) (n int, err error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+
+	for n < len(p) {
+		m := min(len(p)-n, cap(e.samples)-len(e.samples))
+		for _, s := range p[n : n+m] {
+			e.samples = append(e.samples, int16(dsp.Clamp(s)*(1<<15-1)))
+		}
+		n += m
+
+		if len(e.samples) == cap(e.samples) {
+			if err := e.flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// flush encodes and writes the buffered samples as a block.
+func (e *encoder) flush() error {
+	var err error
+	e.buf, err = EncodeBlock(e.buf[:0], e.samples, e.numChannels, e.stepIndex)
+	if err != nil {
+		return err
+	}
+	e.samples = e.samples[:0]
+	_, err = e.w.Write(e.buf)
+	return err
+}
+
+// Close encodes and writes the last partial block.
+func (e *encoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	frames := len(e.samples) / e.numChannels
+	if frames == 0 {
+		return nil
+	}
+
+	last := e.samples[(frames-1)*e.numChannels : frames*e.numChannels]
+	for (frames-1)%8 != 0 {
+		e.samples = append(e.samples, last...)
+		frames++
+	}
+	return e.flush()
+}
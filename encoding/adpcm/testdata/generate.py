@@ -0,0 +1,89 @@
+#!/usr/bin/env python3
+
+"""
+Generates golden IMA ADPCM test data with the IMA (Intel/DVI) ADPCM codec of Python's audioop module.
+Do not edit the output files manually!
+
+audioop was removed from the standard library in Python 3.13; run this with Python 3.12 or older,
+or install the audioop-lts package.
+
+Outputs, all little-endian:
+  mono.pcm, stereo.pcm         the 16-bit input samples
+  mono.ima, stereo.ima         the samples encoded into blocks of 256 (mono) or 512 (stereo) bytes
+  mono.dec.pcm, stereo.dec.pcm the blocks decoded back into 16-bit samples
+
+Every block starts from the first sample of the block and the step index left by the previous block,
+as in Microsoft IMA ADPCM WAV files. audioop packs the first codeword into the high nibble,
+so the nibbles are swapped into WAV order, and the channels are interleaved in 4-byte chunks.
+"""
+
+import audioop
+import math
+import struct
+
+
+def signal(n: int, ch: int) -> list[int]:
+    """Returns a sweep, noise and full scale bursts, so that the step index and the predictor hit their limits."""
+    seed = 12345 + ch
+    out = []
+    for i in range(n):
+        seed = (seed * 1103515245 + 12345) & 0x7FFFFFFF
+        noise = (seed >> 16) - 16384
+        t = i / 22050
+        x = 12000 * math.sin(2 * math.pi * (200 + 3000 * t) * t * (ch + 1)) + noise / 4
+        if n // 2 <= i < n // 2 + 300:
+            x = 32767 if (i // 20) % 2 == 0 else -32768  # square burst
+        elif i >= n - 200:
+            x = 0  # silence
+        out.append(max(-32768, min(32767, int(x))))
+    return out
+
+
+def swap_nibbles(b: bytes) -> bytes:
+    return bytes(((x & 0x0F) << 4) | (x >> 4) for x in b)
+
+
+def generate(name: str, num_channels: int, block_size: int, num_blocks: int):
+    print(f"[*] Generating {name}")
+
+    frames = (block_size // num_channels - 4) * 2 + 1
+    chans = [signal(frames * num_blocks, ch) for ch in range(num_channels)]
+
+    pcm = bytearray()
+    ima = bytearray()
+    dec = bytearray()
+    index = [0] * num_channels
+    for blk in range(num_blocks):
+        codes = []
+        decoded = []
+        for ch in range(num_channels):
+            x = chans[ch][blk * frames:(blk + 1) * frames]
+            first = x[0]
+            data, (_, next_index) = audioop.lin2adpcm(struct.pack(f"<{frames - 1}h", *x[1:]), 2, (first, index[ch]))
+            y, _ = audioop.adpcm2lin(data, 2, (first, index[ch]))
+            codes.append(swap_nibbles(data))
+            decoded.append([first, *struct.unpack(f"<{frames - 1}h", y)])
+            ima += struct.pack("<hBB", first, index[ch], 0)
+            index[ch] = next_index
+        for i in range(0, len(codes[0]), 4):
+            for ch in range(num_channels):
+                ima += codes[ch][i:i + 4]
+        for i in range(frames):
+            for ch in range(num_channels):
+                pcm += struct.pack("<h", chans[ch][blk * frames + i])
+                dec += struct.pack("<h", decoded[ch][i])
+
+    with open(f"{name}.pcm", "wb") as f:
+        f.write(pcm)
+    with open(f"{name}.ima", "wb") as f:
+        f.write(ima)
+    with open(f"{name}.dec.pcm", "wb") as f:
+        f.write(dec)
+
+
+def main():
+    generate("mono", 1, 256, 4)
+    generate("stereo", 2, 512, 4)
+
+
+if __name__ == "__main__": main()
@@ -4,24 +4,40 @@ import (
 	"io"
 
 	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
 )
 
-// EncodeAlaw encodes a slice of float32 samples into A-law.
-func EncodeAlaw(s []float32) []byte {
-	b := make([]byte, len(s))
-	for i := range s {
-		i16 := int16(s[i] * (1<<15 - 1))
-		if i16 >= 0 {
-			b[i] = alawEnc[i16>>4]
-		} else {
-			b[i] = 0x7F & alawEnc[-i16>>4]
-		}
+// alawFloat holds the decoded A-law samples.
+var alawFloat [256]float32
+
+func init() {
+	for i, v := range alawDec {
+		alawFloat[i] = float32(v) / (1<<15 - 1)
 	}
-	return b
+}
+
+// encodeAlawSample encodes a 16-bit linear sample into A-law.
+// Negative samples are complemented rather than negated, as in the ITU-T G.191 reference implementation.
+func encodeAlawSample(v int16) byte {
+	if v >= 0 {
+		return alawEnc[v>>4]
+	}
+	return 0x7F & alawEnc[^v>>4]
+}
+
+// EncodeAlaw encodes float32 samples from src into A-law bytes in dst.
+// It returns the number of samples encoded, which is the minimum of len(dst) and len(src).
+func EncodeAlaw(dst []byte, src []float32) int {
+	n := min(len(dst), len(src))
+	for i, s := range src[:n] {
+		dst[i] = encodeAlawSample(int16(dsp.Clamp(s) * (1<<15 - 1)))
+	}
+	return n
 }
 
 type alawEncoder struct {
-	w io.Writer
+	w   io.Writer
+	buf []byte
 }
 
 // NewAlawEncoder returns an aio.SampleWriter that encodes and writes A-law samples to the provided [io.Writer].
@@ -30,45 +46,40 @@ func NewAlawEncoder(w io.Writer) aio.SampleWriter {
 }
 
 func (e *alawEncoder) WriteSamples(p []float32) (int, error) {
-	alaw := EncodeAlaw(p)
-	return e.w.Write(alaw)
-}
-
-// DecodeAlaw decodes A-law encoded samples.
-func DecodeAlaw(b []byte) []float32 {
-	s := make([]float32, len(b))
-	for i := range b {
-		i16 := alawDec[b[i]]
-		s[i] = float32(i16) / (1<<15 - 1)
+	if cap(e.buf) < len(p) {
+		e.buf = make([]byte, len(p))
+	} else {
+		e.buf = e.buf[:len(p)]
 	}
-	return s
+	EncodeAlaw(e.buf, p)
+	return e.w.Write(e.buf)
 }
 
-type alawDecoder struct {
-	r   io.Reader
-	buf []byte
+// DecodeAlaw decodes A-law bytes from src into float32 samples in dst.
+// It returns the number of samples decoded, which is the minimum of len(dst) and len(src).
+func DecodeAlaw(dst []float32, src []byte) int {
+	n := min(len(dst), len(src))
+	for i, b := range src[:n] {
+		dst[i] = alawFloat[b]
+	}
+	return n
 }
 
 // NewAlawDecoder returns an aio.SampleReader that reads and decodes A-law encoded samples from the provided [io.Reader].
+// The returned reader is a [*Decoder], which also supports random access if r implements [io.Seeker].
 func NewAlawDecoder(r io.Reader) aio.SampleReader {
-	return &alawDecoder{r: r}
+	d := &Decoder{lut: &alawFloat}
+	d.Reset(r)
+	return d
 }
 
-func (d *alawDecoder) ReadSamples(p []float32) (int, error) {
-	numSamples := len(p)
-	if cap(d.buf) < numSamples {
-		d.buf = make([]byte, numSamples)
-	} else {
-		d.buf = d.buf[:numSamples]
+// NewSeekableAlawDecoder returns a [Decoder] that reads and decodes A-law encoded samples from the provided [io.ReadSeeker]
+// with random access. Offsets are relative to the current position of r.
+// An [io.ReaderAt] can be used by wrapping it with [io.NewSectionReader].
+func NewSeekableAlawDecoder(r io.ReadSeeker) (*Decoder, error) {
+	d := &Decoder{lut: &alawFloat}
+	if err := d.reset(r); err != nil {
+		return nil, err
 	}
-
-	n, err := d.r.Read(d.buf)
-	if err != nil && err != io.EOF {
-		return 0, err
-	}
-
-	f64buf := DecodeAlaw(d.buf[:n])
-	copy(p, f64buf)
-
-	return n, err
+	return d, nil
 }
@@ -0,0 +1,134 @@
+package g711
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+)
+
+var (
+	_ aio.SampleReadSeeker = (*Decoder)(nil)
+	_ aio.SampleReaderAt   = (*Decoder)(nil)
+)
+
+// Decoder reads and decodes A-law or μ-law samples.
+//
+// As every sample is exactly one byte, a Decoder supports random access if its source implements [io.Seeker].
+// Seek and ReadSamplesAt offsets are in samples (frames of a mono stream),
+// relative to the position of the source when the Decoder was created or reset.
+type Decoder struct {
+	r    io.Reader
+	lut  *[256]float32
+	buf  []byte
+	base int64 // position of the first sample in r
+}
+
+// Reset makes the decoder read from r, using the same law, so it can be reused.
+// If the position of r cannot be determined, offsets are relative to the start of r.
+func (d *Decoder) Reset(r io.Reader) {
+	_ = d.reset(r)
+}
+
+func (d *Decoder) reset(r io.Reader) error {
+	d.r = r
+	d.base = 0
+	if s, ok := r.(io.Seeker); ok {
+		pos, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("g711: failed to get position: %w", err)
+		}
+		d.base = pos
+	}
+	return nil
+}
+
+// ReadSamples reads and decodes float32 samples into p.
+// It returns the number of samples read and/or an error.
+func (d *Decoder) ReadSamples(p []float32) (int, error) {
+	if cap(d.buf) < len(p) {
+		d.buf = make([]byte, len(p))
+	} else {
+		d.buf = d.buf[:len(p)]
+	}
+
+	n, err := d.r.Read(d.buf)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	d.decode(p, d.buf[:n])
+	return n, err
+}
+
+func (d *Decoder) decode(dst []float32, src []byte) {
+	for i, b := range src {
+		dst[i] = d.lut[b]
+	}
+}
+
+// Seek sets the offset in samples for the next ReadSamples call.
+// It returns the new offset and/or an error.
+// It will return an error if the source is not an [io.Seeker].
+func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
+	s, ok := d.r.(io.Seeker)
+	if !ok {
+		return 0, errors.New("g711: source does not support seeking")
+	}
+
+	if whence == io.SeekStart {
+		if offset < 0 {
+			return 0, errors.New("g711: negative position")
+		}
+		offset += d.base
+	}
+	pos, err := s.Seek(offset, whence)
+	if err != nil {
+		return 0, err
+	}
+	if pos < d.base {
+		// restore a valid position
+		_, _ = s.Seek(d.base, io.SeekStart)
+		return 0, errors.New("g711: negative position")
+	}
+	return pos - d.base, nil
+}
+
+// ReadSamplesAt reads and decodes float32 samples into p, starting at sample offset off.
+// It does not affect the offset of ReadSamples.
+//
+// If the source implements [io.ReaderAt], ReadSamplesAt is safe for parallel use.
+// Otherwise the source must implement [io.Seeker] and the read seeks there and back.
+func (d *Decoder) ReadSamplesAt(p []float32, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("g711: negative offset")
+	}
+	buf := make([]byte, len(p))
+
+	var n int
+	var err error
+	switch r := d.r.(type) {
+	case io.ReaderAt:
+		n, err = r.ReadAt(buf, d.base+off)
+	case io.ReadSeeker:
+		var pos int64
+		if pos, err = r.Seek(0, io.SeekCurrent); err != nil {
+			return 0, err
+		}
+		if _, err = r.Seek(d.base+off, io.SeekStart); err != nil {
+			return 0, err
+		}
+		n, err = io.ReadFull(r, buf)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		if _, serr := r.Seek(pos, io.SeekStart); serr != nil && err == nil {
+			err = serr
+		}
+	default:
+		return 0, errors.New("g711: source does not support random access")
+	}
+
+	d.decode(p, buf[:n])
+	return n, err
+}
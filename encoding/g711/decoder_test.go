@@ -0,0 +1,126 @@
+package g711_test
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/encoding/g711"
+)
+
+// onlyReadSeeker hides the io.ReaderAt implementation of the underlying reader.
+type onlyReadSeeker struct {
+	io.ReadSeeker
+}
+
+func TestDecoderRandomAccess(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	// a header before the samples, which the offsets must be relative to
+	src := append([]byte("header"), data...)
+
+	want, err := aio.ReadAll(g711.NewUlawDecoder(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, r := range map[string]io.ReadSeeker{
+		"ReaderAt":   bytes.NewReader(src),
+		"ReadSeeker": onlyReadSeeker{bytes.NewReader(src)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := r.Seek(6, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			dec, err := g711.NewSeekableUlawDecoder(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// ReadSamplesAt does not affect the offset of ReadSamples
+			first := make([]float32, 10)
+			if _, err := aio.ReadFull(dec, first); err != nil {
+				t.Fatal(err)
+			}
+			for _, off := range []int64{0, 123, 500, 990} {
+				got := make([]float32, 10)
+				if n, err := dec.ReadSamplesAt(got, off); n != 10 || err != nil {
+					t.Fatalf("ReadSamplesAt(%d): got %d, %v", off, n, err)
+				}
+				if !slices.Equal(got, want[off:off+10]) {
+					t.Errorf("ReadSamplesAt(%d): expected %v, got %v", off, want[off:off+10], got)
+				}
+			}
+			if pos, _ := dec.Seek(0, io.SeekCurrent); pos != 10 {
+				t.Errorf("expected position 10, got %d", pos)
+			}
+
+			// reading past the end
+			got := make([]float32, 20)
+			if n, err := dec.ReadSamplesAt(got, 990); n != 10 || err != io.EOF {
+				t.Errorf("expected 10 samples and EOF, got %d, %v", n, err)
+			}
+
+			for _, tc := range []struct {
+				offset int64
+				whence int
+				pos    int64
+			}{
+				{700, io.SeekStart, 700},
+				{-50, io.SeekCurrent, 660},
+				{-100, io.SeekEnd, 900},
+			} {
+				pos, err := dec.Seek(tc.offset, tc.whence)
+				if err != nil || pos != tc.pos {
+					t.Fatalf("Seek(%d, %d): got %d, %v", tc.offset, tc.whence, pos, err)
+				}
+				got := make([]float32, 10)
+				if _, err := aio.ReadFull(dec, got); err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Equal(got, want[tc.pos:tc.pos+10]) {
+					t.Errorf("after Seek(%d, %d): expected %v, got %v", tc.offset, tc.whence, want[tc.pos:tc.pos+10], got)
+				}
+			}
+
+			if _, err := dec.Seek(-1, io.SeekStart); err == nil {
+				t.Error("expected error when seeking before the start")
+			}
+			if _, err := dec.Seek(-2000, io.SeekEnd); err == nil {
+				t.Error("expected error when seeking before the start")
+			}
+		})
+	}
+}
+
+func TestDecoderReset(t *testing.T) {
+	dec := g711.NewAlawDecoder(bytes.NewReader([]byte{0xD5, 0xD5})).(*g711.Decoder)
+	if _, err := aio.ReadAll(dec); err != nil {
+		t.Fatal(err)
+	}
+
+	dec.Reset(bytes.NewReader([]byte{0x2A, 0xAA, 0xD5}))
+	got, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make([]float32, 3)
+	g711.DecodeAlaw(want, []byte{0x2A, 0xAA, 0xD5})
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDecoderNotSeekable(t *testing.T) {
+	dec := g711.NewUlawDecoder(bytes.NewBufferString("abc")).(*g711.Decoder)
+	if _, err := dec.Seek(1, io.SeekStart); err == nil {
+		t.Error("expected error when seeking")
+	}
+	if _, err := dec.ReadSamplesAt(make([]float32, 1), 1); err == nil {
+		t.Error("expected error when reading at an offset")
+	}
+}
@@ -1,3 +1,6 @@
 // Package g711 implements the G.711 audio codec, which is widely used in telephony systems.
-// It provides utilities to encode and decode audio data using the A-law and μ-law algorithms.
+// It provides utilities to encode and decode audio data using the A-law and μ-law algorithms,
+// both as streams and in bulk on byte slices (such as RTP payloads), and to transcode between the two laws.
+//
+// Encoding and decoding is bit-exact with the ITU-T G.191 reference implementation.
 package g711
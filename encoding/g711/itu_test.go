@@ -0,0 +1,133 @@
+package g711
+
+import (
+	"math"
+	"testing"
+)
+
+// The reference functions below follow alaw_compress, alaw_expand, ulaw_compress and ulaw_expand
+// of the ITU-T G.191 Software Tool Library (g711.c), operating on 16-bit left-justified samples.
+
+func ituAlawCompress(lin int16) byte {
+	var ix int
+	if lin < 0 {
+		ix = int(^lin) >> 4
+	} else {
+		ix = int(lin) >> 4
+	}
+	if ix > 15 {
+		iexp := 1
+		for ix > 16+15 {
+			ix >>= 1
+			iexp++
+		}
+		ix -= 16
+		ix += iexp << 4
+	}
+	if lin >= 0 {
+		ix |= 0x80
+	}
+	return byte(ix ^ 0x55)
+}
+
+func ituAlawExpand(log byte) int16 {
+	ix := int(log^0x55) & 0x7F
+	iexp := ix >> 4
+	mant := ix & 0xF
+	if iexp > 0 {
+		mant += 16
+	}
+	mant = mant<<4 + 8
+	if iexp > 1 {
+		mant <<= iexp - 1
+	}
+	if log > 127 {
+		return int16(mant)
+	}
+	return int16(-mant)
+}
+
+func ituUlawCompress(lin int16) byte {
+	var absno int
+	if lin < 0 {
+		absno = int(^lin)>>2 + 33
+	} else {
+		absno = int(lin)>>2 + 33
+	}
+	absno = min(absno, 0x1FFF)
+	segno := 1
+	for i := absno >> 6; i != 0; i >>= 1 {
+		segno++
+	}
+	high := 8 - segno
+	low := 0xF - (absno>>segno)&0xF
+	log := byte(high<<4 | low)
+	if lin >= 0 {
+		log |= 0x80
+	}
+	return log
+}
+
+func ituUlawExpand(log byte) int16 {
+	sign := 1
+	if log < 0x80 {
+		sign = -1
+	}
+	mantissa := ^int(log)
+	exponent := (mantissa >> 4) & 7
+	segment := exponent + 1
+	mantissa &= 0xF
+	step := 4 << segment
+	return int16(sign * (0x80<<exponent + step*mantissa + step/2 - 4*33))
+}
+
+func TestITUReference(t *testing.T) {
+	for i := range 256 {
+		if got, want := alawDec[i], ituAlawExpand(byte(i)); got != want {
+			t.Errorf("A-law expand %#02x: expected %d, got %d", i, want, got)
+		}
+		if got, want := ulawDec[i], ituUlawExpand(byte(i)); got != want {
+			t.Errorf("μ-law expand %#02x: expected %d, got %d", i, want, got)
+		}
+	}
+
+	for v := math.MinInt16; v <= math.MaxInt16; v++ {
+		if got, want := encodeAlawSample(int16(v)), ituAlawCompress(int16(v)); got != want {
+			t.Fatalf("A-law compress %d: expected %#02x, got %#02x", v, want, got)
+		}
+		if got, want := encodeUlawSample(int16(v)), ituUlawCompress(int16(v)); got != want {
+			t.Fatalf("μ-law compress %d: expected %#02x, got %#02x", v, want, got)
+		}
+	}
+}
+
+func TestTranscode(t *testing.T) {
+	src := make([]byte, 256)
+	for i := range src {
+		src[i] = byte(i)
+	}
+
+	alaw := make([]byte, 256)
+	if n := TranscodeUlawToAlaw(alaw, src); n != 256 {
+		t.Fatalf("expected 256 bytes, got %d", n)
+	}
+	ulaw := make([]byte, 256)
+	TranscodeAlawToUlaw(ulaw, src)
+
+	for i := range 256 {
+		if want := ituAlawCompress(ituUlawExpand(byte(i))); alaw[i] != want {
+			t.Errorf("μ-law %#02x: expected A-law %#02x, got %#02x", i, want, alaw[i])
+		}
+		if want := ituUlawCompress(ituAlawExpand(byte(i))); ulaw[i] != want {
+			t.Errorf("A-law %#02x: expected μ-law %#02x, got %#02x", i, want, ulaw[i])
+		}
+	}
+
+	// transcoding in place
+	TranscodeUlawToAlaw(src, src)
+	for i := range src {
+		if src[i] != alaw[i] {
+			t.Fatalf("in place: byte %d: expected %#02x, got %#02x", i, alaw[i], src[i])
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package g711
+
+// Transcoding tables, mapping each code to the code whose quantization interval
+// contains the decoded value of the other law.
+var (
+	ulawToAlaw [256]byte
+	alawToUlaw [256]byte
+)
+
+func init() {
+	for i := range 256 {
+		ulawToAlaw[i] = encodeAlawSample(ulawDec[i])
+		alawToUlaw[i] = encodeUlawSample(alawDec[i])
+	}
+}
+
+// TranscodeUlawToAlaw converts μ-law bytes from src into A-law bytes in dst without a round trip through float32 samples.
+// It returns the number of bytes converted, which is the minimum of len(dst) and len(src).
+// dst and src may be the same slice.
+func TranscodeUlawToAlaw(dst, src []byte) int {
+	n := min(len(dst), len(src))
+	for i, b := range src[:n] {
+		dst[i] = ulawToAlaw[b]
+	}
+	return n
+}
+
+// TranscodeAlawToUlaw converts A-law bytes from src into μ-law bytes in dst without a round trip through float32 samples.
+// It returns the number of bytes converted, which is the minimum of len(dst) and len(src).
+// dst and src may be the same slice.
+func TranscodeAlawToUlaw(dst, src []byte) int {
+	n := min(len(dst), len(src))
+	for i, b := range src[:n] {
+		dst[i] = alawToUlaw[b]
+	}
+	return n
+}
@@ -4,24 +4,40 @@ import (
 	"io"
 
 	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
 )
 
-// EncodeUlaw encodes a slice of float32 samples into μ-law.
-func EncodeUlaw(s []float32) []byte {
-	b := make([]byte, len(s))
-	for i := range s {
-		i16 := int16(s[i] * (1<<15 - 1))
-		if i16 >= 0 {
-			b[i] = ulawEnc[i16>>4]
-		} else {
-			b[i] = 0x7F & ulawEnc[-i16>>4]
-		}
+// ulawFloat holds the decoded μ-law samples.
+var ulawFloat [256]float32
+
+func init() {
+	for i, v := range ulawDec {
+		ulawFloat[i] = float32(v) / (1<<15 - 1)
 	}
-	return b
+}
+
+// encodeUlawSample encodes a 16-bit linear sample into μ-law.
+// Negative samples are complemented rather than negated, as in the ITU-T G.191 reference implementation.
+func encodeUlawSample(v int16) byte {
+	if v >= 0 {
+		return ulawEnc[v>>2]
+	}
+	return 0x7F & ulawEnc[^v>>2]
+}
+
+// EncodeUlaw encodes float32 samples from src into μ-law bytes in dst.
+// It returns the number of samples encoded, which is the minimum of len(dst) and len(src).
+func EncodeUlaw(dst []byte, src []float32) int {
+	n := min(len(dst), len(src))
+	for i, s := range src[:n] {
+		dst[i] = encodeUlawSample(int16(dsp.Clamp(s) * (1<<15 - 1)))
+	}
+	return n
 }
 
 type ulawEncoder struct {
-	w io.Writer
+	w   io.Writer
+	buf []byte
 }
 
 // NewUlawEncoder returns an aio.SampleWriter that encodes and writes μ-law samples to the provided [io.Writer].
@@ -30,45 +46,40 @@ func NewUlawEncoder(w io.Writer) aio.SampleWriter {
 }
 
 func (e *ulawEncoder) WriteSamples(p []float32) (int, error) {
-	ulaw := EncodeUlaw(p)
-	return e.w.Write(ulaw)
-}
-
-// DecodeUlaw decodes μ-law encoded samples.
-func DecodeUlaw(b []byte) []float32 {
-	s := make([]float32, len(b))
-	for i := range b {
-		i16 := ulawDec[b[i]]
-		s[i] = float32(i16) / (1<<15 - 1)
+	if cap(e.buf) < len(p) {
+		e.buf = make([]byte, len(p))
+	} else {
+		e.buf = e.buf[:len(p)]
 	}
-	return s
+	EncodeUlaw(e.buf, p)
+	return e.w.Write(e.buf)
 }
 
-type ulawDecoder struct {
-	r   io.Reader
-	buf []byte
+// DecodeUlaw decodes μ-law bytes from src into float32 samples in dst.
+// It returns the number of samples decoded, which is the minimum of len(dst) and len(src).
+func DecodeUlaw(dst []float32, src []byte) int {
+	n := min(len(dst), len(src))
+	for i, b := range src[:n] {
+		dst[i] = ulawFloat[b]
+	}
+	return n
 }
 
 // NewUlawDecoder returns an aio.SampleReader that reads and decodes μ-law encoded samples from the provided [io.Reader].
+// The returned reader is a [*Decoder], which also supports random access if r implements [io.Seeker].
 func NewUlawDecoder(r io.Reader) aio.SampleReader {
-	return &ulawDecoder{r: r}
+	d := &Decoder{lut: &ulawFloat}
+	d.Reset(r)
+	return d
 }
 
-func (d *ulawDecoder) ReadSamples(p []float32) (int, error) {
-	numSamples := len(p)
-	if cap(d.buf) < numSamples {
-		d.buf = make([]byte, numSamples)
-	} else {
-		d.buf = d.buf[:numSamples]
+// NewSeekableUlawDecoder returns a [Decoder] that reads and decodes μ-law encoded samples from the provided [io.ReadSeeker]
+// with random access. Offsets are relative to the current position of r.
+// An [io.ReaderAt] can be used by wrapping it with [io.NewSectionReader].
+func NewSeekableUlawDecoder(r io.ReadSeeker) (*Decoder, error) {
+	d := &Decoder{lut: &ulawFloat}
+	if err := d.reset(r); err != nil {
+		return nil, err
 	}
-
-	n, err := d.r.Read(d.buf)
-	if err != nil && err != io.EOF {
-		return 0, err
-	}
-
-	f64buf := DecodeUlaw(d.buf[:n])
-	copy(p, f64buf)
-
-	return n, err
+	return d, nil
 }
@@ -10,14 +10,40 @@ import (
 func TestUlawRoundTrip(t *testing.T) {
 	samples := []float32{0, 0.5, -0.5, 1, -1}
 
-	encoded := g711.EncodeUlaw(samples)
-	decoded := g711.DecodeUlaw(encoded)
-
-	if len(decoded) != len(samples) {
-		t.Fatalf("sample count mismatch: got %d, want %d", len(decoded), len(samples))
+	encoded := make([]byte, len(samples))
+	if n := g711.EncodeUlaw(encoded, samples); n != len(samples) {
+		t.Fatalf("encoded sample count mismatch: got %d, want %d", n, len(samples))
+	}
+	decoded := make([]float32, len(encoded))
+	if n := g711.DecodeUlaw(decoded, encoded); n != len(samples) {
+		t.Fatalf("sample count mismatch: got %d, want %d", n, len(samples))
 	}
 
-	if !testutil.EqualSliceWithinTolerance(decoded, samples, 1) {
+	if !testutil.EqualSliceWithinTolerance(decoded, samples, 0.1) {
 		t.Errorf("Decoded samples do not match original samples: got %v, want %v", decoded, samples)
 	}
 }
+
+func BenchmarkEncodeUlaw(b *testing.B) {
+	src := make([]float32, 160) // 20 ms at 8 kHz
+	for i := range src {
+		src[i] = float32(i-80) / 80
+	}
+	dst := make([]byte, len(src))
+	b.SetBytes(int64(len(src)))
+	for b.Loop() {
+		g711.EncodeUlaw(dst, src)
+	}
+}
+
+func BenchmarkDecodeUlaw(b *testing.B) {
+	src := make([]byte, 160) // 20 ms at 8 kHz
+	for i := range src {
+		src[i] = byte(i)
+	}
+	dst := make([]float32, len(src))
+	b.SetBytes(int64(len(src)))
+	for b.Loop() {
+		g711.DecodeUlaw(dst, src)
+	}
+}
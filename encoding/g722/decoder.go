@@ -0,0 +1,141 @@
+package g722
+
+import (
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+)
+
+// decode decodes a codeword of bps bits into two 16 kHz samples.
+func (s *state) decode(code, bps int) (int, int) {
+	var wd1, wd2, ihigh int
+	switch bps {
+	case 8:
+		wd1 = code & 0x3F
+		ihigh = (code >> 6) & 0x03
+		wd2 = qm6[wd1]
+		wd1 >>= 2
+	case 7:
+		wd1 = code & 0x1F
+		ihigh = (code >> 5) & 0x03
+		wd2 = qm5[wd1]
+		wd1 >>= 1
+	case 6:
+		wd1 = code & 0x0F
+		ihigh = (code >> 4) & 0x03
+		wd2 = qm4[wd1]
+	}
+
+	// lower band: INVQBL, RECONS, LIMIT
+	lo := &s.band[0]
+	rlow := min(max(lo.s+lo.det*wd2>>15, -16384), 16383)
+
+	// INVQAL
+	dlow := lo.det * qm4[wd1] >> 15
+
+	lo.scaleLow(wd1)
+	lo.update(dlow)
+
+	// higher band: INVQAH, RECONS, LIMIT
+	hi := &s.band[1]
+	dhigh := hi.det * qm2[ihigh] >> 15
+	rhigh := min(max(dhigh+hi.s, -16384), 16383)
+
+	hi.scaleHigh(ihigh)
+	hi.update(dhigh)
+
+	// receive QMF
+	copy(s.x[:], s.x[2:])
+	s.x[22] = rlow + rhigh
+	s.x[23] = rlow - rhigh
+	var xout1, xout2 int
+	for i := range 12 {
+		xout2 += s.x[2*i] * qmfCoeffs[i]
+		xout1 += s.x[2*i+1] * qmfCoeffs[11-i]
+	}
+	return saturate(xout1 >> 11), saturate(xout2 >> 11)
+}
+
+type decoder struct {
+	r          io.Reader
+	buf        []byte
+	state      state
+	bps        int     // bits per codeword
+	pending    float32 // second sample of a codeword that did not fit into the previous read
+	hasPending bool    // whether pending is valid
+	bits       uint32  // unread codeword bits
+	nbits      int     // number of unread codeword bits
+	err        error
+}
+
+// NewDecoder returns an aio.SampleReader that reads and decodes G.722 codewords from the provided [io.Reader].
+// The samples are 16 kHz mono.
+func NewDecoder(r io.Reader, opts ...Option) aio.SampleReader {
+	o := applyOptions(opts)
+	return &decoder{
+		r:     r,
+		state: newState(),
+		bps:   o.bitsPerSample,
+		err:   o.err,
+	}
+}
+
+func (d *decoder) ReadSamples(p []float32) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	i := 0
+	if d.hasPending {
+		p[0] = d.pending
+		d.hasPending = false
+		i++
+	}
+
+	// each codeword yields two samples
+	numCodes := (len(p) - i + 1) / 2
+	numBytes := max((numCodes*d.bps-d.nbits+7)/8, 0)
+	if cap(d.buf) < numBytes {
+		d.buf = make([]byte, numBytes)
+	} else {
+		d.buf = d.buf[:numBytes]
+	}
+
+	n, err := d.r.Read(d.buf)
+	if err != nil && err != io.EOF {
+		return i, err
+	}
+
+	decodeBits := func() {
+		for d.nbits >= d.bps && i < len(p) {
+			code := int(d.bits & (1<<d.bps - 1))
+			d.bits >>= d.bps
+			d.nbits -= d.bps
+
+			x0, x1 := d.state.decode(code, d.bps)
+			p[i] = float32(x0) / (1<<15 - 1)
+			i++
+			if i < len(p) {
+				p[i] = float32(x1) / (1<<15 - 1)
+				i++
+			} else {
+				d.pending, d.hasPending = float32(x1)/(1<<15-1), true
+			}
+		}
+	}
+
+	decodeBits() // codewords left over from the previous read
+	for _, b := range d.buf[:n] {
+		d.bits |= uint32(b) << d.nbits
+		d.nbits += 8
+		decodeBits()
+	}
+
+	if i > 0 && err == io.EOF {
+		err = nil
+	}
+	return i, err
+}
@@ -0,0 +1,179 @@
+package g722
+
+import (
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+)
+
+var (
+	q6 = [32]int{
+		0, 35, 72, 110, 150, 190, 233, 276,
+		323, 370, 422, 473, 530, 587, 650, 714,
+		786, 858, 940, 1023, 1121, 1219, 1339, 1458,
+		1612, 1765, 1980, 2195, 2557, 2919, 0, 0,
+	}
+	iln = [32]int{
+		0, 63, 62, 31, 30, 29, 28, 27,
+		26, 25, 24, 23, 22, 21, 20, 19,
+		18, 17, 16, 15, 14, 13, 12, 11,
+		10, 9, 8, 7, 6, 5, 4, 0,
+	}
+	ilp = [32]int{
+		0, 61, 60, 59, 58, 57, 56, 55,
+		54, 53, 52, 51, 50, 49, 48, 47,
+		46, 45, 44, 43, 42, 41, 40, 39,
+		38, 37, 36, 35, 34, 33, 32, 0,
+	}
+	ihn = [3]int{0, 1, 0}
+	ihp = [3]int{0, 3, 2}
+)
+
+// encode encodes two 16 kHz samples into an 8-bit codeword.
+func (s *state) encode(x0, x1 int) int {
+	// transmit QMF
+	copy(s.x[:], s.x[2:])
+	s.x[22] = x0
+	s.x[23] = x1
+	var sumEven, sumOdd int
+	for i := range 12 {
+		sumOdd += s.x[2*i] * qmfCoeffs[i]
+		sumEven += s.x[2*i+1] * qmfCoeffs[11-i]
+	}
+	xlow := (sumEven + sumOdd) >> 14
+	xhigh := (sumEven - sumOdd) >> 14
+
+	// lower band: SUBTRA, QUANTL
+	lo := &s.band[0]
+	el := saturate(xlow - lo.s)
+	wd := el
+	if el < 0 {
+		wd = -(el + 1)
+	}
+	i := 1
+	for ; i < 30; i++ {
+		if wd < q6[i]*lo.det>>12 {
+			break
+		}
+	}
+	ilow := ilp[i]
+	if el < 0 {
+		ilow = iln[i]
+	}
+
+	// INVQAL
+	ril := ilow >> 2
+	dlow := lo.det * qm4[ril] >> 15
+
+	lo.scaleLow(ril)
+	lo.update(dlow)
+
+	// higher band: SUBTRA, QUANTH
+	hi := &s.band[1]
+	eh := saturate(xhigh - hi.s)
+	wd = eh
+	if eh < 0 {
+		wd = -(eh + 1)
+	}
+	mih := 1
+	if wd >= 564*hi.det>>12 {
+		mih = 2
+	}
+	ihigh := ihp[mih]
+	if eh < 0 {
+		ihigh = ihn[mih]
+	}
+
+	// INVQAH
+	dhigh := hi.det * qm2[ihigh] >> 15
+
+	hi.scaleHigh(ihigh)
+	hi.update(dhigh)
+
+	return ihigh<<6 | ilow
+}
+
+type encoder struct {
+	w          io.Writer
+	buf        []byte
+	state      state
+	bps        int    // bits per codeword
+	pending    int    // unpaired sample from the previous write
+	hasPending bool   // whether pending is valid
+	bits       uint32 // pending codeword bits
+	nbits      int    // number of pending codeword bits
+	err        error
+}
+
+// NewEncoder returns an aio.SampleWriteCloser that encodes and writes G.722 codewords to the provided [io.Writer].
+// The samples are expected to be 16 kHz mono.
+//
+// Close must be called to flush the last partial codeword or byte. It will NOT close the underlying writer.
+func NewEncoder(w io.Writer, opts ...Option) aio.SampleWriteCloser {
+	o := applyOptions(opts)
+	return &encoder{
+		w:     w,
+		state: newState(),
+		bps:   o.bitsPerSample,
+		err:   o.err,
+	}
+}
+
+func toInt16(s float32) int {
+	return int(int16(dsp.Clamp(s) * (1<<15 - 1)))
+}
+
+func (e *encoder) WriteSamples(p []float32) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+
+	e.buf = e.buf[:0]
+	for _, s := range p {
+		if !e.hasPending {
+			e.pending, e.hasPending = toInt16(s), true
+			continue
+		}
+		e.hasPending = false
+		e.put(e.state.encode(e.pending, toInt16(s)))
+	}
+
+	if _, err := e.w.Write(e.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// put appends a codeword to the buffer, dropping the least significant bits of the lower band in the 56 and 48 kbit/s modes.
+func (e *encoder) put(code int) {
+	e.bits |= uint32(code>>(8-e.bps)) << e.nbits
+	e.nbits += e.bps
+	for e.nbits >= 8 {
+		e.buf = append(e.buf, byte(e.bits))
+		e.bits >>= 8
+		e.nbits -= 8
+	}
+}
+
+// Close encodes the last unpaired sample, padded with a zero sample, and flushes the last partial byte, padded with zero bits.
+func (e *encoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+
+	e.buf = e.buf[:0]
+	if e.hasPending {
+		e.hasPending = false
+		e.put(e.state.encode(e.pending, 0))
+	}
+	if e.nbits > 0 {
+		e.buf = append(e.buf, byte(e.bits))
+		e.bits, e.nbits = 0, 0
+	}
+	if len(e.buf) == 0 {
+		return nil
+	}
+	_, err := e.w.Write(e.buf)
+	return err
+}
@@ -0,0 +1,41 @@
+package g722_test
+
+import (
+	"os"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/encoding/g722"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// This example converts a 16 kHz mono WAV file to raw G.722.
+func Example() {
+	in, err := os.Open("input.wav")
+	if err != nil {
+		panic(err)
+	}
+	defer in.Close()
+
+	dec, err := wav.NewDecoder(in)
+	if err != nil {
+		panic(err)
+	}
+	if f := dec.Format(); f.SampleRate != 16*freq.KiloHertz || f.NumChannels != 1 {
+		panic("G.722 expects 16 kHz mono audio")
+	}
+
+	out, err := os.Create("output.g722")
+	if err != nil {
+		panic(err)
+	}
+	defer out.Close()
+
+	enc := g722.NewEncoder(out)
+	if _, err := aio.Copy(enc, dec); err != nil {
+		panic(err)
+	}
+	if err := enc.Close(); err != nil {
+		panic(err)
+	}
+}
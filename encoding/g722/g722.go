@@ -0,0 +1,245 @@
+// Package g722 implements the G.722 wideband audio codec, which is used by SIP phones and DECT.
+//
+// G.722 codes 16 kHz mono audio by splitting it into two sub-bands with a QMF filter bank
+// and coding each sub-band with ADPCM: 6 bits per sample for the lower band and 2 bits per sample for the higher band.
+// The encoder and decoder expect 16 kHz mono samples; other sample rates must be resampled first.
+//
+// Each pair of input samples becomes a single codeword. At 64 kbit/s (the default) a codeword is 8 bits,
+// while the 56 and 48 kbit/s modes drop 1 or 2 bits of the lower band, giving 7- or 6-bit codewords
+// that are packed least significant bits first.
+package g722
+
+import "fmt"
+
+// Original C implementation: spandsp g722.c by Steve Underwood, based on the CMU G.722 codec
+/*
+ * The ITU G.722 codec, encode part.
+ *
+ * Copyright (C) 2005 Steve Underwood
+ *
+ * Despite my general liking of the GPL, I place my own contributions
+ * to this code in the public domain for the benefit of all mankind -
+ * even the slimy ones who might try to proprietize my work and use it
+ * to my detriment.
+ *
+ * Based on a single channel 64kbps only G.722 codec which is:
+ *
+ *****    Copyright (c) CMU    1993      *****
+ * Computer Science, Speech Group
+ * Chengxiang Lu and Alex Hauptmann
+ */
+
+// Bit rates.
+const (
+	Bitrate64k = 64000
+	Bitrate56k = 56000
+	Bitrate48k = 48000
+)
+
+// Option configures an encoder or decoder.
+type Option func(*options)
+
+type options struct {
+	bitsPerSample int
+	err           error
+}
+
+// WithBitrate sets the bit rate in bits per second: [Bitrate64k] (default), [Bitrate56k] or [Bitrate48k].
+// The decoder must use the same bit rate as the encoder.
+func WithBitrate(bitrate int) Option {
+	return func(o *options) {
+		switch bitrate {
+		case Bitrate64k:
+			o.bitsPerSample = 8
+		case Bitrate56k:
+			o.bitsPerSample = 7
+		case Bitrate48k:
+			o.bitsPerSample = 6
+		default:
+			o.err = fmt.Errorf("g722: unsupported bit rate: %d", bitrate)
+		}
+	}
+}
+
+func applyOptions(opts []Option) options {
+	o := options{bitsPerSample: 8}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+var (
+	wl   = [8]int{-60, -30, 58, 172, 334, 538, 1198, 3042}
+	rl42 = [16]int{0, 7, 6, 5, 4, 3, 2, 1, 7, 6, 5, 4, 3, 2, 1, 0}
+	ilb  = [32]int{
+		2048, 2093, 2139, 2186, 2233, 2282, 2332, 2383,
+		2435, 2489, 2543, 2599, 2656, 2714, 2774, 2834,
+		2896, 2960, 3025, 3091, 3158, 3228, 3298, 3371,
+		3444, 3520, 3597, 3676, 3756, 3838, 3922, 4008,
+	}
+	wh  = [3]int{0, -214, 798}
+	rh2 = [4]int{2, 1, 2, 1}
+	qm2 = [4]int{-7408, -1616, 7408, 1616}
+	qm4 = [16]int{
+		0, -20456, -12896, -8968, -6288, -4240, -2584, -1200,
+		20456, 12896, 8968, 6288, 4240, 2584, 1200, 0,
+	}
+	qm5 = [32]int{
+		-280, -280, -23352, -17560, -14120, -11664, -9752, -8184,
+		-6864, -5712, -4696, -3784, -2960, -2208, -1520, -880,
+		23352, 17560, 14120, 11664, 9752, 8184, 6864, 5712,
+		4696, 3784, 2960, 2208, 1520, 880, 280, -280,
+	}
+	qm6 = [64]int{
+		-136, -136, -136, -136, -24808, -21904, -19008, -16704,
+		-14984, -13512, -12280, -11192, -10232, -9360, -8576, -7856,
+		-7192, -6576, -6000, -5456, -4944, -4464, -4008, -3576,
+		-3168, -2776, -2400, -2032, -1688, -1360, -1040, -728,
+		24808, 21904, 19008, 16704, 14984, 13512, 12280, 11192,
+		10232, 9360, 8576, 7856, 7192, 6576, 6000, 5456,
+		4944, 4464, 4008, 3576, 3168, 2776, 2400, 2032,
+		1688, 1360, 1040, 728, 432, 136, -432, -136,
+	}
+	qmfCoeffs = [12]int{3, -11, 12, 32, -210, 951, 3876, -805, 362, -156, 53, -11}
+)
+
+func saturate(amp int) int {
+	return min(max(amp, -32768), 32767)
+}
+
+// band holds the ADPCM state of a sub-band.
+type band struct {
+	s, sp, sz int
+	r         [3]int
+	a, ap     [3]int
+	p         [3]int
+	d         [7]int
+	b, bp     [7]int
+	sg        [7]int
+	nb        int
+	det       int
+}
+
+// scale updates the logarithmic scale factor nb with the increment wd and computes the step size det.
+func (b *band) scale(wd, maxNb, shift int) {
+	b.nb = min(max(b.nb*127>>7+wd, 0), maxNb)
+
+	wd1 := (b.nb >> 6) & 31
+	wd2 := shift - (b.nb >> 11)
+	var wd3 int
+	if wd2 < 0 {
+		wd3 = ilb[wd1] << -wd2
+	} else {
+		wd3 = ilb[wd1] >> wd2
+	}
+	b.det = wd3 << 2
+}
+
+// scaleLow updates the step size of the lower band from a 4-bit codeword.
+func (b *band) scaleLow(ril int) {
+	b.scale(wl[rl42[ril]], 18432, 8)
+}
+
+// scaleHigh updates the step size of the higher band from a 2-bit codeword.
+func (b *band) scaleHigh(ihigh int) {
+	b.scale(wh[rh2[ihigh]], 22528, 10)
+}
+
+// update updates the predictor with the quantized difference signal d (block 4).
+func (b *band) update(d int) {
+	// RECONS
+	b.d[0] = d
+	b.r[0] = saturate(b.s + d)
+
+	// PARREC
+	b.p[0] = saturate(b.sz + d)
+
+	// UPPOL2
+	for i := range 3 {
+		b.sg[i] = b.p[i] >> 15
+	}
+	wd1 := saturate(b.a[1] << 2)
+	wd2 := wd1
+	if b.sg[0] == b.sg[1] {
+		wd2 = -wd1
+	}
+	wd2 = min(wd2, 32767)
+	wd3 := -128
+	if b.sg[0] == b.sg[2] {
+		wd3 = 128
+	}
+	wd3 += wd2 >> 7
+	wd3 += b.a[2] * 32512 >> 15
+	b.ap[2] = min(max(wd3, -12288), 12288)
+
+	// UPPOL1
+	b.sg[0] = b.p[0] >> 15
+	b.sg[1] = b.p[1] >> 15
+	wd1 = -192
+	if b.sg[0] == b.sg[1] {
+		wd1 = 192
+	}
+	wd2 = b.a[1] * 32640 >> 15
+	b.ap[1] = saturate(wd1 + wd2)
+	wd3 = saturate(15360 - b.ap[2])
+	b.ap[1] = min(max(b.ap[1], -wd3), wd3)
+
+	// UPZERO
+	wd1 = 128
+	if d == 0 {
+		wd1 = 0
+	}
+	b.sg[0] = d >> 15
+	for i := 1; i < 7; i++ {
+		b.sg[i] = b.d[i] >> 15
+		wd2 = -wd1
+		if b.sg[i] == b.sg[0] {
+			wd2 = wd1
+		}
+		wd3 = b.b[i] * 32640 >> 15
+		b.bp[i] = saturate(wd2 + wd3)
+	}
+
+	// DELAYA
+	for i := 6; i > 0; i-- {
+		b.d[i] = b.d[i-1]
+		b.b[i] = b.bp[i]
+	}
+	for i := 2; i > 0; i-- {
+		b.r[i] = b.r[i-1]
+		b.p[i] = b.p[i-1]
+		b.a[i] = b.ap[i]
+	}
+
+	// FILTEP
+	wd1 = saturate(b.r[1] + b.r[1])
+	wd1 = b.a[1] * wd1 >> 15
+	wd2 = saturate(b.r[2] + b.r[2])
+	wd2 = b.a[2] * wd2 >> 15
+	b.sp = saturate(wd1 + wd2)
+
+	// FILTEZ
+	b.sz = 0
+	for i := 6; i > 0; i-- {
+		wd1 = saturate(b.d[i] + b.d[i])
+		b.sz += b.b[i] * wd1 >> 15
+	}
+	b.sz = saturate(b.sz)
+
+	// PREDIC
+	b.s = saturate(b.sp + b.sz)
+}
+
+// state holds the state of a G.722 encoder or decoder.
+type state struct {
+	x    [24]int // QMF delay line
+	band [2]band
+}
+
+func newState() state {
+	var s state
+	s.band[0].det = 32
+	s.band[1].det = 8
+	return s
+}
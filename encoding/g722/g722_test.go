@@ -0,0 +1,188 @@
+package g722_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/MatusOllah/resona/encoding/g722"
+)
+
+func encode(t *testing.T, samples []float32, opts ...g722.Option) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := g722.NewEncoder(&buf, opts...)
+	if _, err := enc.WriteSamples(samples); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decode(t *testing.T, b []byte, opts ...g722.Option) []float32 {
+	t.Helper()
+	dec := g722.NewDecoder(bytes.NewReader(b), opts...)
+	var out []float32
+	buf := make([]float32, 127) // odd size, so codewords are split across reads
+	for {
+		n, err := dec.ReadSamples(buf)
+		out = append(out, buf[:n]...)
+		if errors.Is(err, io.EOF) || (err == nil && n == 0) {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// snr returns the signal-to-noise ratio of x against ref, compensating for the codec delay.
+func snr(ref, x []float32, delay int) float64 {
+	var sig, noise float64
+	for i := range ref[:len(ref)-delay] {
+		sig += float64(ref[i]) * float64(ref[i])
+		d := float64(ref[i]) - float64(x[i+delay])
+		noise += d * d
+	}
+	return 10 * math.Log10(sig/noise)
+}
+
+func sine(n int, f float64) []float32 {
+	s := make([]float32, n)
+	for i := range s {
+		s[i] = float32(0.5 * math.Sin(2*math.Pi*f*float64(i)/16000))
+	}
+	return s
+}
+
+// The QMF filter banks of the encoder and decoder delay the signal by 22 samples.
+const delay = 22
+
+func TestSilence(t *testing.T) {
+	// From the initial state, silence quantizes to the lower band codeword 58 and the higher band codeword 3.
+	b := encode(t, make([]float32, 2))
+	if len(b) != 1 || b[0] != 0xFA {
+		t.Errorf("expected fa, got %x", b)
+	}
+
+	for i, s := range decode(t, encode(t, make([]float32, 1600))) {
+		if math.Abs(float64(s)) > 0.001 {
+			t.Fatalf("sample %d: expected silence, got %f", i, s)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		bitrate int
+		minSNR  float64
+	}{
+		{g722.Bitrate64k, 45},
+		{g722.Bitrate56k, 40},
+		{g722.Bitrate48k, 35},
+	}
+
+	for _, f := range []float64{440, 1000, 6000} {
+		samples := sine(16000, f)
+		for _, tt := range tests {
+			b := encode(t, samples, g722.WithBitrate(tt.bitrate))
+			if want := len(samples) / 2 * tt.bitrate / 64000; len(b) != want {
+				t.Fatalf("%d bit/s: expected %d bytes, got %d", tt.bitrate, want, len(b))
+			}
+
+			decoded := decode(t, b, g722.WithBitrate(tt.bitrate))
+			if len(decoded) != len(samples) {
+				t.Fatalf("%d bit/s: expected %d samples, got %d", tt.bitrate, len(samples), len(decoded))
+			}
+			minSNR := tt.minSNR
+			if f > 4000 {
+				minSNR = 25 // the higher band is always coded with 2 bits
+			}
+			// skip the initial adaptation
+			if got := snr(samples[1600:], decoded[1600:], delay); got < minSNR {
+				t.Errorf("%v Hz at %d bit/s: SNR too low: %.2f dB", f, tt.bitrate, got)
+			}
+		}
+	}
+}
+
+// readInt16s reads a file of little-endian 16-bit samples.
+func readInt16s(t *testing.T, name string) []int16 {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := make([]int16, len(b)/2)
+	for i := range s {
+		s[i] = int16(binary.LittleEndian.Uint16(b[2*i:]))
+	}
+	return s
+}
+
+// The golden data in testdata is generated by testdata/generate.py with a reference coder written after
+// the block diagrams of ITU-T G.722, which codes 14-bit samples. The input samples are multiples of 4,
+// and the 14 most significant bits of the decoded samples are compared to the 14-bit reference output.
+func TestGolden(t *testing.T) {
+	input := readInt16s(t, "testdata/input.pcm")
+	samples := make([]float32, len(input))
+	for i, x := range input {
+		samples[i] = float32(x) / (1<<15 - 1)
+	}
+
+	for _, bitrate := range []int{g722.Bitrate64k, g722.Bitrate56k, g722.Bitrate48k} {
+		t.Run(strconv.Itoa(bitrate/1000)+"k", func(t *testing.T) {
+			name := "testdata/" + strconv.Itoa(bitrate/1000)
+			codes, err := os.ReadFile(name + ".g722")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := encode(t, samples, g722.WithBitrate(bitrate)); !bytes.Equal(got, codes) {
+				i := 0
+				for i < min(len(got), len(codes)) && got[i] == codes[i] {
+					i++
+				}
+				t.Errorf("encoded data differs from byte %d of %d", i, len(codes))
+			}
+
+			want := readInt16s(t, name+".pcm")
+			got := decode(t, codes, g722.WithBitrate(bitrate))
+			if len(got) != len(want) {
+				t.Fatalf("expected %d samples, got %d", len(want), len(got))
+			}
+			for i := range want {
+				if x := int(math.Round(float64(got[i])*(1<<15-1))) >> 2; x != int(want[i]) {
+					t.Fatalf("sample %d: expected %d, got %d", i, want[i], x)
+				}
+			}
+		})
+	}
+}
+
+func TestOddLength(t *testing.T) {
+	b := encode(t, sine(101, 440))
+	if len(b) != 51 {
+		t.Fatalf("expected 51 bytes, got %d", len(b))
+	}
+	// the padding sample decodes to one extra sample
+	if got := len(decode(t, b)); got != 102 {
+		t.Errorf("expected 102 samples, got %d", got)
+	}
+}
+
+func TestInvalidBitrate(t *testing.T) {
+	if _, err := g722.NewEncoder(io.Discard, g722.WithBitrate(32000)).WriteSamples(make([]float32, 2)); err == nil {
+		t.Error("expected encoder error")
+	}
+	if _, err := g722.NewDecoder(bytes.NewReader([]byte{0}), g722.WithBitrate(32000)).ReadSamples(make([]float32, 2)); err == nil {
+		t.Error("expected decoder error")
+	}
+}
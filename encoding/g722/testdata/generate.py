@@ -0,0 +1,282 @@
+#!/usr/bin/env python3
+
+"""
+Generates golden G.722 test data with a reference coder written after the block diagrams of ITU-T G.722
+(11/88), independently of the Go code. Every block works on words of the length given in the Recommendation,
+saturating 16-bit additions, so that the golden data pins the fixed-point behavior of the standard.
+Do not edit the output files manually!
+
+The Recommendation codes 14-bit uniform PCM. The 16-bit input samples are multiples of 4, so that their
+14 most significant bits are the coder input without rounding, and the output is 14-bit.
+
+Outputs, all little-endian:
+  input.pcm   16 kHz 16-bit input samples
+  64.g722     the input encoded at 64 kbit/s, one 8-bit codeword (IH, IL) per pair of samples
+  56.g722     the same codewords without the lowest bit of IL, 7-bit codewords packed least significant bits first
+  48.g722     the same codewords without the 2 lowest bits of IL, 6-bit codewords packed least significant bits first
+  <rate>.pcm  each file decoded in its mode into 14-bit output samples
+"""
+
+import math
+import struct
+
+# QMF coefficients h(0)-h(23), in units of 2^-13
+H = [3, -11, -11, 53, 12, -156, 32, 362, -210, -805, 951, 3876,
+     3876, 951, -805, -210, 362, 32, -156, 12, 53, -11, -11, 3]
+
+# QUANTL decision levels, in units of 2^-12 of DETL
+Q6 = [0, 35, 72, 110, 150, 190, 233, 276, 323, 370, 422, 473, 530, 587, 650, 714,
+      786, 858, 940, 1023, 1121, 1219, 1339, 1458, 1612, 1765, 1980, 2195, 2557, 2919]
+# QUANTL codewords of the intervals 1-30, for negative and positive differences
+ILN = [None, 63, 62, 31, 30, 29, 28, 27, 26, 25, 24, 23, 22, 21, 20, 19,
+       18, 17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4]
+ILP = [None, 61, 60, 59, 58, 57, 56, 55, 54, 53, 52, 51, 50, 49, 48, 47,
+       46, 45, 44, 43, 42, 41, 40, 39, 38, 37, 36, 35, 34, 33, 32]
+
+# inverse quantizer outputs, indexed by codeword, in units of 2^-15 of DETL or DETH
+QM2 = [-7408, -1616, 7408, 1616]
+QM4 = [0, -20456, -12896, -8968, -6288, -4240, -2584, -1200,
+       20456, 12896, 8968, 6288, 4240, 2584, 1200, 0]
+QM5 = [-280, -280, -23352, -17560, -14120, -11664, -9752, -8184,
+       -6864, -5712, -4696, -3784, -2960, -2208, -1520, -880,
+       23352, 17560, 14120, 11664, 9752, 8184, 6864, 5712,
+       4696, 3784, 2960, 2208, 1520, 880, 280, -280]
+QM6 = [-136, -136, -136, -136, -24808, -21904, -19008, -16704,
+       -14984, -13512, -12280, -11192, -10232, -9360, -8576, -7856,
+       -7192, -6576, -6000, -5456, -4944, -4464, -4008, -3576,
+       -3168, -2776, -2400, -2032, -1688, -1360, -1040, -728,
+       24808, 21904, 19008, 16704, 14984, 13512, 12280, 11192,
+       10232, 9360, 8576, 7856, 7192, 6576, 6000, 5456,
+       4944, 4464, 4008, 3576, 3168, 2776, 2400, 2032,
+       1688, 1360, 1040, 728, 432, 136, -432, -136]
+
+# LOGSCL and LOGSCH: magnitude of the 4-bit (lower band) or 2-bit (higher band) codeword and log scale factor multipliers
+RIL4 = [0, 7, 6, 5, 4, 3, 2, 1, 7, 6, 5, 4, 3, 2, 1, 0]
+WL = [-60, -30, 58, 172, 334, 538, 1198, 3042]
+RIH2 = [2, 1, 2, 1]
+WH = [0, -214, 798]
+
+# SCALEL and SCALEH: antilog table
+ILB = [2048, 2093, 2139, 2186, 2233, 2282, 2332, 2383, 2435, 2489, 2543, 2599, 2656, 2714, 2774, 2834,
+       2896, 2960, 3025, 3091, 3158, 3228, 3298, 3371, 3444, 3520, 3597, 3676, 3756, 3838, 3922, 4008]
+
+
+def sat(x: int) -> int:
+    """Limits x to a 16-bit two's complement word."""
+    return max(-32768, min(32767, x))
+
+
+def sgn(x: int) -> int:
+    return 1 if x < 0 else 0
+
+
+class Band:
+    """The ADPCM state of a sub-band: the adaptive predictor and the scale factor."""
+
+    def __init__(self, det: int, nb_max: int, shift: int):
+        self.nb_max, self.shift = nb_max, shift
+        self.nb = 0
+        self.det = det
+        self.a = [0, 0, 0]  # A1, A2 (index 0 unused)
+        self.b = [0] * 7  # B1-B6 (index 0 unused)
+        self.dlt = [0] * 7  # DLT, DLT1-DLT6
+        self.p = [0] * 3  # PLT, PLT1, PLT2
+        self.r = [0] * 3  # RLT, RLT1, RLT2
+        self.sp = self.sz = self.s = 0
+
+    def logscl(self, w: int):
+        # LOGSCL/LOGSCH, SCALEL/SCALEH
+        nb = ((self.nb * 32512) >> 15) + w
+        self.nb = max(0, min(self.nb_max, nb))
+        wd1 = (self.nb >> 6) & 31
+        wd2 = self.nb >> 11
+        sh = self.shift - wd2
+        wd3 = ILB[wd1] >> sh if sh >= 0 else ILB[wd1] << -sh
+        self.det = wd3 << 2
+
+    def adapt(self, dlt: int):
+        # RECONS, PARREC
+        self.dlt[0] = dlt
+        self.r[0] = sat(self.s + dlt)
+        self.p[0] = sat(dlt + self.sz)
+
+        # UPPOL2
+        sg0, sg1, sg2 = sgn(self.p[0]), sgn(self.p[1]), sgn(self.p[2])
+        wd1 = sat(self.a[1] * 4)
+        wd2 = -wd1 if sg0 == sg1 else wd1
+        wd2 = min(wd2, 32767) >> 7
+        wd3 = 128 if sg0 == sg2 else -128
+        wd5 = (self.a[2] * 32512) >> 15
+        apl2 = max(-12288, min(12288, sat(sat(wd2 + wd3) + wd5)))
+
+        # UPPOL1
+        wd1 = 192 if sg0 == sg1 else -192
+        wd2 = (self.a[1] * 32640) >> 15
+        apl1 = sat(wd1 + wd2)
+        wd3 = sat(15360 - apl2)
+        apl1 = max(-wd3, min(wd3, apl1))
+
+        # UPZERO
+        wd1 = 0 if dlt == 0 else 128
+        bp = [0] * 7
+        for i in range(1, 7):
+            wd2 = wd1 if sgn(dlt) == sgn(self.dlt[i]) else -wd1
+            wd3 = (self.b[i] * 32640) >> 15
+            bp[i] = sat(wd2 + wd3)
+
+        # DELAYA
+        self.dlt = [0] + self.dlt[:6]
+        self.b = bp
+        self.r = [0] + self.r[:2]
+        self.p = [0] + self.p[:2]
+        self.a = [0, apl1, apl2]
+
+        # FILTEP
+        wd1 = (self.a[1] * sat(self.r[1] + self.r[1])) >> 15
+        wd2 = (self.a[2] * sat(self.r[2] + self.r[2])) >> 15
+        self.sp = sat(wd1 + wd2)
+
+        # FILTEZ
+        sz = 0
+        for i in range(1, 7):
+            sz = sat(sz + ((self.b[i] * sat(self.dlt[i] + self.dlt[i])) >> 15))
+        self.sz = sz
+
+        # PREDIC
+        self.s = sat(self.sp + self.sz)
+
+
+class Encoder:
+    def __init__(self):
+        self.x = [0] * 24  # xin(j) ... xin(j-23)
+        self.low = Band(32, 18432, 8)
+        self.high = Band(8, 22528, 10)
+
+    def encode(self, x0: int, x1: int) -> int:
+        """Encodes two 14-bit samples into an 8-bit codeword."""
+        # transmit QMF
+        self.x = [x1, x0] + self.x[:22]
+        even = sum(H[2 * i] * self.x[2 * i] for i in range(12))
+        odd = sum(H[2 * i + 1] * self.x[2 * i + 1] for i in range(12))
+        xl = sat((even + odd) >> 12)
+        xh = sat((even - odd) >> 12)
+
+        # lower band: SUBTRA, QUANTL, INVQAL
+        lo = self.low
+        el = sat(xl - lo.s)
+        wd = el if el >= 0 else -(el + 1)
+        mil = 30
+        for m in range(1, 30):
+            if wd < (Q6[m] * lo.det) >> 12:
+                mil = m
+                break
+        il = ILN[mil] if el < 0 else ILP[mil]
+        ril = il >> 2
+        dlt = (lo.det * QM4[ril]) >> 15
+        lo.logscl(WL[RIL4[ril]])
+        lo.adapt(dlt)
+
+        # higher band: SUBTRA, QUANTH, INVQAH
+        hi = self.high
+        eh = sat(xh - hi.s)
+        wd = eh if eh >= 0 else -(eh + 1)
+        mih = 2 if wd >= (564 * hi.det) >> 12 else 1
+        ih = [None, 1, 0][mih] if eh < 0 else [None, 3, 2][mih]
+        dh = (hi.det * QM2[ih]) >> 15
+        hi.logscl(WH[RIH2[ih]])
+        hi.adapt(dh)
+
+        return ih << 6 | il
+
+
+class Decoder:
+    def __init__(self, mode: int):
+        self.mode = mode  # 1: 64 kbit/s, 2: 56 kbit/s, 3: 48 kbit/s
+        self.xd = [0] * 12
+        self.xs = [0] * 12
+        self.low = Band(32, 18432, 8)
+        self.high = Band(8, 22528, 10)
+
+    def decode(self, ilr: int, ih: int) -> tuple[int, int]:
+        """Decodes the received lower band codeword ilr (6, 5 or 4 bits) and higher band codeword ih into two 14-bit samples."""
+        # lower band: INVQBL, RECONS, LIMIT
+        lo = self.low
+        qm = {1: QM6, 2: QM5, 3: QM4}[self.mode]
+        dl = (lo.det * qm[ilr]) >> 15
+        rl = max(-16384, min(16383, sat(lo.s + dl)))
+
+        # INVQAL with the 4 most significant bits
+        ril = ilr >> (3 - self.mode)
+        dlt = (lo.det * QM4[ril]) >> 15
+        lo.logscl(WL[RIL4[ril]])
+        lo.adapt(dlt)
+
+        # higher band: INVQAH, RECONS, LIMIT
+        hi = self.high
+        dh = (hi.det * QM2[ih]) >> 15
+        rh = max(-16384, min(16383, sat(hi.s + dh)))
+        hi.logscl(WH[RIH2[ih]])
+        hi.adapt(dh)
+
+        # receive QMF
+        self.xd = [rl - rh] + self.xd[:11]
+        self.xs = [rl + rh] + self.xs[:11]
+        xout1 = sum(H[2 * i] * self.xd[i] for i in range(12)) >> 13
+        xout2 = sum(H[2 * i + 1] * self.xs[i] for i in range(12)) >> 13
+        return max(-8192, min(8191, xout1)), max(-8192, min(8191, xout2))
+
+
+def signal(n: int) -> list[int]:
+    """Returns 14-bit samples of a sweep over both sub-bands with noise, a near full-scale burst and silence."""
+    seed = 7
+    out = []
+    for k in range(n):
+        seed = (seed * 1103515245 + 12345) & 0x7FFFFFFF
+        noise = ((seed >> 16) - 16384) / 16384
+        t = k / 16000
+        x = 3000 * math.sin(2 * math.pi * (100 + 3500 * t) * t) + 1500 * math.sin(2 * math.pi * 5500 * t) + 200 * noise
+        if 4000 <= k < 4600:
+            x = 7000 * math.sin(2 * math.pi * 440 * t) + 3000 * noise
+        elif k >= n - 400:
+            x = 0
+        out.append(max(-8192, min(8191, int(x))))
+    return out
+
+
+def pack(codes: list[int], bits: int) -> bytes:
+    """Packs codewords least significant bits first."""
+    out = bytearray()
+    acc = nacc = 0
+    for c in codes:
+        acc |= c << nacc
+        nacc += bits
+        while nacc >= 8:
+            out.append(acc & 0xFF)
+            acc >>= 8
+            nacc -= 8
+    assert nacc == 0
+    return bytes(out)
+
+
+def main():
+    x = signal(6400)
+    with open("input.pcm", "wb") as f:
+        f.write(struct.pack(f"<{len(x)}h", *(4 * v for v in x)))
+
+    enc = Encoder()
+    codes = [enc.encode(x[k], x[k + 1]) for k in range(0, len(x), 2)]
+
+    for rate, mode in [(64, 1), (56, 2), (48, 3)]:
+        print(f"[*] Generating {rate} kbit/s")
+        drop = mode - 1
+        dec = Decoder(mode)
+        out = []
+        for c in codes:
+            out.extend(dec.decode((c & 0x3F) >> drop, c >> 6))
+        with open(f"{rate}.g722", "wb") as f:
+            f.write(pack([c >> drop for c in codes], 8 - drop))
+        with open(f"{rate}.pcm", "wb") as f:
+            f.write(struct.pack(f"<{len(out)}h", *out))
+
+
+if __name__ == "__main__": main()
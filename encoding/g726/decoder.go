@@ -0,0 +1,93 @@
+package g726
+
+import (
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+)
+
+type decoder struct {
+	r       io.Reader
+	buf     []byte
+	mode    *mode
+	packing Packing
+	state   []state
+	ch      int    // channel of the next sample
+	bits    uint32 // unread codeword bits
+	nbits   int    // number of unread codeword bits
+	err     error
+}
+
+// NewDecoder returns an aio.SampleReader that reads and decodes G.726 samples from the provided [io.Reader].
+// Samples of numChannels channels are expected to be interleaved, each channel having its own decoder state.
+// Unless configured otherwise with opts, the bit rate is 32 kbit/s (G.721) and codewords are packed least significant bits first.
+func NewDecoder(r io.Reader, numChannels int, opts ...Option) aio.SampleReader {
+	o := applyOptions(opts)
+	d := &decoder{
+		r:       r,
+		mode:    o.mode,
+		packing: o.packing,
+		state:   make([]state, max(numChannels, 1)),
+		err:     o.err,
+	}
+	for i := range d.state {
+		d.state[i] = newState()
+	}
+	return d
+}
+
+func (d *decoder) ReadSamples(p []float32) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	codeSize := d.mode.codeSize
+	numBytes := max((len(p)*codeSize-d.nbits+7)/8, 0)
+	if cap(d.buf) < numBytes {
+		d.buf = make([]byte, numBytes)
+	} else {
+		d.buf = d.buf[:numBytes]
+	}
+
+	n, err := d.r.Read(d.buf)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	i := 0
+	decodeBits := func() {
+		for d.nbits >= codeSize && i < len(p) {
+			var code int
+			d.nbits -= codeSize
+			if d.packing == PackMSBFirst {
+				code = int(d.bits>>d.nbits) & (1<<codeSize - 1)
+				d.bits &= 1<<d.nbits - 1
+			} else {
+				code = int(d.bits & (1<<codeSize - 1))
+				d.bits >>= codeSize
+			}
+
+			sr := d.state[d.ch].decode(d.mode, code)
+			p[i] = dsp.Clamp(float32(sr<<2) / (1<<15 - 1))
+			i++
+			d.ch = (d.ch + 1) % len(d.state)
+		}
+	}
+
+	decodeBits() // codewords left over from the previous read
+	for _, b := range d.buf[:n] {
+		if d.packing == PackMSBFirst {
+			d.bits = d.bits<<8 | uint32(b)
+		} else {
+			d.bits |= uint32(b) << d.nbits
+		}
+		d.nbits += 8
+		decodeBits()
+	}
+
+	return i, err
+}
@@ -0,0 +1,93 @@
+package g726
+
+import (
+	"io"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp"
+)
+
+type encoder struct {
+	w       io.Writer
+	buf     []byte
+	mode    *mode
+	packing Packing
+	state   []state
+	ch      int    // channel of the next sample
+	bits    uint32 // pending codeword bits
+	nbits   int    // number of pending codeword bits
+	err     error
+}
+
+// NewEncoder returns an aio.SampleWriteCloser that encodes and writes G.726 samples to the provided [io.Writer].
+// Samples of numChannels channels are expected to be interleaved, each channel having its own encoder state.
+// Unless configured otherwise with opts, the bit rate is 32 kbit/s (G.721) and codewords are packed least significant bits first.
+//
+// Close must be called to flush the last partial byte. It will NOT close the underlying writer.
+func NewEncoder(w io.Writer, numChannels int, opts ...Option) aio.SampleWriteCloser {
+	o := applyOptions(opts)
+	e := &encoder{
+		w:       w,
+		mode:    o.mode,
+		packing: o.packing,
+		state:   make([]state, max(numChannels, 1)),
+		err:     o.err,
+	}
+	for i := range e.state {
+		e.state[i] = newState()
+	}
+	return e
+}
+
+func (e *encoder) WriteSamples(p []float32) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+
+	codeSize := e.mode.codeSize
+	e.buf = e.buf[:0]
+	for _, s := range p {
+		sl := int(int16(dsp.Clamp(s)*(1<<15-1))) >> 2 // 14-bit dynamic range
+		code := e.state[e.ch].encode(e.mode, sl)
+		e.ch = (e.ch + 1) % len(e.state)
+
+		if e.packing == PackMSBFirst {
+			e.bits = e.bits<<codeSize | uint32(code)
+		} else {
+			e.bits |= uint32(code) << e.nbits
+		}
+		e.nbits += codeSize
+		for e.nbits >= 8 {
+			e.nbits -= 8
+			if e.packing == PackMSBFirst {
+				e.buf = append(e.buf, byte(e.bits>>e.nbits))
+				e.bits &= 1<<e.nbits - 1
+			} else {
+				e.buf = append(e.buf, byte(e.bits))
+				e.bits >>= 8
+			}
+		}
+	}
+
+	if _, err := e.w.Write(e.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close flushes the last partial byte, padding it with zero bits.
+func (e *encoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.nbits == 0 {
+		return nil
+	}
+	b := byte(e.bits)
+	if e.packing == PackMSBFirst {
+		b = byte(e.bits << (8 - e.nbits))
+	}
+	e.bits, e.nbits = 0, 0
+	_, err := e.w.Write([]byte{b})
+	return err
+}
@@ -0,0 +1,483 @@
+// Package g726 implements the G.726 ADPCM audio codec, which is widely used in telephony systems.
+//
+// All four bit rates are supported: 16, 24, 32 and 40 kbit/s (2, 3, 4 and 5 bits per sample).
+// The 32 kbit/s mode, originally standardized as G.721, is the default; the 24 and 40 kbit/s modes were originally standardized as G.723.
+// By default, codewords are packed least significant bits first, as in RTP (RFC 3551) and Sun/NeXT AU files.
+package g726
+
+import "fmt"
+
+// Original C implementation: Sun Microsystems, Inc. G.721/G.723 reference implementation (g72x.c, g721.c, g723_24.c, g723_40.c)
+/*
+ * This source code is a product of Sun Microsystems, Inc. and is provided
+ * for unrestricted use.  Users may copy or modify this source code without
+ * charge.
+ */
+
+var power2 = [15]int{1, 2, 4, 8, 0x10, 0x20, 0x40, 0x80, 0x100, 0x200, 0x400, 0x800, 0x1000, 0x2000, 0x4000}
+
+// quan returns the index of the first table entry greater than val, or len(table).
+func quan(val int, table []int) int {
+	for i, v := range table {
+		if val < v {
+			return i
+		}
+	}
+	return len(table)
+}
+
+// fmult returns the integer product of the 14-bit integer an and the floating point representation (4-bit exponent, 6-bit mantissa) srn.
+func fmult(an, srn int) int {
+	anmag := an
+	if an <= 0 {
+		anmag = -an & 0x1FFF
+	}
+	anexp := quan(anmag, power2[:]) - 6
+	var anmant int
+	switch {
+	case anmag == 0:
+		anmant = 32
+	case anexp >= 0:
+		anmant = anmag >> anexp
+	default:
+		anmant = anmag << -anexp
+	}
+	wanexp := anexp + ((srn >> 6) & 0xF) - 13
+
+	wanmant := (anmant*(srn&0o77) + 0x30) >> 4
+	var retval int
+	if wanexp >= 0 {
+		retval = (wanmant << wanexp) & 0x7FFF
+	} else {
+		retval = wanmant >> -wanexp
+	}
+
+	if (an ^ srn) < 0 {
+		return -retval
+	}
+	return retval
+}
+
+// mode holds the quantization tables of a G.726 bit rate.
+type mode struct {
+	codeSize int   // bits per codeword
+	qtab     []int // quantizer decision levels
+	dqlntab  []int // log of the quantized difference signal magnitude
+	witab    []int // scale factor multipliers
+	fitab    []int // transition detection values
+}
+
+// mode16 is the 16 kbit/s mode.
+var mode16 = &mode{
+	codeSize: 2,
+	qtab:     []int{261},
+	dqlntab:  []int{116, 365, 365, 116},
+	witab:    []int{-22, 439, 439, -22},
+	fitab:    []int{0, 0xE00, 0xE00, 0},
+}
+
+// mode24 is the 24 kbit/s mode (G.723).
+var mode24 = &mode{
+	codeSize: 3,
+	qtab:     []int{8, 218, 331},
+	dqlntab:  []int{-2048, 135, 273, 373, 373, 273, 135, -2048},
+	witab:    []int{-4, 30, 137, 582, 582, 137, 30, -4},
+	fitab:    []int{0, 0x200, 0x400, 0xE00, 0xE00, 0x400, 0x200, 0},
+}
+
+// mode32 is the 32 kbit/s mode (G.721).
+var mode32 = &mode{
+	codeSize: 4,
+	qtab:     []int{-124, 80, 178, 246, 300, 349, 400},
+	dqlntab:  []int{-2048, 4, 135, 213, 273, 323, 373, 425, 425, 373, 323, 273, 213, 135, 4, -2048},
+	witab:    []int{-12, 18, 41, 64, 112, 198, 355, 1122, 1122, 355, 198, 112, 64, 41, 18, -12},
+	fitab:    []int{0, 0, 0, 0x200, 0x200, 0x200, 0x600, 0xE00, 0xE00, 0x600, 0x200, 0x200, 0x200, 0, 0, 0},
+}
+
+// mode40 is the 40 kbit/s mode (G.723).
+var mode40 = &mode{
+	codeSize: 5,
+	qtab:     []int{-122, -16, 68, 139, 198, 250, 298, 339, 378, 413, 445, 475, 502, 528, 553},
+	dqlntab: []int{
+		-2048, -66, 28, 104, 169, 224, 274, 318, 358, 395, 429, 459, 488, 514, 539, 566,
+		566, 539, 514, 488, 459, 429, 395, 358, 318, 274, 224, 169, 104, 28, -66, -2048,
+	},
+	witab: []int{
+		14, 14, 24, 39, 40, 41, 58, 100, 141, 179, 219, 280, 358, 440, 529, 696,
+		696, 529, 440, 358, 280, 219, 179, 141, 100, 58, 41, 40, 39, 24, 14, 14,
+	},
+	fitab: []int{
+		0, 0, 0, 0, 0, 0x200, 0x200, 0x200, 0x200, 0x200, 0x400, 0x600, 0x800, 0xA00, 0xC00, 0xC00,
+		0xC00, 0xC00, 0xA00, 0x800, 0x600, 0x400, 0x200, 0x200, 0x200, 0x200, 0x200, 0, 0, 0, 0, 0,
+	},
+}
+
+// Bit rates.
+const (
+	Bitrate16k = 16000
+	Bitrate24k = 24000
+	Bitrate32k = 32000
+	Bitrate40k = 40000
+)
+
+// Packing is the order in which codewords are packed into bytes.
+type Packing int
+
+const (
+	// PackLSBFirst packs the first codeword into the least significant bits of a byte.
+	// It is used by RTP (RFC 3551) and Sun/NeXT AU files.
+	PackLSBFirst Packing = iota
+
+	// PackMSBFirst packs the first codeword into the most significant bits of a byte.
+	// It is used by ATM AAL2 (ITU-T I.366.2).
+	PackMSBFirst
+)
+
+// Option configures an encoder or decoder.
+type Option func(*options)
+
+type options struct {
+	mode    *mode
+	packing Packing
+	err     error
+}
+
+// WithBitrate sets the bit rate in bits per second: [Bitrate16k], [Bitrate24k], [Bitrate32k] (default) or [Bitrate40k].
+func WithBitrate(bitrate int) Option {
+	return func(o *options) {
+		switch bitrate {
+		case Bitrate16k:
+			o.mode = mode16
+		case Bitrate24k:
+			o.mode = mode24
+		case Bitrate32k:
+			o.mode = mode32
+		case Bitrate40k:
+			o.mode = mode40
+		default:
+			o.err = fmt.Errorf("g726: unsupported bit rate: %d", bitrate)
+		}
+	}
+}
+
+// WithPacking sets the order in which codewords are packed into bytes. The default is [PackLSBFirst].
+func WithPacking(packing Packing) Option {
+	return func(o *options) {
+		switch packing {
+		case PackLSBFirst, PackMSBFirst:
+			o.packing = packing
+		default:
+			o.err = fmt.Errorf("g726: invalid packing: %d", packing)
+		}
+	}
+}
+
+func applyOptions(opts []Option) options {
+	o := options{mode: mode32}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// state holds the state of a G.726 encoder or decoder for a single channel.
+// The fields mirror the 16-bit integers of the reference implementation, including their overflow behavior.
+type state struct {
+	yl  int32    // locked or steady state step size multiplier
+	yu  int16    // unlocked or non-steady state step size multiplier
+	dms int16    // short term energy estimate
+	dml int16    // long term energy estimate
+	ap  int16    // linear weighting coefficient of yl and yu
+	a   [2]int16 // coefficients of pole portion of prediction filter
+	b   [6]int16 // coefficients of zero portion of prediction filter
+	pk  [2]int16 // signs of previous two samples of a partially reconstructed signal
+	dq  [6]int16 // previous 6 samples of the quantized difference signal in floating point format
+	sr  [2]int16 // previous 2 samples of the reconstructed signal in floating point format
+	td  int16    // delayed tone detect
+}
+
+func newState() state {
+	return state{
+		yl: 34816,
+		yu: 544,
+		sr: [2]int16{32, 32},
+		dq: [6]int16{32, 32, 32, 32, 32, 32},
+	}
+}
+
+// predictorZero computes the estimated signal from the 6-zero predictor.
+func (s *state) predictorZero() int {
+	sezi := 0
+	for i := range s.b {
+		sezi += fmult(int(s.b[i])>>2, int(s.dq[i]))
+	}
+	return sezi
+}
+
+// predictorPole computes the estimated signal from the 2-pole predictor.
+func (s *state) predictorPole() int {
+	return fmult(int(s.a[1])>>2, int(s.sr[1])) + fmult(int(s.a[0])>>2, int(s.sr[0]))
+}
+
+// stepSize computes the quantization step size of the adaptive quantizer.
+func (s *state) stepSize() int {
+	if s.ap >= 256 {
+		return int(s.yu)
+	}
+	y := int(s.yl >> 6)
+	dif := int(s.yu) - y
+	al := int(s.ap) >> 2
+	if dif > 0 {
+		y += (dif * al) >> 6
+	} else if dif < 0 {
+		y += (dif*al + 0x3F) >> 6
+	}
+	return y
+}
+
+// quantize returns the ADPCM codeword of the difference signal d given the step size y.
+func quantize(d, y int, m *mode) int {
+	dqm := d
+	if dqm < 0 {
+		dqm = -dqm
+	}
+	exp := quan(dqm>>1, power2[:])
+	mant := ((dqm << 7) >> exp) & 0x7F
+	dl := (exp << 7) + mant
+
+	dln := dl - (y >> 2)
+	i := quan(dln, m.qtab)
+	size := len(m.qtab)
+	switch {
+	case d < 0:
+		return (size << 1) + 1 - i
+	case i == 0 && m.codeSize != 2:
+		// the all-zero codeword is not used, except in the 16 kbit/s mode which has no zero level
+		return (size << 1) + 1
+	default:
+		return i
+	}
+}
+
+// reconstruct returns the reconstructed difference signal from the log of its magnitude dqln and its sign.
+func reconstruct(sign bool, dqln, y int) int {
+	dql := dqln + (y >> 2)
+	if dql < 0 {
+		if sign {
+			return -0x8000
+		}
+		return 0
+	}
+	dex := (dql >> 7) & 15
+	dqt := 128 + (dql & 127)
+	dq := (dqt << 7) >> (14 - dex)
+	if sign {
+		return dq - 0x8000
+	}
+	return dq
+}
+
+// toFloat converts a magnitude and sign to the 4-bit exponent, 6-bit mantissa floating point format.
+func toFloat(mag int, neg bool) int16 {
+	if mag == 0 {
+		if neg {
+			return -0x3E0 // 0xFC20
+		}
+		return 0x20
+	}
+	exp := quan(mag, power2[:])
+	v := int16((exp << 6) + ((mag << 6) >> exp))
+	if neg {
+		v -= 0x400
+	}
+	return v
+}
+
+// update updates the state after encoding or decoding a codeword.
+func (s *state) update(codeSize, y, wi, fi, dq, sr, dqsez int) {
+	pk0 := int16(0)
+	if dqsez < 0 {
+		pk0 = 1
+	}
+	mag := dq & 0x7FFF
+
+	// Transition detect
+	ylint := int(s.yl >> 15)
+	ylfrac := int(s.yl>>10) & 0x1F
+	thr1 := (32 + ylfrac) << ylint
+	thr2 := thr1
+	if ylint > 9 {
+		thr2 = 31 << 10
+	}
+	dqthr := (thr2 + (thr2 >> 1)) >> 1
+	tr := s.td != 0 && mag > dqthr
+
+	// Quantizer scale factor adaptation
+	yu := y + ((wi - y) >> 5)
+	yu = min(max(yu, 544), 5120)
+	s.yu = int16(yu)
+	s.yl += int32(yu) + ((-s.yl) >> 6)
+
+	// Adaptive predictor coefficients
+	var a2p int
+	if tr {
+		s.a = [2]int16{}
+		s.b = [6]int16{}
+	} else {
+		pks1 := pk0 ^ s.pk[0]
+
+		a2p = int(s.a[1]) - (int(s.a[1]) >> 7)
+		if dqsez != 0 {
+			fa1 := -int(s.a[0])
+			if pks1 != 0 {
+				fa1 = int(s.a[0])
+			}
+			switch {
+			case fa1 < -8191:
+				a2p -= 0x100
+			case fa1 > 8191:
+				a2p += 0xFF
+			default:
+				a2p += fa1 >> 5
+			}
+
+			if pk0^s.pk[1] != 0 {
+				switch {
+				case a2p <= -12160:
+					a2p = -12288
+				case a2p >= 12416:
+					a2p = 12288
+				default:
+					a2p -= 0x80
+				}
+			} else {
+				switch {
+				case a2p <= -12416:
+					a2p = -12288
+				case a2p >= 12160:
+					a2p = 12288
+				default:
+					a2p += 0x80
+				}
+			}
+		}
+		s.a[1] = int16(a2p)
+
+		s.a[0] -= s.a[0] >> 8
+		if dqsez != 0 {
+			if pks1 == 0 {
+				s.a[0] += 192
+			} else {
+				s.a[0] -= 192
+			}
+		}
+		a1ul := int16(15360 - a2p)
+		s.a[0] = min(max(s.a[0], -a1ul), a1ul)
+
+		for i := range s.b {
+			if codeSize == 5 {
+				s.b[i] -= s.b[i] >> 9
+			} else {
+				s.b[i] -= s.b[i] >> 8
+			}
+			if dq&0x7FFF != 0 {
+				if (dq ^ int(s.dq[i])) >= 0 {
+					s.b[i] += 128
+				} else {
+					s.b[i] -= 128
+				}
+			}
+		}
+	}
+
+	copy(s.dq[1:], s.dq[:5])
+	s.dq[0] = toFloat(mag, dq < 0)
+
+	s.sr[1] = s.sr[0]
+	switch {
+	case sr > -32768:
+		s.sr[0] = toFloat(max(sr, -sr), sr < 0)
+	default:
+		s.sr[0] = -0x3E0 // 0xFC20
+	}
+
+	// Delay
+	s.pk[1] = s.pk[0]
+	s.pk[0] = pk0
+
+	// Tone detect
+	if !tr && a2p < -11776 {
+		s.td = 1
+	} else {
+		s.td = 0
+	}
+
+	// Adaptation speed control
+	s.dms += (int16(fi) - s.dms) >> 5
+	s.dml += ((int16(fi) << 2) - s.dml) >> 7
+
+	switch {
+	case tr:
+		s.ap = 256
+	case y < 1536, s.td == 1:
+		s.ap += (0x200 - s.ap) >> 4
+	case abs((int(s.dms)<<2)-int(s.dml)) >= int(s.dml)>>3:
+		s.ap += (0x200 - s.ap) >> 4
+	default:
+		s.ap += (-s.ap) >> 4
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// encode encodes a 14-bit linear sample into a codeword.
+func (s *state) encode(m *mode, sl int) int {
+	sezi := s.predictorZero()
+	sez := sezi >> 1
+	se := (sezi + s.predictorPole()) >> 1
+
+	d := sl - se
+
+	y := s.stepSize()
+	i := quantize(d, y, m)
+
+	dq := reconstruct(i&(1<<(m.codeSize-1)) != 0, m.dqlntab[i], y)
+
+	var sr int
+	if dq < 0 {
+		sr = se - (dq & 0x3FFF)
+	} else {
+		sr = se + dq
+	}
+	dqsez := sr + sez - se
+
+	s.update(m.codeSize, y, m.witab[i]<<5, m.fitab[i], dq, sr, dqsez)
+	return i
+}
+
+// decode decodes a codeword into a 14-bit linear sample.
+func (s *state) decode(m *mode, i int) int {
+	i &= 1<<m.codeSize - 1
+	sezi := s.predictorZero()
+	sez := sezi >> 1
+	se := (sezi + s.predictorPole()) >> 1
+
+	y := s.stepSize()
+	dq := reconstruct(i&(1<<(m.codeSize-1)) != 0, m.dqlntab[i], y)
+
+	var sr int
+	if dq < 0 {
+		sr = se - (dq & 0x3FFF)
+	} else {
+		sr = se + dq
+	}
+	dqsez := sr - se + sez
+
+	s.update(m.codeSize, y, m.witab[i]<<5, m.fitab[i], dq, sr, dqsez)
+	return sr
+}
@@ -0,0 +1,248 @@
+package g726_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/encoding/g726"
+)
+
+func encode(t *testing.T, samples []float32, numChannels int, opts ...g726.Option) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := g726.NewEncoder(&buf, numChannels, opts...)
+	if _, err := enc.WriteSamples(samples); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decode(t *testing.T, b []byte, numChannels int, opts ...g726.Option) []float32 {
+	t.Helper()
+	dec := g726.NewDecoder(bytes.NewReader(b), numChannels, opts...)
+	var out []float32
+	buf := make([]float32, 127) // odd size, so codewords are split across reads
+	for {
+		n, err := dec.ReadSamples(buf)
+		out = append(out, buf[:n]...)
+		if errors.Is(err, io.EOF) || (err == nil && n == 0) {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func snr(ref, x []float32) float64 {
+	var sig, noise float64
+	for i := range ref {
+		sig += float64(ref[i]) * float64(ref[i])
+		d := float64(ref[i]) - float64(x[i])
+		noise += d * d
+	}
+	return 10 * math.Log10(sig/noise)
+}
+
+func sine(n int, f float64) []float32 {
+	s := make([]float32, n)
+	for i := range s {
+		s[i] = float32(0.5 * math.Sin(2*math.Pi*f*float64(i)/8000))
+	}
+	return s
+}
+
+func TestSilence(t *testing.T) {
+	// An idle channel encodes to all-ones codewords.
+	b := encode(t, make([]float32, 64), 1)
+	if want := bytes.Repeat([]byte{0xFF}, 32); !bytes.Equal(b, want) {
+		t.Errorf("expected %x, got %x", want, b)
+	}
+
+	for i, s := range decode(t, b, 1) {
+		if math.Abs(float64(s)) > 0.01 {
+			t.Fatalf("sample %d: expected silence, got %f", i, s)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	samples := sine(8000, 440)
+	b := encode(t, samples, 1)
+	if len(b) != len(samples)/2 {
+		t.Fatalf("expected %d bytes, got %d", len(samples)/2, len(b))
+	}
+
+	decoded := decode(t, b, 1)
+	if len(decoded) != len(samples) {
+		t.Fatalf("expected %d samples, got %d", len(samples), len(decoded))
+	}
+	// skip the initial adaptation
+	if got := snr(samples[800:], decoded[800:]); got < 20 {
+		t.Errorf("SNR too low: %.2f dB", got)
+	}
+}
+
+func TestRoundTripStereo(t *testing.T) {
+	left, right := sine(4000, 440), sine(4000, 1000)
+	samples := make([]float32, 0, 8000)
+	for i := range left {
+		samples = append(samples, left[i], right[i])
+	}
+
+	decoded := decode(t, encode(t, samples, 2), 2)
+	if len(decoded) != len(samples) {
+		t.Fatalf("expected %d samples, got %d", len(samples), len(decoded))
+	}
+	if got := snr(samples[800:], decoded[800:]); got < 20 {
+		t.Errorf("SNR too low: %.2f dB", got)
+	}
+}
+
+func TestOddLength(t *testing.T) {
+	samples := sine(101, 440)
+	b := encode(t, samples, 1)
+	if len(b) != 51 {
+		t.Fatalf("expected 51 bytes, got %d", len(b))
+	}
+	// the padding nibble decodes to one extra sample
+	if got := len(decode(t, b, 1)); got != 102 {
+		t.Errorf("expected 102 samples, got %d", got)
+	}
+}
+
+// readInt16s reads a file of little-endian 16-bit samples.
+func readInt16s(t *testing.T, name string) []int16 {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := make([]int16, len(b)/2)
+	for i := range s {
+		s[i] = int16(binary.LittleEndian.Uint16(b[2*i:]))
+	}
+	return s
+}
+
+// The golden data in testdata is generated by testdata/generate.py with a reference coder written after
+// the block diagrams of ITU-T G.726 and its Annex A, which takes the 14 most significant bits of the input.
+func TestGolden(t *testing.T) {
+	input := readInt16s(t, "testdata/input.pcm")
+	samples := make([]float32, len(input))
+	for i, x := range input {
+		samples[i] = float32(x) / (1<<15 - 1)
+	}
+
+	for _, bitrate := range []int{g726.Bitrate16k, g726.Bitrate24k, g726.Bitrate32k, g726.Bitrate40k} {
+		t.Run(strconv.Itoa(bitrate/1000)+"k", func(t *testing.T) {
+			name := "testdata/" + strconv.Itoa(bitrate/1000)
+			codes, err := os.ReadFile(name + ".g726")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := encode(t, samples, 1, g726.WithBitrate(bitrate)); !bytes.Equal(got, codes) {
+				i := 0
+				for i < min(len(got), len(codes)) && got[i] == codes[i] {
+					i++
+				}
+				t.Errorf("encoded data differs from byte %d of %d", i, len(codes))
+			}
+
+			sr := readInt16s(t, name+".pcm")
+			got := decode(t, codes, 1, g726.WithBitrate(bitrate))
+			if len(got) != len(sr) {
+				t.Fatalf("expected %d samples, got %d", len(sr), len(got))
+			}
+			for i := range sr {
+				if want := dsp.Clamp(float32(int(sr[i])<<2) / (1<<15 - 1)); got[i] != want {
+					t.Fatalf("sample %d: expected %v (SR %d), got %v", i, want, sr[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+// The bit rates are also checked for round-trip quality, which must improve with each step.
+func TestBitrates(t *testing.T) {
+	tests := []struct {
+		bitrate int
+		minSNR  float64
+	}{
+		{g726.Bitrate16k, 15},
+		{g726.Bitrate24k, 25},
+		{g726.Bitrate32k, 35},
+		{g726.Bitrate40k, 40},
+	}
+
+	samples := sine(8000, 440)
+	prev := 0.0
+	for _, tt := range tests {
+		b := encode(t, samples, 1, g726.WithBitrate(tt.bitrate))
+		if want := len(samples) * tt.bitrate / 64000; len(b) != want {
+			t.Fatalf("%d bit/s: expected %d bytes, got %d", tt.bitrate, want, len(b))
+		}
+
+		decoded := decode(t, b, 1, g726.WithBitrate(tt.bitrate))
+		if len(decoded) != len(samples) {
+			t.Fatalf("%d bit/s: expected %d samples, got %d", tt.bitrate, len(samples), len(decoded))
+		}
+		got := snr(samples[800:], decoded[800:])
+		if got < tt.minSNR {
+			t.Errorf("%d bit/s: SNR too low: %.2f dB", tt.bitrate, got)
+		}
+		if got <= prev {
+			t.Errorf("%d bit/s: expected higher SNR than the lower bit rate, got %.2f dB <= %.2f dB", tt.bitrate, got, prev)
+		}
+		prev = got
+	}
+}
+
+func TestPacking(t *testing.T) {
+	samples := sine(801, 440)
+	for _, bitrate := range []int{g726.Bitrate16k, g726.Bitrate24k, g726.Bitrate32k, g726.Bitrate40k} {
+		lsb := encode(t, samples, 1, g726.WithBitrate(bitrate))
+		msb := encode(t, samples, 1, g726.WithBitrate(bitrate), g726.WithPacking(g726.PackMSBFirst))
+		if len(lsb) != len(msb) {
+			t.Fatalf("%d bit/s: expected %d bytes, got %d", bitrate, len(lsb), len(msb))
+		}
+
+		want := decode(t, lsb, 1, g726.WithBitrate(bitrate))
+		got := decode(t, msb, 1, g726.WithBitrate(bitrate), g726.WithPacking(g726.PackMSBFirst))
+		if !slices.Equal(got, want) {
+			t.Errorf("%d bit/s: decoded samples differ between packings", bitrate)
+		}
+	}
+
+	// at 32 kbit/s the packings only differ in the nibble order
+	lsb := encode(t, samples[:100], 1)
+	msb := encode(t, samples[:100], 1, g726.WithPacking(g726.PackMSBFirst))
+	for i := range lsb {
+		if msb[i] != lsb[i]<<4|lsb[i]>>4 {
+			t.Fatalf("byte %d: expected %02x, got %02x", i, lsb[i]<<4|lsb[i]>>4, msb[i])
+		}
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	for _, opt := range []g726.Option{g726.WithBitrate(8000), g726.WithPacking(2)} {
+		if _, err := g726.NewEncoder(io.Discard, 1, opt).WriteSamples(make([]float32, 2)); err == nil {
+			t.Error("expected encoder error")
+		}
+		if _, err := g726.NewDecoder(bytes.NewReader([]byte{0}), 1, opt).ReadSamples(make([]float32, 2)); err == nil {
+			t.Error("expected decoder error")
+		}
+	}
+}
@@ -0,0 +1,351 @@
+#!/usr/bin/env python3
+
+"""
+Generates golden G.726 test data with a reference coder written after the block diagrams of ITU-T G.726
+(12/90) and its Annex A (uniform PCM input and output), independently of the Go code.
+Every block works on words of the length given in the Recommendation, so that the golden data pins
+the fixed-point behavior of the standard.
+Do not edit the output files manually!
+
+Outputs, all little-endian:
+  input.pcm      16-bit input samples; the coder takes their 14 most significant bits (SL).
+                 The 2 lowest bits are set to 10, so that converting the samples to float and back
+                 cannot change SL.
+  <rate>.g726    the input encoded at 16, 24, 32 and 40 kbit/s, packed least significant bits first (RFC 3551)
+  <rate>.pcm     the codewords decoded into 16-bit reconstructed signal samples (SR)
+"""
+
+import math
+import struct
+
+# Tables of the quantizer (QUAN), its inverse (RECONST), the scale factor multipliers (FUNCTW)
+# and the speed control values (FUNCTF), indexed by |I|; DLN and DQLN in units of 2^-7, W in units of 2^-4.
+RATES = {
+    16: dict(
+        bits=2,
+        quan=[261],
+        dqln=[116, 365],
+        w=[-22, 439],
+        f=[0, 7],
+    ),
+    24: dict(
+        bits=3,
+        quan=[8, 218, 331],
+        dqln=[-2048, 135, 273, 373],
+        w=[-4, 30, 137, 582],
+        f=[0, 1, 2, 7],
+    ),
+    32: dict(
+        bits=4,
+        quan=[-124, 80, 178, 246, 300, 349, 400],
+        dqln=[-2048, 4, 135, 213, 273, 323, 373, 425],
+        w=[-12, 18, 41, 64, 112, 198, 355, 1122],
+        f=[0, 0, 0, 1, 1, 1, 3, 7],
+    ),
+    40: dict(
+        bits=5,
+        quan=[-122, -16, 68, 139, 198, 250, 298, 339, 378, 413, 445, 475, 502, 528, 553],
+        dqln=[-2048, -66, 28, 104, 169, 224, 274, 318, 358, 395, 429, 459, 488, 514, 539, 566],
+        w=[14, 14, 24, 39, 40, 41, 58, 100, 141, 179, 219, 280, 358, 440, 529, 696],
+        f=[0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 2, 3, 4, 5, 6, 6],
+    ),
+}
+
+
+def tc(x: int, n: int) -> int:
+    """Interprets the n-bit word x as two's complement."""
+    x &= (1 << n) - 1
+    return x - (1 << n) if x >> (n - 1) else x
+
+
+def word(x: int, n: int) -> int:
+    """Returns the n-bit two's complement word of x."""
+    return x & ((1 << n) - 1)
+
+
+class Coder:
+    def __init__(self, rate: int):
+        self.t = RATES[rate]
+        self.rate = rate
+        # reset state (4.2.7)
+        self.b = [0] * 6  # B1-B6, 16-bit TC
+        self.a = [0] * 2  # A1, A2, 16-bit TC
+        self.dq = [32] * 6  # DQ1-DQ6, 11-bit floating point
+        self.sr = [32] * 2  # SR1, SR2, 11-bit floating point
+        self.pk = [0] * 2  # PK1, PK2
+        self.yu = 544  # 13 bits
+        self.yl = 34816  # 19 bits
+        self.dms = 0  # 12 bits
+        self.dml = 0  # 14 bits
+        self.ap = 0  # 10 bits
+        self.td = 0
+
+    # FMULT: multiplies a 16-bit TC coefficient by an 11-bit floating point signal, giving a 16-bit TC word.
+    @staticmethod
+    def fmult(an: int, srn: int) -> int:
+        ans = an >> 15
+        anmag = (16384 - (an >> 2)) & 8191 if ans else an >> 2
+        anexp = anmag.bit_length()
+        anmant = 32 if anmag == 0 else (anmag << 6) >> anexp
+        srns = srn >> 10
+        srnexp = (srn >> 6) & 15
+        srnmant = srn & 63
+        wans = srns ^ ans
+        wanexp = srnexp + anexp
+        wanmant = ((srnmant * anmant) + 48) >> 4
+        if wanexp <= 26:
+            wanmag = (wanmant << 7) >> (26 - wanexp)
+        else:
+            wanmag = ((wanmant << 7) << (wanexp - 26)) & 32767
+        return (65536 - wanmag) & 65535 if wans else wanmag
+
+    # FLOATA/FLOATB: converts a 16-bit magnitude and a sign to the 11-bit floating point format.
+    @staticmethod
+    def float11(sign: int, mag: int) -> int:
+        exp = mag.bit_length()
+        mant = (mag << 6) >> exp if mag else 32
+        return (sign << 10) + (exp << 6) + mant
+
+    def predict(self):
+        # FMULT, ACCUM: 16-bit sums, SEZ and SE are 15-bit TC
+        wb = [self.fmult(self.b[i], self.dq[i]) for i in range(6)]
+        wa = [self.fmult(self.a[i], self.sr[i]) for i in range(2)]
+        sezi = word(sum(wb), 16)
+        sei = word(sezi + wa[1] + wa[0], 16)
+        return sezi >> 1, sei >> 1
+
+    def step_size(self):
+        # LIMA, MIX
+        al = 64 if self.ap >= 256 else self.ap >> 2
+        dif = word(self.yu - (self.yl >> 6), 14)
+        difs = dif >> 13
+        difm = (16384 - dif) & 8191 if difs else dif
+        prodm = (difm * al) >> 6
+        prod = (16384 - prodm) & 16383 if difs else prodm
+        return word((self.yl >> 6) + prod, 13)
+
+    def quantize(self, d: int, y: int) -> int:
+        # LOG, SUBTB, QUAN
+        ds = d >> 15
+        dqm = (65536 - d) & 32767 if ds else d
+        exp = max(dqm.bit_length() - 1, 0)
+        mant = ((dqm << 7) >> exp) & 127
+        dl = (exp << 7) + mant
+        dln = tc(dl + 4096 - (y >> 2), 12)
+        mag = sum(1 for q in self.t["quan"] if dln >= q)
+        n = self.t["bits"]
+        i = ((1 << n) - 1 - mag) if ds else mag
+        if self.rate != 16 and i == 0:
+            i = (1 << n) - 1  # the all-zero codeword is not transmitted
+        return i
+
+    def magnitude(self, i: int) -> tuple[int, int]:
+        """Returns |I| and the sign of the codeword i."""
+        n = self.t["bits"]
+        if i >> (n - 1):
+            return (1 << n) - 1 - i, 1
+        return i, 0
+
+    def reconstruct(self, i: int, y: int) -> int:
+        # RECONST, ADDA, ANTILOG: DQ is 16-bit sign-magnitude
+        mag, dqs = self.magnitude(i)
+        dqln = word(self.t["dqln"][mag], 12)
+        dql = word(dqln + (y >> 2), 12)
+        ds = dql >> 11
+        dex = (dql >> 7) & 15
+        dqt = 128 + (dql & 127)
+        dqmag = 0 if ds else (dqt << 7) >> (14 - dex)
+        return (dqs << 15) + dqmag
+
+    def update(self, i: int, y: int, dq: int, se: int, sez: int) -> int:
+        """Updates the state and returns the 16-bit TC reconstructed signal SR."""
+        mag, _ = self.magnitude(i)
+        dqs = dq >> 15
+        dqmag = dq & 32767
+        dqi = (65536 - dqmag) & 65535 if dqs else dq
+
+        # ADDB, ADDC
+        sei = se + 32768 if se >> 14 else se
+        sr = word(dqi + sei, 16)
+        sezi = sez + 32768 if sez >> 14 else sez
+        dqsez = word(dqi + sezi, 16)
+        pk0 = dqsez >> 15
+        sigpk = 1 if dqsez == 0 else 0
+
+        # TRANS
+        ylint = self.yl >> 15
+        ylfrac = (self.yl >> 10) & 31
+        thr1 = (32 + ylfrac) << ylint
+        thr2 = 31 << 10 if ylint > 9 else thr1
+        dqthr = (thr2 + (thr2 >> 1)) >> 1
+        tr = 1 if dqmag > dqthr and self.td == 1 else 0
+
+        # UPA2, LIMC
+        a1, a2 = self.a
+        pks1 = pk0 ^ self.pk[0]
+        pks2 = pk0 ^ self.pk[1]
+        uga2a = 114688 if pks2 else 16384
+        if a1 >> 15 == 0:
+            fa1 = a1 << 2 if a1 <= 8191 else 8191 << 2
+        else:
+            fa1 = (a1 << 2) & 131071 if a1 >= 57345 else 24577 << 2
+        fa = fa1 if pks1 else (131072 - fa1) & 131071
+        uga2b = (uga2a + fa) & 131071
+        if sigpk:
+            uga2 = 0
+        else:
+            uga2 = (uga2b >> 7) + 64512 if uga2b >> 16 else uga2b >> 7
+        if a2 >> 15:
+            ula2 = (65536 - ((a2 >> 7) + 65024)) & 65535
+        else:
+            ula2 = (65536 - (a2 >> 7)) & 65535
+        a2t = word(a2 + word(uga2 + ula2, 16), 16)
+        if 32768 <= a2t <= 53248:
+            a2p = 53248
+        elif 12288 <= a2t <= 32767:
+            a2p = 12288
+        else:
+            a2p = a2t
+
+        # UPA1, LIMD
+        uga1 = 0 if sigpk else (65344 if pks1 else 192)
+        if a1 >> 15:
+            ula1 = (65536 - ((a1 >> 8) + 65280)) & 65535
+        else:
+            ula1 = (65536 - (a1 >> 8)) & 65535
+        a1t = word(a1 + word(uga1 + ula1, 16), 16)
+        a1ul = tc(15360 - a2p, 16)
+        a1p = tc(a1t, 16)
+        a1p = word(min(max(a1p, -a1ul), a1ul), 16)
+
+        # UPB, XOR
+        bp = []
+        for n in range(6):
+            bn = self.b[n]
+            un = dqs ^ (self.dq[n] >> 10)
+            ugbn = 0 if dqmag == 0 else (65408 if un else 128)
+            sh = 9 if self.rate == 40 else 8
+            if bn >> 15:
+                ulbn = (65536 - ((bn >> sh) + (65536 - (1 << (16 - sh))))) & 65535
+            else:
+                ulbn = (65536 - (bn >> sh)) & 65535
+            bp.append(word(bn + word(ugbn + ulbn, 16), 16))
+
+        # TONE, TRIGB
+        tdp = 1 if 32768 <= a2p < 53760 else 0
+        if tr:
+            a1p, a2p, bp, tdp = 0, 0, [0] * 6, 0
+
+        # FUNCTW, FILTD, LIMB, FILTE
+        wi = word(self.t["w"][mag], 12)
+        dif = word((wi << 5) + 131072 - y, 17)
+        difsx = (dif >> 5) + 4096 if dif >> 16 else dif >> 5
+        yut = word(y + difsx, 13)
+        geul = ((yut + 11264) & 16383) >> 13
+        gell = ((yut + 15840) & 16383) >> 13
+        yup = 544 if gell == 1 else (5120 if geul == 0 else yut)
+        dif = word(yup + ((1048576 - self.yl) >> 6), 14)
+        difsx = dif + 507904 if dif >> 13 else dif
+        ylp = word(self.yl + difsx, 19)
+
+        # FUNCTF, FILTA, FILTB, SUBTC, FILTC, TRIGA
+        fi = self.t["f"][mag]
+        dif = word((fi << 9) + 8192 - self.dms, 13)
+        difsx = (dif >> 5) + 3840 if dif >> 12 else dif >> 5
+        dmsp = word(difsx + self.dms, 12)
+        dif = word((fi << 11) + 32768 - self.dml, 15)
+        difsx = (dif >> 7) + 16128 if dif >> 14 else dif >> 7
+        dmlp = word(difsx + self.dml, 14)
+        dif = word((dmsp << 2) + 32768 - dmlp, 15)
+        difm = (32768 - dif) & 16383 if dif >> 14 else dif
+        dthr = dmlp >> 3
+        ax = 0 if y >= 1536 and difm < dthr and tdp == 0 else 1
+        dif = word((ax << 9) + 2048 - self.ap, 11)
+        difsx = (dif >> 4) + 896 if dif >> 10 else dif >> 4
+        app = word(difsx + self.ap, 10)
+        apr = 256 if tr else app
+
+        # FLOATA, FLOATB, delays
+        srs = sr >> 15
+        srmag = (65536 - sr) & 32767 if srs else sr
+        self.dq = [self.float11(dqs, dqmag)] + self.dq[:5]
+        self.sr = [self.float11(srs, srmag), self.sr[0]]
+        self.pk = [pk0, self.pk[0]]
+        self.a = [a1p, a2p]
+        self.b = bp
+        self.yu, self.yl = yup, ylp
+        self.dms, self.dml, self.ap = dmsp, dmlp, apr
+        self.td = tdp
+        return sr
+
+    def encode(self, sl: int) -> int:
+        sez, se = self.predict()
+        y = self.step_size()
+        # SUBTA: SL is 14-bit TC, SE is 15-bit TC, D is 16-bit TC
+        sli = word(sl, 16)
+        sei = se + 32768 if se >> 14 else se
+        d = word(sli + 65536 - sei, 16)
+        i = self.quantize(d, y)
+        dq = self.reconstruct(i, y)
+        self.update(i, y, dq, se, sez)
+        return i
+
+    def decode(self, i: int) -> int:
+        sez, se = self.predict()
+        y = self.step_size()
+        dq = self.reconstruct(i, y)
+        return tc(self.update(i, y, dq, se, sez), 16)
+
+
+def pack(codes: list[int], bits: int) -> bytes:
+    """Packs codewords least significant bits first."""
+    out = bytearray()
+    acc = nacc = 0
+    for i in codes:
+        acc |= i << nacc
+        nacc += bits
+        while nacc >= 8:
+            out.append(acc & 0xFF)
+            acc >>= 8
+            nacc -= 8
+    assert nacc == 0
+    return bytes(out)
+
+
+def signal(n: int) -> list[int]:
+    """Returns 14-bit samples of a tone sweep with noise, a loud burst, a sustained tone and silence."""
+    seed = 1
+    out = []
+    for k in range(n):
+        seed = (seed * 1103515245 + 12345) & 0x7FFFFFFF
+        noise = ((seed >> 16) - 16384) / 16384
+        t = k / 8000
+        x = 3000 * math.sin(2 * math.pi * (100 + 1500 * t) * t) + 300 * noise
+        if 2000 <= k < 2400:
+            x = 8000 * math.sin(2 * math.pi * 300 * t) + 6000 * noise  # near full scale
+        elif 2800 <= k < 3600:
+            x = 4000 * math.sin(2 * math.pi * 1000 * t)  # a pure tone trips the tone detector
+        elif k >= n - 300:
+            x = 0
+        out.append(max(-8192, min(8191, int(x))))
+    return out
+
+
+def main():
+    sl = signal(4000)
+    with open("input.pcm", "wb") as f:
+        f.write(struct.pack(f"<{len(sl)}h", *(4 * x + 2 for x in sl)))
+
+    for rate in RATES:
+        print(f"[*] Generating {rate} kbit/s")
+        enc = Coder(rate)
+        codes = [enc.encode(x) for x in sl]
+        dec = Coder(rate)
+        sr = [dec.decode(i) for i in codes]
+        with open(f"{rate}.g726", "wb") as f:
+            f.write(pack(codes, RATES[rate]["bits"]))
+        with open(f"{rate}.pcm", "wb") as f:
+            f.write(struct.pack(f"<{len(sr)}h", *sr))
+
+
+if __name__ == "__main__": main()
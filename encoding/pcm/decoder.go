@@ -10,25 +10,41 @@ import (
 	"github.com/MatusOllah/resona/aio"
 )
 
-type decoder struct {
+// Decoder reads and decodes PCM samples.
+type Decoder struct {
 	r            io.Reader
 	sampleFormat afmt.SampleFormat
 	pcmBuf       []byte
+	rem          [8]byte // incomplete sample from the previous read
+	numRem       int
 }
 
 // NewDecoder returns an aio.SampleReader that reads and decodes PCM samples from the provided [io.Reader].
+// The returned reader is a [*Decoder].
+//
+// Reads from r do not have to be aligned to whole samples; an incomplete sample is kept until the rest of it is read.
+// If r ends in the middle of a sample, ReadSamples returns [io.ErrUnexpectedEOF].
 func NewDecoder(r io.Reader, sampleFormat afmt.SampleFormat) aio.SampleReader {
 	if sampleFormat.Endian == nil {
 		sampleFormat.Endian = binary.NativeEndian
 	}
 
-	return &decoder{
+	return &Decoder{
 		r:            r,
 		sampleFormat: sampleFormat,
 	}
 }
 
-func (d *decoder) ReadSamples(p []float32) (int, error) {
+// Reset makes the decoder read from r, discarding the incomplete sample kept from the previous reads, if any.
+// It must be called after seeking the source, with the same r, so that the first sample read is not corrupted.
+func (d *Decoder) Reset(r io.Reader) {
+	d.r = r
+	d.numRem = 0
+}
+
+// ReadSamples reads and decodes float32 samples into p.
+// It returns the number of samples read and/or an error.
+func (d *Decoder) ReadSamples(p []float32) (int, error) {
 	if d.sampleFormat.BitDepth <= 0 {
 		return 0, ErrInvalidBitDepth
 	}
@@ -37,6 +53,9 @@ func (d *decoder) ReadSamples(p []float32) (int, error) {
 	}
 
 	sampleSize := d.sampleFormat.BytesPerSample()
+	if len(p) == 0 {
+		return 0, nil
+	}
 	numBytes := sampleSize * len(p)
 
 	if cap(d.pcmBuf) < numBytes {
@@ -45,17 +64,24 @@ func (d *decoder) ReadSamples(p []float32) (int, error) {
 		d.pcmBuf = d.pcmBuf[:numBytes]
 	}
 
-	n, err := d.r.Read(d.pcmBuf)
-	if err != nil && err != io.EOF {
-		return 0, err
+	// start with the bytes of an incomplete sample left over from the previous call
+	pending := copy(d.pcmBuf, d.rem[:d.numRem])
+	d.numRem = 0
+
+	n, err := io.ReadAtLeast(d.r, d.pcmBuf[pending:], sampleSize-pending)
+	total := pending + n
+	if err == io.EOF && total > 0 {
+		// the stream ended in the middle of a sample
+		err = io.ErrUnexpectedEOF
 	}
 
-	for i := range n / sampleSize {
-		offset := i * sampleSize
-		if offset+sampleSize > len(d.pcmBuf) {
-			return i, io.ErrUnexpectedEOF
-		}
+	numSamples := total / sampleSize
+	if err == nil {
+		d.numRem = copy(d.rem[:], d.pcmBuf[numSamples*sampleSize:total])
+	}
 
+	for i := range numSamples {
+		offset := i * sampleSize
 		switch d.sampleFormat.Encoding {
 		case afmt.SampleEncodingInt:
 			switch d.sampleFormat.BitDepth {
@@ -66,46 +92,34 @@ func (d *decoder) ReadSamples(p []float32) (int, error) {
 				v := int16(d.sampleFormat.Endian.Uint16(d.pcmBuf[offset:]))
 				p[i] = float32(v) / (1<<15 - 1)
 			case 24:
-				b := d.pcmBuf[offset : offset+3]
-				v := int32(uint24(b, d.sampleFormat.Endian))
-				if v&(1<<23) != 0 {
-					v |= ^0xFFFFFF
-				}
-				p[i] = float32(v) / (1<<23 - 1)
+				p[i] = float32(int24(d.pcmBuf[offset:], d.sampleFormat.Endian)) / (1<<23 - 1)
 			case 32:
 				v := int32(d.sampleFormat.Endian.Uint32(d.pcmBuf[offset:]))
-				p[i] = float32(v) / (1<<31 - 1)
-				/*
-					case 64:
-						v := int64(d.sampleFormat.Endian.Uint64(d.pcmBuf[offset:]))
-						p[i] = float32(v) / (1<<63 - 1)
-				*/
+				p[i] = float32(float64(v) / (1<<31 - 1))
+			case 64:
+				v := int64(d.sampleFormat.Endian.Uint64(d.pcmBuf[offset:]))
+				p[i] = float32(float64(v) / (1<<63 - 1))
 			default:
 				return 0, ErrInvalidBitDepth
 			}
 		case afmt.SampleEncodingUint:
+			// unsigned samples are offset binary, flipping the most significant bit makes them signed
 			switch d.sampleFormat.BitDepth {
 			case 8:
 				v := d.pcmBuf[offset]
 				p[i] = float32(v)/127.5 - 1.0
-				/*
-					case 16:
-						v := d.sampleFormat.Endian.Uint16(d.pcmBuf[offset:])
-						p[i] = float32(v) / (1<<16 - 1)
-					case 24:
-						if offset+3 > len(d.pcmBuf) {
-							return i, io.ErrUnexpectedEOF
-						}
-						b := d.pcmBuf[offset : offset+3]
-						v := uint24(b, d.sampleFormat.Endian)
-						p[i] = float32(v) / (1<<24 - 1)
-					case 32:
-						v := d.sampleFormat.Endian.Uint32(d.pcmBuf[offset:])
-						p[i] = float32(v) / (1<<32 - 1)
-					case 64:
-						v := d.sampleFormat.Endian.Uint64(d.pcmBuf[offset:])
-						p[i] = float32(v) / (1<<64 - 1)
-				*/
+			case 16:
+				v := int16(d.sampleFormat.Endian.Uint16(d.pcmBuf[offset:]) ^ 1<<15)
+				p[i] = float32(v) / (1<<15 - 1)
+			case 24:
+				v := uint24(d.pcmBuf[offset:offset+3], d.sampleFormat.Endian) ^ 1<<23
+				p[i] = float32(signExtend24(v)) / (1<<23 - 1)
+			case 32:
+				v := int32(d.sampleFormat.Endian.Uint32(d.pcmBuf[offset:]) ^ 1<<31)
+				p[i] = float32(float64(v) / (1<<31 - 1))
+			case 64:
+				v := int64(d.sampleFormat.Endian.Uint64(d.pcmBuf[offset:]) ^ 1<<63)
+				p[i] = float32(float64(v) / (1<<63 - 1))
 			default:
 				return 0, ErrInvalidBitDepth
 			}
@@ -125,7 +139,15 @@ func (d *decoder) ReadSamples(p []float32) (int, error) {
 		}
 	}
 
-	return n / sampleSize, err
+	return numSamples, err
+}
+
+func int24(p []byte, endian binary.ByteOrder) int32 {
+	return signExtend24(uint24(p[:3], endian))
+}
+
+func signExtend24(v uint32) int32 {
+	return int32(v<<8) >> 8
 }
 
 func uint24(p []byte, endian binary.ByteOrder) uint32 {
@@ -0,0 +1,128 @@
+package pcm
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+)
+
+// Dither represents a kind of dither added to samples before they are quantized to integers.
+type Dither int
+
+const (
+	// DitherNone quantizes samples without dither. It is the default and the output is bit-exact.
+	DitherNone Dither = iota
+
+	// DitherTPDF adds triangular probability density function dither with an amplitude of ±1 LSB.
+	// It decorrelates the quantization error from the signal, turning distortion into a constant noise floor.
+	DitherTPDF
+)
+
+// Option configures an encoder.
+type Option func(*options)
+
+type options struct {
+	dither      Dither
+	numChannels int // number of channels for noise shaping, 0 if disabled
+	src         rand.Source
+	err         error
+}
+
+// WithDither sets the dither added to samples when encoding to an integer format. The default is [DitherNone].
+func WithDither(dither Dither) Option {
+	return func(o *options) {
+		switch dither {
+		case DitherNone, DitherTPDF:
+			o.dither = dither
+		default:
+			o.err = fmt.Errorf("pcm: invalid dither: %d", dither)
+		}
+	}
+}
+
+// WithNoiseShaping enables first-order noise shaping when encoding to an integer format,
+// which moves the quantization noise to higher frequencies where it is less audible.
+// The quantization error is fed back per channel, so the encoder needs the number of interleaved channels.
+func WithNoiseShaping(numChannels int) Option {
+	return func(o *options) {
+		if numChannels <= 0 {
+			o.err = fmt.Errorf("pcm: invalid number of channels: %d", numChannels)
+			return
+		}
+		o.numChannels = numChannels
+	}
+}
+
+// WithRandSource sets the source of random numbers used for dither.
+// By default, the source is seeded randomly; use a fixed seed for reproducible output.
+func WithRandSource(src rand.Source) Option {
+	return func(o *options) {
+		o.src = src
+	}
+}
+
+func applyOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// quantizer rounds scaled samples to integers, optionally with dither and noise shaping.
+type quantizer struct {
+	dither Dither
+	rng    *rand.Rand
+	errs   []float64 // quantization error of the previous sample of each channel for noise shaping
+	ch     int       // channel of the next sample
+}
+
+func newQuantizer(o options) *quantizer {
+	q := &quantizer{dither: o.dither}
+	if o.dither != DitherNone {
+		src := o.src
+		if src == nil {
+			var seed1, seed2 uint64
+			_ = binary.Read(crand.Reader, binary.BigEndian, &seed1)
+			_ = binary.Read(crand.Reader, binary.BigEndian, &seed2)
+			src = rand.NewPCG(seed1, seed2)
+		}
+		q.rng = rand.New(src)
+	}
+	if o.numChannels > 0 {
+		q.errs = make([]float64, o.numChannels)
+	}
+	return q
+}
+
+// quantize rounds x, a sample scaled to integer steps, to the nearest integer in [lo, hi].
+func (q *quantizer) quantize(x, lo, hi float64) float64 {
+	if q.dither == DitherNone && q.errs == nil {
+		return math.Round(x)
+	}
+
+	u := x
+	if q.errs != nil {
+		u -= q.errs[q.ch]
+	}
+	v := u
+	if q.dither == DitherTPDF {
+		// the difference of two uniform random numbers has a triangular distribution
+		v += q.rng.Float64() - q.rng.Float64()
+	}
+	r := math.Round(v)
+	if q.errs != nil {
+		// the error is taken before clamping so that clipping does not accumulate
+		q.errs[q.ch] = r - u
+		q.ch = (q.ch + 1) % len(q.errs)
+	}
+	return min(max(r, lo), hi)
+}
+
+// quantizeInt scales s to a signed integer of the given bit depth and quantizes it.
+func (q *quantizer) quantizeInt(s float64, bitDepth int) float64 {
+	m := float64(int64(1)<<(bitDepth-1) - 1)
+	return q.quantize(s*m, -m, m)
+}
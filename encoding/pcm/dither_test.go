@@ -0,0 +1,157 @@
+package pcm_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/encoding/pcm"
+)
+
+var int16LE = afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 16, Endian: binary.LittleEndian}
+
+// ditherStats encodes a slow ramp spanning a few LSBs to 16-bit and returns statistics of the quantization error:
+// the longest run of identical output values, the mean error and the lag-1 autocorrelation of the error, all in LSBs.
+func ditherStats(t *testing.T, opts ...pcm.Option) (longestRun int, mean, autocorr float64) {
+	t.Helper()
+
+	const n = 20000
+	x := make([]float64, n) // in LSBs
+	samples := make([]float32, n)
+	for i := range samples {
+		samples[i] = float32(4*float64(i)/n) / (1<<15 - 1)
+		x[i] = float64(samples[i]) * (1<<15 - 1)
+	}
+
+	b, err := pcm.Encode(samples, int16LE, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make([]float64, n)
+	run := 0
+	for i := range n {
+		v := int16(binary.LittleEndian.Uint16(b[2*i:]))
+		errs[i] = float64(v) - x[i]
+		mean += errs[i]
+
+		if i > 0 && v == int16(binary.LittleEndian.Uint16(b[2*i-2:])) {
+			run++
+		} else {
+			run = 1
+		}
+		longestRun = max(longestRun, run)
+	}
+	mean /= n
+
+	var num, den float64
+	for i := range n {
+		d := errs[i] - mean
+		den += d * d
+		if i > 0 {
+			num += d * (errs[i-1] - mean)
+		}
+	}
+	return longestRun, mean, num / den
+}
+
+func TestDitherTPDF(t *testing.T) {
+	run, _, autocorr := ditherStats(t)
+	t.Logf("no dither: longest run %d, autocorrelation %.3f", run, autocorr)
+	if run < 1000 || autocorr < 0.9 {
+		t.Fatalf("expected correlated error without dither, got longest run %d, autocorrelation %.3f", run, autocorr)
+	}
+
+	run, mean, autocorr := ditherStats(t, pcm.WithDither(pcm.DitherTPDF), pcm.WithRandSource(rand.NewPCG(1, 2)))
+	t.Logf("TPDF dither: longest run %d, mean %.3f, autocorrelation %.3f", run, mean, autocorr)
+	if run > 100 {
+		t.Errorf("expected short runs of identical values, got %d", run)
+	}
+	if math.Abs(autocorr) > 0.05 {
+		t.Errorf("expected uncorrelated error, got autocorrelation %.3f", autocorr)
+	}
+	if math.Abs(mean) > 0.05 {
+		t.Errorf("expected unbiased error, got mean %.3f", mean)
+	}
+}
+
+func TestNoiseShaping(t *testing.T) {
+	// first-order noise shaping differentiates the error, which makes it negatively correlated
+	_, mean, autocorr := ditherStats(t, pcm.WithDither(pcm.DitherTPDF), pcm.WithNoiseShaping(1), pcm.WithRandSource(rand.NewPCG(1, 2)))
+	t.Logf("TPDF dither with noise shaping: mean %.3f, autocorrelation %.3f", mean, autocorr)
+	if autocorr > -0.3 {
+		t.Errorf("expected negatively correlated error, got autocorrelation %.3f", autocorr)
+	}
+	if math.Abs(mean) > 0.05 {
+		t.Errorf("expected unbiased error, got mean %.3f", mean)
+	}
+}
+
+func TestDitherDeterministic(t *testing.T) {
+	samples := make([]float32, 1000)
+	for i := range samples {
+		samples[i] = float32(math.Sin(float64(i) / 10))
+	}
+
+	a, err := pcm.Encode(samples, int16LE, pcm.WithDither(pcm.DitherTPDF), pcm.WithRandSource(rand.NewPCG(3, 4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := pcm.Encode(samples, int16LE, pcm.WithDither(pcm.DitherTPDF), pcm.WithRandSource(rand.NewPCG(3, 4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Error("expected identical output with the same seed")
+	}
+}
+
+func TestDitherClamp(t *testing.T) {
+	samples := []float32{1, -1, 1, -1, 1.5, -2}
+	for _, sampleFormat := range []afmt.SampleFormat{
+		{Encoding: afmt.SampleEncodingInt, BitDepth: 8},
+		int16LE,
+		{Encoding: afmt.SampleEncodingInt, BitDepth: 24, Endian: binary.BigEndian},
+		{Encoding: afmt.SampleEncodingInt, BitDepth: 32, Endian: binary.LittleEndian},
+		{Encoding: afmt.SampleEncodingUint, BitDepth: 8},
+		{Encoding: afmt.SampleEncodingUint, BitDepth: 16, Endian: binary.LittleEndian},
+	} {
+		t.Run(sampleFormat.String(), func(t *testing.T) {
+			for seed := range uint64(20) {
+				b, err := pcm.Encode(samples, sampleFormat,
+					pcm.WithDither(pcm.DitherTPDF),
+					pcm.WithNoiseShaping(2),
+					pcm.WithRandSource(rand.NewPCG(seed, seed)),
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := pcm.Decode(b, sampleFormat)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// dither can only pull full-scale samples inwards by one step, never wrap them around
+				step := 1 / float32(int64(1)<<(sampleFormat.BitDepth-1)-1)
+				for i, s := range got {
+					want := max(min(samples[i], 1), -1)
+					if s*want < 0 || math.Abs(float64(s-want)) > 1.5*float64(step) {
+						t.Fatalf("seed %d: sample %d: expected %v, got %v", seed, i, want, s)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDitherInvalid(t *testing.T) {
+	if _, err := pcm.Encode([]float32{0}, int16LE, pcm.WithDither(42)); err == nil {
+		t.Error("expected error for invalid dither")
+	}
+	if _, err := pcm.Encode([]float32{0}, int16LE, pcm.WithNoiseShaping(0)); err == nil {
+		t.Error("expected error for invalid number of channels")
+	}
+}
@@ -1,2 +1,11 @@
 // Package pcm implements encoding and decoding of Pulse Code Mudulation (PCM).
+//
+// Supported sample formats are signed and unsigned (offset binary) integers
+// with bit depths of 8, 16, 24, 32 and 64 bits, and 32- and 64-bit floats.
+// Integer samples wider than 8 bits are scaled by 2^(bitDepth-1) - 1 and rounded to the nearest value when encoding.
+//
+// Like everywhere else in Resona, [NewDecoder] and [NewEncoder] use interleaved samples.
+// [PlanarDecoder] and [PlanarEncoder] handle data stored with every channel in a separate plane;
+// their ReadSamples and WriteSamples methods still use interleaved samples, while ReadPlanes and WritePlanes
+// use one slice per channel.
 package pcm
@@ -15,21 +15,32 @@ type encoder struct {
 	w            io.Writer
 	sampleFormat afmt.SampleFormat
 	buf          []byte
+	samples      []float32 // clamped copy of the samples being written
+	q            *quantizer
+	err          error
 }
 
 // NewEncoder returns an aio.SampleWriter that encodes and writes PCM samples to the provided [io.Writer].
-func NewEncoder(w io.Writer, sampleFormat afmt.SampleFormat) aio.SampleWriter {
+//
+// Samples are rounded to integer formats without dither, unless configured otherwise with [WithDither] or [WithNoiseShaping].
+func NewEncoder(w io.Writer, sampleFormat afmt.SampleFormat, opts ...Option) aio.SampleWriter {
 	if sampleFormat.Endian == nil {
 		sampleFormat.Endian = binary.NativeEndian
 	}
 
+	o := applyOptions(opts)
 	return &encoder{
 		w:            w,
 		sampleFormat: sampleFormat,
+		q:            newQuantizer(o),
+		err:          o.err,
 	}
 }
 
 func (e *encoder) WriteSamples(p []float32) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
 	if e.sampleFormat.BitDepth <= 0 {
 		return 0, ErrInvalidBitDepth
 	}
@@ -46,32 +57,53 @@ func (e *encoder) WriteSamples(p []float32) (int, error) {
 		e.buf = e.buf[:totalBytes]
 	}
 
-	for i := range p {
-		s := float64(dsp.Clamp(p[i]))
+	e.samples = append(e.samples[:0], p...)
+	dsp.ClampSlice(e.samples)
+
+	for i, s32 := range e.samples {
+		s := float64(s32)
 		offset := i * sampleSize
 
 		switch e.sampleFormat.Encoding {
 		case afmt.SampleEncodingInt:
 			switch e.sampleFormat.BitDepth {
 			case 8:
-				e.buf[offset] = byte(int8(s * 127))
+				e.buf[offset] = byte(int8(e.q.quantizeInt(s, 8)))
 			case 16:
-				v := int16(s * (1<<15 - 1))
+				v := int16(e.q.quantizeInt(s, 16))
 				e.sampleFormat.Endian.PutUint16(e.buf[offset:], uint16(v))
 			case 24:
-				v := int32(s * (1<<23 - 1))
+				v := int32(e.q.quantizeInt(s, 24))
 				putUint24(e.buf[offset:], uint32(v), e.sampleFormat.Endian)
 			case 32:
-				v := int32(s * (1<<31 - 1))
+				v := int32(e.q.quantizeInt(s, 32))
 				e.sampleFormat.Endian.PutUint32(e.buf[offset:], uint32(v))
+			case 64:
+				e.sampleFormat.Endian.PutUint64(e.buf[offset:], uint64(int64Sample(s)))
 			default:
 				return 0, ErrInvalidBitDepth
 			}
 		case afmt.SampleEncodingUint:
+			// unsigned samples are offset binary, flipping the most significant bit of the signed value
 			switch e.sampleFormat.BitDepth {
 			case 8:
-				v := byte((s + 1.0) * 0.5 * 255)
-				e.buf[offset] = v
+				if e.q.dither == DitherNone && e.q.errs == nil {
+					e.buf[offset] = byte((s + 1.0) * 0.5 * 255)
+				} else {
+					// the mapping above truncates, shift by half a step to match it when rounding
+					e.buf[offset] = byte(e.q.quantize((s+1.0)*0.5*255-0.5, 0, 255))
+				}
+			case 16:
+				v := int16(e.q.quantizeInt(s, 16))
+				e.sampleFormat.Endian.PutUint16(e.buf[offset:], uint16(v)^1<<15)
+			case 24:
+				v := int32(e.q.quantizeInt(s, 24))
+				putUint24(e.buf[offset:], uint32(v)^1<<23, e.sampleFormat.Endian)
+			case 32:
+				v := int32(e.q.quantizeInt(s, 32))
+				e.sampleFormat.Endian.PutUint32(e.buf[offset:], uint32(v)^1<<31)
+			case 64:
+				e.sampleFormat.Endian.PutUint64(e.buf[offset:], uint64(int64Sample(s))^1<<63)
 			default:
 				return 0, ErrInvalidBitDepth
 			}
@@ -97,6 +129,16 @@ func (e *encoder) WriteSamples(p []float32) (int, error) {
 	return n / sampleSize, nil
 }
 
+// int64Sample scales s to the full int64 range.
+// s * (1<<63 - 1) rounds up to 1<<63 in float64, which does not fit into an int64.
+func int64Sample(s float64) int64 {
+	v := math.Round(s * (1<<63 - 1))
+	if v >= 1<<63 {
+		return math.MaxInt64
+	}
+	return int64(v)
+}
+
 func putUint24(p []byte, v uint32, endian binary.ByteOrder) {
 	if len(p) < 3 {
 		return
@@ -116,9 +158,9 @@ func putUint24(p []byte, v uint32, endian binary.ByteOrder) {
 }
 
 // Encode encodes a slice of float32 samples into a PCM byte slice.
-func Encode(s []float32, sampleFormat afmt.SampleFormat) ([]byte, error) {
+func Encode(s []float32, sampleFormat afmt.SampleFormat, opts ...Option) ([]byte, error) {
 	var buf bytes.Buffer
-	enc := NewEncoder(&buf, sampleFormat)
+	enc := NewEncoder(&buf, sampleFormat, opts...)
 	_, err := enc.WriteSamples(s)
 	if err != nil {
 		return nil, err
@@ -3,32 +3,53 @@ package pcm_test
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
 	"testing"
+	"testing/iotest"
 
 	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
 	"github.com/MatusOllah/resona/encoding/pcm"
 	"github.com/MatusOllah/resona/internal/testutil"
 )
 
 func TestPCMRoundTrip(t *testing.T) {
-	samples := []float32{0.0, 0.5, -0.5, 1.0, -1.0}
+	samples := []float32{0.0, 0.5, -0.5, 1.0, -1.0, 0.123456, -0.987654, 1e-4}
+
+	// Integer formats up to 24 bits are checked to within one quantization step.
+	// 32- and 64-bit integers have more precision than the 24-bit mantissa of a float32,
+	// so they can't round-trip exactly either; 1e-7 allows for about one float32 ULP near 1.0.
+	const tolWide = 1e-7
 
 	tests := []struct {
 		name         string
 		sampleFormat afmt.SampleFormat
+		tolerance    float64
 	}{
-		{"Int8", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 8}},
-		{"Int16LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 16, Endian: binary.LittleEndian}},
-		{"Int16BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 16, Endian: binary.BigEndian}},
-		{"Int24LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 24, Endian: binary.LittleEndian}},
-		{"Int24BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 24, Endian: binary.BigEndian}},
-		{"Int32LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 32, Endian: binary.LittleEndian}},
-		{"Int32BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 32, Endian: binary.BigEndian}},
-		{"Uint8", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 8}},
-		{"Float32LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingFloat, BitDepth: 32, Endian: binary.LittleEndian}},
-		{"Float32BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingFloat, BitDepth: 32, Endian: binary.BigEndian}},
-		{"Float64LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingFloat, BitDepth: 64, Endian: binary.LittleEndian}},
-		{"Float64BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingFloat, BitDepth: 64, Endian: binary.BigEndian}},
+		{"Int8", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 8}, 1.0 / (1<<7 - 1)},
+		{"Int16LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 16, Endian: binary.LittleEndian}, 1.0 / (1<<15 - 1)},
+		{"Int16BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 16, Endian: binary.BigEndian}, 1.0 / (1<<15 - 1)},
+		{"Int24LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 24, Endian: binary.LittleEndian}, 1.0 / (1<<23 - 1)},
+		{"Int24BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 24, Endian: binary.BigEndian}, 1.0 / (1<<23 - 1)},
+		{"Int32LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 32, Endian: binary.LittleEndian}, tolWide},
+		{"Int32BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 32, Endian: binary.BigEndian}, tolWide},
+		{"Int64LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 64, Endian: binary.LittleEndian}, tolWide},
+		{"Int64BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 64, Endian: binary.BigEndian}, tolWide},
+		{"Uint8", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 8}, 1.0 / (1<<7 - 1)},
+		{"Uint16LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 16, Endian: binary.LittleEndian}, 1.0 / (1<<15 - 1)},
+		{"Uint16BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 16, Endian: binary.BigEndian}, 1.0 / (1<<15 - 1)},
+		{"Uint24LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 24, Endian: binary.LittleEndian}, 1.0 / (1<<23 - 1)},
+		{"Uint24BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 24, Endian: binary.BigEndian}, 1.0 / (1<<23 - 1)},
+		{"Uint32LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 32, Endian: binary.LittleEndian}, tolWide},
+		{"Uint32BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 32, Endian: binary.BigEndian}, tolWide},
+		{"Uint64LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 64, Endian: binary.LittleEndian}, tolWide},
+		{"Uint64BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 64, Endian: binary.BigEndian}, tolWide},
+		{"Float32LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingFloat, BitDepth: 32, Endian: binary.LittleEndian}, 0},
+		{"Float32BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingFloat, BitDepth: 32, Endian: binary.BigEndian}, 0},
+		{"Float64LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingFloat, BitDepth: 64, Endian: binary.LittleEndian}, 0},
+		{"Float64BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingFloat, BitDepth: 64, Endian: binary.BigEndian}, 0},
 	}
 
 	for _, tt := range tests {
@@ -44,6 +65,9 @@ func TestPCMRoundTrip(t *testing.T) {
 			if n != len(samples) {
 				t.Fatalf("Expected to encode %d samples, got %d", len(samples), n)
 			}
+			if buf.Len() != len(samples)*tt.sampleFormat.BytesPerSample() {
+				t.Fatalf("Expected %d bytes, got %d", len(samples)*tt.sampleFormat.BytesPerSample(), buf.Len())
+			}
 
 			// Decode
 			decoder := pcm.NewDecoder(&buf, tt.sampleFormat)
@@ -58,9 +82,156 @@ func TestPCMRoundTrip(t *testing.T) {
 			}
 
 			// Verify
-			if !testutil.EqualSliceWithinTolerance(samples, decodedSamples, 1e-2) {
+			if !testutil.EqualSliceWithinTolerance(samples, decodedSamples, tt.tolerance) {
 				t.Errorf("Decoded samples do not match original samples: got %v, want %v", decodedSamples, samples)
 			}
 		})
 	}
 }
+
+func TestPCMEncodeWide(t *testing.T) {
+	tests := []struct {
+		name         string
+		sampleFormat afmt.SampleFormat
+		want         []byte // encoded 0, 1, -1
+	}{
+		{"Uint16LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 16, Endian: binary.LittleEndian}, []byte{
+			0x00, 0x80,
+			0xFF, 0xFF,
+			0x01, 0x00,
+		}},
+		{"Uint24BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 24, Endian: binary.BigEndian}, []byte{
+			0x80, 0x00, 0x00,
+			0xFF, 0xFF, 0xFF,
+			0x00, 0x00, 0x01,
+		}},
+		{"Uint32BE", afmt.SampleFormat{Encoding: afmt.SampleEncodingUint, BitDepth: 32, Endian: binary.BigEndian}, []byte{
+			0x80, 0x00, 0x00, 0x00,
+			0xFF, 0xFF, 0xFF, 0xFF,
+			0x00, 0x00, 0x00, 0x01,
+		}},
+		{"Int64LE", afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 64, Endian: binary.LittleEndian}, []byte{
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x7F,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pcm.Encode([]float32{0, 1, -1}, tt.sampleFormat)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("expected % X, got % X", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPCMInvalidBitDepth(t *testing.T) {
+	for _, f := range []afmt.SampleFormat{
+		{Encoding: afmt.SampleEncodingInt, BitDepth: 12},
+		{Encoding: afmt.SampleEncodingUint, BitDepth: 20},
+		{Encoding: afmt.SampleEncodingFloat, BitDepth: 16},
+	} {
+		t.Run(f.String(), func(t *testing.T) {
+			if _, err := pcm.Encode([]float32{0}, f); !errors.Is(err, pcm.ErrInvalidBitDepth) {
+				t.Errorf("Encode: expected ErrInvalidBitDepth, got %v", err)
+			}
+			if _, err := pcm.Decode(make([]byte, 4), f); !errors.Is(err, pcm.ErrInvalidBitDepth) {
+				t.Errorf("Decode: expected ErrInvalidBitDepth, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecoderPartialReads(t *testing.T) {
+	sampleFormat := afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 24, Endian: binary.LittleEndian}
+
+	data := make([]byte, 3*1000)
+	for i := range data {
+		data[i] = byte(i*31 + i/7)
+	}
+	want, err := pcm.Decode(data, sampleFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) != 1000 {
+		t.Fatalf("expected 1000 samples, got %d", len(want))
+	}
+
+	for name, r := range map[string]func() io.Reader{
+		"OneByteReader": func() io.Reader { return iotest.OneByteReader(bytes.NewReader(data)) },
+		"HalfReader":    func() io.Reader { return iotest.HalfReader(bytes.NewReader(data)) },
+		"DataErrReader": func() io.Reader { return iotest.DataErrReader(bytes.NewReader(data)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			dec := pcm.NewDecoder(r(), sampleFormat)
+
+			// odd buffer sizes so that reads never line up with the samples
+			var got []float32
+			buf := make([]float32, 7)
+			for {
+				n, err := dec.ReadSamples(buf)
+				got = append(got, buf[:n]...)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !slices.Equal(got, want) {
+				t.Errorf("decoded samples differ from a single read")
+			}
+		})
+	}
+}
+
+func TestDecoderTruncated(t *testing.T) {
+	sampleFormat := afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 24, Endian: binary.BigEndian}
+	data := []byte{0x40, 0x00, 0x00, 0xC0, 0x00, 0x00, 0x12, 0x34}
+
+	dec := pcm.NewDecoder(iotest.OneByteReader(bytes.NewReader(data)), sampleFormat)
+	p := make([]float32, 4)
+	n, err := aio.ReadFull(dec, p)
+	if n != 2 || err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected 2 samples and io.ErrUnexpectedEOF, got %d, %v", n, err)
+	}
+	if p[0] <= 0 || p[1] >= 0 {
+		t.Errorf("unexpected samples %v", p[:n])
+	}
+}
+
+func TestDecoderReset(t *testing.T) {
+	sampleFormat := afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 24, Endian: binary.LittleEndian}
+	want := []float32{0.1, 0.2, 0.3, 0.4, 0.5}
+	data, err := pcm.Encode(want, sampleFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 5 bytes per read leave an incomplete sample behind
+	r := &testutil.ShortReadSeeker{ReadSeeker: bytes.NewReader(data), N: 5}
+	dec := pcm.NewDecoder(r, sampleFormat).(*pcm.Decoder)
+	if n, err := dec.ReadSamples(make([]float32, 2)); n != 1 || err != nil {
+		t.Fatalf("expected 1 sample of the 5 bytes read, got %d, %v", n, err)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	dec.Reset(r)
+	p := make([]float32, 3)
+	if _, err := aio.ReadFull(dec, p); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range p {
+		if !testutil.EqualWithinTolerance(v, want[i], 1e-6) {
+			t.Fatalf("expected %v after seeking to the start, got %v", want[:3], p)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package pcm
+
+import (
+	"errors"
+	"io"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+)
+
+var (
+	_ aio.SampleReader      = (*PlanarDecoder)(nil)
+	_ aio.SampleWriteCloser = (*PlanarEncoder)(nil)
+)
+
+// PlanarDecoder reads and decodes PCM samples stored in planes.
+//
+// The stream consists of blocks of a fixed number of frames. Each block stores all samples
+// of the first channel, followed by all samples of the second channel and so on.
+// The last block may be shorter, as long as all of its planes have the same length.
+//
+// ReadSamples converts the samples to interleaved frames, like every [aio.SampleReader].
+// ReadPlanes returns the samples of every channel in a separate slice without interleaving.
+type PlanarDecoder struct {
+	dec         aio.SampleReader
+	numChannels int
+	frames      int
+	block       []float32 // current block, planar
+	blockFrames int       // number of frames in block
+	pos         int       // next sample in block, in interleaved order
+	err         error
+}
+
+// NewPlanarDecoder returns a new PlanarDecoder that reads blocks of the given number of frames
+// with numChannels planes each from r.
+func NewPlanarDecoder(r io.Reader, sampleFormat afmt.SampleFormat, numChannels, frames int) *PlanarDecoder {
+	d := &PlanarDecoder{
+		dec:         NewDecoder(r, sampleFormat),
+		numChannels: numChannels,
+		frames:      frames,
+	}
+	if numChannels <= 0 {
+		d.err = errors.New("pcm: invalid number of channels")
+	} else if frames <= 0 {
+		d.err = errors.New("pcm: invalid number of frames per block")
+	} else {
+		d.block = make([]float32, numChannels*frames)
+	}
+	return d
+}
+
+func (d *PlanarDecoder) fill() error {
+	n, err := aio.ReadFull(d.dec, d.block)
+	if err == io.ErrUnexpectedEOF && n%d.numChannels == 0 {
+		// short last block, the planes are shorter too
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	d.blockFrames = n / d.numChannels
+	d.pos = 0
+	return nil
+}
+
+// ReadSamples reads and decodes interleaved float32 samples into p.
+// It returns the number of samples read and/or an error.
+func (d *PlanarDecoder) ReadSamples(p []float32) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	for n := range p {
+		if d.pos == d.blockFrames*d.numChannels {
+			if err := d.fill(); err != nil {
+				return n, err
+			}
+		}
+		frame, ch := d.pos/d.numChannels, d.pos%d.numChannels
+		p[n] = d.block[ch*d.blockFrames+frame]
+		d.pos++
+	}
+	return len(p), nil
+}
+
+// ReadPlanes reads and decodes samples into p, one slice per channel.
+// It reads up to the length of the shortest slice in p and returns the number of frames read and/or an error.
+func (d *PlanarDecoder) ReadPlanes(p [][]float32) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if len(p) != d.numChannels {
+		return 0, errors.New("pcm: number of planes does not match the number of channels")
+	}
+	if d.pos%d.numChannels != 0 {
+		return 0, errors.New("pcm: cannot read planes in the middle of a frame")
+	}
+
+	numFrames := len(p[0])
+	for _, plane := range p[1:] {
+		numFrames = min(numFrames, len(plane))
+	}
+
+	n := 0
+	for n < numFrames {
+		if d.pos == d.blockFrames*d.numChannels {
+			if err := d.fill(); err != nil {
+				return n, err
+			}
+		}
+		frame := d.pos / d.numChannels
+		count := min(numFrames-n, d.blockFrames-frame)
+		for ch, plane := range p {
+			copy(plane[n:n+count], d.block[ch*d.blockFrames+frame:])
+		}
+		d.pos += count * d.numChannels
+		n += count
+	}
+	return n, nil
+}
+
+// PlanarEncoder encodes and writes PCM samples in planes.
+// See [PlanarDecoder] for the layout.
+//
+// WriteSamples takes interleaved frames, like every [aio.SampleWriter].
+// WritePlanes takes the samples of every channel in a separate slice.
+// Close must be called to write the last, possibly shorter, block.
+type PlanarEncoder struct {
+	enc         aio.SampleWriter
+	numChannels int
+	frames      int
+	block       []float32 // current block, planar with a stride of frames
+	pos         int       // next sample in block, in interleaved order
+	planar      []float32 // block with the planes packed together, for a short last block
+	err         error
+}
+
+// NewPlanarEncoder returns a new PlanarEncoder that writes blocks of the given number of frames
+// with numChannels planes each to w.
+func NewPlanarEncoder(w io.Writer, sampleFormat afmt.SampleFormat, numChannels, frames int) *PlanarEncoder {
+	e := &PlanarEncoder{
+		enc:         NewEncoder(w, sampleFormat),
+		numChannels: numChannels,
+		frames:      frames,
+	}
+	if numChannels <= 0 {
+		e.err = errors.New("pcm: invalid number of channels")
+	} else if frames <= 0 {
+		e.err = errors.New("pcm: invalid number of frames per block")
+	} else {
+		e.block = make([]float32, numChannels*frames)
+	}
+	return e
+}
+
+// flush writes the first numFrames frames of every plane in the current block.
+func (e *PlanarEncoder) flush(numFrames int) error {
+	buf := e.block
+	if numFrames < e.frames {
+		e.planar = e.planar[:0]
+		for ch := range e.numChannels {
+			e.planar = append(e.planar, e.block[ch*e.frames:ch*e.frames+numFrames]...)
+		}
+		buf = e.planar
+	}
+
+	if _, err := e.enc.WriteSamples(buf); err != nil {
+		return err
+	}
+	e.pos = 0
+	return nil
+}
+
+// WriteSamples encodes and writes interleaved float32 samples from p.
+// It returns the number of samples written and/or an error.
+func (e *PlanarEncoder) WriteSamples(p []float32) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+
+	for n, s := range p {
+		frame, ch := e.pos/e.numChannels, e.pos%e.numChannels
+		e.block[ch*e.frames+frame] = s
+		e.pos++
+
+		if e.pos == e.frames*e.numChannels {
+			if err := e.flush(e.frames); err != nil {
+				return n + 1, err
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// WritePlanes encodes and writes samples from p, one slice per channel.
+// It writes up to the length of the shortest slice in p and returns the number of frames written and/or an error.
+func (e *PlanarEncoder) WritePlanes(p [][]float32) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	if len(p) != e.numChannels {
+		return 0, errors.New("pcm: number of planes does not match the number of channels")
+	}
+	if e.pos%e.numChannels != 0 {
+		return 0, errors.New("pcm: cannot write planes in the middle of a frame")
+	}
+
+	numFrames := len(p[0])
+	for _, plane := range p[1:] {
+		numFrames = min(numFrames, len(plane))
+	}
+
+	n := 0
+	for n < numFrames {
+		frame := e.pos / e.numChannels
+		count := min(numFrames-n, e.frames-frame)
+		for ch, plane := range p {
+			copy(e.block[ch*e.frames+frame:], plane[n:n+count])
+		}
+		e.pos += count * e.numChannels
+		n += count
+
+		if e.pos == e.frames*e.numChannels {
+			if err := e.flush(e.frames); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close writes the last block, if it is not empty.
+// It returns an error if the last frame is incomplete.
+// It does not close the underlying [io.Writer].
+func (e *PlanarEncoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.pos%e.numChannels != 0 {
+		return errors.New("pcm: incomplete frame")
+	}
+	if e.pos == 0 {
+		return nil
+	}
+	return e.flush(e.pos / e.numChannels)
+}
@@ -0,0 +1,176 @@
+package pcm_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/encoding/pcm"
+)
+
+const (
+	planarChannels = 3
+	planarFrames   = 4 // frames per block
+)
+
+var planarFormat = afmt.SampleFormat{Encoding: afmt.SampleEncodingInt, BitDepth: 16, Endian: binary.LittleEndian}
+
+// planarTestData returns 11 interleaved frames and their planar encoding with blocks of planarFrames frames.
+func planarTestData(t *testing.T) ([]float32, []byte) {
+	t.Helper()
+
+	const numFrames = 11
+	interleaved := make([]float32, numFrames*planarChannels)
+	for i := range interleaved {
+		interleaved[i] = float32(i%17)/17 - 0.5
+	}
+
+	var planar []byte
+	for start := 0; start < numFrames; start += planarFrames {
+		end := min(start+planarFrames, numFrames)
+		for ch := range planarChannels {
+			var plane []float32
+			for i := start; i < end; i++ {
+				plane = append(plane, interleaved[i*planarChannels+ch])
+			}
+			b, err := pcm.Encode(plane, planarFormat)
+			if err != nil {
+				t.Fatal(err)
+			}
+			planar = append(planar, b...)
+		}
+	}
+
+	// round the samples the same way the interleaved path does
+	b, err := pcm.Encode(interleaved, planarFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	interleaved, err = pcm.Decode(b, planarFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return interleaved, planar
+}
+
+func TestPlanarDecoder(t *testing.T) {
+	want, planar := planarTestData(t)
+
+	dec := pcm.NewPlanarDecoder(bytes.NewReader(planar), planarFormat, planarChannels, planarFrames)
+	var got []float32
+	buf := make([]float32, 5) // not a whole number of frames
+	for {
+		n, err := dec.ReadSamples(buf)
+		got = append(got, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPlanarDecoderPlanes(t *testing.T) {
+	want, planar := planarTestData(t)
+
+	dec := pcm.NewPlanarDecoder(bytes.NewReader(planar), planarFormat, planarChannels, planarFrames)
+	planes := make([][]float32, planarChannels)
+	for ch := range planes {
+		planes[ch] = make([]float32, 20)
+	}
+	n, err := dec.ReadPlanes(planes)
+	if err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+	if n != len(want)/planarChannels {
+		t.Fatalf("expected %d frames, got %d", len(want)/planarChannels, n)
+	}
+
+	for ch, plane := range planes {
+		for i, s := range plane[:n] {
+			if s != want[i*planarChannels+ch] {
+				t.Errorf("channel %d, frame %d: expected %v, got %v", ch, i, want[i*planarChannels+ch], s)
+			}
+		}
+	}
+}
+
+func TestPlanarEncoder(t *testing.T) {
+	interleaved, want := planarTestData(t)
+
+	var buf bytes.Buffer
+	enc := pcm.NewPlanarEncoder(&buf, planarFormat, planarChannels, planarFrames)
+	// write in chunks that do not line up with the frames or blocks
+	for chunk := range slices.Chunk(interleaved, 5) {
+		if _, err := enc.WriteSamples(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("expected % X, got % X", want, buf.Bytes())
+	}
+}
+
+func TestPlanarEncoderPlanes(t *testing.T) {
+	interleaved, want := planarTestData(t)
+
+	planes := make([][]float32, planarChannels)
+	for i, s := range interleaved {
+		planes[i%planarChannels] = append(planes[i%planarChannels], s)
+	}
+
+	var buf bytes.Buffer
+	enc := pcm.NewPlanarEncoder(&buf, planarFormat, planarChannels, planarFrames)
+	n, err := enc.WritePlanes(planes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(planes[0]) {
+		t.Errorf("expected %d frames, got %d", len(planes[0]), n)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("expected % X, got % X", want, buf.Bytes())
+	}
+
+	// and back
+	got, err := aio.ReadAll(pcm.NewPlanarDecoder(&buf, planarFormat, planarChannels, planarFrames))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, interleaved) {
+		t.Errorf("expected %v, got %v", interleaved, got)
+	}
+}
+
+func TestPlanarInvalid(t *testing.T) {
+	if _, err := pcm.NewPlanarDecoder(bytes.NewReader(nil), planarFormat, 0, 4).ReadSamples(make([]float32, 4)); err == nil {
+		t.Error("expected error for 0 channels")
+	}
+	if _, err := pcm.NewPlanarEncoder(io.Discard, planarFormat, 2, 0).WriteSamples(make([]float32, 4)); err == nil {
+		t.Error("expected error for 0 frames per block")
+	}
+
+	// the last block must contain whole frames
+	dec := pcm.NewPlanarDecoder(bytes.NewReader(make([]byte, 3*2)), planarFormat, 2, 4)
+	if _, err := aio.ReadAll(dec); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package freq
+
+import "slices"
+
+// Standard sample rates of digital audio, in the 48 kHz family (multiples of 8 kHz)
+// and the 44.1 kHz family (multiples of 11.025 kHz).
+const (
+	SampleRate8k     = 8 * KiloHertz
+	SampleRate11k025 = 11025 * Hertz
+	SampleRate16k    = 16 * KiloHertz
+	SampleRate22k05  = 22050 * Hertz
+	SampleRate32k    = 32 * KiloHertz
+	SampleRate44k1   = 44100 * Hertz
+	SampleRate48k    = 48 * KiloHertz
+	SampleRate88k2   = 88200 * Hertz
+	SampleRate96k    = 96 * KiloHertz
+	SampleRate176k4  = 176400 * Hertz
+	SampleRate192k   = 192 * KiloHertz
+)
+
+var standardRates = []Frequency{
+	SampleRate8k,
+	SampleRate11k025,
+	SampleRate16k,
+	SampleRate22k05,
+	SampleRate32k,
+	SampleRate44k1,
+	SampleRate48k,
+	SampleRate88k2,
+	SampleRate96k,
+	SampleRate176k4,
+	SampleRate192k,
+}
+
+// StandardRates returns the standard sample rates in ascending order, such as for a UI to offer.
+// The returned slice is a copy, which the caller may modify.
+func StandardRates() []Frequency {
+	return slices.Clone(standardRates)
+}
+
+// NearestStandardRate returns the standard sample rate closest to f, which is f itself if it is standard.
+// If f lies exactly halfway between two standard rates, the one in the 48 kHz family is returned.
+func NearestStandardRate(f Frequency) Frequency {
+	nearest := standardRates[0]
+	if f <= nearest {
+		return nearest
+	}
+	for _, rate := range standardRates[1:] {
+		d, nd := distance(f, rate), distance(f, nearest)
+		if d < nd || (d == nd && rate%SampleRate8k == 0) {
+			nearest = rate
+		}
+	}
+	return nearest
+}
+
+func distance(a, b Frequency) Frequency {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
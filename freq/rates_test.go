@@ -0,0 +1,72 @@
+package freq_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/freq"
+)
+
+func TestStandardRates(t *testing.T) {
+	rates := freq.StandardRates()
+	want := []freq.Frequency{8000, 11025, 16000, 22050, 32000, 44100, 48000, 88200, 96000, 176400, 192000}
+	for i := range want {
+		want[i] *= freq.Hertz
+	}
+	if !slices.Equal(rates, want) {
+		t.Fatalf("expected %v, got %v", want, rates)
+	}
+
+	rates[0] = 0
+	if freq.StandardRates()[0] != freq.SampleRate8k {
+		t.Error("expected StandardRates to return a copy")
+	}
+}
+
+func TestNearestStandardRate(t *testing.T) {
+	tests := []struct {
+		f, want freq.Frequency
+	}{
+		// standard rates are exact
+		{freq.SampleRate8k, freq.SampleRate8k},
+		{freq.SampleRate11k025, freq.SampleRate11k025},
+		{freq.SampleRate16k, freq.SampleRate16k},
+		{freq.SampleRate22k05, freq.SampleRate22k05},
+		{freq.SampleRate32k, freq.SampleRate32k},
+		{freq.SampleRate44k1, freq.SampleRate44k1},
+		{freq.SampleRate48k, freq.SampleRate48k},
+		{freq.SampleRate88k2, freq.SampleRate88k2},
+		{freq.SampleRate96k, freq.SampleRate96k},
+		{freq.SampleRate176k4, freq.SampleRate176k4},
+		{freq.SampleRate192k, freq.SampleRate192k},
+
+		// off by a little
+		{44099 * freq.Hertz, freq.SampleRate44k1},
+		{44100*freq.Hertz + 1, freq.SampleRate44k1},
+		{47999900 * freq.MilliHertz, freq.SampleRate48k},
+		{12 * freq.KiloHertz, freq.SampleRate11k025},
+		{24 * freq.KiloHertz, freq.SampleRate22k05},
+		{64 * freq.KiloHertz, freq.SampleRate48k},
+		{46050*freq.Hertz - 1, freq.SampleRate44k1},
+		{46050*freq.Hertz + 1, freq.SampleRate48k},
+
+		// ties go to the 48 kHz family
+		{9512500 * freq.MilliHertz, freq.SampleRate8k},
+		{13512500 * freq.MilliHertz, freq.SampleRate16k},
+		{46050 * freq.Hertz, freq.SampleRate48k},
+		{92100 * freq.Hertz, freq.SampleRate96k},
+		{184200 * freq.Hertz, freq.SampleRate192k},
+
+		// out of range
+		{0, freq.SampleRate8k},
+		{-48 * freq.KiloHertz, freq.SampleRate8k},
+		{1 * freq.Hertz, freq.SampleRate8k},
+		{384 * freq.KiloHertz, freq.SampleRate192k},
+		{freq.GigaHertz, freq.SampleRate192k},
+	}
+	for _, tt := range tests {
+		if got := freq.NearestStandardRate(tt.f); got != tt.want {
+			t.Errorf("NearestStandardRate(%v) = %v; want %v", tt.f, got, tt.want)
+		}
+	}
+}
@@ -4,8 +4,10 @@ go 1.24
 
 require (
 	github.com/ebitengine/oto/v3 v3.3.3
+	github.com/gen2brain/malgo v0.11.24
 	github.com/jfreymuth/oggvorbis v1.0.5
 	github.com/mewkiz/flac v1.0.12
+	golang.org/x/sys v0.25.0
 )
 
 require (
@@ -13,5 +15,4 @@ require (
 	github.com/icza/bitio v1.1.0 // indirect
 	github.com/jfreymuth/vorbis v1.0.2 // indirect
 	github.com/mewkiz/pkg v0.0.0-20230226050401-4010bf0fec14 // indirect
-	golang.org/x/sys v0.25.0 // indirect
 )
@@ -0,0 +1,177 @@
+// Package encoders is the table of the encoders the commands can write files with.
+package encoders
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec/aiff"
+	"github.com/MatusOllah/resona/codec/au"
+	"github.com/MatusOllah/resona/codec/avr"
+	"github.com/MatusOllah/resona/codec/qoa"
+	"github.com/MatusOllah/resona/codec/wav"
+)
+
+// Encoder describes an output format the commands can write.
+type Encoder struct {
+	Name string
+	Exts []string
+
+	// IntDepths and FloatDepths are the bit depths of the integer and floating-point samples the format can store.
+	// Both are empty for lossy formats, which have no bit depth.
+	IntDepths, FloatDepths []int
+
+	// New creates an encoder for the format, with samples of the given bit depth unless the format is lossy.
+	New func(w io.WriteSeeker, format afmt.Format, sampleFmt afmt.SampleFormat) (aio.SampleWriteCloser, error)
+}
+
+var encoders = []Encoder{
+	{
+		Name:        "wav",
+		Exts:        []string{".wav", ".wave"},
+		IntDepths:   []int{8, 16, 24, 32},
+		FloatDepths: []int{32, 64},
+		New: func(w io.WriteSeeker, format afmt.Format, sampleFmt afmt.SampleFormat) (aio.SampleWriteCloser, error) {
+			sampleFmt.Endian = binary.LittleEndian
+			wavFormat := uint16(wav.FormatInt)
+			switch {
+			case sampleFmt.Encoding == afmt.SampleEncodingFloat:
+				wavFormat = wav.FormatFloat
+			case sampleFmt.BitDepth == 8:
+				sampleFmt.Encoding = afmt.SampleEncodingUint // 8-bit WAVE samples are unsigned
+			}
+			return wav.NewEncoder(w, format, sampleFmt, wavFormat)
+		},
+	},
+	{
+		Name:        "au",
+		Exts:        []string{".au", ".snd"},
+		IntDepths:   []int{8, 16, 24, 32},
+		FloatDepths: []int{32, 64},
+		New: func(w io.WriteSeeker, format afmt.Format, sampleFmt afmt.SampleFormat) (aio.SampleWriteCloser, error) {
+			var encoding uint32
+			if sampleFmt.Encoding == afmt.SampleEncodingFloat {
+				encoding = map[int]uint32{32: au.LPCMFloat32, 64: au.LPCMFloat64}[sampleFmt.BitDepth]
+			} else {
+				encoding = map[int]uint32{8: au.LPCMInt8, 16: au.LPCMInt16, 24: au.LPCMInt24, 32: au.LPCMInt32}[sampleFmt.BitDepth]
+			}
+			return au.NewEncoder(w, format, encoding, nil)
+		},
+	},
+	{
+		Name:      "aiff",
+		Exts:      []string{".aiff", ".aif"},
+		IntDepths: []int{8, 16, 24, 32},
+		New: func(w io.WriteSeeker, format afmt.Format, sampleFmt afmt.SampleFormat) (aio.SampleWriteCloser, error) {
+			return aiff.NewEncoder(w, format, sampleFmt)
+		},
+	},
+	{
+		Name:      "avr",
+		Exts:      []string{".avr"},
+		IntDepths: []int{8, 16},
+		New: func(w io.WriteSeeker, format afmt.Format, sampleFmt afmt.SampleFormat) (aio.SampleWriteCloser, error) {
+			return avr.NewEncoder(w, format, sampleFmt)
+		},
+	},
+	{
+		Name: "qoa",
+		Exts: []string{".qoa"},
+		New: func(w io.WriteSeeker, format afmt.Format, sampleFmt afmt.SampleFormat) (aio.SampleWriteCloser, error) {
+			return qoa.NewEncoder(w, format)
+		},
+	},
+}
+
+// Names returns the names of the output formats.
+func Names() []string {
+	names := make([]string, len(encoders))
+	for i, e := range encoders {
+		names[i] = e.Name
+	}
+	return names
+}
+
+// Lookup returns the output format with the given name.
+func Lookup(name string) (*Encoder, error) {
+	for i := range encoders {
+		if encoders[i].Name == strings.ToLower(name) {
+			return &encoders[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown output format %q (supported: %s)", name, strings.Join(Names(), ", "))
+}
+
+// FormatFromName infers the output format from the extension of a file name.
+func FormatFromName(name string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range encoders {
+		if slices.Contains(e.Exts, ext) {
+			return e.Name, nil
+		}
+	}
+	return "", fmt.Errorf("cannot infer the output format from %q", name)
+}
+
+// Lossy reports whether the format has no bit depth.
+func (e *Encoder) Lossy() bool {
+	return len(e.IntDepths) == 0 && len(e.FloatDepths) == 0
+}
+
+// SampleFormat returns the sample format to write, which is the requested one if the format can store it.
+// If the bit depth is 0, the sample format of the input is kept if possible, or 16-bit integers are written.
+func (e *Encoder) SampleFormat(bitDepth int, float bool, input afmt.SampleFormat) (afmt.SampleFormat, error) {
+	if e.Lossy() {
+		if bitDepth != 0 || float {
+			return afmt.SampleFormat{}, fmt.Errorf("%s is a lossy format and has no bit depth", e.Name)
+		}
+		return afmt.SampleFormat{}, nil
+	}
+
+	if bitDepth == 0 {
+		inFloat := input.Encoding == afmt.SampleEncodingFloat
+		if (!float || inFloat) && e.supports(input.BitDepth, inFloat) {
+			return e.intOrFloat(input.BitDepth, inFloat), nil
+		}
+		if !float {
+			return e.intOrFloat(16, false), nil
+		}
+		if len(e.FloatDepths) > 0 {
+			return e.intOrFloat(e.FloatDepths[0], true), nil
+		}
+	}
+	if !e.supports(bitDepth, float) {
+		kind := "integer"
+		depths := e.IntDepths
+		if float {
+			kind, depths = "floating-point", e.FloatDepths
+		}
+		if len(depths) == 0 {
+			return afmt.SampleFormat{}, fmt.Errorf("%s cannot store %s samples", e.Name, kind)
+		}
+		return afmt.SampleFormat{}, fmt.Errorf("%s cannot store %d-bit %s samples (supported bit depths: %s)",
+			e.Name, bitDepth, kind, strings.Trim(fmt.Sprint(depths), "[]"))
+	}
+	return e.intOrFloat(bitDepth, float), nil
+}
+
+func (e *Encoder) supports(bitDepth int, float bool) bool {
+	if float {
+		return slices.Contains(e.FloatDepths, bitDepth)
+	}
+	return slices.Contains(e.IntDepths, bitDepth)
+}
+
+func (e *Encoder) intOrFloat(bitDepth int, float bool) afmt.SampleFormat {
+	f := afmt.SampleFormat{BitDepth: bitDepth, Encoding: afmt.SampleEncodingInt, Endian: binary.BigEndian}
+	if float {
+		f.Encoding = afmt.SampleEncodingFloat
+	}
+	return f
+}
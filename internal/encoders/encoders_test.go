@@ -0,0 +1,23 @@
+package encoders_test
+
+import (
+	"testing"
+
+	"github.com/MatusOllah/resona/internal/encoders"
+)
+
+func TestFormatFromName(t *testing.T) {
+	for name, want := range map[string]string{
+		"out.wav":       "wav",
+		"OUT.AIF":       "aiff",
+		"dir.x/out.snd": "au",
+		"a.qoa":         "qoa",
+	} {
+		if got, err := encoders.FormatFromName(name); err != nil || got != want {
+			t.Errorf("encoders.FormatFromName(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := encoders.FormatFromName("out.xyz"); err == nil {
+		t.Error("expected an error for an unknown extension")
+	}
+}
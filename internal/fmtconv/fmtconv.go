@@ -0,0 +1,35 @@
+// Package fmtconv converts audio streams between formats, for the packages and commands that adapt their input.
+package fmtconv
+
+import (
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/resample"
+)
+
+// Convert converts the samples of r from the format from to the format to, mapping the channels with [audio.MapChannels]
+// and resampling with the pure Go resampler at the given quality as needed. The channels are mapped before resampling
+// when there are fewer of them afterwards, and after resampling otherwise, so that as few channels as possible are resampled.
+//
+// The resampler is returned as well, so that it can be closed; it is nil if the sample rates match.
+func Convert(r aio.SampleReader, from, to afmt.Format, quality resample.Quality) (aio.SampleReader, resample.Resampler, error) {
+	if to.NumChannels < from.NumChannels {
+		r = audio.MapChannels(r, from.NumChannels, to.NumChannels)
+	}
+
+	var rs resample.Resampler
+	if from.SampleRate != to.SampleRate {
+		var err error
+		rs, err = resample.New("go", r, from.SampleRate, to.SampleRate, min(from.NumChannels, to.NumChannels), quality)
+		if err != nil {
+			return nil, nil, err
+		}
+		r = rs
+	}
+
+	if to.NumChannels > from.NumChannels {
+		r = audio.MapChannels(r, from.NumChannels, to.NumChannels)
+	}
+	return r, rs, nil
+}
@@ -0,0 +1,11 @@
+package term
+
+import "errors"
+
+// ErrUnsupported is returned by [MakeRaw] on platforms without raw mode support.
+var ErrUnsupported = errors.New("term: raw mode not supported on this platform")
+
+// State is the state of a terminal, to restore after [MakeRaw].
+type State struct {
+	state
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly && !windows
+
+package term
+
+type state struct{}
+
+// IsTerminal reports whether fd is a terminal. It always returns false on this platform.
+func IsTerminal(fd int) bool {
+	return false
+}
+
+// MakeRaw returns [ErrUnsupported] on this platform.
+func MakeRaw(fd int) (*State, error) {
+	return nil, ErrUnsupported
+}
+
+// Restore returns [ErrUnsupported] on this platform.
+func Restore(fd int, s *State) error {
+	return ErrUnsupported
+}
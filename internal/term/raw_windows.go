@@ -0,0 +1,33 @@
+package term
+
+import "golang.org/x/sys/windows"
+
+type state struct {
+	mode uint32
+}
+
+// IsTerminal reports whether fd is a terminal.
+func IsTerminal(fd int) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(fd), &mode) == nil
+}
+
+// MakeRaw puts the terminal fd into raw mode, in which key presses are read as they are typed, without echo,
+// and returns its previous state. Arrow keys are sent as ANSI escape sequences, like on other platforms.
+func MakeRaw(fd int) (*State, error) {
+	var mode uint32
+	if err := windows.GetConsoleMode(windows.Handle(fd), &mode); err != nil {
+		return nil, err
+	}
+	raw := mode &^ (windows.ENABLE_ECHO_INPUT | windows.ENABLE_PROCESSED_INPUT | windows.ENABLE_LINE_INPUT)
+	raw |= windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(windows.Handle(fd), raw); err != nil {
+		return nil, err
+	}
+	return &State{state{mode: mode}}, nil
+}
+
+// Restore restores the terminal fd to a state returned by [MakeRaw].
+func Restore(fd int, s *State) error {
+	return windows.SetConsoleMode(windows.Handle(fd), s.mode)
+}
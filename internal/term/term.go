@@ -0,0 +1,106 @@
+// Package term provides the terminal handling of the command line tools: raw mode and decoding of key presses.
+package term
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Key represents a key press. Printable keys are their runes; special keys are negative.
+type Key rune
+
+// Special keys.
+const (
+	KeyUnknown Key = -(iota + 1)
+	KeyUp
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyEscape
+)
+
+// Control characters, which keep their values in raw mode.
+const (
+	KeyCtrlC Key = 0x03
+	KeyCtrlD Key = 0x04
+	KeyEnter Key = '\r'
+)
+
+// ParseKeys decodes the key presses in b, which is what a terminal in raw mode sent in one read.
+// Arrow keys are decoded from their ANSI escape sequences; other escape sequences are [KeyUnknown].
+func ParseKeys(b []byte) []Key {
+	var keys []Key
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			k, n := parseEscape(b)
+			keys = append(keys, k)
+			b = b[n:]
+			continue
+		}
+		r, n := utf8.DecodeRune(b)
+		if r == utf8.RuneError {
+			keys = append(keys, KeyUnknown)
+		} else {
+			keys = append(keys, Key(r))
+		}
+		b = b[n:]
+	}
+	return keys
+}
+
+// parseEscape decodes the escape sequence at the start of b and returns its key and length.
+func parseEscape(b []byte) (Key, int) {
+	if len(b) < 2 || (b[1] != '[' && b[1] != 'O') {
+		return KeyEscape, 1
+	}
+	// CSI and SS3 sequences end with a byte from 0x40 to 0x7e
+	for i := 2; i < len(b); i++ {
+		if b[i] < 0x40 || b[i] > 0x7e {
+			continue
+		}
+		if i != 2 {
+			return KeyUnknown, i + 1
+		}
+		switch b[i] {
+		case 'A':
+			return KeyUp, 3
+		case 'B':
+			return KeyDown, 3
+		case 'C':
+			return KeyRight, 3
+		case 'D':
+			return KeyLeft, 3
+		default:
+			return KeyUnknown, 3
+		}
+	}
+	return KeyUnknown, len(b)
+}
+
+// ReadKeys reads key presses from r, which is a terminal in raw mode, and sends them to keys until r fails.
+// It closes keys when it returns.
+func ReadKeys(r io.Reader, keys chan<- Key) error {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		for _, k := range ParseKeys(buf[:n]) {
+			keys <- k
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Bindings maps key presses to the functions that handle them.
+type Bindings map[Key]func()
+
+// Dispatch calls the function bound to k, and reports whether there is one.
+func (b Bindings) Dispatch(k Key) bool {
+	f, ok := b[k]
+	if ok {
+		f()
+	}
+	return ok
+}
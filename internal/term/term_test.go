@@ -0,0 +1,73 @@
+package term_test
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/internal/term"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []term.Key
+	}{
+		{"Empty", "", nil},
+		{"Runes", " q+-", []term.Key{' ', 'q', '+', '-'}},
+		{"UTF8", "é", []term.Key{'é'}},
+		{"Arrows", "\x1b[A\x1b[B\x1b[C\x1b[D", []term.Key{term.KeyUp, term.KeyDown, term.KeyRight, term.KeyLeft}},
+		{"SS3Arrows", "\x1bOC\x1bOD", []term.Key{term.KeyRight, term.KeyLeft}},
+		{"Escape", "\x1b", []term.Key{term.KeyEscape}},
+		{"EscapeThenRune", "\x1bq", []term.Key{term.KeyEscape, 'q'}},
+		{"UnknownSequence", "\x1b[1;5C ", []term.Key{term.KeyUnknown, ' '}},
+		{"TruncatedSequence", "\x1b[1", []term.Key{term.KeyUnknown}},
+		{"CtrlC", "\x03", []term.Key{term.KeyCtrlC}},
+		{"InvalidUTF8", "\xff+", []term.Key{term.KeyUnknown, '+'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := term.ParseKeys([]byte(tt.in)); !slices.Equal(got, tt.want) {
+				t.Errorf("ParseKeys(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadKeys(t *testing.T) {
+	keys := make(chan term.Key, 16)
+	err := term.ReadKeys(bytes.NewReader([]byte(" \x1b[Cq")), keys)
+	if err != io.EOF {
+		t.Fatalf("ReadKeys returned %v, want io.EOF", err)
+	}
+	var got []term.Key
+	for k := range keys {
+		got = append(got, k)
+	}
+	if want := []term.Key{' ', term.KeyRight, 'q'}; !slices.Equal(got, want) {
+		t.Errorf("got keys %v, want %v", got, want)
+	}
+}
+
+func TestBindingsDispatch(t *testing.T) {
+	var calls []string
+	b := term.Bindings{
+		' ':           func() { calls = append(calls, "pause") },
+		term.KeyRight: func() { calls = append(calls, "forward") },
+		'q':           func() { calls = append(calls, "quit") },
+	}
+	for _, k := range term.ParseKeys([]byte(" x\x1b[Cq\x1b[A")) {
+		b.Dispatch(k)
+	}
+	if want := []string{"pause", "forward", "quit"}; !slices.Equal(calls, want) {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+	if b.Dispatch('x') {
+		t.Error("Dispatch reported a binding for an unbound key")
+	}
+	if !b.Dispatch('q') {
+		t.Error("Dispatch reported no binding for a bound key")
+	}
+}
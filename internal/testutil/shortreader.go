@@ -0,0 +1,16 @@
+package testutil
+
+import "io"
+
+// ShortReadSeeker is an io.ReadSeeker that reads at most N bytes at a time, like a network stream.
+type ShortReadSeeker struct {
+	io.ReadSeeker
+	N int
+}
+
+func (r *ShortReadSeeker) Read(p []byte) (int, error) {
+	if len(p) > r.N {
+		p = p[:r.N]
+	}
+	return r.ReadSeeker.Read(p)
+}
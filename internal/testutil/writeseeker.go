@@ -0,0 +1,45 @@
+package testutil
+
+import (
+	"errors"
+	"io"
+)
+
+// WriteSeeker is an in-memory io.WriteSeeker.
+type WriteSeeker struct {
+	buf []byte
+	pos int
+}
+
+func (ws *WriteSeeker) Write(p []byte) (int, error) {
+	if end := ws.pos + len(p); end > len(ws.buf) {
+		ws.buf = append(ws.buf, make([]byte, end-len(ws.buf))...)
+	}
+	n := copy(ws.buf[ws.pos:], p)
+	ws.pos += n
+	return n, nil
+}
+
+func (ws *WriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = int64(ws.pos) + offset
+	case io.SeekEnd:
+		abs = int64(len(ws.buf)) + offset
+	default:
+		return 0, errors.New("testutil: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("testutil: negative position")
+	}
+	ws.pos = int(abs)
+	return abs, nil
+}
+
+// Bytes returns the written data.
+func (ws *WriteSeeker) Bytes() []byte {
+	return ws.buf
+}
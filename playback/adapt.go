@@ -0,0 +1,33 @@
+package playback
+
+import (
+	"fmt"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/internal/fmtconv"
+	"github.com/MatusOllah/resona/resample"
+)
+
+// sourceFormat returns the format of src, if it reports a valid one.
+func sourceFormat(src aio.SampleReader) (afmt.Format, bool) {
+	f, ok := src.(afmt.Formatter)
+	if !ok {
+		return afmt.Format{}, false
+	}
+	format := f.Format()
+	if format.NumChannels <= 0 || format.SampleRate <= 0 {
+		return afmt.Format{}, false
+	}
+	return format, true
+}
+
+// adapt converts the samples of r from the format from to the format to, mapping the channels and resampling as needed.
+// The resampler is returned as well, so that it can be closed; it is nil if the sample rates match.
+func adapt(r aio.SampleReader, from, to afmt.Format) (aio.SampleReader, resample.Resampler, error) {
+	r, rs, err := fmtconv.Convert(r, from, to, resample.QualityMedium)
+	if err != nil {
+		return nil, nil, fmt.Errorf("playback: %w", err)
+	}
+	return r, rs, nil
+}
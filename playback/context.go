@@ -2,10 +2,18 @@ package playback
 
 import (
 	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/MatusOllah/resona/abufio"
 	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
 	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
 	"github.com/MatusOllah/resona/playback/driver"
 )
 
@@ -19,6 +27,14 @@ func WithDriver(name string) ContextOption {
 	}
 }
 
+// WithDriverInstance sets the playback driver to drv, which does not need to be registered,
+// such as a driver created for this context only.
+func WithDriverInstance(drv driver.Driver) ContextOption {
+	return func(ctx *Context) {
+		ctx.drv = drv
+	}
+}
+
 // WithBufferSize sets the buffer size.
 // Bigger buffer size means lower CPU usage and more reliable playback.
 // Lower buffer size means better responsiveness and less delay.
@@ -37,22 +53,148 @@ func WithBufferSizeBytes(size int) ContextOption {
 	}
 }
 
+// WithBufferDuration sets the total buffer size as a duration, which takes precedence over [WithBufferSize].
+// Without [WithDriverPlayerSplit], it is all buffered by the context, and the driver keeps its own buffer size.
+func WithBufferDuration(d time.Duration) ContextOption {
+	return func(ctx *Context) {
+		ctx.bufferDuration = d
+	}
+}
+
+// WithDriverPlayerSplit splits the total buffer size between the driver and the context,
+// giving the driver the fraction ratio, between 0 and 1, and the context the rest.
+// A bigger driver buffer makes the playback more reliable, while a bigger context buffer reads the players further ahead.
+// The driver must support setting its buffer size.
+func WithDriverPlayerSplit(ratio float64) ContextOption {
+	return func(ctx *Context) {
+		ctx.split = ratio
+	}
+}
+
+// WithOutputSampleFormat sets the sample format the driver sends to the device,
+// such as 16-bit integers to halve the bandwidth on constrained devices. The driver must support it.
+func WithOutputSampleFormat(format afmt.SampleFormat) ContextOption {
+	return func(ctx *Context) {
+		ctx.drvConfig.SampleFormat = format
+	}
+}
+
+// WithExclusive makes the driver take exclusive control of the device, bypassing the mixer of the system
+// for lower latency. The driver must support it.
+func WithExclusive(exclusive bool) ContextOption {
+	return func(ctx *Context) {
+		ctx.drvConfig.Exclusive = exclusive
+	}
+}
+
+// WithDevice makes the driver play to the device with the given ID, as listed by [Devices],
+// instead of its default device. The driver must support it.
+func WithDevice(id string) ContextOption {
+	return func(ctx *Context) {
+		ctx.drvConfig.Device = id
+	}
+}
+
+// WithStrictFormat makes players fail instead of adapting sources whose format does not match the format of the context.
+// The error is reported through [Player.Done] and [Player.Err] once the player is played.
+func WithStrictFormat() ContextOption {
+	return func(ctx *Context) {
+		ctx.strictFormat = true
+	}
+}
+
+// WithUnderrunCallback sets a function that is called on every underrun of the context with its statistics,
+// such as to log glitches. It is called on the goroutine of the driver, so it must return quickly.
+func WithUnderrunCallback(f func(Stats)) ContextOption {
+	return func(ctx *Context) {
+		ctx.onUnderrun = f
+	}
+}
+
+// WithErrorCallback sets a function that is called with the player and the error when the source of a player fails,
+// besides reporting the error through [Player.Done] and [Player.Err]. The other players keep playing.
+// It is called on the goroutine of the driver, so it must return quickly.
+func WithErrorCallback(f func(p *Player, err error)) ContextOption {
+	return func(ctx *Context) {
+		ctx.onError = f
+	}
+}
+
+// Stats represents the playback statistics of a [Context].
+type Stats struct {
+	// Underruns is the number of times the driver read from the context while the players
+	// had fewer samples ready than it requested, such as when a source was too slow.
+	Underruns int64
+
+	// SilentSamples is the total number of samples of silence inserted in place of the missing samples.
+	SilentSamples int64
+
+	// LastUnderrun is the time of the last underrun, or the zero time if there has been none.
+	LastUnderrun time.Time
+}
+
 // Context represents the playback context.
+//
+// A context created by [NewContext] is running until it is closed, and can be suspended meanwhile; see [State].
+// Using a closed context, or one not created by NewContext, does not panic, but returns [ErrClosed] or [ErrNotInitialized]
+// where it can fail, and does nothing otherwise.
 type Context struct {
-	driverName string
-	drv        driver.Driver
-	bufferSize int
-	mux        *audio.Mixer
+	driverName   string
+	drv          driver.Driver
+	bufferSize   int
+	format       afmt.Format
+	mux          *audio.Mixer
+	strictFormat bool
+	onUnderrun   func(Stats)
+	onError      func(*Player, error)
+
+	bufferDuration time.Duration
+	split          float64
+	drvConfig      driver.Config
+
+	state     atomic.Int32 // State
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu     sync.Mutex // guards the pull path, which the driver reads from
+	buf    *abufio.Reader
+	failed []*Player // players whose source failed during the current read
+	ready  int       // the most samples any input of the mixer had ready during the current read of the mixer
+	silent int       // samples of silence the mixer inserted during the current read of the context
+	master *effect.ChannelGain
+	volume atomic.Uint64 // math.Float64bits of the master volume
+
+	clock atomic.Int64 // frames delivered to the driver
+
+	// statistics, kept in atomics so that reading them does not hold up the driver
+	underruns     atomic.Int64
+	silentSamples atomic.Int64
+	lastUnderrun  atomic.Int64 // Unix time in nanoseconds, or 0
 }
 
+// masterVolumeSmoothing is the time over which changes of the master volume ramp to the new value.
+const masterVolumeSmoothing = 10 * time.Millisecond
+
 // NewContext creates a new [Context] with the specified format and options.
 // If no driver is specified, the default driver (first one registered) is used.
+//
+// Several contexts can exist at once, each with its own mixer and players. Registered drivers that implement
+// [driver.Instancer] play every context through its own instance; the others play one context at a time,
+// until it is closed.
 func NewContext(format afmt.Format, opts ...ContextOption) (*Context, error) {
+	if err := checkFormat(format); err != nil {
+		return nil, err
+	}
 	ctx := &Context{
 		driverName: "",   // Empty string = default driver
 		bufferSize: 1024, // Default buffer size
+		format:     format,
 		mux:        audio.NewMixer(nil),
+		closed:     make(chan struct{}),
+		master:     effect.NewChannelGain(max(format.NumChannels, 1), nil),
 	}
+	ctx.master.SetSmoothingTime(masterVolumeSmoothing, format)
+	ctx.volume.Store(math.Float64bits(1))
 
 	// Apply options
 	for _, opt := range opts {
@@ -61,13 +203,16 @@ func NewContext(format afmt.Format, opts ...ContextOption) (*Context, error) {
 
 	ctx.mux.KeepAlive(true)
 
-	if ctx.driverName == "" {
+	switch {
+	case ctx.drv != nil:
+		// Use the given driver instance
+	case ctx.driverName == "":
 		// Use default driver
 		if defaultDriver == nil {
 			return nil, fmt.Errorf("playback: no default driver registered")
 		}
-		ctx.drv = defaultDriver
-	} else {
+		ctx.drv = instance(defaultDriver)
+	default:
 		// Look up and use specified driver
 		var ok bool
 		driversMu.RLock()
@@ -76,18 +221,265 @@ func NewContext(format afmt.Format, opts ...ContextOption) (*Context, error) {
 		if !ok {
 			return nil, fmt.Errorf("playback: unknown driver %q (forgotten import?)", ctx.driverName)
 		}
+		ctx.drv = instance(ctx.drv)
+	}
+
+	if err := ctx.configure(); err != nil {
+		return nil, err
 	}
 
 	// Init driver
-	if err := ctx.drv.Init(format, abufio.NewReaderSize(ctx.mux, ctx.bufferSize)); err != nil {
+	ctx.buf = abufio.NewReaderSize(mixReader{ctx}, ctx.bufferSize)
+	if err := ctx.drv.Init(format, contextReader{ctx}); err != nil {
+		if !afmt.IsStandardRate(format.SampleRate) {
+			// devices often support the standard rates only
+			return nil, fmt.Errorf("playback: failed to initialize driver %q: %w (%v is not a standard sample rate, try %v)",
+				ctx.driverName, err, format.SampleRate, freq.NearestStandardRate(format.SampleRate))
+		}
 		return nil, fmt.Errorf("playback: failed to initialize driver %q: %w", ctx.driverName, err)
 	}
 
+	ctx.state.Store(int32(StateRunning))
 	return ctx, nil
 }
 
-// Close closes the underlying playback driver and the context.
+// checkFormat checks that a context can play audio of the format.
+func checkFormat(format afmt.Format) error {
+	if format.NumChannels <= 0 {
+		return fmt.Errorf("playback: invalid number of channels: %d", format.NumChannels)
+	}
+	if format.SampleRate <= 0 {
+		return fmt.Errorf("playback: invalid sample rate: %v", format.SampleRate)
+	}
+	return nil
+}
+
+// configure lays out the buffers and configures the driver, if any of its settings were given.
+func (ctx *Context) configure() error {
+	if ctx.bufferDuration < 0 {
+		return fmt.Errorf("playback: invalid buffer duration: %v", ctx.bufferDuration)
+	}
+	if ctx.bufferDuration > 0 {
+		frames := int(ctx.bufferDuration.Seconds() * ctx.format.SampleRate.Hertz())
+		ctx.bufferSize = frames * ctx.format.NumChannels
+	}
+	if ctx.split != 0 {
+		if ctx.split <= 0 || ctx.split >= 1 {
+			return fmt.Errorf("playback: invalid driver/player split: %v (must be between 0 and 1)", ctx.split)
+		}
+		frames := ctx.bufferSize / max(ctx.format.NumChannels, 1)
+		ctx.drvConfig.BufferFrames = max(int(math.Round(float64(frames)*ctx.split)), 1)
+		ctx.bufferSize = max(ctx.bufferSize-ctx.drvConfig.BufferFrames*ctx.format.NumChannels, ctx.format.NumChannels)
+	}
+
+	if ctx.drvConfig == (driver.Config{}) {
+		return nil
+	}
+	c, ok := ctx.drv.(driver.Configurer)
+	if !ok {
+		return fmt.Errorf("playback: driver %q does not support buffer sizes, sample formats, exclusive mode or device selection", ctx.driverName)
+	}
+	if err := c.Configure(ctx.drvConfig); err != nil {
+		return fmt.Errorf("playback: failed to configure driver %q: %w", ctx.driverName, err)
+	}
+	return nil
+}
+
+// Config represents the effective configuration of a [Context].
+type Config struct {
+	// BufferSize is the size of the buffer of the context in samples.
+	BufferSize int
+
+	// Driver is the effective configuration of the driver, as far as the driver reports it.
+	Driver driver.Config
+}
+
+// Config returns the effective configuration of the context.
+func (ctx *Context) Config() Config {
+	cfg := Config{BufferSize: ctx.bufferSize}
+	if c, ok := ctx.drv.(driver.Configurer); ok {
+		cfg.Driver = c.Config()
+	}
+	return cfg
+}
+
+// contextReader is the pull path of a [Context], which the driver reads from.
+type contextReader struct {
+	ctx *Context
+}
+
+func (r contextReader) ReadSamples(p []float32) (int, error) {
+	ctx := r.ctx
+	if !ctx.IsRunning() {
+		// suspended or closing, so the players are not read
+		clear(p)
+		ctx.clock.Add(int64(len(p) / ctx.format.NumChannels))
+		return len(p), nil
+	}
+	ctx.mu.Lock()
+	n, missing, err := ctx.read(p)
+	ctx.clock.Add(int64(n / ctx.format.NumChannels))
+	failed := ctx.failed
+	ctx.failed = nil
+	ctx.mu.Unlock()
+
+	if ctx.onError != nil {
+		for _, player := range failed {
+			ctx.onError(player, player.Err())
+		}
+	}
+	if missing > 0 {
+		stats := ctx.recordUnderrun(missing)
+		if ctx.onUnderrun != nil {
+			ctx.onUnderrun(stats)
+		}
+	}
+	return n, err
+}
+
+// read reads p from the players and applies the master volume.
+// It also returns the number of samples of silence the mixer inserted because none of the players had any ready,
+// including those read ahead into the buffer of the context.
+// ctx.mu must be held.
+func (ctx *Context) read(p []float32) (n, missing int, err error) {
+	ctx.silent = 0
+	for n < len(p) && err == nil {
+		var nn int
+		nn, err = ctx.buf.ReadSamples(p[n:])
+		n += nn
+		if nn == 0 {
+			break
+		}
+	}
+	if fxErr := ctx.master.Process(p[:n]); fxErr != nil {
+		return 0, 0, fxErr
+	}
+	return n, ctx.silent, err
+}
+
+// mixReader is what the buffer of the context reads the mixer through.
+// As the mixer plays silence while its readers have nothing ready, it tells that silence apart from the audio of the players
+// by the samples its inputs had ready. The mixer is read with ctx.mu held.
+type mixReader struct {
+	ctx *Context
+}
+
+func (r mixReader) ReadSamples(p []float32) (int, error) {
+	ctx := r.ctx
+	ctx.ready = 0
+	n, err := ctx.mux.ReadSamples(p)
+	if n > ctx.ready {
+		ctx.silent += n - ctx.ready
+	}
+	return n, err
+}
+
+// mixerInput is a reader added to the mixer, which notes how many samples it had ready for [mixReader].
+type mixerInput struct {
+	ctx *Context
+	r   aio.SampleReader
+}
+
+func (in mixerInput) ReadSamples(p []float32) (int, error) {
+	n, err := in.r.ReadSamples(p)
+	in.ctx.ready = max(in.ctx.ready, n)
+	return n, err
+}
+
+// recordUnderrun counts an underrun in which the given number of samples were missing and returns the new statistics.
+func (ctx *Context) recordUnderrun(missing int) Stats {
+	now := time.Now()
+	ctx.lastUnderrun.Store(now.UnixNano())
+	return Stats{
+		Underruns:     ctx.underruns.Add(1),
+		SilentSamples: ctx.silentSamples.Add(int64(missing)),
+		LastUnderrun:  now,
+	}
+}
+
+// Stats returns the playback statistics of the context.
+func (ctx *Context) Stats() Stats {
+	stats := Stats{
+		Underruns:     ctx.underruns.Load(),
+		SilentSamples: ctx.silentSamples.Load(),
+	}
+	if t := ctx.lastUnderrun.Load(); t != 0 {
+		stats.LastUnderrun = time.Unix(0, t)
+	}
+	return stats
+}
+
+// BufferSize returns the size of the buffer of the context in samples, which does not include the buffer of the driver.
+func (ctx *Context) BufferSize() int {
+	return ctx.bufferSize
+}
+
+// Latency returns the time it takes for the audio of the players to be heard, that is,
+// the duration of the audio read from the players but not played yet.
+// It includes the buffer of the driver if the driver reports it, and changes as the buffers drain and fill.
+// It is 0 for a closed context.
+func (ctx *Context) Latency() time.Duration {
+	if ctx.checkOpen() != nil {
+		return 0
+	}
+	ctx.mu.Lock()
+	frames := ctx.buffered()
+	ctx.mu.Unlock()
+	return time.Duration(float64(frames) / ctx.format.SampleRate.Hertz() * float64(time.Second))
+}
+
+// SetMasterVolume sets the linear gain applied to the audio of all players of the context, from 0 (silence) up.
+// It affects the audio buffered by the context, but not by the driver, so it is heard as soon as possible.
+// Changes ramp to the new volume over a short time to avoid clicks.
+func (ctx *Context) SetMasterVolume(gain float64) {
+	if ctx.master == nil {
+		return // not initialized
+	}
+	gain = max(gain, 0)
+	ctx.volume.Store(math.Float64bits(gain))
+	gains := make([]float64, max(ctx.format.NumChannels, 1))
+	for i := range gains {
+		gains[i] = gain
+	}
+	ctx.master.SetGains(gains)
+}
+
+// SetMasterVolumeDB is like [Context.SetMasterVolume], but takes the gain in dB.
+func (ctx *Context) SetMasterVolumeDB(db float64) {
+	ctx.SetMasterVolume(dsp.DBToAmplitude(db))
+}
+
+// MasterVolume returns the linear gain applied to the audio of all players of the context.
+func (ctx *Context) MasterVolume() float64 {
+	return math.Float64frombits(ctx.volume.Load())
+}
+
+// buffered returns the number of frames read from the mixer that have not been played yet,
+// as far as the driver reports them.
+// ctx.mu must be held.
+func (ctx *Context) buffered() int64 {
+	n := int64(ctx.buf.Buffered() / ctx.format.NumChannels)
+	if b, ok := ctx.drv.(driver.Bufferer); ok {
+		n += int64(b.BufferedFrames())
+	}
+	return n
+}
+
+// Close closes the underlying playback driver and the context. Closing a closed context does nothing.
 func (ctx *Context) Close() error {
+	for {
+		state := ctx.State()
+		switch state {
+		case StateUninitialized:
+			return ErrNotInitialized
+		case StateClosed:
+			return nil
+		}
+		if ctx.state.CompareAndSwap(int32(state), int32(StateClosed)) {
+			break
+		}
+	}
+	ctx.closeOnce.Do(func() { close(ctx.closed) })
 	ctx.mux.Clear()
 	return ctx.drv.Close()
 }
@@ -0,0 +1,487 @@
+package playback_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/generator"
+	"github.com/MatusOllah/resona/playback"
+	"github.com/MatusOllah/resona/playback/driver"
+	"github.com/MatusOllah/resona/playback/driver/null"
+	"github.com/MatusOllah/resona/playback/driver/writer"
+)
+
+// fakeDriver keeps the source of the context, so that the test can pull the mixed audio out of it.
+type fakeDriver struct {
+	src    aio.SampleReader
+	closed bool
+}
+
+func (d *fakeDriver) Init(format afmt.Format, src aio.SampleReader) error {
+	d.src = src
+	return nil
+}
+
+func (d *fakeDriver) Close() error {
+	d.closed = true
+	return nil
+}
+
+var fakes = [2]*fakeDriver{{}, {}}
+
+func init() {
+	playback.Register("fake0", fakes[0])
+	playback.Register("fake1", fakes[1])
+}
+
+func TestContextsCoexist(t *testing.T) {
+	format := afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}
+
+	var ctxs [2]*playback.Context
+	for i, name := range []string{"fake0", "fake1"} {
+		ctx, err := playback.NewContext(format, playback.WithDriver(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctxs[i] = ctx
+		ctx.NewPlayer(aio.LimitReader(generator.NewConstant(float32(i+1)), 1<<16)).Play()
+	}
+
+	// each driver gets the audio of its own context only
+	for i, d := range fakes {
+		p := make([]float32, 256)
+		if _, err := aio.ReadFull(d.src, p); err != nil {
+			t.Fatal(err)
+		}
+		for _, x := range p {
+			if x != float32(i+1) {
+				t.Fatalf("driver %d: expected %v, got %v", i, float32(i+1), x)
+			}
+		}
+	}
+
+	for i, ctx := range ctxs {
+		if err := ctx.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if !fakes[i].closed {
+			t.Errorf("expected driver %d to be closed", i)
+		}
+	}
+}
+
+func TestContextMasterVolume(t *testing.T) {
+	out := audio.NewBufferSize(1024)
+	drv := writer.New(out)
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(drv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	if got := ctx.MasterVolume(); got != 1 {
+		t.Errorf("expected a master volume of 1, got %v", got)
+	}
+
+	ctx.NewPlayer(generator.NewConstant(0.5)).Play()
+	if err := drv.RenderFor(50 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	ctx.SetMasterVolume(0.5)
+	if err := drv.RenderFor(50 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	p := out.Float32s()
+	if p[0] != 0.5 {
+		t.Errorf("expected 0.5 before the change, got %v", p[0])
+	}
+	if got := p[len(p)-1]; got != 0.25 {
+		t.Errorf("expected 0.25 after the change, got %v", got)
+	}
+
+	// the volume ramps over 10ms, rather than jumping
+	maxStep := 0.25 / (0.010 * 48000) * 1.01
+	for i := nullFormat.NumChannels; i < len(p); i++ {
+		if d := math.Abs(float64(p[i] - p[i-nullFormat.NumChannels])); d > maxStep {
+			t.Fatalf("expected steps of at most %v, got %v at %d", maxStep, d, i)
+		}
+	}
+
+	ctx.SetMasterVolumeDB(-20)
+	if got := ctx.MasterVolume(); math.Abs(got-0.1) > 1e-9 {
+		t.Errorf("expected a master volume of 0.1, got %v", got)
+	}
+	ctx.SetMasterVolume(-1)
+	if got := ctx.MasterVolume(); got != 0 {
+		t.Errorf("expected negative volumes to mean 0, got %v", got)
+	}
+}
+
+func TestContextLatency(t *testing.T) {
+	// a device buffer of 100ms and nothing buffered by the context, as nothing is playing
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(&null.Driver{BufferFrames: 4800}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ctx.Latency(); got != 100*time.Millisecond {
+		t.Errorf("expected a latency of 100ms, got %v", got)
+	}
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the context reads ahead by its buffer size
+	drv := writer.New(aio.Discard)
+	drv.ChunkFrames = 100
+	ctx, err = playback.NewContext(nullFormat, playback.WithDriverInstance(drv), playback.WithBufferSize(4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+	if got := ctx.BufferSize(); got != 4096 {
+		t.Errorf("expected a buffer size of 4096, got %d", got)
+	}
+
+	player := ctx.NewPlayer(generator.NewConstant(0.5))
+	if got := player.BufferedDuration(); got != 0 {
+		t.Errorf("expected nothing buffered before playing, got %v", got)
+	}
+	player.Play()
+	if err := drv.RenderFor(100 * time.Second / 48000); err != nil {
+		t.Fatal(err)
+	}
+	frames := 4096/nullFormat.NumChannels - 100
+	want := time.Duration(float64(frames) / 48000 * float64(time.Second))
+	if got := ctx.Latency(); got != want {
+		t.Errorf("expected a latency of %v, got %v", want, got)
+	}
+	if got := player.BufferedDuration(); got != want {
+		t.Errorf("expected %v buffered, got %v", want, got)
+	}
+}
+
+func TestContextSequential(t *testing.T) {
+	for i := range 2 {
+		ctx, err := playback.NewContext(nullFormat, playback.WithDriver("null"))
+		if err != nil {
+			t.Fatalf("context %d: %v", i, err)
+		}
+		select {
+		case <-ctx.NewPlayer(aio.LimitReader(generator.NewConstant(0.5), 2*480)).PlayWithDone():
+		case <-time.After(time.Second):
+			t.Fatalf("context %d: expected the player to finish", i)
+		}
+		if err := ctx.Close(); err != nil {
+			t.Fatalf("context %d: %v", i, err)
+		}
+	}
+}
+
+// instancingDriver hands out a new fakeDriver for every context.
+type instancingDriver struct {
+	fakeDriver
+	instances []*fakeDriver
+}
+
+func (d *instancingDriver) NewInstance() driver.Driver {
+	inst := &fakeDriver{}
+	d.instances = append(d.instances, inst)
+	return inst
+}
+
+var instancing = &instancingDriver{}
+
+func init() {
+	playback.Register("instancing", instancing)
+}
+
+func TestContextInstances(t *testing.T) {
+	drv := instancing
+	drv.instances = nil
+
+	format := afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 1}
+	var ctxs [2]*playback.Context
+	for i := range ctxs {
+		ctx, err := playback.NewContext(format, playback.WithDriver("instancing"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctxs[i] = ctx
+		ctx.NewPlayer(generator.NewConstant(float32(i + 1))).Play()
+	}
+	if len(drv.instances) != 2 {
+		t.Fatalf("expected 2 instances of the driver, got %d", len(drv.instances))
+	}
+
+	for i, inst := range drv.instances {
+		p := make([]float32, 16)
+		if _, err := aio.ReadFull(inst.src, p); err != nil {
+			t.Fatal(err)
+		}
+		if p[0] != float32(i+1) {
+			t.Errorf("instance %d: expected %v, got %v", i, float32(i+1), p[0])
+		}
+	}
+
+	for i, ctx := range ctxs {
+		if err := ctx.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if !drv.instances[i].closed {
+			t.Errorf("expected instance %d to be closed", i)
+		}
+	}
+}
+
+// slowReader has samples ready only for every other read, like a source that cannot keep up.
+type slowReader struct {
+	reads int
+}
+
+func (r *slowReader) ReadSamples(p []float32) (int, error) {
+	r.reads++
+	if r.reads%2 == 0 {
+		return 0, nil
+	}
+	for i := range p {
+		p[i] = 0.5
+	}
+	return len(p), nil
+}
+
+func TestContextStats(t *testing.T) {
+	var calls atomic.Int64
+	ctx, err := playback.NewContext(nullFormat,
+		playback.WithDriverInstance(&null.Driver{}),
+		playback.WithUnderrunCallback(func(playback.Stats) { calls.Add(1) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	// a source that keeps up causes no underruns
+	player := ctx.NewPlayer(aio.LimitReader(generator.NewConstant(0.5), 48000))
+	<-player.PlayWithDone()
+	if stats := ctx.Stats(); stats.Underruns != 0 || !stats.LastUnderrun.IsZero() {
+		t.Fatalf("expected no underruns, got %+v", stats)
+	}
+
+	start := time.Now()
+	ctx.NewPlayer(&slowReader{}).Play()
+	deadline := time.Now().Add(time.Second)
+	for ctx.Stats().Underruns < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected underruns from the slow source")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stats := ctx.Stats()
+	if stats.SilentSamples < stats.Underruns {
+		t.Errorf("expected silent samples for every underrun, got %+v", stats)
+	}
+	if stats.LastUnderrun.Before(start) {
+		t.Errorf("expected the last underrun after %v, got %v", start, stats.LastUnderrun)
+	}
+	if calls.Load() < 3 {
+		t.Errorf("expected the callback to be called on every underrun, got %d calls", calls.Load())
+	}
+}
+
+func TestContextConfig(t *testing.T) {
+	int16LE := afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}
+	ctx, err := playback.NewContext(nullFormat,
+		playback.WithDriverInstance(&null.Driver{}),
+		playback.WithBufferDuration(100*time.Millisecond),
+		playback.WithDriverPlayerSplit(0.25),
+		playback.WithOutputSampleFormat(int16LE),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	cfg := ctx.Config()
+	if cfg.Driver.BufferFrames != 1200 {
+		t.Errorf("expected a driver buffer of 1200 frames, got %d", cfg.Driver.BufferFrames)
+	}
+	if cfg.BufferSize != 2*3600 {
+		t.Errorf("expected a context buffer of 7200 samples, got %d", cfg.BufferSize)
+	}
+	if cfg.Driver.SampleFormat != int16LE {
+		t.Errorf("expected sample format %v, got %v", int16LE, cfg.Driver.SampleFormat)
+	}
+	if cfg.Driver.Exclusive {
+		t.Error("expected shared mode")
+	}
+	if got := ctx.Latency(); got != 25*time.Millisecond {
+		t.Errorf("expected the driver buffer to be 25ms, got %v", got)
+	}
+}
+
+func TestContextBufferDuration(t *testing.T) {
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(&null.Driver{}), playback.WithBufferDuration(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+	if got := ctx.Config().BufferSize; got != 9600 {
+		t.Errorf("expected a buffer of 9600 samples, got %d", got)
+	}
+	if got := ctx.Config().Driver.BufferFrames; got != 0 {
+		t.Errorf("expected the driver to keep its buffer size, got %d", got)
+	}
+}
+
+func TestContextConfigErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		drv  driver.Driver
+		opts []playback.ContextOption
+		want string
+	}{
+		{"exclusive", &null.Driver{}, []playback.ContextOption{playback.WithExclusive(true)}, "exclusive mode not supported"},
+		{"split too big", &null.Driver{}, []playback.ContextOption{playback.WithDriverPlayerSplit(1.5)}, "invalid driver/player split"},
+		{"negative split", &null.Driver{}, []playback.ContextOption{playback.WithDriverPlayerSplit(-0.5)}, "invalid driver/player split"},
+		{"negative duration", &null.Driver{}, []playback.ContextOption{playback.WithBufferDuration(-time.Second)}, "invalid buffer duration"},
+		{"unknown device", &null.Driver{}, []playback.ContextOption{playback.WithDevice("hw:0,0")}, "unknown device"},
+		{"not configurable", writer.New(aio.Discard), []playback.ContextOption{playback.WithDriverPlayerSplit(0.5)}, "does not support"},
+		{"no device selection", writer.New(aio.Discard), []playback.ContextOption{playback.WithDevice("null")}, "device selection"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := playback.NewContext(nullFormat, append(tt.opts, playback.WithDriverInstance(tt.drv))...)
+			if err == nil {
+				ctx.Close()
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// failingDriver fails to initialize, like a device that does not support the format.
+type failingDriver struct{}
+
+func (failingDriver) Init(afmt.Format, aio.SampleReader) error {
+	return errors.New("format not supported")
+}
+func (failingDriver) Close() error { return nil }
+
+func TestContextFormat(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		format afmt.Format
+		drv    driver.Driver
+		want   string
+	}{
+		{"no channels", afmt.Format{SampleRate: freq.SampleRate48k}, &null.Driver{}, "invalid number of channels"},
+		{"no sample rate", afmt.Format{NumChannels: 2}, &null.Driver{}, "invalid sample rate"},
+		{"non-standard rate", afmt.Format{SampleRate: 47 * freq.KiloHertz, NumChannels: 2}, failingDriver{}, "try 48kHz"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := playback.NewContext(tt.format, playback.WithDriverInstance(tt.drv))
+			if err == nil {
+				ctx.Close()
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// a standard rate gets no hint
+	_, err := playback.NewContext(afmt.Format{SampleRate: freq.SampleRate44k1, NumChannels: 2}, playback.WithDriverInstance(failingDriver{}))
+	if err == nil || strings.Contains(err.Error(), "standard") {
+		t.Errorf("expected an error without a hint, got %v", err)
+	}
+}
+
+func TestDevices(t *testing.T) {
+	devices, err := playback.Devices("null")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 || devices[0].ID != null.DeviceID || !devices[0].Default {
+		t.Fatalf("expected the default null device, got %+v", devices)
+	}
+
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(&null.Driver{}), playback.WithDevice(devices[0].ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+	if got := ctx.Config().Driver.Device; got != null.DeviceID {
+		t.Errorf("expected device %q, got %q", null.DeviceID, got)
+	}
+
+	if _, err := playback.Devices("fake0"); err == nil {
+		t.Error("expected an error listing the devices of a driver that does not list them")
+	}
+	if _, err := playback.Devices("nonexistent"); err == nil {
+		t.Error("expected an error listing the devices of an unknown driver")
+	}
+}
+
+// targetDriver selects devices without listing them, accepting any device but "missing".
+type targetDriver struct {
+	fakeDriver
+	cfg driver.Config
+}
+
+func (d *targetDriver) NewInstance() driver.Driver {
+	return &targetDriver{}
+}
+
+func (d *targetDriver) Configure(cfg driver.Config) error {
+	if cfg.Device == "missing" {
+		return errors.New("no such device")
+	}
+	d.cfg = cfg
+	return nil
+}
+
+func (d *targetDriver) Config() driver.Config {
+	return d.cfg
+}
+
+var target = &targetDriver{}
+
+func init() {
+	playback.Register("target", target)
+}
+
+func TestCheckDevice(t *testing.T) {
+	tests := []struct {
+		driver, device string
+		ok             bool
+	}{
+		{"null", null.DeviceID, true},
+		{"null", "other", false},
+		{"target", "sink", true},
+		{"target", "missing", false},
+		{"fake0", "sink", false}, // neither lists nor selects devices
+		{"nonexistent", "sink", false},
+	}
+	for _, tt := range tests {
+		if err := playback.CheckDevice(tt.driver, tt.device); (err == nil) != tt.ok {
+			t.Errorf("%s, %s: expected ok %v, got %v", tt.driver, tt.device, tt.ok, err)
+		}
+	}
+	if target.cfg != (driver.Config{}) {
+		t.Errorf("expected the registered driver to be left unconfigured, got %+v", target.cfg)
+	}
+}
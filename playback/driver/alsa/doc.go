@@ -0,0 +1,12 @@
+// Package alsa provides a native ALSA playback driver for Linux, which talks to the device directly
+// for lower latency than the Oto driver. It needs cgo and the ALSA library (libasound);
+// on other platforms and without cgo, the package is empty and registers nothing.
+//
+// The driver plays through the PCM device "default" unless another is given, such as "hw:0,0" or "plughw:1".
+// To use another device or buffer sizes, register a configured driver under a name of its own:
+//
+//	playback.Register("alsa-usb", &alsa.Driver{Device: "plughw:1", PeriodFrames: 128, BufferFrames: 512})
+//
+// The devices that can play are listed by [playback.Devices], and one can be chosen for a single context
+// with [playback.WithDevice].
+package alsa
@@ -0,0 +1,365 @@
+//go:build linux && cgo
+
+package alsa
+
+//#cgo pkg-config: alsa
+//#include <errno.h>
+//#include <stdlib.h>
+//#include <alsa/asoundlib.h>
+import "C"
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/encoding/pcm"
+	"github.com/MatusOllah/resona/playback"
+	"github.com/MatusOllah/resona/playback/driver"
+)
+
+// Default sizes of the buffer of a [Driver].
+const (
+	DefaultPeriodFrames = 256
+	DefaultBufferFrames = 1024
+)
+
+// sampleFormat maps an ALSA sample format to its afmt equivalent.
+type sampleFormat struct {
+	alsa C.snd_pcm_format_t
+	afmt afmt.SampleFormat
+}
+
+// sampleFormats are the sample formats the driver can play, best first.
+var sampleFormats = []sampleFormat{
+	{C.SND_PCM_FORMAT_FLOAT_LE, afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingFloat, Endian: binary.LittleEndian}},
+	{C.SND_PCM_FORMAT_S32_LE, afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}},
+	{C.SND_PCM_FORMAT_S24_3LE, afmt.SampleFormat{BitDepth: 24, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}},
+	{C.SND_PCM_FORMAT_S16_LE, afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}},
+}
+
+// Driver represents the driver.
+//
+// It writes to the device from a goroutine of its own, one period at a time.
+// When the context cannot keep up and the device runs out of audio, the driver recovers and counts the underrun.
+type Driver struct {
+	// Device is the name of the ALSA PCM device. If it is empty, "default" is used.
+	Device string
+
+	// PeriodFrames is the number of frames written to the device at a time.
+	// If it is zero, [DefaultPeriodFrames] is used.
+	PeriodFrames int
+
+	// BufferFrames is the size of the buffer of the device in frames, which is its latency.
+	// If it is zero, [DefaultBufferFrames] is used. The device may round both sizes.
+	BufferFrames int
+
+	pcm         *C.snd_pcm_t
+	device      string // the name of the opened device
+	numChannels int
+	period      int
+	sampleFmt   afmt.SampleFormat
+	stop        chan struct{}
+	done        chan struct{}
+
+	cfg    driver.Config // set by Configure for the next Init
+	buffer int           // the buffer size of the device in frames
+
+	delay     atomic.Int64
+	underruns atomic.Int64
+}
+
+// Configure implements the driver.Configurer interface. The configured buffer size and device take precedence over
+// BufferFrames and Device, and the configured sample format must be one of float32le, int32le, int24le (packed) and int16le.
+// Exclusive mode is not supported; to bypass the mixer of the system, use a hw device instead.
+func (d *Driver) Configure(cfg driver.Config) error {
+	if cfg.Exclusive {
+		return errors.New("alsa: exclusive mode not supported (use a hw device instead)")
+	}
+	if cfg.BufferFrames < 0 {
+		return errors.New("alsa: invalid buffer size")
+	}
+	if cfg.SampleFormat != (afmt.SampleFormat{}) {
+		if _, ok := alsaFormat(cfg.SampleFormat); !ok {
+			return fmt.Errorf("alsa: unsupported sample format %v", cfg.SampleFormat)
+		}
+	}
+	d.cfg = cfg
+	return nil
+}
+
+// Config implements the driver.Configurer interface.
+func (d *Driver) Config() driver.Config {
+	return driver.Config{BufferFrames: d.buffer, SampleFormat: d.sampleFmt, Device: d.device}
+}
+
+// Devices implements the driver.DeviceLister interface. It lists the PCM devices that can play, as "aplay -L" does;
+// their IDs are the device names, such as "default" or "plughw:CARD=PCH,DEV=0".
+func (d *Driver) Devices() ([]driver.Device, error) {
+	iface := C.CString("pcm")
+	defer C.free(unsafe.Pointer(iface))
+
+	var hints *unsafe.Pointer
+	if code := C.snd_device_name_hint(-1, iface, &hints); code < 0 {
+		return nil, alsaError("listing devices", code)
+	}
+	defer C.snd_device_name_free_hint(hints)
+
+	var devices []driver.Device
+	for h := hints; *h != nil; h = (*unsafe.Pointer)(unsafe.Add(unsafe.Pointer(h), unsafe.Sizeof(*h))) {
+		// the direction is missing for devices that can both play and capture
+		if io := deviceHint(*h, "IOID"); io != "" && io != "Output" {
+			continue
+		}
+		name := deviceHint(*h, "NAME")
+		if name == "" || name == "null" {
+			continue
+		}
+		desc := strings.ReplaceAll(deviceHint(*h, "DESC"), "\n", ", ")
+		devices = append(devices, driver.Device{ID: name, Name: desc, Default: name == "default"})
+	}
+	return devices, nil
+}
+
+// deviceHint returns the value of the hint with the given id of a device, or "" if it has none.
+func deviceHint(hint unsafe.Pointer, id string) string {
+	cID := C.CString(id)
+	defer C.free(unsafe.Pointer(cID))
+	v := C.snd_device_name_get_hint(hint, cID)
+	if v == nil {
+		return ""
+	}
+	defer C.free(unsafe.Pointer(v))
+	return C.GoString(v)
+}
+
+// alsaFormat returns the ALSA sample format of f.
+func alsaFormat(f afmt.SampleFormat) (C.snd_pcm_format_t, bool) {
+	for _, sf := range sampleFormats {
+		if sf.afmt == f {
+			return sf.alsa, true
+		}
+	}
+	return 0, false
+}
+
+// NewInstance implements the driver.Instancer interface, so that every playback context opens the device itself.
+func (d *Driver) NewInstance() driver.Driver {
+	return &Driver{Device: d.Device, PeriodFrames: d.PeriodFrames, BufferFrames: d.BufferFrames}
+}
+
+func alsaError(what string, code C.int) error {
+	return fmt.Errorf("alsa: %s: %s", what, C.GoString(C.snd_strerror(code)))
+}
+
+// Init opens the device with the format and starts playing the source.
+func (d *Driver) Init(format afmt.Format, src aio.SampleReader) error {
+	if format.NumChannels <= 0 {
+		return errors.New("alsa: invalid number of channels")
+	}
+	if format.SampleRate <= 0 {
+		return errors.New("alsa: invalid sample rate")
+	}
+
+	device := d.Device
+	if d.cfg.Device != "" {
+		device = d.cfg.Device
+	}
+	if device == "" {
+		device = "default"
+	}
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	var h *C.snd_pcm_t
+	if code := C.snd_pcm_open(&h, cDevice, C.SND_PCM_STREAM_PLAYBACK, 0); code < 0 {
+		return alsaError("opening device "+device, code)
+	}
+	if err := d.setParams(h, format); err != nil {
+		C.snd_pcm_close(h)
+		return err
+	}
+	if code := C.snd_pcm_prepare(h); code < 0 {
+		C.snd_pcm_close(h)
+		return alsaError("preparing device", code)
+	}
+
+	d.pcm = h
+	d.device = device
+	d.numChannels = format.NumChannels
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+	go d.run(src)
+	return nil
+}
+
+// setParams sets the hardware parameters of h for format, choosing the best sample format the device supports.
+func (d *Driver) setParams(h *C.snd_pcm_t, format afmt.Format) error {
+	var params *C.snd_pcm_hw_params_t
+	if code := C.snd_pcm_hw_params_malloc(&params); code < 0 {
+		return alsaError("allocating hardware parameters", code)
+	}
+	defer C.snd_pcm_hw_params_free(params)
+
+	if code := C.snd_pcm_hw_params_any(h, params); code < 0 {
+		return alsaError("getting hardware parameters", code)
+	}
+	if code := C.snd_pcm_hw_params_set_access(h, params, C.SND_PCM_ACCESS_RW_INTERLEAVED); code < 0 {
+		return alsaError("setting access", code)
+	}
+
+	formats := sampleFormats
+	if d.cfg.SampleFormat != (afmt.SampleFormat{}) {
+		f, _ := alsaFormat(d.cfg.SampleFormat)
+		formats = []sampleFormat{{f, d.cfg.SampleFormat}}
+	}
+	found := false
+	for _, f := range formats {
+		if C.snd_pcm_hw_params_test_format(h, params, f.alsa) == 0 {
+			if code := C.snd_pcm_hw_params_set_format(h, params, f.alsa); code < 0 {
+				return alsaError("setting sample format", code)
+			}
+			d.sampleFmt = f.afmt
+			found = true
+			break
+		}
+	}
+	if !found {
+		if d.cfg.SampleFormat != (afmt.SampleFormat{}) {
+			return fmt.Errorf("alsa: device does not support sample format %v", d.cfg.SampleFormat)
+		}
+		return errors.New("alsa: device supports no known sample format")
+	}
+
+	if code := C.snd_pcm_hw_params_set_channels(h, params, C.uint(format.NumChannels)); code < 0 {
+		return alsaError(fmt.Sprintf("setting %d channels", format.NumChannels), code)
+	}
+	if code := C.snd_pcm_hw_params_set_rate(h, params, C.uint(format.SampleRate.Hertz()), 0); code < 0 {
+		return alsaError(fmt.Sprintf("setting sample rate %v (try a plughw device)", format.SampleRate), code)
+	}
+
+	period := C.snd_pcm_uframes_t(DefaultPeriodFrames)
+	if d.PeriodFrames > 0 {
+		period = C.snd_pcm_uframes_t(d.PeriodFrames)
+	}
+	buffer := C.snd_pcm_uframes_t(DefaultBufferFrames)
+	if d.cfg.BufferFrames > 0 {
+		buffer = C.snd_pcm_uframes_t(d.cfg.BufferFrames)
+	} else if d.BufferFrames > 0 {
+		buffer = C.snd_pcm_uframes_t(d.BufferFrames)
+	}
+	if code := C.snd_pcm_hw_params_set_period_size_near(h, params, &period, nil); code < 0 {
+		return alsaError("setting period size", code)
+	}
+	if code := C.snd_pcm_hw_params_set_buffer_size_near(h, params, &buffer); code < 0 {
+		return alsaError("setting buffer size", code)
+	}
+	if code := C.snd_pcm_hw_params(h, params); code < 0 {
+		return alsaError("applying hardware parameters", code)
+	}
+	d.period = int(period)
+	d.buffer = int(buffer)
+	return nil
+}
+
+// run writes the source to the device a period at a time until the driver is closed.
+func (d *Driver) run(src aio.SampleReader) {
+	defer close(d.done)
+
+	samples := make([]float32, d.period*d.numChannels)
+	var buf bytes.Buffer
+	enc := pcm.NewEncoder(&buf, d.sampleFmt)
+	for {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		// fill the period, with silence for what the source lacks
+		n := 0
+		for n < len(samples) {
+			nn, err := src.ReadSamples(samples[n:])
+			n += nn
+			if nn == 0 || err != nil {
+				break
+			}
+		}
+		clear(samples[n:])
+
+		buf.Reset()
+		if _, err := enc.WriteSamples(samples); err != nil {
+			return
+		}
+		if !d.write(buf.Bytes()) {
+			return
+		}
+
+		var delay C.snd_pcm_sframes_t
+		if C.snd_pcm_delay(d.pcm, &delay) == 0 {
+			d.delay.Store(int64(delay))
+		}
+	}
+}
+
+// write writes the encoded period p to the device, recovering from underruns.
+// It returns false if the device failed.
+func (d *Driver) write(p []byte) bool {
+	frameSize := len(p) / d.period
+	for len(p) > 0 {
+		n := C.snd_pcm_writei(d.pcm, unsafe.Pointer(&p[0]), C.snd_pcm_uframes_t(len(p)/frameSize))
+		if n == -C.EPIPE {
+			// underrun: the device ran out of audio
+			d.underruns.Add(1)
+			if C.snd_pcm_prepare(d.pcm) < 0 {
+				return false
+			}
+			continue
+		}
+		if n < 0 {
+			if C.snd_pcm_recover(d.pcm, C.int(n), 1) < 0 {
+				return false
+			}
+			continue
+		}
+		p = p[int(n)*frameSize:]
+	}
+	return true
+}
+
+// BufferedFrames returns the number of frames written to the device but not played yet, as of the last period.
+func (d *Driver) BufferedFrames() int {
+	return int(d.delay.Load())
+}
+
+// Underruns returns the number of times the device ran out of audio since the driver was initialized.
+func (d *Driver) Underruns() int64 {
+	return d.underruns.Load()
+}
+
+// Close stops playback and closes the device.
+func (d *Driver) Close() error {
+	if d.pcm == nil {
+		return nil
+	}
+	close(d.stop)
+	<-d.done
+	C.snd_pcm_drop(d.pcm)
+	code := C.snd_pcm_close(d.pcm)
+	d.pcm = nil
+	d.cfg = driver.Config{}
+	if code < 0 {
+		return alsaError("closing device", code)
+	}
+	return nil
+}
+
+func init() {
+	playback.Register("alsa", &Driver{}) // register driver
+}
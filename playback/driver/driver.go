@@ -15,3 +15,67 @@ type Driver interface {
 
 	io.Closer
 }
+
+// Flusher is implemented by drivers that can drop the audio they have read from the source but not played yet,
+// such as when seeking.
+type Flusher interface {
+	// Flush drops the buffered audio.
+	Flush() error
+}
+
+// Bufferer is implemented by drivers that report how much audio they have read from the source but not played yet.
+type Bufferer interface {
+	// BufferedFrames returns the number of buffered frames.
+	BufferedFrames() int
+}
+
+// Instancer is implemented by drivers that can play several contexts at once.
+// A context plays through a new instance of such a driver instead of the registered driver itself.
+type Instancer interface {
+	// NewInstance returns a new, uninitialized instance of the driver.
+	NewInstance() Driver
+}
+
+// Config represents the settings of a driver beyond the format. The zero value of every field means the driver's default.
+type Config struct {
+	// BufferFrames is the size of the buffer of the driver in frames.
+	BufferFrames int
+
+	// SampleFormat is the sample format the driver sends to the device, such as 16-bit integers to halve the bandwidth.
+	SampleFormat afmt.SampleFormat
+
+	// Exclusive makes the driver take exclusive control of the device, bypassing the mixer of the system,
+	// such as the exclusive mode of WASAPI.
+	Exclusive bool
+
+	// Device is the ID of the device to play to, as listed by [DeviceLister].
+	Device string
+}
+
+// Configurer is implemented by drivers that can be configured beyond the format.
+type Configurer interface {
+	// Configure sets the configuration used by the next call to Init.
+	// It returns an error describing the settings the driver cannot honor, instead of ignoring them.
+	Configure(cfg Config) error
+
+	// Config returns the effective configuration of the initialized driver, with the defaults filled in where known.
+	Config() Config
+}
+
+// Device represents an output device of a driver.
+type Device struct {
+	// ID identifies the device to [Config].Device.
+	ID string
+
+	// Name is the human-readable name of the device.
+	Name string
+
+	// Default reports whether the driver plays to the device unless another is configured.
+	Default bool
+}
+
+// DeviceLister is implemented by drivers that can enumerate the devices they can play to.
+type DeviceLister interface {
+	// Devices returns the output devices available to the driver.
+	Devices() ([]Device, error)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/MatusOllah/resona/aio"
 	"github.com/MatusOllah/resona/dsp"
 	"github.com/MatusOllah/resona/playback"
+	"github.com/MatusOllah/resona/playback/driver"
 )
 
 // Driver represents the driver.
@@ -47,6 +48,11 @@ func getChanLayout(numCh int) string {
 	}
 }
 
+// NewInstance implements the driver.Instancer interface, so that every playback context runs its own ffplay.
+func (d *Driver) NewInstance() driver.Driver {
+	return &Driver{}
+}
+
 // Init initializes the driver based on the format and source.
 // It blocks until the driver is ready.
 func (d *Driver) Init(format afmt.Format, src aio.SampleReader) error {
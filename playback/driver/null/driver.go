@@ -0,0 +1,172 @@
+// Package null provides a playback driver that discards the audio,
+// for tests and machines without an audio device.
+//
+// Importing the package registers a [Driver] that reads as fast as possible as "null".
+// To simulate a real device, register another one that paces reading to the sample rate:
+//
+//	playback.Register("null-realtime", &null.Driver{Realtime: true})
+package null
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/playback"
+	"github.com/MatusOllah/resona/playback/driver"
+)
+
+// DefaultChunkFrames is the default number of frames a [Driver] reads at a time.
+const DefaultChunkFrames = 512
+
+// Default is the [Driver] registered as "null".
+var Default = &Driver{}
+
+// Driver represents the driver. It reads the audio from the context in chunks on its own goroutine and discards it.
+type Driver struct {
+	// Realtime paces reading to the sample rate, like a real device does.
+	// Otherwise, the driver reads as fast as possible.
+	Realtime bool
+
+	// ChunkFrames is the number of frames read at a time. If it is zero, [DefaultChunkFrames] is used.
+	ChunkFrames int
+
+	// BufferFrames is the number of frames the driver reports as buffered, modeling the fixed buffer of a device.
+	BufferFrames int
+
+	cfg driver.Config // set by Configure for the next Init
+
+	frames  atomic.Int64
+	running atomic.Bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Init initializes the driver based on the format and source and starts reading.
+func (d *Driver) Init(format afmt.Format, src aio.SampleReader) error {
+	if format.NumChannels <= 0 {
+		return errors.New("null: invalid number of channels")
+	}
+	if d.Realtime && format.SampleRate <= 0 {
+		return errors.New("null: invalid sample rate")
+	}
+	if d.stop != nil {
+		return errors.New("null: driver already initialized")
+	}
+
+	chunk := d.ChunkFrames
+	if chunk <= 0 {
+		chunk = DefaultChunkFrames
+	}
+
+	d.frames.Store(0)
+	d.running.Store(true)
+	d.stop = make(chan struct{})
+	d.wg.Add(1)
+	go d.run(format, src, chunk, d.stop)
+	return nil
+}
+
+func (d *Driver) run(format afmt.Format, src aio.SampleReader, chunk int, stop chan struct{}) {
+	defer d.wg.Done()
+
+	buf := make([]float32, chunk*format.NumChannels)
+	start := time.Now()
+	var periods int64
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		n, err := src.ReadSamples(buf)
+		d.frames.Add(int64(n / format.NumChannels))
+
+		var wait time.Duration
+		if d.Realtime {
+			// a device plays a whole chunk each period, filling what the source lacks with silence
+			periods++
+			wait = time.Until(start.Add(time.Duration(float64(periods*int64(chunk)) / format.SampleRate.Hertz() * float64(time.Second))))
+		} else if n == 0 || err != nil {
+			// do not spin on a source with nothing to read
+			wait = time.Millisecond
+		}
+		if wait > 0 {
+			select {
+			case <-stop:
+				return
+			case <-time.After(wait):
+			}
+		}
+	}
+}
+
+// Frames returns the number of frames read since the driver was initialized.
+func (d *Driver) Frames() int64 {
+	return d.frames.Load()
+}
+
+// BufferedFrames returns BufferFrames, or the configured buffer size, while the driver is running, and 0 otherwise.
+func (d *Driver) BufferedFrames() int {
+	if !d.running.Load() {
+		return 0
+	}
+	return d.bufferFrames()
+}
+
+func (d *Driver) bufferFrames() int {
+	if d.cfg.BufferFrames > 0 {
+		return d.cfg.BufferFrames
+	}
+	return d.BufferFrames
+}
+
+// DeviceID is the ID of the only device of the driver, which it always plays to.
+const DeviceID = "null"
+
+// Devices implements the driver.DeviceLister interface. The driver has a single device, whose ID is [DeviceID].
+func (d *Driver) Devices() ([]driver.Device, error) {
+	return []driver.Device{{ID: DeviceID, Name: "Null output (discards the audio)", Default: true}}, nil
+}
+
+// Configure implements the driver.Configurer interface. As the audio is discarded, any sample format is accepted,
+// but there is no device to take exclusive control of. The configuration applies until the driver is closed.
+func (d *Driver) Configure(cfg driver.Config) error {
+	if cfg.Exclusive {
+		return errors.New("null: exclusive mode not supported")
+	}
+	if cfg.BufferFrames < 0 {
+		return errors.New("null: invalid buffer size")
+	}
+	if cfg.Device != "" && cfg.Device != DeviceID {
+		return fmt.Errorf("null: unknown device %q", cfg.Device)
+	}
+	d.cfg = cfg
+	return nil
+}
+
+// Config implements the driver.Configurer interface.
+func (d *Driver) Config() driver.Config {
+	return driver.Config{BufferFrames: d.bufferFrames(), SampleFormat: d.cfg.SampleFormat, Device: DeviceID}
+}
+
+// Close stops reading.
+func (d *Driver) Close() error {
+	if d.stop != nil {
+		close(d.stop)
+		d.wg.Wait()
+		d.running.Store(false)
+		d.stop = nil
+	}
+	d.cfg = driver.Config{}
+	return nil
+}
+
+func init() {
+	playback.Register("null", Default) // register driver
+}
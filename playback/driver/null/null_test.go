@@ -0,0 +1,71 @@
+package null_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/generator"
+	"github.com/MatusOllah/resona/playback"
+	"github.com/MatusOllah/resona/playback/driver/null"
+)
+
+var format = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+func TestDriver(t *testing.T) {
+	ctx, err := playback.NewContext(format, playback.WithDriver("null"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	// a second of audio plays in much less than a second
+	src := aio.LimitReader(generator.NewConstant(0.5), 2*48000)
+	select {
+	case <-ctx.NewPlayer(src).PlayWithDone():
+	case <-time.After(time.Second):
+		t.Fatal("expected the driver to read as fast as possible")
+	}
+	if got := null.Default.Frames(); got < 48000 {
+		t.Errorf("expected at least 48000 frames, got %d", got)
+	}
+
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ctx.NewPlayer(generator.NewConstant(0.5)).Play()
+	frames := null.Default.Frames()
+	time.Sleep(10 * time.Millisecond)
+	if got := null.Default.Frames(); got != frames {
+		t.Errorf("expected no reading after closing, got %d more frames", got-frames)
+	}
+}
+
+var registerRealtime = sync.OnceValue(func() *null.Driver {
+	drv := &null.Driver{Realtime: true, ChunkFrames: 480}
+	playback.Register("null-realtime", drv)
+	return drv
+})
+
+func TestDriverRealtime(t *testing.T) {
+	drv := registerRealtime()
+	ctx, err := playback.NewContext(format, playback.WithDriver("null-realtime"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	ctx.NewPlayer(generator.NewConstant(0.5)).Play()
+	time.Sleep(200 * time.Millisecond)
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 200ms at 48 kHz, with plenty of room for a busy machine
+	if got := drv.Frames(); got < 4800 || got > 14400 {
+		t.Errorf("expected about 9600 frames, got %d", got)
+	}
+}
@@ -8,42 +8,146 @@ package oto
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
 	"math"
+	"sync"
+	"time"
 
 	"github.com/MatusOllah/resona/afmt"
 	"github.com/MatusOllah/resona/aio"
 	"github.com/MatusOllah/resona/dsp"
 	"github.com/MatusOllah/resona/playback"
+	"github.com/MatusOllah/resona/playback/driver"
 	"github.com/ebitengine/oto/v3"
 )
 
+// Oto can only create one context per process, so it is shared by all instances of the driver,
+// which play through players of their own.
+var (
+	sharedMu     sync.Mutex
+	sharedCtx    *oto.Context
+	sharedFormat afmt.Format
+	sharedConfig driver.Config // the configuration the device was opened with
+	sharedUsers  int           // number of initialized instances
+)
+
+// Sample formats Oto can send to the device.
+var (
+	float32LE = afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingFloat, Endian: binary.LittleEndian}
+	int16LE   = afmt.SampleFormat{BitDepth: 16, Encoding: afmt.SampleEncodingInt, Endian: binary.LittleEndian}
+	uint8PCM  = afmt.SampleFormat{BitDepth: 8, Encoding: afmt.SampleEncodingUint}
+)
+
 // Driver represents the driver.
+//
+// Every playback context gets its own instance of the driver. As Oto can only open the device once per process,
+// the instances share it, so all contexts must have the same format and configuration as the first one.
 type Driver struct {
-	ctx    *oto.Context
-	player *oto.Player
+	player      *oto.Player
+	numChannels int
+	sampleSize  int
+	cfg         driver.Config // set by Configure for the next Init
+}
+
+// NewInstance implements the driver.Instancer interface.
+func (d *Driver) NewInstance() driver.Driver {
+	return &Driver{}
+}
+
+// Configure implements the driver.Configurer interface. Oto supports the buffer size
+// and float32, int16 and uint8 samples, but neither exclusive mode nor device selection.
+func (d *Driver) Configure(cfg driver.Config) error {
+	if cfg.Exclusive {
+		return errors.New("oto: exclusive mode not supported")
+	}
+	if cfg.Device != "" {
+		return errors.New("oto: device selection not supported (Oto plays to the default device)")
+	}
+	if cfg.BufferFrames < 0 {
+		return errors.New("oto: invalid buffer size")
+	}
+	if _, err := otoFormat(cfg.SampleFormat); err != nil {
+		return err
+	}
+	d.cfg = cfg
+	return nil
+}
+
+// Config implements the driver.Configurer interface. The buffer size is 0 if Oto chose it.
+func (d *Driver) Config() driver.Config {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	return sharedConfig
+}
+
+// otoFormat returns the Oto format of a sample format, which is float32 if it is the zero value.
+func otoFormat(f afmt.SampleFormat) (oto.Format, error) {
+	switch f {
+	case afmt.SampleFormat{}, float32LE:
+		return oto.FormatFloat32LE, nil
+	case int16LE:
+		return oto.FormatSignedInt16LE, nil
+	case uint8PCM:
+		return oto.FormatUnsignedInt8, nil
+	default:
+		return 0, fmt.Errorf("oto: unsupported sample format %v (supported: float32le, int16le, uint8)", f)
+	}
 }
 
 // Init initializes the driver based on the format and source.
 // It blocks until the driver is ready.
 func (d *Driver) Init(format afmt.Format, src aio.SampleReader) error {
-	ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
-		SampleRate:   int(format.SampleRate.Hertz()),
-		ChannelCount: format.NumChannels,
-		Format:       oto.FormatFloat32LE,
-	})
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	cfg := d.cfg
+	d.cfg = driver.Config{}
+	if cfg.SampleFormat == (afmt.SampleFormat{}) {
+		cfg.SampleFormat = float32LE
+	}
+	of, err := otoFormat(cfg.SampleFormat)
 	if err != nil {
 		return err
 	}
-	<-ready
 
-	d.ctx = ctx
+	if sharedCtx == nil {
+		ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
+			SampleRate:   int(format.SampleRate.Hertz()),
+			ChannelCount: format.NumChannels,
+			Format:       of,
+			BufferSize:   time.Duration(float64(cfg.BufferFrames) / format.SampleRate.Hertz() * float64(time.Second)),
+		})
+		if err != nil {
+			return err
+		}
+		<-ready
+		sharedCtx = ctx
+		sharedFormat = format
+		sharedConfig = cfg
+	} else if format != sharedFormat {
+		return fmt.Errorf("oto: format %v differs from the format %v the device was opened with", format, sharedFormat)
+	} else if cfg.SampleFormat != sharedConfig.SampleFormat {
+		return fmt.Errorf("oto: sample format %v differs from the sample format %v the device was opened with", cfg.SampleFormat, sharedConfig.SampleFormat)
+	} else if cfg.BufferFrames != 0 && cfg.BufferFrames != sharedConfig.BufferFrames {
+		return fmt.Errorf("oto: buffer size %d differs from the buffer size %d the device was opened with", cfg.BufferFrames, sharedConfig.BufferFrames)
+	}
+	if sharedUsers == 0 {
+		if err := sharedCtx.Resume(); err != nil {
+			return err
+		}
+	}
+	sharedUsers++
 
-	d.player = ctx.NewPlayer(&pcmReader{src: src})
+	d.numChannels = format.NumChannels
+	d.sampleSize = cfg.SampleFormat.BytesPerSample()
+	d.player = sharedCtx.NewPlayer(&pcmReader{src: src, format: of, sampleSize: d.sampleSize})
 	d.player.Play()
 	return nil
 }
 
-// Close closes audio playback.
+// Close closes audio playback of the instance. Once no instance plays anymore, the device is suspended.
 // However, the underlying driver keeps existing until the process dies,
 // as closing it is not supported (see [Oto issue #149]).
 //
@@ -52,22 +156,45 @@ func (d *Driver) Init(format afmt.Format, src aio.SampleReader) error {
 //
 // [Oto issue #149]: https://github.com/ebitengine/oto/issues/149
 func (d *Driver) Close() error {
-	if err := d.player.Close(); err != nil {
-		return err
+	if d.player == nil {
+		return nil
 	}
-	return d.ctx.Suspend()
+	err := d.player.Close()
+	d.player = nil
+
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	sharedUsers--
+	if sharedUsers == 0 {
+		if sErr := sharedCtx.Suspend(); err == nil {
+			err = sErr
+		}
+	}
+	return err
+}
+
+// Flush drops the audio Oto has buffered but not played yet.
+func (d *Driver) Flush() error {
+	// seeking the player drops its buffer; pcmReader ignores the seek itself
+	_, err := d.player.Seek(0, io.SeekCurrent)
+	return err
+}
+
+// BufferedFrames returns the number of frames Oto has buffered but not played yet.
+func (d *Driver) BufferedFrames() int {
+	return d.player.BufferedSize() / d.sampleSize / d.numChannels
 }
 
-// pcmReader is an [io.Reader] that wraps aio.SampleReader and encodes audio to float32 little endian PCM.
+// pcmReader is an [io.Reader] that wraps aio.SampleReader and encodes audio to PCM in the Oto format.
 type pcmReader struct {
-	src aio.SampleReader
-	buf []float32
+	src        aio.SampleReader
+	format     oto.Format
+	sampleSize int
+	buf        []float32
 }
 
 func (r *pcmReader) Read(p []byte) (int, error) {
-	const sampleSize = 4 // float32 size = 4 bytes
-
-	numSamples := len(p) / sampleSize
+	numSamples := len(p) / r.sampleSize
 
 	if cap(r.buf) < numSamples {
 		r.buf = make([]float32, numSamples)
@@ -80,10 +207,23 @@ func (r *pcmReader) Read(p []byte) (int, error) {
 	if err != nil && n == 0 {
 		return 0, err
 	}
-	for i := range r.buf[:n] {
-		binary.LittleEndian.PutUint32(p[i*sampleSize:], math.Float32bits(dsp.Clamp(r.buf[i])))
+	for i, v := range r.buf[:n] {
+		v = dsp.Clamp(v)
+		switch r.format {
+		case oto.FormatFloat32LE:
+			binary.LittleEndian.PutUint32(p[i*4:], math.Float32bits(v))
+		case oto.FormatSignedInt16LE:
+			binary.LittleEndian.PutUint16(p[i*2:], uint16(int16(math.Round(float64(v)*math.MaxInt16))))
+		case oto.FormatUnsignedInt8:
+			p[i] = uint8(math.Round(float64(v)*127) + 128)
+		}
 	}
-	return n * sampleSize, err
+	return n * r.sampleSize, err
+}
+
+// Seek lets Oto drop its buffer in [Driver.Flush]. It does not seek anything.
+func (r *pcmReader) Seek(offset int64, whence int) (int64, error) {
+	return 0, nil
 }
 
 func init() {
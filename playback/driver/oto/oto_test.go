@@ -0,0 +1,28 @@
+package oto_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/generator"
+	"github.com/MatusOllah/resona/playback"
+	_ "github.com/MatusOllah/resona/playback/driver/oto"
+)
+
+func TestLatency(t *testing.T) {
+	ctx, err := playback.NewContext(afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}, playback.WithDriver("oto"))
+	if err != nil {
+		t.Skipf("no audio device: %v", err)
+	}
+	defer ctx.Close()
+
+	ctx.SetMasterVolume(0) // do not make noise
+	ctx.NewPlayer(generator.NewConstant(0)).Play()
+	time.Sleep(200 * time.Millisecond)
+
+	if got := ctx.Latency(); got <= 0 || got >= time.Second {
+		t.Errorf("expected a latency between 0 and 1s, got %v", got)
+	}
+}
@@ -0,0 +1,31 @@
+// Package pipewire provides a native PipeWire playback driver for Linux, which plays through a stream of its own
+// instead of the ALSA compatibility layer, for lower latency and with the metadata desktop volume controls display,
+// such as the application name and the media role.
+//
+// The driver needs cgo and libpipewire-0.3 with its development files, so it is only built with the pipewire build tag:
+//
+//	go build -tags pipewire
+//
+// Without the tag, on other platforms and without cgo, the package is empty and registers nothing.
+//
+// The stream asks for float32 samples in the format of the context. If the graph runs at another sample rate,
+// the driver resamples the audio of the context, also when the rate changes during playback.
+// To name the stream or choose the device, register a configured driver under a name of its own:
+//
+//	playback.Register("pipewire-music", &pipewire.Driver{NodeName: "my-player", MediaRole: "Music"})
+//
+// The device of a single context can also be chosen with [playback.WithDevice] and the name or serial of the node.
+//
+// # Testing
+//
+// The unit tests cover the negotiation with a mocked graph and run everywhere. To test the driver with PipeWire,
+// play a file with the pipewire tag and check the stream in pw-top, or in the volume control of the desktop:
+//
+//	go run -tags pipewire ./cmd/play -driver pipewire file.flac
+//	pw-top
+//
+// Then change the rate of the graph during playback, which the driver should follow without a change in pitch:
+//
+//	pw-metadata -n settings 0 clock.force-rate 96000
+//	pw-metadata -n settings 0 clock.force-rate 0
+package pipewire
@@ -0,0 +1,145 @@
+//go:build linux && cgo && pipewire
+
+package pipewire
+
+//#cgo pkg-config: libpipewire-0.3
+//#include <stdlib.h>
+//#include "glue.h"
+import "C"
+
+import (
+	"errors"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/playback"
+	"github.com/MatusOllah/resona/playback/driver"
+)
+
+// Driver represents the driver.
+//
+// Every playback context plays through a stream of its own.
+type Driver struct {
+	// NodeName is the name of the stream node, as shown by pw-top. If it is empty, "resona" is used.
+	NodeName string
+
+	// AppName is the name of the application shown by the volume controls. If it is empty, PipeWire uses the name of the program.
+	AppName string
+
+	// MediaRole is the role of the stream, such as "Music", "Game" or "Notification". If it is empty, "Music" is used.
+	MediaRole string
+
+	// Target is the name or serial of the node to play to. If it is empty, the stream plays to the default sink.
+	Target string
+
+	// LatencyFrames is the latency to ask of the graph in frames. If it is zero, the graph chooses it.
+	LatencyFrames int
+
+	cfg    driver.Config // set by Configure for the next Init
+	pw     *C.resona_pw
+	handle cgo.Handle
+	s      *stream
+}
+
+// NewInstance implements the driver.Instancer interface, so that every playback context gets a stream of its own.
+func (d *Driver) NewInstance() driver.Driver {
+	return &Driver{NodeName: d.NodeName, AppName: d.AppName, MediaRole: d.MediaRole, Target: d.Target, LatencyFrames: d.LatencyFrames}
+}
+
+// Configure implements the driver.Configurer interface. Only the device can be configured,
+// which is the name or serial of the target node and takes precedence over Target.
+// The graph chooses the buffer size and sample format, and mixes every stream.
+func (d *Driver) Configure(cfg driver.Config) error {
+	if cfg.Exclusive {
+		return errors.New("pipewire: exclusive mode not supported")
+	}
+	if cfg.BufferFrames != 0 {
+		return errors.New("pipewire: buffer size not supported (set LatencyFrames instead)")
+	}
+	if cfg.SampleFormat != (afmt.SampleFormat{}) {
+		return errors.New("pipewire: sample format not supported (the graph converts the float32 stream)")
+	}
+	d.cfg = cfg
+	return nil
+}
+
+// Config implements the driver.Configurer interface.
+func (d *Driver) Config() driver.Config {
+	return driver.Config{Device: d.target()}
+}
+
+// target returns the node to play to, or "" for the default sink.
+func (d *Driver) target() string {
+	if d.cfg.Device != "" {
+		return d.cfg.Device
+	}
+	return d.Target
+}
+
+// cString returns s as a C string, or nil if it is empty. It must be freed.
+func cString(s string) *C.char {
+	if s == "" {
+		return nil
+	}
+	return C.CString(s)
+}
+
+// Init connects a stream with the format and starts playing the source.
+func (d *Driver) Init(format afmt.Format, src aio.SampleReader) error {
+	if format.NumChannels <= 0 {
+		return errors.New("pipewire: invalid number of channels")
+	}
+	if format.SampleRate <= 0 {
+		return errors.New("pipewire: invalid sample rate")
+	}
+
+	d.s = newStream(format, src)
+	d.handle = cgo.NewHandle(d)
+
+	strs := []*C.char{cString(d.NodeName), cString(d.AppName), cString(d.MediaRole), cString(d.target())}
+	defer func() {
+		for _, s := range strs {
+			C.free(unsafe.Pointer(s))
+		}
+	}()
+
+	var cErr *C.char
+	d.pw = C.resona_pw_new(C.uintptr_t(d.handle), strs[0], strs[1], strs[2], strs[3],
+		C.int(format.SampleRate.Hertz()), C.int(format.NumChannels), C.int(d.LatencyFrames), &cErr)
+	if d.pw == nil {
+		d.handle.Delete()
+		return errors.New("pipewire: " + C.GoString(cErr))
+	}
+	return nil
+}
+
+// Close disconnects the stream.
+func (d *Driver) Close() error {
+	if d.pw == nil {
+		return nil
+	}
+	C.resona_pw_free(d.pw) // stops the thread loop, so there are no more callbacks
+	d.pw = nil
+	d.handle.Delete()
+	d.cfg = driver.Config{}
+	return d.s.close()
+}
+
+//export resonaPipeWireProcess
+func resonaPipeWireProcess(handle C.uintptr_t, buf *C.float, frames C.int) {
+	d := cgo.Handle(handle).Value().(*Driver)
+	p := unsafe.Slice((*float32)(unsafe.Pointer(buf)), int(frames)*d.s.format.NumChannels)
+	d.s.fill(p)
+}
+
+//export resonaPipeWireFormat
+func resonaPipeWireFormat(handle C.uintptr_t, rate, channels C.int) {
+	d := cgo.Handle(handle).Value().(*Driver)
+	d.s.setFormat(int(rate), int(channels)) // the stream plays silence if the format cannot be played
+}
+
+func init() {
+	playback.Register("pipewire", &Driver{}) // register driver
+}
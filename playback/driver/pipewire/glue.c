@@ -0,0 +1,140 @@
+//go:build linux && cgo && pipewire
+
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include <pipewire/pipewire.h>
+#include <spa/param/audio/format-utils.h>
+
+#include "glue.h"
+#include "_cgo_export.h"
+
+struct resona_pw {
+	struct pw_thread_loop *loop;
+	struct pw_stream *stream;
+	struct spa_hook listener;
+	uintptr_t handle;
+	int channels;
+};
+
+static void on_process(void *data) {
+	struct resona_pw *pw = data;
+	struct pw_buffer *b = pw_stream_dequeue_buffer(pw->stream);
+	if (b == NULL)
+		return;
+
+	struct spa_data *d = &b->buffer->datas[0];
+	if (d->data == NULL) {
+		pw_stream_queue_buffer(pw->stream, b);
+		return;
+	}
+	int stride = sizeof(float) * pw->channels;
+	uint32_t frames = d->maxsize / stride;
+	if (b->requested != 0 && b->requested < frames)
+		frames = b->requested;
+
+	resonaPipeWireProcess(pw->handle, (float *)d->data, (int)frames);
+
+	d->chunk->offset = 0;
+	d->chunk->stride = stride;
+	d->chunk->size = frames * stride;
+	pw_stream_queue_buffer(pw->stream, b);
+}
+
+static void on_param_changed(void *data, uint32_t id, const struct spa_pod *param) {
+	struct resona_pw *pw = data;
+	if (param == NULL || id != SPA_PARAM_Format)
+		return;
+
+	struct spa_audio_info_raw info;
+	spa_zero(info);
+	if (spa_format_audio_raw_parse(param, &info) < 0)
+		return;
+	resonaPipeWireFormat(pw->handle, (int)info.rate, (int)info.channels);
+}
+
+static const struct pw_stream_events stream_events = {
+	PW_VERSION_STREAM_EVENTS,
+	.param_changed = on_param_changed,
+	.process = on_process,
+};
+
+resona_pw *resona_pw_new(uintptr_t handle, const char *node_name, const char *app_name, const char *media_role,
+                         const char *target, int rate, int channels, int latency_frames, const char **err) {
+	pw_init(NULL, NULL);
+
+	struct resona_pw *pw = calloc(1, sizeof(*pw));
+	if (pw == NULL) {
+		*err = "out of memory";
+		return NULL;
+	}
+	pw->handle = handle;
+	pw->channels = channels;
+
+	pw->loop = pw_thread_loop_new("resona", NULL);
+	if (pw->loop == NULL) {
+		*err = "cannot create thread loop";
+		free(pw);
+		return NULL;
+	}
+
+	struct pw_properties *props = pw_properties_new(
+		PW_KEY_MEDIA_TYPE, "Audio",
+		PW_KEY_MEDIA_CATEGORY, "Playback",
+		PW_KEY_MEDIA_ROLE, media_role != NULL ? media_role : "Music",
+		NULL);
+	if (node_name != NULL)
+		pw_properties_set(props, PW_KEY_NODE_NAME, node_name);
+	if (app_name != NULL)
+		pw_properties_set(props, PW_KEY_APP_NAME, app_name);
+	if (target != NULL)
+		pw_properties_set(props, PW_KEY_TARGET_OBJECT, target);
+	if (latency_frames > 0)
+		pw_properties_setf(props, PW_KEY_NODE_LATENCY, "%d/%d", latency_frames, rate);
+
+	pw_thread_loop_lock(pw->loop);
+	pw->stream = pw_stream_new_simple(pw_thread_loop_get_loop(pw->loop),
+		node_name != NULL ? node_name : "resona", props, &stream_events, pw);
+	if (pw->stream == NULL) {
+		pw_thread_loop_unlock(pw->loop);
+		pw_thread_loop_destroy(pw->loop);
+		free(pw);
+		*err = "cannot create stream";
+		return NULL;
+	}
+
+	uint8_t buffer[1024];
+	struct spa_pod_builder b = SPA_POD_BUILDER_INIT(buffer, sizeof(buffer));
+	const struct spa_pod *params[1];
+	params[0] = spa_format_audio_raw_build(&b, SPA_PARAM_EnumFormat,
+		&SPA_AUDIO_INFO_RAW_INIT(.format = SPA_AUDIO_FORMAT_F32, .rate = rate, .channels = channels));
+
+	int res = pw_stream_connect(pw->stream, PW_DIRECTION_OUTPUT, PW_ID_ANY,
+		PW_STREAM_FLAG_AUTOCONNECT | PW_STREAM_FLAG_MAP_BUFFERS | PW_STREAM_FLAG_RT_PROCESS,
+		params, 1);
+	pw_thread_loop_unlock(pw->loop);
+	if (res < 0) {
+		pw_stream_destroy(pw->stream);
+		pw_thread_loop_destroy(pw->loop);
+		free(pw);
+		*err = "cannot connect stream";
+		return NULL;
+	}
+
+	if (pw_thread_loop_start(pw->loop) < 0) {
+		pw_stream_destroy(pw->stream);
+		pw_thread_loop_destroy(pw->loop);
+		free(pw);
+		*err = "cannot start thread loop";
+		return NULL;
+	}
+	return pw;
+}
+
+void resona_pw_free(resona_pw *pw) {
+	pw_thread_loop_stop(pw->loop);
+	pw_stream_destroy(pw->stream);
+	pw_thread_loop_destroy(pw->loop);
+	free(pw);
+}
@@ -0,0 +1,18 @@
+#ifndef RESONA_PIPEWIRE_GLUE_H
+#define RESONA_PIPEWIRE_GLUE_H
+
+#include <stdint.h>
+
+// resona_pw is a playback stream on its own thread loop.
+typedef struct resona_pw resona_pw;
+
+// resona_pw_new creates and connects a playback stream of float32 samples.
+// The strings may be NULL to use the defaults. handle identifies the driver in the callbacks into Go.
+// On failure, it returns NULL and sets *err to a static message.
+resona_pw *resona_pw_new(uintptr_t handle, const char *node_name, const char *app_name, const char *media_role,
+                         const char *target, int rate, int channels, int latency_frames, const char **err);
+
+// resona_pw_free disconnects and destroys the stream.
+void resona_pw_free(resona_pw *pw);
+
+#endif
@@ -0,0 +1,100 @@
+package pipewire
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/resample"
+)
+
+// negotiate decides how to play audio of format on a stream the graph negotiated with the given rate and channels,
+// either of which is 0 if the graph left it to the stream. It returns the sample rate to resample the audio to,
+// or 0 if it can be played as it is.
+func negotiate(format afmt.Format, rate, channels int) (freq.Frequency, error) {
+	if rate < 0 || channels < 0 {
+		return 0, fmt.Errorf("pipewire: invalid stream format: %d Hz, %d channels", rate, channels)
+	}
+	if channels != 0 && channels != format.NumChannels {
+		// the stream asks for the channels of the context, which PipeWire converts itself
+		return 0, fmt.Errorf("pipewire: graph negotiated %d channels instead of %d", channels, format.NumChannels)
+	}
+	if rate == 0 || freq.Frequency(rate)*freq.Hertz == format.SampleRate {
+		return 0, nil
+	}
+	return freq.Frequency(rate) * freq.Hertz, nil
+}
+
+// stream is the part of the driver that does not depend on PipeWire: it follows the format negotiated with the graph
+// and fills the buffers of the stream from the context.
+type stream struct {
+	format afmt.Format
+	src    aio.SampleReader
+
+	// the graph changes the format on its own thread while the buffers are filled on the realtime thread
+	mu  sync.Mutex
+	r   aio.SampleReader
+	rs  resample.Resampler // nil if the graph runs at the sample rate of the context
+	err error              // the error of the last negotiation
+}
+
+func newStream(format afmt.Format, src aio.SampleReader) *stream {
+	return &stream{format: format, src: src, r: src}
+}
+
+// setFormat follows the format the graph negotiated. If the format cannot be played,
+// the stream plays silence until it is renegotiated, and the error is returned.
+func (s *stream) setFormat(rate, channels int) error {
+	outRate, err := negotiate(s.format, rate, channels)
+	var rs resample.Resampler
+	if err == nil && outRate != 0 {
+		rs, err = resample.New("go", s.src, s.format.SampleRate, outRate, s.format.NumChannels, resample.QualityMedium)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rs != nil {
+		s.rs.Close()
+	}
+	s.rs = rs
+	s.err = err
+	switch {
+	case err != nil:
+		s.r = nil
+	case rs != nil:
+		s.r = rs
+	default:
+		s.r = s.src
+	}
+	return err
+}
+
+// fill fills p with the audio of the context, padding it with silence if there is not enough.
+func (s *stream) fill(p []float32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for s.r != nil && n < len(p) {
+		nn, err := s.r.ReadSamples(p[n:])
+		n += nn
+		if nn == 0 || err != nil {
+			break
+		}
+	}
+	clear(p[n:])
+}
+
+// close frees the resampler.
+func (s *stream) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	if s.rs != nil {
+		err = s.rs.Close()
+		s.rs = nil
+	}
+	s.r = nil
+	return err
+}
@@ -0,0 +1,105 @@
+package pipewire
+
+import (
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/generator"
+)
+
+var testFormat = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+func TestNegotiate(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		rate, channels int
+		want           freq.Frequency
+		wantErr        bool
+	}{
+		{"same", 48000, 2, 0, false},
+		{"unset", 0, 0, 0, false},
+		{"other rate", 44100, 2, 44100 * freq.Hertz, false},
+		{"other channels", 48000, 6, 0, true},
+		{"invalid", -1, 2, 0, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := negotiate(testFormat, tt.rate, tt.channels)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected to resample to %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// mockGraph pulls buffers of a fixed quantum from a stream at its rate, like the realtime thread of PipeWire.
+func mockGraph(s *stream, rate, quantum int, d float64) []float32 {
+	var out []float32
+	buf := make([]float32, quantum*s.format.NumChannels)
+	for range int(d * float64(rate) / float64(quantum)) {
+		s.fill(buf)
+		out = append(out, buf...)
+	}
+	return out
+}
+
+func TestStreamFollowsGraphRate(t *testing.T) {
+	s := newStream(testFormat, generator.NewConstant(0.5))
+	defer s.close()
+
+	if err := s.setFormat(48000, 2); err != nil {
+		t.Fatal(err)
+	}
+	if s.rs != nil {
+		t.Error("expected no resampling at the rate of the context")
+	}
+	for i, v := range mockGraph(s, 48000, 256, 0.1) {
+		if v != 0.5 {
+			t.Fatalf("expected the audio of the context as it is, got %v at %d", v, i)
+		}
+	}
+
+	// the graph switches to 96 kHz during playback
+	if err := s.setFormat(96000, 2); err != nil {
+		t.Fatal(err)
+	}
+	if s.rs == nil || s.rs.Ratio() != 2 {
+		t.Fatal("expected the audio to be resampled by 2")
+	}
+	out := mockGraph(s, 96000, 256, 0.1)
+	if v := out[len(out)-1]; v < 0.49 || v > 0.51 {
+		t.Errorf("expected the resampled audio of the context, got %v", v)
+	}
+
+	// and back
+	if err := s.setFormat(48000, 2); err != nil {
+		t.Fatal(err)
+	}
+	if s.rs != nil {
+		t.Error("expected the resampler to be dropped")
+	}
+}
+
+func TestStreamUnplayableFormat(t *testing.T) {
+	s := newStream(testFormat, generator.NewConstant(0.5))
+	defer s.close()
+
+	if err := s.setFormat(48000, 1); err == nil {
+		t.Fatal("expected an error for a mono stream")
+	}
+	for i, v := range mockGraph(s, 48000, 256, 0.01) {
+		if v != 0 {
+			t.Fatalf("expected silence until renegotiated, got %v at %d", v, i)
+		}
+	}
+
+	if err := s.setFormat(48000, 2); err != nil {
+		t.Fatal(err)
+	}
+	if out := mockGraph(s, 48000, 256, 0.01); out[0] != 0.5 {
+		t.Errorf("expected the audio of the context after renegotiating, got %v", out[0])
+	}
+}
@@ -0,0 +1,146 @@
+// Package writer provides a playback driver that renders the audio of a context to an aio.SampleWriter,
+// such as a WAV encoder, instead of playing it on a device.
+//
+// As there is no device clock, nothing is rendered by itself; [Driver.RenderFor] and [Driver.RenderUntilDrained]
+// pull the audio through the context as fast as the writer takes it. Players behave exactly as during live playback.
+//
+// A driver renders a single context, so it is passed to it directly instead of being registered:
+//
+//	drv := writer.New(enc)
+//	ctx, err := playback.NewContext(format, playback.WithDriverInstance(drv))
+//	// add players...
+//	err = drv.RenderUntilDrained()
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+)
+
+// DefaultChunkFrames is the default number of frames a [Driver] renders at a time.
+const DefaultChunkFrames = 1024
+
+// Driver represents the driver.
+type Driver struct {
+	// ChunkFrames is the number of frames rendered at a time. If it is zero, [DefaultChunkFrames] is used.
+	ChunkFrames int
+
+	w      aio.SampleWriter
+	format afmt.Format
+	src    aio.SampleReader
+	buf    []float32
+	frames int64
+}
+
+// New creates a new [Driver] that writes the rendered audio to w.
+func New(w aio.SampleWriter) *Driver {
+	return &Driver{w: w}
+}
+
+// Init initializes the driver based on the format and source. It does not render anything.
+func (d *Driver) Init(format afmt.Format, src aio.SampleReader) error {
+	if d.w == nil {
+		return errors.New("writer: writer is nil")
+	}
+	if format.NumChannels <= 0 {
+		return errors.New("writer: invalid number of channels")
+	}
+	if format.SampleRate <= 0 {
+		return errors.New("writer: invalid sample rate")
+	}
+	d.format = format
+	d.src = src
+	d.frames = 0
+	return nil
+}
+
+// Close stops rendering. It does not close the writer.
+func (d *Driver) Close() error {
+	d.src = nil
+	return nil
+}
+
+// Frames returns the number of frames rendered since the driver was initialized.
+func (d *Driver) Frames() int64 {
+	return d.frames
+}
+
+// RenderFor renders the next d of audio. Like a device, it renders silence while nothing is playing,
+// so that exactly d is rendered.
+func (d *Driver) RenderFor(dur time.Duration) error {
+	if d.src == nil {
+		return errors.New("writer: driver not initialized")
+	}
+	remain := int(math.Round(dur.Seconds() * d.format.SampleRate.Hertz()))
+	for remain > 0 {
+		p := d.chunk(remain)
+		n := 0
+		for n < len(p) {
+			nn, err := d.src.ReadSamples(p[n:])
+			n += nn
+			if err != nil {
+				return fmt.Errorf("writer: failed to read samples: %w", err)
+			}
+			if nn == 0 {
+				// nothing is playing
+				break
+			}
+		}
+		clear(p[n:])
+		if err := d.write(p); err != nil {
+			return err
+		}
+		remain -= len(p) / d.format.NumChannels
+	}
+	return nil
+}
+
+// RenderUntilDrained renders until all players of the context have finished playing.
+// It never returns while a player with an endless source is playing.
+func (d *Driver) RenderUntilDrained() error {
+	if d.src == nil {
+		return errors.New("writer: driver not initialized")
+	}
+	for {
+		p := d.chunk(math.MaxInt)
+		n, err := d.src.ReadSamples(p)
+		n -= n % d.format.NumChannels
+		if n == 0 {
+			// the mixer of the context returns nothing once all players have finished
+			return nil
+		}
+		if err := d.write(p[:n]); err != nil {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("writer: failed to read samples: %w", err)
+		}
+	}
+}
+
+// chunk returns the buffer for rendering at most maxFrames frames.
+func (d *Driver) chunk(maxFrames int) []float32 {
+	size := d.ChunkFrames
+	if size <= 0 {
+		size = DefaultChunkFrames
+	}
+	size = min(size, maxFrames) * d.format.NumChannels
+	if cap(d.buf) < size {
+		d.buf = make([]float32, size)
+	}
+	return d.buf[:size]
+}
+
+func (d *Driver) write(p []float32) error {
+	n, err := d.w.WriteSamples(p)
+	d.frames += int64(n / d.format.NumChannels)
+	if err != nil {
+		return fmt.Errorf("writer: failed to write samples: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package writer_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/codec/wav"
+	"github.com/MatusOllah/resona/effect"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/generator"
+	"github.com/MatusOllah/resona/internal/testutil"
+	"github.com/MatusOllah/resona/playback"
+	"github.com/MatusOllah/resona/playback/driver/writer"
+)
+
+var format = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+// newSine returns 2 seconds of a 440 Hz sine at half amplitude.
+func newSine() aio.SampleReader {
+	osc := generator.NewOscillator(440*freq.Hertz, format.SampleRate, generator.SineWaveform)
+	half := effect.EffectFunc(func(p []float32) error {
+		for i := range p {
+			p[i] *= 0.5
+		}
+		return nil
+	})
+	return aio.LimitReader(effect.Reader(osc, half), 2*2*48000)
+}
+
+// render renders the players added by play to a float WAV file and decodes it again.
+func render(t *testing.T, play func(ctx *playback.Context), do func(drv *writer.Driver) error) []float32 {
+	t.Helper()
+
+	ws := &testutil.WriteSeeker{}
+	enc, err := wav.NewEncoder(ws, format, afmt.SampleFormat{BitDepth: 32, Encoding: afmt.SampleEncodingFloat, Endian: binary.LittleEndian}, wav.FormatFloat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drv := writer.New(enc)
+	ctx, err := playback.NewContext(format, playback.WithDriverInstance(drv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	play(ctx)
+	if err := do(drv); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dec, err := wav.NewDecoder(bytes.NewReader(ws.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := aio.ReadAll(dec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := int64(len(out) / format.NumChannels); got != drv.Frames() {
+		t.Errorf("expected %d frames in the file, got %d", drv.Frames(), got)
+	}
+	return out
+}
+
+func TestRenderUntilDrained(t *testing.T) {
+	var dones []chan struct{}
+	out := render(t, func(ctx *playback.Context) {
+		dones = append(dones,
+			ctx.NewPlayer(aio.LimitReader(generator.NewConstant(0.25), 2*2*48000)).PlayWithDone(),
+			ctx.NewPlayer(newSine()).PlayWithDone(),
+		)
+	}, (*writer.Driver).RenderUntilDrained)
+
+	if got := len(out) / format.NumChannels; got != 2*48000 {
+		t.Fatalf("expected %d frames, got %d", 2*48000, got)
+	}
+
+	want, err := aio.ReadAll(newSine())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range out {
+		if w := 0.25 + want[i]; math.Abs(float64(x-w)) > 1e-6 {
+			t.Fatalf("expected %v at %d, got %v", w, i, x)
+		}
+	}
+
+	for i, done := range dones {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Errorf("expected player %d to finish", i)
+		}
+	}
+}
+
+func TestRenderFor(t *testing.T) {
+	out := render(t, func(ctx *playback.Context) {
+		ctx.NewPlayer(aio.LimitReader(generator.NewConstant(0.25), 2*48000)).Play()
+	}, func(drv *writer.Driver) error {
+		return drv.RenderFor(2 * time.Second)
+	})
+
+	// the second after the source ends is silent
+	if got := len(out) / format.NumChannels; got != 2*48000 {
+		t.Fatalf("expected %d frames, got %d", 2*48000, got)
+	}
+	for i, x := range out {
+		want := float32(0.25)
+		if i >= 2*48000 {
+			want = 0
+		}
+		if x != want {
+			t.Fatalf("expected %v at %d, got %v", want, i, x)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package playback
 
 import (
+	"fmt"
 	"maps"
 	"slices"
 	"sync"
@@ -36,3 +37,58 @@ func Drivers() []string {
 	defer driversMu.RUnlock()
 	return slices.Sorted(maps.Keys(drivers))
 }
+
+// Devices returns the output devices of the registered driver with the given name, which must implement [driver.DeviceLister].
+// Their IDs select the device to play to with [WithDevice].
+func Devices(name string) ([]driver.Device, error) {
+	driversMu.RLock()
+	drv, ok := drivers[name]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("playback: unknown driver %q (forgotten import?)", name)
+	}
+	l, ok := drv.(driver.DeviceLister)
+	if !ok {
+		return nil, fmt.Errorf("playback: driver %q does not list devices", name)
+	}
+	return l.Devices()
+}
+
+// CheckDevice returns an error if the registered driver with the given name cannot play to the device with the given ID.
+// A driver that implements [driver.DeviceLister] must list the device. Any other driver must accept it in its
+// [driver.Config], as drivers that select devices without listing them, such as PipeWire, do.
+func CheckDevice(name, id string) error {
+	driversMu.RLock()
+	drv, ok := drivers[name]
+	driversMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("playback: unknown driver %q (forgotten import?)", name)
+	}
+	if l, ok := drv.(driver.DeviceLister); ok {
+		devices, err := l.Devices()
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(devices, func(d driver.Device) bool { return d.ID == id }) {
+			return fmt.Errorf("playback: driver %q has no device %q", name, id)
+		}
+		return nil
+	}
+
+	c, ok := instance(drv).(driver.Configurer)
+	if !ok {
+		return fmt.Errorf("playback: driver %q does not support device selection", name)
+	}
+	if err := c.Configure(driver.Config{Device: id}); err != nil {
+		return fmt.Errorf("playback: driver %q cannot play to device %q: %w", name, id, err)
+	}
+	return nil
+}
+
+// instance returns the driver a context plays through for the registered driver drv.
+func instance(drv driver.Driver) driver.Driver {
+	if inst, ok := drv.(driver.Instancer); ok {
+		return inst.NewInstance()
+	}
+	return drv
+}
@@ -1,50 +1,363 @@
 package playback
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/MatusOllah/resona/afmt"
 	"github.com/MatusOllah/resona/aio"
 	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/playback/driver"
+	"github.com/MatusOllah/resona/resample"
 )
 
 // Player represents an audio player.
+//
+// A player can be paused, during which it plays silence without reading from its source.
+// A paused player does not finish, even if its source has nothing left to play, until it is resumed and the source ends;
+// [Player.PlayAndWait] simply keeps waiting meanwhile.
+//
+// A player finishes when its source ends or fails. If the source returns an error other than [io.EOF],
+// the player stops playing it and reports the error through [Player.Done] and [Player.Err].
+//
+// If the source reports its format through [afmt.Formatter] and it does not match the format of the context,
+// the player resamples it and maps its channels to the format of the context, unless the context was created
+// with [WithStrictFormat]. Sources that do not report their format are played as they are.
+//
+// By default, the source is read when the driver reads the context, so a slow source holds up the whole context.
+// With [WithPlayerBuffer], the player reads its source ahead on a goroutine of its own instead.
 type Player struct {
-	mux *audio.Mixer
-	src aio.SampleReader
+	ctx       *Context
+	mux       *audio.Mixer
+	src       aio.SampleReader
+	srcMu     sync.Mutex         // guards the source while it is read ahead into buf
+	r         aio.SampleReader   // src, adapted to the format of the context
+	rs        resample.Resampler // nil if the source is not resampled
+	buf       *playerBuffer      // nil if the player is not buffered
+	pr        *aio.PausableReader
+	srcFormat afmt.Format
+	adapted   bool
+	initErr   error
+
+	bufferSize time.Duration
+	preload    bool
+	filling    bool // whether the source is being read into buf, guarded by srcMu
+
+	started  atomic.Bool
+	once     sync.Once
+	finished chan struct{}
+	done     chan error
+	err      error
 }
 
-// NewPlayer creates a new [Player].
-func (ctx *Context) NewPlayer(src aio.SampleReader) *Player {
-	return &Player{
-		mux: ctx.mux,
-		src: src,
+// PlayerOption represents an option for configuring [Player].
+type PlayerOption func(*Player)
+
+// WithPlayerBuffer makes the player read its source ahead by up to d on a goroutine of its own,
+// so that a source that is sometimes slow, such as one reading from a network, does not cause underruns
+// as long as it catches up before the buffer runs out. Pausing takes effect immediately,
+// but seeking drops the buffered audio.
+//
+// Bigger buffers ride out longer stalls, but the source is read further ahead.
+func WithPlayerBuffer(d time.Duration) PlayerOption {
+	return func(p *Player) {
+		p.bufferSize = d
 	}
 }
 
-// Play starts the playback.
-func (p *Player) Play() {
-	p.mux.Add(p.src)
+// WithPreload makes a player with a buffer fill it before it starts playing, so that [Player.Play] blocks
+// until the buffer is full or the source has ended, but the playback starts with a full buffer.
+func WithPreload(preload bool) PlayerOption {
+	return func(p *Player) {
+		p.preload = preload
+	}
 }
 
-// PlayWithDone starts the playback and returns a channel that closes when the player has finished playing and drained.
-func (p *Player) PlayWithDone() chan struct{} {
-	done := make(chan struct{})
+// NewPlayer creates a new [Player] with the specified options.
+func (ctx *Context) NewPlayer(src aio.SampleReader, opts ...PlayerOption) *Player {
+	p := &Player{
+		ctx:       ctx,
+		mux:       ctx.mux,
+		src:       src,
+		r:         src,
+		srcFormat: ctx.format,
+		finished:  make(chan struct{}),
+		done:      make(chan error, 1),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if err := ctx.checkOpen(); err != nil {
+		p.initErr = err
+		p.pr = aio.NewPausableReader(src)
+		return p
+	}
 
-	var wg sync.WaitGroup
-	wg.Add(1)
+	if format, ok := sourceFormat(src); ok && format != ctx.format {
+		if ctx.strictFormat {
+			p.initErr = fmt.Errorf("playback: source format (%v, %d channels) does not match context format (%v, %d channels)",
+				format.SampleRate, format.NumChannels, ctx.format.SampleRate, ctx.format.NumChannels)
+		} else {
+			p.r, p.rs, p.initErr = adapt(src, format, ctx.format)
+			p.srcFormat = format
+			p.adapted = p.initErr == nil
+		}
+	}
 
-	wrapped := aio.CallbackReader(p.src, func() {
-		wg.Done()
+	if p.bufferSize > 0 && p.initErr == nil {
+		frames := int(p.bufferSize.Seconds() * ctx.format.SampleRate.Hertz())
+		p.buf = newPlayerBuffer(max(frames, 1)*ctx.format.NumChannels, ctx.format.NumChannels)
+		p.pr = aio.NewPausableReader(p.buf)
+	} else {
+		p.pr = aio.NewPausableReader(p.r)
+	}
+	return p
+}
+
+// Adapted reports whether the source of the player is resampled or its channels are mapped
+// to match the format of the context.
+func (p *Player) Adapted() bool {
+	return p.adapted
+}
+
+// SourceFormat returns the format of the source of the player,
+// which is the format of the context if the source does not report it.
+func (p *Player) SourceFormat() afmt.Format {
+	return p.srcFormat
+}
+
+// Done returns a channel that receives the result of the playback once the player has finished playing:
+// nil if the source ended, or the error of the source otherwise. The channel is closed afterwards.
+func (p *Player) Done() <-chan error {
+	return p.done
+}
+
+// Err returns the error the source of the player failed with, or nil if it has not failed.
+func (p *Player) Err() error {
+	select {
+	case <-p.finished:
+		return p.err
+	default:
+		return nil
+	}
+}
+
+// finish reports the result of the playback.
+func (p *Player) finish(err error) {
+	p.once.Do(func() {
+		if p.buf != nil {
+			p.buf.Close() // stops the goroutine reading the source
+		}
+		if p.rs != nil {
+			p.srcMu.Lock()
+			if closeErr := p.rs.Close(); err == nil {
+				err = closeErr
+			}
+			p.srcMu.Unlock()
+		}
+		p.err = err
+		close(p.finished)
+		p.done <- err
+		close(p.done)
 	})
+}
+
+// playerReader is what the mixer of the context reads a [Player] through.
+// The mixer gets [io.EOF] once the source fails, so that only the player reports the error.
+type playerReader struct {
+	p *Player
+}
+
+func (r playerReader) ReadSamples(buf []float32) (int, error) {
+	p := r.p
+	n, err := p.pr.ReadSamples(buf)
+	if err == io.EOF {
+		p.finish(nil)
+	} else if err != nil {
+		p.finish(err)
+		p.ctx.failed = append(p.ctx.failed, p) // the mixer is read with ctx.mu held
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Pause pauses the player. It can be paused before it starts playing, in which case it starts paused.
+func (p *Player) Pause() {
+	p.pr.Pause()
+}
+
+// Resume resumes the player.
+func (p *Player) Resume() {
+	p.pr.Resume()
+}
+
+// IsPaused reports whether the player is paused.
+func (p *Player) IsPaused() bool {
+	return p.pr.IsPaused()
+}
+
+// Seek seeks the source of the player, which must implement [io.Seeker], and returns its new offset.
+//
+// The audio of the context read before seeking but not played yet is dropped, as far as the driver allows,
+// so that the old position is not heard after Seek returns. Because the audio is mixed by then,
+// this also drops a short stretch of the other players of the context.
+func (p *Player) Seek(offset int64, whence int) (int64, error) {
+	s, ok := p.src.(io.Seeker)
+	if !ok {
+		return 0, errors.New("playback: source is not an io.Seeker")
+	}
+	if err := p.ctx.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	p.ctx.mu.Lock()
+	p.srcMu.Lock()
+	pos, err := s.Seek(offset, whence)
+	if err != nil {
+		p.srcMu.Unlock()
+		p.ctx.mu.Unlock()
+		return 0, err
+	}
+	if c, ok := p.rs.(*resample.Converter); ok {
+		c.Reset() // drops the frames read ahead of the old position
+	}
+	if p.buf != nil {
+		p.buf.reset()
+		if p.started.Load() && !p.filling {
+			// the source had ended, but there is more to play now
+			p.filling = true
+			go p.fill()
+		}
+	}
+	p.srcMu.Unlock()
+	p.ctx.buf.Reset(mixReader{p.ctx})
+	p.ctx.mu.Unlock()
 
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
+	// the driver may be reading from the context while flushing, so it is flushed without the lock
+	if f, ok := p.ctx.drv.(driver.Flusher); ok {
+		if err := f.Flush(); err != nil {
+			return pos, err
+		}
+	}
+	return pos, nil
+}
 
-	p.mux.Add(wrapped)
+// Position returns the position of the source, which must implement [io.Seeker], that is being heard,
+// taking the audio buffered by the player, its resampler, the context and the driver into account.
+// Like the offsets of the decoders, the position is in frames.
+//
+// The audio buffered by the driver is only accounted for if the driver reports it.
+func (p *Player) Position() (int64, error) {
+	s, ok := p.src.(io.Seeker)
+	if !ok {
+		return 0, errors.New("playback: source is not an io.Seeker")
+	}
+	if err := p.ctx.checkOpen(); err != nil {
+		return 0, err
+	}
 
-	return done
+	p.ctx.mu.Lock()
+	defer p.ctx.mu.Unlock()
+	p.srcMu.Lock()
+	defer p.srcMu.Unlock()
+	pos, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	buffered := p.ctx.buffered()
+	if p.buf != nil {
+		buffered += int64(p.buf.buffered() / p.ctx.format.NumChannels)
+	}
+	if p.srcFormat.SampleRate != p.ctx.format.SampleRate {
+		// the buffered audio is at the sample rate of the context, like the delay of the resampler
+		delay := float64(buffered)
+		if c, ok := p.rs.(*resample.Converter); ok {
+			delay += c.Delay()
+		}
+		buffered = int64(math.Round(delay * p.srcFormat.SampleRate.Hertz() / p.ctx.format.SampleRate.Hertz()))
+	}
+	return max(pos-buffered, 0), nil
+}
+
+// PositionDur is like [Player.Position], but returns the position as a duration at the sample rate of the source.
+func (p *Player) PositionDur() (time.Duration, error) {
+	pos, err := p.Position()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(float64(pos) / p.srcFormat.SampleRate.Hertz() * float64(time.Second)), nil
+}
+
+// BufferedDuration returns the duration of the audio of the player read from its source but not played yet.
+// As the players are mixed before the audio is buffered, that is the [Context.Latency] while the player is playing,
+// plus the audio in the buffer of the player, if it has one, and 0 before it starts or once it has finished.
+func (p *Player) BufferedDuration() time.Duration {
+	select {
+	case <-p.finished:
+		return 0
+	default:
+	}
+	if !p.started.Load() {
+		return 0
+	}
+	d := p.ctx.Latency()
+	if p.buf != nil {
+		frames := p.buf.buffered() / p.ctx.format.NumChannels
+		d += time.Duration(float64(frames) / p.ctx.format.SampleRate.Hertz() * float64(time.Second))
+	}
+	return d
+}
+
+// Play starts the playback. If the context is closed, the player finishes right away with [ErrClosed].
+func (p *Player) Play() {
+	p.play(nil)
+}
+
+// play starts the playback, at the given time if it is not nil.
+func (p *Player) play(when *FrameTime) {
+	if p.initErr == nil {
+		p.initErr = p.ctx.checkOpen()
+	}
+	if p.initErr != nil {
+		p.finish(p.initErr)
+		if p.ctx.onError != nil {
+			p.ctx.onError(p, p.initErr)
+		}
+		return
+	}
+	p.started.Store(true)
+	if p.buf == nil {
+		p.ctx.add(playerReader{p}, when)
+		return
+	}
+
+	p.srcMu.Lock()
+	if p.filling {
+		p.srcMu.Unlock()
+		return
+	}
+	p.filling = true
+	if p.preload {
+		p.fillFull()
+	}
+	filling := p.filling
+	p.srcMu.Unlock()
+
+	p.ctx.add(playerReader{p}, when)
+	if filling {
+		go p.fill()
+	}
+}
+
+// PlayWithDone starts the playback and returns a channel that closes when the player has finished playing and drained.
+func (p *Player) PlayWithDone() chan struct{} {
+	p.Play()
+	return p.finished
 }
 
 // PlayAndWait starts the playback and blocks until the player has finished playing and drained.
@@ -0,0 +1,669 @@
+package playback_test
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/generator"
+	"github.com/MatusOllah/resona/playback"
+	"github.com/MatusOllah/resona/playback/driver/null"
+	"github.com/MatusOllah/resona/playback/driver/writer"
+)
+
+var nullFormat = afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}
+
+// newNullContext creates a [playback.Context] with the null driver, which is closed when the test ends.
+func newNullContext(t *testing.T) *playback.Context {
+	t.Helper()
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriver("null"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ctx.Close() })
+	return ctx
+}
+
+func TestPlayerPlayWithDone(t *testing.T) {
+	ctx := newNullContext(t)
+
+	src := aio.LimitReader(generator.NewConstant(0.5), 2*4800)
+	select {
+	case <-ctx.NewPlayer(src).PlayWithDone():
+	case <-time.After(time.Second):
+		t.Fatal("expected the player to finish")
+	}
+	if got := null.Default.Frames(); got < 4800 {
+		t.Errorf("expected at least 4800 frames consumed, got %d", got)
+	}
+}
+
+func TestPlayerPlayAndWait(t *testing.T) {
+	ctx := newNullContext(t)
+
+	done := make(chan struct{})
+	go func() {
+		ctx.NewPlayer(aio.LimitReader(generator.NewConstant(0.5), 2*4800)).PlayAndWait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected PlayAndWait to return")
+	}
+}
+
+func TestPlayerConcurrent(t *testing.T) {
+	ctx := newNullContext(t)
+
+	var dones []chan struct{}
+	for range 8 {
+		dones = append(dones, ctx.NewPlayer(aio.LimitReader(generator.NewConstant(0.1), 2*4800)).PlayWithDone())
+	}
+	for i, done := range dones {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("expected player %d to finish", i)
+		}
+	}
+}
+
+func TestContextCloseClears(t *testing.T) {
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriver("null"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an endless source never finishes by itself
+	done := ctx.NewPlayer(generator.NewConstant(0.5)).PlayWithDone()
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+		t.Error("expected the cleared player not to finish")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	frames := null.Default.Frames()
+	time.Sleep(10 * time.Millisecond)
+	if got := null.Default.Frames(); got != frames {
+		t.Errorf("expected no reading after closing, got %d more frames", got-frames)
+	}
+}
+
+// countingReader counts the samples read from r.
+type countingReader struct {
+	r aio.SampleReader
+	n atomic.Int64
+}
+
+func (c *countingReader) ReadSamples(p []float32) (int, error) {
+	n, err := c.r.ReadSamples(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func TestPlayerPause(t *testing.T) {
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(&null.Driver{Realtime: true, ChunkFrames: 240}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	src := &countingReader{r: generator.NewConstant(0.5)}
+	player := ctx.NewPlayer(src)
+	player.Play()
+	time.Sleep(50 * time.Millisecond)
+	if src.n.Load() == 0 {
+		t.Fatal("expected the source to be read while playing")
+	}
+
+	player.Pause()
+	if !player.IsPaused() {
+		t.Error("expected the player to be paused")
+	}
+	time.Sleep(20 * time.Millisecond) // let a read in progress finish
+	paused := src.n.Load()
+	time.Sleep(50 * time.Millisecond)
+	if got := src.n.Load(); got != paused {
+		t.Errorf("expected the source not to be read while paused, got %d more samples", got-paused)
+	}
+
+	player.Resume()
+	if player.IsPaused() {
+		t.Error("expected the player to be resumed")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if src.n.Load() == paused {
+		t.Error("expected the source to be read after resuming")
+	}
+}
+
+func TestPlayerPausedDoesNotFinish(t *testing.T) {
+	ctx := newNullContext(t)
+
+	player := ctx.NewPlayer(aio.LimitReader(generator.NewConstant(0.5), 2*480))
+	player.Pause() // before playing, so it starts paused
+	done := player.PlayWithDone()
+	select {
+	case <-done:
+		t.Fatal("expected a paused player not to finish")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	player.Resume()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the player to finish after resuming")
+	}
+}
+
+// rampSource is an endless seekable source whose samples are the index of their frame.
+type rampSource struct {
+	numChannels int
+	pos         int64 // samples
+}
+
+func (r *rampSource) ReadSamples(p []float32) (int, error) {
+	for i := range p {
+		p[i] = float32((r.pos + int64(i)) / int64(r.numChannels))
+	}
+	r.pos += int64(len(p))
+	return len(p), nil
+}
+
+func (r *rampSource) Seek(offset int64, whence int) (int64, error) {
+	frame := r.pos / int64(r.numChannels)
+	switch whence {
+	case io.SeekStart:
+		frame = offset
+	case io.SeekCurrent:
+		frame += offset
+	default:
+		return 0, errors.New("rampSource: invalid whence")
+	}
+	r.pos = frame * int64(r.numChannels)
+	return frame, nil
+}
+
+func TestPlayerSeek(t *testing.T) {
+	out := audio.NewBufferSize(1024)
+	drv := writer.New(out)
+	drv.ChunkFrames = 100
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(drv), playback.WithBufferSize(4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	player := ctx.NewPlayer(&rampSource{numChannels: nullFormat.NumChannels})
+	player.Play()
+	if err := drv.RenderFor(100 * time.Second / 48000); err != nil {
+		t.Fatal(err)
+	}
+
+	// the context has read ahead of what has been rendered
+	if pos, err := player.Position(); err != nil {
+		t.Fatal(err)
+	} else if pos != 100 {
+		t.Errorf("expected position 100, got %d", pos)
+	}
+
+	if pos, err := player.Seek(10000, io.SeekStart); err != nil {
+		t.Fatal(err)
+	} else if pos != 10000 {
+		t.Errorf("expected Seek to return 10000, got %d", pos)
+	}
+	out.Reset()
+	if err := drv.RenderFor(10 * time.Second / 48000); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.Float32s()[0]; got != 10000 {
+		t.Errorf("expected the first frame after seeking to be 10000, got %v", got)
+	}
+	if pos, err := player.Position(); err != nil {
+		t.Fatal(err)
+	} else if pos != 10010 {
+		t.Errorf("expected position 10010, got %d", pos)
+	}
+}
+
+func TestPlayerSeekWhilePlaying(t *testing.T) {
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(&null.Driver{Realtime: true, ChunkFrames: 240}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	player := ctx.NewPlayer(&rampSource{numChannels: nullFormat.NumChannels})
+	player.Play()
+	for i := range 10 {
+		time.Sleep(5 * time.Millisecond)
+		if _, err := player.Seek(int64(i*48000), io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		if pos, err := player.Position(); err != nil {
+			t.Fatal(err)
+		} else if pos > int64(i*48000)+48000 {
+			t.Errorf("expected a position shortly after %d, got %d", i*48000, pos)
+		}
+	}
+}
+
+func TestPlayerSeekNotSeeker(t *testing.T) {
+	ctx := newNullContext(t)
+	player := ctx.NewPlayer(generator.NewConstant(0.5))
+	if _, err := player.Seek(0, io.SeekStart); err == nil {
+		t.Error("expected an error for a source that is not an io.Seeker")
+	}
+	if _, err := player.Position(); err == nil {
+		t.Error("expected an error for a source that is not an io.Seeker")
+	}
+}
+
+// failingReader returns err after n samples.
+type failingReader struct {
+	n   int
+	err error
+}
+
+func (r *failingReader) ReadSamples(p []float32) (int, error) {
+	if r.n == 0 {
+		return 0, r.err
+	}
+	n := min(len(p), r.n)
+	clear(p[:n])
+	r.n -= n
+	return n, nil
+}
+
+func TestPlayerDone(t *testing.T) {
+	ctx := newNullContext(t)
+
+	errBroken := errors.New("broken source")
+	failing := ctx.NewPlayer(&failingReader{n: 100, err: errBroken})
+	ok := ctx.NewPlayer(aio.LimitReader(generator.NewConstant(0.5), 100))
+	failing.Play()
+	ok.Play()
+
+	for _, tt := range []struct {
+		player *playback.Player
+		want   error
+	}{
+		{failing, errBroken},
+		{ok, nil},
+	} {
+		select {
+		case err := <-tt.player.Done():
+			if err != tt.want {
+				t.Errorf("expected %v from Done, got %v", tt.want, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected the player to finish")
+		}
+		if err := tt.player.Err(); err != tt.want {
+			t.Errorf("expected %v from Err, got %v", tt.want, err)
+		}
+		if _, ok := <-tt.player.Done(); ok {
+			t.Error("expected Done to be closed after the result")
+		}
+	}
+}
+
+// formatReader is a reader that reports a format.
+type formatReader struct {
+	aio.SampleReader
+	format afmt.Format
+}
+
+func (r formatReader) Format() afmt.Format { return r.format }
+
+// formatRamp is a [rampSource] that reports a format.
+type formatRamp struct {
+	*rampSource
+	format afmt.Format
+}
+
+func (r formatRamp) Format() afmt.Format { return r.format }
+
+func TestPlayerAdaptsFormat(t *testing.T) {
+	out := audio.NewBufferSize(1024)
+	drv := writer.New(out)
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(drv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	// half a second of a mono sine at 22.05 kHz
+	srcFormat := afmt.Format{SampleRate: 22050 * freq.Hertz, NumChannels: 1}
+	src := formatReader{aio.LimitReader(generator.NewOscillator(440*freq.Hertz, srcFormat.SampleRate, generator.SineWaveform), 11025), srcFormat}
+	player := ctx.NewPlayer(src)
+	if !player.Adapted() {
+		t.Fatal("expected the player to adapt the source")
+	}
+	if got := player.SourceFormat(); got != srcFormat {
+		t.Errorf("expected source format %v, got %v", srcFormat, got)
+	}
+	player.Play()
+	if err := drv.RenderUntilDrained(); err != nil {
+		t.Fatal(err)
+	}
+	if err := player.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	p := out.Float32s()
+	frames := len(p) / 2
+	if frames < 23900 || frames > 24100 {
+		t.Errorf("expected about 24000 frames, got %d", frames)
+	}
+	var peakL, peakR float32
+	for i := 0; i+1 < len(p); i += 2 {
+		if p[i] != p[i+1] {
+			t.Fatalf("expected both channels to match, got %v and %v at frame %d", p[i], p[i+1], i/2)
+		}
+		peakL = max(peakL, p[i])
+		peakR = max(peakR, p[i+1])
+	}
+	if peakL < 0.9 || peakR < 0.9 {
+		t.Errorf("expected both channels to be populated, got peaks %v and %v", peakL, peakR)
+	}
+}
+
+func TestPlayerSeekAdapted(t *testing.T) {
+	out := audio.NewBufferSize(1024)
+	drv := writer.New(out)
+	drv.ChunkFrames = 100
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(drv), playback.WithBufferSize(4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	// a mono ramp at 24 kHz, of which the resampler reads ahead
+	srcFormat := afmt.Format{SampleRate: 24 * freq.KiloHertz, NumChannels: 1}
+	player := ctx.NewPlayer(formatRamp{&rampSource{numChannels: 1}, srcFormat})
+	if !player.Adapted() {
+		t.Fatal("expected the player to adapt the source")
+	}
+	player.Play()
+	if err := drv.RenderFor(100 * time.Second / 48000); err != nil {
+		t.Fatal(err)
+	}
+	if pos, err := player.Position(); err != nil {
+		t.Fatal(err)
+	} else if pos != 50 {
+		t.Errorf("expected position 50, got %d", pos)
+	}
+
+	if _, err := player.Seek(10000, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := drv.RenderFor(200 * time.Second / 48000); err != nil {
+		t.Fatal(err)
+	}
+
+	// every frame is from after the new position; once the kernel no longer reaches back
+	// to the silence before the restart, the ramp goes on by half a frame per frame
+	p := out.Float32s()
+	for i := 0; i < 200; i++ {
+		got := p[2*i]
+		if got < 5000 {
+			t.Fatalf("frame %d: expected audio from after the seek, got %v", i, got)
+		}
+		if want := 10000 + float32(i)/2; i >= 128 && math.Abs(float64(got-want)) > 1 {
+			t.Fatalf("frame %d: expected %v, got %v", i, want, got)
+		}
+	}
+	if pos, err := player.Position(); err != nil {
+		t.Fatal(err)
+	} else if pos != 10100 {
+		t.Errorf("expected position 10100, got %d", pos)
+	}
+}
+
+func TestPlayerPassesThroughUnknownFormat(t *testing.T) {
+	ctx := newNullContext(t)
+	player := ctx.NewPlayer(generator.NewConstant(0.5))
+	if player.Adapted() {
+		t.Error("expected a source without a format not to be adapted")
+	}
+	player = ctx.NewPlayer(formatReader{generator.NewConstant(0.5), nullFormat})
+	if player.Adapted() {
+		t.Error("expected a source with the format of the context not to be adapted")
+	}
+}
+
+func TestPlayerStrictFormat(t *testing.T) {
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriver("null"), playback.WithStrictFormat())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	src := formatReader{generator.NewConstant(0.5), afmt.Format{SampleRate: 22050 * freq.Hertz, NumChannels: 1}}
+	player := ctx.NewPlayer(src)
+	player.Play()
+	select {
+	case err := <-player.Done():
+		if err == nil {
+			t.Error("expected an error for a mismatched format")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the player to fail")
+	}
+	if player.Adapted() {
+		t.Error("expected a strict context not to adapt the source")
+	}
+}
+
+// stallingReader is an endless source that stalls once, after some frames, like a slow disk.
+type stallingReader struct {
+	after   int // samples
+	stall   time.Duration
+	read    int
+	stalled bool
+}
+
+func (r *stallingReader) ReadSamples(p []float32) (int, error) {
+	if !r.stalled && r.read >= r.after {
+		r.stalled = true
+		time.Sleep(r.stall)
+	}
+	for i := range p {
+		p[i] = 0.5
+	}
+	r.read += len(p)
+	return len(p), nil
+}
+
+func TestPlayerBufferRidesOutStall(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		buffer    time.Duration
+		underruns bool
+	}{
+		{"200ms", 200 * time.Millisecond, false},
+		{"5ms", 5 * time.Millisecond, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(&null.Driver{Realtime: true}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ctx.Close()
+
+			// stall for 50ms after 100ms, in 300ms of audio
+			src := aio.LimitReader(&stallingReader{after: 2 * 4800, stall: 50 * time.Millisecond}, 2*14400)
+			player := ctx.NewPlayer(src, playback.WithPlayerBuffer(tt.buffer), playback.WithPreload(true))
+			select {
+			case <-player.PlayWithDone():
+			case <-time.After(2 * time.Second):
+				t.Fatal("expected the player to finish")
+			}
+
+			stats := ctx.Stats()
+			if tt.underruns && stats.Underruns == 0 {
+				t.Error("expected the stall to cause underruns")
+			}
+			if !tt.underruns && stats.Underruns != 0 {
+				t.Errorf("expected the buffer to ride out the stall, got %+v", stats)
+			}
+		})
+	}
+}
+
+func TestPlayerBufferSeek(t *testing.T) {
+	out := audio.NewBufferSize(1024)
+	drv := writer.New(out)
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(drv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	player := ctx.NewPlayer(&rampSource{numChannels: 2}, playback.WithPlayerBuffer(100*time.Millisecond), playback.WithPreload(true))
+	player.Play()
+	if err := drv.RenderFor(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if got := player.BufferedDuration(); got < 50*time.Millisecond {
+		t.Errorf("expected the buffer of the player to be counted, got %v", got)
+	}
+
+	if _, err := player.Seek(48000, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	pos, err := player.Position()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 48000 {
+		t.Errorf("expected position 48000 right after seeking, got %d", pos)
+	}
+
+	out.Reset()
+	for range 100 {
+		if err := drv.RenderFor(time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+		if p := out.Float32s(); len(p) > 0 && p[len(p)-1] != 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	p := out.Float32s()
+	var first float32
+	for _, v := range p {
+		if v != 0 {
+			first = v
+			break
+		}
+	}
+	if first != 48000 {
+		t.Errorf("expected playback to resume at frame 48000, got %v", first)
+	}
+}
+
+func TestPlayerErrorCallback(t *testing.T) {
+	type failure struct {
+		player *playback.Player
+		err    error
+	}
+	var failures []failure
+	out := audio.NewBufferSize(1024)
+	drv := writer.New(out)
+	ctx, err := playback.NewContext(nullFormat,
+		playback.WithDriverInstance(drv),
+		playback.WithErrorCallback(func(p *playback.Player, err error) {
+			failures = append(failures, failure{p, err})
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	errBroken := errors.New("broken source")
+	failing := ctx.NewPlayer(&failingReader{n: 100, err: errBroken})
+	good := ctx.NewPlayer(aio.LimitReader(generator.NewConstant(0.25), 2*4800))
+	failing.Play()
+	good.Play()
+	if err := drv.RenderUntilDrained(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(failures) != 1 || failures[0].player != failing || failures[0].err != errBroken {
+		t.Fatalf("expected one failure of the failing player, got %v", failures)
+	}
+	if err := <-good.Done(); err != nil {
+		t.Errorf("expected the good player to finish without an error, got %v", err)
+	}
+
+	// the good source plays to completion, with silence in place of the failed one
+	p := out.Float32s()
+	if len(p) != 2*4800 {
+		t.Fatalf("expected %d samples, got %d", 2*4800, len(p))
+	}
+	if got := p[len(p)-1]; got != 0.25 {
+		t.Errorf("expected the good source alone at the end, got %v", got)
+	}
+}
+
+// bufferingDriver is a [fakeDriver] that reports a fixed number of frames as buffered, like a device buffer.
+type bufferingDriver struct {
+	fakeDriver
+	frames int
+}
+
+func (d *bufferingDriver) BufferedFrames() int { return d.frames }
+
+func TestPlayerPositionAudible(t *testing.T) {
+	drv := &bufferingDriver{frames: 480}
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(drv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	src := &rampSource{numChannels: 2}
+	player := ctx.NewPlayer(src)
+	player.Play()
+	buf := make([]float32, 2*100)
+	for range 48 {
+		if _, err := aio.ReadFull(drv.src, buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the source was read ahead by the context, and the driver holds 480 frames
+	if read := src.pos / 2; read <= 4800 {
+		t.Fatalf("expected the context to read ahead of the driver, got %d frames read", read)
+	}
+	pos, err := player.Position()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 4800-480 {
+		t.Errorf("expected position %d, got %d", 4800-480, pos)
+	}
+	d, err := player.PositionDur()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 90*time.Millisecond {
+		t.Errorf("expected position 90ms, got %v", d)
+	}
+}
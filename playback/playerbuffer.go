@@ -0,0 +1,174 @@
+package playback
+
+import (
+	"sync"
+	"time"
+)
+
+// playerBuffer is the buffer of a [Player] created with [WithPlayerBuffer].
+// The player reads its source ahead into the buffer on a goroutine of its own, and the mixer reads from the buffer
+// without waiting: when the buffer runs empty, it reads nothing, which the context counts as an underrun.
+type playerBuffer struct {
+	mu          sync.Mutex
+	buf         []float32
+	numChannels int
+	start       int   // index of the oldest sample
+	n           int   // number of samples held
+	err         error // the error the source ended with, once it has
+	closed      bool
+
+	space chan struct{} // signaled when samples are read or the buffer is closed
+}
+
+func newPlayerBuffer(size, numChannels int) *playerBuffer {
+	return &playerBuffer{
+		buf:         make([]float32, max(size-size%numChannels, numChannels)),
+		numChannels: numChannels,
+		space:       make(chan struct{}, 1),
+	}
+}
+
+// ReadSamples implements the aio.SampleReader interface. It reads whole frames only,
+// and returns the error the source ended with once the buffer is empty.
+func (b *playerBuffer) ReadSamples(p []float32) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.n == 0 {
+		return 0, b.err
+	}
+
+	n := min(len(p), b.n)
+	n -= n % b.numChannels
+	m := copy(p[:n], b.buf[b.start:])
+	copy(p[m:n], b.buf)
+	b.start = (b.start + n) % len(b.buf)
+	b.n -= n
+
+	b.signal()
+	return n, nil
+}
+
+// write writes as much of p as fits into the buffer and returns the number of samples written.
+func (b *playerBuffer) write(p []float32) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := min(len(p), len(b.buf)-b.n)
+	end := (b.start + b.n) % len(b.buf)
+	m := copy(b.buf[end:], p[:n])
+	copy(b.buf, p[m:n])
+	b.n += n
+	return n
+}
+
+// free returns the number of samples that fit into the buffer.
+func (b *playerBuffer) free() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.buf) - b.n
+}
+
+// buffered returns the number of samples held by the buffer.
+func (b *playerBuffer) buffered() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.n
+}
+
+// end records that the source ended with err.
+func (b *playerBuffer) end(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+}
+
+// reset drops the buffered samples and clears the error the source ended with.
+func (b *playerBuffer) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.start, b.n, b.err = 0, 0, nil
+	b.signal()
+}
+
+// Close makes the goroutine filling the buffer stop.
+func (b *playerBuffer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.signal()
+}
+
+func (b *playerBuffer) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// signal wakes up the goroutine filling the buffer. b.mu must be held.
+func (b *playerBuffer) signal() {
+	select {
+	case b.space <- struct{}{}:
+	default:
+	}
+}
+
+// fillChunk is the number of frames the goroutine filling a [playerBuffer] reads from the source at a time.
+const fillChunk = 512
+
+// fillFull reads the source of p into its buffer until the buffer is full or the source ends.
+// p.srcMu must be held.
+func (p *Player) fillFull() {
+	chunk := make([]float32, min(fillChunk*p.ctx.format.NumChannels, len(p.buf.buf)))
+	for p.filling {
+		free := p.buf.free()
+		if free < p.ctx.format.NumChannels {
+			return
+		}
+		if p.readChunk(chunk[:min(len(chunk), free-free%p.ctx.format.NumChannels)]) == 0 {
+			return // the source has nothing to read yet, so start with what there is
+		}
+	}
+}
+
+// fill reads the source of p into its buffer until the source ends or the buffer is closed.
+func (p *Player) fill() {
+	chunk := make([]float32, min(fillChunk*p.ctx.format.NumChannels, len(p.buf.buf)))
+	for !p.buf.isClosed() {
+		if p.buf.free() < len(chunk) {
+			select {
+			case <-p.buf.space:
+			case <-p.ctx.closed:
+				return
+			}
+			continue
+		}
+
+		p.srcMu.Lock()
+		n := p.readChunk(chunk)
+		filling := p.filling
+		p.srcMu.Unlock()
+		if !filling {
+			return
+		}
+		if n == 0 {
+			// do not spin on a source with nothing to read yet
+			select {
+			case <-time.After(time.Millisecond):
+			case <-p.ctx.closed:
+				return
+			}
+		}
+	}
+}
+
+// readChunk reads a chunk of the source of p into its buffer and returns the number of samples read.
+// Once the source ends, it stops filling the buffer.
+// p.srcMu must be held.
+func (p *Player) readChunk(chunk []float32) int {
+	n, err := p.r.ReadSamples(chunk)
+	p.buf.write(chunk[:n])
+	if err != nil {
+		p.buf.end(err)
+		p.filling = false
+	}
+	return n
+}
@@ -0,0 +1,69 @@
+package playback
+
+import "github.com/MatusOllah/resona/aio"
+
+// FrameTime is a point in time on the output clock of a [Context], in frames.
+type FrameTime int64
+
+// Now returns the current time on the output clock of the context, which counts the frames the context
+// has delivered to the driver. The clock stands still while the context has nothing to play,
+// as the driver then plays silence of its own.
+func (ctx *Context) Now() FrameTime {
+	return FrameTime(ctx.clock.Load())
+}
+
+// PlayAt creates a [Player] of r and starts it at the given time on the output clock of the context.
+// It is a shorthand for [Context.NewPlayer] followed by [Player.PlayAt].
+func (ctx *Context) PlayAt(r aio.SampleReader, when FrameTime, opts ...PlayerOption) *Player {
+	p := ctx.NewPlayer(r, opts...)
+	p.PlayAt(when)
+	return p
+}
+
+// PlayAt starts the playback at the given time on the output clock of the context, such as a beat of a metronome.
+// If the time has passed, the playback starts immediately.
+//
+// The start is exact to the frame in the output of the context, as the player is preceded by silence up to when,
+// computed against the clock as the player is added. It is heard [Context.Latency] later, like all audio of the context.
+// Players whose sources return fewer samples than asked for before their end drift against the clock,
+// so scheduling is only exact relative to players that do not.
+func (p *Player) PlayAt(when FrameTime) {
+	p.play(&when)
+}
+
+// add adds r to the mixer, delayed with silence until when if it is not nil.
+func (ctx *Context) add(r aio.SampleReader, when *FrameTime) {
+	if when == nil {
+		ctx.mux.Add(mixerInput{ctx, r})
+		return
+	}
+
+	// the mixer is not read while ctx.mu is held, so it is at the clock plus what the context has buffered
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	pos := ctx.clock.Load() + int64(ctx.buf.Buffered()/ctx.format.NumChannels)
+	if delay := int64(*when) - pos; delay > 0 {
+		r = &delayedReader{r: r, delay: int(delay) * ctx.format.NumChannels}
+	}
+	ctx.mux.Add(mixerInput{ctx, r})
+}
+
+// delayedReader plays silence before r. Its reads are always full, so that r keeps in step with the mixer.
+type delayedReader struct {
+	r     aio.SampleReader
+	delay int // samples of silence left
+}
+
+func (d *delayedReader) ReadSamples(p []float32) (int, error) {
+	if d.delay == 0 {
+		return d.r.ReadSamples(p)
+	}
+	n := min(d.delay, len(p))
+	clear(p[:n])
+	d.delay -= n
+	if n == len(p) {
+		return n, nil
+	}
+	nn, err := d.r.ReadSamples(p[n:])
+	return n + nn, err
+}
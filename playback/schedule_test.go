@@ -0,0 +1,82 @@
+package playback_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/generator"
+	"github.com/MatusOllah/resona/playback"
+	"github.com/MatusOllah/resona/playback/driver/writer"
+)
+
+// firstNonZeroFrame returns the index of the first frame of p with a non-zero sample, or -1.
+func firstNonZeroFrame(p []float32, numChannels int) int {
+	for i, v := range p {
+		if v != 0 {
+			return i / numChannels
+		}
+	}
+	return -1
+}
+
+// newScheduleContext creates a context rendering to a buffer, with an endless silent player keeping it busy.
+func newScheduleContext(t *testing.T) (*playback.Context, *writer.Driver, *audio.Buffer) {
+	t.Helper()
+	out := audio.NewBufferSize(1024)
+	drv := writer.New(out)
+	drv.ChunkFrames = 100
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(drv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ctx.Close() })
+	ctx.NewPlayer(generator.NewConstant(0)).Play()
+	return ctx, drv, out
+}
+
+func TestContextPlayAt(t *testing.T) {
+	ctx, drv, out := newScheduleContext(t)
+
+	if err := drv.RenderFor(1000 * time.Second / 48000); err != nil {
+		t.Fatal(err)
+	}
+	now := ctx.Now()
+	if now != 1000 {
+		t.Fatalf("expected the clock at 1000 frames, got %d", now)
+	}
+
+	// a click 4800 frames from now
+	click := ctx.PlayAt(aio.LimitReader(generator.NewConstant(1), 2*10), now+4800)
+	if err := drv.RenderFor(10000 * time.Second / 48000); err != nil {
+		t.Fatal(err)
+	}
+	if got := firstNonZeroFrame(out.Float32s(), 2); got != int(now)+4800 {
+		t.Errorf("expected the click at frame %d, got %d", now+4800, got)
+	}
+	select {
+	case <-click.Done():
+	default:
+		t.Error("expected the click to have finished")
+	}
+}
+
+func TestContextPlayAtPast(t *testing.T) {
+	ctx, drv, out := newScheduleContext(t)
+
+	if err := drv.RenderFor(1000 * time.Second / 48000); err != nil {
+		t.Fatal(err)
+	}
+	now := ctx.Now()
+	ctx.PlayAt(aio.LimitReader(generator.NewConstant(1), 2*10), now-500)
+	if err := drv.RenderFor(5000 * time.Second / 48000); err != nil {
+		t.Fatal(err)
+	}
+
+	// the context had read ahead by its buffer, so the click starts right after it
+	got := firstNonZeroFrame(out.Float32s(), 2)
+	if want := int(now) + ctx.BufferSize()/2; got < int(now) || got > want {
+		t.Errorf("expected the click between frames %d and %d, got %d", now, want, got)
+	}
+}
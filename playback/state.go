@@ -0,0 +1,94 @@
+package playback
+
+import (
+	"errors"
+	"fmt"
+)
+
+// State represents the state of a [Context].
+type State int32
+
+const (
+	// StateUninitialized is the state of a Context not created by [NewContext], such as its zero value.
+	StateUninitialized State = iota
+
+	// StateRunning is the state of a Context that is playing its players.
+	StateRunning
+
+	// StateSuspended is the state of a Context that plays silence, with its players holding their positions,
+	// until it is resumed.
+	StateSuspended
+
+	// StateClosed is the state of a closed Context. A closed Context cannot be used again;
+	// to play again, create a new one with [NewContext].
+	StateClosed
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateUninitialized:
+		return "uninitialized"
+	case StateRunning:
+		return "running"
+	case StateSuspended:
+		return "suspended"
+	case StateClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("State(%d)", int32(s))
+	}
+}
+
+var (
+	// ErrNotInitialized is returned when using a [Context] not created by [NewContext].
+	ErrNotInitialized = errors.New("playback: context not initialized")
+
+	// ErrClosed is returned when using a closed [Context].
+	ErrClosed = errors.New("playback: context closed")
+)
+
+// State returns the state of the context.
+func (ctx *Context) State() State {
+	return State(ctx.state.Load())
+}
+
+// IsRunning reports whether the context is playing its players, that is, it is neither suspended nor closed.
+func (ctx *Context) IsRunning() bool {
+	return ctx.State() == StateRunning
+}
+
+// Suspend makes the context play silence without reading its players, which hold their positions until it is resumed.
+// Suspending a suspended context does nothing.
+func (ctx *Context) Suspend() error {
+	if ctx.state.CompareAndSwap(int32(StateRunning), int32(StateSuspended)) {
+		return nil
+	}
+	if err := ctx.checkOpen(); err != nil {
+		return err
+	}
+	return nil // already suspended
+}
+
+// Resume resumes a suspended context. Resuming a running context does nothing.
+func (ctx *Context) Resume() error {
+	if ctx.state.CompareAndSwap(int32(StateSuspended), int32(StateRunning)) {
+		return nil
+	}
+	if err := ctx.checkOpen(); err != nil {
+		return err
+	}
+	return nil // already running
+}
+
+// checkOpen returns an error if the context is not running or suspended.
+func (ctx *Context) checkOpen() error {
+	switch ctx.State() {
+	case StateUninitialized:
+		return ErrNotInitialized
+	case StateClosed:
+		return ErrClosed
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,150 @@
+package playback_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/playback"
+	"github.com/MatusOllah/resona/playback/driver/null"
+)
+
+// newStateContext returns a context in the given state.
+func newStateContext(t *testing.T, state playback.State) *playback.Context {
+	t.Helper()
+	if state == playback.StateUninitialized {
+		return &playback.Context{}
+	}
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(&null.Driver{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch state {
+	case playback.StateSuspended:
+		if err := ctx.Suspend(); err != nil {
+			t.Fatal(err)
+		}
+	case playback.StateClosed:
+		if err := ctx.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { ctx.Close() })
+	return ctx
+}
+
+func TestContextStates(t *testing.T) {
+	for _, tt := range []struct {
+		state playback.State
+		err   error // error of the functions that need an open context
+	}{
+		{playback.StateUninitialized, playback.ErrNotInitialized},
+		{playback.StateRunning, nil},
+		{playback.StateSuspended, nil},
+		{playback.StateClosed, playback.ErrClosed},
+	} {
+		t.Run(tt.state.String(), func(t *testing.T) {
+			ctx := newStateContext(t, tt.state)
+			if got := ctx.State(); got != tt.state {
+				t.Fatalf("expected state %v, got %v", tt.state, got)
+			}
+			if got := ctx.IsRunning(); got != (tt.state == playback.StateRunning) {
+				t.Errorf("expected IsRunning %v, got %v", tt.state == playback.StateRunning, got)
+			}
+
+			// none of these may panic
+			ctx.SetMasterVolume(0.5)
+			ctx.SetMasterVolumeDB(-6)
+			_ = ctx.MasterVolume()
+			_ = ctx.Latency()
+			_ = ctx.Stats()
+			_ = ctx.Config()
+			_ = ctx.BufferSize()
+
+			player := ctx.NewPlayer(&rampSource{numChannels: 2}, playback.WithPlayerBuffer(10*time.Millisecond))
+			player.Pause()
+			player.Resume()
+			_ = player.BufferedDuration()
+			player.Play()
+			if tt.err != nil {
+				select {
+				case err := <-player.Done():
+					if !errors.Is(err, tt.err) {
+						t.Errorf("Play: expected %v, got %v", tt.err, err)
+					}
+				case <-time.After(time.Second):
+					t.Fatal("Play: expected the player to fail")
+				}
+			}
+			if _, err := player.Seek(0, io.SeekStart); !errors.Is(err, tt.err) {
+				t.Errorf("Seek: expected %v, got %v", tt.err, err)
+			}
+			if _, err := player.Position(); !errors.Is(err, tt.err) {
+				t.Errorf("Position: expected %v, got %v", tt.err, err)
+			}
+			if _, err := player.PositionDur(); !errors.Is(err, tt.err) {
+				t.Errorf("PositionDur: expected %v, got %v", tt.err, err)
+			}
+
+			if err := ctx.Suspend(); !errors.Is(err, tt.err) {
+				t.Errorf("Suspend: expected %v, got %v", tt.err, err)
+			}
+			if err := ctx.Resume(); !errors.Is(err, tt.err) {
+				t.Errorf("Resume: expected %v, got %v", tt.err, err)
+			}
+
+			closeErr := error(nil)
+			if tt.state == playback.StateUninitialized {
+				closeErr = playback.ErrNotInitialized
+			}
+			for range 2 {
+				if err := ctx.Close(); !errors.Is(err, closeErr) {
+					t.Errorf("Close: expected %v, got %v", closeErr, err)
+				}
+			}
+		})
+	}
+}
+
+func TestContextSuspend(t *testing.T) {
+	drv := &fakeDriver{}
+	ctx, err := playback.NewContext(nullFormat, playback.WithDriverInstance(drv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	src := &rampSource{numChannels: 2}
+	ctx.NewPlayer(src).Play()
+	if err := ctx.Suspend(); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.IsRunning() {
+		t.Error("expected the context not to run while suspended")
+	}
+
+	p := make([]float32, 256)
+	if _, err := aio.ReadFull(drv.src, p); err != nil {
+		t.Fatal(err)
+	}
+	if src.pos != 0 {
+		t.Errorf("expected the player not to be read while suspended, got %d samples read", src.pos)
+	}
+	for _, v := range p {
+		if v != 0 {
+			t.Fatalf("expected silence while suspended, got %v", v)
+		}
+	}
+
+	if err := ctx.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := aio.ReadFull(drv.src, p); err != nil {
+		t.Fatal(err)
+	}
+	if src.pos == 0 {
+		t.Error("expected the player to be read after resuming")
+	}
+}
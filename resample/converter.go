@@ -0,0 +1,331 @@
+package resample
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/dsp/filter"
+	"github.com/MatusOllah/resona/dsp/window"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// kernel is a tabulated symmetric kernel, stored from its center outwards.
+type kernel struct {
+	table     []float64 // phases entries per unit, followed by a zero
+	phases    int       // table entries per input frame (of the lower rate)
+	zeros     int       // half width in frames (of the lower rate)
+	filtering bool      // whether the kernel is widened by the ratio when downsampling
+}
+
+// at returns the kernel at the distance x from its center, in frames of the lower rate.
+func (k *kernel) at(x float64) float64 {
+	x = math.Abs(x) * float64(k.phases)
+	i := int(x)
+	if i >= len(k.table)-1 {
+		return 0
+	}
+	f := x - float64(i)
+	return k.table[i] + f*(k.table[i+1]-k.table[i])
+}
+
+// designKernel designs a windowed-sinc kernel keeping the given fraction of the passband
+// and attenuating the stopband by attenuationDB, tabulated with the given number of phases.
+func designKernel(passband, attenuationDB float64, phases int) *kernel {
+	// Kaiser's formula for the length of a filter with a transition band from the passband edge to the Nyquist frequency,
+	// in cycles per frame
+	transition := (1 - passband) / 2
+	taps := (attenuationDB - 7.95) / (14.36 * transition)
+	zeros := int(math.Ceil(taps / 2))
+
+	// the cutoff lies in the middle of the transition band
+	cutoff := (1 + passband) / 4
+	coeffs := filter.DesignLowpass(
+		2*zeros*phases+1,
+		freq.Frequency(cutoff*float64(freq.Hertz)), freq.Frequency(phases)*freq.Hertz,
+		window.Kaiser(window.KaiserForAttenuation(attenuationDB)),
+	)
+
+	// DesignLowpass normalizes the whole table, but every phase should have unity gain at DC
+	table := make([]float64, zeros*phases+2)
+	for i := range zeros*phases + 1 {
+		table[i] = coeffs[zeros*phases+i] * float64(phases)
+	}
+	return &kernel{table: table, phases: phases, zeros: zeros, filtering: true}
+}
+
+// kernels holds the kernels of the quality tiers, which are designed on first use.
+var kernels = [...]func() *kernel{
+	QualityLinear: func() *kernel {
+		// a triangle
+		return &kernel{table: []float64{1, 0, 0}, phases: 1, zeros: 1}
+	},
+	QualityLow:    sync.OnceValue(func() *kernel { return designKernel(0.80, 60, 128) }),
+	QualityMedium: sync.OnceValue(func() *kernel { return designKernel(0.90, 90, 512) }),
+	QualityHigh:   sync.OnceValue(func() *kernel { return designKernel(0.95, 120, 2048) }),
+}
+
+// ratioDen is the denominator of the fraction the step between output frames is rounded to
+// when the ratio is not given by two sample rates.
+const ratioDen = 1 << 32
+
+// maxBank is the largest number of weights precomputed for all phases of a ratio.
+const maxBank = 1 << 18
+
+// readFrames is the number of frames read from the underlying reader at once.
+const readFrames = 1024
+
+// Converter is the pure-Go [Resampler], registered as the "go" backend.
+// A Converter evaluates a band-limited (windowed-sinc) kernel at the exact position of every output frame,
+// so it converts between any two sample rates, including ratios that are not rational.
+// The kernels are designed with a Kaiser window and tabulated once per [Quality];
+// values between the table entries are linearly interpolated.
+//
+// The output starts at the same instant as the input and has no delay; the frames the kernel needs from before the start
+// are silence. Once the input ends, the output continues until it covers the whole input, and then ends with io.EOF.
+// Converting n input frames by the ratio r produces ceil(n*r) output frames.
+type Converter struct {
+	r           aio.SampleReader
+	numChannels int
+	ratio       float64 // output rate / input rate
+	step, den   int64   // input frames per output frame, as a fraction step/den
+	k           *kernel
+	scale       float64 // factor from input frames to frames of the lower rate
+	halfWidth   int     // half width of the kernel in input frames
+
+	buf   []float32 // interleaved input frames, the first one at start
+	start int       // absolute index of the first frame in buf
+	pos   int       // absolute index of the input frame at or before the next output frame
+	frac  int64     // position of the next output frame between pos and pos+1, in units of 1/den
+	eof   bool
+	end   int // absolute index of the frame after the last input frame, once eof is set
+
+	weights []float64
+	bank    []float64 // the weights of every phase, for ratios with a small denominator
+}
+
+// NewConverter creates a new [Converter] that converts the samples of r from inRate to outRate.
+// r must produce interleaved samples with the given number of channels.
+func NewConverter(r aio.SampleReader, inRate, outRate freq.Frequency, channels int, quality Quality) (*Converter, error) {
+	if inRate <= 0 {
+		return nil, errors.New("resample: invalid input sample rate")
+	}
+	if outRate <= 0 {
+		return nil, errors.New("resample: invalid output sample rate")
+	}
+
+	res, err := NewConverterWithRatio(r, outRate.Hertz()/inRate.Hertz(), channels, quality)
+	if err != nil {
+		return nil, err
+	}
+	// step exactly between the two rates, so that the phase never drifts
+	d := gcd(int64(inRate), int64(outRate))
+	res.step, res.den = int64(inRate)/d, int64(outRate)/d
+
+	// common ratios such as 160/147 have few phases, whose weights are cheaper to compute once
+	if n := res.den * int64(len(res.weights)); n <= maxBank {
+		res.bank = make([]float64, n)
+		for frac := range res.den {
+			res.computeWeights(res.bank[frac*int64(len(res.weights)):][:len(res.weights)], frac)
+		}
+	}
+	return res, nil
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// NewConverterWithRatio creates a new [Converter] that converts the samples of r by ratio, which is the output sample rate
+// divided by the input sample rate. Unlike [NewConverter], the ratio can be any number from 2^-24 to 2^24, rational or not;
+// the position of the output frames is kept to a precision of 2^-32 input frames.
+func NewConverterWithRatio(r aio.SampleReader, ratio float64, channels int, quality Quality) (*Converter, error) {
+	if r == nil {
+		return nil, errors.New("resample: reader is nil")
+	}
+	if !validRatio(ratio) {
+		return nil, fmt.Errorf("resample: invalid ratio: %v", ratio)
+	}
+	if channels <= 0 {
+		return nil, fmt.Errorf("resample: invalid number of channels: %d", channels)
+	}
+	if quality < 0 || int(quality) >= len(kernels) {
+		return nil, fmt.Errorf("resample: invalid quality: %v", quality)
+	}
+
+	res := &Converter{
+		r:           r,
+		numChannels: channels,
+		k:           kernels[quality](),
+	}
+	res.setRatio(ratio)
+	return res, nil
+}
+
+func validRatio(ratio float64) bool {
+	return ratio >= 1.0/(1<<24) && ratio <= 1<<24
+}
+
+// setRatio changes the ratio, keeping the position of the next output frame.
+func (r *Converter) setRatio(ratio float64) {
+	scale := 1.0
+	if r.k.filtering {
+		// when downsampling, the kernel is widened to filter at the lower rate
+		scale = min(ratio, 1)
+	}
+	halfWidth := int(math.Ceil(float64(r.k.zeros) / scale))
+
+	if r.den != 0 {
+		r.frac = int64(float64(r.frac) / float64(r.den) * ratioDen)
+	}
+	r.ratio = ratio
+	r.step, r.den = int64(math.Round(ratioDen/ratio)), ratioDen
+	r.scale, r.halfWidth = scale, halfWidth
+	r.weights = make([]float64, 2*halfWidth)
+	r.bank = nil
+
+	// the kernel may reach further back now; before the start, that is silence
+	if first := r.pos - halfWidth + 1; first < r.start {
+		r.buf = append(make([]float32, (r.start-first)*r.numChannels), r.buf...)
+		r.start = first
+	}
+}
+
+// SetRatio changes the output sample rate divided by the input sample rate, starting from the next output frame.
+func (r *Converter) SetRatio(ratio float64) error {
+	if !validRatio(ratio) {
+		return fmt.Errorf("resample: invalid ratio: %v", ratio)
+	}
+	r.setRatio(ratio)
+	return nil
+}
+
+// Ratio returns the output sample rate divided by the input sample rate.
+func (r *Converter) Ratio() float64 {
+	return r.ratio
+}
+
+// Reset clears the internal state of the converter, such as to reuse it for a new stream after the underlying reader
+// has been seeked. Samples already read but not converted are dropped, and the output restarts as if the next input frame
+// were the first one. The ratio is kept.
+func (r *Converter) Reset() {
+	r.pos, r.frac = 0, 0
+	r.eof, r.end = false, 0
+
+	// the frames the kernel needs from before the start are silence
+	r.start = min(1-r.halfWidth, 0)
+	r.buf = append(r.buf[:0], make([]float32, -r.start*r.numChannels)...)
+}
+
+// Delay returns the number of output frames the converter currently holds back, that is, the input frames read
+// from the underlying reader but not converted yet, at the output sample rate.
+func (r *Converter) Delay() float64 {
+	end := r.bufEnd()
+	if r.eof {
+		end = r.end
+	}
+	frames := float64(end-r.pos) - float64(r.frac)/float64(r.den)
+	return max(frames, 0) * r.ratio
+}
+
+// Close does nothing, as a Converter holds no resources besides memory. It always returns nil.
+func (r *Converter) Close() error {
+	return nil
+}
+
+// bufEnd returns the absolute index of the frame after the last frame in the buffer.
+func (r *Converter) bufEnd() int {
+	return r.start + len(r.buf)/r.numChannels
+}
+
+// fill makes sure that the buffer holds the input frames up to and including last,
+// reading from the underlying reader or appending silence after its end.
+func (r *Converter) fill(last int) error {
+	// drop the frames the kernel no longer needs, keeping some in case SetRatio widens it
+	if drop := r.pos - 2*r.halfWidth + 1 - r.start; drop > 0 && drop*r.numChannels >= len(r.buf)/2 {
+		drop = min(drop, len(r.buf)/r.numChannels)
+		r.buf = append(r.buf[:0], r.buf[drop*r.numChannels:]...)
+		r.start += drop
+	}
+
+	for r.bufEnd() <= last {
+		n := max(readFrames, last-r.bufEnd()+1) * r.numChannels
+		old := len(r.buf)
+		r.buf = append(r.buf, make([]float32, n)...)
+		if r.eof {
+			continue
+		}
+
+		m, err := aio.ReadFull(r.r, r.buf[old:])
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			// a partial frame at the end is dropped
+			m -= m % r.numChannels
+			r.eof = true
+			r.end = r.start + (old+m)/r.numChannels
+			clear(r.buf[old+m:])
+		} else if err != nil {
+			r.buf = r.buf[:old]
+			return fmt.Errorf("resample: failed to read samples: %w", err)
+		}
+	}
+	return nil
+}
+
+// computeWeights computes the weights of the input frames from pos-halfWidth+1 to pos+halfWidth
+// for an output frame frac/den frames after pos.
+func (r *Converter) computeWeights(weights []float64, frac int64) {
+	t := float64(frac)/float64(r.den) + float64(r.halfWidth) - 1
+	for j := range weights {
+		weights[j] = r.scale * r.k.at((t-float64(j))*r.scale)
+	}
+}
+
+// ReadSamples reads samples from the underlying reader, resamples them, and writes the output into p.
+// It returns the number of samples written and/or an error. Only whole frames are written.
+func (r *Converter) ReadSamples(p []float32) (int, error) {
+	numChannels := r.numChannels
+	n := 0
+	for ; n+numChannels <= len(p); n += numChannels {
+		if err := r.fill(r.pos + r.halfWidth); err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if r.eof && r.pos >= r.end {
+			break
+		}
+
+		weights := r.weights
+		if r.bank != nil {
+			weights = r.bank[r.frac*int64(len(weights)):][:len(weights)]
+		} else {
+			r.computeWeights(weights, r.frac)
+		}
+
+		first := r.pos - r.halfWidth + 1
+		frames := r.buf[(first-r.start)*numChannels:][:len(weights)*numChannels]
+		for ch := range numChannels {
+			var sum float64
+			for j, w := range weights {
+				sum += w * float64(frames[j*numChannels+ch])
+			}
+			p[n+ch] = float32(sum)
+		}
+
+		r.frac += r.step
+		r.pos += int(r.frac / r.den)
+		r.frac %= r.den
+	}
+
+	if n == 0 && r.eof && r.pos >= r.end {
+		return 0, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,261 @@
+package resample_test
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/dsp"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/resample"
+)
+
+var tiers = []struct {
+	quality       resample.Quality
+	passband      float64 // fraction of the Nyquist frequency of the lower rate
+	attenuationDB float64
+}{
+	{resample.QualityLow, 0.80, 60},
+	{resample.QualityMedium, 0.90, 90},
+	{resample.QualityHigh, 0.95, 120},
+}
+
+// sweep returns a linear sine sweep from f0 to f1 Hz at the sample rate fs, faded in and out over 10% of its length.
+func sweep(f0, f1, fs float64, numFrames int) []float32 {
+	p := make([]float32, numFrames)
+	fade := numFrames / 10
+	for i := range p {
+		t := float64(i) / fs
+		d := float64(numFrames) / fs
+		x := math.Sin(2 * math.Pi * (f0*t + (f1-f0)*t*t/(2*d)))
+		if i < fade {
+			x *= 0.5 - 0.5*math.Cos(math.Pi*float64(i)/float64(fade))
+		} else if j := numFrames - 1 - i; j < fade {
+			x *= 0.5 - 0.5*math.Cos(math.Pi*float64(j)/float64(fade))
+		}
+		p[i] = float32(x)
+	}
+	return p
+}
+
+func resampleAll(t testing.TB, p []float32, inRate, outRate freq.Frequency, channels int, q resample.Quality) []float32 {
+	t.Helper()
+	r, err := resample.NewConverter(audio.NewReader(p), inRate, outRate, channels, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := aio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func energyDB(p []float32) float64 {
+	var sum float64
+	for _, x := range p {
+		sum += float64(x) * float64(x)
+	}
+	return dsp.PowerToDB(sum)
+}
+
+func TestConverterStopband(t *testing.T) {
+	// everything above 22.05 kHz would alias when converting 48 kHz to 44.1 kHz
+	in := sweep(22100, 23900, 48000, 48000)
+	for _, tt := range tiers {
+		t.Run(tt.quality.String(), func(t *testing.T) {
+			out := resampleAll(t, in, 48*freq.KiloHertz, 44100*freq.Hertz, 1, tt.quality)
+			// compare energies per second, as the output has fewer frames
+			got := energyDB(out) - energyDB(in) + dsp.PowerToDB(48000.0/44100)
+			if got > -tt.attenuationDB {
+				t.Errorf("expected the stopband to be attenuated by %v dB, got %.1f dB", tt.attenuationDB, -got)
+			}
+		})
+	}
+}
+
+func TestConverterPassband(t *testing.T) {
+	for _, tt := range tiers {
+		t.Run(tt.quality.String(), func(t *testing.T) {
+			in := sweep(20, 0.99*tt.passband*22050, 48000, 48000)
+			out := resampleAll(t, in, 48*freq.KiloHertz, 44100*freq.Hertz, 1, tt.quality)
+			got := energyDB(out) - energyDB(in) + dsp.PowerToDB(48000.0/44100)
+			if math.Abs(got) > 0.05 {
+				t.Errorf("expected the passband to be kept, got %.3f dB", got)
+			}
+		})
+	}
+}
+
+func TestConverterImages(t *testing.T) {
+	// converting 44.1 kHz to 48 kHz, the image of a 16 kHz tone at 28.1 kHz aliases to 19.9 kHz
+	in := make([]float32, 44100)
+	for i := range in {
+		in[i] = float32(math.Sin(2 * math.Pi * 16000 * float64(i) / 44100))
+	}
+	for _, tt := range tiers {
+		t.Run(tt.quality.String(), func(t *testing.T) {
+			out := resampleAll(t, in, 44100*freq.Hertz, 48*freq.KiloHertz, 1, tt.quality)
+			x := make([]float64, 24000)
+			for i := range x {
+				// a Hann window against the leakage of the tone
+				w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(len(x)))
+				x[i] = w * float64(out[12000+i])
+			}
+			tone := dsp.Goertzel(x, 48*freq.KiloHertz, 16*freq.KiloHertz)
+			image := dsp.Goertzel(x, 48*freq.KiloHertz, 19900*freq.Hertz)
+			if got := dsp.PowerToDB(image / tone); got > -tt.attenuationDB {
+				t.Errorf("expected the image to be attenuated by %v dB, got %.1f dB", tt.attenuationDB, -got)
+			}
+		})
+	}
+}
+
+func TestConverterLength(t *testing.T) {
+	tests := []struct {
+		inRate, outRate freq.Frequency
+		numFrames       int
+		want            int
+	}{
+		{44100 * freq.Hertz, 48 * freq.KiloHertz, 44100, 48000},
+		{48 * freq.KiloHertz, 44100 * freq.Hertz, 48000, 44100},
+		{8 * freq.KiloHertz, 48 * freq.KiloHertz, 1001, 6006},
+		{48 * freq.KiloHertz, 8 * freq.KiloHertz, 1001, 167},
+		{48 * freq.KiloHertz, 48 * freq.KiloHertz, 500, 500},
+	}
+	for _, tt := range tests {
+		for q := range resample.QualityHigh + 1 {
+			t.Run(fmt.Sprintf("%v/%v/%v", tt.inRate, tt.outRate, q), func(t *testing.T) {
+				out := resampleAll(t, make([]float32, 2*tt.numFrames), tt.inRate, tt.outRate, 2, q)
+				if got := len(out) / 2; got != tt.want {
+					t.Errorf("expected %d frames, got %d", tt.want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestConverterIrrational(t *testing.T) {
+	in := make([]float32, 10000)
+	for i := range in {
+		in[i] = float32(math.Sin(2 * math.Pi * float64(i) / 100))
+	}
+	r, err := resample.NewConverterWithRatio(audio.NewReader(in), math.Sqrt2, 1, resample.QualityMedium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := aio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int(math.Ceil(10000 * math.Sqrt2)); len(out) != want {
+		t.Fatalf("expected %d frames, got %d", want, len(out))
+	}
+
+	// the period of the sine stretches to 100√2 frames
+	for i := 1000; i < len(out)-1000; i += 97 {
+		want := math.Sin(2 * math.Pi * float64(i) / (100 * math.Sqrt2))
+		if diff := math.Abs(float64(out[i]) - want); diff > 1e-4 {
+			t.Fatalf("frame %d: expected %v, got %v", i, want, out[i])
+		}
+	}
+}
+
+func TestConverterChannels(t *testing.T) {
+	left := sweep(100, 20000, 48000, 4800)
+	right := make([]float32, len(left))
+	stereo := make([]float32, 2*len(left))
+	for i := range left {
+		right[i] = 0.5 * float32(math.Sin(float64(i)/10))
+		stereo[2*i], stereo[2*i+1] = left[i], right[i]
+	}
+
+	for q := range resample.QualityHigh + 1 {
+		out := resampleAll(t, stereo, 48*freq.KiloHertz, 44100*freq.Hertz, 2, q)
+		outLeft := resampleAll(t, left, 48*freq.KiloHertz, 44100*freq.Hertz, 1, q)
+		outRight := resampleAll(t, right, 48*freq.KiloHertz, 44100*freq.Hertz, 1, q)
+		for i := range outLeft {
+			if out[2*i] != outLeft[i] || out[2*i+1] != outRight[i] {
+				t.Fatalf("%v: frame %d: expected %v and %v, got %v and %v", q, i, outLeft[i], outRight[i], out[2*i], out[2*i+1])
+			}
+		}
+	}
+}
+
+func TestConverterReset(t *testing.T) {
+	in := sweep(100, 10000, 24000, 5000)
+	want := resampleAll(t, in[2000:], 24*freq.KiloHertz, 48*freq.KiloHertz, 1, resample.QualityMedium)
+
+	src := audio.NewReader(in)
+	r, err := resample.NewConverter(src, 24*freq.KiloHertz, 48*freq.KiloHertz, 1, resample.QualityMedium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := r.Delay(); d != 0 {
+		t.Errorf("expected no delay before reading, got %v", d)
+	}
+
+	// the delay is what was read ahead of the output, at the output rate
+	p := make([]float32, 100)
+	if _, err := aio.ReadFull(r, p); err != nil {
+		t.Fatal(err)
+	}
+	read, _ := src.Seek(0, io.SeekCurrent)
+	if got, want := r.Delay(), float64(read)*2-100; got != want {
+		t.Errorf("expected a delay of %v frames, got %v", want, got)
+	}
+
+	// after reading to the end, seeking back resumes from the new position
+	if _, err := aio.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+	if d := r.Delay(); d != 0 {
+		t.Errorf("expected no delay at the end, got %v", d)
+	}
+	if _, err := src.Seek(2000, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	r.Reset()
+	got, err := aio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("output after Reset does not match a fresh conversion (%d and %d frames)", len(got), len(want))
+	}
+}
+
+func TestConverterInvalid(t *testing.T) {
+	r := audio.NewReader(nil)
+	if _, err := resample.NewConverter(r, 0, 48*freq.KiloHertz, 1, resample.QualityLow); err == nil {
+		t.Error("expected error for invalid input sample rate")
+	}
+	if _, err := resample.NewConverter(r, 48*freq.KiloHertz, 48*freq.KiloHertz, 0, resample.QualityLow); err == nil {
+		t.Error("expected error for invalid number of channels")
+	}
+	if _, err := resample.NewConverterWithRatio(r, math.NaN(), 1, resample.QualityLow); err == nil {
+		t.Error("expected error for invalid ratio")
+	}
+	if _, err := resample.NewConverter(r, 48*freq.KiloHertz, 48*freq.KiloHertz, 1, resample.QualityHigh+1); err == nil {
+		t.Error("expected error for invalid quality")
+	}
+}
+
+func BenchmarkConverter(b *testing.B) {
+	in := make([]float32, 2*48000)
+	for i := range in {
+		in[i] = float32(math.Sin(float64(i) / 10))
+	}
+	for q := range resample.QualityHigh + 1 {
+		b.Run(q.String(), func(b *testing.B) {
+			b.SetBytes(int64(4 * len(in)))
+			for b.Loop() {
+				resampleAll(b, in, 48*freq.KiloHertz, 44100*freq.Hertz, 2, q)
+			}
+		})
+	}
+}
@@ -16,8 +16,10 @@ import (
 	"io"
 	"math"
 	"runtime"
+	"slices"
 	"unsafe"
 
+	"github.com/MatusOllah/resona/afmt"
 	"github.com/MatusOllah/resona/aio"
 	"github.com/MatusOllah/resona/freq"
 )
@@ -39,12 +41,18 @@ func Version() string {
 }
 
 // Resampler represents a libsamplerate instance.
+//
+// Samples stay float32 end to end; they are copied into and out of C memory, which libsamplerate works on, unchanged.
 type Resampler struct {
 	srcState *C.SRC_STATE
 	srcData  C.SRC_DATA
 	r        aio.SampleReader
 	channels int
 	ratio    float64
+	inRate   freq.Frequency // zero if unknown
+
+	pending []float32 // samples read from r that libsamplerate has not used yet
+	eof     bool      // r has ended, so libsamplerate is draining its filter
 
 	// C-allocated buffers to make "cgo argument has Go pointer to unpinned Go pointer" happy
 	inBuf     *C.float // data_in
@@ -53,8 +61,11 @@ type Resampler struct {
 	outBufCap int      // samples
 }
 
-// New creates a new [Resampler] with fixed input and output sample rates.
-func New(r aio.SampleReader, inRate, outRate freq.Frequency, channels int, quality int) (*Resampler, error) {
+func newResampler(r aio.SampleReader, srcRatio float64, channels int, quality int) (*Resampler, error) {
+	if channels <= 0 {
+		return nil, errors.New("libsamplerate: invalid channel count")
+	}
+
 	var err C.int
 	srcState := C.src_new(C.int(quality), C.int(channels), &err)
 	if err != 0 {
@@ -62,7 +73,7 @@ func New(r aio.SampleReader, inRate, outRate freq.Frequency, channels int, quali
 	}
 
 	var srcData C.SRC_DATA
-	srcData.src_ratio = C.double(outRate.Hertz() / inRate.Hertz())
+	srcData.src_ratio = C.double(srcRatio)
 	srcData.end_of_input = C.int(0)
 
 	resampler := &Resampler{
@@ -70,7 +81,6 @@ func New(r aio.SampleReader, inRate, outRate freq.Frequency, channels int, quali
 		srcData:  srcData,
 		r:        r,
 		channels: channels,
-		ratio:    float64(srcData.src_ratio),
 	}
 
 	runtime.SetFinalizer(resampler, (*Resampler).Close)
@@ -78,28 +88,28 @@ func New(r aio.SampleReader, inRate, outRate freq.Frequency, channels int, quali
 	return resampler, nil
 }
 
+// New creates a new [Resampler] with fixed input and output sample rates.
+func New(r aio.SampleReader, inRate, outRate freq.Frequency, channels int, quality int) (*Resampler, error) {
+	resampler, err := newResampler(r, outRate.Hertz()/inRate.Hertz(), channels, quality)
+	if err != nil {
+		return nil, err
+	}
+	resampler.ratio = outRate.Hertz() / inRate.Hertz()
+	resampler.inRate = inRate
+	return resampler, nil
+}
+
 // NewWithRatio creates a new [Resampler] with a resampling ratio.
+// If r implements [afmt.Formatter], its sample rate is used to report the output format.
 func NewWithRatio(r aio.SampleReader, ratio float64, channels int, quality int) (*Resampler, error) {
-	var err C.int
-	srcState := C.src_new(C.int(quality), C.int(channels), &err)
-	if err != 0 {
-		return nil, fmt.Errorf("libsamplerate: %s", C.GoString(C.src_strerror(err)))
+	resampler, err := newResampler(r, 1.0/ratio, channels, quality)
+	if err != nil {
+		return nil, err
 	}
-
-	var srcData C.SRC_DATA
-	srcData.src_ratio = C.double(1.0 / ratio)
-	srcData.end_of_input = C.int(0)
-
-	resampler := &Resampler{
-		srcState: srcState,
-		srcData:  srcData,
-		r:        r,
-		channels: channels,
-		ratio:    ratio,
+	resampler.ratio = ratio
+	if f, ok := r.(afmt.Formatter); ok {
+		resampler.inRate = f.Format().SampleRate
 	}
-
-	runtime.SetFinalizer(resampler, (*Resampler).Close)
-
 	return resampler, nil
 }
 
@@ -127,6 +137,21 @@ func (r *Resampler) Close() {
 	}
 }
 
+// Reset clears the internal state of the resampler, such as to reuse it for a new stream
+// after the underlying reader has been seeked or replaced. Samples already read but not resampled are dropped.
+func (r *Resampler) Reset() error {
+	if r.srcState == nil {
+		return errors.New("libsamplerate: resampler closed")
+	}
+	if err := C.src_reset(r.srcState); err != 0 {
+		return fmt.Errorf("libsamplerate: %s", C.GoString(C.src_strerror(err)))
+	}
+	r.pending = r.pending[:0]
+	r.eof = false
+	r.srcData.end_of_input = C.int(0)
+	return nil
+}
+
 // SetRatio updates the resampling ratio.
 func (r *Resampler) SetRatio(ratio float64) error {
 	if r.srcState == nil {
@@ -146,6 +171,15 @@ func (r *Resampler) Ratio() float64 {
 	return r.ratio
 }
 
+// Format returns the format of the output: the output sample rate and the number of channels.
+// The sample rate is zero if the input sample rate is unknown.
+func (r *Resampler) Format() afmt.Format {
+	return afmt.Format{
+		SampleRate:  freq.Frequency(math.Round(float64(r.inRate) * float64(r.srcData.src_ratio))),
+		NumChannels: r.channels,
+	}
+}
+
 // ensureCFloatCap ensures a C float buffer has at least n samples capacity.
 func ensureCFloatCap(p **C.float, capPtr *int, n int) {
 	if *capPtr >= n {
@@ -160,88 +194,81 @@ func ensureCFloatCap(p **C.float, capPtr *int, n int) {
 	*capPtr = n
 }
 
+// cFloats returns the first n samples of a C float buffer as a Go slice.
+func cFloats(p *C.float, n int) []float32 {
+	return unsafe.Slice((*float32)(unsafe.Pointer(p)), n)
+}
+
 // ReadSamples reads samples from the underlying reader, resamples them,
 // and writes the output into p. It returns the number of samples written.
+//
+// Once the underlying reader ends, ReadSamples returns the output still queued in the filter of the resampler
+// before returning io.EOF, so that the end of the stream is not cut off.
 func (r *Resampler) ReadSamples(p []float32) (int, error) {
 	if r.srcState == nil {
 		return 0, errors.New("libsamplerate: resampler closed")
 	}
+	// we'll only fill whole frames; trim the last partial frame.
+	p = p[:len(p)-len(p)%r.channels]
 	if len(p) == 0 {
 		return 0, nil
 	}
-	if r.channels <= 0 {
-		return 0, errors.New("libsamplerate: invalid channel count")
-	}
-	if len(p)%r.channels != 0 {
-		// we'll only fill whole frames; trim the last partial frame.
-		p = p[:len(p)-len(p)%r.channels]
-		if len(p) == 0 {
-			return 0, nil
-		}
-	}
 
 	ratio := float64(r.srcData.src_ratio)
 	if ratio <= 0 || math.IsNaN(ratio) || math.IsInf(ratio, 0) {
 		return 0, errors.New("libsamplerate: invalid resampling ratio")
 	}
-
 	outFramesReq := len(p) / r.channels
-	if outFramesReq == 0 {
-		return 0, nil
-	}
-
-	// calculate input frames needed (~= outFramesReq/ratio) and add small headroom
-	inFramesNeed := int(math.Ceil(float64(outFramesReq)/ratio)) + 16
-	inSamplesNeed := inFramesNeed * r.channels
 
-	in := make([]float32, inSamplesNeed)
-
-	nInSamples, err := r.r.ReadSamples(in)
-	if err != nil && !errors.Is(err, io.EOF) {
-		return 0, fmt.Errorf("libsamplerate: failed to read samples: %w", err)
+	if !r.eof {
+		// calculate input frames needed (~= outFramesReq/ratio) and add small headroom
+		inSamplesNeed := (int(math.Ceil(float64(outFramesReq)/ratio)) + 16) * r.channels
+		if n := inSamplesNeed - len(r.pending); n > 0 {
+			old := len(r.pending)
+			r.pending = slices.Grow(r.pending, n)[:old+n]
+			m, err := r.r.ReadSamples(r.pending[old:])
+			r.pending = r.pending[:old+m]
+			if errors.Is(err, io.EOF) {
+				r.eof = true
+				// a partial frame at the end is dropped
+				r.pending = r.pending[:len(r.pending)-len(r.pending)%r.channels]
+			} else if err != nil {
+				return 0, fmt.Errorf("libsamplerate: failed to read samples: %w", err)
+			}
+		}
 	}
-	// keep only whole frames
-	nInSamples -= nInSamples % r.channels
-	if nInSamples == 0 {
-		r.srcData.end_of_input = C.int(1)
-		return 0, io.EOF
+	inFrames := len(r.pending) / r.channels
+	if inFrames == 0 && !r.eof {
+		return 0, nil
 	}
-	in = in[:nInSamples]
-	inFrames := nInSamples / r.channels
-
-	// ensure C buffers
-	ensureCFloatCap(&r.inBuf, &r.inBufCap, nInSamples)
-	ensureCFloatCap(&r.outBuf, &r.outBufCap, outFramesReq*r.channels)
 
-	// view C memory as Go slices (backed by C heap).
-	inC := unsafe.Slice(r.inBuf, nInSamples)
-	outC := unsafe.Slice(r.outBuf, outFramesReq*r.channels)
-
-	// convert float32 -> C.float (float32).
-	for i := 0; i < nInSamples; i++ {
-		inC[i] = C.float(in[i])
-	}
+	// copy the samples to C memory, which libsamplerate reads and writes
+	ensureCFloatCap(&r.inBuf, &r.inBufCap, max(inFrames*r.channels, 1))
+	ensureCFloatCap(&r.outBuf, &r.outBufCap, len(p))
+	copy(cFloats(r.inBuf, inFrames*r.channels), r.pending[:inFrames*r.channels])
 
-	// Set up SRC_DATA
 	r.srcData.data_in = r.inBuf
 	r.srcData.data_out = r.outBuf
 	r.srcData.input_frames = C.long(inFrames)
 	r.srcData.output_frames = C.long(outFramesReq)
+	r.srcData.end_of_input = C.int(0)
+	if r.eof {
+		r.srcData.end_of_input = C.int(1)
+	}
 
 	cErr := C.src_process(r.srcState, &r.srcData)
 	if cErr != 0 {
 		return 0, fmt.Errorf("libsamplerate: %s", C.GoString(C.src_strerror(cErr)))
 	}
 
-	// collect output
-	nOutFrames := int(r.srcData.output_frames_gen)
-	nOutSamples := nOutFrames * r.channels
-	for i := 0; i < nOutSamples; i++ {
-		p[i] = float32(outC[i])
-	}
-
-	// reset end_of_input if we had more data earlier
-	r.srcData.end_of_input = C.int(0)
+	// keep the input libsamplerate has not used for the next call
+	used := int(r.srcData.input_frames_used) * r.channels
+	r.pending = append(r.pending[:0], r.pending[used:]...)
 
+	nOutSamples := int(r.srcData.output_frames_gen) * r.channels
+	copy(p, cFloats(r.outBuf, nOutSamples))
+	if nOutSamples == 0 && r.eof && len(r.pending) == 0 {
+		return 0, io.EOF
+	}
 	return nOutSamples, nil
 }
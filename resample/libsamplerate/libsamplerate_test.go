@@ -0,0 +1,186 @@
+package libsamplerate_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/generator"
+	"github.com/MatusOllah/resona/resample/libsamplerate"
+	"github.com/MatusOllah/resona/resample/soxr"
+)
+
+// sine returns numFrames frames of a mono 1 kHz sine at 44.1 kHz.
+func sine(numFrames int) []float32 {
+	p := make([]float32, numFrames)
+	for i := range p {
+		p[i] = float32(0.5 * math.Sin(2*math.Pi*1000*float64(i)/44100))
+	}
+	return p
+}
+
+func readResampled(t *testing.T, r aio.SampleReader) []float32 {
+	t.Helper()
+	out, err := aio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// roundTrip converts p from 44.1 kHz to 48 kHz and back with libsamplerate or soxr.
+func roundTrip(t *testing.T, p []float32, useSoxr bool) []float32 {
+	t.Helper()
+	for _, rates := range [][2]freq.Frequency{{44100 * freq.Hertz, 48 * freq.KiloHertz}, {48 * freq.KiloHertz, 44100 * freq.Hertz}} {
+		if useSoxr {
+			r, err := soxr.New(audio.NewReader(p), rates[0], rates[1], 1, soxr.QualityHigh)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p = readResampled(t, r)
+			r.Close()
+		} else {
+			r, err := libsamplerate.New(audio.NewReader(p), rates[0], rates[1], 1, libsamplerate.QualitySincBest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p = readResampled(t, r)
+			r.Close()
+		}
+	}
+	return p
+}
+
+func TestResamplerLength(t *testing.T) {
+	r, err := libsamplerate.New(audio.NewReader(sine(44100)), 44100*freq.Hertz, 48*freq.KiloHertz, 1, libsamplerate.QualitySincBest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if got := len(readResampled(t, r)); math.Abs(float64(got-48000)) > 1 {
+		t.Errorf("expected 48000 frames, got %d", got)
+	}
+}
+
+func TestResamplerRoundTrip(t *testing.T) {
+	in := sine(44100)
+	lsr := roundTrip(t, in, false)
+	sox := roundTrip(t, in, true)
+	if len(lsr) < 44000 || len(sox) < 44000 {
+		t.Fatalf("expected about 44100 frames, got %d with libsamplerate and %d with soxr", len(lsr), len(sox))
+	}
+
+	// both libraries are linear-phase and keep a 1 kHz sine intact, apart from the edges
+	for i := 1000; i < 43000; i++ {
+		if diff := math.Abs(float64(lsr[i] - in[i])); diff > 1e-3 {
+			t.Fatalf("libsamplerate: frame %d: expected %v, got %v", i, in[i], lsr[i])
+		}
+		if diff := math.Abs(float64(lsr[i] - sox[i])); diff > 1e-3 {
+			t.Fatalf("frame %d: libsamplerate returned %v, soxr %v", i, lsr[i], sox[i])
+		}
+	}
+}
+
+// switchReader reads from r, which can be replaced.
+type switchReader struct {
+	r aio.SampleReader
+}
+
+func (s *switchReader) ReadSamples(p []float32) (int, error) {
+	return s.r.ReadSamples(p)
+}
+
+func TestResamplerReset(t *testing.T) {
+	src := &switchReader{r: audio.NewReader(sine(44100))}
+	r, err := libsamplerate.New(src, 44100*freq.Hertz, 48*freq.KiloHertz, 1, libsamplerate.QualitySincBest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if _, err := aio.ReadFull(r, make([]float32, 4800)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new stream of silence must come out silent, without the sine still in the filter
+	src.r = audio.NewReader(make([]float32, 4410))
+	if err := r.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	out := readResampled(t, r)
+	if len(out) == 0 {
+		t.Fatal("expected output after Reset")
+	}
+	for i, x := range out {
+		if x != 0 {
+			t.Fatalf("frame %d: expected silence after Reset, got %v", i, x)
+		}
+	}
+}
+
+func TestResamplerFormat(t *testing.T) {
+	r, err := libsamplerate.New(audio.NewReader(nil), 44100*freq.Hertz, 48*freq.KiloHertz, 2, libsamplerate.QualityLinear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if got, want := r.Format(), (afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}); got != want {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// BenchmarkResampler matches the benchmark of the pure-Go resampler in the resample package.
+func BenchmarkResampler(b *testing.B) {
+	in := make([]float32, 2*48000)
+	for i := range in {
+		in[i] = float32(math.Sin(float64(i) / 10))
+	}
+	for _, q := range []struct {
+		name    string
+		quality int
+	}{
+		{"linear", libsamplerate.QualityLinear},
+		{"fastest", libsamplerate.QualitySincFastest},
+		{"medium", libsamplerate.QualitySincMedium},
+		{"best", libsamplerate.QualitySincBest},
+	} {
+		b.Run(q.name, func(b *testing.B) {
+			b.SetBytes(int64(4 * len(in)))
+			for b.Loop() {
+				r, err := libsamplerate.New(audio.NewReader(in), 48*freq.KiloHertz, 44100*freq.Hertz, 2, q.quality)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := aio.ReadAll(r); err != nil {
+					b.Fatal(err)
+				}
+				r.Close()
+			}
+		})
+	}
+}
+
+// BenchmarkResamplerRead reads from the resampler in small chunks, like an audio callback does,
+// and should not allocate once the buffers of the resampler have grown.
+func BenchmarkResamplerRead(b *testing.B) {
+	osc := generator.NewOscillator(440*freq.Hertz, 48*freq.KiloHertz, generator.SineWaveform)
+	r, err := libsamplerate.New(osc, 48*freq.KiloHertz, 44100*freq.Hertz, 1, libsamplerate.QualitySincMedium)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer r.Close()
+
+	p := make([]float32, 256)
+	b.SetBytes(int64(4 * len(p)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := r.ReadSamples(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
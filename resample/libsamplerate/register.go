@@ -0,0 +1,57 @@
+//go:build cgo
+
+package libsamplerate
+
+import (
+	"fmt"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/resample"
+)
+
+// qualities maps the backend-neutral quality tiers to libsamplerate converter types.
+var qualities = map[resample.Quality]int{
+	resample.QualityLinear: QualityLinear,
+	resample.QualityLow:    QualitySincFastest,
+	resample.QualityMedium: QualitySincMedium,
+	resample.QualityHigh:   QualitySincBest,
+}
+
+// backend adapts a [Resampler] to [resample.Resampler], whose ratios are output rate / input rate.
+type backend struct {
+	*Resampler
+	ratio float64
+}
+
+func (b *backend) SetRatio(ratio float64) error {
+	// libsamplerate.Resampler.SetRatio takes input rate / output rate
+	if err := b.Resampler.SetRatio(1 / ratio); err != nil {
+		return err
+	}
+	b.ratio = ratio
+	return nil
+}
+
+func (b *backend) Ratio() float64 {
+	return b.ratio
+}
+
+func (b *backend) Close() error {
+	b.Resampler.Close()
+	return nil
+}
+
+func init() {
+	resample.Register("libsamplerate", func(r aio.SampleReader, inRate, outRate freq.Frequency, channels int, quality resample.Quality) (resample.Resampler, error) {
+		q, ok := qualities[quality]
+		if !ok {
+			return nil, fmt.Errorf("libsamplerate: invalid quality: %v", quality)
+		}
+		res, err := New(r, inRate, outRate, channels, q)
+		if err != nil {
+			return nil, err
+		}
+		return &backend{Resampler: res, ratio: outRate.Hertz() / inRate.Hertz()}, nil
+	})
+}
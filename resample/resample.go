@@ -0,0 +1,134 @@
+// Package resample converts the sample rate of audio streams.
+//
+// The conversion is done by one of several backends, selected by name in [New]:
+//
+//   - "go" is the pure-Go [Converter], which is always available.
+//   - "soxr" uses the SoX Resampler Library through cgo and is enabled by importing the resample/soxr package.
+//   - "libsamplerate" uses libsamplerate through cgo and is enabled by importing the resample/libsamplerate package.
+//
+// The cgo backends are faster, but need the C libraries installed. To compare them on a given machine, run
+//
+//	go test -bench . ./resample/...
+package resample
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/freq"
+)
+
+// Resampler is the interface implemented by all resampler backends.
+type Resampler interface {
+	// ReadSamples reads samples from the underlying reader, resamples them, and writes the output into p.
+	aio.SampleReader
+
+	// SetRatio changes the ratio of the output sample rate to the input sample rate during the conversion.
+	SetRatio(ratio float64) error
+
+	// Ratio returns the ratio of the output sample rate to the input sample rate.
+	Ratio() float64
+
+	// Close frees the resources of the resampler.
+	io.Closer
+}
+
+// Quality represents the quality tier of a [Resampler], which trades accuracy against speed.
+// The cgo backends map the tiers to their closest presets; the specifications below are those of the [Converter].
+//
+// The passband and stopband of the filtered tiers are given relative to the Nyquist frequency of the lower of
+// the two sample rates: frequencies up to the passband edge are kept within the ripple of the stopband attenuation,
+// and frequencies from the Nyquist frequency up, which would alias, are attenuated by at least the stopband attenuation.
+type Quality int
+
+const (
+	// QualityLinear interpolates linearly between input frames without filtering.
+	// It is the fastest tier, but it dulls high frequencies and lets them alias,
+	// so it suits previews and speech rather than music.
+	QualityLinear Quality = iota
+
+	// QualityLow keeps 80% of the passband and attenuates the stopband by 60 dB.
+	QualityLow
+
+	// QualityMedium keeps 90% of the passband and attenuates the stopband by 90 dB.
+	QualityMedium
+
+	// QualityHigh keeps 95% of the passband and attenuates the stopband by 120 dB.
+	QualityHigh
+)
+
+// String returns the name of the quality tier.
+func (q Quality) String() string {
+	switch q {
+	case QualityLinear:
+		return "linear"
+	case QualityLow:
+		return "low"
+	case QualityMedium:
+		return "medium"
+	case QualityHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("Quality(%d)", int(q))
+	}
+}
+
+// Backend creates a [Resampler] that converts the interleaved samples of r from inRate to outRate.
+type Backend func(r aio.SampleReader, inRate, outRate freq.Frequency, channels int, quality Quality) (Resampler, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Backend)
+)
+
+// preferredBackends are the backends [New] tries in order when no backend is given.
+var preferredBackends = []string{"soxr", "go"}
+
+// Register registers a resampler backend with the given name.
+func Register(name string, b Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if b == nil {
+		panic("resample: Register backend is nil")
+	}
+	if _, exists := backends[name]; exists {
+		panic("resample: Register called twice for backend " + name)
+	}
+	backends[name] = b
+}
+
+// Backends returns a sorted list of the names of the registered backends.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	return slices.Sorted(maps.Keys(backends))
+}
+
+// New creates a new [Resampler] using the named backend that converts the interleaved samples of r from inRate to outRate.
+// If backend is empty, soxr is used if it is registered, and the pure-Go [Converter] otherwise.
+func New(backend string, r aio.SampleReader, inRate, outRate freq.Frequency, channels int, quality Quality) (Resampler, error) {
+	backendsMu.RLock()
+	b, ok := backends[backend]
+	if backend == "" {
+		for _, name := range preferredBackends {
+			if b, ok = backends[name]; ok {
+				break
+			}
+		}
+	}
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("resample: unknown backend %q (forgotten import?)", backend)
+	}
+	return b(r, inRate, outRate, channels, quality)
+}
+
+func init() {
+	Register("go", func(r aio.SampleReader, inRate, outRate freq.Frequency, channels int, quality Quality) (Resampler, error) {
+		return NewConverter(r, inRate, outRate, channels, quality)
+	})
+}
@@ -0,0 +1,78 @@
+package resample_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/resample"
+)
+
+func TestBackends(t *testing.T) {
+	if !slices.Contains(resample.Backends(), "go") {
+		t.Errorf("expected the go backend to be registered, got %v", resample.Backends())
+	}
+}
+
+func TestNew(t *testing.T) {
+	// without the cgo backends imported, both pick the pure-Go converter
+	for _, backend := range []string{"", "go"} {
+		r, err := resample.New(backend, audio.NewReader(make([]float32, 2*44100)), 44100*freq.Hertz, 48*freq.KiloHertz, 2, resample.QualityMedium)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := r.(*resample.Converter); !ok {
+			t.Errorf("backend %q: expected a *Converter, got %T", backend, r)
+		}
+		if got := r.Ratio(); got != 48000.0/44100 {
+			t.Errorf("backend %q: expected a ratio of %v, got %v", backend, 48000.0/44100, got)
+		}
+
+		out, err := aio.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 2*48000 {
+			t.Errorf("backend %q: expected 48000 frames, got %d", backend, len(out)/2)
+		}
+		if err := r.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := resample.New("nope", audio.NewReader(nil), 44100*freq.Hertz, 48*freq.KiloHertz, 2, resample.QualityLow); err == nil {
+		t.Error("expected error for an unknown backend")
+	}
+}
+
+func TestSetRatio(t *testing.T) {
+	r, err := resample.New("go", audio.NewReader(make([]float32, 3000)), 48*freq.KiloHertz, 48*freq.KiloHertz, 1, resample.QualityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1000 input frames at the original rate, then the rest at half the rate
+	p := make([]float32, 1000)
+	if _, err := aio.ReadFull(r, p); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetRatio(0.5); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Ratio(); got != 0.5 {
+		t.Errorf("expected a ratio of 0.5, got %v", got)
+	}
+	out, err := aio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1000 {
+		t.Errorf("expected 1000 more frames, got %d", len(out))
+	}
+
+	if err := r.SetRatio(0); err == nil {
+		t.Error("expected error for invalid ratio")
+	}
+}
@@ -0,0 +1,70 @@
+//go:build cgo
+
+package soxr
+
+import (
+	"errors"
+	"time"
+)
+
+// Default settings of a [DriftCompensator].
+const (
+	DefaultDriftGain         = 0.01
+	DefaultDriftMaxDeviation = 0.005
+	DefaultDriftSlew         = 100 * time.Millisecond
+)
+
+// DriftCompensator keeps a buffer fed by a [Resampler] in variable-ratio mode at a target fill level
+// by nudging the resampling ratio, such as to compensate for the drift between the clocks of two audio devices.
+//
+// The buffer is assumed to be filled with the output of the resampler and drained at the rate of the other clock.
+// When it holds more than Target frames, the resampler produces too much output, so the ratio is raised to consume
+// more input per output frame, and the other way round. The changes are slewed, so they cannot be heard.
+type DriftCompensator struct {
+	// Nominal is the ratio with no drift.
+	Nominal float64
+
+	// Target is the fill level of the buffer in frames to aim for.
+	Target int
+
+	// Gain is the relative change of the ratio per relative deviation of the fill level from Target.
+	Gain float64
+
+	// MaxDeviation is the largest relative change of the ratio from Nominal.
+	MaxDeviation float64
+
+	// Slew is the time over which the ratio slides to a new value.
+	Slew time.Duration
+
+	r *Resampler
+}
+
+// NewDriftCompensator creates a new [DriftCompensator] for r with the default settings,
+// taking the current ratio of r as the nominal ratio.
+func NewDriftCompensator(r *Resampler, target int) (*DriftCompensator, error) {
+	if !r.isVR {
+		return nil, errors.New("soxr: resampler not in variable-ratio mode")
+	}
+	if target <= 0 {
+		return nil, errors.New("soxr: invalid target fill level")
+	}
+	return &DriftCompensator{
+		Nominal:      r.ratio,
+		Target:       target,
+		Gain:         DefaultDriftGain,
+		MaxDeviation: DefaultDriftMaxDeviation,
+		Slew:         DefaultDriftSlew,
+		r:            r,
+	}, nil
+}
+
+// Update adjusts the ratio of the resampler for the measured fill level of the buffer in frames.
+// It is meant to be called periodically, such as once per buffer period.
+func (d *DriftCompensator) Update(fill int) error {
+	if d.Target <= 0 {
+		return errors.New("soxr: invalid target fill level")
+	}
+	dev := d.Gain * float64(fill-d.Target) / float64(d.Target)
+	dev = max(-d.MaxDeviation, min(dev, d.MaxDeviation))
+	return d.r.SetRatioSlew(d.Nominal*(1+dev), d.Slew)
+}
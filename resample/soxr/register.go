@@ -0,0 +1,49 @@
+//go:build cgo
+
+package soxr
+
+import (
+	"fmt"
+
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/resample"
+)
+
+// qualities maps the backend-neutral quality tiers to SoX presets.
+var qualities = map[resample.Quality]uint32{
+	resample.QualityLinear: QualityQuick,
+	resample.QualityLow:    QualityLow,
+	resample.QualityMedium: QualityMedium,
+	resample.QualityHigh:   QualityHigh,
+}
+
+// backend adapts a [Resampler] to [resample.Resampler], whose ratios are output rate / input rate.
+type backend struct {
+	*Resampler
+}
+
+// SetRatio passes ratio to [Resampler.SetRatio] as input rate / output rate.
+// It fails, as the backend creates resamplers with fixed sample rates.
+func (b backend) SetRatio(ratio float64) error {
+	return b.Resampler.SetRatio(1 / ratio)
+}
+
+func (b backend) Close() error {
+	b.Resampler.Close()
+	return nil
+}
+
+func init() {
+	resample.Register("soxr", func(r aio.SampleReader, inRate, outRate freq.Frequency, channels int, quality resample.Quality) (resample.Resampler, error) {
+		q, ok := qualities[quality]
+		if !ok {
+			return nil, fmt.Errorf("soxr: invalid quality: %v", quality)
+		}
+		res, err := New(r, inRate, outRate, channels, q)
+		if err != nil {
+			return nil, err
+		}
+		return backend{res}, nil
+	})
+}
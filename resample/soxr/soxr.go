@@ -12,8 +12,10 @@ import (
 	"io"
 	"math"
 	"runtime"
+	"time"
 	"unsafe"
 
+	"github.com/MatusOllah/resona/afmt"
 	"github.com/MatusOllah/resona/aio"
 	"github.com/MatusOllah/resona/freq"
 )
@@ -49,8 +51,14 @@ type Resampler struct {
 	isVR     bool
 	inRate   freq.Frequency
 	outRate  freq.Frequency
+	srcRate  freq.Frequency // sample rate of r in variable-ratio mode, if r reports it
 	ratio    float64
 	channels int
+	eof      bool // the end of input has been signaled, so only queued output is left
+
+	// buffers reused across calls to ReadSamples, grown as needed
+	inBuf  []float32
+	outBuf []float32
 }
 
 func validateInput(r aio.SampleReader, inRate, outRate freq.Frequency, channels int) error {
@@ -147,6 +155,7 @@ func NewWithRatio(r aio.SampleReader, ratio float64, channels int, quality uint3
 		ratio:    ratio,
 		channels: channels,
 	}
+	resampler.setSourceRate(r)
 	runtime.SetFinalizer(resampler, (*Resampler).Close)
 
 	return resampler, nil
@@ -161,6 +170,62 @@ func (r *Resampler) Close() {
 	}
 }
 
+// Reset clears the internal state of the resampler and makes it read from r instead,
+// so that it can be reused for a new stream with the same configuration.
+func (r *Resampler) Reset(src aio.SampleReader) error {
+	if r.h == nil {
+		return errors.New("soxr: resampler closed")
+	}
+	if src == nil {
+		return errors.New("soxr: reader is nil")
+	}
+	if soxrErr := C.soxr_clear(r.h); soxrErr != nil {
+		return fmt.Errorf("soxr: %s", C.GoString(soxrErr))
+	}
+	if r.isVR {
+		// restore the ratio, in case clearing reset it
+		if soxrErr := C.soxr_set_io_ratio(r.h, C.double(r.ratio), 0); soxrErr != nil {
+			return fmt.Errorf("soxr: %s", C.GoString(soxrErr))
+		}
+	}
+	r.r = src
+	r.setSourceRate(src)
+	r.eof = false
+	return nil
+}
+
+// SetSource makes the resampler read from r instead, keeping its internal state,
+// such as to continue a stream from another reader without a gap.
+func (r *Resampler) SetSource(src aio.SampleReader) error {
+	if src == nil {
+		return errors.New("soxr: reader is nil")
+	}
+	r.r = src
+	r.setSourceRate(src)
+	return nil
+}
+
+// setSourceRate records the sample rate of src in variable-ratio mode, if it reports one.
+func (r *Resampler) setSourceRate(src aio.SampleReader) {
+	if f, ok := src.(afmt.Formatter); ok && r.isVR {
+		r.srcRate = f.Format().SampleRate
+	}
+}
+
+// Format returns the format of the output: the output sample rate and the number of channels.
+// In variable-ratio mode, the sample rates are not known, so the sample rate is zero.
+func (r *Resampler) Format() afmt.Format {
+	return afmt.Format{SampleRate: r.outRate, NumChannels: r.channels}
+}
+
+// Delay returns the number of output frames the resampler currently holds back, that is, the delay of its output.
+func (r *Resampler) Delay() float64 {
+	if r.h == nil {
+		return 0
+	}
+	return float64(C.soxr_delay(r.h))
+}
+
 // VariableRatioMode returns whether variable-ratio mode is enabled.
 func (r *Resampler) VariableRatioMode() bool {
 	return r.isVR
@@ -183,6 +248,31 @@ func (r *Resampler) SetRatio(ratio float64) error {
 	return nil
 }
 
+// SetRatioSlew is like [Resampler.SetRatio], but instead of jumping to the new ratio, which can be heard as a thump,
+// the ratio slides to it over the given time of output, such as for compensating clock drift.
+// The output sample rate is derived from the sample rate of the underlying reader,
+// so it must implement afmt.Formatter.
+func (r *Resampler) SetRatioSlew(ratio float64, over time.Duration) error {
+	if !r.isVR {
+		return errors.New("soxr: resampler not in variable-ratio mode")
+	}
+	if r.h == nil {
+		return errors.New("soxr: resampler closed")
+	}
+	if r.srcRate <= 0 {
+		return errors.New("soxr: unknown sample rate")
+	}
+
+	// the io ratio is input rate / output rate
+	slew := max(math.Round(over.Seconds()*r.srcRate.Hertz()/r.ratio), 0)
+	soxrErr := C.soxr_set_io_ratio(r.h, C.double(ratio), C.size_t(slew))
+	if soxrErr != nil {
+		return fmt.Errorf("soxr: %s", C.GoString(soxrErr))
+	}
+	r.ratio = ratio
+	return nil
+}
+
 // Ratio returns the resampling ratio.
 func (r *Resampler) Ratio() float64 {
 	if r.isVR {
@@ -194,6 +284,9 @@ func (r *Resampler) Ratio() float64 {
 
 // ReadSamples reads samples from the underlying reader, resamples them,
 // and writes the output into p. It returns the number of samples written.
+//
+// Once the underlying reader ends, ReadSamples returns the output still queued in the filter of the resampler
+// before returning io.EOF, so that the end of the stream is not cut off.
 func (r *Resampler) ReadSamples(p []float32) (int, error) {
 	if r.h == nil {
 		return 0, errors.New("soxr: resampler closed")
@@ -212,6 +305,10 @@ func (r *Resampler) ReadSamples(p []float32) (int, error) {
 		}
 	}
 
+	if r.eof {
+		return r.Flush(p)
+	}
+
 	ratio := r.Ratio()
 	if ratio <= 0 || math.IsNaN(ratio) || math.IsInf(ratio, 0) {
 		return 0, errors.New("soxr: invalid resampling ratio")
@@ -226,8 +323,9 @@ func (r *Resampler) ReadSamples(p []float32) (int, error) {
 	inFramesNeed := int(math.Ceil(float64(outFramesReq)/ratio)) + 16
 	inSamplesNeed := inFramesNeed * r.channels
 
-	in := make([]float32, inSamplesNeed)
-	out := make([]float32, outFramesReq*r.channels)
+	r.inBuf = growBuf(r.inBuf, inSamplesNeed)
+	r.outBuf = growBuf(r.outBuf, outFramesReq*r.channels)
+	in, out := r.inBuf, r.outBuf
 
 	nInSamples, err := r.r.ReadSamples(in)
 	if err != nil && !errors.Is(err, io.EOF) {
@@ -236,7 +334,11 @@ func (r *Resampler) ReadSamples(p []float32) (int, error) {
 	// keep only whole frames
 	nInSamples -= nInSamples % r.channels
 	if nInSamples == 0 {
-		return 0, io.EOF
+		if err == nil {
+			return 0, nil
+		}
+		// the input has ended, so return what is left in the filter
+		return r.Flush(p)
 	}
 	in = in[:nInSamples]
 	inFrames := nInSamples / r.channels
@@ -257,3 +359,43 @@ func (r *Resampler) ReadSamples(p []float32) (int, error) {
 	copy(p, out[:nOutSamples])
 	return nOutSamples, nil
 }
+
+// growBuf returns buf resized to n samples, reallocating it only if it is too small.
+func growBuf(buf []float32, n int) []float32 {
+	if cap(buf) < n {
+		return make([]float32, n)
+	}
+	return buf[:n]
+}
+
+// Flush signals the end of the input to the resampler, without reading from the underlying reader any more,
+// and writes the output still queued in its filter into p. It returns the number of samples written,
+// and io.EOF once all output has been returned.
+//
+// [Resampler.ReadSamples] flushes the resampler by itself when the underlying reader ends,
+// so Flush is only needed to end the stream early.
+func (r *Resampler) Flush(p []float32) (int, error) {
+	if r.h == nil {
+		return 0, errors.New("soxr: resampler closed")
+	}
+	p = p[:len(p)-len(p)%r.channels]
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// a NULL input tells libsoxr that the input has ended; it keeps returning queued output until there is none left
+	r.eof = true
+	var nOutFrames C.size_t
+	soxrErr := C.soxr_process(
+		r.h,
+		nil, 0, nil,
+		C.soxr_out_t(unsafe.Pointer(&p[0])), C.size_t(len(p)/r.channels), &nOutFrames,
+	)
+	if soxrErr != nil {
+		return 0, fmt.Errorf("soxr: %s", C.GoString(soxrErr))
+	}
+	if nOutFrames == 0 {
+		return 0, io.EOF
+	}
+	return int(nOutFrames) * r.channels, nil
+}
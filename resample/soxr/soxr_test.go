@@ -0,0 +1,296 @@
+package soxr_test
+
+import (
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MatusOllah/resona/afmt"
+	"github.com/MatusOllah/resona/aio"
+	"github.com/MatusOllah/resona/audio"
+	"github.com/MatusOllah/resona/freq"
+	"github.com/MatusOllah/resona/generator"
+	"github.com/MatusOllah/resona/resample/soxr"
+)
+
+func TestResamplerLength(t *testing.T) {
+	const (
+		numFrames   = 44100
+		numChannels = 2
+	)
+	in := make([]float32, numFrames*numChannels)
+	for i := range numFrames {
+		x := float32(0.5 * math.Sin(2*math.Pi*440*float64(i)/44100))
+		in[2*i], in[2*i+1] = x, x
+	}
+
+	r, err := soxr.New(audio.NewReader(in), 44100*freq.Hertz, 48*freq.KiloHertz, numChannels, soxr.QualityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	out, err := aio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := numFrames * 48000.0 / 44100
+	if got := float64(len(out) / numChannels); math.Abs(got-want) > 1 {
+		t.Errorf("expected %v frames, got %v", want, got)
+	}
+
+	// the tail of the sine must survive
+	if peak := max(math.Abs(float64(out[len(out)-200])), math.Abs(float64(out[len(out)-100]))); peak < 0.01 {
+		t.Errorf("expected the tail to be kept, got %v and %v", out[len(out)-200], out[len(out)-100])
+	}
+}
+
+func TestResamplerFlush(t *testing.T) {
+	r, err := soxr.New(audio.NewReader(make([]float32, 1<<20)), 44100*freq.Hertz, 48*freq.KiloHertz, 1, soxr.QualityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	p := make([]float32, 4410)
+	if _, err := aio.ReadFull(r, p); err != nil {
+		t.Fatal(err)
+	}
+
+	// ending the stream early drains the filter and stops reading
+	for range 1000 {
+		if _, err := r.Flush(p); err == io.EOF {
+			if _, err := r.ReadSamples(p); err != io.EOF {
+				t.Errorf("expected io.EOF after flushing, got %v", err)
+			}
+			return
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Error("expected the flush to end")
+}
+
+// sine returns numFrames frames of a mono sine with the frequency f at 44.1 kHz.
+func sine(f float64, numFrames int) []float32 {
+	p := make([]float32, numFrames)
+	for i := range p {
+		p[i] = float32(0.5 * math.Sin(2*math.Pi*f*float64(i)/44100))
+	}
+	return p
+}
+
+func TestResamplerReset(t *testing.T) {
+	r, err := soxr.New(audio.NewReader(sine(1000, 44100)), 44100*freq.Hertz, 48*freq.KiloHertz, 1, soxr.QualityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// stop in the middle of the first stream, with the sine still in the filter
+	if _, err := aio.ReadFull(r, make([]float32, 4800)); err != nil {
+		t.Fatal(err)
+	}
+	if d := r.Delay(); d <= 0 {
+		t.Errorf("expected a delay while the filter holds samples, got %v", d)
+	}
+
+	// a second stream of silence comes out silent
+	if err := r.Reset(audio.NewReader(make([]float32, 4410))); err != nil {
+		t.Fatal(err)
+	}
+	out, err := aio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) < 4790 {
+		t.Fatalf("expected about 4800 frames after Reset, got %d", len(out))
+	}
+	for i, x := range out {
+		if x != 0 {
+			t.Fatalf("frame %d: expected silence after Reset, got %v", i, x)
+		}
+	}
+
+	// and a third one matches a fresh resampler
+	in := sine(3000, 4410)
+	if err := r.Reset(audio.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := aio.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := soxr.New(audio.NewReader(in), 44100*freq.Hertz, 48*freq.KiloHertz, 1, soxr.QualityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+	want, err := aio.ReadAll(fresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d frames, got %d", len(want), len(got))
+	}
+	for i := range got {
+		if math.Abs(float64(got[i]-want[i])) > 1e-6 {
+			t.Fatalf("frame %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestResamplerFormat(t *testing.T) {
+	r, err := soxr.New(audio.NewReader(nil), 44100*freq.Hertz, 48*freq.KiloHertz, 2, soxr.QualityQuick)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got, want := r.Format(), (afmt.Format{SampleRate: 48 * freq.KiloHertz, NumChannels: 2}); got != want {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	vr, err := soxr.NewWithRatio(audio.NewReader(nil), 1.5, 2, soxr.QualityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vr.Close()
+	if got, want := vr.Format(), (afmt.Format{NumChannels: 2}); got != want {
+		t.Errorf("variable-ratio mode: expected %v, got %v", want, got)
+	}
+}
+
+// BenchmarkResampler matches the benchmark of the pure-Go resampler in the resample package.
+func BenchmarkResampler(b *testing.B) {
+	in := make([]float32, 2*48000)
+	for i := range in {
+		in[i] = float32(math.Sin(float64(i) / 10))
+	}
+	for _, q := range []struct {
+		name    string
+		quality uint32
+	}{
+		{"low", soxr.QualityLow},
+		{"medium", soxr.QualityMedium},
+		{"high", soxr.QualityHigh},
+	} {
+		b.Run(q.name, func(b *testing.B) {
+			b.SetBytes(int64(4 * len(in)))
+			for b.Loop() {
+				r, err := soxr.New(audio.NewReader(in), 48*freq.KiloHertz, 44100*freq.Hertz, 2, q.quality)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := aio.ReadAll(r); err != nil {
+					b.Fatal(err)
+				}
+				r.Close()
+			}
+		})
+	}
+}
+
+func TestResamplerSetRatioSlew(t *testing.T) {
+	const f = 1000
+	src := audio.NewReader(sine(f, 44100))
+	src.Fmt = afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 1}
+	r, err := soxr.NewWithRatio(src, 1, 1, soxr.QualityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	before := make([]float32, 22050)
+	if _, err := aio.ReadFull(r, before); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetRatioSlew(1.001, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	after := make([]float32, 11025)
+	if _, err := aio.ReadFull(r, after); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Ratio(); got != 1.001 {
+		t.Errorf("expected a ratio of 1.001, got %v", got)
+	}
+
+	// the steepest slope of the sine, with a little headroom for the changed ratio
+	maxStep := 1.05 * 2 * math.Pi * f / 44100 * 0.5
+	out := append(before[2048:], after...) // skip the start-up of the filter
+	for i := 1; i < len(out); i++ {
+		if d := math.Abs(float64(out[i] - out[i-1])); d > maxStep {
+			t.Fatalf("expected steps of at most %v, got %v at %d", maxStep, d, i)
+		}
+	}
+}
+
+func TestResamplerSetRatioSlewFixed(t *testing.T) {
+	r, err := soxr.New(audio.NewReader(sine(440, 4410)), 44100*freq.Hertz, 48*freq.KiloHertz, 1, soxr.QualityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if err := r.SetRatioSlew(1.001, 100*time.Millisecond); err == nil {
+		t.Error("expected an error in fixed-rate mode")
+	}
+	if _, err := soxr.NewDriftCompensator(r, 1024); err == nil {
+		t.Error("expected an error for a drift compensator in fixed-rate mode")
+	}
+}
+
+func TestDriftCompensator(t *testing.T) {
+	src := audio.NewReader(sine(440, 4410))
+	src.Fmt = afmt.Format{SampleRate: 44100 * freq.Hertz, NumChannels: 1}
+	r, err := soxr.NewWithRatio(src, 1, 1, soxr.QualityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	d, err := soxr.NewDriftCompensator(r, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		fill int
+		want float64
+	}{
+		{1000, 1},
+		{1100, 1.001},
+		{900, 0.999},
+		{1_000_000, 1.005}, // clamped to MaxDeviation
+		{0, 0.995},
+	} {
+		if err := d.Update(tt.fill); err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Ratio(); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("fill %d: expected a ratio of %v, got %v", tt.fill, tt.want, got)
+		}
+	}
+}
+
+// BenchmarkResamplerRead reads from the resampler in small chunks, like an audio callback does,
+// and should not allocate once the buffers of the resampler have grown.
+func BenchmarkResamplerRead(b *testing.B) {
+	osc := generator.NewOscillator(440*freq.Hertz, 48*freq.KiloHertz, generator.SineWaveform)
+	r, err := soxr.New(osc, 48*freq.KiloHertz, 44100*freq.Hertz, 1, soxr.QualityHigh)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer r.Close()
+
+	p := make([]float32, 256)
+	b.SetBytes(int64(4 * len(p)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := r.ReadSamples(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}